@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/assignment"
+)
+
+var errAgentHandoffRuleNotFound = errors.New("agent handoff rule not found")
+
+func (r *Resolver) AgentHandoffRule() AgentHandoffRuleResolver {
+	return &agentHandoffRuleResolver{r}
+}
+
+type agentHandoffRuleResolver struct{ *Resolver }
+
+func (r *agentHandoffRuleResolver) FromAgent(ctx context.Context, obj *model.AgentHandoffRule) (*model.AIAgent, error) {
+	return r.handoffAgent(ctx, obj.FromAgentID)
+}
+
+func (r *agentHandoffRuleResolver) ToAgent(ctx context.Context, obj *model.AgentHandoffRule) (*model.AIAgent, error) {
+	return r.handoffAgent(ctx, obj.ToAgentID)
+}
+
+func (r *Resolver) handoffAgent(ctx context.Context, id string) (*model.AIAgent, error) {
+	agent, err := r.DB.GetAIAgentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, errors.New("AI agent not found")
+	}
+	return agent, nil
+}
+
+func (r *Resolver) LeadAgentOwnership() LeadAgentOwnershipResolver {
+	return &leadAgentOwnershipResolver{r}
+}
+
+type leadAgentOwnershipResolver struct{ *Resolver }
+
+func (r *leadAgentOwnershipResolver) Agent(ctx context.Context, obj *model.LeadAgentOwnership) (*model.AIAgent, error) {
+	return r.handoffAgent(ctx, obj.AgentID)
+}
+
+func (r *leadAgentOwnershipResolver) HandoffRule(ctx context.Context, obj *model.LeadAgentOwnership) (*model.AgentHandoffRule, error) {
+	if obj.HandoffRuleID == nil {
+		return nil, nil
+	}
+	return r.DB.GetAgentHandoffRuleByID(ctx, *obj.HandoffRuleID)
+}
+
+func (r *leadResolver) AgentHistory(ctx context.Context, obj *model.Lead) ([]*model.LeadAgentOwnership, error) {
+	return r.DB.GetLeadAgentHistory(ctx, obj.ID)
+}
+
+func (r *queryResolver) AgentHandoffRules(ctx context.Context, fromAgentID *string) ([]*model.AgentHandoffRule, error) {
+	return r.DB.GetAgentHandoffRules(ctx, fromAgentID)
+}
+
+func (r *mutationResolver) CreateAgentHandoffRule(ctx context.Context, input model.AgentHandoffRuleInput) (*model.AgentHandoffRule, error) {
+	rule := agentHandoffRuleFromInput(input)
+	if err := assignment.ValidateHandoffRule(rule); err != nil {
+		return nil, err
+	}
+
+	rule.CreatedAt = time.Now()
+	created, err := r.DB.CreateAgentHandoffRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, errors.New("AI agent not found")
+	}
+	return created, nil
+}
+
+func (r *mutationResolver) UpdateAgentHandoffRule(ctx context.Context, id string, input model.AgentHandoffRuleInput) (*model.AgentHandoffRule, error) {
+	existing, err := r.DB.GetAgentHandoffRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errAgentHandoffRuleNotFound
+	}
+
+	rule := agentHandoffRuleFromInput(input)
+	rule.ID = id
+	if err := assignment.ValidateHandoffRule(rule); err != nil {
+		return nil, err
+	}
+
+	updated, err := r.DB.UpdateAgentHandoffRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("AI agent not found")
+	}
+	return updated, nil
+}
+
+func (r *mutationResolver) DeleteAgentHandoffRule(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteAgentHandoffRule(ctx, id)
+}
+
+func agentHandoffRuleFromInput(input model.AgentHandoffRuleInput) *model.AgentHandoffRule {
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+	return &model.AgentHandoffRule{
+		FromAgentID:    input.FromAgentID,
+		ToAgentID:      input.ToAgentID,
+		Status:         input.Status,
+		MinIntentScore: input.MinIntentScore,
+		Enabled:        enabled,
+	}
+}
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+func (r *aiAgentResolver) Persona(ctx context.Context, obj *model.AIAgent) (*model.AgentPersona, error) {
+	return r.DB.GetCurrentAgentPersona(ctx, obj.ID)
+}
+
+func (r *aiAgentResolver) PersonaHistory(ctx context.Context, obj *model.AIAgent) ([]*model.AgentPersona, error) {
+	return r.DB.GetAgentPersonas(ctx, obj.ID)
+}
+
+func (r *interactionResolver) Persona(ctx context.Context, obj *model.Interaction) (*model.AgentPersona, error) {
+	return r.DB.GetInteractionPersona(ctx, obj.ID)
+}
+
+func (r *Resolver) AgentPersona() AgentPersonaResolver {
+	return &agentPersonaResolver{r}
+}
+
+type agentPersonaResolver struct{ *Resolver }
+
+func (r *agentPersonaResolver) CreatedBy(ctx context.Context, obj *model.AgentPersona) (*model.User, error) {
+	if obj.CreatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.CreatedByID)
+}
+
+// UpdateAgentPersona saves a new version of the agent's persona. Fields left
+// out keep their current value; an empty string or list clears them.
+func (r *mutationResolver) UpdateAgentPersona(ctx context.Context, agentID string, input model.AgentPersonaInput) (*model.AgentPersona, error) {
+	current, err := r.DB.GetCurrentAgentPersona(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	persona := &model.AgentPersona{
+		AgentID:     agentID,
+		Dos:         []string{},
+		Donts:       []string{},
+		CreatedByID: currentUserID(ctx),
+		CreatedAt:   time.Now(),
+	}
+	if current != nil {
+		persona.Tone, persona.Language, persona.Signature = current.Tone, current.Language, current.Signature
+		persona.Dos, persona.Donts = current.Dos, current.Donts
+	}
+
+	if input.Tone != nil {
+		persona.Tone = trimmedOrNil(*input.Tone)
+	}
+	if input.Language != nil {
+		persona.Language = trimmedOrNil(*input.Language)
+	}
+	if input.Signature != nil {
+		persona.Signature = trimmedOrNil(*input.Signature)
+	}
+	if input.Dos != nil {
+		persona.Dos = personaRules(input.Dos)
+	}
+	if input.Donts != nil {
+		persona.Donts = personaRules(input.Donts)
+	}
+
+	created, err := r.DB.CreateAgentPersona(ctx, persona)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, errors.New("AI agent not found")
+	}
+	return created, nil
+}
+
+func trimmedOrNil(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func personaRules(rules []string) []string {
+	kept := []string{}
+	for _, rule := range rules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			kept = append(kept, rule)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"salesagency/graph/model"
+	"salesagency/internal/events"
+)
+
+func (r *aiAgentResolver) Runs(ctx context.Context, obj *model.AIAgent, limit *int, offset *int) ([]*model.AgentRun, error) {
+	return r.DB.GetAgentRunsByAIAgentID(ctx, obj.ID, limit, offset)
+}
+
+func (r *agentRunResolver) Logs(ctx context.Context, obj *model.AgentRun, after *string, limit *int) ([]*model.AgentRunLog, error) {
+	var afterID int64
+	if after != nil {
+		var err error
+		if afterID, err = strconv.ParseInt(*after, 10, 64); err != nil {
+			return nil, errors.New("invalid log cursor")
+		}
+	}
+	return r.DB.GetAgentRunLogs(ctx, obj.ID, afterID, limit)
+}
+
+func (r *queryResolver) AgentRun(ctx context.Context, id string) (*model.AgentRun, error) {
+	return r.DB.GetAgentRunByID(ctx, id)
+}
+
+// AgentRunLogs replays the run's log so far and then streams new lines as the
+// run writes them.
+func (r *subscriptionResolver) AgentRunLogs(ctx context.Context, runID string) (<-chan *model.AgentRunLog, error) {
+	run, err := r.DB.GetAgentRunByID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, errors.New("agent run not found")
+	}
+
+	// Subscribe before reading the backlog so no line falls in between.
+	source := r.Events.Subscribe(ctx, events.TopicAgentRunLog)
+	backlog, err := r.DB.GetAgentRunLogs(ctx, runID, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *model.AgentRunLog, 1)
+
+	go func() {
+		defer close(out)
+
+		var lastID int64
+		for _, entry := range backlog {
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+			lastID, _ = strconv.ParseInt(entry.ID, 10, 64)
+		}
+
+		for event := range source {
+			entry, ok := event.Payload.(*model.AgentRunLog)
+			if !ok || entry.RunID != runID {
+				continue
+			}
+			if id, _ := strconv.ParseInt(entry.ID, 10, 64); id <= lastID {
+				continue
+			}
+
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
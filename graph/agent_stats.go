@@ -0,0 +1,206 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/dataloader"
+)
+
+// maxStatsPoints bounds the series a single stats query returns.
+const maxStatsPoints = 400
+
+// Stats returns the agent's lifetime totals, or with a range its totals over
+// the range's UTC days broken into periods and compared with the range of the
+// same length just before it.
+func (r *aiAgentResolver) Stats(ctx context.Context, obj *model.AIAgent, period model.StatsPeriod, dateRange *model.DateRangeInput) (*model.AgentStats, error) {
+	if dateRange == nil {
+		loaders, err := dataloader.For(ctx)
+		if err != nil {
+			return nil, err
+		}
+		total, err := loaders.StatsByAgentID.Load(ctx, obj.ID)
+		if err != nil {
+			return nil, err
+		}
+		stats := newAgentStats(obj.ID, period, total)
+		stats.ID = obj.ID
+		return stats, nil
+	}
+
+	from, to := statsDay(dateRange.From), statsDay(dateRange.To)
+	if dateRange.To.After(to) {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return nil, errors.New("stats range must end after it starts")
+	}
+
+	buckets := statsBuckets(period, from, to)
+	if len(buckets) > maxStatsPoints {
+		return nil, fmt.Errorf("stats range has more than %d periods", maxStatsPoints)
+	}
+
+	previousFrom := from.Add(-to.Sub(from))
+	days, err := r.DB.GetAgentDayStats(ctx, obj.ID, previousFrom, to)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := &database.AgentDayStats{AgentID: obj.ID}
+	total := &database.AgentDayStats{AgentID: obj.ID}
+	for _, day := range days {
+		if day.Day.Before(from) {
+			addDayStats(previous, day)
+		} else {
+			addDayStats(total, day)
+		}
+	}
+
+	stats := newAgentStats(obj.ID, period, total)
+	stats.ID = fmt.Sprintf("%s:%s:%s:%s", obj.ID, period, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	stats.Series = make([]*model.AgentStatsPoint, 0, len(buckets))
+	for _, bucket := range buckets {
+		sum := &database.AgentDayStats{AgentID: obj.ID}
+		for _, day := range days {
+			if !day.Day.Before(bucket[0]) && day.Day.Before(bucket[1]) {
+				addDayStats(sum, day)
+			}
+		}
+		stats.Series = append(stats.Series, agentStatsPoint(bucket[0], bucket[1], sum))
+	}
+	stats.Previous = agentStatsPoint(previousFrom, from, previous)
+	stats.Change = agentStatsChange(stats.Previous, agentStatsPoint(from, to, total))
+
+	return stats, nil
+}
+
+func (r *agentStatsResolver) Agent(ctx context.Context, obj *model.AgentStats) (*model.AIAgent, error) {
+	agent, err := r.DB.GetAIAgentByID(ctx, obj.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, errors.New("AI agent not found")
+	}
+	return agent, nil
+}
+
+func newAgentStats(agentID string, period model.StatsPeriod, total *database.AgentDayStats) *model.AgentStats {
+	point := agentStatsPoint(time.Time{}, time.Time{}, total)
+	createdAt := total.ComputedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return &model.AgentStats{
+		AgentID:           agentID,
+		LeadsEngaged:      point.LeadsEngaged,
+		MessagesDelivered: point.MessagesDelivered,
+		Replies:           point.Replies,
+		Conversions:       point.Conversions,
+		ResponseRate:      point.ResponseRate,
+		ConversionRate:    point.ConversionRate,
+		AvgResponseTime:   point.AvgResponseTime,
+		Period:            period,
+		Series:            []*model.AgentStatsPoint{},
+		CreatedAt:         createdAt,
+	}
+}
+
+func agentStatsPoint(start, end time.Time, sum *database.AgentDayStats) *model.AgentStatsPoint {
+	return &model.AgentStatsPoint{
+		Start:             start,
+		End:               end,
+		LeadsEngaged:      sum.LeadsEngaged,
+		MessagesDelivered: sum.MessagesDelivered,
+		Replies:           sum.Replies,
+		Conversions:       sum.Conversions,
+		ResponseRate:      statsRate(sum.Replies, sum.MessagesDelivered),
+		ConversionRate:    statsRate(sum.Conversions, sum.LeadsEngaged),
+		AvgResponseTime:   statsAverage(sum.ResponseSeconds, sum.Replies),
+	}
+}
+
+func agentStatsChange(previous, current *model.AgentStatsPoint) *model.AgentStatsChange {
+	return &model.AgentStatsChange{
+		LeadsEngaged:      statsChange(float64(previous.LeadsEngaged), float64(current.LeadsEngaged)),
+		MessagesDelivered: statsChange(float64(previous.MessagesDelivered), float64(current.MessagesDelivered)),
+		Replies:           statsChange(float64(previous.Replies), float64(current.Replies)),
+		Conversions:       statsChange(float64(previous.Conversions), float64(current.Conversions)),
+		ResponseRate:      statsChange(previous.ResponseRate, current.ResponseRate),
+		ConversionRate:    statsChange(previous.ConversionRate, current.ConversionRate),
+		AvgResponseTime:   statsChange(previous.AvgResponseTime, current.AvgResponseTime),
+	}
+}
+
+func addDayStats(sum, day *database.AgentDayStats) {
+	sum.LeadsEngaged += day.LeadsEngaged
+	sum.MessagesDelivered += day.MessagesDelivered
+	sum.Replies += day.Replies
+	sum.Conversions += day.Conversions
+	sum.ResponseSeconds += day.ResponseSeconds
+	if day.ComputedAt.After(sum.ComputedAt) {
+		sum.ComputedAt = day.ComputedAt
+	}
+}
+
+// statsBuckets splits [from, to) into UTC days, Monday weeks or calendar
+// months, clipping the first and last to the range.
+func statsBuckets(period model.StatsPeriod, from, to time.Time) [][2]time.Time {
+	var buckets [][2]time.Time
+	for start := from; start.Before(to); {
+		var end time.Time
+		switch period {
+		case model.StatsPeriodWeek:
+			weekday := (int(start.Weekday()) + 6) % 7
+			end = start.AddDate(0, 0, 7-weekday)
+		case model.StatsPeriodMonth:
+			end = time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		default:
+			end = start.AddDate(0, 0, 1)
+		}
+		if end.After(to) {
+			end = to
+		}
+		buckets = append(buckets, [2]time.Time{start, end})
+		if len(buckets) > maxStatsPoints {
+			break
+		}
+		start = end
+	}
+	return buckets
+}
+
+// statsRate is the share of total that count makes up, capped at 1 since
+// replies and conversions can land in a later period than what led to them.
+func statsRate(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return min(float64(count)/float64(total), 1)
+}
+
+func statsAverage(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// statsChange is the change from previous relative to it, or nil when
+// previous is zero.
+func statsChange(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := (current - previous) / previous
+	return &change
+}
+
+func statsDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
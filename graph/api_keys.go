@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+)
+
+func (r *queryResolver) APIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	return r.DB.GetAPIKeys(ctx)
+}
+
+func (r *mutationResolver) CreateAPIKey(ctx context.Context, name string, role model.UserRole, integration *model.IntegrationKind) (*model.CreateAPIKeyPayload, error) {
+	// API keys are not tied to a user, so there is no client list to scope
+	// them to.
+	if auth.Role(role) == auth.RoleClient || auth.Role(role) == auth.RoleClientViewer {
+		return nil, errors.New("API keys cannot use client roles")
+	}
+
+	key, keyHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := r.DB.CreateAPIKey(ctx, &model.APIKey{
+		Name:        name,
+		Role:        role,
+		Integration: integration,
+		CreatedAt:   time.Now(),
+	}, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CreateAPIKeyPayload{APIKey: apiKey, Key: key}, nil
+}
+
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (bool, error) {
+	return r.DB.RevokeAPIKey(ctx, id)
+}
@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"salesagency/graph/model"
+	"salesagency/internal/events"
+)
+
+func (r *aiAgentResolver) MaxActiveLeads(ctx context.Context, obj *model.AIAgent) (*int, error) {
+	return r.DB.GetAIAgentMaxActiveLeads(ctx, obj.ID)
+}
+
+func (r *aiAgentResolver) ActiveLeadCount(ctx context.Context, obj *model.AIAgent) (int, error) {
+	return r.DB.CountActiveLeadsByAIAgentID(ctx, obj.ID)
+}
+
+func (r *mutationResolver) AutoAssignLead(ctx context.Context, leadID string, aiAgentIds []string, balancing *model.LoadBalancing) (*model.Lead, error) {
+	lead, err := r.Assignment.AutoAssign(ctx, leadID, aiAgentIds, loadBalancing(balancing))
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	r.Events.Publish(events.TopicLeadUpdated, lead)
+
+	return lead, nil
+}
+
+func (r *mutationResolver) SetAIAgentCapacity(ctx context.Context, id string, maxActiveLeads *int) (*model.AIAgent, error) {
+	if maxActiveLeads != nil && *maxActiveLeads <= 0 {
+		return nil, errors.New("maxActiveLeads must be positive")
+	}
+
+	ok, err := r.DB.SetAIAgentMaxActiveLeads(ctx, id, maxActiveLeads)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("ai agent not found")
+	}
+
+	return r.DB.GetAIAgentByID(ctx, id)
+}
+
+func (r *mutationResolver) RebalanceAgentLoads(ctx context.Context, aiAgentID *string, balancing *model.LoadBalancing) (*model.RebalanceResult, error) {
+	return r.Assignment.Rebalance(ctx, aiAgentID, loadBalancing(balancing))
+}
+
+func loadBalancing(balancing *model.LoadBalancing) model.LoadBalancing {
+	if balancing == nil {
+		return model.LoadBalancingLeastLoaded
+	}
+	return *balancing
+}
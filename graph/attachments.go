@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+	"salesagency/internal/storage"
+)
+
+// attachmentURLTTL is how long signed attachment download links stay valid.
+const attachmentURLTTL = 15 * time.Minute
+
+func (r *leadResolver) Attachments(ctx context.Context, obj *model.Lead) ([]*model.Attachment, error) {
+	return r.DB.GetAttachmentsByLeadID(ctx, obj.ID)
+}
+
+func (r *Resolver) Interaction() InteractionResolver {
+	return &interactionResolver{r}
+}
+
+type interactionResolver struct{ *Resolver }
+
+func (r *interactionResolver) Attachments(ctx context.Context, obj *model.Interaction) ([]*model.Attachment, error) {
+	return r.DB.GetAttachmentsByInteractionID(ctx, obj.ID)
+}
+
+func (r *Resolver) Attachment() AttachmentResolver {
+	return &attachmentResolver{r}
+}
+
+type attachmentResolver struct{ *Resolver }
+
+func (r *attachmentResolver) URL(ctx context.Context, obj *model.Attachment) (string, error) {
+	if r.Storage == nil {
+		return "", storage.ErrNotConfigured
+	}
+	return r.Storage.DownloadURL(obj.StorageKey, obj.Filename, attachmentURLTTL)
+}
+
+func (r *attachmentResolver) UploadedBy(ctx context.Context, obj *model.Attachment) (*model.User, error) {
+	if obj.UploadedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.UploadedByID)
+}
+
+func (r *mutationResolver) UploadLeadAttachment(ctx context.Context, leadID string, file graphql.Upload) (*model.Attachment, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	return r.storeAttachment(ctx, file, &model.Attachment{LeadID: &lead.ID})
+}
+
+func (r *mutationResolver) UploadInteractionAttachment(ctx context.Context, interactionID string, file graphql.Upload) (*model.Attachment, error) {
+	interaction, err := r.DB.GetInteractionByID(ctx, interactionID)
+	if err != nil {
+		return nil, err
+	}
+	if interaction == nil {
+		return nil, errors.New("interaction not found")
+	}
+
+	attachment, err := r.storeAttachment(ctx, file, &model.Attachment{InteractionID: &interaction.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// A recording uploaded to a call replaces whatever was transcribed
+	// before. The upload stands even if queueing fails.
+	if interaction.Type == model.InteractionTypeCall && isAudio(attachment.ContentType) {
+		if _, err := r.DB.QueueTranscript(ctx, interaction.ID, &attachment.ID); err != nil {
+			logging.FromContext(ctx).Error("Failed to queue call transcript", "interaction_id", interaction.ID, "error", err)
+		}
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment removes the attachment and its stored file. A file that
+// cannot be deleted from storage is logged and left behind.
+func (r *mutationResolver) DeleteAttachment(ctx context.Context, id string) (bool, error) {
+	attachment, err := r.DB.DeleteAttachment(ctx, id)
+	if err != nil || attachment == nil {
+		return false, err
+	}
+
+	r.deleteStoredFiles(ctx, attachment.StorageKey)
+
+	return true, nil
+}
+
+// storeAttachment validates the upload against the configured limits, saves
+// it under a random key and records it.
+func (r *mutationResolver) storeAttachment(ctx context.Context, file graphql.Upload, attachment *model.Attachment) (*model.Attachment, error) {
+	if r.Storage == nil {
+		return nil, storage.ErrNotConfigured
+	}
+
+	contentType, err := r.UploadLimits.Check(file.Filename, file.ContentType, file.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := attachmentKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Storage.Put(ctx, key, file.File, file.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	filename := path.Base(strings.ReplaceAll(file.Filename, `\`, "/"))
+	if filename == "." || filename == "/" {
+		filename = "attachment"
+	}
+
+	attachment.StorageKey = key
+	attachment.Filename = filename
+	attachment.ContentType = contentType
+	attachment.Size = int(file.Size)
+	attachment.UploadedByID = currentUserID(ctx)
+	attachment.CreatedAt = time.Now()
+
+	created, err := r.DB.CreateAttachment(ctx, attachment)
+	if err != nil {
+		r.deleteStoredFiles(ctx, key)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *Resolver) deleteStoredFiles(ctx context.Context, keys ...string) {
+	if r.Storage == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := r.Storage.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Error("Failed to delete stored file", "key", key, "error", err)
+		}
+	}
+}
+
+func attachmentKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating attachment key: %w", err)
+	}
+	return "attachments/" + hex.EncodeToString(b), nil
+}
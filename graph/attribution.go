@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/capture"
+	"salesagency/internal/reports"
+)
+
+func (r *leadResolver) Attribution(ctx context.Context, obj *model.Lead) (*model.LeadAttribution, error) {
+	return r.DB.GetLeadAttribution(ctx, obj.ID)
+}
+
+func (r *Resolver) LeadAttribution() LeadAttributionResolver {
+	return &leadAttributionResolver{r}
+}
+
+type leadAttributionResolver struct{ *Resolver }
+
+func (r *leadAttributionResolver) Campaign(ctx context.Context, obj *model.LeadAttribution) (*model.Campaign, error) {
+	if obj.CampaignID == nil {
+		return nil, nil
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, *obj.CampaignID)
+}
+
+func (r *queryResolver) AttributionReport(ctx context.Context, period string) (*model.AttributionReport, error) {
+	p, err := reports.ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := r.DB.GetAttributionSources(ctx, p.Start, p.End)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.AttributionReport{Period: p.Label, Sources: sources}
+	for _, source := range sources {
+		report.TotalLeads += source.Leads
+	}
+	return report, nil
+}
+
+func (r *queryResolver) LeadCaptureKey(ctx context.Context) (*string, error) {
+	return r.DB.GetCaptureKey(ctx)
+}
+
+func (r *mutationResolver) RotateLeadCaptureKey(ctx context.Context) (string, error) {
+	key, err := capture.GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	if err := r.DB.SetCaptureKey(ctx, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/events"
+	"salesagency/internal/logging"
+	"salesagency/internal/sendwindow"
+)
+
+// maxBulkLeads caps how many leads one bulk mutation changes.
+const maxBulkLeads = 10000
+
+// publishBatchSize is how many changed leads are read at once to publish
+// their updates.
+const publishBatchSize = 500
+
+func (r *mutationResolver) BulkUpdateLeads(ctx context.Context, ids []string, patch model.LeadPatchInput) ([]*model.BulkLeadResult, error) {
+	if err := checkBulkSize(ids); err != nil {
+		return nil, err
+	}
+	if patch.Timezone != nil {
+		if err := sendwindow.CheckTimezone(*patch.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	changed, err := r.Store.Leads.BulkUpdateLeads(ctx, ids, &patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.bulkResults(ctx, ids, changed, nil), nil
+}
+
+func (r *mutationResolver) BulkTagLeads(ctx context.Context, ids []string, addTags []string, removeTags []string) ([]*model.BulkLeadResult, error) {
+	if err := checkBulkSize(ids); err != nil {
+		return nil, err
+	}
+	if len(addTags) == 0 && len(removeTags) == 0 {
+		return nil, errors.New("addTags or removeTags is required")
+	}
+
+	changed, err := r.Store.Leads.BulkTagLeads(ctx, ids, addTags, removeTags)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.bulkResults(ctx, ids, changed, nil), nil
+}
+
+func (r *mutationResolver) BulkChangeStatus(ctx context.Context, ids []string, status model.LeadStatus, reason *string) ([]*model.BulkLeadResult, error) {
+	if err := checkBulkSize(ids); err != nil {
+		return nil, err
+	}
+
+	moved, failures, err := r.Pipeline.ChangeStatuses(ctx, ids, status, reason, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.bulkResults(ctx, ids, moved, failures), nil
+}
+
+func checkBulkSize(ids []string) error {
+	if len(ids) > maxBulkLeads {
+		return fmt.Errorf("at most %d leads can be changed at once", maxBulkLeads)
+	}
+	return nil
+}
+
+// bulkResults reports, in the order of ids, which leads were changed and
+// why the others were not, and publishes the changed leads' updates.
+func (r *mutationResolver) bulkResults(ctx context.Context, ids, changed []string, failures map[string]error) []*model.BulkLeadResult {
+	succeeded := make(map[string]bool, len(changed))
+	for _, id := range changed {
+		succeeded[id] = true
+	}
+
+	results := make([]*model.BulkLeadResult, len(ids))
+	for i, id := range ids {
+		result := &model.BulkLeadResult{LeadID: id, Success: succeeded[id]}
+		if !result.Success {
+			message := "lead not found"
+			if err, ok := failures[id]; ok {
+				message = err.Error()
+			}
+			result.Error = &message
+		}
+		results[i] = result
+	}
+
+	for start := 0; start < len(changed); start += publishBatchSize {
+		end := min(start+publishBatchSize, len(changed))
+		leads, err := r.Store.Leads.GetLeadsByIDs(ctx, changed[start:end])
+		if err != nil {
+			// The changes are committed; only their updates go unpublished.
+			logging.FromContext(ctx).Warn("reading bulk-changed leads failed", "error", err)
+			break
+		}
+		for _, lead := range leads {
+			r.Events.Publish(events.TopicLeadUpdated, lead)
+		}
+	}
+
+	return results
+}
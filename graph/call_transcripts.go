@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+func (r *interactionResolver) Transcript(ctx context.Context, obj *model.Interaction) (*model.CallTranscript, error) {
+	if obj.Type != model.InteractionTypeCall {
+		return nil, nil
+	}
+	return r.DB.GetCallTranscript(ctx, obj.ID)
+}
+
+// TranscribeCall (re)transcribes the call's latest audio attachment or, when
+// it has none, the recording the call provider reported.
+func (r *mutationResolver) TranscribeCall(ctx context.Context, interactionID string) (*model.CallTranscript, error) {
+	interaction, err := r.DB.GetInteractionByID(ctx, interactionID)
+	if err != nil {
+		return nil, err
+	}
+	if interaction == nil {
+		return nil, errors.New("interaction not found")
+	}
+	if interaction.Type != model.InteractionTypeCall {
+		return nil, errors.New("only calls can be transcribed")
+	}
+
+	attachments, err := r.DB.GetAttachmentsByInteractionID(ctx, interaction.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(attachments) - 1; i >= 0; i-- {
+		if isAudio(attachments[i].ContentType) {
+			return r.DB.QueueTranscript(ctx, interaction.ID, &attachments[i].ID)
+		}
+	}
+
+	call, err := r.DB.GetCallByInteractionID(ctx, interaction.ID)
+	if err != nil {
+		return nil, err
+	}
+	if call == nil || call.RecordingURL == nil {
+		return nil, errors.New("call has no recording")
+	}
+
+	return r.DB.QueueTranscript(ctx, interaction.ID, nil)
+}
+
+func isAudio(contentType string) bool {
+	return strings.HasPrefix(contentType, "audio/")
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/calls"
+)
+
+func (r *interactionResolver) Call(ctx context.Context, obj *model.Interaction) (*model.Call, error) {
+	if obj.Type != model.InteractionTypeCall {
+		return nil, nil
+	}
+	return r.DB.GetCallByInteractionID(ctx, obj.ID)
+}
+
+func (r *Resolver) AgentStats() AgentStatsResolver {
+	return &agentStatsResolver{r}
+}
+
+type agentStatsResolver struct{ *Resolver }
+
+func (r *agentStatsResolver) CallsMade(ctx context.Context, obj *model.AgentStats) (int, error) {
+	stats, err := r.DB.GetAgentCallStats(ctx, obj.AgentID)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Made, nil
+}
+
+func (r *agentStatsResolver) ConnectRate(ctx context.Context, obj *model.AgentStats) (float64, error) {
+	stats, err := r.DB.GetAgentCallStats(ctx, obj.AgentID)
+	if err != nil || stats.Made == 0 {
+		return 0, err
+	}
+	return float64(stats.Connected) / float64(stats.Made), nil
+}
+
+func (r *mutationResolver) LogCall(ctx context.Context, leadID string, outcome model.CallOutcome, durationSeconds *int, recordingURL *string, notes *string, aiAgentID *string) (*model.Interaction, error) {
+	return r.Calls.LogCall(ctx, &calls.Log{
+		LeadID:          leadID,
+		AIAgentID:       aiAgentID,
+		Outcome:         outcome,
+		DurationSeconds: durationSeconds,
+		RecordingURL:    recordingURL,
+		Notes:           notes,
+	})
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/scheduler"
+)
+
+func (r *queryResolver) CampaignCalendar(ctx context.Context, month string, timezone *string) (*model.CampaignCalendar, error) {
+	loc := time.UTC
+	if timezone != nil && *timezone != "" && *timezone != "Local" {
+		var err error
+		if loc, err = time.LoadLocation(*timezone); err != nil {
+			return nil, errors.New("timezone must be an IANA time zone such as Europe/Berlin")
+		}
+	}
+
+	from, err := time.ParseInLocation("2006-01", month, loc)
+	if err != nil {
+		return nil, errors.New("month must be given as YYYY-MM")
+	}
+	to := from.AddDate(0, 1, 0)
+
+	events, err := r.DB.GetCalendarEvents(ctx, from, to, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := r.DB.GetAgentScheduleTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err = addScheduledRuns(events, schedules, from, to, time.Now(), loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CampaignCalendar{
+		Month:    month,
+		Timezone: loc.String(),
+		From:     from,
+		To:       to,
+		Events:   events,
+	}, nil
+}
+
+// addScheduledRuns counts the runs schedules will queue in [from, to) into
+// each agent's AGENT_RUN event for the day, adding events for days without
+// one. A schedule queues its next run at nextRunAt and, as the scheduler
+// skips runs it missed, later ones after now at the earliest.
+func addScheduledRuns(events []*model.CalendarEvent, schedules []*database.AgentScheduleTime, from, to, now time.Time, loc *time.Location) ([]*model.CalendarEvent, error) {
+	byID := make(map[string]*model.CalendarEvent)
+	for _, event := range events {
+		if event.Kind == model.CalendarEventKindAgentRun {
+			byID[event.ID] = event
+		}
+	}
+
+	for _, schedule := range schedules {
+		agentID := schedule.AgentID
+		for at := schedule.NextRunAt; at.Before(to); {
+			if !at.Before(from) {
+				id := agentID + ":" + at.In(loc).Format(time.DateOnly)
+				event, ok := byID[id]
+				if !ok {
+					event = &model.CalendarEvent{
+						ID:        id,
+						Kind:      model.CalendarEventKindAgentRun,
+						Title:     schedule.AgentName,
+						Start:     at,
+						AIAgentID: &agentID,
+					}
+					byID[id] = event
+					events = append(events, event)
+				}
+				addCalendarRun(event, at)
+			}
+
+			after := at
+			if after.Before(now) {
+				after = now
+			}
+			// Jump straight to the month for schedules that reach it later.
+			if after.Before(from) {
+				after = from.Add(-time.Second)
+			}
+			next, err := scheduler.NextRun(schedule.Cron, after)
+			if err != nil {
+				return nil, err
+			}
+			if next.IsZero() {
+				// The expression never matches again.
+				break
+			}
+			at = next
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Start.Equal(events[j].Start) {
+			return events[i].Start.Before(events[j].Start)
+		}
+		if events[i].Kind != events[j].Kind {
+			return events[i].Kind < events[j].Kind
+		}
+		return events[i].ID < events[j].ID
+	})
+	return events, nil
+}
+
+// addCalendarRun counts a run at at into event, widening its span to it.
+func addCalendarRun(event *model.CalendarEvent, at time.Time) {
+	event.Count++
+	if at.Before(event.Start) {
+		event.Start = at
+	}
+	if event.End == nil || at.After(*event.End) {
+		end := at
+		event.End = &end
+	}
+}
+
+func (r *Resolver) CalendarEvent() CalendarEventResolver {
+	return &calendarEventResolver{r}
+}
+
+type calendarEventResolver struct{ *Resolver }
+
+func (r *calendarEventResolver) Campaign(ctx context.Context, obj *model.CalendarEvent) (*model.Campaign, error) {
+	if obj.CampaignID == nil {
+		return nil, nil
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, *obj.CampaignID)
+}
+
+func (r *calendarEventResolver) Sequence(ctx context.Context, obj *model.CalendarEvent) (*model.Sequence, error) {
+	if obj.SequenceID == nil {
+		return nil, nil
+	}
+	return r.DB.GetSequenceByID(ctx, *obj.SequenceID)
+}
+
+func (r *calendarEventResolver) AIAgent(ctx context.Context, obj *model.CalendarEvent) (*model.AIAgent, error) {
+	if obj.AIAgentID == nil {
+		return nil, nil
+	}
+	return r.DB.GetAIAgentByID(ctx, *obj.AIAgentID)
+}
+
+func (r *calendarEventResolver) Lead(ctx context.Context, obj *model.CalendarEvent) (*model.Lead, error) {
+	if obj.LeadID == nil {
+		return nil, nil
+	}
+	return r.Store.Leads.GetLeadByID(ctx, *obj.LeadID)
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+)
+
+func (r *campaignResolver) Goals(ctx context.Context, obj *model.Campaign) ([]*model.CampaignGoal, error) {
+	return r.DB.GetCampaignGoals(ctx, obj.ID)
+}
+
+func (r *campaignResolver) GoalProgress(ctx context.Context, obj *model.Campaign) ([]*model.CampaignGoalProgress, error) {
+	goals, err := r.DB.GetCampaignGoals(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]*model.CampaignGoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		p, err := r.Campaigns.GoalProgress(ctx, goal)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+func (r *mutationResolver) SetCampaignGoal(ctx context.Context, campaignID string, input model.CampaignGoalInput) (*model.CampaignGoal, error) {
+	goal := &model.CampaignGoal{
+		CampaignID: campaignID,
+		Metric:     input.Metric,
+		Target:     input.Target,
+		Days:       input.Days,
+	}
+	if input.StartsAt != nil {
+		goal.StartsAt = *input.StartsAt
+	}
+	return r.Campaigns.SetGoal(ctx, goal)
+}
+
+func (r *mutationResolver) RemoveCampaignGoal(ctx context.Context, campaignID string, metric model.CampaignGoalMetric) (bool, error) {
+	return r.DB.DeleteCampaignGoal(ctx, campaignID, metric)
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+)
+
+func (r *campaignResolver) Leads(ctx context.Context, obj *model.Campaign, status *model.CampaignLeadStatus, limit *int, offset *int) ([]*model.CampaignLead, error) {
+	return r.DB.GetCampaignLeads(ctx, obj.ID, status, limit, offset)
+}
+
+func (r *leadResolver) Campaigns(ctx context.Context, obj *model.Lead) ([]*model.CampaignLead, error) {
+	return r.DB.GetLeadCampaigns(ctx, obj.ID)
+}
+
+func (r *Resolver) CampaignLead() CampaignLeadResolver {
+	return &campaignLeadResolver{r}
+}
+
+type campaignLeadResolver struct{ *Resolver }
+
+func (r *campaignLeadResolver) Campaign(ctx context.Context, obj *model.CampaignLead) (*model.Campaign, error) {
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, obj.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *campaignLeadResolver) Lead(ctx context.Context, obj *model.CampaignLead) (*model.Lead, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, obj.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+	return lead, nil
+}
+
+func (r *campaignLeadResolver) EnrolledBy(ctx context.Context, obj *model.CampaignLead) (*model.User, error) {
+	if obj.EnrolledByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.EnrolledByID)
+}
+
+func (r *mutationResolver) EnrollLeadsInCampaign(ctx context.Context, campaignID string, leadIds []string) ([]*model.CampaignLead, error) {
+	return r.Campaigns.EnrollLeads(ctx, campaignID, leadIds, currentUserID(ctx))
+}
+
+func (r *mutationResolver) RemoveLeadFromCampaign(ctx context.Context, campaignID string, leadID string) (*model.CampaignLead, error) {
+	return r.Campaigns.RemoveLead(ctx, campaignID, leadID)
+}
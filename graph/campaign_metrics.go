@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/dataloader"
+)
+
+func (r *Resolver) CampaignMetrics() CampaignMetricsResolver {
+	return &campaignMetricsResolver{r}
+}
+
+type campaignMetricsResolver struct{ *Resolver }
+
+// Channels and Audiences are empty for metrics not of a single campaign.
+func (r *campaignMetricsResolver) Channels(ctx context.Context, obj *model.CampaignMetrics) ([]*model.ChannelMetrics, error) {
+	if obj.Campaign == nil {
+		return []*model.ChannelMetrics{}, nil
+	}
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.ChannelsByCampaignID.Load(ctx, obj.Campaign.ID)
+}
+
+func (r *campaignMetricsResolver) Audiences(ctx context.Context, obj *model.CampaignMetrics) ([]*model.AudienceMetrics, error) {
+	if obj.Campaign == nil {
+		return []*model.AudienceMetrics{}, nil
+	}
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := loaders.AudiencesByCampaignID.Load(ctx, obj.Campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := loaders.TargetsByCampaignID.Load(ctx, obj.Campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.TargetAudience, len(targets))
+	for _, target := range targets {
+		byID[target.ID] = target
+	}
+	// Copied, as the loaders share what they load across fields.
+	audiences := make([]*model.AudienceMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		if target := byID[m.Audience.ID]; target != nil {
+			audience := *m
+			audience.Audience = target
+			audiences = append(audiences, &audience)
+		}
+	}
+	return audiences, nil
+}
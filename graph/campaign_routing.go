@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+)
+
+const defaultAssignmentLogLimit = 50
+
+func (r *campaignResolver) Routing(ctx context.Context, obj *model.Campaign) (*model.CampaignRouting, error) {
+	return r.DB.GetCampaignRouting(ctx, obj.ID)
+}
+
+func (r *Resolver) CampaignRouting() CampaignRoutingResolver {
+	return &campaignRoutingResolver{r}
+}
+
+type campaignRoutingResolver struct{ *Resolver }
+
+func (r *campaignRoutingResolver) Campaign(ctx context.Context, obj *model.CampaignRouting) (*model.Campaign, error) {
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, obj.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *Resolver) AssignmentLogEntry() AssignmentLogEntryResolver {
+	return &assignmentLogEntryResolver{r}
+}
+
+type assignmentLogEntryResolver struct{ *Resolver }
+
+func (r *assignmentLogEntryResolver) Lead(ctx context.Context, obj *model.AssignmentLogEntry) (*model.Lead, error) {
+	return r.Store.Leads.GetLeadByID(ctx, obj.LeadID)
+}
+
+func (r *assignmentLogEntryResolver) Campaign(ctx context.Context, obj *model.AssignmentLogEntry) (*model.Campaign, error) {
+	if obj.CampaignID == nil {
+		return nil, nil
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, *obj.CampaignID)
+}
+
+func (r *queryResolver) CampaignRoutings(ctx context.Context) ([]*model.CampaignRouting, error) {
+	return r.DB.GetCampaignRoutings(ctx)
+}
+
+func (r *queryResolver) AssignmentLog(ctx context.Context, leadID *string, campaignID *string, limit *int, offset *int) ([]*model.AssignmentLogEntry, error) {
+	n := defaultAssignmentLogLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	skip := 0
+	if offset != nil && *offset > 0 {
+		skip = *offset
+	}
+	return r.DB.GetAssignmentLog(ctx, leadID, campaignID, n, skip)
+}
+
+func (r *mutationResolver) SetCampaignRouting(ctx context.Context, campaignID string, input model.CampaignRoutingInput) (*model.CampaignRouting, error) {
+	routing := &model.CampaignRouting{
+		CampaignID: campaignID,
+		Weight:     input.Weight,
+		Industries: input.Industries,
+		Regions:    input.Regions,
+		Enabled:    true,
+	}
+	if input.Enabled != nil {
+		routing.Enabled = *input.Enabled
+	}
+	return r.Distribution.SetRouting(ctx, routing)
+}
+
+func (r *mutationResolver) RemoveCampaignRouting(ctx context.Context, campaignID string) (bool, error) {
+	return r.DB.DeleteCampaignRouting(ctx, campaignID)
+}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+)
+
+const defaultCampaignSpendLimit = 50
+
+func (r *campaignResolver) SpendToDate(ctx context.Context, obj *model.Campaign) (float64, error) {
+	return r.Campaigns.SpendToDate(ctx, obj.ID)
+}
+
+func (r *campaignResolver) Spend(ctx context.Context, obj *model.Campaign, limit *int) ([]*model.CampaignSpend, error) {
+	n := defaultCampaignSpendLimit
+	if limit != nil {
+		if *limit < 1 {
+			return nil, errors.New("limit must be positive")
+		}
+		n = *limit
+	}
+	return r.DB.GetCampaignSpend(ctx, obj.ID, n)
+}
+
+func (r *Resolver) CampaignSpend() CampaignSpendResolver {
+	return &campaignSpendResolver{r}
+}
+
+type campaignSpendResolver struct{ *Resolver }
+
+func (r *campaignSpendResolver) Interaction(ctx context.Context, obj *model.CampaignSpend) (*model.Interaction, error) {
+	if obj.InteractionID == nil {
+		return nil, nil
+	}
+	return r.DB.GetInteractionByID(ctx, *obj.InteractionID)
+}
+
+// RecordCampaignSpend logs a cost incurred outside the system's own sends,
+// such as a lead enrichment call, against the campaign's budget.
+func (r *mutationResolver) RecordCampaignSpend(ctx context.Context, campaignID string, input model.CampaignSpendInput) (*model.CampaignSpend, error) {
+	return r.Campaigns.RecordSpend(ctx, &model.CampaignSpend{
+		CampaignID:  campaignID,
+		Kind:        input.Kind,
+		Amount:      input.Amount,
+		Description: input.Description,
+	})
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+)
+
+func (r *Resolver) CampaignTemplate() CampaignTemplateResolver {
+	return &campaignTemplateResolver{r}
+}
+
+type campaignTemplateResolver struct{ *Resolver }
+
+func (r *campaignTemplateResolver) SourceCampaign(ctx context.Context, obj *model.CampaignTemplate) (*model.Campaign, error) {
+	if obj.SourceCampaignID == nil {
+		return nil, nil
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, *obj.SourceCampaignID)
+}
+
+func (r *campaignTemplateResolver) CreatedBy(ctx context.Context, obj *model.CampaignTemplate) (*model.User, error) {
+	if obj.CreatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.CreatedByID)
+}
+
+func (r *queryResolver) CampaignTemplate(ctx context.Context, id string) (*model.CampaignTemplate, error) {
+	template, _, err := r.DB.GetCampaignTemplateByID(ctx, id)
+	return template, err
+}
+
+func (r *queryResolver) CampaignTemplates(ctx context.Context) ([]*model.CampaignTemplate, error) {
+	return r.DB.GetCampaignTemplates(ctx)
+}
+
+func (r *mutationResolver) CloneCampaign(ctx context.Context, id string, overrides *model.CampaignOverridesInput) (*model.Campaign, error) {
+	return r.Campaigns.Clone(ctx, id, overrides)
+}
+
+func (r *mutationResolver) SaveCampaignTemplate(ctx context.Context, campaignID string, name string, description *string) (*model.CampaignTemplate, error) {
+	return r.Campaigns.SaveTemplate(ctx, campaignID, name, description, currentUserID(ctx))
+}
+
+func (r *mutationResolver) CreateCampaignFromTemplate(ctx context.Context, templateID string, overrides *model.CampaignOverridesInput) (*model.Campaign, error) {
+	return r.Campaigns.Instantiate(ctx, templateID, overrides)
+}
+
+func (r *mutationResolver) DeleteCampaignTemplate(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteCampaignTemplate(ctx, id)
+}
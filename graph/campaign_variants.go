@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/channels"
+	"salesagency/internal/templates"
+)
+
+func (r *campaignResolver) Variants(ctx context.Context, obj *model.Campaign) ([]*model.CampaignVariant, error) {
+	return r.DB.GetCampaignVariants(ctx, obj.ID)
+}
+
+func (r *campaignResolver) AbTestResults(ctx context.Context, obj *model.Campaign) ([]*model.VariantResult, error) {
+	return r.Campaigns.ABTestResults(ctx, obj.ID)
+}
+
+func (r *Resolver) CampaignVariant() CampaignVariantResolver {
+	return &campaignVariantResolver{r}
+}
+
+type campaignVariantResolver struct{ *Resolver }
+
+func (r *campaignVariantResolver) Template(ctx context.Context, obj *model.CampaignVariant) (*model.MessageTemplate, error) {
+	template, err := r.DB.GetMessageTemplateByID(ctx, obj.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("message template not found")
+	}
+	return template, nil
+}
+
+// SetCampaignVariants replaces the traffic split of the campaign's A/B test.
+// Every variant must be one of the campaign's own templates, and all of them
+// must use the same channel so they are compared like for like.
+func (r *mutationResolver) SetCampaignVariants(ctx context.Context, campaignID string, variants []*model.CampaignVariantInput) ([]*model.CampaignVariant, error) {
+	var channel model.Channel
+	seen := make(map[string]bool, len(variants))
+	weights := make([]*model.CampaignVariant, 0, len(variants))
+
+	for _, input := range variants {
+		if input.Weight < 0 {
+			return nil, errors.New("variant weight must not be negative")
+		}
+		if seen[input.TemplateID] {
+			return nil, fmt.Errorf("template %s is listed more than once", input.TemplateID)
+		}
+		seen[input.TemplateID] = true
+
+		template, err := r.DB.GetMessageTemplateByID(ctx, input.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return nil, errors.New("message template not found")
+		}
+		if template.Campaign == nil || template.Campaign.ID != campaignID {
+			return nil, fmt.Errorf("template %s does not belong to the campaign", template.ID)
+		}
+		if channel == "" {
+			channel = template.Channel
+		} else if template.Channel != channel {
+			return nil, errors.New("all variants must use the same channel")
+		}
+
+		weights = append(weights, &model.CampaignVariant{TemplateID: template.ID, Weight: input.Weight})
+	}
+
+	saved, err := r.DB.SaveCampaignVariants(ctx, campaignID, weights)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return saved, nil
+}
+
+// SendCampaignMessage sends the lead the campaign's A/B test variant it is
+// assigned to and records the variant on the interaction.
+func (r *mutationResolver) SendCampaignMessage(ctx context.Context, campaignID string, leadID string) (*model.Interaction, error) {
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+	if c.Status != model.CampaignStatusActive {
+		return nil, errors.New("campaign is not active")
+	}
+
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	variant, err := r.Campaigns.AssignVariant(ctx, campaignID, leadID)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := r.DB.GetMessageTemplateByID(ctx, variant.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("message template not found")
+	}
+
+	content, err := r.DB.GetLocalizedTemplateContent(ctx, template, lead.Language)
+	if err != nil {
+		return nil, err
+	}
+	body, err := templates.Render(content, templates.LeadData(lead))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &channels.Outbound{
+		Lead:     lead,
+		Body:     body,
+		Template: template,
+		AIAgent:  template.AIAgent,
+		Variant:  variant,
+	}
+	if template.Channel == model.ChannelEmail {
+		msg.Subject = template.Name
+	}
+
+	return r.Channels.Send(ctx, template.Channel, msg)
+}
@@ -0,0 +1,166 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+)
+
+const maxCommentLength = 4000
+
+// ClientPortal refuses client portal users every root field not marked
+// @clientAccess, and those whose role argument their role does not meet.
+// What they may see through the allowed fields is limited to their clients
+// by the database, from the scope auth.WithUser sets.
+type ClientPortal struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.RootFieldInterceptor
+} = ClientPortal{}
+
+func (ClientPortal) ExtensionName() string {
+	return "ClientPortal"
+}
+
+func (ClientPortal) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (ClientPortal) InterceptRootField(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+	user := auth.UserFromContext(ctx)
+	if user == nil || !user.IsClientScoped() {
+		return next(ctx)
+	}
+
+	field := graphql.GetRootFieldContext(ctx).Field
+	if strings.HasPrefix(field.Name, "__") || clientMayUse(user, field.Definition) {
+		return next(ctx)
+	}
+
+	graphql.AddError(ctx, auth.ErrForbidden)
+	return graphql.Null
+}
+
+func clientMayUse(user *auth.User, definition *ast.FieldDefinition) bool {
+	if definition == nil {
+		return false
+	}
+	directive := definition.Directives.ForName("clientAccess")
+	if directive == nil {
+		return false
+	}
+
+	role := auth.RoleClientViewer
+	if arg := directive.Arguments.ForName("role"); arg != nil && arg.Value != nil {
+		role = auth.Role(arg.Value.Raw)
+	}
+	// Both client roles rank the same, so CLIENT is told apart by name.
+	return role == auth.RoleClientViewer || user.Role == role
+}
+
+func (r *queryResolver) ClientReport(ctx context.Context, clientID string, period string, format *model.ReportFormat) (*model.ClientReport, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	if !user.IsClientScoped() && !user.HasRole(auth.RoleAgencyManager) {
+		return nil, auth.ErrForbidden
+	}
+	return r.clientReport(ctx, clientID, period, format)
+}
+
+func (r *campaignResolver) Comments(ctx context.Context, obj *model.Campaign) ([]*model.CampaignComment, error) {
+	return r.DB.GetCampaignComments(ctx, obj.ID)
+}
+
+func (r *campaignResolver) Approval(ctx context.Context, obj *model.Campaign) (*model.CampaignApproval, error) {
+	return r.DB.GetCampaignApproval(ctx, obj.ID)
+}
+
+func (r *Resolver) CampaignComment() CampaignCommentResolver {
+	return &campaignCommentResolver{r}
+}
+
+type campaignCommentResolver struct{ *Resolver }
+
+func (r *campaignCommentResolver) Author(ctx context.Context, obj *model.CampaignComment) (*model.User, error) {
+	if obj.AuthorID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.AuthorID)
+}
+
+func (r *Resolver) CampaignApproval() CampaignApprovalResolver {
+	return &campaignApprovalResolver{r}
+}
+
+type campaignApprovalResolver struct{ *Resolver }
+
+func (r *campaignApprovalResolver) ApprovedBy(ctx context.Context, obj *model.CampaignApproval) (*model.User, error) {
+	if obj.ApprovedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.ApprovedByID)
+}
+
+func (r *mutationResolver) CommentOnCampaign(ctx context.Context, campaignID string, body string) (*model.CampaignComment, error) {
+	body = strings.TrimSpace(body)
+	switch {
+	case body == "":
+		return nil, errors.New("comment must not be empty")
+	case len([]rune(body)) > maxCommentLength:
+		return nil, errors.New("comment is too long")
+	}
+
+	if _, err := r.accessibleCampaign(ctx, campaignID); err != nil {
+		return nil, err
+	}
+
+	return r.DB.CreateCampaignComment(ctx, &model.CampaignComment{
+		CampaignID: campaignID,
+		AuthorID:   currentUserID(ctx),
+		Body:       body,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (r *mutationResolver) ApproveCampaign(ctx context.Context, campaignID string) (*model.Campaign, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil || !user.IsClientScoped() {
+		return nil, errors.New("only client users approve campaigns")
+	}
+
+	campaign, err := r.accessibleCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.DB.ApproveCampaign(ctx, campaign.ID, &user.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// accessibleCampaign returns the campaign, or an error when it does not
+// exist or belongs to a client the user may not see.
+func (r *Resolver) accessibleCampaign(ctx context.Context, id string) (*model.Campaign, error) {
+	campaign, err := r.Store.Campaigns.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("campaign not found")
+	}
+	if !canAccessCampaign(auth.UserFromContext(ctx), campaign) {
+		return nil, auth.ErrForbidden
+	}
+	return campaign, nil
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/sendwindow"
+)
+
+func (r *leadResolver) ContactPreferences(ctx context.Context, obj *model.Lead) (*model.ContactPreferences, error) {
+	prefs, err := r.DB.GetLeadContactPreferences(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		return &model.ContactPreferences{}, nil
+	}
+	return prefs, nil
+}
+
+func (r *campaignResolver) FallbackRules(ctx context.Context, obj *model.Campaign) ([]*model.ChannelFallbackRule, error) {
+	return r.DB.GetCampaignFallbackRules(ctx, obj.ID)
+}
+
+func (r *Resolver) ChannelFallbackRule() ChannelFallbackRuleResolver {
+	return &channelFallbackRuleResolver{r}
+}
+
+type channelFallbackRuleResolver struct{ *Resolver }
+
+func (r *channelFallbackRuleResolver) Template(ctx context.Context, obj *model.ChannelFallbackRule) (*model.MessageTemplate, error) {
+	if obj.TemplateID == nil {
+		return nil, nil
+	}
+	return r.DB.GetMessageTemplateByID(ctx, *obj.TemplateID)
+}
+
+func (r *mutationResolver) SetLeadContactPreferences(ctx context.Context, leadID string, input model.ContactPreferencesInput) (*model.Lead, error) {
+	prefs := &model.ContactPreferences{PreferredChannel: input.PreferredChannel}
+	if input.QuietHours != nil {
+		if _, err := sendwindow.Quiet(input.QuietHours.Start, input.QuietHours.End); err != nil {
+			return nil, err
+		}
+		prefs.QuietHours = &model.QuietHours{Start: input.QuietHours.Start, End: input.QuietHours.End}
+	}
+
+	found, err := r.DB.SetLeadContactPreferences(ctx, leadID, prefs)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("lead not found")
+	}
+	return r.Store.Leads.GetLeadByID(ctx, leadID)
+}
+
+func (r *mutationResolver) SetCampaignFallbackRules(ctx context.Context, campaignID string, rules []*model.ChannelFallbackRuleInput) ([]*model.ChannelFallbackRule, error) {
+	saved := make([]*model.ChannelFallbackRule, len(rules))
+	for i, rule := range rules {
+		saved[i] = &model.ChannelFallbackRule{
+			Channel:         rule.Channel,
+			Trigger:         rule.Trigger,
+			Threshold:       1,
+			FallbackChannel: rule.FallbackChannel,
+			TemplateID:      rule.TemplateID,
+		}
+		if rule.Threshold != nil {
+			saved[i].Threshold = *rule.Threshold
+		}
+	}
+	return r.Sequences.SetFallbackRules(ctx, campaignID, saved)
+}
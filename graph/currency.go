@@ -0,0 +1,22 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/internal/money"
+)
+
+func (r *queryResolver) BaseCurrency(ctx context.Context) (string, error) {
+	return r.DB.GetBaseCurrency(ctx)
+}
+
+func (r *mutationResolver) SetBaseCurrency(ctx context.Context, currency string) (string, error) {
+	code, err := money.ParseCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+	if err := r.DB.SetBaseCurrency(ctx, code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
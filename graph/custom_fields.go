@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/customfields"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+)
+
+var errCustomFieldNotFound = errors.New("custom field not found")
+
+func (r *leadResolver) CustomFields(ctx context.Context, obj *model.Lead) ([]*model.CustomFieldValue, error) {
+	values, err := r.DB.GetLeadCustomFields(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*model.CustomFieldValue, 0, len(values))
+	for key, value := range values {
+		fields = append(fields, &model.CustomFieldValue{Key: key, Value: customfields.Format(value)})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+
+	return fields, nil
+}
+
+func (r *queryResolver) CustomFieldDefinitions(ctx context.Context) ([]*model.CustomFieldDefinition, error) {
+	return r.DB.GetCustomFieldDefinitions(ctx)
+}
+
+func (r *mutationResolver) CreateCustomField(ctx context.Context, input model.CustomFieldDefinitionInput) (*model.CustomFieldDefinition, error) {
+	def := customFieldDefinition(input)
+	if err := customfields.ValidateDefinition(def); err != nil {
+		return nil, err
+	}
+	def.CreatedAt = time.Now()
+
+	return r.DB.CreateCustomFieldDefinition(ctx, def)
+}
+
+func (r *mutationResolver) UpdateCustomField(ctx context.Context, id string, input model.CustomFieldDefinitionInput) (*model.CustomFieldDefinition, error) {
+	existing, err := r.DB.GetCustomFieldDefinitionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errCustomFieldNotFound
+	}
+
+	def := customFieldDefinition(input)
+	if def.Key != existing.Key || def.Type != existing.Type {
+		return nil, errors.New("the key and type of a custom field cannot be changed")
+	}
+	if err := customfields.ValidateDefinition(def); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	def.ID = id
+	def.CreatedAt = existing.CreatedAt
+	def.UpdatedAt = &now
+
+	ok, err := r.DB.UpdateCustomFieldDefinition(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errCustomFieldNotFound
+	}
+
+	return def, nil
+}
+
+func (r *mutationResolver) DeleteCustomField(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteCustomFieldDefinition(ctx, id)
+}
+
+// SetLeadCustomFields checks each value against the field's definition in
+// the lead's agency; nothing is saved if any value is rejected.
+func (r *mutationResolver) SetLeadCustomFields(ctx context.Context, leadID string, values []*model.CustomFieldValueInput) (*model.Lead, error) {
+	defs, err := r.DB.GetLeadCustomFieldDefinitions(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*model.CustomFieldDefinition, len(defs))
+	for _, def := range defs {
+		byKey[def.Key] = def
+	}
+
+	set := database.CustomFieldValues{}
+	var remove []string
+	for _, value := range values {
+		def := byKey[value.Key]
+		if def == nil {
+			return nil, fmt.Errorf("unknown custom field %q", value.Key)
+		}
+		if value.Value == nil || strings.TrimSpace(*value.Value) == "" {
+			remove = append(remove, value.Key)
+			continue
+		}
+		parsed, err := customfields.Parse(def, *value.Value)
+		if err != nil {
+			return nil, err
+		}
+		set[value.Key] = parsed
+	}
+
+	found, err := r.DB.SetLeadCustomFields(ctx, leadID, set, remove)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("lead not found")
+	}
+
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	r.Events.Publish(events.TopicLeadUpdated, lead)
+
+	return lead, nil
+}
+
+func customFieldDefinition(input model.CustomFieldDefinitionInput) *model.CustomFieldDefinition {
+	def := &model.CustomFieldDefinition{
+		Key:     strings.TrimSpace(input.Key),
+		Label:   strings.TrimSpace(input.Label),
+		Type:    input.Type,
+		Options: input.Options,
+		Pattern: input.Pattern,
+		Min:     input.Min,
+		Max:     input.Max,
+	}
+	if def.Pattern != nil && *def.Pattern == "" {
+		def.Pattern = nil
+	}
+	return def
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/reports"
+)
+
+func (r *queryResolver) DashboardStats(ctx context.Context, period string) (*model.DashboardStats, error) {
+	p, err := reports.ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	return r.Reports.Dashboard(ctx, p, time.Now())
+}
+
+func (r *Resolver) TopCampaign() TopCampaignResolver {
+	return &topCampaignResolver{r}
+}
+
+type topCampaignResolver struct{ *Resolver }
+
+func (r *topCampaignResolver) Campaign(ctx context.Context, obj *model.TopCampaign) (*model.Campaign, error) {
+	campaign, err := r.Store.Campaigns.GetCampaignByID(ctx, obj.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("campaign not found")
+	}
+	return campaign, nil
+}
+
+func (r *queryResolver) ConversionFunnel(ctx context.Context, filter *model.LeadFilterInput, period string, cohortBy *model.FunnelCohortBy) (*model.ConversionFunnel, error) {
+	p, err := reports.ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	return r.Reports.ConversionFunnel(ctx, filter, p, cohortBy)
+}
+
+func (r *Resolver) FunnelCohort() FunnelCohortResolver {
+	return &funnelCohortResolver{r}
+}
+
+type funnelCohortResolver struct{ *Resolver }
+
+// Campaign is nil for the cohort of leads in no campaign, and for campaigns
+// since deleted.
+func (r *funnelCohortResolver) Campaign(ctx context.Context, obj *model.FunnelCohort) (*model.Campaign, error) {
+	if obj.CampaignID == nil {
+		return nil, nil
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, *obj.CampaignID)
+}
@@ -0,0 +1,217 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/money"
+)
+
+var errDealNotFound = errors.New("deal not found")
+
+func (r *Resolver) Deal() DealResolver {
+	return &dealResolver{r}
+}
+
+type dealResolver struct{ *Resolver }
+
+func (r *dealResolver) Lead(ctx context.Context, obj *model.Deal) (*model.Lead, error) {
+	if obj.LeadID == nil {
+		return nil, nil
+	}
+	return r.Store.Leads.GetLeadByID(ctx, *obj.LeadID)
+}
+
+func (r *dealResolver) Client(ctx context.Context, obj *model.Deal) (*model.Client, error) {
+	if obj.ClientID == nil {
+		return nil, nil
+	}
+	return r.Store.Clients.GetClientByID(ctx, *obj.ClientID)
+}
+
+func (r *dealResolver) Campaign(ctx context.Context, obj *model.Deal) (*model.Campaign, error) {
+	if obj.CampaignID == nil {
+		return nil, nil
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, *obj.CampaignID)
+}
+
+func (r *dealResolver) CreatedBy(ctx context.Context, obj *model.Deal) (*model.User, error) {
+	if obj.CreatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.CreatedByID)
+}
+
+func (r *leadResolver) Deals(ctx context.Context, obj *model.Lead) ([]*model.Deal, error) {
+	return r.DB.GetDeals(ctx, &model.DealFilterInput{LeadID: &obj.ID}, nil, nil)
+}
+
+func (r *clientResolver) Deals(ctx context.Context, obj *model.Client) ([]*model.Deal, error) {
+	return r.DB.GetDeals(ctx, &model.DealFilterInput{ClientID: &obj.ID}, nil, nil)
+}
+
+func (r *clientResolver) PipelineValue(ctx context.Context, obj *model.Client) ([]*model.MoneyTotal, error) {
+	return r.DB.GetClientPipelineValue(ctx, obj.ID)
+}
+
+func (r *campaignResolver) AttributedRevenue(ctx context.Context, obj *model.Campaign) ([]*model.MoneyTotal, error) {
+	return r.DB.GetCampaignRevenue(ctx, obj.ID)
+}
+
+func (r *dealResolver) AmountInBaseCurrency(ctx context.Context, obj *model.Deal) (*model.Money, error) {
+	amount, err := r.Money.ToBaseCurrency(ctx, obj.Amount())
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
+
+func (r *clientResolver) PipelineTotal(ctx context.Context, obj *model.Client) (*model.Money, error) {
+	total, err := r.DB.GetClientPipelineTotal(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if total == nil {
+		return nil, errors.New("client not found")
+	}
+	return total, nil
+}
+
+func (r *campaignResolver) AttributedRevenueTotal(ctx context.Context, obj *model.Campaign) (*model.Money, error) {
+	total, err := r.DB.GetCampaignRevenueTotal(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if total == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return total, nil
+}
+
+func (r *queryResolver) Deal(ctx context.Context, id string) (*model.Deal, error) {
+	return r.DB.GetDealByID(ctx, id)
+}
+
+func (r *queryResolver) Deals(ctx context.Context, filter *model.DealFilterInput, limit *int, offset *int) ([]*model.Deal, error) {
+	return r.DB.GetDeals(ctx, filter, limit, offset)
+}
+
+func (r *mutationResolver) CreateDeal(ctx context.Context, input model.DealInput) (*model.Deal, error) {
+	deal := &model.Deal{CreatedByID: currentUserID(ctx), CreatedAt: time.Now()}
+	if err := r.applyDealInput(ctx, deal, input); err != nil {
+		return nil, err
+	}
+	return r.DB.CreateDeal(ctx, deal)
+}
+
+func (r *mutationResolver) UpdateDeal(ctx context.Context, id string, input model.DealInput) (*model.Deal, error) {
+	deal, err := r.DB.GetDealByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if deal == nil {
+		return nil, errDealNotFound
+	}
+
+	if err := r.applyDealInput(ctx, deal, input); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	deal.UpdatedAt = &now
+
+	updated, err := r.DB.UpdateDeal(ctx, deal)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errDealNotFound
+	}
+	return updated, nil
+}
+
+func (r *mutationResolver) DeleteDeal(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteDeal(ctx, id)
+}
+
+// applyDealInput validates input and copies it onto deal. A deal is closed
+// when it first reaches WON or LOST, and reopened when it leaves them.
+func (r *mutationResolver) applyDealInput(ctx context.Context, deal *model.Deal, input model.DealInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return errors.New("deal name is required")
+	}
+	if input.Value < 0 {
+		return errors.New("deal value must not be negative")
+	}
+	currency, err := money.ParseCurrency(input.Currency)
+	if err != nil {
+		return err
+	}
+	if input.LeadID == nil && input.ClientID == nil {
+		return errors.New("a deal needs a lead or a client")
+	}
+
+	campaignID := input.CampaignID
+	if input.LeadID != nil {
+		lead, err := r.Store.Leads.GetLeadByID(ctx, *input.LeadID)
+		if err != nil {
+			return err
+		}
+		if lead == nil {
+			return errors.New("lead not found")
+		}
+
+		if campaignID == nil {
+			attribution, err := r.DB.GetLeadAttribution(ctx, lead.ID)
+			if err != nil {
+				return err
+			}
+			if attribution != nil {
+				campaignID = attribution.CampaignID
+			}
+		}
+	}
+	if input.ClientID != nil {
+		client, err := r.Store.Clients.GetClientByID(ctx, *input.ClientID)
+		if err != nil {
+			return err
+		}
+		if client == nil {
+			return errors.New("client not found")
+		}
+	}
+	if input.CampaignID != nil {
+		c, err := r.Store.Campaigns.GetCampaignByID(ctx, *input.CampaignID)
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			return campaign.ErrNotFound
+		}
+	}
+
+	wasClosed := deal.IsClosed() && deal.ClosedAt != nil
+	deal.Name = name
+	deal.LeadID = input.LeadID
+	deal.ClientID = input.ClientID
+	deal.CampaignID = campaignID
+	deal.Value = input.Value
+	deal.Currency = currency
+	deal.Stage = input.Stage
+	deal.ExpectedCloseDate = input.ExpectedCloseDate
+
+	switch {
+	case !deal.IsClosed():
+		deal.ClosedAt = nil
+	case !wasClosed:
+		now := time.Now()
+		deal.ClosedAt = &now
+	}
+
+	return nil
+}
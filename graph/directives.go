@@ -0,0 +1,21 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+)
+
+func HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, role model.UserRole) (interface{}, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	if !user.HasRole(auth.Role(role)) {
+		return nil, auth.ErrForbidden
+	}
+	return next(ctx)
+}
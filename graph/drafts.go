@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+)
+
+func (r *aiAgentResolver) DryRun(ctx context.Context, obj *model.AIAgent) (bool, error) {
+	return r.DB.GetAIAgentDryRun(ctx, obj.ID)
+}
+
+func (r *interactionResolver) Subject(ctx context.Context, obj *model.Interaction) (*string, error) {
+	if obj.Direction == model.InteractionDirectionInbound {
+		return nil, nil
+	}
+	return r.DB.GetInteractionSubject(ctx, obj.ID)
+}
+
+func (r *mutationResolver) SetAIAgentDryRun(ctx context.Context, id string, dryRun bool) (*model.AIAgent, error) {
+	ok, err := r.DB.SetAIAgentDryRun(ctx, id, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("ai agent not found")
+	}
+
+	return r.DB.GetAIAgentByID(ctx, id)
+}
+
+func (r *mutationResolver) ApproveDraft(ctx context.Context, interactionID string) (*model.Interaction, error) {
+	interaction, err := r.Channels.SendDraft(ctx, interactionID, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if interaction == nil {
+		return nil, errors.New("draft not found")
+	}
+
+	return interaction, nil
+}
+
+func (r *mutationResolver) RejectDraft(ctx context.Context, interactionID string, reason *string) (*model.Interaction, error) {
+	interaction, err := r.Channels.RejectDraft(ctx, interactionID, currentUserID(ctx), reason)
+	if err != nil {
+		return nil, err
+	}
+	if interaction == nil {
+		return nil, errors.New("draft not found")
+	}
+
+	return interaction, nil
+}
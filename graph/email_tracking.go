@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+func (r *interactionResolver) Opens(ctx context.Context, obj *model.Interaction) (int, error) {
+	if obj.Channel != model.ChannelEmail {
+		return 0, nil
+	}
+	counts, err := r.DB.CountEmailEvents(ctx, obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.Opens, nil
+}
+
+func (r *interactionResolver) Clicks(ctx context.Context, obj *model.Interaction) (int, error) {
+	if obj.Channel != model.ChannelEmail {
+		return 0, nil
+	}
+	counts, err := r.DB.CountEmailEvents(ctx, obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.Clicks, nil
+}
+
+func (r *interactionResolver) EmailEvents(ctx context.Context, obj *model.Interaction) ([]*model.EmailEvent, error) {
+	if obj.Channel != model.ChannelEmail {
+		return []*model.EmailEvent{}, nil
+	}
+	return r.DB.GetEmailEvents(ctx, obj.ID)
+}
+
+func (r *leadResolver) EmailSuppression(ctx context.Context, obj *model.Lead) (*model.EmailSuppression, error) {
+	return r.DB.GetLeadEmailSuppression(ctx, obj.ID)
+}
+
+func (r *agentStatsResolver) EmailsSent(ctx context.Context, obj *model.AgentStats) (int, error) {
+	stats, err := r.DB.GetAgentEmailStats(ctx, obj.AgentID)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Sent, nil
+}
+
+func (r *agentStatsResolver) OpenRate(ctx context.Context, obj *model.AgentStats) (float64, error) {
+	return r.emailRate(ctx, obj, func(stats *database.EmailStats) int { return stats.Opened })
+}
+
+func (r *agentStatsResolver) ClickRate(ctx context.Context, obj *model.AgentStats) (float64, error) {
+	return r.emailRate(ctx, obj, func(stats *database.EmailStats) int { return stats.Clicked })
+}
+
+func (r *agentStatsResolver) BounceRate(ctx context.Context, obj *model.AgentStats) (float64, error) {
+	return r.emailRate(ctx, obj, func(stats *database.EmailStats) int { return stats.Bounced })
+}
+
+// emailRate returns the share of the agent's sent emails that count.
+func (r *agentStatsResolver) emailRate(ctx context.Context, obj *model.AgentStats, count func(*database.EmailStats) int) (float64, error) {
+	stats, err := r.DB.GetAgentEmailStats(ctx, obj.AgentID)
+	if err != nil || stats.Sent == 0 {
+		return 0, err
+	}
+	return float64(count(stats)) / float64(stats.Sent), nil
+}
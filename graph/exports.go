@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/export"
+	"salesagency/internal/tenant"
+)
+
+func (r *mutationResolver) ExportLeads(ctx context.Context, filter *model.LeadFilterInput, format *model.ExportFormat) (*model.LeadExport, error) {
+	agencyID, err := tenant.AgencyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if agencyID == "" {
+		return nil, errors.New("exports must be requested within an agency")
+	}
+
+	req := export.Request{AgencyID: agencyID, Filter: filter, Format: model.ExportFormatCSV}
+	if format != nil {
+		req.Format = *format
+	}
+	if user := auth.UserFromContext(ctx); user != nil {
+		req.UserID = user.ID
+	}
+
+	url, expiresAt, err := r.Exports.URL(req)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LeadExport{URL: url, ExpiresAt: expiresAt}, nil
+}
@@ -0,0 +1,27 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+)
+
+// Entity resolves references to this subgraph's entities from the federation
+// gateway. References get the same access checks as the matching queries.
+func (r *Resolver) Entity() EntityResolver {
+	return &entityResolver{r}
+}
+
+type entityResolver struct{ *Resolver }
+
+func (r *entityResolver) FindLeadByID(ctx context.Context, id string) (*model.Lead, error) {
+	return (&queryResolver{r.Resolver}).Lead(ctx, id, nil)
+}
+
+func (r *entityResolver) FindClientByID(ctx context.Context, id string) (*model.Client, error) {
+	return (&queryResolver{r.Resolver}).Client(ctx, id, nil)
+}
+
+func (r *entityResolver) FindCampaignByID(ctx context.Context, id string) (*model.Campaign, error) {
+	return (&queryResolver{r.Resolver}).Campaign(ctx, id)
+}
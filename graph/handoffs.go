@@ -0,0 +1,203 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/dataloader"
+	"salesagency/internal/validation"
+)
+
+// handoffSLAWindow is the range handoffSLA covers by default.
+const handoffSLAWindow = 30 * 24 * time.Hour
+
+func (r *leadResolver) Handoff(ctx context.Context, obj *model.Lead) (*model.LeadHandoff, error) {
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.HandoffsByLeadID.Load(ctx, obj.ID)
+}
+
+func (r *Resolver) LeadHandoff() LeadHandoffResolver {
+	return &leadHandoffResolver{r}
+}
+
+type leadHandoffResolver struct{ *Resolver }
+
+func (r *leadHandoffResolver) Lead(ctx context.Context, obj *model.LeadHandoff) (*model.Lead, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, obj.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+	return lead, nil
+}
+
+func (r *leadHandoffResolver) Rep(ctx context.Context, obj *model.LeadHandoff) (*model.User, error) {
+	rep, err := r.DB.GetUserByID(ctx, obj.RepID)
+	if err != nil {
+		return nil, err
+	}
+	if rep == nil {
+		return nil, errors.New("sales rep not found")
+	}
+	return rep, nil
+}
+
+func (r *leadHandoffResolver) EscalatedBy(ctx context.Context, obj *model.LeadHandoff) (*model.User, error) {
+	if obj.EscalatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.EscalatedByID)
+}
+
+func (r *leadHandoffResolver) PickupTime(ctx context.Context, obj *model.LeadHandoff) (float64, error) {
+	return handoffPickupTime(obj, time.Now()).Seconds(), nil
+}
+
+func (r *leadHandoffResolver) Overdue(ctx context.Context, obj *model.LeadHandoff) (bool, error) {
+	return handoffPickupTime(obj, time.Now()) > r.HandoffTarget, nil
+}
+
+// handoffPickupTime is how long the handoff took to be picked up, or has
+// waited by now.
+func handoffPickupTime(handoff *model.LeadHandoff, now time.Time) time.Duration {
+	if handoff.PickedUpAt != nil {
+		now = *handoff.PickedUpAt
+	}
+	return now.Sub(handoff.CreatedAt)
+}
+
+func (r *queryResolver) SalesReps(ctx context.Context, status *model.UserStatus) ([]*model.User, error) {
+	return r.DB.GetUsersByRole(ctx, database.SalesRepRoles, status)
+}
+
+func (r *queryResolver) MyLeads(ctx context.Context, filter *model.LeadFilterInput, limit *int, offset *int) ([]*model.Lead, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	return r.DB.GetRepLeads(ctx, user.ID, filter, limit, offset)
+}
+
+func (r *queryResolver) Handoffs(ctx context.Context, repID *string, status *model.HandoffStatus, limit *int, offset *int) ([]*model.LeadHandoff, error) {
+	return r.DB.GetLeadHandoffs(ctx, repID, status, limit, offset)
+}
+
+func (r *queryResolver) HandoffSLA(ctx context.Context, repID *string, dateRange *model.DateRangeInput) (*model.HandoffSLA, error) {
+	now := time.Now()
+	from, to := now.Add(-handoffSLAWindow), now
+	if dateRange != nil {
+		from, to = dateRange.From, dateRange.To
+	}
+	if !to.After(from) {
+		return nil, errors.New("range must end after it starts")
+	}
+	return r.DB.GetHandoffSLA(ctx, repID, from, to, now, r.HandoffTarget)
+}
+
+func (r *mutationResolver) EscalateToHuman(ctx context.Context, leadID string, repID string, reason string) (*model.LeadHandoff, error) {
+	reason = strings.TrimSpace(reason)
+	switch {
+	case reason == "":
+		return nil, errors.New("reason must not be empty")
+	case len([]rune(reason)) > validation.MaxTextLength:
+		return nil, errors.New("reason is too long")
+	}
+
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	rep, err := r.DB.GetUserByID(ctx, repID)
+	if err != nil {
+		return nil, err
+	}
+	if rep == nil {
+		return nil, errors.New("sales rep not found")
+	}
+	if rep.Status != model.UserStatusActive || !slices.Contains(database.SalesRepRoles, rep.Role) {
+		return nil, errors.New("leads can only be escalated to active sales reps")
+	}
+
+	handoff, err := r.DB.CreateLeadHandoff(ctx, &model.LeadHandoff{
+		LeadID:        lead.ID,
+		RepID:         rep.ID,
+		EscalatedByID: currentUserID(ctx),
+		Reason:        reason,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.Notifier.LeadEscalated(ctx, handoff, lead)
+	return handoff, nil
+}
+
+func (r *mutationResolver) PickUpHandoff(ctx context.Context, id string) (*model.LeadHandoff, error) {
+	handoff, err := r.accessibleHandoff(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	picked, err := r.DB.PickUpLeadHandoff(ctx, handoff.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if picked == nil {
+		return nil, errors.New("handoff is not pending")
+	}
+	return picked, nil
+}
+
+func (r *mutationResolver) ResolveHandoff(ctx context.Context, id string) (*model.LeadHandoff, error) {
+	handoff, err := r.accessibleHandoff(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := r.DB.ResolveLeadHandoff(ctx, handoff.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if resolved == nil {
+		return nil, errors.New("handoff is already resolved")
+	}
+	return resolved, nil
+}
+
+// accessibleHandoff returns the handoff, or an error when it does not exist
+// or was handed to another rep. Managers may act on any handoff when
+// managers is set.
+func (r *Resolver) accessibleHandoff(ctx context.Context, id string, managers bool) (*model.LeadHandoff, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	handoff, err := r.DB.GetLeadHandoffByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if handoff == nil {
+		return nil, errors.New("handoff not found")
+	}
+	if handoff.RepID != user.ID && !(managers && user.HasRole(auth.RoleManager)) {
+		return nil, auth.ErrForbidden
+	}
+	return handoff, nil
+}
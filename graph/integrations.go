@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/tenant"
+)
+
+func (r *Resolver) SyncError() SyncErrorResolver {
+	return &syncErrorResolver{r}
+}
+
+type syncErrorResolver struct{ *Resolver }
+
+func (r *syncErrorResolver) Lead(ctx context.Context, obj *model.SyncError) (*model.Lead, error) {
+	if obj.LeadID == nil {
+		return nil, nil
+	}
+	return r.Store.Leads.GetLeadByID(ctx, *obj.LeadID)
+}
+
+func (r *queryResolver) SalesforceConnection(ctx context.Context) (*model.SalesforceConnection, error) {
+	agencyID, err := tenant.AgencyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := r.DB.GetSalesforceConnection(ctx, agencyID)
+	if err != nil || conn == nil {
+		return nil, err
+	}
+	return &model.SalesforceConnection{InstanceURL: conn.InstanceURL, ConnectedAt: conn.ConnectedAt}, nil
+}
+
+func (r *queryResolver) SyncErrors(ctx context.Context, resolved *bool, limit *int, offset *int) ([]*model.SyncError, error) {
+	return r.DB.GetSyncErrors(ctx, resolved, limit, offset)
+}
+
+// ConnectSalesforce returns the URL to send the user to; the connection is
+// made when Salesforce redirects back to salesforce.CallbackPath.
+func (r *mutationResolver) ConnectSalesforce(ctx context.Context) (string, error) {
+	return r.Salesforce.AuthorizeURL(ctx)
+}
+
+func (r *mutationResolver) DisconnectSalesforce(ctx context.Context) (bool, error) {
+	return r.Salesforce.Disconnect(ctx)
+}
+
+func (r *mutationResolver) ResolveSyncError(ctx context.Context, id string) (*model.SyncError, error) {
+	syncError, err := r.DB.ResolveSyncError(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if syncError == nil {
+		return nil, errors.New("sync error not found")
+	}
+	return syncError, nil
+}
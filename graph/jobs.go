@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/jobs"
+	"salesagency/internal/reports"
+	"salesagency/internal/sendwindow"
+)
+
+// enrollJobBatchSize is how many leads an enrollment job enrolls at once,
+// reporting its progress after each batch.
+const enrollJobBatchSize = 500
+
+type bulkUpdateJob struct {
+	IDs   []string             `json:"ids"`
+	Patch model.LeadPatchInput `json:"patch"`
+}
+
+type bulkTagJob struct {
+	IDs        []string `json:"ids"`
+	AddTags    []string `json:"addTags"`
+	RemoveTags []string `json:"removeTags"`
+}
+
+type bulkStatusJob struct {
+	IDs    []string         `json:"ids"`
+	Status model.LeadStatus `json:"status"`
+	Reason *string          `json:"reason"`
+}
+
+type enrollJob struct {
+	CampaignID string   `json:"campaignId"`
+	LeadIDs    []string `json:"leadIds"`
+}
+
+type clientReportJob struct {
+	ClientID string              `json:"clientId"`
+	Period   string              `json:"period"`
+	Format   *model.ReportFormat `json:"format"`
+}
+
+// RegisterJobs sets the handlers of the jobs the ...Async mutations queue.
+// Each runs the synchronous mutation as the user who queued the job.
+func (r *Resolver) RegisterJobs(queue *jobs.Queue) {
+	m := &mutationResolver{r}
+
+	queue.Register(model.JobKindBulkUpdateLeads, func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+		var job bulkUpdateJob
+		if err := json.Unmarshal(input, &job); err != nil {
+			return nil, err
+		}
+		jobs.Progress(ctx, 0, len(job.IDs))
+		return m.BulkUpdateLeads(ctx, job.IDs, job.Patch)
+	})
+
+	queue.Register(model.JobKindBulkTagLeads, func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+		var job bulkTagJob
+		if err := json.Unmarshal(input, &job); err != nil {
+			return nil, err
+		}
+		jobs.Progress(ctx, 0, len(job.IDs))
+		return m.BulkTagLeads(ctx, job.IDs, job.AddTags, job.RemoveTags)
+	})
+
+	queue.Register(model.JobKindBulkChangeStatus, func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+		var job bulkStatusJob
+		if err := json.Unmarshal(input, &job); err != nil {
+			return nil, err
+		}
+		jobs.Progress(ctx, 0, len(job.IDs))
+		return m.BulkChangeStatus(ctx, job.IDs, job.Status, job.Reason)
+	})
+
+	queue.Register(model.JobKindEnrollLeads, func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+		var job enrollJob
+		if err := json.Unmarshal(input, &job); err != nil {
+			return nil, err
+		}
+
+		enrolled := 0
+		for start := 0; start < len(job.LeadIDs); start += enrollJobBatchSize {
+			jobs.Progress(ctx, start, len(job.LeadIDs))
+			end := min(start+enrollJobBatchSize, len(job.LeadIDs))
+			enrollments, err := r.Campaigns.EnrollLeads(ctx, job.CampaignID, job.LeadIDs[start:end], currentUserID(ctx))
+			if err != nil {
+				return nil, err
+			}
+			enrolled += len(enrollments)
+		}
+		return enrolled, nil
+	})
+
+	queue.Register(model.JobKindClientReport, func(ctx context.Context, input json.RawMessage) (interface{}, error) {
+		var job clientReportJob
+		if err := json.Unmarshal(input, &job); err != nil {
+			return nil, err
+		}
+		jobs.Progress(ctx, 0, 1)
+		report, err := r.clientReport(ctx, job.ClientID, job.Period, job.Format)
+		if err != nil {
+			return nil, err
+		}
+		return report.File, nil
+	})
+}
+
+// The ...Async mutations check what they can before queueing, so that bad
+// input fails at once rather than in the job.
+
+func (r *mutationResolver) BulkUpdateLeadsAsync(ctx context.Context, ids []string, patch model.LeadPatchInput) (*model.Job, error) {
+	if err := checkBulkSize(ids); err != nil {
+		return nil, err
+	}
+	if patch.Timezone != nil {
+		if err := sendwindow.CheckTimezone(*patch.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	return r.Jobs.Enqueue(ctx, model.JobKindBulkUpdateLeads, bulkUpdateJob{IDs: ids, Patch: patch})
+}
+
+func (r *mutationResolver) BulkTagLeadsAsync(ctx context.Context, ids []string, addTags []string, removeTags []string) (*model.Job, error) {
+	if err := checkBulkSize(ids); err != nil {
+		return nil, err
+	}
+	if len(addTags) == 0 && len(removeTags) == 0 {
+		return nil, errors.New("addTags or removeTags is required")
+	}
+	return r.Jobs.Enqueue(ctx, model.JobKindBulkTagLeads, bulkTagJob{IDs: ids, AddTags: addTags, RemoveTags: removeTags})
+}
+
+func (r *mutationResolver) BulkChangeStatusAsync(ctx context.Context, ids []string, status model.LeadStatus, reason *string) (*model.Job, error) {
+	if err := checkBulkSize(ids); err != nil {
+		return nil, err
+	}
+	return r.Jobs.Enqueue(ctx, model.JobKindBulkChangeStatus, bulkStatusJob{IDs: ids, Status: status, Reason: reason})
+}
+
+func (r *mutationResolver) EnrollLeadsInCampaignAsync(ctx context.Context, campaignID string, leadIds []string) (*model.Job, error) {
+	if err := checkBulkSize(leadIds); err != nil {
+		return nil, err
+	}
+	return r.Jobs.Enqueue(ctx, model.JobKindEnrollLeads, enrollJob{CampaignID: campaignID, LeadIDs: leadIds})
+}
+
+func (r *mutationResolver) GenerateClientReportAsync(ctx context.Context, clientID string, period string, format *model.ReportFormat) (*model.Job, error) {
+	if user := auth.UserFromContext(ctx); user != nil && !user.CanAccessClient(clientID) {
+		return nil, auth.ErrForbidden
+	}
+	if _, err := reports.ParsePeriod(period); err != nil {
+		return nil, err
+	}
+	return r.Jobs.Enqueue(ctx, model.JobKindClientReport, clientReportJob{ClientID: clientID, Period: period, Format: format})
+}
+
+// Job returns the job if the user started it or manages the agency.
+func (r *queryResolver) Job(ctx context.Context, id string) (*model.Job, error) {
+	job, err := r.DB.GetJobByID(ctx, id)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	if !user.HasRole(auth.RoleAgencyManager) && (job.CreatedByID == nil || *job.CreatedByID != user.ID) {
+		return nil, nil
+	}
+	return job, nil
+}
+
+func (r *Resolver) Job() JobResolver {
+	return &jobResolver{r}
+}
+
+type jobResolver struct{ *Resolver }
+
+func (r *jobResolver) CreatedBy(ctx context.Context, obj *model.Job) (*model.User, error) {
+	if obj.CreatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.CreatedByID)
+}
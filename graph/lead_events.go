@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/masking"
+)
+
+const (
+	defaultLeadEventLimit = 100
+	maxLeadEventLimit     = 1000
+)
+
+func (r *leadResolver) Timeline(ctx context.Context, obj *model.Lead, limit *int) ([]*model.LeadEvent, error) {
+	return r.DB.GetLeadEvents(ctx, obj.ID, limit)
+}
+
+func (r *Resolver) LeadEvent() LeadEventResolver {
+	return &leadEventResolver{r}
+}
+
+type leadEventResolver struct{ *Resolver }
+
+func (r *leadEventResolver) Lead(ctx context.Context, obj *model.LeadEvent) (*model.Lead, error) {
+	return r.Store.Leads.GetLeadByID(ctx, obj.LeadID)
+}
+
+func (r *leadEventResolver) From(ctx context.Context, obj *model.LeadEvent) (*string, error) {
+	return r.maskEventValue(ctx, obj, obj.From)
+}
+
+func (r *leadEventResolver) To(ctx context.Context, obj *model.LeadEvent) (*string, error) {
+	return r.maskEventValue(ctx, obj, obj.To)
+}
+
+func (r *leadEventResolver) Actor(ctx context.Context, obj *model.LeadEvent) (*model.User, error) {
+	if obj.ActorID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.ActorID)
+}
+
+// maskEventValue masks a value of the event as the lead field it belongs to
+// is masked for the user: the changed field's, or each field of a CREATED
+// snapshot.
+func (r *leadEventResolver) maskEventValue(ctx context.Context, event *model.LeadEvent, value *string) (*string, error) {
+	user := auth.UserFromContext(ctx)
+	if value == nil || user == nil {
+		return value, nil
+	}
+
+	if event.Type != model.LeadEventTypeCreated {
+		if event.Field == nil {
+			return value, nil
+		}
+		kind, ok := r.Masking.Rule("Lead", *event.Field, user.Role)
+		if !ok {
+			return value, nil
+		}
+		masked := string(maskJSON(kind, json.RawMessage(*value)))
+		return &masked, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*value), &fields); err != nil {
+		return nil, err
+	}
+	for name, raw := range fields {
+		if kind, ok := r.Masking.Rule("Lead", name, user.Role); ok {
+			fields[name] = maskJSON(kind, raw)
+		}
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	masked := string(b)
+	return &masked, nil
+}
+
+// maskJSON masks a JSON string. Other values are left alone, as the field
+// interceptor leaves fields that are not strings.
+func maskJSON(kind masking.Kind, raw json.RawMessage) json.RawMessage {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return raw
+	}
+	masked, err := json.Marshal(masking.Mask(kind, s))
+	if err != nil {
+		return raw
+	}
+	return masked
+}
+
+func (r *queryResolver) LeadEvents(ctx context.Context, after *string, limit *int) ([]*model.LeadEvent, error) {
+	n := defaultLeadEventLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	if n > maxLeadEventLimit {
+		n = maxLeadEventLimit
+	}
+
+	cursor := ""
+	if after != nil {
+		cursor = *after
+	}
+	return r.DB.GetLeadEventsAfter(ctx, cursor, n)
+}
+
+func (r *queryResolver) LeadsByStageAsOf(ctx context.Context, at time.Time) ([]*model.StageCount, error) {
+	return r.DB.GetLeadStageCountsAsOf(ctx, at)
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/events"
+	"salesagency/internal/pipeline"
+)
+
+func (r *leadResolver) StatusHistory(ctx context.Context, obj *model.Lead) ([]*model.LeadStatusChange, error) {
+	return r.DB.GetLeadStatusHistory(ctx, obj.ID)
+}
+
+func (r *Resolver) LeadStatusChange() LeadStatusChangeResolver {
+	return &leadStatusChangeResolver{r}
+}
+
+type leadStatusChangeResolver struct{ *Resolver }
+
+func (r *leadStatusChangeResolver) ChangedBy(ctx context.Context, obj *model.LeadStatusChange) (*model.User, error) {
+	if obj.ChangedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.ChangedByID)
+}
+
+func (r *mutationResolver) ChangeLeadStatus(ctx context.Context, id string, status model.LeadStatus, reason *string, lossReason *model.LossReason) (*model.Lead, error) {
+	var lead *model.Lead
+	var err error
+	switch {
+	case status == model.LeadStatusLost:
+		lead, err = r.Pipeline.MarkLost(ctx, id, lossReason, reason, currentUserID(ctx))
+	case lossReason != nil:
+		return nil, pipeline.ErrLossReason
+	default:
+		lead, err = r.Pipeline.ChangeStatus(ctx, id, status, reason, currentUserID(ctx))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.Events.Publish(events.TopicLeadUpdated, lead)
+
+	return lead, nil
+}
+
+// currentUserID returns the authenticated user's ID for audit columns.
+func currentUserID(ctx context.Context) *string {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil
+	}
+	return &user.ID
+}
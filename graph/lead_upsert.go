@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/events"
+)
+
+// UpsertLead creates a lead or updates the one with the same email or phone,
+// so importers can write leads idempotently. Status and intent score only
+// apply to new leads.
+func (r *mutationResolver) UpsertLead(ctx context.Context, input model.LeadInput, matchOn *model.LeadMatchKey) (*model.Lead, error) {
+	key := model.LeadMatchKeyEmail
+	if matchOn != nil {
+		key = *matchOn
+	}
+	if key == model.LeadMatchKeyPhone && (input.Phone == nil || *input.Phone == "") {
+		return nil, errors.New("matching on phone requires a phone number")
+	}
+
+	lead, err := newLead(input)
+	if err != nil {
+		return nil, err
+	}
+
+	upserted, created, err := r.Store.Leads.UpsertLead(ctx, lead, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		r.Events.Publish(events.TopicLeadCreated, upserted)
+	} else {
+		r.Events.Publish(events.TopicLeadUpdated, upserted)
+	}
+
+	return upserted, nil
+}
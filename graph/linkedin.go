@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/channels"
+	"salesagency/internal/channels/linkedin"
+	"salesagency/internal/templates"
+)
+
+func (r *leadResolver) Linkedin(ctx context.Context, obj *model.Lead) (*model.LinkedInProfile, error) {
+	return r.DB.GetLinkedInProfile(ctx, obj.ID)
+}
+
+func (r *mutationResolver) SetLeadLinkedInProfile(ctx context.Context, leadID string, profileURL string) (*model.LinkedInProfile, error) {
+	normalized, err := linkedin.NormalizeProfileURL(profileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := r.DB.SetLinkedInProfile(ctx, leadID, normalized)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, errors.New("lead not found")
+	}
+	return profile, nil
+}
+
+func (r *mutationResolver) SendLinkedInMessage(ctx context.Context, leadID string, message *string, templateID *string) (*model.Interaction, error) {
+	if (message == nil) == (templateID == nil) {
+		return nil, errors.New("exactly one of message or templateId is required")
+	}
+
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	msg := &channels.Outbound{Lead: lead}
+	if message != nil {
+		msg.Body = *message
+	} else {
+		template, err := r.DB.GetMessageTemplateByID(ctx, *templateID)
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return nil, errors.New("message template not found")
+		}
+		if template.Channel != model.ChannelLinkedin {
+			return nil, errors.New("message template is not a LINKEDIN template")
+		}
+
+		content, err := r.DB.GetLocalizedTemplateContent(ctx, template, lead.Language)
+		if err != nil {
+			return nil, err
+		}
+		msg.Body, err = templates.Render(content, templates.LeadData(lead))
+		if err != nil {
+			return nil, err
+		}
+		msg.Subject = template.Name
+		msg.Template = template
+		msg.AIAgent = template.AIAgent
+	}
+
+	return r.Channels.Send(ctx, model.ChannelLinkedin, msg)
+}
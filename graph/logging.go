@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+)
+
+func (r *queryResolver) LogLevel(ctx context.Context) (model.LogLevel, error) {
+	return logLevelToModel(logging.Level()), nil
+}
+
+// SetLogLevel changes the log level of this instance only; it lasts until
+// the process restarts.
+func (r *mutationResolver) SetLogLevel(ctx context.Context, level model.LogLevel) (model.LogLevel, error) {
+	parsed, err := logging.ParseLevel(string(level))
+	if err != nil {
+		return "", err
+	}
+
+	previous := logging.Level()
+	logging.SetLevel(parsed)
+	logging.FromContext(ctx).Warn("log level changed", "from", previous.String(), "to", parsed.String())
+
+	return logLevelToModel(parsed), nil
+}
+
+func logLevelToModel(level slog.Level) model.LogLevel {
+	switch {
+	case level <= slog.LevelDebug:
+		return model.LogLevelDebug
+	case level <= slog.LevelInfo:
+		return model.LogLevelInfo
+	case level <= slog.LevelWarn:
+		return model.LogLevelWarn
+	default:
+		return model.LogLevelError
+	}
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"salesagency/internal/auth"
+	"salesagency/internal/masking"
+)
+
+// FieldMasking masks the fields its policy names for the user's role,
+// wherever they are resolved: in queries, mutation payloads and
+// subscriptions alike.
+type FieldMasking struct {
+	Policy masking.Policy
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = FieldMasking{}
+
+func (FieldMasking) ExtensionName() string {
+	return "FieldMasking"
+}
+
+// Validate refuses policies naming fields the schema does not have, so that
+// a typo does not leave a field unmasked.
+func (m FieldMasking) Validate(schema graphql.ExecutableSchema) error {
+	for field := range m.Policy {
+		typ, name, _ := strings.Cut(field, ".")
+		def := schema.Schema().Types[typ]
+		if def == nil || def.Fields.ForName(name) == nil {
+			return fmt.Errorf("masking policy names unknown field %s", field)
+		}
+	}
+	return nil
+}
+
+func (m FieldMasking) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return res, nil
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil {
+		return res, nil
+	}
+	kind, ok := m.Policy.Rule(fc.Object, fc.Field.Name, user.Role)
+	if !ok {
+		return res, nil
+	}
+
+	switch value := res.(type) {
+	case string:
+		return masking.Mask(kind, value), nil
+	case *string:
+		if value == nil {
+			return value, nil
+		}
+		masked := masking.Mask(kind, *value)
+		return &masked, nil
+	default:
+		// Only strings are masked; a policy naming another field leaves
+		// it alone.
+		return res, nil
+	}
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/calendar"
+	"salesagency/internal/events"
+	"salesagency/internal/tenant"
+)
+
+func (r *aiAgentResolver) CalendarID(ctx context.Context, obj *model.AIAgent) (*string, error) {
+	calendarID, err := r.DB.GetAIAgentCalendarID(ctx, obj.ID)
+	if err != nil || calendarID == "" {
+		return nil, err
+	}
+	return &calendarID, nil
+}
+
+func (r *queryResolver) AvailableSlots(ctx context.Context, agentID string, dateRange model.DateRangeInput) ([]*model.TimeSlot, error) {
+	slots, err := r.Calendar.AvailableSlots(ctx, agentID, dateRange.From, dateRange.To)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.TimeSlot, 0, len(slots))
+	for _, slot := range slots {
+		result = append(result, &model.TimeSlot{Start: slot.Start, End: slot.End})
+	}
+	return result, nil
+}
+
+func (r *mutationResolver) BookMeeting(ctx context.Context, leadID string, slot model.TimeSlotInput, agentID *string) (*model.Interaction, error) {
+	lead, interaction, err := r.Calendar.BookMeeting(ctx, leadID, agentID, calendar.Slot{Start: slot.Start, End: slot.End}, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Events.Publish(events.TopicLeadUpdated, lead)
+
+	return interaction, nil
+}
+
+func (r *queryResolver) MeetingRescheduleSlots(ctx context.Context, token string) ([]*model.TimeSlot, error) {
+	interactionID, err := r.MeetingLinks.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens carry no user, and interaction IDs are globally unique.
+	slots, err := r.Calendar.RescheduleSlots(tenant.WithSystem(ctx), interactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.TimeSlot, 0, len(slots))
+	for _, slot := range slots {
+		result = append(result, &model.TimeSlot{Start: slot.Start, End: slot.End})
+	}
+	return result, nil
+}
+
+func (r *mutationResolver) RescheduleMeeting(ctx context.Context, token string, slot model.TimeSlotInput) (*model.TimeSlot, error) {
+	interactionID, err := r.MeetingLinks.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	meeting, err := r.Calendar.RescheduleMeeting(tenant.WithSystem(ctx), interactionID, calendar.Slot{Start: slot.Start, End: slot.End})
+	if err != nil {
+		return nil, err
+	}
+	return &model.TimeSlot{Start: meeting.StartsAt, End: meeting.EndsAt}, nil
+}
+
+func (r *mutationResolver) CancelMeeting(ctx context.Context, token string, reason *string) (bool, error) {
+	interactionID, err := r.MeetingLinks.Verify(token)
+	if err != nil {
+		return false, err
+	}
+	if reason != nil && len([]rune(*reason)) > maxCommentLength {
+		return false, fmt.Errorf("reason must be at most %d characters", maxCommentLength)
+	}
+
+	if _, err := r.Calendar.CancelMeeting(tenant.WithSystem(ctx), interactionID, reason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) SetAIAgentCalendar(ctx context.Context, id string, calendarID *string) (*model.AIAgent, error) {
+	if calendarID != nil && *calendarID == "" {
+		calendarID = nil
+	}
+
+	ok, err := r.DB.SetAIAgentCalendarID(ctx, id, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("ai agent not found")
+	}
+
+	return r.DB.GetAIAgentByID(ctx, id)
+}
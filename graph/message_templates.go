@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/templates"
+)
+
+func (r *mutationResolver) CreateMessageTemplate(ctx context.Context, input model.MessageTemplateInput) (*model.MessageTemplate, error) {
+	template := &model.MessageTemplate{CreatedAt: time.Now()}
+	if err := r.applyTemplateInput(ctx, template, input); err != nil {
+		return nil, err
+	}
+
+	return r.DB.CreateMessageTemplate(ctx, template, currentUserID(ctx))
+}
+
+func (r *mutationResolver) UpdateMessageTemplate(ctx context.Context, id string, input model.MessageTemplateInput) (*model.MessageTemplate, error) {
+	template, err := r.DB.GetMessageTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("message template not found")
+	}
+
+	if err := r.applyTemplateInput(ctx, template, input); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template.UpdatedAt = &now
+
+	return r.saveMessageTemplate(ctx, template)
+}
+
+// RevertTemplate saves the content and variables of an earlier version as
+// the template's next version. The template keeps its ID, so interactions,
+// variants and metrics stay linked to it.
+func (r *mutationResolver) RevertTemplate(ctx context.Context, id string, version int) (*model.MessageTemplate, error) {
+	template, err := r.DB.GetMessageTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("message template not found")
+	}
+
+	earlier, err := r.DB.GetTemplateVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if earlier == nil {
+		return nil, fmt.Errorf("message template has no version %d", version)
+	}
+
+	template.Content = earlier.Content
+	template.Variables = earlier.Variables
+	now := time.Now()
+	template.UpdatedAt = &now
+
+	return r.saveMessageTemplate(ctx, template)
+}
+
+func (r *mutationResolver) saveMessageTemplate(ctx context.Context, template *model.MessageTemplate) (*model.MessageTemplate, error) {
+	saved, err := r.DB.UpdateMessageTemplate(ctx, template, currentUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, errors.New("message template not found")
+	}
+	return saved, nil
+}
+
+func (r *mutationResolver) DeleteMessageTemplate(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteMessageTemplate(ctx, id)
+}
+
+// applyTemplateInput validates the template syntax and references before
+// copying input onto template. When no variables are given they are taken
+// from the content.
+func (r *mutationResolver) applyTemplateInput(ctx context.Context, template *model.MessageTemplate, input model.MessageTemplateInput) error {
+	parsed, err := templates.Parse(input.Content)
+	if err != nil {
+		return fmt.Errorf("invalid template content: %w", err)
+	}
+
+	template.AIAgent = nil
+	if input.AIAgentID != nil {
+		agent, err := r.DB.GetAIAgentByID(ctx, *input.AIAgentID)
+		if err != nil {
+			return err
+		}
+		if agent == nil {
+			return errors.New("AI agent not found")
+		}
+		template.AIAgent = agent
+	}
+
+	template.Campaign = nil
+	if input.CampaignID != nil {
+		campaign, err := r.Store.Campaigns.GetCampaignByID(ctx, *input.CampaignID)
+		if err != nil {
+			return err
+		}
+		if campaign == nil {
+			return errors.New("campaign not found")
+		}
+		template.Campaign = campaign
+	}
+
+	template.Name = input.Name
+	template.Content = input.Content
+	template.Channel = input.Channel
+	template.Purpose = input.Purpose
+	template.Variables = input.Variables
+	if template.Variables == nil {
+		template.Variables = parsed.Variables()
+	}
+
+	return nil
+}
+
+func (r *queryResolver) PreviewTemplate(ctx context.Context, templateID string, leadID string) (string, error) {
+	template, err := r.DB.GetMessageTemplateByID(ctx, templateID)
+	if err != nil {
+		return "", err
+	}
+	if template == nil {
+		return "", errors.New("message template not found")
+	}
+
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return "", err
+	}
+	if lead == nil {
+		return "", errors.New("lead not found")
+	}
+
+	content, err := r.DB.GetLocalizedTemplateContent(ctx, template, lead.Language)
+	if err != nil {
+		return "", err
+	}
+	return templates.Render(content, templates.LeadData(lead))
+}
+
+func (r *messageTemplateResolver) Versions(ctx context.Context, obj *model.MessageTemplate) ([]*model.TemplateVersion, error) {
+	return r.DB.GetTemplateVersions(ctx, obj.ID)
+}
+
+func (r *interactionResolver) TemplateVersion(ctx context.Context, obj *model.Interaction) (*model.TemplateVersion, error) {
+	return r.DB.GetInteractionTemplateVersion(ctx, obj.ID)
+}
+
+func (r *Resolver) TemplateVersion() TemplateVersionResolver {
+	return &templateVersionResolver{r}
+}
+
+type templateVersionResolver struct{ *Resolver }
+
+func (r *templateVersionResolver) CreatedBy(ctx context.Context, obj *model.TemplateVersion) (*model.User, error) {
+	if obj.CreatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.CreatedByID)
+}
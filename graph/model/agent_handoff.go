@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// AgentHandoffRule hands the leads of one AI agent to another once they
+// meet its conditions: Status, MinIntentScore or both.
+type AgentHandoffRule struct {
+	ID             string      `json:"id"`
+	FromAgentID    string      `json:"-"`
+	ToAgentID      string      `json:"-"`
+	Status         *LeadStatus `json:"status,omitempty"`
+	MinIntentScore *float64    `json:"minIntentScore,omitempty"`
+	Enabled        bool        `json:"enabled"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      *time.Time  `json:"updatedAt,omitempty"`
+}
+
+// Matches reports whether the lead meets the rule's conditions.
+func (r *AgentHandoffRule) Matches(lead *Lead) bool {
+	if r.Status != nil && lead.Status != *r.Status {
+		return false
+	}
+	if r.MinIntentScore != nil && lead.IntentScore < *r.MinIntentScore {
+		return false
+	}
+	return true
+}
+
+// LeadAgentOwnership is a span of time during which an AI agent was
+// assigned a lead. UnassignedAt is nil while it still is. HandoffRuleID is
+// set when a handoff rule assigned it.
+type LeadAgentOwnership struct {
+	AgentID       string     `json:"-"`
+	HandoffRuleID *string    `json:"-"`
+	AssignedAt    time.Time  `json:"assignedAt"`
+	UnassignedAt  *time.Time `json:"unassignedAt,omitempty"`
+}
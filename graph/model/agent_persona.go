@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type AgentPersona struct {
+	ID          string    `json:"id"`
+	AgentID     string    `json:"-"`
+	Version     int       `json:"version"`
+	Tone        *string   `json:"tone,omitempty"`
+	Language    *string   `json:"language,omitempty"`
+	Signature   *string   `json:"signature,omitempty"`
+	Dos         []string  `json:"dos"`
+	Donts       []string  `json:"donts"`
+	CreatedByID *string   `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
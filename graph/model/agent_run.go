@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type AgentSchedule struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"-"`
+	Cron      string    `json:"cron"`
+	NextRunAt time.Time `json:"nextRunAt"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type AgentRun struct {
+	ID           string         `json:"id"`
+	AgentID      string         `json:"-"`
+	ScheduleID   *string        `json:"-"`
+	Status       AgentRunStatus `json:"status"`
+	ScheduledFor time.Time      `json:"scheduledFor"`
+	StartedAt    *time.Time     `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time     `json:"finishedAt,omitempty"`
+	DurationMs   *int           `json:"durationMs,omitempty"`
+	Error        *string        `json:"error,omitempty"`
+	// The counters are updated by the executor while the run is in progress
+	// and persisted when it finishes.
+	LeadsProcessed int       `json:"leadsProcessed"`
+	MessagesSent   int       `json:"messagesSent"`
+	ErrorCount     int       `json:"errorCount"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type AgentRunLog struct {
+	ID        string           `json:"id"`
+	RunID     string           `json:"-"`
+	Level     AgentRunLogLevel `json:"level"`
+	Message   string           `json:"message"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// AgentStats sums an AI agent's daily stats snapshots, over all time or
+// over a range broken into periods.
+type AgentStats struct {
+	ID                string             `json:"id"`
+	AgentID           string             `json:"-"`
+	Agent             *AIAgent           `json:"agent"`
+	LeadsEngaged      int                `json:"leadsEngaged"`
+	MessagesDelivered int                `json:"messagesDelivered"`
+	Replies           int                `json:"replies"`
+	Conversions       int                `json:"conversions"`
+	ResponseRate      float64            `json:"responseRate"`
+	ConversionRate    float64            `json:"conversionRate"`
+	AvgResponseTime   float64            `json:"avgResponseTime"`
+	Period            StatsPeriod        `json:"period"`
+	Series            []*AgentStatsPoint `json:"series"`
+	Previous          *AgentStatsPoint   `json:"previous,omitempty"`
+	Change            *AgentStatsChange  `json:"change,omitempty"`
+	CreatedAt         time.Time          `json:"createdAt"`
+}
+
+// AgentStatsPoint is an agent's stats over [Start, End).
+type AgentStatsPoint struct {
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	LeadsEngaged      int       `json:"leadsEngaged"`
+	MessagesDelivered int       `json:"messagesDelivered"`
+	Replies           int       `json:"replies"`
+	Conversions       int       `json:"conversions"`
+	ResponseRate      float64   `json:"responseRate"`
+	ConversionRate    float64   `json:"conversionRate"`
+	AvgResponseTime   float64   `json:"avgResponseTime"`
+}
+
+// AgentStatsChange holds the relative change of each stat from the previous
+// range, e.g. 0.25 for a quarter more. A stat that was zero has no change.
+type AgentStatsChange struct {
+	LeadsEngaged      *float64 `json:"leadsEngaged,omitempty"`
+	MessagesDelivered *float64 `json:"messagesDelivered,omitempty"`
+	Replies           *float64 `json:"replies,omitempty"`
+	Conversions       *float64 `json:"conversions,omitempty"`
+	ResponseRate      *float64 `json:"responseRate,omitempty"`
+	ConversionRate    *float64 `json:"conversionRate,omitempty"`
+	AvgResponseTime   *float64 `json:"avgResponseTime,omitempty"`
+}
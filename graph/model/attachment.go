@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type Attachment struct {
+	ID            string    `json:"id"`
+	LeadID        *string   `json:"-"`
+	InteractionID *string   `json:"-"`
+	StorageKey    string    `json:"-"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"contentType"`
+	Size          int       `json:"size"`
+	UploadedByID  *string   `json:"-"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
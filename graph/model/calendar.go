@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// CalendarEvent is an entry of the campaign calendar. Sequence sends and
+// agent runs are counted per day in Count; other events count one.
+type CalendarEvent struct {
+	ID         string            `json:"id"`
+	Kind       CalendarEventKind `json:"kind"`
+	Title      string            `json:"title"`
+	Start      time.Time         `json:"start"`
+	End        *time.Time        `json:"end"`
+	Count      int               `json:"count"`
+	CampaignID *string           `json:"-"`
+	LeadID     *string           `json:"-"`
+	AIAgentID  *string           `json:"-"`
+	SequenceID *string           `json:"-"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Call holds the details of a CALL interaction. Provider is "manual" for
+// calls logged with logCall.
+type Call struct {
+	InteractionID   string      `json:"-"`
+	Outcome         CallOutcome `json:"outcome"`
+	DurationSeconds *int        `json:"durationSeconds,omitempty"`
+	RecordingURL    *string     `json:"recordingUrl,omitempty"`
+	Provider        string      `json:"provider"`
+	ProviderCallID  *string     `json:"-"`
+	CreatedAt       time.Time   `json:"createdAt"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// CallTranscript is the transcript of a CALL interaction's recording.
+// AttachmentID is nil when the call's recording URL was transcribed.
+type CallTranscript struct {
+	InteractionID string           `json:"-"`
+	AttachmentID  *string          `json:"-"`
+	Status        TranscriptStatus `json:"status"`
+	Text          *string          `json:"text,omitempty"`
+	KeyPoints     []string         `json:"keyPoints"`
+	Attempts      int              `json:"attempts"`
+	LastError     *string          `json:"lastError,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	CompletedAt   *time.Time       `json:"completedAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// CampaignComment is a note a client leaves on one of their campaigns.
+type CampaignComment struct {
+	ID         string    `json:"id"`
+	CampaignID string    `json:"-"`
+	AuthorID   *string   `json:"-"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CampaignApproval is a client's sign-off on a campaign.
+type CampaignApproval struct {
+	CampaignID   string    `json:"-"`
+	ApprovedByID *string   `json:"-"`
+	ApprovedAt   time.Time `json:"approvedAt"`
+}
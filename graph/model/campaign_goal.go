@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type CampaignGoal struct {
+	CampaignID string             `json:"-"`
+	Metric     CampaignGoalMetric `json:"metric"`
+	Target     int                `json:"target"`
+	Days       int                `json:"days"`
+	StartsAt   time.Time          `json:"startsAt"`
+	// AtRiskSince is set while the goal is projected to be missed.
+	AtRiskSince *time.Time `json:"atRiskSince,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+// EndsAt is when the goal's window closes.
+func (g *CampaignGoal) EndsAt() time.Time {
+	return g.StartsAt.AddDate(0, 0, g.Days)
+}
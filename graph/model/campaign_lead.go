@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// CampaignLead is a lead's enrollment in a campaign.
+type CampaignLead struct {
+	CampaignID   string             `json:"-"`
+	LeadID       string             `json:"-"`
+	Status       CampaignLeadStatus `json:"status"`
+	EnrolledByID *string            `json:"-"`
+	EnrolledAt   time.Time          `json:"enrolledAt"`
+	UpdatedAt    *time.Time         `json:"updatedAt,omitempty"`
+}
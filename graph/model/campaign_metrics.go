@@ -0,0 +1,25 @@
+package model
+
+// ChannelMetrics are a campaign's results on one channel. A lead reached on
+// several channels counts towards each of them.
+type ChannelMetrics struct {
+	Channel        Channel `json:"channel"`
+	LeadsGenerated int     `json:"leadsGenerated"`
+	MessagesSent   int     `json:"messagesSent"`
+	Replies        int     `json:"replies"`
+	Conversions    int     `json:"conversions"`
+	ReplyRate      float64 `json:"replyRate"`
+	ConversionRate float64 `json:"conversionRate"`
+}
+
+// AudienceMetrics are a campaign's results among the leads of one of its
+// target audiences.
+type AudienceMetrics struct {
+	Audience       *TargetAudience `json:"audience"`
+	LeadsGenerated int             `json:"leadsGenerated"`
+	MessagesSent   int             `json:"messagesSent"`
+	Replies        int             `json:"replies"`
+	Conversions    int             `json:"conversions"`
+	ReplyRate      float64         `json:"replyRate"`
+	ConversionRate float64         `json:"conversionRate"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// CampaignRouting is a campaign's share of the inbound leads captured from
+// web forms, and the rules a lead must meet to be given to it.
+type CampaignRouting struct {
+	CampaignID   string   `json:"-"`
+	CampaignName string   `json:"-"`
+	Weight       int      `json:"weight"`
+	Industries   []string `json:"industries"`
+	Regions      []string `json:"regions"`
+	Enabled      bool     `json:"enabled"`
+	// Credit is the campaign's balance in the weighted round robin.
+	Credit    int        `json:"-"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// AssignmentLogEntry records why an inbound lead landed in a campaign.
+type AssignmentLogEntry struct {
+	ID         string                 `json:"id"`
+	LeadID     string                 `json:"-"`
+	CampaignID *string                `json:"-"`
+	Reason     AssignmentReason       `json:"reason"`
+	Industry   *string                `json:"industry,omitempty"`
+	Timezone   *string                `json:"timezone,omitempty"`
+	Candidates []*AssignmentCandidate `json:"candidates"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+// AssignmentCandidate is a campaign considered for an inbound lead. It is
+// stored with the log entry as it was at the time.
+type AssignmentCandidate struct {
+	CampaignID   string  `json:"campaignId"`
+	CampaignName string  `json:"campaignName"`
+	Weight       int     `json:"weight"`
+	Eligible     bool    `json:"eligible"`
+	Reason       *string `json:"reason,omitempty"`
+	Credit       *int    `json:"credit,omitempty"`
+}
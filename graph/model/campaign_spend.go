@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type CampaignSpend struct {
+	ID            string    `json:"id"`
+	CampaignID    string    `json:"-"`
+	InteractionID *string   `json:"-"`
+	Kind          SpendKind `json:"kind"`
+	Amount        float64   `json:"amount"`
+	Description   *string   `json:"description,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// CampaignTemplate is a reusable campaign setup saved from a campaign. The
+// counts describe what instantiating it creates.
+type CampaignTemplate struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Description      *string   `json:"description,omitempty"`
+	SourceCampaignID *string   `json:"-"`
+	TargetCount      int       `json:"targetCount"`
+	MessageCount     int       `json:"messageCount"`
+	SequenceCount    int       `json:"sequenceCount"`
+	AIAgentCount     int       `json:"aiAgentCount"`
+	CreatedByID      *string   `json:"-"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
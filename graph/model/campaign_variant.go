@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type CampaignVariant struct {
+	ID         string    `json:"id"`
+	CampaignID string    `json:"-"`
+	TemplateID string    `json:"-"`
+	Weight     int       `json:"weight"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
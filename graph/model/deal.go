@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type Deal struct {
+	ID                string     `json:"id"`
+	Name              string     `json:"name"`
+	LeadID            *string    `json:"-"`
+	ClientID          *string    `json:"-"`
+	CampaignID        *string    `json:"-"`
+	Value             float64    `json:"value"`
+	Currency          string     `json:"currency"`
+	Stage             DealStage  `json:"stage"`
+	ExpectedCloseDate *time.Time `json:"expectedCloseDate,omitempty"`
+	ClosedAt          *time.Time `json:"closedAt,omitempty"`
+	CreatedByID       *string    `json:"-"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// IsClosed reports whether the deal was won or lost.
+func (d *Deal) IsClosed() bool {
+	return d.Stage == DealStageWon || d.Stage == DealStageLost
+}
+
+// Amount returns the deal's value as Money.
+func (d *Deal) Amount() Money {
+	return NewMoney(d.Value, d.Currency)
+}
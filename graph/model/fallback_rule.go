@@ -0,0 +1,12 @@
+package model
+
+// ChannelFallbackRule switches a campaign's sequence steps on Channel to
+// FallbackChannel for leads Trigger applies to. Rules are stored as JSON, so
+// the template is kept by ID.
+type ChannelFallbackRule struct {
+	Channel         Channel         `json:"channel"`
+	Trigger         FallbackTrigger `json:"trigger"`
+	Threshold       int             `json:"threshold"`
+	FallbackChannel Channel         `json:"fallbackChannel"`
+	TemplateID      *string         `json:"templateId,omitempty"`
+}
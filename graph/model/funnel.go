@@ -0,0 +1,10 @@
+package model
+
+// FunnelCohort is the conversion funnel of the leads with one source or in
+// one campaign.
+type FunnelCohort struct {
+	Source     *string        `json:"source,omitempty"`
+	CampaignID *string        `json:"-"`
+	TotalLeads int            `json:"totalLeads"`
+	Stages     []*FunnelStage `json:"stages"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type Job struct {
+	ID       string    `json:"id"`
+	Kind     JobKind   `json:"kind"`
+	Status   JobStatus `json:"status"`
+	Progress int       `json:"progress"`
+	Total    *int      `json:"total,omitempty"`
+	// Result is the JSON the job's mutation returned.
+	Result      *string    `json:"result,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	CreatedByID *string    `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// LeadAttribution records where a lead was first captured from.
+type LeadAttribution struct {
+	LeadID      string    `json:"-"`
+	UtmSource   *string   `json:"utmSource,omitempty"`
+	UtmMedium   *string   `json:"utmMedium,omitempty"`
+	UtmCampaign *string   `json:"utmCampaign,omitempty"`
+	UtmTerm     *string   `json:"utmTerm,omitempty"`
+	UtmContent  *string   `json:"utmContent,omitempty"`
+	Referrer    *string   `json:"referrer,omitempty"`
+	LandingPage *string   `json:"landingPage,omitempty"`
+	CampaignID  *string   `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// AttributionSource totals the leads created in a period with the same UTM
+// source, medium and campaign. Converted counts those now WON.
+type AttributionSource struct {
+	UtmSource      *string `json:"utmSource,omitempty"`
+	UtmMedium      *string `json:"utmMedium,omitempty"`
+	UtmCampaign    *string `json:"utmCampaign,omitempty"`
+	Leads          int     `json:"leads"`
+	Qualified      int     `json:"qualified"`
+	Converted      int     `json:"converted"`
+	ConversionRate float64 `json:"conversionRate"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// LeadEvent is one change in a lead's history. From and To hold the values
+// as JSON.
+type LeadEvent struct {
+	ID         string        `json:"id"`
+	LeadID     string        `json:"leadId"`
+	Type       LeadEventType `json:"type"`
+	Field      *string       `json:"field,omitempty"`
+	From       *string       `json:"from,omitempty"`
+	To         *string       `json:"to,omitempty"`
+	ActorID    *string       `json:"-"`
+	OccurredAt time.Time     `json:"occurredAt"`
+	Cursor     string        `json:"cursor"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// LeadFilterInput is declared here rather than generated because saved
+// filters, such as a Segment's, are returned as the LeadFilter type in the
+// shape they were given. It is stored as JSON.
+type LeadFilterInput struct {
+	Status            []LeadStatus            `json:"status,omitempty"`
+	MinIntentScore    *float64                `json:"minIntentScore,omitempty"`
+	Tags              []string                `json:"tags,omitempty"`
+	ExcludeTags       []string                `json:"excludeTags,omitempty"`
+	Source            *string                 `json:"source,omitempty"`
+	CompanyContains   *string                 `json:"companyContains,omitempty"`
+	LastContactAfter  *time.Time              `json:"lastContactAfter,omitempty"`
+	LastContactBefore *time.Time              `json:"lastContactBefore,omitempty"`
+	CreatedAfter      *time.Time              `json:"createdAfter,omitempty"`
+	CreatedBefore     *time.Time              `json:"createdBefore,omitempty"`
+	Text              *string                 `json:"text,omitempty"`
+	Replied           *bool                   `json:"replied,omitempty"`
+	CustomField       *CustomFieldFilterInput `json:"customField,omitempty"`
+}
+
+// CustomFieldFilterInput is LeadFilterInput's custom field condition, and
+// is returned as CustomFieldFilter for the same reason.
+type CustomFieldFilterInput struct {
+	Key   string        `json:"key"`
+	Op    CustomFieldOp `json:"op"`
+	Value *string       `json:"value,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// LeadHandoff is a lead escalated from AI outreach to a human sales rep.
+// Status follows from PickedUpAt and ResolvedAt.
+type LeadHandoff struct {
+	ID            string        `json:"id"`
+	LeadID        string        `json:"-"`
+	RepID         string        `json:"-"`
+	EscalatedByID *string       `json:"-"`
+	Reason        string        `json:"reason"`
+	Status        HandoffStatus `json:"status"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	PickedUpAt    *time.Time    `json:"pickedUpAt"`
+	ResolvedAt    *time.Time    `json:"resolvedAt"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type LeadStatusChange struct {
+	ID          string      `json:"id"`
+	LeadID      string      `json:"-"`
+	FromStatus  LeadStatus  `json:"fromStatus"`
+	ToStatus    LeadStatus  `json:"toStatus"`
+	Reason      *string     `json:"reason,omitempty"`
+	LossReason  *LossReason `json:"lossReason,omitempty"`
+	ChangedByID *string     `json:"-"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
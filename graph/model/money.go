@@ -0,0 +1,38 @@
+package model
+
+import "math"
+
+// Money is an amount in the minor units of its currency, such as cents of
+// USD or yen of JPY, so it adds up without rounding errors.
+type Money struct {
+	MinorUnits int64  `json:"minorUnits"`
+	Currency   string `json:"currency"`
+}
+
+// minorDigits lists the ISO 4217 currencies without two minor digits.
+var minorDigits = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// MinorDigits returns how many digits of the currency's amounts follow the
+// decimal point.
+func MinorDigits(currency string) int {
+	if digits, ok := minorDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// NewMoney rounds amount, in major units such as dollars, to the currency's
+// minor units.
+func NewMoney(amount float64, currency string) Money {
+	scale := math.Pow10(MinorDigits(currency))
+	return Money{MinorUnits: int64(math.Round(amount * scale)), Currency: currency}
+}
+
+// Amount returns the amount in major units.
+func (m Money) Amount() float64 {
+	return float64(m.MinorUnits) / math.Pow10(MinorDigits(m.Currency))
+}
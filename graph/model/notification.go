@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Notification is an in-app notification for one user.
+type Notification struct {
+	ID        string           `json:"id"`
+	UserID    string           `json:"-"`
+	Kind      NotificationKind `json:"kind"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body"`
+	SubjectID *string          `json:"subjectId,omitempty"`
+	ReadAt    *time.Time       `json:"readAt,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
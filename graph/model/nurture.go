@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// NurturePolicy enrolls the leads a campaign loses in a nurture sequence.
+// LossReason is nil for the policy that covers every reason without its own.
+type NurturePolicy struct {
+	ID         string      `json:"id"`
+	CampaignID string      `json:"-"`
+	LossReason *LossReason `json:"lossReason,omitempty"`
+	SequenceID string      `json:"-"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  *time.Time  `json:"updatedAt,omitempty"`
+}
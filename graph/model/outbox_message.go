@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// OutboxMessage is a side effect waiting in, or dead-lettered from, the
+// outbox. Payload is JSON whose shape depends on Kind.
+type OutboxMessage struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	Payload     string     `json:"payload"`
+	Attempts    int        `json:"attempts"`
+	LastError   *string    `json:"lastError,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	DeadAt      *time.Time `json:"deadAt,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+type Pipeline struct {
+	Stages         []*PipelineStage `json:"stages"`
+	TotalCount     int              `json:"totalCount"`
+	PotentialValue float64          `json:"potentialValue"`
+}
+
+type PipelineStage struct {
+	Status             LeadStatus `json:"status"`
+	Count              int        `json:"count"`
+	PotentialValue     float64    `json:"potentialValue"`
+	AverageDaysInStage *float64   `json:"averageDaysInStage,omitempty"`
+	LeadIDs            []string   `json:"-"`
+}
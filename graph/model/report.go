@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+type ClientReport struct {
+	Client         *Client                 `json:"client"`
+	Period         string                  `json:"period"`
+	PeriodStart    time.Time               `json:"periodStart"`
+	PeriodEnd      time.Time               `json:"periodEnd"`
+	LeadsGenerated int                     `json:"leadsGenerated"`
+	MessagesSent   int                     `json:"messagesSent"`
+	Replies        int                     `json:"replies"`
+	MeetingsBooked int                     `json:"meetingsBooked"`
+	Conversions    int                     `json:"conversions"`
+	Spend          float64                 `json:"spend"`
+	Currency       string                  `json:"currency"`
+	Campaigns      []*ClientReportCampaign `json:"campaigns"`
+	Agents         []*ClientReportAgent    `json:"agents"`
+	File           *ReportFile             `json:"file"`
+}
+
+type ClientReportCampaign struct {
+	CampaignID     string         `json:"-"`
+	Name           string         `json:"name"`
+	Status         CampaignStatus `json:"status"`
+	LeadsGenerated int            `json:"leadsGenerated"`
+	MessagesSent   int            `json:"messagesSent"`
+	Replies        int            `json:"replies"`
+	MeetingsBooked int            `json:"meetingsBooked"`
+	Conversions    int            `json:"conversions"`
+	Spend          float64        `json:"spend"`
+	Currency       string         `json:"currency"`
+	// BaseSpend is Spend in the agency's base currency.
+	BaseSpend float64 `json:"-"`
+}
+
+type ClientReportAgent struct {
+	AgentID        string `json:"-"`
+	Name           string `json:"name"`
+	LeadsEngaged   int    `json:"leadsEngaged"`
+	MessagesSent   int    `json:"messagesSent"`
+	Replies        int    `json:"replies"`
+	MeetingsBooked int    `json:"meetingsBooked"`
+}
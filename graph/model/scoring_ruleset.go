@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type ScoringRuleset struct {
+	ID        string     `json:"id"`
+	ClientID  string     `json:"-"`
+	Name      string     `json:"name"`
+	Rules     string     `json:"rules"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
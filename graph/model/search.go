@@ -0,0 +1,8 @@
+package model
+
+type SearchHit struct {
+	Type     SearchType `json:"type"`
+	ResultID string     `json:"-"`
+	Rank     float64    `json:"rank"`
+	Snippet  *string    `json:"snippet,omitempty"`
+}
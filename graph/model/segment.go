@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Segment is a named, saved lead filter. LeadCount is as of CountedAt.
+type Segment struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Filter      *LeadFilterInput `json:"filter"`
+	LeadCount   *int             `json:"leadCount,omitempty"`
+	CountedAt   *time.Time       `json:"countedAt,omitempty"`
+	CreatedByID *string          `json:"-"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// SendingIdentity is an address a client's campaigns email leads from. Mail
+// is only sent from it once both its domain and its DKIM key are verified.
+type SendingIdentity struct {
+	ID                string            `json:"id"`
+	ClientID          string            `json:"-"`
+	FromName          string            `json:"fromName"`
+	FromEmail         string            `json:"fromEmail"`
+	Domain            string            `json:"domain"`
+	DkimSelector      string            `json:"dkimSelector"`
+	VerificationToken string            `json:"-"`
+	DomainStatus      DomainCheckStatus `json:"domainStatus"`
+	DkimStatus        DomainCheckStatus `json:"dkimStatus"`
+	IsDefault         bool              `json:"isDefault"`
+	LastCheckedAt     *time.Time        `json:"lastCheckedAt"`
+	VerifiedAt        *time.Time        `json:"verifiedAt"`
+	CheckError        *string           `json:"checkError"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	UpdatedAt         *time.Time        `json:"updatedAt,omitempty"`
+}
+
+// Verified reports whether mail may be sent from the identity.
+func (s *SendingIdentity) Verified() bool {
+	return s.DomainStatus == DomainCheckStatusVerified && s.DkimStatus == DomainCheckStatusVerified
+}
+
+// DNSRecord is a record a client publishes to verify a sending identity.
+type DNSRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type Sequence struct {
+	ID         string     `json:"id"`
+	CampaignID string     `json:"-"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+type SequenceStep struct {
+	ID         string  `json:"id"`
+	SequenceID string  `json:"-"`
+	Position   int     `json:"position"`
+	Day        int     `json:"day"`
+	Channel    Channel `json:"channel"`
+	TemplateID *string `json:"-"`
+	Task       *string `json:"task,omitempty"`
+}
+
+type SequenceEnrollment struct {
+	ID          string           `json:"id"`
+	SequenceID  string           `json:"-"`
+	LeadID      string           `json:"-"`
+	Status      EnrollmentStatus `json:"status"`
+	CurrentStep int              `json:"currentStep"`
+	NextRunAt   *time.Time       `json:"nextRunAt,omitempty"`
+	EnrolledAt  time.Time        `json:"enrolledAt"`
+	UpdatedAt   *time.Time       `json:"updatedAt,omitempty"`
+	// Nurture is set on enrollments started when the lead was lost.
+	Nurture bool `json:"nurture"`
+}
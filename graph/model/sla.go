@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// SLA is a response time commitment to a client, counted in working hours.
+// WorkingHours holds a send window as JSON; without one the clock always
+// runs.
+type SLA struct {
+	ID            string     `json:"id"`
+	ClientID      string     `json:"-"`
+	Name          string     `json:"name"`
+	Kind          SLAKind    `json:"kind"`
+	TargetMinutes int        `json:"targetMinutes"`
+	WorkingHours  *string    `json:"-"`
+	Enabled       bool       `json:"enabled"`
+	EnabledAt     *time.Time `json:"enabledAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+// SLACheck is one inbound reply or escalation an SLA applies to. Exactly one
+// of InteractionID and HandoffID is set, following the SLA's kind.
+type SLACheck struct {
+	ID            string     `json:"id"`
+	SLAID         string     `json:"-"`
+	LeadID        string     `json:"-"`
+	InteractionID *string    `json:"-"`
+	HandoffID     *string    `json:"-"`
+	StartedAt     time.Time  `json:"startedAt"`
+	DueAt         time.Time  `json:"dueAt"`
+	RespondedAt   *time.Time `json:"respondedAt"`
+	BreachedAt    *time.Time `json:"breachedAt"`
+}
+
+type SLAReport struct {
+	Client         *Client          `json:"client"`
+	Period         string           `json:"period"`
+	PeriodStart    time.Time        `json:"periodStart"`
+	PeriodEnd      time.Time        `json:"periodEnd"`
+	Checks         int              `json:"checks"`
+	Met            int              `json:"met"`
+	Breached       int              `json:"breached"`
+	Compliance     *float64         `json:"compliance"`
+	SLAs           []*SLACompliance `json:"slas"`
+	WorstOffenders []*SLACheck      `json:"worstOffenders"`
+}
+
+type SLACompliance struct {
+	SLAID      string   `json:"-"`
+	Checks     int      `json:"checks"`
+	Met        int      `json:"met"`
+	Breached   int      `json:"breached"`
+	Compliance *float64 `json:"compliance"`
+}
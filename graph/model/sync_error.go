@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type SyncError struct {
+	ID         string        `json:"id"`
+	LeadID     *string       `json:"-"`
+	Provider   string        `json:"provider"`
+	Kind       SyncErrorKind `json:"kind"`
+	Message    string        `json:"message"`
+	DetectedAt time.Time     `json:"detectedAt"`
+	ResolvedAt *time.Time    `json:"resolvedAt,omitempty"`
+}
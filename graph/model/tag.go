@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Tag is a tag in the agency's taxonomy. Leads carry tags by Name, which is
+// unique within the agency regardless of case. UsageCount is how many live
+// leads carry it.
+type Tag struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Color      *string    `json:"color,omitempty"`
+	UsageCount int        `json:"usageCount"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// TemplateTranslation is a message template's content in another locale.
+type TemplateTranslation struct {
+	TemplateID string     `json:"-"`
+	Locale     string     `json:"locale"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
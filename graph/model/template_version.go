@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type TemplateVersion struct {
+	ID          string    `json:"id"`
+	TemplateID  string    `json:"-"`
+	Version     int       `json:"version"`
+	Name        string    `json:"name"`
+	Content     string    `json:"content"`
+	Variables   []string  `json:"variables"`
+	Channel     Channel   `json:"channel"`
+	Purpose     string    `json:"purpose"`
+	CreatedByID *string   `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// TopCampaign is a campaign's results over a dashboard period.
+type TopCampaign struct {
+	CampaignID     string  `json:"-"`
+	LeadsContacted int     `json:"leadsContacted"`
+	MessagesSent   int     `json:"messagesSent"`
+	Replies        int     `json:"replies"`
+	MeetingsBooked int     `json:"meetingsBooked"`
+	Conversions    int     `json:"conversions"`
+	ReplyRate      float64 `json:"replyRate"`
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/events"
+	"time"
+)
+
+func (r *queryResolver) Notifications(ctx context.Context, unreadOnly *bool, limit *int, offset *int) ([]*model.Notification, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	return r.DB.GetNotifications(ctx, user.ID, unreadOnly != nil && *unreadOnly, limit, offset)
+}
+
+func (r *queryResolver) UnreadNotificationCount(ctx context.Context) (int, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return 0, auth.ErrUnauthenticated
+	}
+	return r.DB.CountUnreadNotifications(ctx, user.ID)
+}
+
+func (r *queryResolver) NotificationPreferences(ctx context.Context) ([]*model.NotificationPreference, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	return r.Notifier.Preferences(ctx, user.ID)
+}
+
+func (r *queryResolver) SlackWebhookURL(ctx context.Context) (*string, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	webhookURL, err := r.DB.GetSlackWebhookURL(ctx, user.ID)
+	if err != nil || webhookURL == "" {
+		return nil, err
+	}
+	return &webhookURL, nil
+}
+
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []string) (int, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return 0, auth.ErrUnauthenticated
+	}
+	return r.DB.MarkNotificationsRead(ctx, user.ID, ids, time.Now())
+}
+
+func (r *mutationResolver) SetNotificationPreference(ctx context.Context, input model.NotificationPreferenceInput) (*model.NotificationPreference, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	preference := &model.NotificationPreference{
+		Kind:  input.Kind,
+		InApp: input.InApp,
+		Email: input.Email,
+		Slack: input.Slack,
+	}
+	if err := r.DB.SetNotificationPreference(ctx, user.ID, preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (r *mutationResolver) SetSlackWebhookURL(ctx context.Context, webhookURL *string) (bool, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return false, auth.ErrUnauthenticated
+	}
+
+	if webhookURL != nil && *webhookURL == "" {
+		webhookURL = nil
+	}
+	if webhookURL != nil {
+		parsed, err := url.Parse(*webhookURL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return false, errors.New("url must be an https URL")
+		}
+	}
+
+	if err := r.DB.SetSlackWebhookURL(ctx, user.ID, webhookURL); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *subscriptionResolver) NotificationReceived(ctx context.Context) (<-chan *model.Notification, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	source := r.Events.Subscribe(ctx, events.TopicNotificationCreated)
+	out := make(chan *model.Notification, 1)
+
+	go func() {
+		defer close(out)
+		for event := range source {
+			notification, ok := event.Payload.(*model.Notification)
+			if !ok || notification.UserID != user.ID {
+				continue
+			}
+
+			select {
+			case out <- notification:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
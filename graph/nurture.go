@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/sequence"
+)
+
+func (r *campaignResolver) NurturePolicies(ctx context.Context, obj *model.Campaign) ([]*model.NurturePolicy, error) {
+	return r.DB.GetNurturePolicies(ctx, obj.ID)
+}
+
+func (r *leadResolver) LossReason(ctx context.Context, obj *model.Lead) (*model.LossReason, error) {
+	if obj.Status != model.LeadStatusLost {
+		return nil, nil
+	}
+	return r.DB.GetLeadLossReason(ctx, obj.ID)
+}
+
+func (r *Resolver) NurturePolicy() NurturePolicyResolver {
+	return &nurturePolicyResolver{r}
+}
+
+type nurturePolicyResolver struct{ *Resolver }
+
+func (r *nurturePolicyResolver) Campaign(ctx context.Context, obj *model.NurturePolicy) (*model.Campaign, error) {
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, obj.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *nurturePolicyResolver) Sequence(ctx context.Context, obj *model.NurturePolicy) (*model.Sequence, error) {
+	seq, err := r.DB.GetSequenceByID(ctx, obj.SequenceID)
+	if err != nil {
+		return nil, err
+	}
+	if seq == nil {
+		return nil, sequence.ErrNotFound
+	}
+	return seq, nil
+}
+
+func (r *mutationResolver) SetNurturePolicy(ctx context.Context, campaignID string, lossReason *model.LossReason, sequenceID string) (*model.NurturePolicy, error) {
+	return r.Nurture.SetPolicy(ctx, campaignID, lossReason, sequenceID)
+}
+
+func (r *mutationResolver) RemoveNurturePolicy(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteNurturePolicy(ctx, id)
+}
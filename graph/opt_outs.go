@@ -0,0 +1,16 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/dataloader"
+)
+
+func (r *leadResolver) OptedOutChannels(ctx context.Context, obj *model.Lead) ([]model.Channel, error) {
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.OptOutsByLeadID.Load(ctx, obj.ID)
+}
@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+)
+
+const defaultDeadLetterLimit = 50
+
+func (r *queryResolver) DeadLetters(ctx context.Context, limit *int) ([]*model.OutboxMessage, error) {
+	n := defaultDeadLetterLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	return r.DB.GetDeadOutboxMessages(ctx, n)
+}
+
+func (r *mutationResolver) RetryDeadLetter(ctx context.Context, id string) (*model.OutboxMessage, error) {
+	msg, err := r.DB.RequeueOutboxMessage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, errors.New("dead letter not found")
+	}
+	return msg, nil
+}
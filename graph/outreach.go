@@ -0,0 +1,24 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+)
+
+func (r *mutationResolver) GenerateOutreachDraft(ctx context.Context, leadID string, agentID string) ([]*model.OutreachDraft, error) {
+	return r.Conversations.DraftOutreach(ctx, leadID, agentID)
+}
+
+func (r *mutationResolver) ResendInteraction(ctx context.Context, id string) (*model.Interaction, error) {
+	interaction, err := r.Channels.Resend(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if interaction == nil {
+		return nil, errors.New("interaction not found")
+	}
+
+	return interaction, nil
+}
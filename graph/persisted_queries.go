@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"salesagency/internal/persisted"
+)
+
+// PersistedQueries runs operations registered in its manifest by hash, sent
+// the way automatic persisted queries are: as
+// {"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "..."}}}
+// with no query. Unless AllowUnlisted is set, it refuses every other
+// operation, whether sent by hash or in full.
+//
+// Use it ahead of extension.AutomaticPersistedQuery, which then only caches
+// what this lets through.
+type PersistedQueries struct {
+	Manifest      persisted.Manifest
+	AllowUnlisted bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = PersistedQueries{}
+
+func (PersistedQueries) ExtensionName() string {
+	return "PersistedQueries"
+}
+
+func (PersistedQueries) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (p PersistedQueries) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	var hash string
+	if ext, ok := rawParams.Extensions["persistedQuery"].(map[string]interface{}); ok {
+		hash, _ = ext["sha256Hash"].(string)
+		hash = strings.ToLower(hash)
+	}
+
+	if rawParams.Query == "" && hash != "" {
+		if query, ok := p.Manifest[hash]; ok {
+			rawParams.Query = query
+			return nil
+		}
+		if p.AllowUnlisted {
+			return nil
+		}
+		// The message and code automatic persisted query clients expect.
+		return &gqlerror.Error{
+			Message:    "PersistedQueryNotFound",
+			Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"},
+		}
+	}
+
+	if p.AllowUnlisted {
+		return nil
+	}
+	if _, ok := p.Manifest[persisted.Hash(rawParams.Query)]; !ok {
+		return &gqlerror.Error{
+			Message:    "operation is not a registered persisted query",
+			Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_LISTED"},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+)
+
+func (r *queryResolver) Pipeline(ctx context.Context, clientID *string, campaignID *string, leadsPerStage *int) (*model.Pipeline, error) {
+	if leadsPerStage != nil && *leadsPerStage < 0 {
+		return nil, errors.New("leadsPerStage must not be negative")
+	}
+	// The method shadows the embedded Resolver's Pipeline service.
+	return r.Resolver.Pipeline.Board(ctx, clientID, campaignID, leadsPerStage)
+}
+
+func (r *Resolver) PipelineStage() PipelineStageResolver {
+	return &pipelineStageResolver{r}
+}
+
+type pipelineStageResolver struct{ *Resolver }
+
+func (r *pipelineStageResolver) Leads(ctx context.Context, obj *model.PipelineStage) ([]*model.Lead, error) {
+	if len(obj.LeadIDs) == 0 {
+		return []*model.Lead{}, nil
+	}
+	return r.Store.Leads.GetLeadsByIDs(ctx, obj.LeadIDs)
+}
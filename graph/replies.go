@@ -0,0 +1,19 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/dataloader"
+)
+
+func (r *interactionResolver) ReplyIntent(ctx context.Context, obj *model.Interaction) (*model.ReplyIntent, error) {
+	if obj.Direction != model.InteractionDirectionInbound {
+		return nil, nil
+	}
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.IntentByReplyID.Load(ctx, obj.ID)
+}
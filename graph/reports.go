@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/reports"
+)
+
+func (r *mutationResolver) GenerateClientReport(ctx context.Context, clientID string, period string, format *model.ReportFormat) (*model.ClientReport, error) {
+	return r.clientReport(ctx, clientID, period, format)
+}
+
+func (r *Resolver) clientReport(ctx context.Context, clientID string, period string, format *model.ReportFormat) (*model.ClientReport, error) {
+	if user := auth.UserFromContext(ctx); user != nil && !user.CanAccessClient(clientID) {
+		return nil, auth.ErrForbidden
+	}
+
+	p, err := reports.ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := r.Reports.Generate(ctx, clientID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	f := model.ReportFormatPDF
+	if format != nil {
+		f = *format
+	}
+	file, err := reports.Render(report, f)
+	if err != nil {
+		return nil, err
+	}
+
+	report.File = &model.ReportFile{
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		Content:     base64.StdEncoding.EncodeToString(file.Data),
+	}
+	return report, nil
+}
+
+func (r *Resolver) ClientReportCampaign() ClientReportCampaignResolver {
+	return &clientReportCampaignResolver{r}
+}
+
+type clientReportCampaignResolver struct{ *Resolver }
+
+func (r *clientReportCampaignResolver) Campaign(ctx context.Context, obj *model.ClientReportCampaign) (*model.Campaign, error) {
+	campaign, err := r.Store.Campaigns.GetCampaignByID(ctx, obj.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("campaign not found")
+	}
+	return campaign, nil
+}
+
+func (r *Resolver) ClientReportAgent() ClientReportAgentResolver {
+	return &clientReportAgentResolver{r}
+}
+
+type clientReportAgentResolver struct{ *Resolver }
+
+func (r *clientReportAgentResolver) Agent(ctx context.Context, obj *model.ClientReportAgent) (*model.AIAgent, error) {
+	agent, err := r.DB.GetAIAgentByID(ctx, obj.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, errors.New("AI agent not found")
+	}
+	return agent, nil
+}
@@ -2,13 +2,83 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"salesagency/graph/model"
+	"salesagency/internal/assignment"
+	"salesagency/internal/auth"
+	"salesagency/internal/calendar"
+	"salesagency/internal/calls"
+	"salesagency/internal/campaign"
+	"salesagency/internal/channels"
+	"salesagency/internal/conversation"
 	"salesagency/internal/database"
+	"salesagency/internal/dataloader"
+	"salesagency/internal/distribution"
+	"salesagency/internal/events"
+	"salesagency/internal/export"
+	"salesagency/internal/identity"
+	"salesagency/internal/jobs"
+	"salesagency/internal/leadquery"
+	"salesagency/internal/masking"
+	"salesagency/internal/money"
+	"salesagency/internal/notifications"
+	"salesagency/internal/nurture"
+	"salesagency/internal/pipeline"
+	"salesagency/internal/reports"
+	"salesagency/internal/retention"
+	"salesagency/internal/salesforce"
+	"salesagency/internal/scheduler"
+	"salesagency/internal/scoring"
+	"salesagency/internal/sequence"
+	"salesagency/internal/sendwindow"
+	"salesagency/internal/slack"
+	"salesagency/internal/storage"
+	"salesagency/internal/templates"
 	"time"
 )
 
 type Resolver struct {
 	DB *database.DB
+	// Store reads and writes leads, clients and campaigns; the other data
+	// is still read through DB.
+	Store         database.Repositories
+	Events        *events.Broker
+	Tokens        *auth.TokenService
+	Channels      *channels.Dispatcher
+	Scoring       *scoring.Engine
+	Campaigns     *campaign.Service
+	Sequences     *sequence.Engine
+	Pipeline      *pipeline.Service
+	Nurture       *nurture.Service
+	Conversations *conversation.Engine
+	Reports       *reports.Service
+	Calendar      *calendar.Service
+	MeetingLinks  *calendar.Signer
+	Exports       *export.Signer
+	Salesforce    *salesforce.Service
+	Calls         *calls.Service
+	Retention     *retention.Service
+	Assignment    *assignment.Service
+	Distribution  *distribution.Service
+	Money         *money.Converter
+	Notifier      *notifications.Service
+	Jobs          *jobs.Queue
+	LeadQueries   *leadquery.Planner
+	// HandoffTarget is how soon reps should pick up leads escalated to them.
+	HandoffTarget time.Duration
+	// Storage keeps attachment files. It is nil when no bucket is
+	// configured.
+	Storage      storage.Store
+	UploadLimits storage.Limits
+	// SlackLinks is nil when the Slack app is off.
+	SlackLinks *slack.Linker
+	// Identities verifies the addresses clients' campaigns email leads
+	// from.
+	Identities *identity.Service
+	// Masking is applied to lead values in lead events, which the field
+	// interceptor cannot tell apart.
+	Masking masking.Policy
 }
 
 func (r *Resolver) Lead() LeadResolver {
@@ -18,7 +88,15 @@ func (r *Resolver) Lead() LeadResolver {
 type leadResolver struct{ *Resolver }
 
 func (r *leadResolver) Interactions(ctx context.Context, obj *model.Lead) ([]*model.Interaction, error) {
-	return r.DB.GetInteractionsByLeadID(ctx, obj.ID)
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.InteractionsByLeadID.Load(ctx, obj.ID)
+}
+
+func (r *leadResolver) IntentScoreHistory(ctx context.Context, obj *model.Lead, limit *int) ([]*model.IntentScoreEntry, error) {
+	return r.DB.GetIntentScoreHistory(ctx, obj.ID, limit)
 }
 
 func (r *Resolver) Client() ClientResolver {
@@ -28,11 +106,15 @@ func (r *Resolver) Client() ClientResolver {
 type clientResolver struct{ *Resolver }
 
 func (r *clientResolver) ActiveServices(ctx context.Context, obj *model.Client) ([]*model.Service, error) {
-	return r.DB.GetServicesByClientID(ctx, obj.ID)
+	return r.Store.Clients.GetServicesByClientID(ctx, obj.ID)
 }
 
 func (r *clientResolver) Campaigns(ctx context.Context, obj *model.Client) ([]*model.Campaign, error) {
-	return r.DB.GetCampaignsByClientID(ctx, obj.ID)
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.CampaignsByClientID.Load(ctx, obj.ID)
 }
 
 func (r *Resolver) AIAgent() AIAgentResolver {
@@ -42,19 +124,39 @@ func (r *Resolver) AIAgent() AIAgentResolver {
 type aiAgentResolver struct{ *Resolver }
 
 func (r *aiAgentResolver) Leads(ctx context.Context, obj *model.AIAgent) ([]*model.Lead, error) {
-	return r.DB.GetLeadsByAIAgentID(ctx, obj.ID)
+	return r.Store.Leads.GetLeadsByAIAgentID(ctx, obj.ID)
 }
 
 func (r *aiAgentResolver) Campaigns(ctx context.Context, obj *model.AIAgent) ([]*model.Campaign, error) {
-	return r.DB.GetCampaignsByAIAgentID(ctx, obj.ID)
+	return r.Store.Campaigns.GetCampaignsByAIAgentID(ctx, obj.ID)
 }
 
 func (r *aiAgentResolver) Templates(ctx context.Context, obj *model.AIAgent) ([]*model.MessageTemplate, error) {
 	return r.DB.GetTemplatesByAIAgentID(ctx, obj.ID)
 }
 
-func (r *aiAgentResolver) Stats(ctx context.Context, obj *model.AIAgent) (*model.AgentStats, error) {
-	return r.DB.GetAgentStats(ctx, obj.ID)
+func (r *aiAgentResolver) Schedules(ctx context.Context, obj *model.AIAgent) ([]*model.AgentSchedule, error) {
+	return r.DB.GetSchedulesByAIAgentID(ctx, obj.ID)
+}
+
+func (r *Resolver) AgentSchedule() AgentScheduleResolver {
+	return &agentScheduleResolver{r}
+}
+
+type agentScheduleResolver struct{ *Resolver }
+
+func (r *agentScheduleResolver) Agent(ctx context.Context, obj *model.AgentSchedule) (*model.AIAgent, error) {
+	return r.DB.GetAIAgentByID(ctx, obj.AgentID)
+}
+
+func (r *Resolver) AgentRun() AgentRunResolver {
+	return &agentRunResolver{r}
+}
+
+type agentRunResolver struct{ *Resolver }
+
+func (r *agentRunResolver) Agent(ctx context.Context, obj *model.AgentRun) (*model.AIAgent, error) {
+	return r.DB.GetAIAgentByID(ctx, obj.AgentID)
 }
 
 func (r *Resolver) Campaign() CampaignResolver {
@@ -67,11 +169,15 @@ func (r *campaignResolver) Client(ctx context.Context, obj *model.Campaign) (*mo
 	if obj.ClientID == nil {
 		return nil, nil
 	}
-	return r.DB.GetClientByID(ctx, *obj.ClientID)
+	return r.Store.Clients.GetClientByID(ctx, *obj.ClientID)
 }
 
 func (r *campaignResolver) Targets(ctx context.Context, obj *model.Campaign) ([]*model.TargetAudience, error) {
-	return r.DB.GetTargetsByCampaignID(ctx, obj.ID)
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.TargetsByCampaignID.Load(ctx, obj.ID)
 }
 
 func (r *campaignResolver) Messages(ctx context.Context, obj *model.Campaign) ([]*model.MessageTemplate, error) {
@@ -83,7 +189,11 @@ func (r *campaignResolver) AIAgents(ctx context.Context, obj *model.Campaign) ([
 }
 
 func (r *campaignResolver) Metrics(ctx context.Context, obj *model.Campaign) (*model.CampaignMetrics, error) {
-	return r.DB.GetCampaignMetrics(ctx, obj.ID)
+	loaders, err := dataloader.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.MetricsByCampaignID.Load(ctx, obj.ID)
 }
 
 func (r *Resolver) Mutation() MutationResolver {
@@ -93,6 +203,24 @@ func (r *Resolver) Mutation() MutationResolver {
 type mutationResolver struct{ *Resolver }
 
 func (r *mutationResolver) CreateLead(ctx context.Context, input model.LeadInput) (*model.Lead, error) {
+	lead, err := newLead(input)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.Store.Leads.CreateLead(ctx, lead)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Events.Publish(events.TopicLeadCreated, created)
+
+	return created, nil
+}
+
+// newLead builds a lead to create from input, with the defaults for a new
+// lead.
+func newLead(input model.LeadInput) (*model.Lead, error) {
 	lead := &model.Lead{
 		Name:       input.Name,
 		Email:      input.Email,
@@ -102,9 +230,20 @@ func (r *mutationResolver) CreateLead(ctx context.Context, input model.LeadInput
 		Tags:       input.Tags,
 		Source:     input.Source,
 		Notes:      input.Notes,
+		DealValue:  input.DealValue,
+		Timezone:   input.Timezone,
+		Language:   input.Language,
 		CreatedAt:  time.Now(),
 	}
 	
+	if input.Timezone != nil {
+		if err := sendwindow.CheckTimezone(*input.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	sendwindow.DetectTimezone(lead)
+	templates.DetectLanguage(lead)
+	
 	if input.Status != nil {
 		lead.Status = *input.Status
 	} else {
@@ -117,16 +256,30 @@ func (r *mutationResolver) CreateLead(ctx context.Context, input model.LeadInput
 	} else {
 		lead.IntentScore = 0.5
 	}
-	
-	return r.DB.CreateLead(ctx, lead)
+
+	return lead, nil
 }
 
-func (r *mutationResolver) UpdateLead(ctx context.Context, id string, input model.LeadInput) (*model.Lead, error) {
-	lead, err := r.DB.GetLeadByID(ctx, id)
+// errUpdateLeadLost points edits that mark a lead LOST to changeLeadStatus,
+// which takes the loss reason nurture enrolls the lead by. LOST leads cannot
+// be reopened by an edit either, as pipeline.Validate allows no move out of
+// LOST.
+var errUpdateLeadLost = errors.New("leads are marked LOST with changeLeadStatus")
+
+func (r *mutationResolver) UpdateLead(ctx context.Context, id string, input model.LeadInput, version int) (*model.Lead, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+	if lead.Version != version {
+		return nil, conflictError(ctx, lead)
+	}
 	
+	previousScore := lead.IntentScore
+	previousStatus := lead.Status
 	lead.Name = input.Name
 	lead.Email = input.Email
 	
@@ -140,6 +293,12 @@ func (r *mutationResolver) UpdateLead(ctx context.Context, id string, input mode
 		lead.Position = input.Position
 	}
 	if input.Status != nil {
+		if *input.Status == model.LeadStatusLost && lead.Status != model.LeadStatusLost {
+			return nil, errUpdateLeadLost
+		}
+		if err := pipeline.Validate(lead.Status, *input.Status); err != nil {
+			return nil, err
+		}
 		lead.Status = *input.Status
 	}
 	if input.IntentScore != nil {
@@ -154,19 +313,200 @@ func (r *mutationResolver) UpdateLead(ctx context.Context, id string, input mode
 	if input.Notes != nil {
 		lead.Notes = input.Notes
 	}
+	if input.DealValue != nil {
+		lead.DealValue = input.DealValue
+	}
+	if input.Timezone != nil {
+		if err := sendwindow.CheckTimezone(*input.Timezone); err != nil {
+			return nil, err
+		}
+		lead.Timezone = input.Timezone
+	}
+	sendwindow.DetectTimezone(lead)
+	if input.Language != nil {
+		lead.Language = input.Language
+	}
+	templates.DetectLanguage(lead)
 	
 	lead.UpdatedAt = &time.Time{}
 	*lead.UpdatedAt = time.Now()
 	
-	return r.DB.UpdateLead(ctx, lead)
+	updatedLead, err := r.Store.Leads.UpdateLead(ctx, lead)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return nil, r.leadConflict(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if updatedLead.IntentScore != previousScore {
+		if err := r.Scoring.RecordManual(ctx, updatedLead, previousScore); err != nil {
+			return nil, err
+		}
+	}
+
+	if updatedLead.Status != previousStatus {
+		err = r.Store.Leads.RecordLeadStatusChange(ctx, updatedLead.ID, previousStatus, updatedLead.Status, nil, currentUserID(ctx))
+		if err != nil {
+			return nil, err
+		}
+		r.Pipeline.StatusChanged(ctx, updatedLead.ID, updatedLead.Status)
+	}
+
+	r.Events.Publish(events.TopicLeadUpdated, updatedLead)
+
+	return updatedLead, nil
 }
 
 func (r *mutationResolver) DeleteLead(ctx context.Context, id string) (bool, error) {
-	return r.DB.DeleteLead(ctx, id)
+	return r.Store.Leads.DeleteLead(ctx, id)
 }
 
 func (r *mutationResolver) AssignLeadToAIAgent(ctx context.Context, leadID string, aiAgentID string) (*model.Lead, error) {
-	return r.DB.AssignLeadToAIAgent(ctx, leadID, aiAgentID)
+	lead, err := r.Store.Leads.AssignLeadToAIAgent(ctx, leadID, aiAgentID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead or AI agent not found")
+	}
+
+	r.Events.Publish(events.TopicLeadUpdated, lead)
+
+	return lead, nil
+}
+
+func (r *mutationResolver) RecalculateIntentScores(ctx context.Context, leadIds []string) ([]*model.Lead, error) {
+	leads, err := r.Scoring.Recalculate(ctx, leadIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lead := range leads {
+		r.Events.Publish(events.TopicLeadUpdated, lead)
+	}
+
+	return leads, nil
+}
+
+func (r *mutationResolver) SendEmailToLead(ctx context.Context, leadID string, templateID string) (*model.Interaction, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	template, err := r.DB.GetMessageTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("message template not found")
+	}
+	if template.Channel != model.ChannelEmail {
+		return nil, errors.New("message template is not an email template")
+	}
+
+	content, err := r.DB.GetLocalizedTemplateContent(ctx, template, lead.Language)
+	if err != nil {
+		return nil, err
+	}
+	body, err := templates.Render(content, templates.LeadData(lead))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Channels.Send(ctx, model.ChannelEmail, &channels.Outbound{
+		Lead:     lead,
+		Subject:  template.Name,
+		Body:     body,
+		Template: template,
+		AIAgent:  template.AIAgent,
+	})
+}
+
+func (r *mutationResolver) SendSMSToLead(ctx context.Context, leadID string, message *string, templateID *string, whatsapp *bool) (*model.Interaction, error) {
+	if (message == nil) == (templateID == nil) {
+		return nil, errors.New("exactly one of message or templateId is required")
+	}
+
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	channel := model.ChannelSms
+	if whatsapp != nil && *whatsapp {
+		channel = model.ChannelWhatsapp
+	}
+
+	msg := &channels.Outbound{Lead: lead}
+	if message != nil {
+		msg.Body = *message
+	} else {
+		template, err := r.DB.GetMessageTemplateByID(ctx, *templateID)
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return nil, errors.New("message template not found")
+		}
+		if template.Channel != channel {
+			return nil, fmt.Errorf("message template is not a %s template", channel)
+		}
+
+		content, err := r.DB.GetLocalizedTemplateContent(ctx, template, lead.Language)
+		if err != nil {
+			return nil, err
+		}
+		msg.Body, err = templates.Render(content, templates.LeadData(lead))
+		if err != nil {
+			return nil, err
+		}
+		msg.Template = template
+		msg.AIAgent = template.AIAgent
+	}
+
+	return r.Channels.Send(ctx, channel, msg)
+}
+
+func (r *mutationResolver) CreateInteraction(ctx context.Context, input model.InteractionInput) (*model.Interaction, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, input.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	now := time.Now()
+	interaction := &model.Interaction{
+		Lead:      lead,
+		Type:      input.Type,
+		Channel:   input.Channel,
+		Message:   input.Message,
+		Timestamp: now,
+		Status:    model.InteractionStatusDelivered,
+		Notes:     input.Notes,
+		CreatedAt: now,
+	}
+
+	if input.Status != nil {
+		interaction.Status = *input.Status
+	}
+	if input.AIAgentID != nil {
+		interaction.AIAgent = &model.AIAgent{ID: *input.AIAgentID}
+	}
+	if input.TemplateID != nil {
+		interaction.Template = &model.MessageTemplate{ID: *input.TemplateID}
+	}
+
+	return r.Channels.Record(ctx, interaction)
 }
 
 func (r *mutationResolver) CreateClient(ctx context.Context, input model.ClientInput) (*model.Client, error) {
@@ -190,13 +530,13 @@ func (r *mutationResolver) CreateClient(ctx context.Context, input model.ClientI
 		client.Status = defaultStatus
 	}
 	
-	newClient, err := r.DB.CreateClient(ctx, client)
+	newClient, err := r.Store.Clients.CreateClient(ctx, client)
 	if err != nil {
 		return nil, err
 	}
 	
 	if input.ServiceIds != nil {
-		err = r.DB.AssignServicesToClient(ctx, newClient.ID, input.ServiceIds)
+		err = r.Store.Clients.AssignServicesToClient(ctx, newClient.ID, input.ServiceIds)
 		if err != nil {
 			return nil, err
 		}
@@ -205,26 +545,170 @@ func (r *mutationResolver) CreateClient(ctx context.Context, input model.ClientI
 	return newClient, nil
 }
 
+func (r *mutationResolver) UpdateClient(ctx context.Context, id string, input model.ClientInput, version int) (*model.Client, error) {
+	client, err := r.Store.Clients.GetClientByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+	if client.Version != version {
+		return nil, conflictError(ctx, client)
+	}
+
+	client.Name = input.Name
+	client.Industry = input.Industry
+	client.ContactPerson = input.ContactPerson
+	client.Email = input.Email
+	client.StartDate = input.StartDate
+
+	if input.Website != nil {
+		client.Website = input.Website
+	}
+	if input.Phone != nil {
+		client.Phone = input.Phone
+	}
+	if input.Address != nil {
+		client.Address = input.Address
+	}
+	if input.Notes != nil {
+		client.Notes = input.Notes
+	}
+	if input.Status != nil && *input.Status != client.Status {
+		if *input.Status == model.ClientStatusArchived {
+			return nil, errors.New("use archiveClient to archive a client")
+		}
+		client.Status = *input.Status
+	}
+
+	client.UpdatedAt = &time.Time{}
+	*client.UpdatedAt = time.Now()
+
+	updatedClient, err := r.Store.Clients.UpdateClient(ctx, client)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return nil, r.clientConflict(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ServiceIds != nil {
+		err = r.Store.Clients.SetClientServices(ctx, updatedClient.ID, input.ServiceIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return updatedClient, nil
+}
+
+func (r *mutationResolver) ArchiveClient(ctx context.Context, id string) (*model.Client, error) {
+	archived, err := r.Store.Clients.ArchiveClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		return nil, errors.New("client not found or already archived")
+	}
+	return r.Store.Clients.GetClientByID(ctx, id)
+}
+
+func (r *mutationResolver) DeleteClient(ctx context.Context, id string) (bool, error) {
+	return r.Store.Clients.DeleteClient(ctx, id)
+}
+
+func (r *mutationResolver) Login(ctx context.Context, email string, password string) (*model.AuthPayload, error) {
+	user, credentials, err := r.DB.GetUserCredentialsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !auth.CheckPassword(credentials.PasswordHash, password) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if user.Status != model.UserStatusActive {
+		return nil, auth.ErrInactiveAccount
+	}
+
+	clientIDs, err := r.Store.Clients.GetClientIDsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := r.Tokens.Issue(&auth.User{
+		ID:        user.ID,
+		Email:     user.Email,
+		Role:      auth.Role(user.Role),
+		AgencyID:  credentials.AgencyID,
+		ClientIDs: clientIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AuthPayload{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	}, nil
+}
+
 func (r *Resolver) Query() QueryResolver {
 	return &queryResolver{r}
 }
 
 type queryResolver struct{ *Resolver }
 
-func (r *queryResolver) Lead(ctx context.Context, id string) (*model.Lead, error) {
-	return r.DB.GetLeadByID(ctx, id)
+func (r *queryResolver) Lead(ctx context.Context, id string, includeDeleted *bool) (*model.Lead, error) {
+	ctx, err := withDeleted(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return r.Store.Leads.GetLeadByID(ctx, id)
 }
 
-func (r *queryResolver) Leads(ctx context.Context, filter *model.LeadFilterInput, limit *int, offset *int) ([]*model.Lead, error) {
-	return r.DB.GetLeadsByFilter(ctx, filter, limit, offset)
+func (r *queryResolver) Leads(ctx context.Context, filter *model.LeadFilterInput, limit *int, offset *int, includeDeleted *bool) ([]*model.Lead, error) {
+	ctx, err := withDeleted(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return r.Store.Leads.GetLeadsByFilter(ctx, filter, limit, offset)
 }
 
-func (r *queryResolver) Client(ctx context.Context, id string) (*model.Client, error) {
-	return r.DB.GetClientByID(ctx, id)
+func (r *queryResolver) Client(ctx context.Context, id string, includeDeleted *bool) (*model.Client, error) {
+	if user := auth.UserFromContext(ctx); user != nil && !user.CanAccessClient(id) {
+		return nil, auth.ErrForbidden
+	}
+	ctx, err := withDeleted(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return r.Store.Clients.GetClientByID(ctx, id)
 }
 
-func (r *queryResolver) Clients(ctx context.Context, status *model.ClientStatus, limit *int, offset *int) ([]*model.Client, error) {
-	return r.DB.GetClientsByStatus(ctx, status, limit, offset)
+func (r *queryResolver) Clients(ctx context.Context, status *model.ClientStatus, limit *int, offset *int, includeDeleted *bool) ([]*model.Client, error) {
+	ctx, err := withDeleted(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := r.Store.Clients.GetClientsByStatus(ctx, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil || !user.IsClientScoped() {
+		return clients, nil
+	}
+
+	visible := make([]*model.Client, 0, len(clients))
+	for _, client := range clients {
+		if user.CanAccessClient(client.ID) {
+			visible = append(visible, client)
+		}
+	}
+	return visible, nil
 }
 
 func (r *queryResolver) AIAgent(ctx context.Context, id string) (*model.AIAgent, error) {
@@ -236,15 +720,111 @@ func (r *queryResolver) AIAgents(ctx context.Context, status *model.AgentStatus,
 }
 
 func (r *queryResolver) Campaign(ctx context.Context, id string) (*model.Campaign, error) {
-	return r.DB.GetCampaignByID(ctx, id)
+	campaign, err := r.Store.Campaigns.GetCampaignByID(ctx, id)
+	if err != nil || campaign == nil {
+		return campaign, err
+	}
+
+	if !canAccessCampaign(auth.UserFromContext(ctx), campaign) {
+		return nil, auth.ErrForbidden
+	}
+	return campaign, nil
 }
 
 func (r *queryResolver) Campaigns(ctx context.Context, filter *model.CampaignFilterInput, limit *int, offset *int) ([]*model.Campaign, error) {
-	return r.DB.GetCampaignsByFilter(ctx, filter, limit, offset)
+	campaigns, err := r.Store.Campaigns.GetCampaignsByFilter(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil || !user.IsClientScoped() {
+		return campaigns, nil
+	}
+
+	visible := make([]*model.Campaign, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		if canAccessCampaign(user, campaign) {
+			visible = append(visible, campaign)
+		}
+	}
+	return visible, nil
+}
+
+func canAccessCampaign(user *auth.User, campaign *model.Campaign) bool {
+	if user == nil || !user.IsClientScoped() {
+		return true
+	}
+	return campaign.ClientID != nil && user.CanAccessClient(*campaign.ClientID)
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, user.ID)
+}
+
+func (r *mutationResolver) StartCampaign(ctx context.Context, id string) (*model.Campaign, error) {
+	return r.Campaigns.Start(ctx, id)
+}
+
+func (r *mutationResolver) PauseCampaign(ctx context.Context, id string) (*model.Campaign, error) {
+	return r.Campaigns.Pause(ctx, id)
+}
+
+func (r *mutationResolver) CompleteCampaign(ctx context.Context, id string) (*model.Campaign, error) {
+	return r.Campaigns.Complete(ctx, id)
+}
+
+func (r *mutationResolver) CancelCampaign(ctx context.Context, id string) (*model.Campaign, error) {
+	return r.Campaigns.Cancel(ctx, id)
 }
 
 func (r *mutationResolver) TriggerAIAgentRun(ctx context.Context, id string) (bool, error) {
-	return r.DB.TriggerAIAgentRun(ctx, id)
+	agent, err := r.DB.GetAIAgentByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if agent == nil {
+		return false, errors.New("AI agent not found")
+	}
+
+	if _, err := r.DB.EnqueueAgentRun(ctx, id, nil, time.Now()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) ScheduleAIAgentRun(ctx context.Context, agentID string, cron string) (*model.AgentSchedule, error) {
+	agent, err := r.DB.GetAIAgentByID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, errors.New("AI agent not found")
+	}
+
+	now := time.Now()
+	nextRunAt, err := scheduler.NextRun(cron, now)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &model.AgentSchedule{
+		AgentID:   agentID,
+		Cron:      cron,
+		NextRunAt: nextRunAt,
+		Enabled:   true,
+		CreatedAt: now,
+	}
+
+	return r.DB.CreateAgentSchedule(ctx, schedule)
+}
+
+func (r *mutationResolver) CancelScheduledRun(ctx context.Context, scheduleID string) (bool, error) {
+	return r.DB.DisableAgentSchedule(ctx, scheduleID)
 }
 
 func (r *mutationResolver) PauseAIAgent(ctx context.Context, id string) (bool, error) {
@@ -253,4 +833,114 @@ func (r *mutationResolver) PauseAIAgent(ctx context.Context, id string) (bool, e
 
 func (r *mutationResolver) ResumeAIAgent(ctx context.Context, id string) (bool, error) {
 	return r.DB.UpdateAIAgentStatus(ctx, id, model.AgentStatusActive)
-}
\ No newline at end of file
+}
+
+func (r *Resolver) Subscription() SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
+
+type subscriptionResolver struct{ *Resolver }
+
+func (r *subscriptionResolver) LeadCreated(ctx context.Context) (<-chan *model.Lead, error) {
+	return r.leadStream(ctx, r.Events.Subscribe(ctx, events.TopicLeadCreated), nil), nil
+}
+
+func (r *subscriptionResolver) LeadUpdated(ctx context.Context, leadID *string) (<-chan *model.Lead, error) {
+	return r.leadStream(ctx, r.Events.Subscribe(ctx, events.TopicLeadUpdated), leadID), nil
+}
+
+func (r *subscriptionResolver) LeadReplied(ctx context.Context, leadID *string) (<-chan *model.Interaction, error) {
+	source := r.Events.Subscribe(ctx, events.TopicLeadReplied)
+	out := make(chan *model.Interaction, 1)
+
+	go func() {
+		defer close(out)
+		for event := range source {
+			reply, ok := event.Payload.(*model.Interaction)
+			if !ok {
+				continue
+			}
+			if leadID != nil && reply.Lead.ID != *leadID {
+				continue
+			}
+			// Replies to leads the subscriber cannot see are never sent.
+			if lead, err := r.Store.Leads.GetLeadByID(ctx, reply.Lead.ID); err != nil || lead == nil {
+				continue
+			}
+
+			select {
+			case out <- reply:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (r *subscriptionResolver) HighIntentLeadDetected(ctx context.Context, threshold float64) (<-chan *model.Lead, error) {
+	if threshold < 0 || threshold > 1 {
+		return nil, errors.New("threshold must be between 0 and 1")
+	}
+
+	source := r.Events.Subscribe(ctx, events.TopicLeadIntentScoreChanged)
+	out := make(chan *model.Lead, 1)
+
+	go func() {
+		defer close(out)
+		for event := range source {
+			change, ok := event.Payload.(*database.IntentScoreChange)
+			if !ok || change.Previous >= threshold || change.Lead.IntentScore < threshold {
+				continue
+			}
+
+			// Reload through the subscriber's context so leads of other
+			// agencies are never sent.
+			lead, err := r.Store.Leads.GetLeadByID(ctx, change.Lead.ID)
+			if err != nil || lead == nil {
+				continue
+			}
+
+			select {
+			case out <- lead:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// leadStream sends the leads of source's events, reloaded through the
+// subscriber's context as HighIntentLeadDetected does, so leads of other
+// agencies or clients are never sent.
+func (r *subscriptionResolver) leadStream(ctx context.Context, source <-chan events.Event, leadID *string) <-chan *model.Lead {
+	out := make(chan *model.Lead, 1)
+
+	go func() {
+		defer close(out)
+		for event := range source {
+			changed, ok := event.Payload.(*model.Lead)
+			if !ok {
+				continue
+			}
+			if leadID != nil && changed.ID != *leadID {
+				continue
+			}
+			lead, err := r.Store.Leads.GetLeadByID(ctx, changed.ID)
+			if err != nil || lead == nil {
+				continue
+			}
+
+			select {
+			case out <- lead:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,24 @@
+package graph
+
+import (
+	"context"
+	"errors"
+)
+
+func (r *mutationResolver) ForgetLead(ctx context.Context, id string) (bool, error) {
+	return r.Retention.ForgetLead(ctx, id)
+}
+
+func (r *queryResolver) LeadRetentionDays(ctx context.Context) (*int, error) {
+	return r.DB.GetLeadRetentionDays(ctx)
+}
+
+func (r *mutationResolver) SetLeadRetention(ctx context.Context, days *int) (*int, error) {
+	if days != nil && *days <= 0 {
+		return nil, errors.New("retention must be at least one day")
+	}
+	if err := r.DB.SetLeadRetentionDays(ctx, days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
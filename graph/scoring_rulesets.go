@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/scoring"
+)
+
+func (r *Resolver) ScoringRuleset() ScoringRulesetResolver {
+	return &scoringRulesetResolver{r}
+}
+
+type scoringRulesetResolver struct{ *Resolver }
+
+func (r *scoringRulesetResolver) Client(ctx context.Context, obj *model.ScoringRuleset) (*model.Client, error) {
+	client, err := r.Store.Clients.GetClientByID(ctx, obj.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+	return client, nil
+}
+
+func (r *queryResolver) ScoringRuleset(ctx context.Context, id string) (*model.ScoringRuleset, error) {
+	return r.DB.GetScoringRulesetByID(ctx, id)
+}
+
+func (r *queryResolver) ScoringRulesets(ctx context.Context, clientID *string) ([]*model.ScoringRuleset, error) {
+	return r.DB.GetScoringRulesets(ctx, clientID)
+}
+
+// SimulateScore scores the lead with the ruleset, active or not, without
+// saving the result.
+func (r *queryResolver) SimulateScore(ctx context.Context, leadID string, rulesetID string) (*model.ScoreSimulation, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	ruleset, err := r.getScoringRuleset(ctx, rulesetID)
+	if err != nil {
+		return nil, err
+	}
+
+	score, contributions, err := r.Scoring.Simulate(ctx, lead, ruleset.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := &model.ScoreSimulation{
+		Lead:         lead,
+		Ruleset:      ruleset,
+		Score:        score,
+		CurrentScore: lead.IntentScore,
+		Rules:        make([]*model.RuleContribution, len(contributions)),
+	}
+	for i, c := range contributions {
+		simulation.Rules[i] = &model.RuleContribution{Rule: c.Rule, Matched: c.Matched, Points: c.Points}
+	}
+
+	return simulation, nil
+}
+
+func (r *mutationResolver) CreateScoringRuleset(ctx context.Context, input model.ScoringRulesetInput) (*model.ScoringRuleset, error) {
+	if err := r.validateScoringRulesetInput(ctx, input); err != nil {
+		return nil, err
+	}
+
+	return r.DB.CreateScoringRuleset(ctx, &model.ScoringRuleset{
+		ClientID:  input.ClientID,
+		Name:      input.Name,
+		Rules:     input.Rules,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (r *mutationResolver) UpdateScoringRuleset(ctx context.Context, id string, input model.ScoringRulesetInput) (*model.ScoringRuleset, error) {
+	ruleset, err := r.getScoringRuleset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.ClientID != ruleset.ClientID {
+		return nil, errors.New("a scoring ruleset cannot be moved to another client")
+	}
+	if err := r.validateScoringRulesetInput(ctx, input); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ruleset.Name = input.Name
+	ruleset.Rules = input.Rules
+	ruleset.UpdatedAt = &now
+
+	return r.DB.UpdateScoringRuleset(ctx, ruleset)
+}
+
+func (r *mutationResolver) DeleteScoringRuleset(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteScoringRuleset(ctx, id)
+}
+
+// ActivateScoringRuleset makes the ruleset the one used for its client's
+// leads from the next recalculation on, replacing any other.
+func (r *mutationResolver) ActivateScoringRuleset(ctx context.Context, id string) (*model.ScoringRuleset, error) {
+	return r.setScoringRulesetActive(ctx, id, true)
+}
+
+func (r *mutationResolver) DeactivateScoringRuleset(ctx context.Context, id string) (*model.ScoringRuleset, error) {
+	return r.setScoringRulesetActive(ctx, id, false)
+}
+
+func (r *mutationResolver) setScoringRulesetActive(ctx context.Context, id string, active bool) (*model.ScoringRuleset, error) {
+	ruleset, err := r.DB.SetScoringRulesetActive(ctx, id, active)
+	if err != nil {
+		return nil, err
+	}
+	if ruleset == nil {
+		return nil, errors.New("scoring ruleset not found")
+	}
+	return ruleset, nil
+}
+
+func (r *mutationResolver) validateScoringRulesetInput(ctx context.Context, input model.ScoringRulesetInput) error {
+	client, err := r.Store.Clients.GetClientByID(ctx, input.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return errors.New("client not found")
+	}
+
+	_, err = scoring.ParseRuleset(input.Rules)
+	return err
+}
+
+func (r *Resolver) getScoringRuleset(ctx context.Context, id string) (*model.ScoringRuleset, error) {
+	ruleset, err := r.DB.GetScoringRulesetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ruleset == nil {
+		return nil, errors.New("scoring ruleset not found")
+	}
+	return ruleset, nil
+}
@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (r *queryResolver) Search(ctx context.Context, query string, types []model.SearchType, limit *int) ([]*model.SearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("query must not be empty")
+	}
+
+	n := defaultSearchLimit
+	if limit != nil {
+		n = *limit
+	}
+	if n <= 0 || n > maxSearchLimit {
+		n = maxSearchLimit
+	}
+
+	hits, err := r.DB.Search(ctx, query, types, n)
+	if err != nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil || !user.IsClientScoped() {
+		return hits, nil
+	}
+
+	// Client users only see their own clients and campaigns.
+	visible := make([]*model.SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		switch hit.Type {
+		case model.SearchTypeClient:
+			if !user.CanAccessClient(hit.ResultID) {
+				continue
+			}
+		case model.SearchTypeCampaign:
+			campaign, err := r.Store.Campaigns.GetCampaignByID(ctx, hit.ResultID)
+			if err != nil {
+				return nil, err
+			}
+			if campaign == nil || !canAccessCampaign(user, campaign) {
+				continue
+			}
+		}
+		visible = append(visible, hit)
+	}
+	return visible, nil
+}
+
+func (r *Resolver) SearchHit() SearchHitResolver {
+	return &searchHitResolver{r}
+}
+
+type searchHitResolver struct{ *Resolver }
+
+func (r *searchHitResolver) Result(ctx context.Context, obj *model.SearchHit) (model.SearchResult, error) {
+	var result model.SearchResult
+	var err error
+
+	switch obj.Type {
+	case model.SearchTypeLead:
+		var lead *model.Lead
+		if lead, err = r.Store.Leads.GetLeadByID(ctx, obj.ResultID); lead != nil {
+			result = lead
+		}
+	case model.SearchTypeClient:
+		var client *model.Client
+		if client, err = r.Store.Clients.GetClientByID(ctx, obj.ResultID); client != nil {
+			result = client
+		}
+	case model.SearchTypeCampaign:
+		var campaign *model.Campaign
+		if campaign, err = r.Store.Campaigns.GetCampaignByID(ctx, obj.ResultID); campaign != nil {
+			result = campaign
+		}
+	case model.SearchTypeInteraction:
+		var interaction *model.Interaction
+		if interaction, err = r.DB.GetInteractionByID(ctx, obj.ResultID); interaction != nil {
+			result = interaction
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("search result no longer exists")
+	}
+
+	return result, nil
+}
+
+const (
+	defaultAskLeadsLimit = 50
+	maxAskLeadsLimit     = 200
+)
+
+func (r *queryResolver) AskLeads(ctx context.Context, question string, limit *int) (*model.LeadQueryAnswer, error) {
+	filter, err := r.LeadQueries.Plan(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+
+	n := defaultAskLeadsLimit
+	if limit != nil {
+		n = *limit
+	}
+	if n <= 0 || n > maxAskLeadsLimit {
+		n = maxAskLeadsLimit
+	}
+
+	leads, err := r.Store.Leads.GetLeadsByFilter(ctx, filter, &n, nil)
+	if err != nil {
+		return nil, err
+	}
+	if leads == nil {
+		leads = []*model.Lead{}
+	}
+
+	return &model.LeadQueryAnswer{Filter: filter, Leads: leads}, nil
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+var errSegmentNotFound = errors.New("segment not found")
+
+func (r *Resolver) Segment() SegmentResolver {
+	return &segmentResolver{r}
+}
+
+type segmentResolver struct{ *Resolver }
+
+func (r *segmentResolver) CreatedBy(ctx context.Context, obj *model.Segment) (*model.User, error) {
+	if obj.CreatedByID == nil {
+		return nil, nil
+	}
+	return r.DB.GetUserByID(ctx, *obj.CreatedByID)
+}
+
+func (r *queryResolver) Segment(ctx context.Context, id string) (*model.Segment, error) {
+	return r.DB.GetSegmentByID(ctx, id)
+}
+
+func (r *queryResolver) Segments(ctx context.Context) ([]*model.Segment, error) {
+	return r.DB.GetSegments(ctx)
+}
+
+// SegmentLeads runs the segment's saved filter against the current leads.
+func (r *queryResolver) SegmentLeads(ctx context.Context, segmentID string, limit *int, offset *int) ([]*model.Lead, error) {
+	segment, err := r.DB.GetSegmentByID(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil {
+		return nil, errSegmentNotFound
+	}
+	return r.Store.Leads.GetLeadsByFilter(ctx, segment.Filter, limit, offset)
+}
+
+// CreateSegment saves the filter and counts its leads straight away, so the
+// new segment has a size before the next periodic refresh.
+func (r *mutationResolver) CreateSegment(ctx context.Context, name string, filter model.LeadFilterInput) (*model.Segment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("segment name is required")
+	}
+
+	segment, err := r.DB.CreateSegment(ctx, &model.Segment{
+		Name:        name,
+		Filter:      &filter,
+		CreatedByID: currentUserID(ctx),
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.DB.RefreshSegmentCount(ctx, segment, time.Now()); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func (r *mutationResolver) DeleteSegment(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteSegment(ctx, id)
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/channels"
+)
+
+func (r *aiAgentResolver) SendThrottles(ctx context.Context, obj *model.AIAgent) ([]*model.SendThrottle, error) {
+	return r.DB.GetAIAgentSendThrottles(ctx, obj.ID)
+}
+
+func (r *aiAgentResolver) SendQuota(ctx context.Context, obj *model.AIAgent) ([]*model.SendQuota, error) {
+	throttles, err := r.DB.GetAIAgentSendThrottles(ctx, obj.ID)
+	if err != nil || len(throttles) == 0 {
+		return []*model.SendQuota{}, err
+	}
+
+	now := time.Now()
+	counts, err := r.DB.GetAIAgentSendCounts(ctx, obj.ID, channels.SendDay(now))
+	if err != nil {
+		return nil, err
+	}
+
+	resetsAt := channels.SendDay(now).Add(24 * time.Hour)
+	quota := make([]*model.SendQuota, len(throttles))
+	for i, throttle := range throttles {
+		limit := channels.DailyLimit(throttle, now)
+		sent := counts[throttle.Channel]
+		remaining := limit - sent
+		if remaining < 0 {
+			remaining = 0
+		}
+		quota[i] = &model.SendQuota{
+			Channel:   throttle.Channel,
+			Limit:     limit,
+			Sent:      sent,
+			Remaining: remaining,
+			ResetsAt:  resetsAt,
+		}
+	}
+
+	return quota, nil
+}
+
+func (r *mutationResolver) SetAIAgentSendThrottle(ctx context.Context, id string, input model.SendThrottleInput) (*model.AIAgent, error) {
+	throttle := &model.SendThrottle{
+		Channel:       input.Channel,
+		DailyLimit:    input.DailyLimit,
+		MaxDailyLimit: input.MaxDailyLimit,
+	}
+	if input.RampPerDay != nil {
+		throttle.RampPerDay = *input.RampPerDay
+	}
+
+	switch {
+	case throttle.DailyLimit <= 0:
+		return nil, errors.New("dailyLimit must be positive")
+	case throttle.RampPerDay < 0:
+		return nil, errors.New("rampPerDay must not be negative")
+	case throttle.MaxDailyLimit != nil && *throttle.MaxDailyLimit < throttle.DailyLimit:
+		return nil, errors.New("maxDailyLimit must be at least dailyLimit")
+	}
+
+	ok, err := r.DB.SetAIAgentSendThrottle(ctx, id, throttle)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("ai agent not found")
+	}
+
+	return r.DB.GetAIAgentByID(ctx, id)
+}
+
+func (r *mutationResolver) RemoveAIAgentSendThrottle(ctx context.Context, id string, channel model.Channel) (*model.AIAgent, error) {
+	if _, err := r.DB.DeleteAIAgentSendThrottle(ctx, id, channel); err != nil {
+		return nil, err
+	}
+
+	agent, err := r.DB.GetAIAgentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, errors.New("ai agent not found")
+	}
+
+	return agent, nil
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/sendwindow"
+)
+
+func (r *campaignResolver) SendWindow(ctx context.Context, obj *model.Campaign) (*model.SendWindow, error) {
+	raw, err := r.DB.GetCampaignSendWindow(ctx, obj.ID)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	return parseSendWindow(*raw)
+}
+
+// SetCampaignSendWindow replaces the campaign's send window. Messages already
+// queued are checked against the new window when they come due.
+func (r *mutationResolver) SetCampaignSendWindow(ctx context.Context, id string, input *model.SendWindowInput) (*model.Campaign, error) {
+	raw, err := encodeSendWindow(input)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := r.DB.SetCampaignSendWindow(ctx, id, raw)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, campaign.ErrNotFound
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, id)
+}
+
+// parseSendWindow decodes a window stored as JSON.
+func parseSendWindow(raw string) (*model.SendWindow, error) {
+	window, err := sendwindow.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.SendWindow{Start: window.Start, End: window.End, Days: []model.Weekday{}}
+	for _, day := range window.Days {
+		result.Days = append(result.Days, model.Weekday(day))
+	}
+	if window.Timezone != "" {
+		result.Timezone = &window.Timezone
+	}
+	return result, nil
+}
+
+// encodeSendWindow validates input and encodes it as JSON for storage. A nil
+// input encodes as nil.
+func encodeSendWindow(input *model.SendWindowInput) (*string, error) {
+	if input == nil {
+		return nil, nil
+	}
+
+	days := make([]string, len(input.Days))
+	for i, day := range input.Days {
+		days[i] = string(day)
+	}
+	var timezone string
+	if input.Timezone != nil {
+		timezone = *input.Timezone
+	}
+
+	window, err := sendwindow.New(input.Start, input.End, days, timezone)
+	if err != nil {
+		return nil, err
+	}
+	encoded := window.JSON()
+	return &encoded, nil
+}
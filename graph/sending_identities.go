@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/identity"
+)
+
+func (r *Resolver) SendingIdentity() SendingIdentityResolver {
+	return &sendingIdentityResolver{r}
+}
+
+type sendingIdentityResolver struct{ *Resolver }
+
+func (r *sendingIdentityResolver) Client(ctx context.Context, obj *model.SendingIdentity) (*model.Client, error) {
+	client, err := r.Store.Clients.GetClientByID(ctx, obj.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+	return client, nil
+}
+
+func (r *sendingIdentityResolver) DNSRecords(ctx context.Context, obj *model.SendingIdentity) ([]*model.DNSRecord, error) {
+	return identity.Records(obj), nil
+}
+
+func (r *clientResolver) SendingIdentities(ctx context.Context, obj *model.Client) ([]*model.SendingIdentity, error) {
+	return r.DB.GetSendingIdentities(ctx, obj.ID)
+}
+
+func (r *campaignResolver) SendingIdentity(ctx context.Context, obj *model.Campaign) (*model.SendingIdentity, error) {
+	return r.DB.GetCampaignSendingIdentity(ctx, obj.ID)
+}
+
+func (r *queryResolver) SendingIdentities(ctx context.Context, clientID string) ([]*model.SendingIdentity, error) {
+	return r.DB.GetSendingIdentities(ctx, clientID)
+}
+
+func (r *mutationResolver) CreateSendingIdentity(ctx context.Context, input model.SendingIdentityInput) (*model.SendingIdentity, error) {
+	client, err := r.Store.Clients.GetClientByID(ctx, input.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+
+	sendingIdentity := &model.SendingIdentity{
+		ClientID:  client.ID,
+		FromName:  input.FromName,
+		FromEmail: input.FromEmail,
+	}
+	if input.DkimSelector != nil {
+		sendingIdentity.DkimSelector = *input.DkimSelector
+	}
+	if input.IsDefault != nil {
+		sendingIdentity.IsDefault = *input.IsDefault
+	}
+	return r.Identities.Create(ctx, sendingIdentity)
+}
+
+func (r *mutationResolver) VerifySendingIdentity(ctx context.Context, id string) (*model.SendingIdentity, error) {
+	sendingIdentity, err := r.getSendingIdentity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.Identities.Check(ctx, sendingIdentity)
+}
+
+func (r *mutationResolver) SetDefaultSendingIdentity(ctx context.Context, id string) (*model.SendingIdentity, error) {
+	sendingIdentity, err := r.DB.SetDefaultSendingIdentity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sendingIdentity == nil {
+		return nil, errors.New("sending identity not found")
+	}
+	return sendingIdentity, nil
+}
+
+func (r *mutationResolver) DeleteSendingIdentity(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteSendingIdentity(ctx, id)
+}
+
+// SetCampaignSendingIdentity makes the campaign send from one of its
+// client's identities. It may be chosen before it is verified, but the
+// campaign's emails fail until it is.
+func (r *mutationResolver) SetCampaignSendingIdentity(ctx context.Context, campaignID string, identityID *string) (*model.Campaign, error) {
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+
+	if identityID != nil {
+		sendingIdentity, err := r.getSendingIdentity(ctx, *identityID)
+		if err != nil {
+			return nil, err
+		}
+		if c.ClientID == nil || *c.ClientID != sendingIdentity.ClientID {
+			return nil, identity.ErrWrongClient
+		}
+	}
+
+	found, err := r.DB.SetCampaignSendingIdentity(ctx, campaignID, identityID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, campaign.ErrNotFound
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, campaignID)
+}
+
+func (r *Resolver) getSendingIdentity(ctx context.Context, id string) (*model.SendingIdentity, error) {
+	sendingIdentity, err := r.DB.GetSendingIdentityByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sendingIdentity == nil {
+		return nil, errors.New("sending identity not found")
+	}
+	return sendingIdentity, nil
+}
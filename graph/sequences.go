@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/campaign"
+	"salesagency/internal/sequence"
+)
+
+func (r *campaignResolver) Sequences(ctx context.Context, obj *model.Campaign) ([]*model.Sequence, error) {
+	return r.DB.GetSequencesByCampaignID(ctx, obj.ID)
+}
+
+func (r *queryResolver) Sequence(ctx context.Context, id string) (*model.Sequence, error) {
+	seq, err := r.DB.GetSequenceByID(ctx, id)
+	if err != nil || seq == nil {
+		return seq, err
+	}
+
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, seq.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || !canAccessCampaign(auth.UserFromContext(ctx), c) {
+		return nil, auth.ErrForbidden
+	}
+	return seq, nil
+}
+
+func (r *Resolver) Sequence() SequenceResolver {
+	return &sequenceResolver{r}
+}
+
+type sequenceResolver struct{ *Resolver }
+
+func (r *sequenceResolver) Campaign(ctx context.Context, obj *model.Sequence) (*model.Campaign, error) {
+	c, err := r.Store.Campaigns.GetCampaignByID(ctx, obj.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *sequenceResolver) Steps(ctx context.Context, obj *model.Sequence) ([]*model.SequenceStep, error) {
+	return r.DB.GetSequenceSteps(ctx, obj.ID)
+}
+
+func (r *sequenceResolver) Enrollments(ctx context.Context, obj *model.Sequence, status *model.EnrollmentStatus) ([]*model.SequenceEnrollment, error) {
+	return r.DB.GetSequenceEnrollments(ctx, obj.ID, status)
+}
+
+func (r *sequenceResolver) StepStats(ctx context.Context, obj *model.Sequence) ([]*model.SequenceStepStats, error) {
+	return r.Sequences.StepStats(ctx, obj.ID)
+}
+
+func (r *Resolver) SequenceStep() SequenceStepResolver {
+	return &sequenceStepResolver{r}
+}
+
+type sequenceStepResolver struct{ *Resolver }
+
+func (r *sequenceStepResolver) Template(ctx context.Context, obj *model.SequenceStep) (*model.MessageTemplate, error) {
+	if obj.TemplateID == nil {
+		return nil, nil
+	}
+	return r.DB.GetMessageTemplateByID(ctx, *obj.TemplateID)
+}
+
+func (r *Resolver) SequenceEnrollment() SequenceEnrollmentResolver {
+	return &sequenceEnrollmentResolver{r}
+}
+
+type sequenceEnrollmentResolver struct{ *Resolver }
+
+func (r *sequenceEnrollmentResolver) Sequence(ctx context.Context, obj *model.SequenceEnrollment) (*model.Sequence, error) {
+	seq, err := r.DB.GetSequenceByID(ctx, obj.SequenceID)
+	if err != nil {
+		return nil, err
+	}
+	if seq == nil {
+		return nil, sequence.ErrNotFound
+	}
+	return seq, nil
+}
+
+func (r *sequenceEnrollmentResolver) Lead(ctx context.Context, obj *model.SequenceEnrollment) (*model.Lead, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, obj.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+	return lead, nil
+}
+
+func (r *mutationResolver) CreateSequence(ctx context.Context, input model.SequenceInput) (*model.Sequence, error) {
+	return r.Sequences.Create(ctx, &model.Sequence{CampaignID: input.CampaignID, Name: input.Name}, sequenceSteps(input.Steps))
+}
+
+func (r *mutationResolver) UpdateSequence(ctx context.Context, id string, input model.SequenceInput) (*model.Sequence, error) {
+	existing, err := r.DB.GetSequenceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, sequence.ErrNotFound
+	}
+	if input.CampaignID != existing.CampaignID {
+		return nil, errors.New("a sequence cannot be moved to another campaign")
+	}
+
+	return r.Sequences.Update(ctx, &model.Sequence{ID: id, Name: input.Name}, sequenceSteps(input.Steps))
+}
+
+func (r *mutationResolver) DeleteSequence(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteSequence(ctx, id)
+}
+
+func (r *mutationResolver) EnrollLeadInSequence(ctx context.Context, sequenceID string, leadID string) (*model.SequenceEnrollment, error) {
+	return r.Sequences.Enroll(ctx, sequenceID, leadID)
+}
+
+func (r *mutationResolver) StopSequenceEnrollment(ctx context.Context, id string) (*model.SequenceEnrollment, error) {
+	return r.Sequences.Stop(ctx, id)
+}
+
+func sequenceSteps(input []*model.SequenceStepInput) []*model.SequenceStep {
+	steps := make([]*model.SequenceStep, len(input))
+	for i, step := range input {
+		steps[i] = &model.SequenceStep{
+			Day:        step.Day,
+			Channel:    step.Channel,
+			TemplateID: step.TemplateID,
+			Task:       step.Task,
+		}
+	}
+	return steps
+}
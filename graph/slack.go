@@ -0,0 +1,21 @@
+package graph
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/slack"
+)
+
+func (r *mutationResolver) CreateSlackLinkCode(ctx context.Context) (*model.SlackLinkCode, error) {
+	if r.SlackLinks == nil {
+		return nil, slack.ErrNotConfigured
+	}
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	code, expiresAt := r.SlackLinks.Code(user.ID)
+	return &model.SlackLinkCode{Code: code, ExpiresAt: expiresAt}, nil
+}
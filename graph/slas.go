@@ -0,0 +1,253 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/reports"
+)
+
+// worstSLAOffenders is how many breaches slaReport lists.
+const worstSLAOffenders = 10
+
+func (r *Resolver) SLA() SLAResolver {
+	return &slaResolver{r}
+}
+
+type slaResolver struct{ *Resolver }
+
+func (r *slaResolver) Client(ctx context.Context, obj *model.SLA) (*model.Client, error) {
+	client, err := r.Store.Clients.GetClientByID(ctx, obj.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+	return client, nil
+}
+
+func (r *slaResolver) WorkingHours(ctx context.Context, obj *model.SLA) (*model.SendWindow, error) {
+	if obj.WorkingHours == nil {
+		return nil, nil
+	}
+	return parseSendWindow(*obj.WorkingHours)
+}
+
+func (r *Resolver) SLACheck() SLACheckResolver {
+	return &slaCheckResolver{r}
+}
+
+type slaCheckResolver struct{ *Resolver }
+
+func (r *slaCheckResolver) SLA(ctx context.Context, obj *model.SLACheck) (*model.SLA, error) {
+	return r.getSLA(ctx, obj.SLAID)
+}
+
+func (r *slaCheckResolver) Lead(ctx context.Context, obj *model.SLACheck) (*model.Lead, error) {
+	lead, err := r.Store.Leads.GetLeadByID(ctx, obj.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+	return lead, nil
+}
+
+func (r *slaCheckResolver) Interaction(ctx context.Context, obj *model.SLACheck) (*model.Interaction, error) {
+	if obj.InteractionID == nil {
+		return nil, nil
+	}
+	return r.DB.GetInteractionByID(ctx, *obj.InteractionID)
+}
+
+func (r *slaCheckResolver) Handoff(ctx context.Context, obj *model.SLACheck) (*model.LeadHandoff, error) {
+	if obj.HandoffID == nil {
+		return nil, nil
+	}
+	return r.DB.GetLeadHandoffByID(ctx, *obj.HandoffID)
+}
+
+func (r *slaCheckResolver) LateBy(ctx context.Context, obj *model.SLACheck) (*float64, error) {
+	if obj.BreachedAt == nil {
+		return nil, nil
+	}
+	answered := time.Now()
+	if obj.RespondedAt != nil {
+		answered = *obj.RespondedAt
+	}
+	late := answered.Sub(obj.DueAt).Seconds()
+	return &late, nil
+}
+
+func (r *Resolver) SLACompliance() SLAComplianceResolver {
+	return &slaComplianceResolver{r}
+}
+
+type slaComplianceResolver struct{ *Resolver }
+
+func (r *slaComplianceResolver) SLA(ctx context.Context, obj *model.SLACompliance) (*model.SLA, error) {
+	return r.getSLA(ctx, obj.SLAID)
+}
+
+func (r *queryResolver) SLA(ctx context.Context, id string) (*model.SLA, error) {
+	return r.DB.GetSLAByID(ctx, id)
+}
+
+func (r *queryResolver) Slas(ctx context.Context, clientID *string) ([]*model.SLA, error) {
+	return r.DB.GetSLAs(ctx, clientID)
+}
+
+// SLAReport counts the client's replies and escalations started in the
+// period that met or breached its SLAs, as flagged so far.
+func (r *queryResolver) SLAReport(ctx context.Context, clientID string, period string) (*model.SLAReport, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	if !user.IsClientScoped() && !user.HasRole(auth.RoleManager) {
+		return nil, auth.ErrForbidden
+	}
+	if !user.CanAccessClient(clientID) {
+		return nil, auth.ErrForbidden
+	}
+
+	p, err := reports.ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := r.Store.Clients.GetClientByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+
+	compliance, err := r.DB.GetSLACompliance(ctx, clientID, p.Start, p.End)
+	if err != nil {
+		return nil, err
+	}
+	worst, err := r.DB.GetWorstSLABreaches(ctx, clientID, p.Start, p.End, time.Now(), worstSLAOffenders)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.SLAReport{
+		Client:         client,
+		Period:         p.Label,
+		PeriodStart:    p.Start,
+		PeriodEnd:      p.End,
+		SLAs:           compliance,
+		WorstOffenders: worst,
+	}
+	for _, c := range compliance {
+		c.Compliance = compliancePercentage(c.Met, c.Breached)
+		report.Checks += c.Checks
+		report.Met += c.Met
+		report.Breached += c.Breached
+	}
+	report.Compliance = compliancePercentage(report.Met, report.Breached)
+
+	return report, nil
+}
+
+// compliancePercentage is the percentage of met among met and breached, or
+// nil when both are zero.
+func compliancePercentage(met, breached int) *float64 {
+	if met+breached == 0 {
+		return nil
+	}
+	percentage := float64(met) / float64(met+breached) * 100
+	return &percentage
+}
+
+func (r *mutationResolver) CreateSLA(ctx context.Context, input model.SLAInput) (*model.SLA, error) {
+	sla, err := r.slaFromInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	sla.CreatedAt = time.Now()
+	if sla.Enabled {
+		sla.EnabledAt = &sla.CreatedAt
+	}
+	return r.DB.CreateSLA(ctx, sla)
+}
+
+func (r *mutationResolver) UpdateSLA(ctx context.Context, id string, input model.SLAInput) (*model.SLA, error) {
+	existing, err := r.getSLA(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case input.ClientID != existing.ClientID:
+		return nil, errors.New("an SLA cannot be moved to another client")
+	case input.Kind != existing.Kind:
+		return nil, errors.New("an SLA's kind cannot be changed")
+	}
+
+	sla, err := r.slaFromInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sla.ID = existing.ID
+	sla.UpdatedAt = &now
+
+	updated, err := r.DB.UpdateSLA(ctx, sla)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("SLA not found")
+	}
+	return updated, nil
+}
+
+func (r *mutationResolver) DeleteSLA(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteSLA(ctx, id)
+}
+
+// slaFromInput checks input's client and working hours and returns the SLA
+// it describes.
+func (r *mutationResolver) slaFromInput(ctx context.Context, input model.SLAInput) (*model.SLA, error) {
+	client, err := r.Store.Clients.GetClientByID(ctx, input.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client not found")
+	}
+
+	workingHours, err := encodeSendWindow(input.WorkingHours)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SLA{
+		ClientID:      client.ID,
+		Name:          input.Name,
+		Kind:          input.Kind,
+		TargetMinutes: input.TargetMinutes,
+		WorkingHours:  workingHours,
+		Enabled:       input.Enabled == nil || *input.Enabled,
+	}, nil
+}
+
+func (r *Resolver) getSLA(ctx context.Context, id string) (*model.SLA, error) {
+	sla, err := r.DB.GetSLAByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sla == nil {
+		return nil, errors.New("SLA not found")
+	}
+	return sla, nil
+}
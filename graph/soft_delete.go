@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+)
+
+// withDeleted widens lookups to soft-deleted rows when an admin asks for
+// them.
+func withDeleted(ctx context.Context, includeDeleted *bool) (context.Context, error) {
+	if includeDeleted == nil || !*includeDeleted {
+		return ctx, nil
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil || !user.HasRole(auth.RoleAdmin) {
+		return nil, auth.ErrForbidden
+	}
+	return database.WithDeleted(ctx), nil
+}
+
+func (r *mutationResolver) RestoreLead(ctx context.Context, id string) (*model.Lead, error) {
+	lead, err := r.Store.Leads.RestoreLead(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("deleted lead not found")
+	}
+
+	r.Events.Publish(events.TopicLeadUpdated, lead)
+
+	return lead, nil
+}
+
+// PurgeLead permanently deletes the lead, and with it the stored files of its
+// attachments and those of its interactions.
+func (r *mutationResolver) PurgeLead(ctx context.Context, id string) (bool, error) {
+	keys, err := r.DB.GetLeadAttachmentKeys(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	purged, err := r.Store.Leads.PurgeLead(ctx, id)
+	if err != nil || !purged {
+		return purged, err
+	}
+
+	r.deleteStoredFiles(ctx, keys...)
+
+	return true, nil
+}
+
+func (r *mutationResolver) RestoreClient(ctx context.Context, id string) (*model.Client, error) {
+	client, err := r.Store.Clients.RestoreClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("deleted client not found")
+	}
+	return client, nil
+}
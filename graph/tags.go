@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+const (
+	defaultTagLimit = 20
+	maxTagLimit     = 100
+)
+
+var (
+	errTagNotFound     = errors.New("tag not found")
+	errTagNameRequired = errors.New("tag name is required")
+	errInvalidTagColor = errors.New("tag color must be a hex color such as #1F77B4")
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (r *queryResolver) Tags(ctx context.Context, prefix *string, limit *int) ([]*model.Tag, error) {
+	n := defaultTagLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	if n > maxTagLimit {
+		n = maxTagLimit
+	}
+
+	p := ""
+	if prefix != nil {
+		p = strings.TrimSpace(*prefix)
+	}
+	return r.DB.GetTags(ctx, p, n)
+}
+
+func (r *mutationResolver) CreateTag(ctx context.Context, name string, color *string) (*model.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errTagNameRequired
+	}
+	if err := validateTagColor(color); err != nil {
+		return nil, err
+	}
+	return r.DB.CreateTag(ctx, name, color)
+}
+
+func (r *mutationResolver) RenameTag(ctx context.Context, id string, name string) (*model.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errTagNameRequired
+	}
+
+	tag, err := r.DB.RenameTag(ctx, id, name)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, errTagNotFound
+	}
+	return tag, nil
+}
+
+func (r *mutationResolver) SetTagColor(ctx context.Context, id string, color *string) (*model.Tag, error) {
+	if err := validateTagColor(color); err != nil {
+		return nil, err
+	}
+
+	tag, err := r.DB.SetTagColor(ctx, id, color)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, errTagNotFound
+	}
+	return tag, nil
+}
+
+func (r *mutationResolver) MergeTags(ctx context.Context, sourceIds []string, targetID string) (*model.Tag, error) {
+	if len(sourceIds) == 0 {
+		return nil, errors.New("sourceIds is required")
+	}
+
+	tag, err := r.DB.MergeTags(ctx, sourceIds, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, errTagNotFound
+	}
+	return tag, nil
+}
+
+func (r *mutationResolver) DeleteTag(ctx context.Context, id string) (bool, error) {
+	return r.DB.DeleteTag(ctx, id)
+}
+
+func validateTagColor(color *string) error {
+	if color != nil && !tagColorPattern.MatchString(*color) {
+		return errInvalidTagColor
+	}
+	return nil
+}
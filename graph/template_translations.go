@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/templates"
+	"salesagency/internal/validation"
+)
+
+func (r *Resolver) MessageTemplate() MessageTemplateResolver {
+	return &messageTemplateResolver{r}
+}
+
+type messageTemplateResolver struct{ *Resolver }
+
+func (r *messageTemplateResolver) Translations(ctx context.Context, obj *model.MessageTemplate) ([]*model.TemplateTranslation, error) {
+	return r.DB.GetTemplateTranslations(ctx, obj.ID)
+}
+
+func (r *campaignResolver) DefaultLanguage(ctx context.Context, obj *model.Campaign) (*string, error) {
+	return r.DB.GetCampaignDefaultLanguage(ctx, obj.ID)
+}
+
+// SetCampaignDefaultLanguage changes the language the campaign's templates
+// fall back to for messages rendered from now on.
+func (r *mutationResolver) SetCampaignDefaultLanguage(ctx context.Context, id string, language *string) (*model.Campaign, error) {
+	if language != nil {
+		locale, err := validation.NormalizeLocale(*language)
+		if err != nil {
+			return nil, fmt.Errorf("language %w", err)
+		}
+		language = &locale
+	}
+
+	found, err := r.DB.SetCampaignDefaultLanguage(ctx, id, language)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, campaign.ErrNotFound
+	}
+	return r.Store.Campaigns.GetCampaignByID(ctx, id)
+}
+
+func (r *mutationResolver) AddTemplateTranslation(ctx context.Context, templateID string, input model.TemplateTranslationInput) (*model.MessageTemplate, error) {
+	if _, err := templates.Parse(input.Content); err != nil {
+		return nil, fmt.Errorf("invalid translation content: %w", err)
+	}
+
+	translation, err := r.DB.SetTemplateTranslation(ctx, &model.TemplateTranslation{
+		TemplateID: templateID,
+		Locale:     input.Locale,
+		Content:    input.Content,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if translation == nil {
+		return nil, errors.New("message template not found")
+	}
+	return r.getMessageTemplate(ctx, templateID)
+}
+
+func (r *mutationResolver) RemoveTemplateTranslation(ctx context.Context, templateID string, locale string) (*model.MessageTemplate, error) {
+	template, err := r.getMessageTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if normalized, err := validation.NormalizeLocale(locale); err == nil {
+		locale = normalized
+	}
+	deleted, err := r.DB.DeleteTemplateTranslation(ctx, templateID, locale)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, fmt.Errorf("message template has no %s translation", locale)
+	}
+	return template, nil
+}
+
+func (r *Resolver) getMessageTemplate(ctx context.Context, id string) (*model.MessageTemplate, error) {
+	template, err := r.DB.GetMessageTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, errors.New("message template not found")
+	}
+	return template, nil
+}
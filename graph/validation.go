@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"salesagency/internal/validation"
+)
+
+// Validation checks and normalizes every field's input arguments before its
+// resolver runs. Invalid input fails the field with a BAD_USER_INPUT error
+// whose "fields" extension lists each problem by field.
+type Validation struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = Validation{}
+
+func (Validation) ExtensionName() string {
+	return "Validation"
+}
+
+func (Validation) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (Validation) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || len(fc.Args) == 0 {
+		return next(ctx)
+	}
+
+	err := validation.Args(fc.Args)
+	var invalid validation.Errors
+	if errors.As(err, &invalid) {
+		return nil, &gqlerror.Error{
+			Message:    invalid.Error(),
+			Path:       graphql.GetPath(ctx),
+			Extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "fields": invalid},
+		}
+	}
+	return next(ctx)
+}
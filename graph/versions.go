@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"salesagency/internal/database"
+)
+
+// conflictError reports that an edit was made against an outdated version.
+// It carries the record's current state so the client can merge.
+func conflictError(ctx context.Context, current interface{}) error {
+	return &gqlerror.Error{
+		Message:    database.ErrVersionConflict.Error(),
+		Path:       graphql.GetPath(ctx),
+		Extensions: map[string]interface{}{"code": "CONFLICT", "current": current},
+	}
+}
+
+func (r *mutationResolver) leadConflict(ctx context.Context, id string) error {
+	current, err := r.Store.Leads.GetLeadByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return errors.New("lead not found")
+	}
+	return conflictError(ctx, current)
+}
+
+func (r *mutationResolver) clientConflict(ctx context.Context, id string) error {
+	current, err := r.Store.Clients.GetClientByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return errors.New("client not found")
+	}
+	return conflictError(ctx, current)
+}
@@ -0,0 +1,188 @@
+// Package assignment assigns leads to AI agents within each agent's maximum
+// active leads, balancing the load when several agents could take a lead.
+package assignment
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+)
+
+// ErrNoCapacity is returned when none of the candidate agents can take
+// another lead.
+var ErrNoCapacity = errors.New("no active AI agent has capacity for the lead")
+
+// ErrAgentActive is returned when rebalancing an agent that is still active.
+var ErrAgentActive = errors.New("only leads of inactive AI agents can be rebalanced")
+
+type Service struct {
+	db  *database.DB
+	now func() time.Time
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// AutoAssign assigns the lead to one of the active agents in agentIDs, or of
+// all the agency's active agents when agentIDs is empty, chosen by strategy
+// among those with capacity. It returns nil when the lead does not exist.
+func (s *Service) AutoAssign(ctx context.Context, leadID string, agentIDs []string, strategy model.LoadBalancing) (*model.Lead, error) {
+	loads, err := s.db.GetAgentLoads(ctx, model.AgentStatusActive, agentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		load := pick(loads, strategy)
+		if load == nil {
+			return nil, ErrNoCapacity
+		}
+
+		lead, err := s.db.AssignLeadToAIAgent(ctx, leadID, load.AgentID)
+		if errors.Is(err, database.ErrAgentAtCapacity) {
+			// Another assignment took the last slot since the loads were
+			// read; try the next agent.
+			markFull(load)
+			continue
+		}
+		return lead, err
+	}
+}
+
+// Rebalance moves the active leads of the inactive agent agentID, or of every
+// paused agent when agentID is nil, to the agency's active agents chosen by
+// strategy. Leads that fit nowhere stay where they are; it returns how many
+// were moved and how many were not.
+func (s *Service) Rebalance(ctx context.Context, agentID *string, strategy model.LoadBalancing) (*model.RebalanceResult, error) {
+	var sources []string
+	if agentID != nil {
+		agent, err := s.db.GetAIAgentByID(ctx, *agentID)
+		if err != nil {
+			return nil, err
+		}
+		if agent == nil {
+			return nil, errors.New("ai agent not found")
+		}
+		if agent.Status == model.AgentStatusActive {
+			return nil, ErrAgentActive
+		}
+		sources = []string{agent.ID}
+	} else {
+		paused, err := s.db.GetAgentLoads(ctx, model.AgentStatusPaused, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, load := range paused {
+			if load.ActiveLeads > 0 {
+				sources = append(sources, load.AgentID)
+			}
+		}
+	}
+
+	targets, err := s.db.GetAgentLoads(ctx, model.AgentStatusActive, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.RebalanceResult{}
+	for _, source := range sources {
+		leadIDs, err := s.db.GetActiveLeadIDsByAIAgentID(ctx, source)
+		if err != nil {
+			return result, err
+		}
+
+		for _, leadID := range leadIDs {
+			moved, err := s.move(ctx, leadID, source, targets, strategy)
+			if err != nil {
+				return result, err
+			}
+			if moved {
+				result.Moved++
+			} else {
+				result.Unplaced++
+			}
+		}
+	}
+
+	if result.Moved > 0 || result.Unplaced > 0 {
+		logging.FromContext(ctx).Info("Rebalanced agent loads", "moved", result.Moved, "unplaced", result.Unplaced)
+	}
+
+	return result, nil
+}
+
+// move moves the lead to the agent strategy picks from targets, updating its
+// load. It reports false when no target has capacity.
+func (s *Service) move(ctx context.Context, leadID, source string, targets []*database.AgentLoad, strategy model.LoadBalancing) (bool, error) {
+	for {
+		load := pick(targets, strategy)
+		if load == nil {
+			return false, nil
+		}
+
+		moved, err := s.db.MoveLeadToAIAgent(ctx, leadID, source, load.AgentID)
+		if errors.Is(err, database.ErrAgentAtCapacity) {
+			markFull(load)
+			continue
+		}
+		if err != nil || !moved {
+			return false, err
+		}
+
+		now := s.now()
+		load.ActiveLeads++
+		load.LastAssignedAt = &now
+		return true, nil
+	}
+}
+
+// pick returns the agent strategy chooses among those with capacity, or nil
+// when there is none. Least loaded picks the agent with the fewest active
+// leads; round robin, and ties between equally loaded agents, pick the agent
+// that has waited longest for a lead.
+func pick(loads []*database.AgentLoad, strategy model.LoadBalancing) *database.AgentLoad {
+	available := make([]*database.AgentLoad, 0, len(loads))
+	for _, load := range loads {
+		if load.HasCapacity() {
+			available = append(available, load)
+		}
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(available, func(i, j int) bool {
+		a, b := available[i], available[j]
+		if strategy == model.LoadBalancingLeastLoaded && a.ActiveLeads != b.ActiveLeads {
+			return a.ActiveLeads < b.ActiveLeads
+		}
+		return assignedBefore(a, b)
+	})
+	return available[0]
+}
+
+// assignedBefore orders agents never assigned a lead first, then by their
+// last assignment.
+func assignedBefore(a, b *database.AgentLoad) bool {
+	switch {
+	case a.LastAssignedAt == nil:
+		return b.LastAssignedAt != nil
+	case b.LastAssignedAt == nil:
+		return false
+	default:
+		return a.LastAssignedAt.Before(*b.LastAssignedAt)
+	}
+}
+
+// markFull makes the load report no capacity, for agents that filled up
+// after their loads were read.
+func markFull(load *database.AgentLoad) {
+	full := load.ActiveLeads
+	load.MaxActiveLeads = &full
+}
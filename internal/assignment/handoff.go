@@ -0,0 +1,88 @@
+package assignment
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+)
+
+// ErrSameAgent is returned for a handoff rule from an agent to itself.
+var ErrSameAgent = errors.New("a handoff rule must hand leads to another AI agent")
+
+// ErrNoCondition is returned for a handoff rule without a status or a
+// minimum intent score.
+var ErrNoCondition = errors.New("a handoff rule needs a status, a minimum intent score or both")
+
+// ValidateHandoffRule checks the rule before it is saved.
+func ValidateHandoffRule(rule *model.AgentHandoffRule) error {
+	if rule.FromAgentID == rule.ToAgentID {
+		return ErrSameAgent
+	}
+	if rule.Status == nil && rule.MinIntentScore == nil {
+		return ErrNoCondition
+	}
+	if rule.MinIntentScore != nil && (*rule.MinIntentScore < 0 || *rule.MinIntentScore > 1) {
+		return errors.New("minIntentScore must be between 0 and 1")
+	}
+	return nil
+}
+
+// LeadStatusChanged hands the lead on by its agents' rules. It is
+// registered as a pipeline.StatusHook.
+func (s *Service) LeadStatusChanged(ctx context.Context, leadID string, _ model.LeadStatus) {
+	s.HandOff(ctx, leadID)
+}
+
+// IntentScoreChanged hands the lead on by its agents' rules. It is
+// registered as a scoring.ChangeHook.
+func (s *Service) IntentScoreChanged(ctx context.Context, change *database.IntentScoreChange) {
+	s.HandOff(ctx, change.Lead.ID)
+}
+
+// HandOff moves the lead from each agent it is assigned to along the first
+// of the agent's rules that the lead meets and whose target has capacity.
+// Failures are logged; the lead stays where it is.
+func (s *Service) HandOff(ctx context.Context, leadID string) {
+	log := logging.FromContext(ctx).With("lead_id", leadID)
+
+	rules, err := s.db.GetLeadHandoffRules(ctx, leadID)
+	if err != nil {
+		log.Error("Failed to find agent handoff rules", "error", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	lead, err := s.db.GetLeadByID(ctx, leadID)
+	if err != nil || lead == nil {
+		if err != nil {
+			log.Error("Failed to fetch lead for agent handoff", "error", err)
+		}
+		return
+	}
+
+	handedOff := make(map[string]bool)
+	for _, rule := range rules {
+		if handedOff[rule.FromAgentID] || !rule.Matches(lead) {
+			continue
+		}
+
+		moved, err := s.db.HandOffLead(ctx, leadID, rule)
+		if errors.Is(err, database.ErrAgentAtCapacity) {
+			log.Warn("Agent handoff target is at capacity", "rule_id", rule.ID, "ai_agent_id", rule.ToAgentID)
+			continue
+		}
+		if err != nil {
+			log.Error("Failed to hand off lead", "rule_id", rule.ID, "error", err)
+			return
+		}
+		if moved {
+			handedOff[rule.FromAgentID] = true
+			log.Info("Handed off lead", "rule_id", rule.ID, "from_agent_id", rule.FromAgentID, "to_agent_id", rule.ToAgentID)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const apiKeyPrefix = "sak_"
+
+var ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+
+// GenerateAPIKey returns a new random API key together with the hash that is
+// stored in its place. The key itself is only ever shown once.
+func GenerateAPIKey() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("error generating API key: %w", err)
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes a key for storage and lookup. Keys carry enough entropy
+// that a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
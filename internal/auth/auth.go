@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"salesagency/internal/tenant"
+)
+
+type Role string
+
+const (
+	RoleAdmin         Role = "ADMIN"
+	RoleAgencyManager Role = "AGENCY_MANAGER"
+	RoleManager       Role = "MANAGER"
+	RoleSalesRep      Role = "SALES_REP"
+	RoleBDR           Role = "BDR"
+	RoleAIEngineer    Role = "AI_ENGINEER"
+	RoleClient        Role = "CLIENT"
+	RoleClientViewer  Role = "CLIENT_VIEWER"
+)
+
+// roleRank orders roles so that a higher-ranked role satisfies any
+// requirement for a lower-ranked one.
+var roleRank = map[Role]int{
+	RoleAdmin:         100,
+	RoleAgencyManager: 80,
+	RoleManager:       60,
+	RoleAIEngineer:    40,
+	RoleSalesRep:      40,
+	RoleBDR:           40,
+	RoleClient:        10,
+	RoleClientViewer:  10,
+}
+
+var (
+	ErrUnauthenticated    = errors.New("authentication required")
+	ErrForbidden          = errors.New("insufficient permissions")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInactiveAccount    = errors.New("account is not active")
+)
+
+type User struct {
+	ID        string
+	Email     string
+	Role      Role
+	AgencyID  string
+	ClientIDs []string
+}
+
+// HasRole reports whether the user's role is at least as privileged as required.
+func (u *User) HasRole(required Role) bool {
+	return roleRank[u.Role] >= roleRank[required]
+}
+
+// IsClientScoped reports whether the user may only see data belonging to
+// the clients listed in ClientIDs.
+func (u *User) IsClientScoped() bool {
+	return u.Role == RoleClient || u.Role == RoleClientViewer
+}
+
+func (u *User) CanAccessClient(clientID string) bool {
+	if !u.IsClientScoped() {
+		return true
+	}
+	for _, id := range u.ClientIDs {
+		if id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+type claims struct {
+	Email     string   `json:"email"`
+	Role      Role     `json:"role"`
+	AgencyID  string   `json:"agency_id"`
+	ClientIDs []string `json:"client_ids,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenService struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenService(secret string, ttl time.Duration) *TokenService {
+	return &TokenService{secret: []byte(secret), ttl: ttl}
+}
+
+func (s *TokenService) Issue(user *User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Email:     user.Email,
+		Role:      user.Role,
+		AgencyID:  user.AgencyID,
+		ClientIDs: user.ClientIDs,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+
+	signed, err := token.SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing token: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+func (s *TokenService) Parse(tokenString string) (*User, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(tokenString, &c, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if c.AgencyID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return &User{
+		ID:        c.Subject,
+		Email:     c.Email,
+		Role:      c.Role,
+		AgencyID:  c.AgencyID,
+		ClientIDs: c.ClientIDs,
+	}, nil
+}
+
+type contextKey struct{}
+
+// WithUser attaches the user to ctx and scopes database access to the
+// user's agency and, for client users, to their clients.
+func WithUser(ctx context.Context, user *User) context.Context {
+	ctx = tenant.WithAgency(ctx, user.AgencyID)
+	if user.IsClientScoped() {
+		ctx = tenant.WithClients(ctx, user.ClientIDs)
+	}
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, or nil for anonymous requests.
+func UserFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(contextKey{}).(*User)
+	return user
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware validates a bearer token when one is present and attaches the
+// resulting User to the request context. Requests without a token continue
+// anonymously so that public operations such as login keep working.
+func Middleware(tokens *TokenService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tokenString, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				http.Error(w, "malformed authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			user, err := tokens.Parse(tokenString)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
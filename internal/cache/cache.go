@@ -0,0 +1,123 @@
+// Package cache is a Redis-backed, TTL-based cache for hot reads. A nil
+// *Cache is valid and caches nothing, so callers need not check whether
+// caching is enabled.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultTTL = 5 * time.Minute
+	keyPrefix  = "salesagency:"
+)
+
+type Cache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func New(client *redis.Client, ttl time.Duration) *Cache {
+	return &Cache{client: client, ttl: ttl}
+}
+
+// Config enables the cache. It is disabled when RedisURL is empty or
+// Enabled is false.
+type Config struct {
+	RedisURL string
+	Enabled  bool
+	// TTL defaults to five minutes.
+	TTL time.Duration
+}
+
+// Open connects to cfg.RedisURL. It returns nil, disabling the cache, when
+// the cache is disabled.
+func Open(ctx context.Context, cfg Config) (*Cache, error) {
+	if cfg.RedisURL == "" || !cfg.Enabled {
+		return nil, nil
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to redis: %w", err)
+	}
+
+	return New(client, ttl), nil
+}
+
+// Get decodes the value stored under key into dest and reports whether it
+// was found.
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+
+	data, err := c.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading cache: %w", err)
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(dest); err != nil {
+		return false, fmt.Errorf("error decoding cached value: %w", err)
+	}
+	return true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value interface{}) error {
+	if c == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return fmt.Errorf("error encoding cache value: %w", err)
+	}
+
+	if err := c.client.Set(ctx, keyPrefix+key, buf.Bytes(), c.ttl).Err(); err != nil {
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if c == nil || len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = keyPrefix + key
+	}
+
+	if err := c.client.Del(ctx, prefixed...).Err(); err != nil {
+		return fmt.Errorf("error invalidating cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.client.Close()
+}
@@ -0,0 +1,166 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCalComBaseURL = "https://api.cal.com/v2"
+	calComSlotsVersion   = "2024-09-04"
+	calComBookingVersion = "2024-08-13"
+)
+
+// CalComConfig reads availability from Cal.com event types, so working hours
+// and meeting length are configured there.
+type CalComConfig struct {
+	APIKey  string
+	BaseURL string
+}
+
+type CalCom struct {
+	cfg    CalComConfig
+	client *http.Client
+}
+
+func NewCalCom(cfg CalComConfig, client *http.Client) (*CalCom, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("CALCOM_API_KEY is required for the calcom provider")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultCalComBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &CalCom{cfg: cfg, client: client}, nil
+}
+
+type calComSlotsResponse struct {
+	Data map[string][]struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+	} `json:"data"`
+}
+
+func (c *CalCom) AvailableSlots(ctx context.Context, calendarID string, from, to time.Time) ([]Slot, error) {
+	query := url.Values{
+		"eventTypeId": {calendarID},
+		"start":       {from.UTC().Format(time.RFC3339)},
+		"end":         {to.UTC().Format(time.RFC3339)},
+		"format":      {"range"},
+	}
+
+	var resp calComSlotsResponse
+	if err := c.do(ctx, http.MethodGet, "/slots?"+query.Encode(), calComSlotsVersion, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	slots := []Slot{}
+	for _, day := range resp.Data {
+		for _, slot := range day {
+			slots = append(slots, Slot{Start: slot.Start, End: slot.End})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+
+	return slots, nil
+}
+
+type calComAttendee struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	TimeZone string `json:"timeZone"`
+}
+
+type calComBookingRequest struct {
+	Start       time.Time      `json:"start"`
+	EventTypeID int            `json:"eventTypeId"`
+	Attendee    calComAttendee `json:"attendee"`
+	// Cal.com has no description field; notes are shown on the booking.
+	BookingFieldsResponses map[string]string `json:"bookingFieldsResponses,omitempty"`
+}
+
+type calComBookingResponse struct {
+	Data struct {
+		UID        string `json:"uid"`
+		MeetingURL string `json:"meetingUrl"`
+		Location   string `json:"location"`
+	} `json:"data"`
+}
+
+// Book creates a booking for the event type. The meeting length comes from
+// the event type, so only the slot's start is used.
+func (c *CalCom) Book(ctx context.Context, calendarID string, event *Event) (*Booking, error) {
+	eventTypeID, err := strconv.Atoi(calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("cal.com: calendar ID must be a numeric event type ID, got %q", calendarID)
+	}
+
+	payload := calComBookingRequest{
+		Start:       event.Slot.Start.UTC(),
+		EventTypeID: eventTypeID,
+		Attendee:    calComAttendee{Name: event.AttendeeName, Email: event.AttendeeEmail, TimeZone: "UTC"},
+	}
+	if event.Description != "" {
+		payload.BookingFieldsResponses = map[string]string{"notes": event.Description}
+	}
+
+	var resp calComBookingResponse
+	if err := c.do(ctx, http.MethodPost, "/bookings", calComBookingVersion, payload, &resp); err != nil {
+		return nil, err
+	}
+
+	booking := &Booking{ID: resp.Data.UID, URL: resp.Data.MeetingURL}
+	if booking.URL == "" {
+		booking.URL = resp.Data.Location
+	}
+	return booking, nil
+}
+
+type calComRescheduleRequest struct {
+	Start time.Time `json:"start"`
+}
+
+// Reschedule rebooks the booking at the slot's start. Cal.com cancels the
+// old booking and returns a new one.
+func (c *CalCom) Reschedule(ctx context.Context, calendarID, bookingID string, slot Slot) (*Booking, error) {
+	payload := calComRescheduleRequest{Start: slot.Start.UTC()}
+
+	var resp calComBookingResponse
+	path := "/bookings/" + url.PathEscape(bookingID) + "/reschedule"
+	if err := c.do(ctx, http.MethodPost, path, calComBookingVersion, payload, &resp); err != nil {
+		return nil, err
+	}
+
+	booking := &Booking{ID: resp.Data.UID, URL: resp.Data.MeetingURL}
+	if booking.URL == "" {
+		booking.URL = resp.Data.Location
+	}
+	return booking, nil
+}
+
+type calComCancelRequest struct {
+	CancellationReason string `json:"cancellationReason,omitempty"`
+}
+
+func (c *CalCom) Cancel(ctx context.Context, calendarID, bookingID, reason string) error {
+	path := "/bookings/" + url.PathEscape(bookingID) + "/cancel"
+	return c.do(ctx, http.MethodPost, path, calComBookingVersion, calComCancelRequest{CancellationReason: reason}, nil)
+}
+
+func (c *CalCom) do(ctx context.Context, method, path, version string, body, out interface{}) error {
+	headers := map[string]string{
+		"Authorization":   "Bearer " + c.cfg.APIKey,
+		"cal-api-version": version,
+	}
+	if err := doJSON(ctx, c.client, method, c.cfg.BaseURL+path, headers, body, out); err != nil {
+		return fmt.Errorf("cal.com: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package calendar finds free meeting slots and books meetings with leads
+// through a calendar provider.
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Slot is a bookable period [Start, End).
+type Slot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Event is a meeting to put on a calendar.
+type Event struct {
+	Title         string
+	Description   string
+	Slot          Slot
+	AttendeeName  string
+	AttendeeEmail string
+}
+
+type Booking struct {
+	ID string
+	// URL is the video call link, or the event page when the provider
+	// created no call.
+	URL string
+}
+
+// Provider reads availability from and books events on a calendar. The
+// calendar ID is provider specific: a Google calendar ID or a Cal.com event
+// type ID.
+type Provider interface {
+	AvailableSlots(ctx context.Context, calendarID string, from, to time.Time) ([]Slot, error)
+	Book(ctx context.Context, calendarID string, event *Event) (*Booking, error)
+	// Reschedule moves a booking to slot. Providers that rebook return a
+	// booking with a new ID.
+	Reschedule(ctx context.Context, calendarID, bookingID string, slot Slot) (*Booking, error)
+	Cancel(ctx context.Context, calendarID, bookingID, reason string) error
+}
+
+var ErrNotConfigured = errors.New("calendar provider is not configured")
+
+const defaultTimeout = 15 * time.Second
+
+// Config selects and configures the calendar provider.
+type Config struct {
+	// Provider is "google", "calcom" or empty, which yields a provider that
+	// always fails with ErrNotConfigured.
+	Provider string
+	Google   GoogleConfig
+	CalCom   CalComConfig
+}
+
+// New builds the provider selected by cfg.Provider.
+func New(cfg Config) (Provider, error) {
+	client := &http.Client{Timeout: defaultTimeout}
+
+	switch cfg.Provider {
+	case "":
+		return disabledProvider{}, nil
+	case "google":
+		return NewGoogle(cfg.Google, client)
+	case "calcom":
+		return NewCalCom(cfg.CalCom, client)
+	default:
+		return nil, fmt.Errorf("unknown calendar provider %q", cfg.Provider)
+	}
+}
+
+type disabledProvider struct{}
+
+func (disabledProvider) AvailableSlots(ctx context.Context, calendarID string, from, to time.Time) ([]Slot, error) {
+	return nil, ErrNotConfigured
+}
+
+func (disabledProvider) Book(ctx context.Context, calendarID string, event *Event) (*Booking, error) {
+	return nil, ErrNotConfigured
+}
+
+func (disabledProvider) Reschedule(ctx context.Context, calendarID, bookingID string, slot Slot) (*Booking, error) {
+	return nil, ErrNotConfigured
+}
+
+func (disabledProvider) Cancel(ctx context.Context, calendarID, bookingID, reason string) error {
+	return ErrNotConfigured
+}
+
+// WorkingHours are the weekday hours in which slots are offered, for
+// providers that do not manage availability themselves.
+type WorkingHours struct {
+	Location *time.Location
+	// Start and End are offsets from midnight.
+	Start time.Duration
+	End   time.Duration
+	Slot  time.Duration
+}
+
+// DefaultWorkingHours are 09:00 to 17:00 UTC in 30 minute slots.
+func DefaultWorkingHours() WorkingHours {
+	return WorkingHours{Location: time.UTC, Start: 9 * time.Hour, End: 17 * time.Hour, Slot: 30 * time.Minute}
+}
+
+// ParseWorkingHours parses a "HH:MM-HH:MM" range into offsets from midnight.
+func ParseWorkingHours(value string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(value, "-")
+	start, err = clockOffset(from)
+	end, endErr := clockOffset(to)
+	if !ok || err != nil || endErr != nil || end <= start {
+		return 0, 0, fmt.Errorf("invalid working hours %q: use HH:MM-HH:MM", value)
+	}
+	return start, end, nil
+}
+
+func clockOffset(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// FreeSlots splits the working hours between from and to into slots and
+// drops those overlapping a busy period or starting before from.
+func (h WorkingHours) FreeSlots(from, to time.Time, busy []Slot) []Slot {
+	slots := []Slot{}
+
+	from, to = from.In(h.Location), to.In(h.Location)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, h.Location); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		dayEnd := h.clock(day, h.End)
+		for start := h.clock(day, h.Start); !start.Add(h.Slot).After(dayEnd); start = start.Add(h.Slot) {
+			slot := Slot{Start: start, End: start.Add(h.Slot)}
+			if slot.Start.Before(from) || slot.End.After(to) || overlapsAny(slot, busy) {
+				continue
+			}
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots
+}
+
+// clock returns the wall-clock time offset from midnight on day, which stays
+// correct on days when daylight saving time changes.
+func (h WorkingHours) clock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(offset.Minutes()), 0, 0, h.Location)
+}
+
+func overlapsAny(slot Slot, busy []Slot) bool {
+	for _, period := range busy {
+		if slot.Start.Before(period.End) && period.Start.Before(slot.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// doJSON sends body, if any, to url and decodes a successful response into
+// out, unless out is nil.
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	googleTokenEndpoint    = "https://oauth2.googleapis.com/token"
+	googleCalendarEndpoint = "https://www.googleapis.com/calendar/v3"
+)
+
+// GoogleConfig authenticates as the calendar owner with an OAuth refresh
+// token that has the calendar scope.
+type GoogleConfig struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	Hours        WorkingHours
+}
+
+type Google struct {
+	cfg    GoogleConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewGoogle(cfg GoogleConfig, client *http.Client) (*Google, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.RefreshToken == "" {
+		return nil, errors.New("GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET and GOOGLE_REFRESH_TOKEN are required for the google provider")
+	}
+	return &Google{cfg: cfg, client: client}, nil
+}
+
+type googleTimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type googleFreeBusyItem struct {
+	ID string `json:"id"`
+}
+
+type googleFreeBusyRequest struct {
+	TimeMin time.Time            `json:"timeMin"`
+	TimeMax time.Time            `json:"timeMax"`
+	Items   []googleFreeBusyItem `json:"items"`
+}
+
+type googleFreeBusyResponse struct {
+	Calendars map[string]struct {
+		Busy   []googleTimeRange `json:"busy"`
+		Errors []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	} `json:"calendars"`
+}
+
+// AvailableSlots offers the working-hour slots in which the calendar is free.
+func (g *Google) AvailableSlots(ctx context.Context, calendarID string, from, to time.Time) ([]Slot, error) {
+	payload := googleFreeBusyRequest{TimeMin: from, TimeMax: to, Items: []googleFreeBusyItem{{ID: calendarID}}}
+
+	var resp googleFreeBusyResponse
+	if err := g.do(ctx, http.MethodPost, googleCalendarEndpoint+"/freeBusy", payload, &resp); err != nil {
+		return nil, err
+	}
+
+	calendar, ok := resp.Calendars[calendarID]
+	if !ok {
+		return nil, fmt.Errorf("google calendar: no availability returned for %q", calendarID)
+	}
+	if len(calendar.Errors) > 0 {
+		return nil, fmt.Errorf("google calendar: %s", calendar.Errors[0].Reason)
+	}
+
+	busy := make([]Slot, 0, len(calendar.Busy))
+	for _, period := range calendar.Busy {
+		busy = append(busy, Slot{Start: period.Start, End: period.End})
+	}
+
+	return g.cfg.Hours.FreeSlots(from, to, busy), nil
+}
+
+type googleDateTime struct {
+	DateTime time.Time `json:"dateTime"`
+}
+
+type googleAttendee struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type googleEvent struct {
+	Summary        string           `json:"summary"`
+	Description    string           `json:"description,omitempty"`
+	Start          googleDateTime   `json:"start"`
+	End            googleDateTime   `json:"end"`
+	Attendees      []googleAttendee `json:"attendees"`
+	ConferenceData struct {
+		CreateRequest struct {
+			RequestID             string `json:"requestId"`
+			ConferenceSolutionKey struct {
+				Type string `json:"type"`
+			} `json:"conferenceSolutionKey"`
+		} `json:"createRequest"`
+	} `json:"conferenceData"`
+}
+
+type googleEventResponse struct {
+	ID          string `json:"id"`
+	HTMLLink    string `json:"htmlLink"`
+	HangoutLink string `json:"hangoutLink"`
+}
+
+// Book creates the event with a Google Meet call and emails the attendee an
+// invitation.
+func (g *Google) Book(ctx context.Context, calendarID string, event *Event) (*Booking, error) {
+	payload := googleEvent{
+		Summary:     event.Title,
+		Description: event.Description,
+		Start:       googleDateTime{DateTime: event.Slot.Start},
+		End:         googleDateTime{DateTime: event.Slot.End},
+		Attendees:   []googleAttendee{{Email: event.AttendeeEmail, DisplayName: event.AttendeeName}},
+	}
+	payload.ConferenceData.CreateRequest.RequestID = fmt.Sprintf("%s-%d", event.AttendeeEmail, event.Slot.Start.Unix())
+	payload.ConferenceData.CreateRequest.ConferenceSolutionKey.Type = "hangoutsMeet"
+
+	endpoint := googleCalendarEndpoint + "/calendars/" + url.PathEscape(calendarID) +
+		"/events?sendUpdates=all&conferenceDataVersion=1"
+
+	var resp googleEventResponse
+	if err := g.do(ctx, http.MethodPost, endpoint, payload, &resp); err != nil {
+		return nil, err
+	}
+
+	booking := &Booking{ID: resp.ID, URL: resp.HangoutLink}
+	if booking.URL == "" {
+		booking.URL = resp.HTMLLink
+	}
+	return booking, nil
+}
+
+type googleEventTimes struct {
+	Start googleDateTime `json:"start"`
+	End   googleDateTime `json:"end"`
+}
+
+// Reschedule moves the event and emails the attendee the update.
+func (g *Google) Reschedule(ctx context.Context, calendarID, bookingID string, slot Slot) (*Booking, error) {
+	payload := googleEventTimes{Start: googleDateTime{DateTime: slot.Start}, End: googleDateTime{DateTime: slot.End}}
+
+	var resp googleEventResponse
+	if err := g.do(ctx, http.MethodPatch, g.eventURL(calendarID, bookingID), payload, &resp); err != nil {
+		return nil, err
+	}
+
+	booking := &Booking{ID: resp.ID, URL: resp.HangoutLink}
+	if booking.URL == "" {
+		booking.URL = resp.HTMLLink
+	}
+	return booking, nil
+}
+
+// Cancel deletes the event and emails the attendee the cancellation. Google
+// has no field for the reason.
+func (g *Google) Cancel(ctx context.Context, calendarID, bookingID, reason string) error {
+	return g.do(ctx, http.MethodDelete, g.eventURL(calendarID, bookingID), nil, nil)
+}
+
+func (g *Google) eventURL(calendarID, eventID string) string {
+	return googleCalendarEndpoint + "/calendars/" + url.PathEscape(calendarID) +
+		"/events/" + url.PathEscape(eventID) + "?sendUpdates=all"
+}
+
+func (g *Google) do(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	token, err := g.token(ctx)
+	if err != nil {
+		return fmt.Errorf("google calendar: %w", err)
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	if err := doJSON(ctx, g.client, method, endpoint, headers, body, out); err != nil {
+		return fmt.Errorf("google calendar: %w", err)
+	}
+	return nil
+}
+
+type googleTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// token returns a cached access token, refreshing it shortly before it
+// expires.
+func (g *Google) token(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.accessToken != "" && time.Until(g.expiresAt) > time.Minute {
+		return g.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {g.cfg.ClientID},
+		"client_secret": {g.cfg.ClientSecret},
+		"refresh_token": {g.cfg.RefreshToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error building token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("refreshing access token: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var token googleTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("error decoding token response: %w", err)
+	}
+
+	g.accessToken = token.AccessToken
+	g.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return g.accessToken, nil
+}
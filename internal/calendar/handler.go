@@ -0,0 +1,167 @@
+package calendar
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+// ReschedulePath and CancelPath are where Handler is mounted.
+const (
+	ReschedulePath = "/meetings/reschedule"
+	CancelPath     = "/meetings/cancel"
+)
+
+var page = template.Must(template.New("meeting").Funcs(template.FuncMap{
+	"slot":  describeSlot,
+	"value": formatSlot,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>Your meeting</title></head>
+<body style="font-family: sans-serif; max-width: 32em; margin: 4em auto;">
+{{if .Message}}
+<p>{{.Message}}</p>
+{{else if .Cancel}}
+<p>Cancel your meeting on {{slot .Current}}?</p>
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<p><label>Reason (optional)<br><textarea name="reason" rows="3" cols="40"></textarea></label></p>
+<button type="submit">Cancel meeting</button>
+</form>
+{{else if .Slots}}
+<p>Your meeting is on {{slot .Current}}. Pick a new time:</p>
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+{{range .Slots}}<p><label><input type="radio" name="slot" value="{{value .}}" required> {{slot .}}</label></p>
+{{end}}<button type="submit">Reschedule</button>
+</form>
+{{else}}
+<p>There are no free times in the next two weeks. Please reply to your invitation to find another time.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// Handler serves the reschedule and cancel links in meeting invites. GET
+// shows the meeting with a form, so link scanners cannot change it; POST
+// reschedules or cancels it.
+func Handler(service *Service, signer *Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.FormValue("token")
+		interactionID, err := signer.Verify(token)
+		if err != nil {
+			http.Error(w, "invalid meeting link", http.StatusBadRequest)
+			return
+		}
+
+		// Links carry no user, and interaction IDs are globally unique.
+		ctx := tenant.WithSystem(r.Context())
+		log := logging.FromContext(ctx).With("interaction_id", interactionID)
+		cancel := r.URL.Path == CancelPath
+		data := map[string]interface{}{"Token": token, "Cancel": cancel}
+
+		switch r.Method {
+		case http.MethodGet:
+			meeting, _, err := service.changeableMeeting(ctx, interactionID)
+			if err != nil {
+				renderError(w, log, err)
+				return
+			}
+			data["Current"] = Slot{Start: meeting.StartsAt, End: meeting.EndsAt}
+			if !cancel {
+				if data["Slots"], err = service.RescheduleSlots(ctx, interactionID); err != nil {
+					renderError(w, log, err)
+					return
+				}
+			}
+			render(w, data)
+
+		case http.MethodPost:
+			if cancel {
+				var reason *string
+				if text := strings.TrimSpace(r.PostFormValue("reason")); text != "" {
+					reason = &text
+				}
+				if _, err := service.CancelMeeting(ctx, interactionID, reason); err != nil {
+					renderError(w, log, err)
+					return
+				}
+				render(w, map[string]interface{}{"Message": "Your meeting has been cancelled."})
+				return
+			}
+
+			slot, ok := parseSlot(r.PostFormValue("slot"))
+			if !ok {
+				http.Error(w, "pick a time to reschedule to", http.StatusBadRequest)
+				return
+			}
+			meeting, err := service.RescheduleMeeting(ctx, interactionID, slot)
+			if err != nil {
+				renderError(w, log, err)
+				return
+			}
+			render(w, map[string]interface{}{
+				"Message": "Your meeting has been moved to " + describeSlot(Slot{Start: meeting.StartsAt, End: meeting.EndsAt}) + ". We have emailed you an updated invitation.",
+			})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// formatSlot encodes a slot for the reschedule form.
+func formatSlot(slot Slot) string {
+	return slot.Start.UTC().Format(time.RFC3339) + "/" + slot.End.UTC().Format(time.RFC3339)
+}
+
+// parseSlot parses a slot as submitted by the reschedule form.
+func parseSlot(value string) (Slot, bool) {
+	start, end, ok := strings.Cut(value, "/")
+	if !ok {
+		return Slot{}, false
+	}
+	var slot Slot
+	var err error
+	if slot.Start, err = time.Parse(time.RFC3339, start); err != nil {
+		return Slot{}, false
+	}
+	if slot.End, err = time.Parse(time.RFC3339, end); err != nil {
+		return Slot{}, false
+	}
+	return slot, true
+}
+
+// renderError shows the lead why their meeting cannot be changed, or a
+// generic error for failures they cannot act on.
+func renderError(w http.ResponseWriter, log *slog.Logger, err error) {
+	var message string
+	switch {
+	case errors.Is(err, ErrMeetingNotFound):
+		message = "This meeting could not be found."
+	case errors.Is(err, ErrMeetingCancelled):
+		message = "This meeting has been cancelled."
+	case errors.Is(err, ErrMeetingStarted):
+		message = "This meeting has already started and can no longer be changed."
+	case errors.Is(err, ErrInvalidSlot):
+		message = "That time is no longer available. Please go back and pick another."
+	default:
+		log.Error("error changing meeting", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	render(w, map[string]interface{}{"Message": message})
+}
+
+func render(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		slog.Error("error rendering meeting page", "error", err)
+	}
+}
@@ -0,0 +1,122 @@
+package calendar
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Invite methods, from RFC 5546: a request adds or updates the event on the
+// attendee's calendar and a cancel removes it.
+const (
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// icsEvent is a meeting as an RFC 5545 event.
+type icsEvent struct {
+	UID      string
+	Sequence int
+	Method   string
+	Summary  string
+	// Description and URL are optional.
+	Description string
+	URL         string
+	Slot        Slot
+	Stamp       time.Time
+	// Organizer is optional.
+	Organizer     string
+	OrganizerName string
+	Attendee      string
+	AttendeeName  string
+}
+
+// ics encodes e as an iCalendar object with a single event.
+func (e *icsEvent) ics() []byte {
+	var b bytes.Buffer
+	line := func(name, value string) {
+		b.WriteString(fold(name + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	status := "CONFIRMED"
+	if e.Method == MethodCancel {
+		status = "CANCELLED"
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//salesagency//meetings//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", e.Method)
+	line("BEGIN", "VEVENT")
+	line("UID", e.UID)
+	line("SEQUENCE", strconv.Itoa(e.Sequence))
+	line("DTSTAMP", icsTime(e.Stamp))
+	line("DTSTART", icsTime(e.Slot.Start))
+	line("DTEND", icsTime(e.Slot.End))
+	line("SUMMARY", icsText(e.Summary))
+	if e.Description != "" {
+		line("DESCRIPTION", icsText(e.Description))
+	}
+	if e.URL != "" {
+		line("LOCATION", icsText(e.URL))
+		line("URL", e.URL)
+	}
+	line("STATUS", status)
+	if e.Organizer != "" {
+		line("ORGANIZER"+icsName(e.OrganizerName), "mailto:"+e.Organizer)
+	}
+	line("ATTENDEE"+icsName(e.AttendeeName)+";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=FALSE", "mailto:"+e.Attendee)
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+
+	return b.Bytes()
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// icsText escapes a TEXT value.
+func icsText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
+
+// icsName returns a CN parameter for name, or "" when name is empty.
+// Parameter values cannot contain double quotes, so they are dropped.
+func icsName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '"' || r < ' ' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		return ""
+	}
+	return `;CN="` + name + `"`
+}
+
+// fold splits a content line into lines of at most 75 octets, each after the
+// first starting with a space, without splitting a UTF-8 sequence.
+func fold(line string) string {
+	const max = 75
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > max {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
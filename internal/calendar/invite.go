@@ -0,0 +1,138 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/messaging/email"
+	"salesagency/internal/outbox"
+)
+
+// KindInvite messages email a lead a meeting invite.
+const KindInvite = "meeting_invite"
+
+// Invites configures the invites emailed to leads when meetings are booked,
+// rescheduled or cancelled.
+type Invites struct {
+	// Organizer and OrganizerName are who invites are from, usually the
+	// email sender. Invites have no organizer when Organizer is empty.
+	Organizer     string
+	OrganizerName string
+	// Links signs the reschedule and cancel links in invites.
+	Links *Signer
+}
+
+type invitePayload struct {
+	To      string `json:"to"`
+	ToName  string `json:"toName"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Method  string `json:"method"`
+	ICS     string `json:"ics"`
+}
+
+// invite builds a message that emails the lead the meeting as an ICS
+// attachment: a request for a booked or rescheduled meeting, or a cancel.
+func (s *Service) invite(meeting *database.Meeting, lead *model.Lead, method string) (*model.OutboxMessage, error) {
+	slot := Slot{Start: meeting.StartsAt, End: meeting.EndsAt}
+	title := meetingTitle(lead)
+
+	var subject, body string
+	switch {
+	case method == MethodCancel:
+		subject = "Cancelled: " + title
+		body = fmt.Sprintf("Your meeting on %s has been cancelled.", describeSlot(slot))
+	case meeting.Sequence > 0:
+		subject = "Updated invitation: " + title
+		body = fmt.Sprintf("Your meeting has been moved to %s.", describeSlot(slot))
+	default:
+		subject = "Invitation: " + title
+		body = fmt.Sprintf("Your meeting is booked for %s.", describeSlot(slot))
+	}
+
+	var url string
+	if method != MethodCancel {
+		if meeting.URL != nil {
+			url = *meeting.URL
+			body += "\n\nJoin: " + url
+		}
+		if s.invites.Links != nil {
+			if link := s.invites.Links.RescheduleURL(meeting.InteractionID); link != "" {
+				body += "\n\nTo reschedule: " + link
+				body += "\nTo cancel: " + s.invites.Links.CancelURL(meeting.InteractionID)
+			}
+		}
+	}
+
+	event := &icsEvent{
+		UID:           meeting.InteractionID + "@salesagency",
+		Sequence:      meeting.Sequence,
+		Method:        method,
+		Summary:       title,
+		Description:   body,
+		URL:           url,
+		Slot:          slot,
+		Stamp:         time.Now(),
+		Organizer:     s.invites.Organizer,
+		OrganizerName: s.invites.OrganizerName,
+		Attendee:      lead.Email,
+		AttendeeName:  lead.Name,
+	}
+
+	payload, err := json.Marshal(invitePayload{
+		To:      lead.Email,
+		ToName:  lead.Name,
+		Subject: subject,
+		Body:    body,
+		Method:  method,
+		ICS:     string(event.ics()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding meeting invite: %w", err)
+	}
+
+	return &model.OutboxMessage{Kind: KindInvite, Payload: string(payload)}, nil
+}
+
+// InviteHandler delivers KindInvite messages through sender, with the event
+// attached as invite.ics.
+func InviteHandler(sender email.Sender) outbox.Handler {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload invitePayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("error decoding meeting invite: %w", err)
+		}
+
+		_, err := sender.Send(ctx, &email.Message{
+			To:      payload.To,
+			ToName:  payload.ToName,
+			Subject: payload.Subject,
+			Body:    payload.Body,
+			Attachments: []email.Attachment{{
+				Filename:    "invite.ics",
+				ContentType: "text/calendar; charset=utf-8; method=" + payload.Method,
+				Data:        []byte(payload.ICS),
+			}},
+		})
+		return err
+	}
+}
+
+// meetingTitle names a meeting with the lead.
+func meetingTitle(lead *model.Lead) string {
+	title := "Meeting with " + lead.Name
+	if lead.Company != nil && *lead.Company != "" {
+		title += " (" + *lead.Company + ")"
+	}
+	return title
+}
+
+// describeSlot formats a slot in UTC, such as "Mon, 02 Jan 2006 15:00:00 UTC
+// to 15:30 UTC".
+func describeSlot(slot Slot) string {
+	return fmt.Sprintf("%s to %s", slot.Start.UTC().Format(time.RFC1123), slot.End.UTC().Format("15:04 MST"))
+}
@@ -0,0 +1,348 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/pipeline"
+)
+
+const (
+	// maxRange bounds availability lookups.
+	maxRange = 31 * 24 * time.Hour
+	// rescheduleRange is how far ahead slots are offered to reschedule to.
+	rescheduleRange = 14 * 24 * time.Hour
+)
+
+var (
+	ErrLeadNotFound     = errors.New("lead not found")
+	ErrAgentNotFound    = errors.New("ai agent not found")
+	ErrNoCalendar       = errors.New("no calendar is configured for this agent")
+	ErrInvalidSlot      = errors.New("invalid meeting slot")
+	ErrMeetingNotFound  = errors.New("meeting not found")
+	ErrMeetingCancelled = errors.New("meeting was cancelled")
+	ErrMeetingStarted   = errors.New("meeting has already started")
+)
+
+// Change is a booked meeting that was rescheduled or cancelled.
+type Change struct {
+	Meeting *database.Meeting
+	Lead    *model.Lead
+	// Previous is when the meeting was before it was rescheduled, or nil
+	// when it was cancelled.
+	Previous *Slot
+	// Reason is the reason given for cancelling, if any.
+	Reason *string
+}
+
+// ChangeHook runs after each meeting is rescheduled or cancelled.
+type ChangeHook func(ctx context.Context, change *Change)
+
+// Service books meetings with leads on AI agents' calendars. Agents without
+// a calendar of their own use the default calendar.
+type Service struct {
+	db              *database.DB
+	provider        Provider
+	pipeline        *pipeline.Service
+	defaultCalendar string
+	invites         Invites
+	hooks           []ChangeHook
+}
+
+func NewService(db *database.DB, provider Provider, pipeline *pipeline.Service, defaultCalendar string, invites Invites) *Service {
+	return &Service{db: db, provider: provider, pipeline: pipeline, defaultCalendar: defaultCalendar, invites: invites}
+}
+
+// OnChange registers a hook to run when a meeting is rescheduled or
+// cancelled.
+func (s *Service) OnChange(hook ChangeHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// AvailableSlots lists the free slots on the agent's calendar in [from, to).
+func (s *Service) AvailableSlots(ctx context.Context, agentID string, from, to time.Time) ([]Slot, error) {
+	if !to.After(from) || to.Sub(from) > maxRange {
+		return nil, fmt.Errorf("date range must end after it starts and span at most %d days", int(maxRange.Hours()/24))
+	}
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+
+	calendarID, _, err := s.calendarFor(ctx, &agentID)
+	if err != nil {
+		return nil, err
+	}
+	if !to.After(from) {
+		return []Slot{}, nil
+	}
+
+	return s.provider.AvailableSlots(ctx, calendarID, from, to)
+}
+
+// BookMeeting puts a meeting with the lead on the agent's calendar, or the
+// default calendar when agentID is nil. It records a scheduled MEETING
+// interaction, emails the lead an invite, makes the meeting the lead's next
+// follow-up and advances the lead to MEETING unless it is already further
+// along. The lead and the interaction are returned.
+func (s *Service) BookMeeting(ctx context.Context, leadID string, agentID *string, slot Slot, bookedBy *string) (*model.Lead, *model.Interaction, error) {
+	if !slot.End.After(slot.Start) || !slot.Start.After(time.Now()) {
+		return nil, nil, fmt.Errorf("%w: it must start in the future and end after it starts", ErrInvalidSlot)
+	}
+
+	lead, err := s.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lead == nil {
+		return nil, nil, ErrLeadNotFound
+	}
+
+	calendarID, agent, err := s.calendarFor(ctx, agentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	booking, err := s.provider.Book(ctx, calendarID, &Event{
+		Title:         meetingTitle(lead),
+		Slot:          slot,
+		AttendeeName:  lead.Name,
+		AttendeeEmail: lead.Email,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message := "Meeting booked for " + describeSlot(slot)
+	meeting := &database.Meeting{
+		ExternalID: &booking.ID,
+		CalendarID: &calendarID,
+		BookedBy:   bookedBy,
+		StartsAt:   slot.Start,
+		EndsAt:     slot.End,
+	}
+	if booking.URL != "" {
+		message += "\n" + booking.URL
+		meeting.URL = &booking.URL
+	}
+
+	interaction := &model.Interaction{
+		Lead:       lead,
+		AIAgent:    agent,
+		Type:       model.InteractionTypeMeeting,
+		Channel:    model.ChannelOther,
+		Message:    &message,
+		Timestamp:  time.Now(),
+		Status:     model.InteractionStatusScheduled,
+		Direction:  model.InteractionDirectionOutbound,
+		ExternalID: &booking.ID,
+		CreatedAt:  time.Now(),
+	}
+
+	// The event exists from here on, so later failures are reported without
+	// undoing it.
+	if interaction, err = s.db.CreateMeeting(ctx, interaction, meeting); err != nil {
+		return nil, nil, fmt.Errorf("meeting %s was booked but not recorded: %w", booking.ID, err)
+	}
+	invite, err := s.invite(meeting, lead, MethodRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.db.EnqueueOutbox(ctx, invite); err != nil {
+		return nil, nil, err
+	}
+	if err := s.db.SetLeadNextFollowUp(ctx, leadID, slot.Start); err != nil {
+		return nil, nil, err
+	}
+
+	reason := "Meeting booked"
+	if lead, err = s.pipeline.Advance(ctx, leadID, model.LeadStatusMeeting, &reason, bookedBy); err != nil {
+		return nil, nil, err
+	}
+	interaction.Lead = lead
+
+	return lead, interaction, nil
+}
+
+// RescheduleSlots lists the free slots over the next two weeks that the
+// meeting booked as the given interaction can be moved to.
+func (s *Service) RescheduleSlots(ctx context.Context, interactionID string) ([]Slot, error) {
+	meeting, _, err := s.changeableMeeting(ctx, interactionID)
+	if err != nil {
+		return nil, err
+	}
+	calendarID, err := s.calendarOf(meeting)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return s.provider.AvailableSlots(ctx, calendarID, now, now.Add(rescheduleRange))
+}
+
+// RescheduleMeeting moves the meeting booked as the given interaction to
+// slot on its calendar, emails the lead an updated invite and moves the
+// lead's next follow-up with it. Change hooks run once it is recorded.
+func (s *Service) RescheduleMeeting(ctx context.Context, interactionID string, slot Slot) (*database.Meeting, error) {
+	if !slot.End.After(slot.Start) || !slot.Start.After(time.Now()) {
+		return nil, fmt.Errorf("%w: it must start in the future and end after it starts", ErrInvalidSlot)
+	}
+
+	meeting, lead, err := s.changeableMeeting(ctx, interactionID)
+	if err != nil {
+		return nil, err
+	}
+	calendarID, err := s.calendarOf(meeting)
+	if err != nil {
+		return nil, err
+	}
+
+	booking, err := s.provider.Reschedule(ctx, calendarID, *meeting.ExternalID, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := Slot{Start: meeting.StartsAt, End: meeting.EndsAt}
+	meeting.StartsAt, meeting.EndsAt = slot.Start, slot.End
+	meeting.ExternalID = &booking.ID
+	if booking.URL != "" {
+		meeting.URL = &booking.URL
+	}
+	meeting.Sequence++
+
+	invite, err := s.invite(meeting, lead, MethodRequest)
+	if err != nil {
+		return nil, err
+	}
+	// The event has moved from here on, so failures are reported without
+	// moving it back.
+	ok, err := s.db.RescheduleMeeting(ctx, meeting, previous.Start, invite)
+	if err != nil {
+		return nil, fmt.Errorf("meeting %s was rescheduled but not recorded: %w", interactionID, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("meeting %s was rescheduled but changed meanwhile", interactionID)
+	}
+
+	s.changed(ctx, &Change{Meeting: meeting, Lead: lead, Previous: &previous})
+	return meeting, nil
+}
+
+// CancelMeeting cancels the meeting booked as the given interaction on its
+// calendar, emails the lead the cancellation and clears the lead's next
+// follow-up if it was the meeting. Change hooks run once it is recorded.
+func (s *Service) CancelMeeting(ctx context.Context, interactionID string, reason *string) (*database.Meeting, error) {
+	meeting, lead, err := s.changeableMeeting(ctx, interactionID)
+	if err != nil {
+		return nil, err
+	}
+	calendarID, err := s.calendarOf(meeting)
+	if err != nil {
+		return nil, err
+	}
+
+	var why string
+	if reason != nil {
+		why = *reason
+	}
+	if err := s.provider.Cancel(ctx, calendarID, *meeting.ExternalID, why); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	meeting.CancelledAt = &now
+	meeting.Sequence++
+
+	invite, err := s.invite(meeting, lead, MethodCancel)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.db.CancelMeeting(ctx, meeting, invite)
+	if err != nil {
+		return nil, fmt.Errorf("meeting %s was cancelled but not recorded: %w", interactionID, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("meeting %s was cancelled but changed meanwhile", interactionID)
+	}
+
+	s.changed(ctx, &Change{Meeting: meeting, Lead: lead, Reason: reason})
+	return meeting, nil
+}
+
+// changeableMeeting returns the meeting booked as the given interaction and
+// its lead, unless the meeting was cancelled or has started.
+func (s *Service) changeableMeeting(ctx context.Context, interactionID string) (*database.Meeting, *model.Lead, error) {
+	meeting, err := s.db.GetMeeting(ctx, interactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meeting == nil || meeting.ExternalID == nil {
+		return nil, nil, ErrMeetingNotFound
+	}
+	if meeting.CancelledAt != nil {
+		return nil, nil, ErrMeetingCancelled
+	}
+	if !meeting.StartsAt.After(time.Now()) {
+		return nil, nil, ErrMeetingStarted
+	}
+
+	lead, err := s.db.GetLeadByID(ctx, meeting.LeadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lead == nil {
+		return nil, nil, ErrLeadNotFound
+	}
+	return meeting, lead, nil
+}
+
+// calendarOf returns the calendar a meeting was booked on, which is the
+// default calendar when none was recorded.
+func (s *Service) calendarOf(meeting *database.Meeting) (string, error) {
+	if meeting.CalendarID != nil && *meeting.CalendarID != "" {
+		return *meeting.CalendarID, nil
+	}
+	if s.defaultCalendar == "" {
+		return "", ErrNoCalendar
+	}
+	return s.defaultCalendar, nil
+}
+
+func (s *Service) changed(ctx context.Context, change *Change) {
+	for _, hook := range s.hooks {
+		hook(ctx, change)
+	}
+}
+
+// calendarFor resolves the calendar to use and the agent, which is nil when
+// agentID is.
+func (s *Service) calendarFor(ctx context.Context, agentID *string) (string, *model.AIAgent, error) {
+	calendarID := s.defaultCalendar
+
+	var agent *model.AIAgent
+	if agentID != nil {
+		var err error
+		if agent, err = s.db.GetAIAgentByID(ctx, *agentID); err != nil {
+			return "", nil, err
+		}
+		if agent == nil {
+			return "", nil, ErrAgentNotFound
+		}
+
+		own, err := s.db.GetAIAgentCalendarID(ctx, *agentID)
+		if err != nil {
+			return "", nil, err
+		}
+		if own != "" {
+			calendarID = own
+		}
+	}
+
+	if calendarID == "" {
+		return "", nil, ErrNoCalendar
+	}
+	return calendarID, agent, nil
+}
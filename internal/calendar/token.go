@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidToken = errors.New("invalid meeting link")
+
+// tokenPrefix keeps meeting tokens apart from other links signed with the
+// same secret.
+const tokenPrefix = "meeting|"
+
+// Signer creates and verifies the tokens in the reschedule and cancel links
+// of meeting invites. Tokens never expire; meetings that have started can no
+// longer be changed.
+type Signer struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewSigner signs tokens with secret. baseURL is the server's public address,
+// used to build links; links are not generated when it is empty.
+func NewSigner(secret, baseURL string) *Signer {
+	return &Signer{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Token returns a token for the meeting booked as the given interaction.
+func (s *Signer) Token(interactionID string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + interactionID))
+	return payload + "." + s.sign(payload)
+}
+
+// Verify returns the interaction of the meeting a token was issued for.
+func (s *Signer) Verify(token string) (string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return "", ErrInvalidToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	interactionID, ok := strings.CutPrefix(string(decoded), tokenPrefix)
+	if !ok || interactionID == "" {
+		return "", ErrInvalidToken
+	}
+	return interactionID, nil
+}
+
+// RescheduleURL returns the link to reschedule a meeting, or "" when no
+// public base URL is configured.
+func (s *Signer) RescheduleURL(interactionID string) string {
+	return s.url(ReschedulePath, interactionID)
+}
+
+// CancelURL returns the link to cancel a meeting, or "" when no public base
+// URL is configured.
+func (s *Signer) CancelURL(interactionID string) string {
+	return s.url(CancelPath, interactionID)
+}
+
+func (s *Signer) url(path, interactionID string) string {
+	if s.baseURL == "" {
+		return ""
+	}
+	return s.baseURL + path + "?token=" + url.QueryEscape(s.Token(interactionID))
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,136 @@
+// Package calls records phone calls as CALL interactions, whether logged by
+// hand or reported by a VoIP provider.
+package calls
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+const ProviderManual = "manual"
+
+var (
+	ErrLeadNotFound    = errors.New("lead not found")
+	ErrAgentNotFound   = errors.New("AI agent not found")
+	ErrInvalidDuration = errors.New("call duration must not be negative")
+)
+
+// Log is a call to record against a lead.
+type Log struct {
+	LeadID          string
+	AIAgentID       *string
+	Outcome         model.CallOutcome
+	Direction       model.InteractionDirection
+	DurationSeconds *int
+	RecordingURL    *string
+	Notes           *string
+	Provider        string
+	ProviderCallID  *string
+	At              time.Time
+}
+
+type Service struct {
+	db  *database.DB
+	now func() time.Time
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// LogCall records the call as a CALL interaction on the PHONE channel.
+// Provider calls are attributed to the lead's most recently assigned agent
+// when no agent is given.
+func (s *Service) LogCall(ctx context.Context, log *Log) (*model.Interaction, error) {
+	if log.DurationSeconds != nil && *log.DurationSeconds < 0 {
+		return nil, ErrInvalidDuration
+	}
+	if log.RecordingURL != nil && strings.TrimSpace(*log.RecordingURL) == "" {
+		log.RecordingURL = nil
+	}
+
+	lead, err := s.db.GetLeadByID(ctx, log.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, ErrLeadNotFound
+	}
+
+	if log.Provider == "" {
+		log.Provider = ProviderManual
+	}
+	if log.Direction == "" {
+		log.Direction = model.InteractionDirectionOutbound
+	}
+	if log.At.IsZero() {
+		log.At = s.now()
+	}
+
+	interaction := &model.Interaction{
+		Lead:       lead,
+		Type:       model.InteractionTypeCall,
+		Channel:    model.ChannelPhone,
+		Timestamp:  log.At,
+		Status:     interactionStatus(log.Outcome),
+		Direction:  log.Direction,
+		ExternalID: log.ProviderCallID,
+		Notes:      log.Notes,
+		CreatedAt:  s.now(),
+	}
+
+	agentID := log.AIAgentID
+	if agentID != nil {
+		agent, err := s.db.GetAIAgentByID(ctx, *agentID)
+		if err != nil {
+			return nil, err
+		}
+		if agent == nil {
+			return nil, ErrAgentNotFound
+		}
+	} else if log.Provider != ProviderManual {
+		id, err := s.db.GetLeadLatestAIAgentID(ctx, lead.ID)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			agentID = &id
+		}
+	}
+	if agentID != nil {
+		interaction.AIAgent = &model.AIAgent{ID: *agentID}
+	}
+
+	_, err = s.db.CreateCall(ctx, interaction, &model.Call{
+		Outcome:         log.Outcome,
+		DurationSeconds: log.DurationSeconds,
+		RecordingURL:    log.RecordingURL,
+		Provider:        log.Provider,
+		ProviderCallID:  log.ProviderCallID,
+		CreatedAt:       interaction.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return interaction, nil
+}
+
+// interactionStatus maps a call outcome onto the interaction lifecycle: a
+// conversation counts as a response, a voicemail as delivered, and anything
+// else as failed.
+func interactionStatus(outcome model.CallOutcome) model.InteractionStatus {
+	switch outcome {
+	case model.CallOutcomeConnected:
+		return model.InteractionStatusResponded
+	case model.CallOutcomeVoicemail:
+		return model.InteractionStatusDelivered
+	default:
+		return model.InteractionStatusFailed
+	}
+}
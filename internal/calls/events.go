@@ -0,0 +1,143 @@
+package calls
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/messaging/aircall"
+	"salesagency/internal/messaging/twilio"
+	"salesagency/internal/tenant"
+)
+
+const (
+	ProviderTwilio  = "twilio"
+	ProviderAircall = "aircall"
+)
+
+// Events turns provider webhooks into logged calls. Webhooks carry no user,
+// so lookups run with a system scope and the other party's number is matched
+// to the most recently contacted lead. A provider reporting a call again
+// updates the call already logged.
+type Events struct {
+	service *Service
+}
+
+func NewEvents(s *Service) *Events {
+	return &Events{service: s}
+}
+
+func (e *Events) HandleCall(ctx context.Context, update *twilio.CallUpdate) error {
+	ctx = tenant.WithSystem(ctx)
+
+	// Recording callbacks arrive separately, after the status callback.
+	if update.Status == "" {
+		if update.RecordingURL == "" {
+			return nil
+		}
+		_, err := e.service.db.UpdateProviderCall(ctx, ProviderTwilio, update.CallSID, nil, nil, &update.RecordingURL)
+		return err
+	}
+
+	var outcome model.CallOutcome
+	switch update.Status {
+	case "completed":
+		outcome = model.CallOutcomeConnected
+		if strings.HasPrefix(update.AnsweredBy, "machine") {
+			outcome = model.CallOutcomeVoicemail
+		}
+	case "busy":
+		outcome = model.CallOutcomeBusy
+	case "no-answer", "canceled":
+		outcome = model.CallOutcomeNoAnswer
+	case "failed":
+		outcome = model.CallOutcomeFailed
+	default:
+		return nil
+	}
+
+	direction, number := model.InteractionDirectionOutbound, update.To
+	if update.Direction == "inbound" {
+		direction, number = model.InteractionDirectionInbound, update.From
+	}
+
+	log := &Log{
+		Outcome:         outcome,
+		Direction:       direction,
+		DurationSeconds: update.DurationSeconds,
+		Provider:        ProviderTwilio,
+		ProviderCallID:  &update.CallSID,
+	}
+	if update.RecordingURL != "" {
+		log.RecordingURL = &update.RecordingURL
+	}
+	return e.record(ctx, number, log)
+}
+
+func (e *Events) HandleCallEnded(ctx context.Context, call *aircall.Call) error {
+	ctx = tenant.WithSystem(ctx)
+
+	outcome := model.CallOutcomeNoAnswer
+	switch {
+	case call.AnsweredAt != nil:
+		outcome = model.CallOutcomeConnected
+	case call.Voicemail != "":
+		outcome = model.CallOutcomeVoicemail
+	}
+
+	direction := model.InteractionDirectionOutbound
+	if call.Direction == "inbound" {
+		direction = model.InteractionDirectionInbound
+	}
+
+	id := strconv.FormatInt(call.ID, 10)
+	duration := call.Duration
+	log := &Log{
+		Outcome:         outcome,
+		Direction:       direction,
+		DurationSeconds: &duration,
+		Provider:        ProviderAircall,
+		ProviderCallID:  &id,
+	}
+	if recording := call.Recording; recording != "" {
+		log.RecordingURL = &recording
+	} else if call.Voicemail != "" {
+		log.RecordingURL = &call.Voicemail
+	}
+	return e.record(ctx, call.RawDigits, log)
+}
+
+func (e *Events) record(ctx context.Context, number string, log *Log) error {
+	var leadID string
+	if d := digits(number); d != "" {
+		var err error
+		if leadID, err = e.service.db.GetLeadIDByPhone(ctx, d); err != nil {
+			return err
+		}
+	}
+	if leadID == "" {
+		logging.FromContext(ctx).Info("ignoring call with unknown number", "provider", log.Provider, "call_id", *log.ProviderCallID)
+		return nil
+	}
+	log.LeadID = leadID
+
+	_, err := e.service.LogCall(ctx, log)
+	if errors.Is(err, database.ErrDuplicateCall) {
+		_, err = e.service.db.UpdateProviderCall(ctx, log.Provider, *log.ProviderCallID, &log.Outcome, log.DurationSeconds, log.RecordingURL)
+	}
+	return err
+}
+
+func digits(number string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, number)
+}
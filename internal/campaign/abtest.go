@@ -0,0 +1,150 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+
+	"salesagency/graph/model"
+)
+
+// SignificanceLevel is the false positive rate tolerated across all the
+// comparisons against the control variant of one metric.
+const SignificanceLevel = 0.05
+
+var ErrNoVariants = errors.New("campaign has no A/B test variants")
+
+// AssignVariant picks the variant to send the lead. Leads stay on the
+// variant they were first sent, even after its weight is lowered, so each
+// lead only ever sees one side of the test.
+func (s *Service) AssignVariant(ctx context.Context, campaignID, leadID string) (*model.CampaignVariant, error) {
+	assigned, err := s.db.GetLeadCampaignVariant(ctx, campaignID, leadID)
+	if err != nil || assigned != nil {
+		return assigned, err
+	}
+
+	variants, err := s.db.GetCampaignVariants(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := PickVariant(variants, campaignID, leadID)
+	if variant == nil {
+		return nil, ErrNoVariants
+	}
+	return variant, nil
+}
+
+// PickVariant chooses among variants in proportion to their weights. The
+// choice is a hash of the campaign and lead, so it is stable for as long as
+// the weights are. It returns nil when no variant has a positive weight.
+func PickVariant(variants []*model.CampaignVariant, campaignID, leadID string) *model.CampaignVariant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(campaignID))
+	h.Write([]byte{0})
+	h.Write([]byte(leadID))
+	point := int(h.Sum64() % uint64(total))
+
+	for _, v := range variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return nil
+}
+
+// ABTestResults compares each variant of the campaign with the control, the
+// oldest variant. Rates are per lead sent; significance comes from a two-sided
+// two-proportion z-test with a Bonferroni correction for the number of
+// challengers.
+func (s *Service) ABTestResults(ctx context.Context, campaignID string) ([]*model.VariantResult, error) {
+	counts, err := s.db.GetCampaignVariantCounts(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*model.VariantResult, 0, len(counts))
+	if len(counts) == 0 {
+		return results, nil
+	}
+
+	control := counts[0]
+	alpha := SignificanceLevel
+	if len(counts) > 2 {
+		alpha /= float64(len(counts) - 1)
+	}
+
+	for i, c := range counts {
+		result := &model.VariantResult{
+			Variant:     c.Variant,
+			Control:     i == 0,
+			Leads:       c.Leads,
+			Opens:       c.Opens,
+			Replies:     c.Replies,
+			Conversions: c.Conversions,
+		}
+		if i == 0 {
+			result.OpenRate = &model.RateComparison{Rate: rate(c.Opens, c.Leads)}
+			result.ReplyRate = &model.RateComparison{Rate: rate(c.Replies, c.Leads)}
+			result.ConversionRate = &model.RateComparison{Rate: rate(c.Conversions, c.Leads)}
+		} else {
+			result.OpenRate = compare(c.Opens, c.Leads, control.Opens, control.Leads, alpha)
+			result.ReplyRate = compare(c.Replies, c.Leads, control.Replies, control.Leads, alpha)
+			result.ConversionRate = compare(c.Conversions, c.Leads, control.Conversions, control.Leads, alpha)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func compare(x, n, controlX, controlN int, alpha float64) *model.RateComparison {
+	cmp := &model.RateComparison{Rate: rate(x, n)}
+
+	if controlRate := rate(controlX, controlN); n > 0 && controlRate > 0 {
+		lift := (cmp.Rate - controlRate) / controlRate
+		cmp.Lift = &lift
+	}
+	if p, ok := twoProportionPValue(x, n, controlX, controlN); ok {
+		cmp.PValue = &p
+		cmp.Significant = p < alpha
+	}
+
+	return cmp
+}
+
+func rate(x, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return float64(x) / float64(n)
+}
+
+// twoProportionPValue is the two-sided p-value of the pooled z-test that the
+// success rates x1/n1 and x2/n2 are equal. ok is false when either sample is
+// empty.
+func twoProportionPValue(x1, n1, x2, n2 int) (p float64, ok bool) {
+	if n1 == 0 || n2 == 0 {
+		return 0, false
+	}
+
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		// Both samples are all successes or all failures.
+		return 1, true
+	}
+
+	z := (rate(x1, n1) - rate(x2, n2)) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2), true
+}
@@ -0,0 +1,173 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/outbox"
+)
+
+// BudgetAlertThreshold is the share of its budget a campaign may spend
+// before the budget alert webhook is called.
+const BudgetAlertThreshold = 0.8
+
+var (
+	ErrBudgetExhausted = errors.New("campaign has spent its budget")
+	ErrInvalidSpend    = errors.New("spend amount must not be negative")
+)
+
+// BudgetAlert is the JSON body posted to the budget alert webhook.
+type BudgetAlert struct {
+	CampaignID  string    `json:"campaignId"`
+	Campaign    string    `json:"campaign"`
+	Budget      float64   `json:"budget"`
+	SpendToDate float64   `json:"spendToDate"`
+	Threshold   float64   `json:"threshold"`
+	Paused      bool      `json:"paused"`
+	At          time.Time `json:"at"`
+}
+
+// BudgetAlertHook runs once a campaign's spend reaches BudgetAlertThreshold
+// of its budget.
+type BudgetAlertHook func(ctx context.Context, alert *BudgetAlert)
+
+// SetMessageCosts sets what each message sent for a campaign costs, by
+// channel.
+func (s *Service) SetMessageCosts(costs map[model.Channel]float64) {
+	s.messageCosts = costs
+}
+
+// SetBudgetAlertWebhook makes the service post a BudgetAlert to url, through
+// the outbox, once a campaign's spend reaches BudgetAlertThreshold of its
+// budget.
+func (s *Service) SetBudgetAlertWebhook(url string) {
+	s.alertURL = url
+}
+
+// OnBudgetAlert registers a hook to run, once per budget, when a campaign's
+// spend reaches BudgetAlertThreshold of its budget.
+func (s *Service) OnBudgetAlert(hook BudgetAlertHook) {
+	s.alertHooks = append(s.alertHooks, hook)
+}
+
+// RecordSpend adds an entry to the campaign's spend ledger, then alerts on
+// and enforces the campaign's budget: an ACTIVE campaign whose spend reaches
+// its budget is paused.
+func (s *Service) RecordSpend(ctx context.Context, spend *model.CampaignSpend) (*model.CampaignSpend, error) {
+	if spend.Amount < 0 {
+		return nil, ErrInvalidSpend
+	}
+	if spend.CreatedAt.IsZero() {
+		spend.CreatedAt = s.now()
+	}
+
+	budget, err := s.db.RecordCampaignSpend(ctx, spend)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrNotFound
+	}
+
+	s.enforceBudget(ctx, budget)
+
+	return spend, nil
+}
+
+// RecordMessageSpend records the configured cost of a message delivered for
+// the campaign. It has the signature of a channels.SendHook.
+func (s *Service) RecordMessageSpend(ctx context.Context, interaction *model.Interaction, campaignID string) {
+	cost := s.messageCosts[interaction.Channel]
+	if cost == 0 {
+		return
+	}
+
+	description := fmt.Sprintf("%s message", interaction.Channel)
+	_, err := s.RecordSpend(ctx, &model.CampaignSpend{
+		CampaignID:    campaignID,
+		InteractionID: &interaction.ID,
+		Kind:          model.SpendKindMessage,
+		Amount:        cost,
+		Description:   &description,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to record message spend", "campaign_id", campaignID, "interaction_id", interaction.ID, "error", err)
+	}
+}
+
+// SpendToDate returns the total recorded against the campaign.
+func (s *Service) SpendToDate(ctx context.Context, campaignID string) (float64, error) {
+	budget, err := s.db.GetCampaignBudget(ctx, campaignID)
+	if err != nil {
+		return 0, err
+	}
+	if budget == nil {
+		return 0, ErrNotFound
+	}
+	return budget.Spent, nil
+}
+
+func (s *Service) enforceBudget(ctx context.Context, budget *database.CampaignBudget) {
+	if budget.Budget == nil || *budget.Budget <= 0 {
+		return
+	}
+	limit := *budget.Budget
+	log := logging.FromContext(ctx).With("campaign_id", budget.CampaignID)
+
+	paused := false
+	if budget.Spent >= limit && budget.Status == model.CampaignStatusActive {
+		_, err := s.transitionByID(ctx, budget.CampaignID, model.CampaignStatusPaused)
+		switch {
+		case err == nil:
+			paused = true
+			log.Info("Paused campaign that reached its budget", "budget", limit, "spent", budget.Spent)
+		case !errors.Is(err, ErrInvalidTransition):
+			log.Error("Failed to pause campaign over budget", "error", err)
+		}
+	}
+
+	if (s.alertURL == "" && len(s.alertHooks) == 0) || budget.Spent < limit*BudgetAlertThreshold {
+		return
+	}
+
+	alert := &BudgetAlert{
+		CampaignID:  budget.CampaignID,
+		Campaign:    budget.Name,
+		Budget:      limit,
+		SpendToDate: budget.Spent,
+		Threshold:   BudgetAlertThreshold,
+		Paused:      paused,
+		At:          s.now(),
+	}
+
+	// The webhook is enqueued in the same transaction that claims the
+	// alert, so a campaign alerts once per budget and a failed webhook call
+	// is retried.
+	var webhook *model.OutboxMessage
+	if s.alertURL != "" {
+		var err error
+		webhook, err = outbox.Webhook(s.alertURL, alert)
+		if err != nil {
+			log.Error("Failed to build budget alert", "error", err)
+			return
+		}
+	}
+
+	claimed, err := s.db.ClaimBudgetAlert(ctx, budget.CampaignID, limit, webhook)
+	if err != nil {
+		log.Error("Failed to claim budget alert", "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	for _, hook := range s.alertHooks {
+		hook(ctx, alert)
+	}
+}
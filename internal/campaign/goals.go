@@ -0,0 +1,169 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+)
+
+// GoalPacingGrace is the share of a goal's window that must pass before the
+// pacing monitor judges it: early on, a handful of sends says little about
+// the rate a campaign will convert at.
+const GoalPacingGrace = 0.1
+
+var (
+	ErrInvalidGoal = errors.New("goal target and days must be positive")
+	ErrGoalNoStart = errors.New("goal needs a start date")
+)
+
+// GoalAtRisk describes a goal the campaign is projected to miss.
+type GoalAtRisk struct {
+	CampaignID string
+	Campaign   string
+	Progress   *model.CampaignGoalProgress
+}
+
+// GoalAtRiskHook runs when a goal is first projected to miss its target. It
+// runs again only after the goal has been back on pace.
+type GoalAtRiskHook func(ctx context.Context, risk *GoalAtRisk)
+
+// OnGoalAtRisk registers a hook to run when the pacing monitor finds a goal
+// slipping.
+func (s *Service) OnGoalAtRisk(hook GoalAtRiskHook) {
+	s.goalHooks = append(s.goalHooks, hook)
+}
+
+// SetGoal creates or replaces the campaign's goal for the goal's metric. The
+// goal's window starts with the campaign unless it names another start.
+func (s *Service) SetGoal(ctx context.Context, goal *model.CampaignGoal) (*model.CampaignGoal, error) {
+	if goal.Target <= 0 || goal.Days <= 0 {
+		return nil, ErrInvalidGoal
+	}
+	if goal.StartsAt.IsZero() {
+		c, err := s.get(ctx, goal.CampaignID)
+		if err != nil {
+			return nil, err
+		}
+		goal.StartsAt = c.StartDate
+	}
+	if goal.StartsAt.IsZero() {
+		return nil, ErrGoalNoStart
+	}
+
+	saved, err := s.db.SetCampaignGoal(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, ErrNotFound
+	}
+	return saved, nil
+}
+
+// GoalProgress compares what the campaign has achieved towards goal with the
+// pace the goal needs, and projects where it will finish if it keeps
+// contacting leads and converting them at its current rates.
+func (s *Service) GoalProgress(ctx context.Context, goal *model.CampaignGoal) (*model.CampaignGoalProgress, error) {
+	end := goal.EndsAt()
+	now := s.now()
+	if now.After(end) {
+		now = end
+	}
+
+	progress := &model.CampaignGoalProgress{Goal: goal}
+	if now.Before(goal.StartsAt) {
+		progress.OnPace = true
+		return progress, nil
+	}
+
+	actuals, err := s.db.GetGoalActuals(ctx, goal.CampaignID, goal.StartsAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch goal.Metric {
+	case model.CampaignGoalMetricReplies:
+		progress.Actual = actuals.Replies
+	case model.CampaignGoalMetricMeetingsBooked:
+		progress.Actual = actuals.MeetingsBooked
+	case model.CampaignGoalMetricConversions:
+		progress.Actual = actuals.Conversions
+	}
+	progress.ContactedLeads = actuals.ContactedLeads
+
+	elapsed := now.Sub(goal.StartsAt).Hours() / 24
+	remaining := end.Sub(now).Hours() / 24
+	progress.ElapsedDays = elapsed
+	progress.Expected = float64(goal.Target) * elapsed / float64(goal.Days)
+
+	projected := float64(progress.Actual)
+	if actuals.ContactedLeads > 0 {
+		rate := float64(progress.Actual) / float64(actuals.ContactedLeads)
+		progress.ConversionRate = &rate
+		if elapsed > 0 {
+			pace := float64(actuals.ContactedLeads) / elapsed
+			projected += rate * pace * remaining
+		}
+	}
+	progress.Projected = math.Round(projected*10) / 10
+	progress.OnPace = progress.Projected >= float64(goal.Target)
+
+	return progress, nil
+}
+
+// CheckPacing projects every open goal of an ACTIVE campaign and marks those
+// headed for a miss, running the GoalAtRisk hooks for each that has just
+// slipped. Goals back on pace are unmarked. It returns how many goals were
+// newly marked.
+func (s *Service) CheckPacing(ctx context.Context) (int, error) {
+	now := s.now()
+	goals, err := s.db.GetPacedCampaignGoals(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, goal := range goals {
+		log := logging.FromContext(ctx).With("campaign_id", goal.CampaignID, "metric", goal.Metric)
+
+		window := goal.EndsAt().Sub(goal.StartsAt)
+		if now.Sub(goal.StartsAt) < time.Duration(float64(window)*GoalPacingGrace) {
+			continue
+		}
+
+		progress, err := s.GoalProgress(ctx, goal)
+		if err != nil {
+			log.Error("Failed to compute goal progress", "error", err)
+			continue
+		}
+
+		var atRisk *time.Time
+		if !progress.OnPace {
+			atRisk = &now
+		}
+		changed, err := s.db.MarkCampaignGoalAtRisk(ctx, goal.CampaignID, goal.Metric, atRisk)
+		if err != nil {
+			log.Error("Failed to mark goal pacing", "error", err)
+			continue
+		}
+		if !changed || atRisk == nil {
+			continue
+		}
+
+		marked++
+		goal.AtRiskSince = atRisk
+		risk := &GoalAtRisk{CampaignID: goal.CampaignID, Campaign: goal.CampaignID, Progress: progress}
+		if c, err := s.db.GetCampaignByID(ctx, goal.CampaignID); err == nil && c != nil {
+			risk.Campaign = c.Name
+		}
+		for _, hook := range s.goalHooks {
+			hook(ctx, risk)
+		}
+	}
+
+	return marked, nil
+}
@@ -0,0 +1,87 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+)
+
+var (
+	ErrCampaignClosed = errors.New("campaign is completed or cancelled")
+	ErrLeadsNotFound  = errors.New("one or more leads were not found")
+	ErrNotEnrolled    = errors.New("lead is not enrolled in the campaign")
+)
+
+// maxEnrollBatch caps how many leads one EnrollLeads call takes.
+const maxEnrollBatch = 5000
+
+// EnrollLeads enrolls the leads in the campaign. Leads that had exited it
+// are enrolled again. Closed campaigns take no new leads.
+func (s *Service) EnrollLeads(ctx context.Context, campaignID string, leadIDs []string, enrolledBy *string) ([]*model.CampaignLead, error) {
+	if len(leadIDs) > maxEnrollBatch {
+		return nil, fmt.Errorf("at most %d leads can be enrolled at once", maxEnrollBatch)
+	}
+
+	c, err := s.get(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if closed(c.Status) {
+		return nil, ErrCampaignClosed
+	}
+
+	seen := make(map[string]bool, len(leadIDs))
+	unique := make([]string, 0, len(leadIDs))
+	for _, id := range leadIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	enrollments, err := s.db.EnrollCampaignLeads(ctx, c.ID, unique, enrolledBy)
+	if err != nil {
+		return nil, err
+	}
+	if enrollments == nil {
+		return nil, ErrLeadsNotFound
+	}
+	return enrollments, nil
+}
+
+// RemoveLead takes the lead out of the campaign, leaving its enrollment as
+// EXITED.
+func (s *Service) RemoveLead(ctx context.Context, campaignID, leadID string) (*model.CampaignLead, error) {
+	enrollment, err := s.db.ExitCampaignLead(ctx, campaignID, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrNotEnrolled
+	}
+	return enrollment, nil
+}
+
+// TrackMessage moves the lead's enrollment to IN_PROGRESS when the campaign
+// first messages it, enrolling leads that were messaged without being
+// enrolled. It has the signature of a channels.SendHook.
+func (s *Service) TrackMessage(ctx context.Context, interaction *model.Interaction, campaignID string) {
+	if err := s.db.MarkCampaignLeadContacted(ctx, campaignID, interaction.Lead.ID, interaction.Timestamp); err != nil {
+		logging.FromContext(ctx).Error("Failed to track campaign lead", "campaign_id", campaignID, "lead_id", interaction.Lead.ID, "error", err)
+	}
+}
+
+// HandleReply marks the replying lead's IN_PROGRESS enrollments as REPLIED.
+// It has the signature of a channels.ReplyHook.
+func (s *Service) HandleReply(ctx context.Context, reply *model.Interaction) {
+	if err := s.db.MarkCampaignLeadsReplied(ctx, reply.Lead.ID, reply.Timestamp); err != nil {
+		logging.FromContext(ctx).Error("Failed to track campaign lead reply", "lead_id", reply.Lead.ID, "error", err)
+	}
+}
+
+func closed(status model.CampaignStatus) bool {
+	return status == model.CampaignStatusCompleted || status == model.CampaignStatusCancelled
+}
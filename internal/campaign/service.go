@@ -0,0 +1,159 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+var (
+	ErrNotFound          = errors.New("campaign not found")
+	ErrInvalidTransition = errors.New("invalid campaign status transition")
+)
+
+// transitions is the campaign lifecycle:
+//
+//	DRAFT → SCHEDULED → ACTIVE ⇄ PAUSED → COMPLETED
+//
+// with CANCELLED reachable from every non-terminal state.
+var transitions = map[model.CampaignStatus][]model.CampaignStatus{
+	model.CampaignStatusDraft:     {model.CampaignStatusScheduled, model.CampaignStatusActive, model.CampaignStatusCancelled},
+	model.CampaignStatusScheduled: {model.CampaignStatusActive, model.CampaignStatusCancelled},
+	model.CampaignStatusActive:    {model.CampaignStatusPaused, model.CampaignStatusCompleted, model.CampaignStatusCancelled},
+	model.CampaignStatusPaused:    {model.CampaignStatusActive, model.CampaignStatusCompleted, model.CampaignStatusCancelled},
+}
+
+func CanTransition(from, to model.CampaignStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Service struct {
+	db  *database.DB
+	now func() time.Time
+
+	messageCosts map[model.Channel]float64
+	alertURL     string
+	alertHooks   []BudgetAlertHook
+	goalHooks    []GoalAtRiskHook
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// Start launches a DRAFT or PAUSED campaign. Draft campaigns whose start date
+// is still in the future become SCHEDULED and are activated by ActivateDue.
+// Campaigns that have spent their budget cannot be started.
+func (s *Service) Start(ctx context.Context, id string) (*model.Campaign, error) {
+	c, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	to := model.CampaignStatusActive
+	if c.Status == model.CampaignStatusDraft && c.StartDate.After(s.now()) {
+		to = model.CampaignStatusScheduled
+	}
+
+	if c.Budget != nil && *c.Budget > 0 {
+		budget, err := s.db.GetCampaignBudget(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		if budget != nil && budget.Spent >= *c.Budget {
+			return nil, ErrBudgetExhausted
+		}
+	}
+
+	return s.transition(ctx, c, to)
+}
+
+func (s *Service) Pause(ctx context.Context, id string) (*model.Campaign, error) {
+	return s.transitionByID(ctx, id, model.CampaignStatusPaused)
+}
+
+func (s *Service) Complete(ctx context.Context, id string) (*model.Campaign, error) {
+	return s.transitionByID(ctx, id, model.CampaignStatusCompleted)
+}
+
+func (s *Service) Cancel(ctx context.Context, id string) (*model.Campaign, error) {
+	return s.transitionByID(ctx, id, model.CampaignStatusCancelled)
+}
+
+// ActivateDue moves SCHEDULED campaigns whose start date has arrived to ACTIVE.
+func (s *Service) ActivateDue(ctx context.Context) (int, error) {
+	ids, err := s.db.GetDueScheduledCampaignIDs(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	activated := 0
+	for _, id := range ids {
+		if _, err := s.transitionByID(ctx, id, model.CampaignStatusActive); err != nil {
+			if errors.Is(err, ErrInvalidTransition) {
+				continue
+			}
+			return activated, err
+		}
+		activated++
+	}
+
+	return activated, nil
+}
+
+func (s *Service) transitionByID(ctx context.Context, id string, to model.CampaignStatus) (*model.Campaign, error) {
+	c, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, c, to)
+}
+
+func (s *Service) transition(ctx context.Context, c *model.Campaign, to model.CampaignStatus) (*model.Campaign, error) {
+	if !CanTransition(c.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, c.Status, to)
+	}
+
+	// Agents only work while a campaign is live: resume them on activation
+	// and stop them whenever the campaign leaves the ACTIVE state.
+	var agentStatus *model.AgentStatus
+	switch to {
+	case model.CampaignStatusActive:
+		status := model.AgentStatusActive
+		agentStatus = &status
+	case model.CampaignStatusPaused, model.CampaignStatusCompleted, model.CampaignStatusCancelled:
+		status := model.AgentStatusPaused
+		agentStatus = &status
+	}
+
+	ok, err := s.db.TransitionCampaignStatus(ctx, c.ID, c.Status, to, agentStatus)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Someone else changed the status since we read it.
+		return nil, fmt.Errorf("%w: campaign status changed concurrently", ErrInvalidTransition)
+	}
+
+	return s.db.GetCampaignByID(ctx, c.ID)
+}
+
+func (s *Service) get(ctx context.Context, id string) (*model.Campaign, error) {
+	c, err := s.db.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
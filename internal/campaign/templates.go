@@ -0,0 +1,134 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("campaign template not found")
+	ErrClientNotFound   = errors.New("client not found")
+	ErrInvalidDates     = errors.New("campaign end date must not be before its start date")
+)
+
+// Clone copies the campaign's target audiences, message templates,
+// sequences, A/B split and agents into a new DRAFT campaign. Leads, spend
+// and results are not copied. Unless overridden, the copy keeps the
+// original's settings and is named after it.
+func (s *Service) Clone(ctx context.Context, id string, overrides *model.CampaignOverridesInput) (*model.Campaign, error) {
+	source, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bp, err := s.db.GetCampaignBlueprint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if bp == nil {
+		return nil, ErrNotFound
+	}
+
+	c := &model.Campaign{
+		Name:      source.Name + " (copy)",
+		ClientID:  source.ClientID,
+		StartDate: source.StartDate,
+	}
+	return s.create(ctx, c, bp, overrides)
+}
+
+// SaveTemplate adds the campaign's setup to the agency's template library.
+func (s *Service) SaveTemplate(ctx context.Context, campaignID, name string, description, createdBy *string) (*model.CampaignTemplate, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("template name is required")
+	}
+
+	bp, err := s.db.GetCampaignBlueprint(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if bp == nil {
+		return nil, ErrNotFound
+	}
+
+	return s.db.CreateCampaignTemplate(ctx, &model.CampaignTemplate{
+		Name:             name,
+		Description:      description,
+		SourceCampaignID: &campaignID,
+		CreatedByID:      createdBy,
+		CreatedAt:        s.now(),
+	}, bp)
+}
+
+// Instantiate creates a DRAFT campaign from the template, typically for a
+// new client. Unless overridden, it is named after the template and starts
+// now.
+func (s *Service) Instantiate(ctx context.Context, templateID string, overrides *model.CampaignOverridesInput) (*model.Campaign, error) {
+	template, bp, err := s.db.GetCampaignTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	c := &model.Campaign{Name: template.Name, StartDate: s.now()}
+	return s.create(ctx, c, bp, overrides)
+}
+
+// create fills c from bp and overrides and inserts it. An end date follows
+// an overridden start date, keeping the blueprint's duration.
+func (s *Service) create(ctx context.Context, c *model.Campaign, bp *database.CampaignBlueprint, overrides *model.CampaignOverridesInput) (*model.Campaign, error) {
+	c.Description = bp.Description
+	c.Budget = bp.Budget
+	c.Currency = bp.Currency
+	c.Status = model.CampaignStatusDraft
+	c.CreatedAt = s.now()
+
+	var endDate *time.Time
+	if overrides != nil {
+		if overrides.Name != nil {
+			c.Name = strings.TrimSpace(*overrides.Name)
+		}
+		if overrides.Description != nil {
+			c.Description = overrides.Description
+		}
+		if overrides.ClientID != nil {
+			client, err := s.db.GetClientByID(ctx, *overrides.ClientID)
+			if err != nil {
+				return nil, err
+			}
+			if client == nil {
+				return nil, ErrClientNotFound
+			}
+			c.ClientID = overrides.ClientID
+		}
+		if overrides.StartDate != nil {
+			c.StartDate = *overrides.StartDate
+		}
+		if overrides.Budget != nil {
+			c.Budget = overrides.Budget
+		}
+		endDate = overrides.EndDate
+	}
+	if c.Name == "" {
+		return nil, errors.New("campaign name is required")
+	}
+
+	if endDate == nil && bp.Duration != nil {
+		end := c.StartDate.Add(*bp.Duration)
+		endDate = &end
+	}
+	if endDate != nil && endDate.Before(c.StartDate) {
+		return nil, ErrInvalidDates
+	}
+	c.EndDate = endDate
+
+	return s.db.CreateCampaignFromBlueprint(ctx, c, bp)
+}
@@ -0,0 +1,296 @@
+// Package capture serves the public endpoint that web forms post leads to,
+// recording where each lead came from.
+package capture
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/distribution"
+	"salesagency/internal/events"
+	"salesagency/internal/logging"
+	"salesagency/internal/ratelimit"
+	"salesagency/internal/sendwindow"
+	"salesagency/internal/templates"
+	"salesagency/internal/tenant"
+	"salesagency/internal/validation"
+)
+
+// Path is where Handler is mounted.
+const Path = "/capture"
+
+// DefaultSource is the source of captured leads without a utm_source.
+const DefaultSource = "web form"
+
+const (
+	keyPrefix = "cap_"
+	maxBody   = 64 << 10
+	// maxValue bounds each field, so URLs with huge query strings do not
+	// end up in the database whole.
+	maxValue = 2048
+)
+
+var (
+	errMissingKey   = errors.New("key is required")
+	errInvalidKey   = errors.New("invalid key")
+	errNameEmail    = errors.New("name and email are required")
+	errInvalidEmail = errors.New("invalid email")
+)
+
+// GenerateKey returns a new random capture key.
+func GenerateKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating capture key: %w", err)
+	}
+	return keyPrefix + hex.EncodeToString(buf), nil
+}
+
+type response struct {
+	LeadID string `json:"leadId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler creates or updates a lead from a form post, either URL-encoded or
+// JSON, and records its attribution the first time the lead is captured.
+// The agency is identified by the key field. New leads are given to one of
+// the agency's campaigns by distributor. Callers are limited per IP address
+// by limiter.
+func Handler(db *database.DB, distributor *distribution.Service, broker *events.Broker, limiter *ratelimit.Limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Forms are embedded on any site, so allow cross-origin posts.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		switch r.Method {
+		case http.MethodOptions:
+			w.Header().Set("Access-Control-Allow-Methods", "POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodPost:
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, response{Error: "method not allowed"})
+			return
+		}
+
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+		if result := limiter.Allow(ip); !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())+1))
+			writeJSON(w, http.StatusTooManyRequests, response{Error: "too many requests"})
+			return
+		}
+
+		fields, err := readFields(w, r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+
+		key := fields["key"]
+		if key == "" {
+			writeJSON(w, http.StatusUnauthorized, response{Error: errMissingKey.Error()})
+			return
+		}
+		agencyID, err := db.GetAgencyIDByCaptureKey(r.Context(), key)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+		if agencyID == "" {
+			writeJSON(w, http.StatusUnauthorized, response{Error: errInvalidKey.Error()})
+			return
+		}
+		ctx := tenant.WithAgency(r.Context(), agencyID)
+
+		if fields["language"] == "" {
+			fields["language"] = BrowserLanguage(r)
+		}
+		lead, err := newLead(fields)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+
+		attribution := newAttribution(fields, r)
+		if attribution.CampaignID != nil {
+			// A form pointing at a campaign of another agency, or one that
+			// was deleted, must not lose the lead.
+			c, err := db.GetCampaignByID(ctx, *attribution.CampaignID)
+			if err != nil || c == nil {
+				attribution.CampaignID = nil
+			}
+		}
+
+		upserted, created, err := db.UpsertLead(ctx, lead, model.LeadMatchKeyEmail)
+		if errors.Is(err, database.ErrDuplicateLead) {
+			writeJSON(w, http.StatusConflict, response{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+
+		attribution.LeadID = upserted.ID
+		if _, err := db.CreateLeadAttribution(ctx, attribution); err != nil {
+			serverError(w, r, err)
+			return
+		}
+
+		if created {
+			inbound := distribution.Inbound{CampaignID: attribution.CampaignID, Industry: optional(fields, "industry")}
+			if _, err := distributor.Distribute(ctx, upserted, inbound); err != nil {
+				// The lead is saved and can still be enrolled by hand.
+				logging.FromContext(ctx).Error("Failed to distribute captured lead", "lead_id", upserted.ID, "error", err)
+			}
+		}
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+			broker.Publish(events.TopicLeadCreated, upserted)
+		} else {
+			broker.Publish(events.TopicLeadUpdated, upserted)
+		}
+
+		writeJSON(w, status, response{LeadID: upserted.ID})
+	})
+}
+
+// readFields returns the posted fields, trimmed and cut to maxValue.
+func readFields(w http.ResponseWriter, r *http.Request) (map[string]string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+
+	fields := make(map[string]string)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, errors.New("invalid request body: " + err.Error())
+		}
+		for name, value := range body {
+			if s, ok := value.(string); ok {
+				fields[name] = s
+			}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, errors.New("invalid request body: " + err.Error())
+		}
+		for name := range r.PostForm {
+			fields[name] = r.PostForm.Get(name)
+		}
+	}
+
+	for name, value := range fields {
+		value = strings.TrimSpace(value)
+		if len(value) > maxValue {
+			value = value[:maxValue]
+		}
+		fields[name] = value
+	}
+	return fields, nil
+}
+
+func newLead(fields map[string]string) (*model.Lead, error) {
+	if fields["name"] == "" || fields["email"] == "" {
+		return nil, errNameEmail
+	}
+	if _, err := mail.ParseAddress(fields["email"]); err != nil {
+		return nil, errInvalidEmail
+	}
+
+	source := DefaultSource
+	if fields["utm_source"] != "" {
+		source = fields["utm_source"]
+	}
+
+	lead := &model.Lead{
+		Name:        fields["name"],
+		Email:       fields["email"],
+		Phone:       optional(fields, "phone"),
+		Company:     optional(fields, "company"),
+		Position:    optional(fields, "position"),
+		Notes:       optional(fields, "notes"),
+		Language:    optional(fields, "language"),
+		Source:      &source,
+		Status:      model.LeadStatusNew,
+		IntentScore: 0.5,
+		CreatedAt:   time.Now(),
+	}
+	if err := validation.Lead(lead); err != nil {
+		return nil, err
+	}
+	sendwindow.DetectTimezone(lead)
+	templates.DetectLanguage(lead)
+	return lead, nil
+}
+
+// newAttribution reads the UTM parameters, the page the form is on and the
+// page that sent the visitor there. Forms that do not post landing_page get
+// the Referer header, which browsers set to the page the form was posted
+// from.
+func newAttribution(fields map[string]string, r *http.Request) *model.LeadAttribution {
+	attribution := &model.LeadAttribution{
+		UtmSource:   optional(fields, "utm_source"),
+		UtmMedium:   optional(fields, "utm_medium"),
+		UtmCampaign: optional(fields, "utm_campaign"),
+		UtmTerm:     optional(fields, "utm_term"),
+		UtmContent:  optional(fields, "utm_content"),
+		Referrer:    optional(fields, "referrer"),
+		LandingPage: optional(fields, "landing_page"),
+		CampaignID:  optional(fields, "campaign_id"),
+		CreatedAt:   time.Now(),
+	}
+	if attribution.LandingPage == nil {
+		if referer := r.Referer(); referer != "" && len(referer) <= maxValue {
+			attribution.LandingPage = &referer
+		}
+	}
+	return attribution
+}
+
+// BrowserLanguage returns the language the visitor's browser prefers, or ""
+// when it does not name one.
+func BrowserLanguage(r *http.Request) string {
+	preferred, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ := strings.Cut(preferred, ";")
+	language, err := validation.NormalizeLocale(tag)
+	if err != nil {
+		return ""
+	}
+	return language
+}
+
+func optional(fields map[string]string, name string) *string {
+	if value := fields[name]; value != "" {
+		return &value
+	}
+	return nil
+}
+
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).Error("error capturing lead", "error", err)
+	writeJSON(w, http.StatusInternalServerError, response{Error: http.StatusText(http.StatusInternalServerError)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error encoding capture response", "error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+)
+
+var (
+	ErrUnsupportedChannel = errors.New("channel is not supported")
+	ErrSendUnsupported    = errors.New("channel does not support automated sending")
+	ErrOptedOut           = errors.New("lead has opted out of this channel")
+	ErrQuietHours         = errors.New("lead is in quiet hours")
+	ErrNotPendingReview   = errors.New("interaction is not awaiting review")
+	ErrSendLimitReached   = errors.New("AI agent has reached its daily send limit")
+	ErrNotFailed          = errors.New("only failed outbound messages can be resent")
+	ErrUnverifiedIdentity = errors.New("sending identity is not verified")
+)
+
+// Outbound is a rendered message addressed to a lead.
+type Outbound struct {
+	Lead     *model.Lead
+	Subject  string
+	Body     string
+	Template *model.MessageTemplate
+	AIAgent  *model.AIAgent
+	// Variant is the campaign A/B test variant Template was picked as, if any.
+	Variant *model.CampaignVariant
+	// UnsubscribeURL is filled in by the Dispatcher when unsubscribe links
+	// are configured.
+	UnsubscribeURL string
+	// Identity is the verified sending identity a campaign email goes out
+	// from, filled in by the Dispatcher. Without one the configured sender
+	// is used.
+	Identity *model.SendingIdentity
+}
+
+type Delivery struct {
+	ExternalID string
+}
+
+// Channel sends messages over one medium. Implementations only deliver; the
+// Dispatcher owns interaction bookkeeping so every channel logs the same way.
+type Channel interface {
+	Channel() model.Channel
+	InteractionType() model.InteractionType
+	Send(ctx context.Context, msg *Outbound) (*Delivery, error)
+}
+
+// transitions lists the statuses an interaction may move to from each status.
+// Outbound messages are QUEUED until sent, SENT once the provider accepts
+// them and DELIVERED once it reports they arrived; they end FAILED when the
+// provider rejects them, or BOUNCED when the recipient's server does.
+var transitions = map[model.InteractionStatus][]model.InteractionStatus{
+	model.InteractionStatusQueued: {
+		model.InteractionStatusSent,
+		model.InteractionStatusFailed,
+	},
+	model.InteractionStatusSent: {
+		model.InteractionStatusDelivered,
+		model.InteractionStatusOpened,
+		model.InteractionStatusResponded,
+		model.InteractionStatusBounced,
+		model.InteractionStatusFailed,
+	},
+	model.InteractionStatusDelivered: {
+		model.InteractionStatusOpened,
+		model.InteractionStatusResponded,
+		model.InteractionStatusBounced,
+		model.InteractionStatusFailed,
+	},
+	model.InteractionStatusOpened: {
+		model.InteractionStatusResponded,
+	},
+	model.InteractionStatusFailed: {
+		model.InteractionStatusQueued,
+	},
+	model.InteractionStatusPendingReview: {
+		model.InteractionStatusQueued,
+		model.InteractionStatusRejected,
+	},
+}
+
+func CanTransition(from, to model.InteractionStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves an interaction to a new status, rejecting invalid jumps.
+func Transition(interaction *model.Interaction, to model.InteractionStatus) error {
+	if !CanTransition(interaction.Status, to) {
+		return fmt.Errorf("invalid interaction status transition %s -> %s", interaction.Status, to)
+	}
+	interaction.Status = to
+	return nil
+}
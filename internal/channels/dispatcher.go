@@ -0,0 +1,523 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/sendwindow"
+)
+
+type Dispatcher struct {
+	db          *database.DB
+	channels    map[model.Channel]Channel
+	replyHooks  []ReplyHook
+	sendHooks   []SendHook
+	unsubscribe UnsubscribeFunc
+	// duplicateWindow is how long after a template's message to a lead the
+	// same message is suppressed; zero sends it again.
+	duplicateWindow time.Duration
+}
+
+// UnsubscribeFunc returns the unsubscribe link for a lead on a channel, or ""
+// when there is none.
+type UnsubscribeFunc func(leadID string, channel model.Channel) string
+
+// ReplyHook runs after an inbound reply from a lead has been recorded.
+type ReplyHook func(ctx context.Context, reply *model.Interaction)
+
+// SendHook runs after a message sent for a campaign has been accepted by the
+// provider.
+type SendHook func(ctx context.Context, interaction *model.Interaction, campaignID string)
+
+func NewDispatcher(db *database.DB, channels ...Channel) *Dispatcher {
+	d := &Dispatcher{
+		db:       db,
+		channels: make(map[model.Channel]Channel),
+	}
+	for _, channel := range channels {
+		d.Register(channel)
+	}
+	return d
+}
+
+// Register adds or replaces the implementation for a channel.
+func (d *Dispatcher) Register(channel Channel) {
+	d.channels[channel.Channel()] = channel
+}
+
+// CanSend reports whether messages on channel are sent by a provider rather
+// than only logged.
+func (d *Dispatcher) CanSend(channel model.Channel) bool {
+	impl, ok := d.channels[channel]
+	if !ok {
+		return false
+	}
+	_, manual := impl.(*ManualChannel)
+	return !manual
+}
+
+// InteractionType returns the type of interactions on channel.
+func (d *Dispatcher) InteractionType(channel model.Channel) model.InteractionType {
+	if impl, ok := d.channels[channel]; ok {
+		return impl.InteractionType()
+	}
+	return model.InteractionTypeOther
+}
+
+// OnReply registers a hook to run after each recorded reply.
+func (d *Dispatcher) OnReply(hook ReplyHook) {
+	d.replyHooks = append(d.replyHooks, hook)
+}
+
+// OnSend registers a hook to run after each sent campaign message.
+func (d *Dispatcher) OnSend(hook SendHook) {
+	d.sendHooks = append(d.sendHooks, hook)
+}
+
+// SetUnsubscribeLinks makes outbound messages carry unsubscribe links.
+func (d *Dispatcher) SetUnsubscribeLinks(fn UnsubscribeFunc) {
+	d.unsubscribe = fn
+}
+
+// Send delivers msg over the given channel and records the resulting
+// interaction, SENT once the provider accepts it. A delivery failure is
+// recorded as a FAILED interaction and returned without an error so callers
+// can surface the status; transient failures are instead queued and retried
+// by ReleaseQueued, and the interaction returned QUEUED with the error as
+// its notes. Leads that opted out of the channel are never contacted and
+// yield ErrOptedOut.
+//
+// Messages from a campaign template outside the campaign's send window or
+// the lead's quiet hours are queued instead and returned as a QUEUED
+// interaction; ReleaseQueued sends them once both allow it. Other messages
+// in quiet hours yield ErrQuietHours.
+//
+// Messages from an AI agent count against the agent's send throttle for the
+// channel. Campaign messages over the day's limit are queued until the next
+// day; others yield ErrSendLimitReached.
+//
+// A template's message to a lead that repeats one sent on the same channel
+// within the duplicate window is not sent, and is returned as a SUPPRESSED
+// interaction recorded for audit, without an error.
+//
+// Campaign emails go out from the campaign's sending identity, or else its
+// client's default; one whose domain or DKIM key is not verified yields
+// ErrUnverifiedIdentity.
+func (d *Dispatcher) Send(ctx context.Context, channel model.Channel, msg *Outbound) (*model.Interaction, error) {
+	impl, ok := d.channels[channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, channel)
+	}
+
+	optedOut, err := d.db.IsOptedOut(ctx, msg.Lead.ID, channel)
+	if err != nil {
+		return nil, err
+	}
+	if optedOut {
+		return nil, fmt.Errorf("%w: %s", ErrOptedOut, channel)
+	}
+
+	now := time.Now()
+	body := msg.Body
+	interaction := &model.Interaction{
+		Lead:      msg.Lead,
+		Type:      impl.InteractionType(),
+		Channel:   channel,
+		Message:   &body,
+		AIAgent:   msg.AIAgent,
+		Template:  msg.Template,
+		Variant:   msg.Variant,
+		Timestamp: now,
+		Status:    model.InteractionStatusQueued,
+		CreatedAt: now,
+	}
+
+	suppressed, err := d.suppressDuplicate(ctx, interaction, msg.Subject)
+	if err != nil || suppressed != nil {
+		return suppressed, err
+	}
+
+	var campaignID string
+	if msg.Template != nil && msg.Template.Campaign != nil {
+		campaignID = msg.Template.Campaign.ID
+	}
+	msg.Identity, err = d.sendingIdentity(ctx, channel, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseAt, err := d.releaseTime(ctx, campaignID, msg.Lead, now)
+	if err != nil {
+		return nil, err
+	}
+	if releaseAt.After(now) {
+		if campaignID == "" {
+			return nil, fmt.Errorf("%w until %s", ErrQuietHours, releaseAt.Format(time.RFC3339))
+		}
+		interaction.Timestamp = releaseAt
+		return d.db.QueueInteraction(ctx, interaction, campaignID, msg.Subject, releaseAt, 0)
+	}
+
+	reserved, err := d.reserveSend(ctx, msg.AIAgent, channel, now)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		if campaignID == "" {
+			return nil, fmt.Errorf("%w on %s", ErrSendLimitReached, channel)
+		}
+		releaseAt = nextSendDay(now)
+		interaction.Timestamp = releaseAt
+		return d.db.QueueInteraction(ctx, interaction, campaignID, msg.Subject, releaseAt, 0)
+	}
+
+	retry, err := d.deliver(ctx, impl, msg, interaction)
+	if err != nil {
+		return nil, err
+	}
+	if retry {
+		if err := Transition(interaction, model.InteractionStatusQueued); err != nil {
+			return nil, err
+		}
+		releaseAt = now.Add(retryDelay(1))
+		interaction.Timestamp = releaseAt
+		return d.db.QueueInteraction(ctx, interaction, campaignID, msg.Subject, releaseAt, 1)
+	}
+
+	interaction, err = d.db.CreateSentInteraction(ctx, interaction, msg.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	d.sent(ctx, interaction, campaignID)
+
+	return interaction, nil
+}
+
+func (d *Dispatcher) sent(ctx context.Context, interaction *model.Interaction, campaignID string) {
+	if campaignID == "" || interaction.Status != model.InteractionStatusSent {
+		return
+	}
+	for _, hook := range d.sendHooks {
+		hook(ctx, interaction, campaignID)
+	}
+}
+
+// deliver sends msg and moves the QUEUED interaction to SENT or, with the
+// error as its notes, FAILED. retry reports whether the failure was transient,
+// so sending again later may succeed. Failed messages are not counted against
+// the agent's send limit.
+func (d *Dispatcher) deliver(ctx context.Context, impl Channel, msg *Outbound, interaction *model.Interaction) (retry bool, err error) {
+	if d.unsubscribe != nil {
+		msg.UnsubscribeURL = d.unsubscribe(msg.Lead.ID, interaction.Channel)
+	}
+
+	delivery, sendErr := impl.Send(ctx, msg)
+	if sendErr != nil {
+		d.unreserveSend(ctx, interaction.AIAgent, interaction.Channel, interaction.Timestamp)
+		failure := sendErr.Error()
+		interaction.Notes = &failure
+		return Transient(sendErr), Transition(interaction, model.InteractionStatusFailed)
+	}
+
+	if delivery.ExternalID != "" {
+		interaction.ExternalID = &delivery.ExternalID
+	}
+	return false, Transition(interaction, model.InteractionStatusSent)
+}
+
+// sendingIdentity returns the identity a campaign's emails go out from, or
+// nil when they use the configured sender, as do messages outside campaigns
+// and on other channels.
+func (d *Dispatcher) sendingIdentity(ctx context.Context, channel model.Channel, campaignID string) (*model.SendingIdentity, error) {
+	if campaignID == "" || channel != model.ChannelEmail {
+		return nil, nil
+	}
+	identity, err := d.db.GetCampaignSendingIdentity(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil && !identity.Verified() {
+		return nil, fmt.Errorf("%w: %s", ErrUnverifiedIdentity, identity.FromEmail)
+	}
+	return identity, nil
+}
+
+// releaseTime returns when a message to lead may be sent: now, or when the
+// campaign's send window, if any, is next open outside the lead's quiet
+// hours, both in the lead's time zone. campaignID is empty for messages
+// outside campaigns.
+func (d *Dispatcher) releaseTime(ctx context.Context, campaignID string, lead *model.Lead, now time.Time) (time.Time, error) {
+	var windows []*sendwindow.Window
+
+	if campaignID != "" {
+		raw, err := d.db.GetCampaignSendWindow(ctx, campaignID)
+		if err != nil {
+			return now, err
+		}
+		if raw != nil {
+			window, err := sendwindow.Parse(*raw)
+			if err != nil {
+				return now, err
+			}
+			windows = append(windows, window)
+		}
+	}
+
+	prefs, err := d.db.GetLeadContactPreferences(ctx, lead.ID)
+	if err != nil {
+		return now, err
+	}
+	if prefs != nil && prefs.QuietHours != nil {
+		quiet, err := sendwindow.Quiet(prefs.QuietHours.Start, prefs.QuietHours.End)
+		if err != nil {
+			return now, err
+		}
+		windows = append(windows, quiet)
+	}
+
+	return sendwindow.NextOpen(now, lead.Timezone, windows...), nil
+}
+
+// releaseBatchSize is how many queued messages ReleaseQueued claims at once.
+const releaseBatchSize = 100
+
+// ReleaseQueued sends the queued messages whose send window has opened and
+// returns how many were sent or failed. Messages of paused campaigns, or to
+// leads handed off to a sales rep, stay queued, as do messages over their
+// agent's send limit until the next day; those of completed or cancelled
+// campaigns, or to leads that were deleted or opted out meanwhile, are
+// recorded as FAILED without sending, as are campaign emails whose sending
+// identity is no longer verified. Messages that fail transiently are
+// tried again after retryDelay, up to maxSendAttempts times in all.
+func (d *Dispatcher) ReleaseQueued(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		queued, err := d.db.ClaimQueuedMessages(ctx, time.Now(), releaseBatchSize)
+		if err != nil {
+			return released, err
+		}
+
+		for _, q := range queued {
+			sent, err := d.release(ctx, q)
+			if err != nil {
+				slog.Error("Failed to release queued message", "interaction_id", q.Interaction.ID, "error", err)
+				continue
+			}
+			if sent {
+				released++
+			}
+		}
+
+		if len(queued) < releaseBatchSize {
+			return released, nil
+		}
+	}
+}
+
+func (d *Dispatcher) release(ctx context.Context, q *database.QueuedMessage) (bool, error) {
+	interaction := q.Interaction
+
+	impl, ok := d.channels[interaction.Channel]
+	if !ok {
+		return true, d.failQueued(ctx, interaction, fmt.Sprintf("%s: %s", ErrUnsupportedChannel, interaction.Channel))
+	}
+
+	lead, err := d.db.GetLeadByID(ctx, interaction.Lead.ID)
+	if err != nil {
+		return false, err
+	}
+	if lead == nil {
+		return true, d.failQueued(ctx, interaction, "lead no longer exists")
+	}
+
+	if q.CampaignID != "" {
+		campaign, err := d.db.GetCampaignByID(ctx, q.CampaignID)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case campaign == nil:
+			return true, d.failQueued(ctx, interaction, "campaign no longer exists")
+		case campaign.Status == model.CampaignStatusCompleted || campaign.Status == model.CampaignStatusCancelled:
+			return true, d.failQueued(ctx, interaction, fmt.Sprintf("campaign is %s", campaign.Status))
+		case campaign.Status == model.CampaignStatusPaused:
+			// The claim's lease brings the message back once it runs out.
+			return false, nil
+		}
+	}
+
+	optedOut, err := d.db.IsOptedOut(ctx, lead.ID, interaction.Channel)
+	if err != nil {
+		return false, err
+	}
+	if optedOut {
+		return true, d.failQueued(ctx, interaction, fmt.Sprintf("%s: %s", ErrOptedOut, interaction.Channel))
+	}
+
+	if q.CampaignID != "" {
+		handedOff, err := d.db.IsLeadHandedOff(ctx, lead.ID)
+		if err != nil {
+			return false, err
+		}
+		if handedOff {
+			// Campaign messages wait while a sales rep has the lead.
+			return false, nil
+		}
+	}
+
+	// The campaign's identity may have changed or lost its verification
+	// since queueing.
+	identity, err := d.sendingIdentity(ctx, interaction.Channel, q.CampaignID)
+	if errors.Is(err, ErrUnverifiedIdentity) {
+		return true, d.failQueued(ctx, interaction, err.Error())
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The window, the lead's time zone or quiet hours may have changed
+	// since queueing.
+	now := time.Now()
+	releaseAt, err := d.releaseTime(ctx, q.CampaignID, lead, now)
+	if err != nil {
+		return false, err
+	}
+	if releaseAt.After(now) {
+		return false, d.db.RescheduleQueuedMessage(ctx, interaction.ID, releaseAt)
+	}
+
+	reserved, err := d.reserveSend(ctx, interaction.AIAgent, interaction.Channel, now)
+	if err != nil {
+		return false, err
+	}
+	if !reserved {
+		return false, d.db.RescheduleQueuedMessage(ctx, interaction.ID, nextSendDay(now))
+	}
+
+	msg := &Outbound{
+		Lead:     lead,
+		Subject:  q.Subject,
+		Template: interaction.Template,
+		AIAgent:  interaction.AIAgent,
+		Variant:  interaction.Variant,
+		Identity: identity,
+	}
+	if interaction.Message != nil {
+		msg.Body = *interaction.Message
+	}
+
+	interaction.Lead = lead
+	interaction.Timestamp = now
+	retry, err := d.deliver(ctx, impl, msg, interaction)
+	if err != nil {
+		return false, err
+	}
+	if retry && q.Attempts+1 < maxSendAttempts {
+		if err := Transition(interaction, model.InteractionStatusQueued); err != nil {
+			return false, err
+		}
+		return false, d.db.RetryQueuedMessage(ctx, interaction, now.Add(retryDelay(q.Attempts+1)))
+	}
+
+	if err := d.db.CompleteQueuedMessage(ctx, interaction); err != nil {
+		return false, err
+	}
+
+	d.sent(ctx, interaction, q.CampaignID)
+
+	return true, nil
+}
+
+func (d *Dispatcher) failQueued(ctx context.Context, interaction *model.Interaction, reason string) error {
+	interaction.Notes = &reason
+	interaction.Timestamp = time.Now()
+	if err := Transition(interaction, model.InteractionStatusFailed); err != nil {
+		return err
+	}
+	return d.db.CompleteQueuedMessage(ctx, interaction)
+}
+
+// Record logs an interaction that happened outside the system, such as a
+// phone call made by a rep.
+func (d *Dispatcher) Record(ctx context.Context, interaction *model.Interaction) (*model.Interaction, error) {
+	if !interaction.Status.IsValid() {
+		return nil, fmt.Errorf("invalid interaction status %s", interaction.Status)
+	}
+	return d.db.CreateInteraction(ctx, interaction)
+}
+
+// UpdateDeliveryStatus applies a provider delivery receipt to the interaction
+// created for externalID. Unknown messages and receipts that arrive out of
+// order, such as "delivered" after a reply, are ignored.
+func (d *Dispatcher) UpdateDeliveryStatus(ctx context.Context, externalID string, to model.InteractionStatus, notes *string) error {
+	interaction, err := d.db.GetInteractionByExternalID(ctx, externalID)
+	if err != nil {
+		return err
+	}
+	if interaction == nil || !CanTransition(interaction.Status, to) {
+		return nil
+	}
+	return d.db.UpdateInteractionStatus(ctx, interaction.ID, to, notes)
+}
+
+// ReceiveReply records an inbound message from a lead and marks the latest
+// outbound message on the same channel as responded.
+func (d *Dispatcher) ReceiveReply(ctx context.Context, channel model.Channel, leadID, body, externalID string) (*model.Interaction, error) {
+	return d.receive(ctx, channel, leadID, body, externalID, func() error {
+		return d.db.MarkLatestOutboundResponded(ctx, leadID, channel, body)
+	})
+}
+
+// ReceiveThreadedReply records a reply to a known outbound interaction, as
+// identified by email threading headers.
+func (d *Dispatcher) ReceiveThreadedReply(ctx context.Context, original *model.Interaction, body, externalID string) (*model.Interaction, error) {
+	return d.receive(ctx, original.Channel, original.Lead.ID, body, externalID, func() error {
+		return d.db.MarkInteractionResponded(ctx, original.ID, body)
+	})
+}
+
+func (d *Dispatcher) receive(ctx context.Context, channel model.Channel, leadID, body, externalID string, markResponded func() error) (*model.Interaction, error) {
+	lead, err := d.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, fmt.Errorf("lead %s not found", leadID)
+	}
+
+	now := time.Now()
+	interaction := &model.Interaction{
+		Lead:      lead,
+		Type:      d.InteractionType(channel),
+		Channel:   channel,
+		Message:   &body,
+		Timestamp: now,
+		Status:    model.InteractionStatusDelivered,
+		Direction: model.InteractionDirectionInbound,
+		CreatedAt: now,
+	}
+	if externalID != "" {
+		interaction.ExternalID = &externalID
+	}
+
+	if err := markResponded(); err != nil {
+		return nil, err
+	}
+
+	interaction, err = d.db.CreateInteraction(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hook := range d.replyHooks {
+		hook(ctx, interaction)
+	}
+
+	return interaction, nil
+}
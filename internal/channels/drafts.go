@@ -0,0 +1,160 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// Draft records msg as a PENDING_REVIEW interaction instead of sending it,
+// for agents in dry-run mode. Nothing is sent until SendDraft approves it.
+// As with Send, leads that opted out of the channel yield ErrOptedOut.
+func (d *Dispatcher) Draft(ctx context.Context, channel model.Channel, msg *Outbound) (*model.Interaction, error) {
+	impl, ok := d.channels[channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, channel)
+	}
+
+	optedOut, err := d.db.IsOptedOut(ctx, msg.Lead.ID, channel)
+	if err != nil {
+		return nil, err
+	}
+	if optedOut {
+		return nil, fmt.Errorf("%w: %s", ErrOptedOut, channel)
+	}
+
+	now := time.Now()
+	body := msg.Body
+	interaction := &model.Interaction{
+		Lead:      msg.Lead,
+		Type:      impl.InteractionType(),
+		Channel:   channel,
+		Message:   &body,
+		AIAgent:   msg.AIAgent,
+		Template:  msg.Template,
+		Variant:   msg.Variant,
+		Timestamp: now,
+		Status:    model.InteractionStatusPendingReview,
+		CreatedAt: now,
+	}
+
+	return d.db.CreateDraftInteraction(ctx, interaction, msg.Subject)
+}
+
+// SendDraft approves a PENDING_REVIEW draft and sends it, returning the
+// interaction with its delivery status, or nil when there is no such draft.
+// Drafts already reviewed yield ErrNotPendingReview. Drafts to leads that
+// opted out meanwhile, or that are in quiet hours, are left pending and yield
+// ErrOptedOut or ErrQuietHours; the reviewer may retry or reject them. So are
+// drafts over the agent's send limit, which yield ErrSendLimitReached.
+func (d *Dispatcher) SendDraft(ctx context.Context, interactionID string, reviewedBy *string) (*model.Interaction, error) {
+	draft, err := d.db.GetDraft(ctx, interactionID)
+	if err != nil || draft == nil {
+		return nil, err
+	}
+	interaction := draft.Interaction
+	if interaction.Status != model.InteractionStatusPendingReview {
+		return nil, ErrNotPendingReview
+	}
+
+	impl, ok := d.channels[interaction.Channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, interaction.Channel)
+	}
+
+	lead, err := d.db.GetLeadByID(ctx, interaction.Lead.ID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead no longer exists")
+	}
+
+	optedOut, err := d.db.IsOptedOut(ctx, lead.ID, interaction.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if optedOut {
+		return nil, fmt.Errorf("%w: %s", ErrOptedOut, interaction.Channel)
+	}
+
+	now := time.Now()
+	releaseAt, err := d.releaseTime(ctx, "", lead, now)
+	if err != nil {
+		return nil, err
+	}
+	if releaseAt.After(now) {
+		return nil, fmt.Errorf("%w until %s", ErrQuietHours, releaseAt.Format(time.RFC3339))
+	}
+
+	reserved, err := d.reserveSend(ctx, interaction.AIAgent, interaction.Channel, now)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, fmt.Errorf("%w on %s", ErrSendLimitReached, interaction.Channel)
+	}
+
+	// Claiming the draft first keeps a second approval from sending it
+	// again.
+	claimed, err := d.db.ReviewDraft(ctx, interaction.ID, model.InteractionStatusQueued, reviewedBy, nil)
+	if err != nil || !claimed {
+		d.unreserveSend(ctx, interaction.AIAgent, interaction.Channel, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrNotPendingReview
+	}
+	if err := Transition(interaction, model.InteractionStatusQueued); err != nil {
+		return nil, err
+	}
+
+	msg := &Outbound{Lead: lead, Subject: draft.Subject}
+	if interaction.Message != nil {
+		msg.Body = *interaction.Message
+	}
+
+	interaction.Lead = lead
+	interaction.Timestamp = now
+	if _, err := d.deliver(ctx, impl, msg, interaction); err != nil {
+		return nil, err
+	}
+
+	if err := d.db.CompleteDraft(ctx, interaction); err != nil {
+		return nil, err
+	}
+
+	return interaction, nil
+}
+
+// RejectDraft discards a PENDING_REVIEW draft without sending it, keeping
+// reason as its notes. It returns nil when there is no such draft and
+// ErrNotPendingReview when the draft was already reviewed.
+func (d *Dispatcher) RejectDraft(ctx context.Context, interactionID string, reviewedBy, reason *string) (*model.Interaction, error) {
+	draft, err := d.db.GetDraft(ctx, interactionID)
+	if err != nil || draft == nil {
+		return nil, err
+	}
+	interaction := draft.Interaction
+	if err := Transition(interaction, model.InteractionStatusRejected); err != nil {
+		return nil, ErrNotPendingReview
+	}
+
+	rejected, err := d.db.ReviewDraft(ctx, interaction.ID, model.InteractionStatusRejected, reviewedBy, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !rejected {
+		return nil, ErrNotPendingReview
+	}
+	if reason != nil {
+		interaction.Notes = reason
+	}
+
+	return interaction, nil
+}
@@ -0,0 +1,37 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// SetDuplicateWindow makes Send suppress a template's message to a lead when
+// the same template was sent to the lead on the same channel within window.
+// A zero window turns the guard off.
+func (d *Dispatcher) SetDuplicateWindow(window time.Duration) {
+	d.duplicateWindow = window
+}
+
+// suppressDuplicate records interaction as SUPPRESSED, and returns it, when
+// it repeats a send of its template to its lead on its channel within the
+// duplicate window. It returns nil when the message may go out.
+func (d *Dispatcher) suppressDuplicate(ctx context.Context, interaction *model.Interaction, subject string) (*model.Interaction, error) {
+	if d.duplicateWindow <= 0 || interaction.Template == nil {
+		return nil, nil
+	}
+
+	previous, err := d.db.GetRecentTemplateSend(
+		ctx, interaction.Lead.ID, interaction.Template.ID, interaction.Channel, interaction.Timestamp.Add(-d.duplicateWindow),
+	)
+	if err != nil || previous == nil {
+		return nil, err
+	}
+
+	notes := fmt.Sprintf("duplicate of interaction %s, %s at %s", previous.ID, previous.Status, previous.Timestamp.Format(time.RFC3339))
+	interaction.Status = model.InteractionStatusSuppressed
+	interaction.Notes = &notes
+	return d.db.CreateSuppressedInteraction(ctx, interaction, subject)
+}
@@ -0,0 +1,59 @@
+package channels
+
+import (
+	"context"
+	"html"
+
+	"salesagency/graph/model"
+	"salesagency/internal/messaging/email"
+	"salesagency/internal/tracking"
+)
+
+type EmailChannel struct {
+	Sender email.Sender
+	// Tracker adds open and click tracking; emails are not tracked when it
+	// is nil.
+	Tracker *tracking.Tracker
+}
+
+func (c *EmailChannel) Channel() model.Channel {
+	return model.ChannelEmail
+}
+
+func (c *EmailChannel) InteractionType() model.InteractionType {
+	return model.InteractionTypeEmail
+}
+
+func (c *EmailChannel) Send(ctx context.Context, msg *Outbound) (*Delivery, error) {
+	message := &email.Message{
+		To:      msg.Lead.Email,
+		ToName:  msg.Lead.Name,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	}
+	if msg.Identity != nil {
+		message.From, message.FromName = msg.Identity.FromEmail, msg.Identity.FromName
+	}
+	if c.Tracker != nil {
+		if err := c.Tracker.Track(message); err != nil {
+			return nil, err
+		}
+	}
+	if msg.UnsubscribeURL != "" {
+		footer := "To stop receiving these emails, unsubscribe here: "
+		message.Body += "\n\n--\n" + footer + msg.UnsubscribeURL
+		if message.HTML != "" {
+			message.HTML += `<p>` + html.EscapeString(footer) + `<a href="` + html.EscapeString(msg.UnsubscribeURL) + `">` + html.EscapeString(msg.UnsubscribeURL) + `</a></p>`
+		}
+		message.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + msg.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+
+	result, err := c.Sender.Send(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return &Delivery{ExternalID: result.ProviderMessageID}, nil
+}
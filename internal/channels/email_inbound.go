@@ -0,0 +1,70 @@
+package channels
+
+import (
+	"context"
+	"strings"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+	"salesagency/internal/messaging/email"
+	"salesagency/internal/tenant"
+)
+
+// emailEvents records inbound email replies. A reply is matched to the
+// outbound email it answers through In-Reply-To and References, which carry
+// the Message-ID stored as the interaction's external ID, and otherwise to the
+// lead with the sender's address.
+type emailEvents struct {
+	dispatcher *Dispatcher
+}
+
+func EmailEvents(d *Dispatcher) email.InboundHandler {
+	return &emailEvents{dispatcher: d}
+}
+
+func (e *emailEvents) HandleInbound(ctx context.Context, msg *email.InboundEmail) error {
+	ctx = tenant.WithSystem(ctx)
+	db := e.dispatcher.db
+
+	// SendGrid retries deliveries it thinks failed.
+	if msg.MessageID != "" {
+		existing, err := db.GetInteractionByExternalID(ctx, msg.MessageID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return nil
+		}
+	}
+
+	body := email.ReplyText(msg.Text)
+	if body == "" {
+		body = strings.TrimSpace(msg.Text)
+	}
+
+	for _, ref := range append([]string{msg.InReplyTo}, msg.References...) {
+		if ref == "" {
+			continue
+		}
+		original, err := db.GetInteractionByExternalID(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if original != nil && original.Direction == model.InteractionDirectionOutbound {
+			_, err = e.dispatcher.ReceiveThreadedReply(ctx, original, body, msg.MessageID)
+			return err
+		}
+	}
+
+	leadID, err := db.GetLeadIDByEmail(ctx, msg.From)
+	if err != nil {
+		return err
+	}
+	if leadID == "" {
+		logging.FromContext(ctx).Info("ignoring inbound email from unknown sender", "message_id", msg.MessageID)
+		return nil
+	}
+
+	_, err = e.dispatcher.ReceiveReply(ctx, model.ChannelEmail, leadID, body, msg.MessageID)
+	return err
+}
@@ -0,0 +1,136 @@
+package channels
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+	"salesagency/internal/messaging/email"
+	"salesagency/internal/tenant"
+	"salesagency/internal/tracking"
+)
+
+// emailFeedback records what became of outbound emails: opens and clicks
+// reported by the tracking endpoints, and deliveries, bounces, drops and spam
+// complaints reported by the provider. Emails are matched to their interactions by
+// Message-ID. Addresses that bounce for good or complain are suppressed
+// through an email opt-out, so they are not emailed again.
+type emailFeedback struct {
+	dispatcher *Dispatcher
+}
+
+func EmailTracking(d *Dispatcher) tracking.Recorder {
+	return &emailFeedback{dispatcher: d}
+}
+
+func EmailDeliveryEvents(d *Dispatcher) email.DeliveryEventHandler {
+	return &emailFeedback{dispatcher: d}
+}
+
+func (f *emailFeedback) RecordOpen(ctx context.Context, messageID string) error {
+	return f.engaged(ctx, messageID, model.EmailEventTypeOpen, nil)
+}
+
+func (f *emailFeedback) RecordClick(ctx context.Context, messageID, target string) error {
+	return f.engaged(ctx, messageID, model.EmailEventTypeClick, &target)
+}
+
+// engaged records an open or click and marks the email OPENED; a click
+// shows it was opened even when the pixel was blocked.
+func (f *emailFeedback) engaged(ctx context.Context, messageID string, eventType model.EmailEventType, target *string) error {
+	db := f.dispatcher.db
+
+	interaction, err := db.GetInteractionByExternalID(ctx, messageID)
+	if err != nil || interaction == nil {
+		return err
+	}
+
+	if err := db.RecordEmailEvent(ctx, interaction.ID, eventType, target, nil); err != nil {
+		return err
+	}
+	if CanTransition(interaction.Status, model.InteractionStatusOpened) {
+		return db.UpdateInteractionStatus(ctx, interaction.ID, model.InteractionStatusOpened, nil)
+	}
+	return nil
+}
+
+func (f *emailFeedback) HandleDeliveryEvents(ctx context.Context, events []*email.DeliveryEvent) error {
+	ctx = tenant.WithSystem(ctx)
+	for _, event := range events {
+		if err := f.delivery(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *emailFeedback) delivery(ctx context.Context, event *email.DeliveryEvent) error {
+	db := f.dispatcher.db
+
+	var interaction *model.Interaction
+	if event.MessageID != "" {
+		var err error
+		if interaction, err = db.GetInteractionByExternalID(ctx, event.MessageID); err != nil {
+			return err
+		}
+	}
+
+	var detail *string
+	if event.Reason != "" {
+		detail = &event.Reason
+	}
+
+	var leadID string
+	if interaction != nil {
+		leadID = interaction.Lead.ID
+
+		var eventType model.EmailEventType
+		var status model.InteractionStatus
+		switch event.Type {
+		case email.EventDelivered:
+			status = model.InteractionStatusDelivered
+		case email.EventBounced:
+			eventType, status = model.EmailEventTypeBounce, model.InteractionStatusBounced
+		case email.EventDropped:
+			status = model.InteractionStatusFailed
+		case email.EventComplained:
+			eventType = model.EmailEventTypeComplaint
+		}
+
+		if eventType != "" {
+			if err := db.RecordEmailEvent(ctx, interaction.ID, eventType, nil, detail); err != nil {
+				return err
+			}
+		}
+		if status != "" && CanTransition(interaction.Status, status) {
+			if err := db.UpdateInteractionStatus(ctx, interaction.ID, status, detail); err != nil {
+				return err
+			}
+		}
+	} else if event.Email != "" && event.Type != email.EventDelivered {
+		var err error
+		if leadID, err = db.GetLeadIDByEmail(ctx, event.Email); err != nil {
+			return err
+		}
+	}
+
+	var reason model.EmailSuppressionReason
+	switch {
+	case event.Type == email.EventBounced && event.Permanent:
+		reason = model.EmailSuppressionReasonBounce
+	case event.Type == email.EventComplained:
+		reason = model.EmailSuppressionReasonComplaint
+	default:
+		return nil
+	}
+	if leadID == "" {
+		logging.FromContext(ctx).Info("ignoring email delivery event for unknown recipient", "message_id", event.MessageID)
+		return nil
+	}
+
+	if _, err := db.SuppressLeadEmail(ctx, leadID, reason, event.Reason); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Suppressed email address", "lead_id", leadID, "reason", reason)
+	return nil
+}
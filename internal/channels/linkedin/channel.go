@@ -0,0 +1,158 @@
+package linkedin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/channels"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+var (
+	ErrDailyCapReached = errors.New("daily LinkedIn send cap reached")
+	errNoProfile       = errors.New("lead has no LinkedIn profile")
+)
+
+// Channel sends LinkedIn messages. The first message to a lead goes out as
+// a connection request with the message as its note; once a request has
+// been sent, messages go out as InMail.
+type Channel struct {
+	client *Client
+	db     *database.DB
+}
+
+func NewChannel(client *Client, db *database.DB) *Channel {
+	return &Channel{client: client, db: db}
+}
+
+func (c *Channel) Channel() model.Channel {
+	return model.ChannelLinkedin
+}
+
+func (c *Channel) InteractionType() model.InteractionType {
+	return model.InteractionTypeSocial
+}
+
+func (c *Channel) Send(ctx context.Context, msg *channels.Outbound) (*channels.Delivery, error) {
+	profile, err := c.db.GetLinkedInProfile(ctx, msg.Lead.ID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, errNoProfile
+	}
+
+	// Messages sent without an agent share one cap.
+	var agentID *string
+	if msg.AIAgent != nil {
+		agentID = &msg.AIAgent.ID
+	}
+	now := time.Now().UTC()
+	sent, err := c.db.CountLinkedInSends(ctx, agentID, now.Truncate(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if sent >= c.client.cfg.DailyCap {
+		return nil, ErrDailyCapReached
+	}
+
+	if profile.ConnectionStatus != model.LinkedInConnectionStatusNone {
+		result, err := c.client.SendInMail(ctx, &InMail{
+			ProfileURL: profile.ProfileURL,
+			Subject:    msg.Subject,
+			Body:       msg.Body,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &channels.Delivery{ExternalID: result.ID}, nil
+	}
+
+	result, err := c.client.SendConnectionRequest(ctx, &ConnectionRequest{
+		ProfileURL: profile.ProfileURL,
+		Note:       msg.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The request went out, so the send must not be reported as failed.
+	if err := c.db.MarkLinkedInRequested(ctx, msg.Lead.ID, now); err != nil {
+		logging.FromContext(ctx).Error("error recording linkedin connection request", "lead_id", msg.Lead.ID, "error", err)
+	}
+
+	return &channels.Delivery{ExternalID: result.ID}, nil
+}
+
+// events applies automation API webhooks. Webhooks carry no user, so lookups
+// run with a system scope; request and message IDs are globally unique and
+// received messages are matched to the lead with the sender's profile.
+type events struct {
+	dispatcher *channels.Dispatcher
+	db         *database.DB
+}
+
+func Events(d *channels.Dispatcher, db *database.DB) EventHandler {
+	return &events{dispatcher: d, db: db}
+}
+
+func (e *events) HandleEvent(ctx context.Context, event *Event) error {
+	ctx = tenant.WithSystem(ctx)
+
+	switch event.Type {
+	case EventConnectionAccepted:
+		request, err := e.db.GetInteractionByExternalID(ctx, event.ID)
+		if err != nil || request == nil {
+			return err
+		}
+		if err := e.db.MarkLinkedInConnected(ctx, request.Lead.ID, event.OccurredAt); err != nil {
+			return err
+		}
+		accepted := "connection request accepted"
+		return e.dispatcher.UpdateDeliveryStatus(ctx, event.ID, model.InteractionStatusOpened, &accepted)
+	case EventMessageRead:
+		return e.dispatcher.UpdateDeliveryStatus(ctx, event.ID, model.InteractionStatusOpened, nil)
+	case EventMessageFailed:
+		failure := "linkedin delivery failed"
+		if event.Error != "" {
+			failure += ": " + event.Error
+		}
+		return e.dispatcher.UpdateDeliveryStatus(ctx, event.ID, model.InteractionStatusFailed, &failure)
+	case EventMessageReceived:
+		return e.receive(ctx, event)
+	default:
+		return nil
+	}
+}
+
+func (e *events) receive(ctx context.Context, event *Event) error {
+	// The API retries deliveries it thinks failed.
+	if event.ID != "" {
+		existing, err := e.db.GetInteractionByExternalID(ctx, event.ID)
+		if err != nil || existing != nil {
+			return err
+		}
+	}
+
+	profileURL, err := NormalizeProfileURL(event.ProfileURL)
+	if err != nil {
+		logging.FromContext(ctx).Info("ignoring linkedin message without a profile", "message_id", event.ID)
+		return nil
+	}
+
+	leadID, err := e.db.GetLeadIDByLinkedInProfile(ctx, profileURL)
+	if err != nil {
+		return err
+	}
+	if leadID == "" {
+		logging.FromContext(ctx).Info("ignoring linkedin message from unknown profile", "message_id", event.ID)
+		return nil
+	}
+
+	_, err = e.dispatcher.ReceiveReply(ctx, model.ChannelLinkedin, leadID, event.Text, event.ID)
+	return err
+}
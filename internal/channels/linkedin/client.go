@@ -0,0 +1,125 @@
+// Package linkedin sends LinkedIn connection requests and InMail through a
+// LinkedIn automation API and receives its status webhooks.
+package linkedin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"salesagency/internal/messaging"
+)
+
+// defaultDailyCap keeps each agent well below the volumes LinkedIn flags.
+const defaultDailyCap = 25
+
+// MaxNoteLength is the longest note LinkedIn accepts on a connection request.
+const MaxNoteLength = 300
+
+type Config struct {
+	BaseURL       string // automation API base URL
+	APIKey        string
+	WebhookSecret string // key for the X-Signature HMAC on webhooks
+	// DailyCap is how many LinkedIn messages each AI agent may send per UTC
+	// day.
+	DailyCap int
+}
+
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+// ConnectionRequest asks the owner of ProfileURL to connect, with an
+// optional note.
+type ConnectionRequest struct {
+	ProfileURL string `json:"profile_url"`
+	Note       string `json:"note,omitempty"`
+}
+
+type InMail struct {
+	ProfileURL string `json:"profile_url"`
+	Subject    string `json:"subject,omitempty"`
+	Body       string `json:"body"`
+}
+
+type Result struct {
+	ID string `json:"id"`
+}
+
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" || cfg.APIKey == "" {
+		return nil, errors.New("LINKEDIN_API_URL and LINKEDIN_API_KEY are required for linkedin")
+	}
+	if cfg.DailyCap <= 0 {
+		cfg.DailyCap = defaultDailyCap
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &Client{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (c *Client) SendConnectionRequest(ctx context.Context, req *ConnectionRequest) (*Result, error) {
+	if len([]rune(req.Note)) > MaxNoteLength {
+		return nil, fmt.Errorf("connection request notes are limited to %d characters", MaxNoteLength)
+	}
+	return c.post(ctx, "/connections", req)
+}
+
+func (c *Client) SendInMail(ctx context.Context, msg *InMail) (*Result, error) {
+	return c.post(ctx, "/inmails", msg)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (c *Client) post(ctx context.Context, path string, payload interface{}) (*Result, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding linkedin request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error building linkedin request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending linkedin request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("error reading linkedin response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr errorResponse
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+			return nil, &messaging.StatusError{
+				Provider: "linkedin", Status: resp.Status, StatusCode: resp.StatusCode, Detail: apiErr.Error,
+			}
+		}
+		return nil, &messaging.StatusError{Provider: "linkedin", Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+
+	var result Result
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("error decoding linkedin response: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,36 @@
+package linkedin
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidProfileURL = errors.New("not a LinkedIn profile URL")
+
+// NormalizeProfileURL reduces the forms a profile link comes in, with or
+// without scheme, "www.", query or trailing slash, to
+// https://www.linkedin.com/in/<slug> so profiles can be matched.
+func NormalizeProfileURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ErrInvalidProfileURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != "linkedin.com" && !strings.HasSuffix(host, ".linkedin.com") {
+		return "", ErrInvalidProfileURL
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "in" || parts[1] == "" {
+		return "", ErrInvalidProfileURL
+	}
+
+	return "https://www.linkedin.com/in/" + strings.ToLower(parts[1]), nil
+}
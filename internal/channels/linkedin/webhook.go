@@ -0,0 +1,91 @@
+package linkedin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"salesagency/internal/logging"
+)
+
+// Event types sent by the automation API.
+const (
+	EventConnectionAccepted = "connection.accepted"
+	EventMessageRead        = "message.read"
+	EventMessageFailed      = "message.failed"
+	EventMessageReceived    = "message.received"
+)
+
+// Event is a status update for a request or message sent through the
+// client, or a message received from a lead.
+type Event struct {
+	Type string `json:"type"`
+	// ID is the request or message ID returned by the send, or the received
+	// message's own ID.
+	ID         string    `json:"id"`
+	ProfileURL string    `json:"profile_url"`
+	Text       string    `json:"text"`
+	Error      string    `json:"error"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// EventHandler should return nil for events it cannot match to a record;
+// errors are reported to the API as failures so it retries.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event *Event) error
+}
+
+// WebhookHandler verifies the X-Signature header, a hex HMAC-SHA256 of the
+// body keyed with the webhook secret, and passes events to events.
+func (c *Client) WebhookHandler(events EventHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		if !c.validSignature(body, r.Header.Get("X-Signature")) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+
+		if err := events.HandleEvent(r.Context(), &event); err != nil {
+			logging.FromContext(r.Context()).Error("error handling linkedin webhook", "type", event.Type, "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *Client) validSignature(body []byte, signature string) bool {
+	if c.cfg.WebhookSecret == "" || signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.cfg.WebhookSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,52 @@
+package channels
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+	"salesagency/internal/messaging/email"
+	"salesagency/internal/messaging/twilio"
+	"salesagency/internal/tracking"
+)
+
+// ManualChannel is a channel whose interactions are performed by people, or
+// whose provider is not configured. It can be logged but not sent through.
+type ManualChannel struct {
+	Medium model.Channel
+	Type   model.InteractionType
+}
+
+func (c *ManualChannel) Channel() model.Channel {
+	return c.Medium
+}
+
+func (c *ManualChannel) InteractionType() model.InteractionType {
+	return c.Type
+}
+
+func (c *ManualChannel) Send(ctx context.Context, msg *Outbound) (*Delivery, error) {
+	return nil, ErrSendUnsupported
+}
+
+// Defaults returns the built-in channel set: email backed by emailSender and
+// tracked by tracker when it is non-nil, SMS and WhatsApp backed by
+// twilioClient when it is non-nil, and the remaining channels log-only until
+// their providers are registered.
+func Defaults(emailSender email.Sender, tracker *tracking.Tracker, twilioClient *twilio.Client) []Channel {
+	defaults := []Channel{
+		&EmailChannel{Sender: emailSender, Tracker: tracker},
+		&ManualChannel{Medium: model.ChannelLinkedin, Type: model.InteractionTypeSocial},
+		&ManualChannel{Medium: model.ChannelPhone, Type: model.InteractionTypeCall},
+	}
+
+	if twilioClient != nil {
+		return append(defaults,
+			&TwilioChannel{Client: twilioClient, Medium: model.ChannelSms},
+			&TwilioChannel{Client: twilioClient, Medium: model.ChannelWhatsapp},
+		)
+	}
+	return append(defaults,
+		&ManualChannel{Medium: model.ChannelSms, Type: model.InteractionTypeSms},
+		&ManualChannel{Medium: model.ChannelWhatsapp, Type: model.InteractionTypeChat},
+	)
+}
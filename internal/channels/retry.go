@@ -0,0 +1,97 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// maxSendAttempts is how many times a message is tried before a transient
+// failure is recorded as FAILED.
+const maxSendAttempts = 5
+
+// retryDelay returns how long to wait before trying a message again after
+// its given number of failed attempts: a minute, doubling up to an hour.
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, time.Hour)
+}
+
+// Transient reports whether a send failed in a way that may clear by itself:
+// a network error or timeout, a provider rate limiting or failing, or a
+// temporary SMTP rejection. Other failures, such as an invalid address, fail
+// again when retried.
+func Transient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// Resend queues a copy of a FAILED outbound message to be sent by the next
+// ReleaseQueued, subject to the same checks as the original, and returns the
+// copy as a QUEUED interaction. The failed interaction is kept as the
+// record of the earlier attempt. Resend returns nil when there is no such
+// interaction and ErrNotFailed when it is not a failed outbound message.
+func (d *Dispatcher) Resend(ctx context.Context, interactionID string) (*model.Interaction, error) {
+	failed, err := d.db.GetOutboundMessage(ctx, interactionID)
+	if err != nil || failed == nil {
+		return nil, err
+	}
+	original := failed.Interaction
+	if original.Status != model.InteractionStatusFailed || original.Direction != model.InteractionDirectionOutbound || original.Message == nil {
+		return nil, ErrNotFailed
+	}
+	if !d.CanSend(original.Channel) {
+		return nil, fmt.Errorf("%w: %s", ErrSendUnsupported, original.Channel)
+	}
+
+	lead, err := d.db.GetLeadByID(ctx, original.Lead.ID)
+	if err != nil || lead == nil {
+		return nil, err
+	}
+
+	optedOut, err := d.db.IsOptedOut(ctx, lead.ID, original.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if optedOut {
+		return nil, fmt.Errorf("%w: %s", ErrOptedOut, original.Channel)
+	}
+
+	now := time.Now()
+	body := *original.Message
+	interaction := &model.Interaction{
+		Lead:      lead,
+		Type:      original.Type,
+		Channel:   original.Channel,
+		Message:   &body,
+		AIAgent:   original.AIAgent,
+		Template:  original.Template,
+		Variant:   original.Variant,
+		Timestamp: now,
+		Status:    model.InteractionStatusQueued,
+		CreatedAt: now,
+	}
+	return d.db.QueueInteraction(ctx, interaction, failed.CampaignID, failed.Subject, now, 0)
+}
@@ -0,0 +1,64 @@
+package channels
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// SendDay returns the UTC day a message sent at t counts against.
+func SendDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// DailyLimit returns how many messages throttle allows on the day of t: its
+// daily limit plus its ramp for every day since it was set, up to its
+// maximum.
+func DailyLimit(throttle *model.SendThrottle, t time.Time) int {
+	days := int(SendDay(t).Sub(SendDay(throttle.StartedAt)).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+
+	limit := throttle.DailyLimit + throttle.RampPerDay*days
+	if throttle.MaxDailyLimit != nil && limit > *throttle.MaxDailyLimit {
+		limit = *throttle.MaxDailyLimit
+	}
+	return limit
+}
+
+// reserveSend counts a message the agent sends on channel at now against the
+// agent's throttle for the channel, and reports false when the day's limit
+// is reached. Messages not sent by an agent, and channels without a
+// throttle, are never limited.
+func (d *Dispatcher) reserveSend(ctx context.Context, agent *model.AIAgent, channel model.Channel, now time.Time) (bool, error) {
+	if agent == nil {
+		return true, nil
+	}
+
+	throttle, err := d.db.GetAIAgentSendThrottle(ctx, agent.ID, channel)
+	if err != nil || throttle == nil {
+		return err == nil, err
+	}
+
+	return d.db.ReserveSend(ctx, agent.ID, channel, SendDay(now), DailyLimit(throttle, now))
+}
+
+// unreserveSend gives back what reserveSend counted for a message that was
+// not sent after all. Failures are only logged: the count is then one too
+// high for the rest of the day.
+func (d *Dispatcher) unreserveSend(ctx context.Context, agent *model.AIAgent, channel model.Channel, now time.Time) {
+	if agent == nil {
+		return
+	}
+	if err := d.db.UnreserveSend(ctx, agent.ID, channel, SendDay(now)); err != nil {
+		slog.Error("Failed to unreserve send", "ai_agent_id", agent.ID, "channel", channel, "error", err)
+	}
+}
+
+// nextSendDay returns when the day after now's send day starts.
+func nextSendDay(now time.Time) time.Time {
+	return SendDay(now).Add(24 * time.Hour)
+}
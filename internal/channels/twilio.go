@@ -0,0 +1,123 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+	"salesagency/internal/messaging/twilio"
+	"salesagency/internal/tenant"
+)
+
+var errNoPhone = errors.New("lead has no phone number")
+
+// stopKeywords are the standard carrier opt-out replies. Twilio blocks
+// further messages to the number by itself; recording the opt-out keeps the
+// lead suppressed even if the message would go out another way.
+var stopKeywords = map[string]bool{
+	"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true,
+}
+
+// TwilioChannel sends SMS, or WhatsApp messages when Medium is
+// model.ChannelWhatsapp, through Twilio.
+type TwilioChannel struct {
+	Client *twilio.Client
+	Medium model.Channel
+}
+
+func (c *TwilioChannel) Channel() model.Channel {
+	return c.Medium
+}
+
+func (c *TwilioChannel) InteractionType() model.InteractionType {
+	if c.Medium == model.ChannelWhatsapp {
+		return model.InteractionTypeChat
+	}
+	return model.InteractionTypeSms
+}
+
+func (c *TwilioChannel) Send(ctx context.Context, msg *Outbound) (*Delivery, error) {
+	if msg.Lead.Phone == nil || *msg.Lead.Phone == "" {
+		return nil, errNoPhone
+	}
+
+	result, err := c.Client.Send(ctx, &twilio.Message{
+		To:       *msg.Lead.Phone,
+		Body:     msg.Body,
+		WhatsApp: c.Medium == model.ChannelWhatsapp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Delivery{ExternalID: result.SID}, nil
+}
+
+// twilioEvents applies Twilio webhooks to interactions. Webhooks carry no
+// user, so lookups run with a system scope; message SIDs are globally unique
+// and inbound numbers are matched to the most recently contacted lead.
+type twilioEvents struct {
+	dispatcher *Dispatcher
+}
+
+func TwilioEvents(d *Dispatcher) twilio.EventHandler {
+	return &twilioEvents{dispatcher: d}
+}
+
+func (e *twilioEvents) HandleStatus(ctx context.Context, update *twilio.StatusUpdate) error {
+	var to model.InteractionStatus
+	var notes *string
+	switch update.Status {
+	case "delivered":
+		to = model.InteractionStatusDelivered
+	case "read":
+		to = model.InteractionStatusOpened
+	case "failed", "undelivered":
+		to = model.InteractionStatusFailed
+		failure := "twilio delivery " + update.Status
+		if update.ErrorCode != "" {
+			failure += ", error " + update.ErrorCode
+		}
+		notes = &failure
+	default:
+		return nil
+	}
+
+	return e.dispatcher.UpdateDeliveryStatus(tenant.WithSystem(ctx), update.MessageSID, to, notes)
+}
+
+func (e *twilioEvents) HandleInbound(ctx context.Context, msg *twilio.InboundMessage) error {
+	ctx = tenant.WithSystem(ctx)
+
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, msg.From)
+
+	leadID, err := e.dispatcher.db.GetLeadIDByPhone(ctx, digits)
+	if err != nil {
+		return err
+	}
+	if leadID == "" {
+		logging.FromContext(ctx).Info("ignoring twilio message from unknown number", "message_sid", msg.MessageSID)
+		return nil
+	}
+
+	channel := model.ChannelSms
+	if msg.WhatsApp {
+		channel = model.ChannelWhatsapp
+	}
+
+	if keyword := strings.ToUpper(strings.TrimSpace(msg.Body)); stopKeywords[keyword] {
+		if _, err := e.dispatcher.db.OptOutLead(ctx, leadID, channel, "replied "+keyword); err != nil {
+			return err
+		}
+	}
+
+	_, err = e.dispatcher.ReceiveReply(ctx, channel, leadID, msg.Body, msg.MessageSID)
+	return err
+}
@@ -0,0 +1,313 @@
+// Package chat serves the chat widget that agencies embed on their clients'
+// websites. An AI agent answers visitors from the client's service catalog
+// and, once a visitor leaves their name and email address, turns them into
+// a lead whose first interaction is the transcript.
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/llm"
+	"salesagency/internal/logging"
+	"salesagency/internal/sendwindow"
+	"salesagency/internal/templates"
+	"salesagency/internal/tenant"
+	"salesagency/internal/validation"
+)
+
+// Source is the source of leads captured through the chat widget.
+const Source = "CHAT"
+
+const (
+	// maxMessage bounds a visitor's message, in characters.
+	maxMessage = 1000
+	// maxVisitorMessages and sessionTTL bound what one session can cost in
+	// model calls.
+	maxVisitorMessages = 30
+	sessionTTL         = 24 * time.Hour
+	defaultAgentName   = "Assistant"
+)
+
+var (
+	ErrClientNotFound  = errors.New("client not found")
+	ErrAgentNotFound   = errors.New("AI agent not found")
+	ErrSessionNotFound = errors.New("chat session not found")
+	// ErrSessionClosed is returned for sessions that expired or reached
+	// their message limit; the visitor starts a new one.
+	ErrSessionClosed  = errors.New("chat session is closed")
+	ErrEmptyMessage   = errors.New("message is required")
+	ErrMessageTooLong = fmt.Errorf("message is longer than %d characters", maxMessage)
+	// ErrUnavailable wraps failures of the model; the visitor's message is
+	// not stored and can be sent again.
+	ErrUnavailable = errors.New("chat reply failed")
+)
+
+type Service struct {
+	db       *database.DB
+	provider llm.Provider
+	broker   *events.Broker
+}
+
+func NewService(db *database.DB, provider llm.Provider, broker *events.Broker) *Service {
+	return &Service{db: db, provider: provider, broker: broker}
+}
+
+// Visit is where a visitor opened the chat.
+type Visit struct {
+	Referrer    *string
+	LandingPage *string
+}
+
+// Reply is the agent's answer to a visitor's message. LeadID is set once
+// the visitor became a lead.
+type Reply struct {
+	Body   string
+	LeadID *string
+}
+
+// Start opens a session with the client's visitors for the agency in ctx,
+// answered by agentID when it is not empty, and returns it with the
+// greeting to show.
+func (s *Service) Start(ctx context.Context, clientID, agentID string, visit Visit) (*database.ChatSession, string, error) {
+	client, err := s.db.GetClientByID(ctx, clientID)
+	if err != nil {
+		return nil, "", err
+	}
+	if client == nil || client.Status == model.ClientStatusArchived {
+		return nil, "", ErrClientNotFound
+	}
+
+	session := &database.ChatSession{
+		ClientID:    client.ID,
+		Referrer:    visit.Referrer,
+		LandingPage: visit.LandingPage,
+		CreatedAt:   time.Now(),
+	}
+
+	greeting := fmt.Sprintf("Hi! How can %s help you today?", client.Name)
+	if agentID != "" {
+		agent, err := s.db.GetAIAgentByID(ctx, agentID)
+		if err != nil {
+			return nil, "", err
+		}
+		if agent == nil {
+			return nil, "", ErrAgentNotFound
+		}
+		session.AIAgentID = &agent.ID
+		greeting = fmt.Sprintf("Hi, I'm %s from %s. How can I help you today?", agent.Name, client.Name)
+	}
+
+	session, err = s.db.CreateChatSession(ctx, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, greeting, nil
+}
+
+// Message answers a visitor's message in a session. Details the visitor
+// gives about themselves are picked up by the model; as soon as they
+// include a name and a valid email address, the visitor is upserted as a
+// lead with language as its language when it has none. Sessions are
+// identified by their ID alone.
+func (s *Service) Message(ctx context.Context, sessionID, body, language string) (*Reply, error) {
+	if body == "" {
+		return nil, ErrEmptyMessage
+	}
+	if len([]rune(body)) > maxMessage {
+		return nil, ErrMessageTooLong
+	}
+
+	session, err := s.db.GetChatSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	if time.Since(session.CreatedAt) > sessionTTL {
+		return nil, ErrSessionClosed
+	}
+	ctx = tenant.WithAgency(ctx, session.AgencyID)
+
+	history, err := s.db.GetChatMessages(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	visitorMessages := 0
+	for _, message := range history {
+		if message.Role == database.ChatRoleVisitor {
+			visitorMessages++
+		}
+	}
+	if visitorMessages >= maxVisitorMessages {
+		return nil, ErrSessionClosed
+	}
+
+	client, err := s.db.GetClientByID(ctx, session.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrSessionNotFound
+	}
+	services, err := s.db.GetServicesByClientID(ctx, client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var agent *model.AIAgent
+	var persona *model.AgentPersona
+	agentName := defaultAgentName
+	if session.AIAgentID != nil {
+		if agent, err = s.db.GetAIAgentByID(ctx, *session.AIAgentID); err != nil {
+			return nil, err
+		}
+		if agent != nil {
+			agentName = agent.Name
+			if persona, err = s.db.GetCurrentAgentPersona(ctx, agent.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	messages := make([]llm.Message, 0, len(history)+1)
+	for _, message := range history {
+		role := llm.RoleUser
+		if message.Role == database.ChatRoleAgent {
+			role = llm.RoleAssistant
+		}
+		messages = append(messages, llm.Message{Role: role, Content: message.Body})
+	}
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: body})
+
+	completion, err := s.provider.Complete(ctx, &llm.Request{
+		System:      buildPrompt(client, services, agent, persona),
+		Messages:    messages,
+		MaxTokens:   512,
+		Temperature: 0.4,
+		JSON:        true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	resp, err := parseReply(completion)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	now := time.Now()
+	added := []*database.ChatMessage{
+		{Role: database.ChatRoleVisitor, Body: body, CreatedAt: now},
+		{Role: database.ChatRoleAgent, Body: resp.Reply, CreatedAt: now},
+	}
+	history = append(history, added...)
+	text := transcript(history, agentName)
+	if err := s.db.AddChatMessages(ctx, session, text, added...); err != nil {
+		return nil, err
+	}
+
+	if session.LeadID == nil {
+		// The reply is stored, so answer anyway; the next message tries
+		// capturing the lead again.
+		if err := s.capture(ctx, session, resp.Contact, language, agent, text); err != nil {
+			logging.FromContext(ctx).Error("error capturing chat lead", "chat_session_id", session.ID, "error", err)
+		}
+	}
+
+	return &Reply{Body: resp.Reply, LeadID: session.LeadID}, nil
+}
+
+// capture upserts the visitor as a lead once they have given a name and a
+// valid email address, and records the transcript as its first
+// interaction. Details the lead cannot be saved with, such as an invalid
+// phone number, are left for a later message to correct.
+func (s *Service) capture(ctx context.Context, session *database.ChatSession, visitor contact, language string, agent *model.AIAgent, text string) error {
+	if visitor.Name == "" || visitor.Email == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(visitor.Email); err != nil {
+		return nil
+	}
+
+	source := Source
+	lead := &model.Lead{
+		Name:        visitor.Name,
+		Email:       visitor.Email,
+		Phone:       optional(visitor.Phone),
+		Company:     optional(visitor.Company),
+		Language:    optional(language),
+		Source:      &source,
+		Status:      model.LeadStatusNew,
+		IntentScore: 0.5,
+		CreatedAt:   time.Now(),
+	}
+	log := logging.FromContext(ctx).With("chat_session_id", session.ID)
+	if err := validation.Lead(lead); err != nil {
+		log.Info("not capturing chat lead", "reason", err)
+		return nil
+	}
+	sendwindow.DetectTimezone(lead)
+	templates.DetectLanguage(lead)
+
+	lead, created, err := s.db.UpsertLead(ctx, lead, model.LeadMatchKeyEmail)
+	if errors.Is(err, database.ErrDuplicateLead) {
+		log.Info("not capturing chat lead", "reason", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	interaction := &model.Interaction{
+		Lead:      lead,
+		Type:      model.InteractionTypeChat,
+		Channel:   model.ChannelWebChat,
+		Message:   &text,
+		AIAgent:   agent,
+		Timestamp: session.CreatedAt,
+		Status:    model.InteractionStatusDelivered,
+		Direction: model.InteractionDirectionInbound,
+		CreatedAt: now,
+	}
+	if ok, err := s.db.CaptureChatLead(ctx, session, interaction); err != nil || !ok {
+		// Another message of the session captured the lead first.
+		return err
+	}
+
+	attribution := &model.LeadAttribution{
+		LeadID:      lead.ID,
+		Referrer:    session.Referrer,
+		LandingPage: session.LandingPage,
+		CreatedAt:   now,
+	}
+	if _, err := s.db.CreateLeadAttribution(ctx, attribution); err != nil {
+		return err
+	}
+
+	if created {
+		if agent != nil {
+			if lead, err = s.db.AssignLeadToAIAgent(ctx, lead.ID, agent.ID); err != nil {
+				return err
+			}
+		}
+		s.broker.Publish(events.TopicLeadCreated, lead)
+	} else {
+		s.broker.Publish(events.TopicLeadUpdated, lead)
+	}
+	return nil
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,183 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"salesagency/internal/capture"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/ratelimit"
+	"salesagency/internal/tenant"
+)
+
+// SessionPath and MessagePath are where Handler is mounted.
+const (
+	SessionPath = "/chat/session"
+	MessagePath = "/chat/message"
+)
+
+const (
+	maxBody = 16 << 10
+	// maxValue bounds the referrer and landing page, as on /capture.
+	maxValue = 2048
+)
+
+var errInvalidKey = errors.New("invalid key")
+
+type sessionRequest struct {
+	Key         string `json:"key"`
+	ClientID    string `json:"clientId"`
+	AgentID     string `json:"agentId"`
+	Referrer    string `json:"referrer"`
+	LandingPage string `json:"landingPage"`
+}
+
+type messageRequest struct {
+	SessionID string `json:"sessionId"`
+	Message   string `json:"message"`
+}
+
+type response struct {
+	SessionID string  `json:"sessionId,omitempty"`
+	Reply     string  `json:"reply,omitempty"`
+	LeadID    *string `json:"leadId,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Handler serves the chat widget. A POST of a JSON object to SessionPath
+// with the agency's capture key, a clientId and optionally an agentId opens
+// a session; POSTs to MessagePath with its sessionId and a message get the
+// agent's reply. Callers are limited per IP address by limiter.
+func Handler(db *database.DB, service *Service, limiter *ratelimit.Limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The widget is embedded on any site, so allow cross-origin posts.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		switch r.Method {
+		case http.MethodOptions:
+			w.Header().Set("Access-Control-Allow-Methods", "POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodPost:
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, response{Error: "method not allowed"})
+			return
+		}
+
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+		if result := limiter.Allow(ip); !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())+1))
+			writeJSON(w, http.StatusTooManyRequests, response{Error: "too many requests"})
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		if r.URL.Path == SessionPath {
+			startSession(w, r, db, service)
+		} else {
+			sendMessage(w, r, service)
+		}
+	})
+}
+
+func startSession(w http.ResponseWriter, r *http.Request, db *database.DB, service *Service) {
+	var req sessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: "invalid request body: " + err.Error()})
+		return
+	}
+	if req.ClientID == "" {
+		writeJSON(w, http.StatusBadRequest, response{Error: "clientId is required"})
+		return
+	}
+
+	if req.Key == "" {
+		writeJSON(w, http.StatusUnauthorized, response{Error: "key is required"})
+		return
+	}
+	agencyID, err := db.GetAgencyIDByCaptureKey(r.Context(), req.Key)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	if agencyID == "" {
+		writeJSON(w, http.StatusUnauthorized, response{Error: errInvalidKey.Error()})
+		return
+	}
+	ctx := tenant.WithAgency(r.Context(), agencyID)
+
+	visit := Visit{Referrer: optional(clip(req.Referrer)), LandingPage: optional(clip(req.LandingPage))}
+	if visit.LandingPage == nil {
+		// The widget's page, as on /capture.
+		visit.LandingPage = optional(clip(r.Referer()))
+	}
+
+	session, greeting, err := service.Start(ctx, req.ClientID, strings.TrimSpace(req.AgentID), visit)
+	switch {
+	case errors.Is(err, ErrClientNotFound), errors.Is(err, ErrAgentNotFound):
+		writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+	case err != nil:
+		serverError(w, r, err)
+	default:
+		writeJSON(w, http.StatusCreated, response{SessionID: session.ID, Reply: greeting})
+	}
+}
+
+func sendMessage(w http.ResponseWriter, r *http.Request, service *Service) {
+	var req messageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: "invalid request body: " + err.Error()})
+		return
+	}
+	if req.SessionID == "" {
+		writeJSON(w, http.StatusBadRequest, response{Error: "sessionId is required"})
+		return
+	}
+
+	reply, err := service.Message(r.Context(), req.SessionID, strings.TrimSpace(req.Message), capture.BrowserLanguage(r))
+	switch {
+	case errors.Is(err, ErrEmptyMessage), errors.Is(err, ErrMessageTooLong):
+		writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+	case errors.Is(err, ErrSessionNotFound):
+		writeJSON(w, http.StatusNotFound, response{Error: err.Error()})
+	case errors.Is(err, ErrSessionClosed):
+		writeJSON(w, http.StatusGone, response{Error: err.Error()})
+	case errors.Is(err, ErrUnavailable):
+		logging.FromContext(r.Context()).Warn("chat reply failed", "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, response{Error: "the assistant is unavailable, please try again"})
+	case err != nil:
+		serverError(w, r, err)
+	default:
+		writeJSON(w, http.StatusOK, response{SessionID: req.SessionID, Reply: reply.Body, LeadID: reply.LeadID})
+	}
+}
+
+func clip(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > maxValue {
+		return ""
+	}
+	return value
+}
+
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).Error("error serving chat", "error", err)
+	writeJSON(w, http.StatusInternalServerError, response{Error: http.StatusText(http.StatusInternalServerError)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error encoding chat response", "error", err)
+	}
+}
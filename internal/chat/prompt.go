@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+const systemPrompt = `You are the assistant in the chat widget on a company's website, answering visitors' questions about the company's services.
+Answer in two or three short sentences, only from the information below; when you do not know something, say that someone from the team will follow up.
+Never invent prices, features or commitments. Once you have helped, ask for the visitor's name and email address so the team can get in touch, but do not insist.
+Respond with a JSON object of the form {"reply": "...", "contact": {"name": "", "email": "", "phone": "", "company": ""}} and nothing else.
+Fill in contact only with details the visitor gave in this conversation and leave the others empty.`
+
+func buildPrompt(client *model.Client, services []*model.Service, agent *model.AIAgent, persona *model.AgentPersona) string {
+	var b strings.Builder
+	b.WriteString(systemPrompt)
+
+	if agent != nil {
+		fmt.Fprintf(&b, "\n\nYou are %q, whose purpose is: %s.\n", agent.Name, agent.Purpose)
+		if persona != nil {
+			writePersona(&b, persona)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nCompany: %s, in %s.\n", client.Name, client.Industry)
+	if client.Website != nil {
+		fmt.Fprintf(&b, "Website: %s\n", *client.Website)
+	}
+
+	if len(services) == 0 {
+		b.WriteString("\nThe company has not listed its services; ask what the visitor needs and offer a follow-up.\n")
+		return b.String()
+	}
+	b.WriteString("\nServices:\n")
+	for _, service := range services {
+		fmt.Fprintf(&b, "- %s (%.2f %s): %s\n", service.Name, service.Price, service.Currency, service.Description)
+		if len(service.Features) > 0 {
+			fmt.Fprintf(&b, "  Features: %s\n", strings.Join(service.Features, "; "))
+		}
+	}
+	return b.String()
+}
+
+func writePersona(b *strings.Builder, persona *model.AgentPersona) {
+	if persona.Tone != nil && *persona.Tone != "" {
+		fmt.Fprintf(b, "Tone of voice: %s\n", *persona.Tone)
+	}
+	if persona.Language != nil && *persona.Language != "" {
+		fmt.Fprintf(b, "Reply in %s unless the visitor writes in another language.\n", *persona.Language)
+	}
+	if len(persona.Dos) > 0 {
+		b.WriteString("Always:\n")
+		for _, rule := range persona.Dos {
+			fmt.Fprintf(b, "- %s\n", rule)
+		}
+	}
+	if len(persona.Donts) > 0 {
+		b.WriteString("Never:\n")
+		for _, rule := range persona.Donts {
+			fmt.Fprintf(b, "- %s\n", rule)
+		}
+	}
+}
+
+// contact is what the visitor has told the agent about themselves.
+type contact struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Company string `json:"company"`
+}
+
+type modelResponse struct {
+	Reply   string  `json:"reply"`
+	Contact contact `json:"contact"`
+}
+
+func parseReply(reply string) (*modelResponse, error) {
+	// Some models wrap the object in prose or a code fence.
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("model did not return a JSON object")
+	}
+
+	var resp modelResponse
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &resp); err != nil {
+		return nil, fmt.Errorf("error parsing model response: %w", err)
+	}
+	resp.Reply = strings.TrimSpace(resp.Reply)
+	if resp.Reply == "" {
+		return nil, errors.New("model returned an empty reply")
+	}
+	resp.Contact.Name = strings.TrimSpace(resp.Contact.Name)
+	resp.Contact.Email = strings.TrimSpace(resp.Contact.Email)
+	resp.Contact.Phone = strings.TrimSpace(resp.Contact.Phone)
+	resp.Contact.Company = strings.TrimSpace(resp.Contact.Company)
+	return &resp, nil
+}
+
+// transcript renders the conversation as the message of the lead's
+// interaction, one line per message.
+func transcript(messages []*database.ChatMessage, agentName string) string {
+	var b strings.Builder
+	for i, message := range messages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		speaker := "Visitor"
+		if message.Role == database.ChatRoleAgent {
+			speaker = agentName
+		}
+		fmt.Fprintf(&b, "%s: %s", speaker, message.Body)
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// env reads environment variables and records every problem it finds, so
+// that Load can report them all at once instead of stopping at the first.
+type env struct {
+	problems []string
+}
+
+func (e *env) invalid(name, value string, reason string) {
+	e.problems = append(e.problems, fmt.Sprintf("%s=%q: %s", name, value, reason))
+}
+
+func (e *env) missing(name, reason string) {
+	e.problems = append(e.problems, fmt.Sprintf("%s is required %s", name, reason))
+}
+
+func (e *env) get(name, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// required returns the variable, recording it as missing when it is unset.
+// reason completes "<NAME> is required ...".
+func (e *env) required(name, reason string) string {
+	value := e.get(name, "")
+	if value == "" {
+		e.missing(name, reason)
+	}
+	return value
+}
+
+func (e *env) boolean(name string, fallback bool) bool {
+	value := e.get(name, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.invalid(name, value, "must be true or false")
+		return fallback
+	}
+	return parsed
+}
+
+// positiveInt reads a whole number greater than zero.
+func (e *env) positiveInt(name string, fallback int) int {
+	value := e.get(name, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		e.invalid(name, value, "must be a positive whole number")
+		return fallback
+	}
+	return parsed
+}
+
+// duration reads a Go duration such as "90s". Zero is allowed, negative
+// durations are not.
+func (e *env) duration(name string, fallback time.Duration) time.Duration {
+	value := e.get(name, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		e.invalid(name, value, "must be a duration such as 30s or 15m")
+		return fallback
+	}
+	return parsed
+}
+
+// parse reads a variable with fn, recording fn's error as the reason it is
+// invalid.
+func parse[T any](e *env, name, fallback string, fn func(string) (T, error)) T {
+	value := e.get(name, fallback)
+	parsed, err := fn(value)
+	if err != nil {
+		e.invalid(name, value, err.Error())
+	}
+	return parsed
+}
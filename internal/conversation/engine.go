@@ -0,0 +1,173 @@
+// Package conversation drafts personalized outreach for a lead using an LLM
+// provider.
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/llm"
+)
+
+var (
+	ErrLeadNotFound  = errors.New("lead not found")
+	ErrAgentNotFound = errors.New("AI agent not found")
+)
+
+// historyLimit caps how many past interactions are shown to the model.
+const historyLimit = 5
+
+type Engine struct {
+	db       *database.DB
+	provider llm.Provider
+}
+
+func NewEngine(db *database.DB, provider llm.Provider) *Engine {
+	return &Engine{db: db, provider: provider}
+}
+
+// DraftOutreach asks the model for one draft per channel the lead can be
+// reached on, written in the agent's voice and aimed at the pain points of
+// the agent's campaigns. The agent's current persona, if any, sets the tone,
+// language and rules, and its signature closes email drafts. Drafts are
+// returned for review and are not sent.
+func (e *Engine) DraftOutreach(ctx context.Context, leadID, agentID string) ([]*model.OutreachDraft, error) {
+	lead, err := e.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, ErrLeadNotFound
+	}
+
+	agent, err := e.db.GetAIAgentByID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, ErrAgentNotFound
+	}
+
+	persona, err := e.db.GetCurrentAgentPersona(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns, err := e.db.GetCampaignsByAIAgentID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	campaigns = liveCampaigns(campaigns)
+
+	campaignIDs := make([]string, len(campaigns))
+	for i, campaign := range campaigns {
+		campaignIDs[i] = campaign.ID
+	}
+	targets, err := e.db.GetTargetsByCampaignIDs(ctx, campaignIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := e.db.GetInteractionsByLeadID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) > historyLimit {
+		history = history[:historyLimit]
+	}
+
+	channels := []model.Channel{model.ChannelEmail}
+	if lead.Phone != nil && *lead.Phone != "" {
+		channels = append(channels, model.ChannelSms)
+	}
+
+	reply, err := e.provider.Complete(ctx, &llm.Request{
+		System:      systemPrompt,
+		Messages:    []llm.Message{{Role: llm.RoleUser, Content: buildPrompt(lead, agent, persona, campaigns, targets, history, channels)}},
+		Temperature: 0.7,
+		JSON:        true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error generating outreach: %w", err)
+	}
+
+	drafts, err := parseDrafts(reply, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, draft := range drafts {
+		draft.Persona = persona
+		if persona != nil && persona.Signature != nil && draft.Channel == model.ChannelEmail {
+			draft.Body += "\n\n" + *persona.Signature
+		}
+	}
+	return drafts, nil
+}
+
+// liveCampaigns prefers campaigns that are running or about to run, falling
+// back to everything the agent is assigned to.
+func liveCampaigns(campaigns []*model.Campaign) []*model.Campaign {
+	var live []*model.Campaign
+	for _, campaign := range campaigns {
+		if campaign.Status == model.CampaignStatusActive || campaign.Status == model.CampaignStatusScheduled {
+			live = append(live, campaign)
+		}
+	}
+	if len(live) == 0 {
+		return campaigns
+	}
+	return live
+}
+
+type draftResponse struct {
+	Drafts []struct {
+		Channel string `json:"channel"`
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	} `json:"drafts"`
+}
+
+func parseDrafts(reply string, channels []model.Channel) ([]*model.OutreachDraft, error) {
+	// Some models wrap the object in prose or a code fence.
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("model did not return a JSON object")
+	}
+
+	var resp draftResponse
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &resp); err != nil {
+		return nil, fmt.Errorf("error parsing model response: %w", err)
+	}
+
+	requested := make(map[model.Channel]bool, len(channels))
+	for _, channel := range channels {
+		requested[channel] = true
+	}
+
+	drafts := make([]*model.OutreachDraft, 0, len(resp.Drafts))
+	for _, d := range resp.Drafts {
+		channel := model.Channel(strings.ToUpper(strings.TrimSpace(d.Channel)))
+		body := strings.TrimSpace(d.Body)
+		if !requested[channel] || body == "" {
+			continue
+		}
+
+		draft := &model.OutreachDraft{Channel: channel, Body: body}
+		if subject := strings.TrimSpace(d.Subject); subject != "" && channel == model.ChannelEmail {
+			draft.Subject = &subject
+		}
+		drafts = append(drafts, draft)
+	}
+
+	if len(drafts) == 0 {
+		return nil, errors.New("model returned no usable drafts")
+	}
+	return drafts, nil
+}
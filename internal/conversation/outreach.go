@@ -0,0 +1,121 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/channels"
+	"salesagency/internal/llm"
+	"salesagency/internal/scheduler"
+)
+
+// outreachBatchSize caps how many leads one agent run writes to.
+const outreachBatchSize = 20
+
+// Outreach is the work agent runs do: it writes a first message to each new
+// lead assigned to the agent that has not been contacted yet, and sends it.
+// Agents in dry-run mode store the message as a PENDING_REVIEW draft
+// instead, for someone to approve or reject.
+type Outreach struct {
+	engine     *Engine
+	dispatcher *channels.Dispatcher
+}
+
+func NewOutreach(engine *Engine, dispatcher *channels.Dispatcher) *Outreach {
+	return &Outreach{engine: engine, dispatcher: dispatcher}
+}
+
+// Run matches scheduler.AgentExecutor's Work. Failures for single leads are
+// logged to the run and do not stop it; an unconfigured LLM provider does.
+func (o *Outreach) Run(ctx context.Context, agent *model.AIAgent, run *model.AgentRun) error {
+	runLog := scheduler.LogFrom(ctx)
+
+	dryRun, err := o.engine.db.GetAIAgentDryRun(ctx, agent.ID)
+	if err != nil {
+		return err
+	}
+
+	leadIDs, err := o.engine.db.GetLeadsAwaitingOutreach(ctx, agent.ID, outreachBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(leadIDs) == 0 {
+		runLog.Infof("No new leads to contact")
+		return nil
+	}
+	if dryRun {
+		runLog.Infof("Dry run: messages to %d leads are drafted for review", len(leadIDs))
+	}
+
+	for _, leadID := range leadIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := o.contact(ctx, agent, leadID, dryRun)
+		switch {
+		case errors.Is(err, llm.ErrNotConfigured):
+			return err
+		case errors.Is(err, channels.ErrOptedOut), errors.Is(err, channels.ErrQuietHours),
+			errors.Is(err, channels.ErrSendLimitReached):
+			// Quiet hours and send limits are retried on the next run.
+			runLog.Infof("Skipped lead %s: %v", leadID, err)
+		case err != nil:
+			runLog.Errorf("Failed to contact lead %s: %v", leadID, err)
+		}
+		runLog.LeadProcessed()
+	}
+
+	return nil
+}
+
+// contact drafts outreach for the lead and sends, or stores, the draft for
+// the first channel that can send. Email is drafted first.
+func (o *Outreach) contact(ctx context.Context, agent *model.AIAgent, leadID string, dryRun bool) error {
+	drafts, err := o.engine.DraftOutreach(ctx, leadID, agent.ID)
+	if err != nil {
+		return err
+	}
+
+	var draft *model.OutreachDraft
+	for _, d := range drafts {
+		if o.dispatcher.CanSend(d.Channel) {
+			draft = d
+			break
+		}
+	}
+	if draft == nil {
+		scheduler.LogFrom(ctx).Warnf("No channel can send to lead %s", leadID)
+		return nil
+	}
+
+	lead, err := o.engine.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return err
+	}
+	if lead == nil {
+		return ErrLeadNotFound
+	}
+
+	msg := &channels.Outbound{Lead: lead, Body: draft.Body, AIAgent: agent}
+	if draft.Subject != nil {
+		msg.Subject = *draft.Subject
+	}
+
+	if dryRun {
+		_, err := o.dispatcher.Draft(ctx, draft.Channel, msg)
+		return err
+	}
+
+	interaction, err := o.dispatcher.Send(ctx, draft.Channel, msg)
+	if err != nil {
+		return err
+	}
+	if interaction.Status == model.InteractionStatusFailed {
+		scheduler.LogFrom(ctx).Errorf("Failed to send %s to lead %s", draft.Channel, leadID)
+		return nil
+	}
+	scheduler.LogFrom(ctx).MessageSent()
+	return nil
+}
@@ -0,0 +1,130 @@
+package conversation
+
+import (
+	"fmt"
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+const systemPrompt = `You are a sales development representative writing first-touch and follow-up outreach on behalf of an agency.
+Write short, specific, human messages. Reference the prospect's role and company and connect them to one concrete pain point.
+Never invent facts about the prospect, never use placeholders, and end with a single low-friction call to action.
+Respond with a JSON object of the form {"drafts": [{"channel": "EMAIL", "subject": "...", "body": "..."}]} and nothing else.`
+
+func buildPrompt(lead *model.Lead, agent *model.AIAgent, persona *model.AgentPersona, campaigns []*model.Campaign, targets map[string][]*model.TargetAudience, history []*model.Interaction, channels []model.Channel) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are writing as %q, whose purpose is: %s.\n", agent.Name, agent.Purpose)
+	if agent.Description != nil {
+		fmt.Fprintf(&b, "Agent notes: %s\n", *agent.Description)
+	}
+	if persona != nil {
+		writePersona(&b, persona)
+	}
+
+	b.WriteString("\nProspect:\n")
+	fmt.Fprintf(&b, "- Name: %s\n", lead.Name)
+	writeOptional(&b, "Position", lead.Position)
+	writeOptional(&b, "Company", lead.Company)
+	writeOptional(&b, "Source", lead.Source)
+	fmt.Fprintf(&b, "- Pipeline stage: %s\n", lead.Status)
+	if len(lead.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(lead.Tags, ", "))
+	}
+	writeOptional(&b, "Notes", lead.Notes)
+
+	for _, campaign := range campaigns {
+		fmt.Fprintf(&b, "\nCampaign %q", campaign.Name)
+		if campaign.Description != nil {
+			fmt.Fprintf(&b, ": %s", *campaign.Description)
+		}
+		b.WriteString("\n")
+
+		for _, target := range targets[campaign.ID] {
+			fmt.Fprintf(&b, "- Target audience %q in %s", target.Name, target.Industry)
+			if target.DecisionMakerRole != nil {
+				fmt.Fprintf(&b, ", decision maker: %s", *target.DecisionMakerRole)
+			}
+			if target.CompanySize != nil {
+				fmt.Fprintf(&b, ", company size: %s", *target.CompanySize)
+			}
+			if target.Location != nil {
+				fmt.Fprintf(&b, ", location: %s", *target.Location)
+			}
+			b.WriteString("\n")
+			if len(target.PainPoints) > 0 {
+				fmt.Fprintf(&b, "  Pain points: %s\n", strings.Join(target.PainPoints, "; "))
+			}
+		}
+	}
+
+	if len(history) > 0 {
+		b.WriteString("\nRecent interactions, newest first:\n")
+		for _, interaction := range history {
+			fmt.Fprintf(&b, "- %s %s %s", interaction.Timestamp.Format("2006-01-02"), interaction.Direction, interaction.Channel)
+			if interaction.Message != nil {
+				fmt.Fprintf(&b, ": %s", truncate(*interaction.Message, 300))
+			}
+			if interaction.Response != nil {
+				fmt.Fprintf(&b, " (reply: %s)", truncate(*interaction.Response, 300))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("This is a follow-up; do not repeat earlier messages.\n")
+	} else {
+		b.WriteString("\nThere has been no contact yet; this is the first touch.\n")
+	}
+
+	b.WriteString("\nWrite one draft for each of these channels:\n")
+	for _, channel := range channels {
+		switch channel {
+		case model.ChannelEmail:
+			b.WriteString("- EMAIL: include a subject line under 8 words and a body under 150 words.\n")
+		case model.ChannelSms:
+			b.WriteString("- SMS: no subject, a single message under 300 characters.\n")
+		default:
+			fmt.Fprintf(&b, "- %s\n", channel)
+		}
+	}
+
+	return b.String()
+}
+
+func writePersona(b *strings.Builder, persona *model.AgentPersona) {
+	if persona.Tone != nil && *persona.Tone != "" {
+		fmt.Fprintf(b, "Tone of voice: %s\n", *persona.Tone)
+	}
+	if persona.Language != nil && *persona.Language != "" {
+		fmt.Fprintf(b, "Write every draft in %s.\n", *persona.Language)
+	}
+	if len(persona.Dos) > 0 {
+		b.WriteString("Always:\n")
+		for _, rule := range persona.Dos {
+			fmt.Fprintf(b, "- %s\n", rule)
+		}
+	}
+	if len(persona.Donts) > 0 {
+		b.WriteString("Never:\n")
+		for _, rule := range persona.Donts {
+			fmt.Fprintf(b, "- %s\n", rule)
+		}
+	}
+	if persona.Signature != nil && *persona.Signature != "" {
+		b.WriteString("Do not sign off emails; a signature is added to them afterwards.\n")
+	}
+}
+
+func writeOptional(b *strings.Builder, label string, value *string) {
+	if value != nil && *value != "" {
+		fmt.Fprintf(b, "- %s: %s\n", label, *value)
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
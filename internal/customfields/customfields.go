@@ -0,0 +1,137 @@
+// Package customfields checks the lead fields agencies define for themselves
+// and converts the values entered for them to the form stored with leads.
+package customfields
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// DateLayout is how DATE values are entered and stored.
+const DateLayout = "2006-01-02"
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+var ErrInvalidKey = errors.New("key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+
+// ValidateDefinition checks that a definition is well formed and only sets
+// the rules that apply to its type.
+func ValidateDefinition(def *model.CustomFieldDefinition) error {
+	if !keyPattern.MatchString(def.Key) {
+		return ErrInvalidKey
+	}
+	if strings.TrimSpace(def.Label) == "" {
+		return errors.New("label is required")
+	}
+	if !def.Type.IsValid() {
+		return fmt.Errorf("invalid custom field type %s", def.Type)
+	}
+
+	if def.Type == model.CustomFieldTypeSelect {
+		if len(def.Options) == 0 {
+			return errors.New("SELECT fields need at least one option")
+		}
+		seen := make(map[string]bool, len(def.Options))
+		for _, option := range def.Options {
+			if option == "" || seen[option] {
+				return fmt.Errorf("invalid or repeated option %q", option)
+			}
+			seen[option] = true
+		}
+	} else if len(def.Options) > 0 {
+		return errors.New("options only apply to SELECT fields")
+	}
+
+	if def.Pattern != nil {
+		if def.Type != model.CustomFieldTypeText {
+			return errors.New("pattern only applies to TEXT fields")
+		}
+		if _, err := regexp.Compile(*def.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	if def.Min != nil || def.Max != nil {
+		if def.Type != model.CustomFieldTypeNumber {
+			return errors.New("min and max only apply to NUMBER fields")
+		}
+		if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+			return errors.New("min must not exceed max")
+		}
+	}
+
+	return nil
+}
+
+// Parse converts raw, as entered, to the value stored for a field of def's
+// type, checking def's rules.
+func Parse(def *model.CustomFieldDefinition, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+
+	switch def.Type {
+	case model.CustomFieldTypeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", def.Key)
+		}
+		if def.Min != nil && n < *def.Min {
+			return nil, fmt.Errorf("%s must be at least %v", def.Key, *def.Min)
+		}
+		if def.Max != nil && n > *def.Max {
+			return nil, fmt.Errorf("%s must be at most %v", def.Key, *def.Max)
+		}
+		return n, nil
+
+	case model.CustomFieldTypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", def.Key)
+		}
+		return b, nil
+
+	case model.CustomFieldTypeDate:
+		if _, err := time.Parse(DateLayout, raw); err != nil {
+			return nil, fmt.Errorf("%s must be a date as YYYY-MM-DD", def.Key)
+		}
+		return raw, nil
+
+	case model.CustomFieldTypeSelect:
+		for _, option := range def.Options {
+			if raw == option {
+				return raw, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of %s", def.Key, strings.Join(def.Options, ", "))
+
+	default:
+		if def.Pattern != nil {
+			// Patterns are checked when saved.
+			if !regexp.MustCompile(*def.Pattern).MatchString(raw) {
+				return nil, fmt.Errorf("%s does not match the expected format", def.Key)
+			}
+		}
+		return raw, nil
+	}
+}
+
+// Format renders a stored value the way Parse accepts it.
+func Format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const agentHandoffRuleColumns = `r.id, r.from_agent_id, r.to_agent_id, r.status, r.min_intent_score, r.enabled, r.created_at, r.updated_at`
+
+func scanAgentHandoffRule(row interface{ Scan(...interface{}) error }) (*model.AgentHandoffRule, error) {
+	var rule model.AgentHandoffRule
+	var status sql.NullString
+	var minIntentScore sql.NullFloat64
+	var updatedAt sql.NullTime
+	err := row.Scan(&rule.ID, &rule.FromAgentID, &rule.ToAgentID, &status, &minIntentScore, &rule.Enabled, &rule.CreatedAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if status.Valid {
+		s := model.LeadStatus(status.String)
+		rule.Status = &s
+	}
+	if minIntentScore.Valid {
+		rule.MinIntentScore = &minIntentScore.Float64
+	}
+	if updatedAt.Valid {
+		rule.UpdatedAt = &updatedAt.Time
+	}
+	return &rule, nil
+}
+
+func (db *DB) GetAgentHandoffRuleByID(ctx context.Context, id string) (*model.AgentHandoffRule, error) {
+	query := `SELECT ` + agentHandoffRuleColumns + ` FROM agent_handoff_rules r 
+              WHERE r.id = $1 AND (r.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := scanAgentHandoffRule(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching agent handoff rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetAgentHandoffRules lists the agency's handoff rules, or those of the
+// agent fromAgentID when it is not nil, oldest first.
+func (db *DB) GetAgentHandoffRules(ctx context.Context, fromAgentID *string) ([]*model.AgentHandoffRule, error) {
+	query := `SELECT ` + agentHandoffRuleColumns + ` FROM agent_handoff_rules r 
+              WHERE (r.from_agent_id = $1 OR $1 IS NULL) AND (r.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY r.created_at, r.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryAgentHandoffRules(ctx, query, fromAgentID, agencyID)
+}
+
+// GetLeadHandoffRules returns the enabled rules that could hand the lead on:
+// those of the agents it is assigned to, oldest first. Rules to agents that
+// are not active, or that have had the lead before, are left out, so that
+// leads are never handed back and forth.
+func (db *DB) GetLeadHandoffRules(ctx context.Context, leadID string) ([]*model.AgentHandoffRule, error) {
+	query := `SELECT ` + agentHandoffRuleColumns + ` FROM agent_handoff_rules r 
+              JOIN lead_ai_agent laa ON laa.ai_agent_id = r.from_agent_id AND laa.lead_id = $1 
+              JOIN ai_agents t ON t.id = r.to_agent_id AND t.status = 'ACTIVE' 
+              WHERE r.enabled AND (r.agency_id = $2 OR $2 IS NULL) 
+              AND NOT EXISTS (SELECT 1 FROM lead_agent_history h WHERE h.lead_id = $1 AND h.ai_agent_id = r.to_agent_id) 
+              ORDER BY r.created_at, r.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryAgentHandoffRules(ctx, query, leadID, agencyID)
+}
+
+func (db *DB) queryAgentHandoffRules(ctx context.Context, query string, args ...interface{}) ([]*model.AgentHandoffRule, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent handoff rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*model.AgentHandoffRule{}
+	for rows.Next() {
+		rule, err := scanAgentHandoffRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent handoff rule row: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent handoff rule rows: %w", err)
+	}
+
+	return rules, nil
+}
+
+// CreateAgentHandoffRule saves the rule. It returns nil when either agent
+// is not one of the agency's.
+func (db *DB) CreateAgentHandoffRule(ctx context.Context, rule *model.AgentHandoffRule) (*model.AgentHandoffRule, error) {
+	query := `INSERT INTO agent_handoff_rules (agency_id, from_agent_id, to_agent_id, status, min_intent_score, enabled, created_at) 
+              SELECT f.agency_id, f.id, t.id, $3, $4, $5, $6 
+              FROM ai_agents f JOIN ai_agents t ON t.agency_id = f.agency_id 
+              WHERE f.id = $1 AND t.id = $2 AND f.agency_id = $7 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, rule.FromAgentID, rule.ToAgentID, rule.Status, rule.MinIntentScore, rule.Enabled, rule.CreatedAt, agencyID,
+	).Scan(&rule.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error creating agent handoff rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateAgentHandoffRule saves the rule's agents, conditions and whether it
+// is enabled. It returns nil when the rule or either agent is not the
+// agency's.
+func (db *DB) UpdateAgentHandoffRule(ctx context.Context, rule *model.AgentHandoffRule) (*model.AgentHandoffRule, error) {
+	query := `UPDATE agent_handoff_rules r SET from_agent_id = f.id, to_agent_id = t.id, status = $3, 
+              min_intent_score = $4, enabled = $5, updated_at = $6 
+              FROM ai_agents f JOIN ai_agents t ON t.agency_id = f.agency_id 
+              WHERE r.id = $7 AND f.id = $1 AND t.id = $2 AND f.agency_id = r.agency_id 
+              AND (r.agency_id = $8 OR $8 IS NULL) 
+              RETURNING r.created_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = db.conn.QueryRowContext(
+		ctx, query, rule.FromAgentID, rule.ToAgentID, rule.Status, rule.MinIntentScore, rule.Enabled, now, rule.ID, agencyID,
+	).Scan(&rule.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error updating agent handoff rule: %w", err)
+	}
+	rule.UpdatedAt = &now
+
+	return rule, nil
+}
+
+func (db *DB) DeleteAgentHandoffRule(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM agent_handoff_rules WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting agent handoff rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetLeadAgentHistory lists the AI agents the lead has been assigned to,
+// newest first.
+func (db *DB) GetLeadAgentHistory(ctx context.Context, leadID string) ([]*model.LeadAgentOwnership, error) {
+	query := `SELECT h.ai_agent_id, h.handoff_rule_id, h.assigned_at, h.unassigned_at 
+              FROM lead_agent_history h JOIN leads l ON l.id = h.lead_id 
+              WHERE h.lead_id = $1 AND (l.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY h.assigned_at DESC, h.id DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, leadID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead agent history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*model.LeadAgentOwnership{}
+	for rows.Next() {
+		var ownership model.LeadAgentOwnership
+		var handoffRuleID sql.NullString
+		var unassignedAt sql.NullTime
+		if err := rows.Scan(&ownership.AgentID, &handoffRuleID, &ownership.AssignedAt, &unassignedAt); err != nil {
+			return nil, fmt.Errorf("error scanning lead agent history row: %w", err)
+		}
+		ownership.HandoffRuleID = nullString(handoffRuleID)
+		if unassignedAt.Valid {
+			ownership.UnassignedAt = &unassignedAt.Time
+		}
+		history = append(history, &ownership)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead agent history rows: %w", err)
+	}
+
+	return history, nil
+}
+
+// recordAgentOwnership ends fromAgentID's ownership of the lead, when it is
+// not empty, and starts toAgentID's unless the agent already owns it.
+func recordAgentOwnership(ctx context.Context, exec execer, leadID, fromAgentID, toAgentID string, handoffRuleID *string, at time.Time) error {
+	if fromAgentID != "" {
+		query := `UPDATE lead_agent_history SET unassigned_at = $3 
+              WHERE lead_id = $1 AND ai_agent_id = $2 AND unassigned_at IS NULL`
+		if _, err := exec.ExecContext(ctx, query, leadID, fromAgentID, at); err != nil {
+			return fmt.Errorf("error ending lead agent ownership: %w", err)
+		}
+	}
+
+	query := `INSERT INTO lead_agent_history (lead_id, ai_agent_id, handoff_rule_id, assigned_at) 
+              SELECT $1, $2, $3, $4 
+              WHERE NOT EXISTS (SELECT 1 FROM lead_agent_history 
+                  WHERE lead_id = $1 AND ai_agent_id = $2 AND unassigned_at IS NULL)`
+	if _, err := exec.ExecContext(ctx, query, leadID, toAgentID, handoffRuleID, at); err != nil {
+		return fmt.Errorf("error recording lead agent ownership: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// ErrAgentAtCapacity is returned when assigning a lead would give an AI agent
+// more active leads than its maximum.
+var ErrAgentAtCapacity = errors.New("AI agent has reached its maximum active leads")
+
+// activeLeadCount counts the live leads assigned to agent a.id that are not
+// closed; these are what an agent's maximum limits.
+const activeLeadCount = `(SELECT COUNT(*) FROM lead_ai_agent laa JOIN leads l ON l.id = laa.lead_id 
+              WHERE laa.ai_agent_id = a.id AND l.deleted_at IS NULL 
+              AND l.status NOT IN ('WON', 'LOST', 'DORMANT'))`
+
+// AgentLoad is how busy an AI agent is.
+type AgentLoad struct {
+	AgentID string
+	// MaxActiveLeads is nil when the agent has no limit.
+	MaxActiveLeads *int
+	ActiveLeads    int
+	// LastAssignedAt is nil when the agent was never assigned a lead.
+	LastAssignedAt *time.Time
+}
+
+// HasCapacity reports whether the agent can take another lead.
+func (l *AgentLoad) HasCapacity() bool {
+	return l.MaxActiveLeads == nil || l.ActiveLeads < *l.MaxActiveLeads
+}
+
+// GetAgentLoads returns the load of the agents with the given status,
+// restricted to ids unless it is empty.
+func (db *DB) GetAgentLoads(ctx context.Context, status model.AgentStatus, ids []string) ([]*AgentLoad, error) {
+	query := `SELECT a.id, a.max_active_leads, ` + activeLeadCount + `, 
+              (SELECT MAX(assigned_at) FROM lead_ai_agent WHERE ai_agent_id = a.id) 
+              FROM ai_agents a 
+              WHERE a.status = $1 AND (a.agency_id = $2 OR $2 IS NULL) 
+              AND (cardinality($3::uuid[]) = 0 OR a.id = ANY($3::uuid[])) 
+              ORDER BY a.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ids == nil {
+		ids = []string{}
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, status, agencyID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent loads: %w", err)
+	}
+	defer rows.Close()
+
+	loads := []*AgentLoad{}
+	for rows.Next() {
+		var load AgentLoad
+		var maxActiveLeads sql.NullInt64
+		var lastAssignedAt sql.NullTime
+		if err := rows.Scan(&load.AgentID, &maxActiveLeads, &load.ActiveLeads, &lastAssignedAt); err != nil {
+			return nil, fmt.Errorf("error scanning agent load row: %w", err)
+		}
+		if maxActiveLeads.Valid {
+			max := int(maxActiveLeads.Int64)
+			load.MaxActiveLeads = &max
+		}
+		if lastAssignedAt.Valid {
+			load.LastAssignedAt = &lastAssignedAt.Time
+		}
+		loads = append(loads, &load)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent load rows: %w", err)
+	}
+
+	return loads, nil
+}
+
+// GetAIAgentMaxActiveLeads returns nil when the agent has no limit.
+func (db *DB) GetAIAgentMaxActiveLeads(ctx context.Context, id string) (*int, error) {
+	query := `SELECT max_active_leads FROM ai_agents WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxActiveLeads sql.NullInt64
+	err = db.conn.QueryRowContext(ctx, query, id, agencyID).Scan(&maxActiveLeads)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching ai agent capacity: %w", err)
+	}
+	if !maxActiveLeads.Valid {
+		return nil, nil
+	}
+
+	max := int(maxActiveLeads.Int64)
+	return &max, nil
+}
+
+// SetAIAgentMaxActiveLeads sets the agent's limit; nil removes it. Leads the
+// agent already has above a lowered limit are kept.
+func (db *DB) SetAIAgentMaxActiveLeads(ctx context.Context, id string, maxActiveLeads *int) (bool, error) {
+	query := `UPDATE ai_agents SET max_active_leads = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, maxActiveLeads, time.Now(), id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating ai agent capacity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	db.invalidate(ctx, aiAgentCacheKey(id))
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) CountActiveLeadsByAIAgentID(ctx context.Context, id string) (int, error) {
+	query := `SELECT ` + activeLeadCount + ` FROM ai_agents a WHERE a.id = $1 AND (a.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = db.conn.QueryRowContext(ctx, query, id, agencyID).Scan(&count)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("error counting ai agent active leads: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetActiveLeadIDsByAIAgentID returns the agent's active leads, longest
+// assigned first.
+func (db *DB) GetActiveLeadIDsByAIAgentID(ctx context.Context, id string) ([]string, error) {
+	query := `SELECT l.id FROM lead_ai_agent laa JOIN leads l ON l.id = laa.lead_id 
+              WHERE laa.ai_agent_id = $1 AND (l.agency_id = $2 OR $2 IS NULL) AND l.deleted_at IS NULL 
+              AND l.status NOT IN ('WON', 'LOST', 'DORMANT') 
+              ORDER BY laa.assigned_at, l.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, id, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying ai agent active leads: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var leadID string
+		if err := rows.Scan(&leadID); err != nil {
+			return nil, fmt.Errorf("error scanning lead id row: %w", err)
+		}
+		ids = append(ids, leadID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead id rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// MoveLeadToAIAgent moves the lead from one agent to another within the
+// target's capacity. It reports false when the lead is not assigned to the
+// source agent or the target agent does not exist.
+func (db *DB) MoveLeadToAIAgent(ctx context.Context, leadID, fromAgentID, toAgentID string) (bool, error) {
+	return db.moveLead(ctx, leadID, fromAgentID, toAgentID, nil)
+}
+
+// HandOffLead moves the lead along the handoff rule as MoveLeadToAIAgent
+// does, recording the rule in the lead's agent history.
+func (db *DB) HandOffLead(ctx context.Context, leadID string, rule *model.AgentHandoffRule) (bool, error) {
+	return db.moveLead(ctx, leadID, rule.FromAgentID, rule.ToAgentID, &rule.ID)
+}
+
+func (db *DB) moveLead(ctx context.Context, leadID, fromAgentID, toAgentID string, handoffRuleID *string) (bool, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	found, err := reserveCapacity(ctx, tx, toAgentID, agencyID)
+	if err != nil || !found {
+		return false, err
+	}
+
+	query := `DELETE FROM lead_ai_agent laa USING leads l 
+              WHERE laa.lead_id = l.id AND laa.lead_id = $1 AND laa.ai_agent_id = $2 
+              AND (l.agency_id = $3 OR $3 IS NULL)`
+	result, err := tx.ExecContext(ctx, query, leadID, fromAgentID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error unassigning lead from AI agent: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	// A lead already with the target agent just leaves the source.
+	query = `INSERT INTO lead_ai_agent (lead_id, ai_agent_id, assigned_at) 
+              SELECT l.id, a.id, $3 
+              FROM leads l, ai_agents a 
+              WHERE l.id = $1 AND a.id = $2 AND l.agency_id = a.agency_id 
+              AND (l.agency_id = $4 OR $4 IS NULL) 
+              ON CONFLICT (lead_id, ai_agent_id) DO NOTHING`
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, query, leadID, toAgentID, now, agencyID); err != nil {
+		return false, fmt.Errorf("error assigning lead to AI agent: %w", err)
+	}
+
+	if err := recordLeadAssignment(ctx, tx, leadID, fromAgentID, toAgentID, handoffRuleID, now); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// reserveCapacity locks the agent's row until tx ends, so concurrent
+// assignments to the same agent cannot both take its last slot, and returns
+// ErrAgentAtCapacity when the agent is full. It reports false when the agent
+// does not exist.
+func reserveCapacity(ctx context.Context, tx *sql.Tx, agentID string, agencyID interface{}) (bool, error) {
+	query := `SELECT max_active_leads FROM ai_agents WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) FOR UPDATE`
+
+	var maxActiveLeads sql.NullInt64
+	err := tx.QueryRowContext(ctx, query, agentID, agencyID).Scan(&maxActiveLeads)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking ai agent capacity: %w", err)
+	}
+	if !maxActiveLeads.Valid {
+		return true, nil
+	}
+
+	var active int64
+	query = `SELECT ` + activeLeadCount + ` FROM ai_agents a WHERE a.id = $1`
+	if err := tx.QueryRowContext(ctx, query, agentID).Scan(&active); err != nil {
+		return false, fmt.Errorf("error counting ai agent active leads: %w", err)
+	}
+
+	if active >= maxActiveLeads.Int64 {
+		return false, ErrAgentAtCapacity
+	}
+
+	return true, nil
+}
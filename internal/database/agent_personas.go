@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const personaColumns = `p.id, p.agent_id, p.version, p.tone, p.language, p.signature, p.dos, p.donts, p.created_by, p.created_at`
+
+// CreateAgentPersona saves persona as the agent's next version. It returns
+// nil when the agent does not exist.
+func (db *DB) CreateAgentPersona(ctx context.Context, persona *model.AgentPersona) (*model.AgentPersona, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the agent serializes version numbers.
+	var agentID string
+	err = tx.QueryRowContext(
+		ctx, "SELECT id FROM ai_agents WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) FOR UPDATE",
+		persona.AgentID, agencyID,
+	).Scan(&agentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error locking AI agent: %w", err)
+	}
+
+	query := `INSERT INTO agent_personas (agent_id, version, tone, language, signature, dos, donts, created_by, created_at) 
+              SELECT $1::uuid, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5::text[], $6::text[], $7::uuid, $8::timestamptz 
+              FROM agent_personas WHERE agent_id = $1 
+              RETURNING id, version`
+
+	err = tx.QueryRowContext(
+		ctx, query, agentID, persona.Tone, persona.Language, persona.Signature, pq.Array(persona.Dos),
+		pq.Array(persona.Donts), persona.CreatedByID, persona.CreatedAt,
+	).Scan(&persona.ID, &persona.Version)
+	if err != nil {
+		return nil, fmt.Errorf("error creating agent persona: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return persona, nil
+}
+
+// GetCurrentAgentPersona returns the agent's latest persona, or nil when it
+// has none.
+func (db *DB) GetCurrentAgentPersona(ctx context.Context, agentID string) (*model.AgentPersona, error) {
+	query := `SELECT ` + personaColumns + ` 
+              FROM agent_personas p JOIN ai_agents a ON a.id = p.agent_id 
+              WHERE p.agent_id = $1 AND (a.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY p.version DESC LIMIT 1`
+
+	return db.getAgentPersona(ctx, query, agentID)
+}
+
+func (db *DB) GetAgentPersonaByID(ctx context.Context, id string) (*model.AgentPersona, error) {
+	query := `SELECT ` + personaColumns + ` 
+              FROM agent_personas p JOIN ai_agents a ON a.id = p.agent_id 
+              WHERE p.id = $1 AND (a.agency_id = $2 OR $2 IS NULL)`
+
+	return db.getAgentPersona(ctx, query, id)
+}
+
+// GetInteractionPersona returns the persona the interaction's agent had when
+// the interaction was recorded.
+func (db *DB) GetInteractionPersona(ctx context.Context, interactionID string) (*model.AgentPersona, error) {
+	query := `SELECT ` + personaColumns + ` 
+              FROM interactions i JOIN agent_personas p ON p.id = i.persona_id JOIN ai_agents a ON a.id = p.agent_id 
+              WHERE i.id = $1 AND (a.agency_id = $2 OR $2 IS NULL)`
+
+	return db.getAgentPersona(ctx, query, interactionID)
+}
+
+func (db *DB) getAgentPersona(ctx context.Context, query, id string) (*model.AgentPersona, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	persona, err := scanAgentPersona(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching agent persona: %w", err)
+	}
+
+	return persona, nil
+}
+
+// GetAgentPersonas returns every version of the agent's persona, newest
+// first.
+func (db *DB) GetAgentPersonas(ctx context.Context, agentID string) ([]*model.AgentPersona, error) {
+	query := `SELECT ` + personaColumns + ` 
+              FROM agent_personas p JOIN ai_agents a ON a.id = p.agent_id 
+              WHERE p.agent_id = $1 AND (a.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY p.version DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agentID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent personas: %w", err)
+	}
+	defer rows.Close()
+
+	personas := []*model.AgentPersona{}
+	for rows.Next() {
+		persona, err := scanAgentPersona(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent persona row: %w", err)
+		}
+		personas = append(personas, persona)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent persona rows: %w", err)
+	}
+
+	return personas, nil
+}
+
+func scanAgentPersona(row interface{ Scan(...interface{}) error }) (*model.AgentPersona, error) {
+	var persona model.AgentPersona
+	var tone, language, signature, createdBy sql.NullString
+
+	err := row.Scan(
+		&persona.ID, &persona.AgentID, &persona.Version, &tone, &language, &signature,
+		pq.Array(&persona.Dos), pq.Array(&persona.Donts), &createdBy, &persona.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tone.Valid {
+		persona.Tone = &tone.String
+	}
+	if language.Valid {
+		persona.Language = &language.String
+	}
+	if signature.Valid {
+		persona.Signature = &signature.String
+	}
+	if createdBy.Valid {
+		persona.CreatedByID = &createdBy.String
+	}
+	if persona.Dos == nil {
+		persona.Dos = []string{}
+	}
+	if persona.Donts == nil {
+		persona.Donts = []string{}
+	}
+
+	return &persona, nil
+}
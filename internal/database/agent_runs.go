@@ -0,0 +1,390 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const agentRunColumns = `id, agent_id, schedule_id, status, scheduled_for, started_at, 
+              finished_at, duration_ms, error, leads_processed, messages_sent, error_count, created_at`
+
+func scanAgentRun(scanner interface{ Scan(...interface{}) error }) (*model.AgentRun, error) {
+	var run model.AgentRun
+	var scheduleID, runError sql.NullString
+	var startedAt, finishedAt sql.NullTime
+	var durationMs sql.NullInt64
+
+	err := scanner.Scan(
+		&run.ID, &run.AgentID, &scheduleID, &run.Status, &run.ScheduledFor, &startedAt,
+		&finishedAt, &durationMs, &runError, &run.LeadsProcessed, &run.MessagesSent, &run.ErrorCount, &run.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduleID.Valid {
+		run.ScheduleID = &scheduleID.String
+	}
+	if startedAt.Valid {
+		run.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	if durationMs.Valid {
+		duration := int(durationMs.Int64)
+		run.DurationMs = &duration
+	}
+	if runError.Valid {
+		run.Error = &runError.String
+	}
+
+	return &run, nil
+}
+
+func (db *DB) CreateAgentSchedule(ctx context.Context, schedule *model.AgentSchedule) (*model.AgentSchedule, error) {
+	query := `INSERT INTO agent_schedules (agent_id, cron, next_run_at, enabled, created_at) 
+              VALUES ($1, $2, $3, $4, $5) 
+              RETURNING id`
+
+	err := db.conn.QueryRowContext(
+		ctx, query, schedule.AgentID, schedule.Cron, schedule.NextRunAt, schedule.Enabled, schedule.CreatedAt,
+	).Scan(&schedule.ID)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating agent schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (db *DB) GetSchedulesByAIAgentID(ctx context.Context, aiAgentID string) ([]*model.AgentSchedule, error) {
+	query := `SELECT id, agent_id, cron, next_run_at, enabled, created_at 
+              FROM agent_schedules WHERE agent_id = $1 ORDER BY created_at`
+
+	rows, err := db.conn.QueryContext(ctx, query, aiAgentID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*model.AgentSchedule
+	for rows.Next() {
+		var schedule model.AgentSchedule
+
+		err := rows.Scan(
+			&schedule.ID, &schedule.AgentID, &schedule.Cron, &schedule.NextRunAt,
+			&schedule.Enabled, &schedule.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent schedule row: %w", err)
+		}
+
+		schedules = append(schedules, &schedule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent schedule rows: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// DisableAgentSchedule stops a schedule from producing new runs and cancels
+// any of its runs that have not started yet. Schedules of other agencies'
+// agents are left alone and reported as not found.
+func (db *DB) DisableAgentSchedule(ctx context.Context, scheduleID string) (bool, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE agent_schedules s SET enabled = false 
+              FROM ai_agents a 
+              WHERE s.id = $1 AND a.id = s.agent_id AND (a.agency_id = $2 OR $2 IS NULL)`
+	result, err := tx.ExecContext(ctx, query, scheduleID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error disabling agent schedule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	query = `UPDATE agent_runs SET status = $1, finished_at = $2 
+              WHERE schedule_id = $3 AND status = $4`
+	_, err = tx.ExecContext(ctx, query, model.AgentRunStatusCancelled, time.Now(), scheduleID, model.AgentRunStatusQueued)
+	if err != nil {
+		return false, fmt.Errorf("error cancelling queued agent runs: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+func (db *DB) EnqueueAgentRun(ctx context.Context, aiAgentID string, scheduleID *string, scheduledFor time.Time) (*model.AgentRun, error) {
+	query := `INSERT INTO agent_runs (agent_id, schedule_id, status, scheduled_for, created_at) 
+              VALUES ($1, $2, $3, $4, $5) 
+              RETURNING ` + agentRunColumns
+
+	run, err := scanAgentRun(db.conn.QueryRowContext(
+		ctx, query, aiAgentID, scheduleID, model.AgentRunStatusQueued, scheduledFor, time.Now(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error enqueueing agent run: %w", err)
+	}
+
+	return run, nil
+}
+
+// EnqueueDueScheduledRuns queues a run for every enabled schedule whose
+// next_run_at has passed and advances it using next. Rows are locked with
+// SKIP LOCKED so several server instances can poll concurrently.
+func (db *DB) EnqueueDueScheduledRuns(ctx context.Context, now time.Time, next func(cron string, after time.Time) (time.Time, error)) (int, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT s.id, s.agent_id, s.cron, s.next_run_at 
+              FROM agent_schedules s 
+              WHERE s.enabled AND s.next_run_at <= $1 
+              FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("error querying due agent schedules: %w", err)
+	}
+
+	var due []model.AgentSchedule
+	for rows.Next() {
+		var schedule model.AgentSchedule
+		if err := rows.Scan(&schedule.ID, &schedule.AgentID, &schedule.Cron, &schedule.NextRunAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning agent schedule row: %w", err)
+		}
+		due = append(due, schedule)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating agent schedule rows: %w", err)
+	}
+
+	for _, schedule := range due {
+		nextRunAt, err := next(schedule.Cron, now)
+		if err != nil {
+			return 0, fmt.Errorf("error computing next run for schedule %s: %w", schedule.ID, err)
+		}
+
+		insertQuery := `INSERT INTO agent_runs (agent_id, schedule_id, status, scheduled_for, created_at) 
+                        VALUES ($1, $2, $3, $4, $5)`
+		_, err = tx.ExecContext(ctx, insertQuery, schedule.AgentID, schedule.ID, model.AgentRunStatusQueued, schedule.NextRunAt, now)
+		if err != nil {
+			return 0, fmt.Errorf("error enqueueing scheduled agent run: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE agent_schedules SET next_run_at = $1 WHERE id = $2", nextRunAt, schedule.ID)
+		if err != nil {
+			return 0, fmt.Errorf("error advancing agent schedule: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return len(due), nil
+}
+
+// ClaimQueuedAgentRuns marks up to limit due runs as RUNNING and returns them.
+func (db *DB) ClaimQueuedAgentRuns(ctx context.Context, limit int) ([]*model.AgentRun, error) {
+	query := `UPDATE agent_runs SET status = $1, started_at = $2 
+              WHERE id IN ( 
+                  SELECT id FROM agent_runs 
+                  WHERE status = $3 AND scheduled_for <= $2 
+                  ORDER BY scheduled_for 
+                  LIMIT $4 
+                  FOR UPDATE SKIP LOCKED 
+              ) 
+              RETURNING ` + agentRunColumns
+
+	rows, err := db.conn.QueryContext(ctx, query, model.AgentRunStatusRunning, time.Now(), model.AgentRunStatusQueued, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming agent runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*model.AgentRun
+	for rows.Next() {
+		run, err := scanAgentRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent run row: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent run rows: %w", err)
+	}
+
+	return runs, nil
+}
+
+// FinishAgentRun records the outcome of a run and stamps the agent's last_run.
+func (db *DB) FinishAgentRun(ctx context.Context, run *model.AgentRun) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE agent_runs SET status = $1, finished_at = $2, duration_ms = $3, error = $4, 
+              leads_processed = $5, messages_sent = $6, error_count = $7 
+              WHERE id = $8`
+	_, err = tx.ExecContext(
+		ctx, query, run.Status, run.FinishedAt, run.DurationMs, run.Error,
+		run.LeadsProcessed, run.MessagesSent, run.ErrorCount, run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error finishing agent run: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE ai_agents SET last_run = $1 WHERE id = $2", run.StartedAt, run.AgentID)
+	if err != nil {
+		return fmt.Errorf("error updating agent last run: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, aiAgentCacheKey(run.AgentID))
+
+	return nil
+}
+
+func (db *DB) GetAgentRunByID(ctx context.Context, id string) (*model.AgentRun, error) {
+	query := `SELECT ` + agentRunColumns + ` FROM agent_runs 
+              WHERE id = $1 AND agent_id IN (SELECT id FROM ai_agents WHERE agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := scanAgentRun(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting agent run: %w", err)
+	}
+
+	return run, nil
+}
+
+// GetAgentRunsByAIAgentID lists an agent's runs, newest first.
+func (db *DB) GetAgentRunsByAIAgentID(ctx context.Context, aiAgentID string, limit, offset *int) ([]*model.AgentRun, error) {
+	query := `SELECT ` + agentRunColumns + ` FROM agent_runs 
+              WHERE agent_id = $1 ORDER BY created_at DESC`
+
+	args := []interface{}{aiAgentID}
+	argCount := 2
+	if limit != nil {
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, *limit)
+		argCount++
+	}
+
+	if offset != nil {
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, *offset)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*model.AgentRun{}
+	for rows.Next() {
+		run, err := scanAgentRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent run row: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent run rows: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (db *DB) CreateAgentRunLog(ctx context.Context, entry *model.AgentRunLog) (*model.AgentRunLog, error) {
+	query := `INSERT INTO agent_run_logs (run_id, level, message, created_at) 
+              VALUES ($1, $2, $3, $4) 
+              RETURNING id`
+
+	err := db.conn.QueryRowContext(ctx, query, entry.RunID, entry.Level, entry.Message, entry.CreatedAt).Scan(&entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating agent run log: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetAgentRunLogs returns a run's log lines in order, starting after the line
+// with ID afterID; pass 0 to start at the beginning.
+func (db *DB) GetAgentRunLogs(ctx context.Context, runID string, afterID int64, limit *int) ([]*model.AgentRunLog, error) {
+	query := `SELECT id, run_id, level, message, created_at 
+              FROM agent_run_logs WHERE run_id = $1 AND id > $2 ORDER BY id`
+
+	args := []interface{}{runID, afterID}
+	if limit != nil {
+		query += " LIMIT $3"
+		args = append(args, *limit)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent run logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.AgentRunLog{}
+	for rows.Next() {
+		var entry model.AgentRunLog
+		if err := rows.Scan(&entry.ID, &entry.RunID, &entry.Level, &entry.Message, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning agent run log row: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent run log rows: %w", err)
+	}
+
+	return entries, nil
+}
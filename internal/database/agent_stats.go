@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AgentDayStats is an AI agent's activity on one UTC day, as snapshotted by
+// RollupAgentStats. Counts add up across days; totals over several days
+// leave Day zero.
+type AgentDayStats struct {
+	AgentID string
+	Day     time.Time
+	// LeadsEngaged counts each lead messaged once per day.
+	LeadsEngaged      int
+	MessagesDelivered int
+	// Replies are inbound messages answering the agent's latest message to
+	// the lead, counted on the day they arrived.
+	Replies     int
+	Conversions int
+	// ResponseSeconds totals how long the replies took to arrive.
+	ResponseSeconds float64
+	ComputedAt      time.Time
+}
+
+// agentDayStatsQuery computes the day rows from $1 on. Conversions count
+// leads won that were assigned to the agent.
+const agentDayStatsQuery = `WITH sent AS ( 
+                  SELECT i.ai_agent_id AS agent_id, (i.timestamp AT TIME ZONE 'UTC')::date AS day, 
+                  COUNT(*) AS messages, COUNT(DISTINCT i.lead_id) AS leads 
+                  FROM interactions i 
+                  WHERE i.ai_agent_id IS NOT NULL AND i.timestamp >= $1 AND ` + sentMessageFilter + ` 
+                  GROUP BY 1, 2 
+              ), replies AS ( 
+                  SELECT o.ai_agent_id AS agent_id, (r.timestamp AT TIME ZONE 'UTC')::date AS day, 
+                  COUNT(*) AS replies, SUM(EXTRACT(EPOCH FROM r.timestamp - o.timestamp)) AS seconds 
+                  FROM interactions r 
+                  CROSS JOIN LATERAL ( 
+                      SELECT i.ai_agent_id, i.timestamp FROM interactions i 
+                      WHERE i.lead_id = r.lead_id AND i.timestamp <= r.timestamp AND ` + sentMessageFilter + ` 
+                      ORDER BY i.timestamp DESC LIMIT 1 
+                  ) o 
+                  WHERE r.direction = 'INBOUND' AND r.timestamp >= $1 AND o.ai_agent_id IS NOT NULL 
+                  GROUP BY 1, 2 
+              ), conversions AS ( 
+                  SELECT la.ai_agent_id AS agent_id, (h.created_at AT TIME ZONE 'UTC')::date AS day, 
+                  COUNT(DISTINCT h.lead_id) AS conversions 
+                  FROM lead_status_history h JOIN lead_ai_agent la ON la.lead_id = h.lead_id 
+                  WHERE h.to_status = 'WON' AND h.created_at >= $1 
+                  GROUP BY 1, 2 
+              ) 
+              INSERT INTO agent_daily_stats (agent_id, day, leads_engaged, messages_delivered, replies, conversions, response_seconds, computed_at) 
+              SELECT k.agent_id, k.day, COALESCE(s.leads, 0), COALESCE(s.messages, 0), COALESCE(r.replies, 0), 
+              COALESCE(c.conversions, 0), COALESCE(r.seconds, 0), $2 
+              FROM (SELECT agent_id, day FROM sent UNION SELECT agent_id, day FROM replies UNION SELECT agent_id, day FROM conversions) k 
+              LEFT JOIN sent s USING (agent_id, day) 
+              LEFT JOIN replies r USING (agent_id, day) 
+              LEFT JOIN conversions c USING (agent_id, day)`
+
+// RollupAgentStats rebuilds the day rows of every agent from the day before
+// the latest snapshot through now's day, so late changes such as bounces
+// reach yesterday's row. The first run snapshots all history. It returns
+// how many rows were written.
+func (db *DB) RollupAgentStats(ctx context.Context, now time.Time) (int, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Keeps concurrent rollups on other replicas from interleaving.
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE agent_daily_stats IN EXCLUSIVE MODE`); err != nil {
+		return 0, fmt.Errorf("error locking agent stats: %w", err)
+	}
+
+	var latest, first sql.NullTime
+	query := `SELECT (SELECT MAX(day)::timestamp AT TIME ZONE 'UTC' FROM agent_daily_stats), 
+              (SELECT MIN(timestamp) FROM interactions WHERE ai_agent_id IS NOT NULL)`
+	if err := tx.QueryRowContext(ctx, query).Scan(&latest, &first); err != nil {
+		return 0, fmt.Errorf("error finding agent stats start: %w", err)
+	}
+
+	from := utcDay(now)
+	switch {
+	case latest.Valid:
+		from = utcDay(latest.Time).AddDate(0, 0, -1)
+	case first.Valid:
+		from = utcDay(first.Time)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_daily_stats WHERE day >= $1::date`, from.Format(time.DateOnly)); err != nil {
+		return 0, fmt.Errorf("error clearing agent stats: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, agentDayStatsQuery, from, now)
+	if err != nil {
+		return 0, fmt.Errorf("error rolling up agent stats: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetAgentDayStats returns the agent's day rows for the days in [from, to),
+// oldest first. Days without activity have no row.
+func (db *DB) GetAgentDayStats(ctx context.Context, agentID string, from, to time.Time) ([]*AgentDayStats, error) {
+	query := `SELECT s.agent_id, s.day, s.leads_engaged, s.messages_delivered, s.replies, s.conversions, 
+              s.response_seconds, s.computed_at 
+              FROM agent_daily_stats s JOIN ai_agents a ON a.id = s.agent_id 
+              WHERE s.agent_id = $1 AND s.day >= $2::date AND s.day < $3::date AND (a.agency_id = $4 OR $4 IS NULL) 
+              ORDER BY s.day`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, agentID, from.Format(time.DateOnly), to.Format(time.DateOnly), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent stats: %w", err)
+	}
+	defer rows.Close()
+
+	var days []*AgentDayStats
+	for rows.Next() {
+		var day AgentDayStats
+
+		err := rows.Scan(
+			&day.AgentID, &day.Day, &day.LeadsEngaged, &day.MessagesDelivered, &day.Replies, &day.Conversions,
+			&day.ResponseSeconds, &day.ComputedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent stats row: %w", err)
+		}
+
+		days = append(days, &day)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent stats rows: %w", err)
+	}
+
+	return days, nil
+}
+
+// GetAgentStatsTotals sums each agent's day rows over all time. Agents
+// without any have zero totals.
+func (db *DB) GetAgentStatsTotals(ctx context.Context, agentIDs []string) (map[string]*AgentDayStats, error) {
+	query := `SELECT s.agent_id, SUM(s.leads_engaged), SUM(s.messages_delivered), SUM(s.replies), SUM(s.conversions), 
+              SUM(s.response_seconds), MAX(s.computed_at) 
+              FROM agent_daily_stats s JOIN ai_agents a ON a.id = s.agent_id 
+              WHERE s.agent_id = ANY($1) AND (a.agency_id = $2 OR $2 IS NULL) 
+              GROUP BY s.agent_id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, pq.Array(agentIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent stats: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]*AgentDayStats, len(agentIDs))
+	for rows.Next() {
+		var total AgentDayStats
+
+		err := rows.Scan(
+			&total.AgentID, &total.LeadsEngaged, &total.MessagesDelivered, &total.Replies, &total.Conversions,
+			&total.ResponseSeconds, &total.ComputedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning agent stats row: %w", err)
+		}
+
+		totals[total.AgentID] = &total
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent stats rows: %w", err)
+	}
+
+	for _, agentID := range agentIDs {
+		if _, ok := totals[agentID]; !ok {
+			totals[agentID] = &AgentDayStats{AgentID: agentID}
+		}
+	}
+
+	return totals, nil
+}
+
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
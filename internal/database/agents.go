@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+func (db *DB) UpdateAIAgentStatus(ctx context.Context, id string, status model.AgentStatus) (bool, error) {
+	query := `UPDATE ai_agents SET status = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, status, time.Now(), id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating ai agent status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	db.invalidate(ctx, aiAgentCacheKey(id))
+
+	return rowsAffected > 0, nil
+}
+
+// GetAIAgentCalendarID returns the calendar meetings are booked on for the
+// agent, or "" when none is set.
+func (db *DB) GetAIAgentCalendarID(ctx context.Context, id string) (string, error) {
+	query := `SELECT calendar_id FROM ai_agents WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var calendarID sql.NullString
+	err = db.conn.QueryRowContext(ctx, query, id, agencyID).Scan(&calendarID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("error fetching ai agent calendar: %w", err)
+	}
+
+	return calendarID.String, nil
+}
+
+// SetAIAgentCalendarID sets the agent's calendar; nil clears it.
+func (db *DB) SetAIAgentCalendarID(ctx context.Context, id string, calendarID *string) (bool, error) {
+	query := `UPDATE ai_agents SET calendar_id = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, calendarID, time.Now(), id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating ai agent calendar: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	db.invalidate(ctx, aiAgentCacheKey(id))
+
+	return rowsAffected > 0, nil
+}
+
+// GetAIAgentDryRun reports whether the agent's messages are held for review
+// instead of sent.
+func (db *DB) GetAIAgentDryRun(ctx context.Context, id string) (bool, error) {
+	query := `SELECT dry_run FROM ai_agents WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var dryRun bool
+	err = db.conn.QueryRowContext(ctx, query, id, agencyID).Scan(&dryRun)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("error fetching ai agent dry run: %w", err)
+	}
+
+	return dryRun, nil
+}
+
+func (db *DB) SetAIAgentDryRun(ctx context.Context, id string, dryRun bool) (bool, error) {
+	query := `UPDATE ai_agents SET dry_run = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, dryRun, time.Now(), id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating ai agent dry run: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	db.invalidate(ctx, aiAgentCacheKey(id))
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+func (db *DB) CreateAPIKey(ctx context.Context, key *model.APIKey, keyHash string) (*model.APIKey, error) {
+	query := `INSERT INTO api_keys (name, role, integration, key_hash, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(ctx, query, key.Name, key.Role, key.Integration, keyHash, key.CreatedAt, agencyID).Scan(&key.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating API key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (db *DB) GetAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	query := `SELECT id, name, role, integration, created_at, last_used_at, revoked_at 
+              FROM api_keys WHERE (agency_id = $1 OR $1 IS NULL) 
+              ORDER BY created_at DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*model.APIKey
+	for rows.Next() {
+		var key model.APIKey
+		var lastUsedAt, revokedAt sql.NullTime
+
+		err := rows.Scan(&key.ID, &key.Name, &key.Role, &key.Integration, &key.CreatedAt, &lastUsedAt, &revokedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning API key row: %w", err)
+		}
+
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+
+		keys = append(keys, &key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// AuthenticateAPIKey looks up an active key by hash and records its use. Like
+// GetUserCredentialsByEmail it runs before any tenant is known and is not
+// scoped to an agency; the key's agency is returned alongside it.
+func (db *DB) AuthenticateAPIKey(ctx context.Context, keyHash string) (*model.APIKey, string, error) {
+	query := `UPDATE api_keys SET last_used_at = $1 
+              WHERE key_hash = $2 AND revoked_at IS NULL 
+              RETURNING id, name, role, integration, created_at, last_used_at, agency_id`
+
+	var key model.APIKey
+	var agencyID string
+	var lastUsedAt time.Time
+
+	err := db.conn.QueryRowContext(ctx, query, time.Now(), keyHash).Scan(
+		&key.ID, &key.Name, &key.Role, &key.Integration, &key.CreatedAt, &lastUsedAt, &agencyID,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("error fetching API key: %w", err)
+	}
+
+	key.LastUsedAt = &lastUsedAt
+
+	return &key, agencyID, nil
+}
+
+func (db *DB) RevokeAPIKey(ctx context.Context, id string) (bool, error) {
+	query := `UPDATE api_keys SET revoked_at = $1 
+              WHERE id = $2 AND revoked_at IS NULL AND (agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, time.Now(), id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error revoking API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
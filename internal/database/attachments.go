@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+)
+
+const attachmentColumns = `id, lead_id, interaction_id, storage_key, filename, content_type, size, uploaded_by, created_at`
+
+func (db *DB) CreateAttachment(ctx context.Context, attachment *model.Attachment) (*model.Attachment, error) {
+	query := `INSERT INTO attachments (lead_id, interaction_id, storage_key, filename, content_type, size, 
+              uploaded_by, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, attachment.LeadID, attachment.InteractionID, attachment.StorageKey, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.UploadedByID, attachment.CreatedAt, agencyID,
+	).Scan(&attachment.ID)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+func (db *DB) GetAttachmentByID(ctx context.Context, id string) (*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := scanAttachment(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+func (db *DB) GetAttachmentsByLeadID(ctx context.Context, leadID string) ([]*model.Attachment, error) {
+	return db.getAttachments(ctx, "lead_id", leadID)
+}
+
+func (db *DB) GetAttachmentsByInteractionID(ctx context.Context, interactionID string) ([]*model.Attachment, error) {
+	return db.getAttachments(ctx, "interaction_id", interactionID)
+}
+
+func (db *DB) getAttachments(ctx context.Context, column, id string) ([]*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments 
+              WHERE ` + column + ` = $1 AND (agency_id = $2 OR $2 IS NULL) 
+              ORDER BY created_at, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, id, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []*model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning attachment row: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachment rows: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment removes the attachment's record and returns it so the
+// caller can delete the stored file. It returns nil when there is none.
+func (db *DB) DeleteAttachment(ctx context.Context, id string) (*model.Attachment, error) {
+	query := `DELETE FROM attachments WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) 
+              RETURNING ` + attachmentColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := scanAttachment(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error deleting attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// GetLeadAttachmentKeys returns the storage keys of the files attached to
+// the lead and to its interactions.
+func (db *DB) GetLeadAttachmentKeys(ctx context.Context, leadID string) ([]string, error) {
+	query := `SELECT storage_key FROM attachments 
+              WHERE (lead_id = $1 OR interaction_id IN (SELECT id FROM interactions WHERE lead_id = $1)) 
+              AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, leadID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead attachment keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("error scanning attachment key row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachment key rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (*model.Attachment, error) {
+	var attachment model.Attachment
+	var leadID, interactionID, uploadedBy sql.NullString
+
+	err := row.Scan(
+		&attachment.ID, &leadID, &interactionID, &attachment.StorageKey, &attachment.Filename,
+		&attachment.ContentType, &attachment.Size, &uploadedBy, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if leadID.Valid {
+		attachment.LeadID = &leadID.String
+	}
+	if interactionID.Valid {
+		attachment.InteractionID = &interactionID.String
+	}
+	if uploadedBy.Valid {
+		attachment.UploadedByID = &uploadedBy.String
+	}
+
+	return &attachment, nil
+}
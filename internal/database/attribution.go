@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// qualifiedStatuses are the stages from QUALIFIED onwards.
+var qualifiedStatuses = []string{
+	string(model.LeadStatusQualified), string(model.LeadStatusMeeting), string(model.LeadStatusProposal),
+	string(model.LeadStatusNegotiation), string(model.LeadStatusWon),
+}
+
+// CreateLeadAttribution records where the lead came from, unless it already
+// has an attribution: the first touch is kept. It reports whether it was
+// recorded.
+func (db *DB) CreateLeadAttribution(ctx context.Context, attribution *model.LeadAttribution) (bool, error) {
+	query := `INSERT INTO lead_attribution (lead_id, agency_id, utm_source, utm_medium, utm_campaign, utm_term, 
+              utm_content, referrer, landing_page, campaign_id, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
+              ON CONFLICT (lead_id) DO NOTHING`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(
+		ctx, query, attribution.LeadID, agencyID, attribution.UtmSource, attribution.UtmMedium, attribution.UtmCampaign,
+		attribution.UtmTerm, attribution.UtmContent, attribution.Referrer, attribution.LandingPage, attribution.CampaignID,
+		attribution.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error creating lead attribution: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetLeadAttribution returns nil when the lead was not captured with any
+// attribution.
+func (db *DB) GetLeadAttribution(ctx context.Context, leadID string) (*model.LeadAttribution, error) {
+	query := `SELECT lead_id, utm_source, utm_medium, utm_campaign, utm_term, utm_content, referrer, 
+              landing_page, campaign_id, created_at 
+              FROM lead_attribution WHERE lead_id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var attribution model.LeadAttribution
+	var utmSource, utmMedium, utmCampaign, utmTerm, utmContent, referrer, landingPage, campaignID sql.NullString
+
+	err = db.conn.QueryRowContext(ctx, query, leadID, agencyID).Scan(
+		&attribution.LeadID, &utmSource, &utmMedium, &utmCampaign, &utmTerm, &utmContent, &referrer,
+		&landingPage, &campaignID, &attribution.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching lead attribution: %w", err)
+	}
+
+	attribution.UtmSource = nullString(utmSource)
+	attribution.UtmMedium = nullString(utmMedium)
+	attribution.UtmCampaign = nullString(utmCampaign)
+	attribution.UtmTerm = nullString(utmTerm)
+	attribution.UtmContent = nullString(utmContent)
+	attribution.Referrer = nullString(referrer)
+	attribution.LandingPage = nullString(landingPage)
+	attribution.CampaignID = nullString(campaignID)
+
+	return &attribution, nil
+}
+
+// GetAttributionSources groups the live leads created in [start, end) by
+// UTM source, medium and campaign, largest group first. Leads without
+// attribution form a group with no source.
+func (db *DB) GetAttributionSources(ctx context.Context, start, end time.Time) ([]*model.AttributionSource, error) {
+	query := `SELECT a.utm_source, a.utm_medium, a.utm_campaign, COUNT(*), 
+              COUNT(*) FILTER (WHERE l.status = ANY($1) OR EXISTS ( 
+                  SELECT 1 FROM lead_status_history h WHERE h.lead_id = l.id AND h.to_status = ANY($1))), 
+              COUNT(*) FILTER (WHERE l.status = $2) 
+              FROM leads l LEFT JOIN lead_attribution a ON a.lead_id = l.id 
+              WHERE l.created_at >= $3 AND l.created_at < $4 AND l.deleted_at IS NULL 
+              AND (l.agency_id = $5 OR $5 IS NULL) 
+              GROUP BY 1, 2, 3 
+              ORDER BY 4 DESC, 1 NULLS LAST, 2 NULLS LAST, 3 NULLS LAST`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, pq.Array(qualifiedStatuses), model.LeadStatusWon, start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying attribution sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []*model.AttributionSource{}
+	for rows.Next() {
+		var source model.AttributionSource
+		var utmSource, utmMedium, utmCampaign sql.NullString
+		if err := rows.Scan(&utmSource, &utmMedium, &utmCampaign, &source.Leads, &source.Qualified, &source.Converted); err != nil {
+			return nil, fmt.Errorf("error scanning attribution source row: %w", err)
+		}
+		source.UtmSource = nullString(utmSource)
+		source.UtmMedium = nullString(utmMedium)
+		source.UtmCampaign = nullString(utmCampaign)
+		if source.Leads > 0 {
+			source.ConversionRate = float64(source.Converted) / float64(source.Leads)
+		}
+		sources = append(sources, &source)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attribution source rows: %w", err)
+	}
+
+	return sources, nil
+}
+
+// GetAgencyIDByCaptureKey returns "" when no agency has the key.
+func (db *DB) GetAgencyIDByCaptureKey(ctx context.Context, key string) (string, error) {
+	var agencyID string
+	err := db.conn.QueryRowContext(ctx, "SELECT id FROM agencies WHERE capture_key = $1", key).Scan(&agencyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error fetching agency by capture key: %w", err)
+	}
+
+	return agencyID, nil
+}
+
+// GetCaptureKey returns the agency's lead capture key, or nil when it has
+// none yet.
+func (db *DB) GetCaptureKey(ctx context.Context) (*string, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var key sql.NullString
+	if err := db.conn.QueryRowContext(ctx, "SELECT capture_key FROM agencies WHERE id = $1", agencyID).Scan(&key); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching capture key: %w", err)
+	}
+
+	return nullString(key), nil
+}
+
+// SetCaptureKey replaces the agency's lead capture key, so forms using the
+// old one stop working.
+func (db *DB) SetCaptureKey(ctx context.Context, key string) error {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE agencies SET capture_key = $1, updated_at = $2 WHERE id = $3"
+	if _, err := db.conn.ExecContext(ctx, query, key, time.Now(), agencyID); err != nil {
+		return fmt.Errorf("error setting capture key: %w", err)
+	}
+
+	return nil
+}
+
+// nullString returns nil for NULL.
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// predicate is one condition of a WHERE clause. Its SQL marks arguments with
+// ?, which are numbered when the query is built, so predicates compose in any
+// order without counting placeholders. A nil predicate matches every row,
+// which lets the helpers below take optional filter fields as they are.
+type predicate struct {
+	sql  string
+	args []interface{}
+}
+
+// where is a predicate written by hand. sql must not use the ? operator.
+func where(sql string, args ...interface{}) *predicate {
+	return &predicate{sql: sql, args: args}
+}
+
+// inTenant limits rows to the agency of ctx's tenant argument; nil, for
+// system contexts, allows every agency.
+func inTenant(column string, agencyID interface{}) *predicate {
+	return where("("+column+" = ? OR ? IS NULL)", agencyID, agencyID)
+}
+
+// notDeletedIn hides soft-deleted rows unless the context asks for them.
+func notDeletedIn(ctx context.Context, column string) *predicate {
+	if includeDeleted(ctx) {
+		return nil
+	}
+	return where(column + " IS NULL")
+}
+
+func equals[T any](column string, value *T) *predicate {
+	if value == nil {
+		return nil
+	}
+	return where(column+" = ?", *value)
+}
+
+func atLeast[T any](column string, value *T) *predicate {
+	if value == nil {
+		return nil
+	}
+	return where(column+" >= ?", *value)
+}
+
+func atMost[T any](column string, value *T) *predicate {
+	if value == nil {
+		return nil
+	}
+	return where(column+" <= ?", *value)
+}
+
+// oneOf matches rows whose column is any of values, such as a set of enum
+// values.
+func oneOf[T ~string](column string, values []T) *predicate {
+	if len(values) == 0 {
+		return nil
+	}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = string(v)
+	}
+	return where(column+" = ANY(?)", pq.Array(strs))
+}
+
+// containsText matches rows whose column contains value, ignoring case.
+// value is matched literally: % and _ are not wildcards.
+func containsText(column string, value *string) *predicate {
+	if value == nil || *value == "" {
+		return nil
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(*value)
+	return where(column+" ILIKE '%' || ? || '%'", escaped)
+}
+
+// matchesText runs a full-text search, in web-search syntax, on a tsvector
+// column.
+func matchesText(column string, value *string) *predicate {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return where(column+" @@ websearch_to_tsquery('english', ?)", *value)
+}
+
+// hasAnyOf matches rows whose array column shares an element with values.
+func hasAnyOf(column string, values []string) *predicate {
+	if len(values) == 0 {
+		return nil
+	}
+	return where(column+" && ?", pq.Array(values))
+}
+
+// hasNoneOf matches rows whose array column shares no element with values,
+// including rows where it is NULL.
+func hasNoneOf(column string, values []string) *predicate {
+	if len(values) == 0 {
+		return nil
+	}
+	return where("NOT (COALESCE("+column+", '{}') && ?)", pq.Array(values))
+}
+
+// selectBuilder is a SELECT whose WHERE clause, ordering and pagination are
+// added piece by piece. Its methods return copies, so a base query can be
+// shared and extended, as by a count and a paginated listing.
+type selectBuilder struct {
+	from   string
+	where  []*predicate
+	order  string
+	limit  *int
+	offset *int
+}
+
+// selectFrom starts a query with the given SELECT ... FROM clause.
+func selectFrom(from string, predicates ...*predicate) *selectBuilder {
+	return (&selectBuilder{from: from}).and(predicates...)
+}
+
+// and adds predicates, skipping nil ones.
+func (b *selectBuilder) and(predicates ...*predicate) *selectBuilder {
+	next := *b
+	next.where = make([]*predicate, len(b.where), len(b.where)+len(predicates))
+	copy(next.where, b.where)
+	for _, p := range predicates {
+		if p != nil {
+			next.where = append(next.where, p)
+		}
+	}
+	return &next
+}
+
+func (b *selectBuilder) orderBy(order string) *selectBuilder {
+	next := *b
+	next.order = order
+	return &next
+}
+
+// page sets LIMIT and OFFSET; nil leaves either out.
+func (b *selectBuilder) page(limit, offset *int) *selectBuilder {
+	next := *b
+	next.limit, next.offset = limit, offset
+	return &next
+}
+
+// build returns the query with numbered placeholders and its arguments.
+func (b *selectBuilder) build() (string, []interface{}) {
+	var sql strings.Builder
+	var args []interface{}
+
+	sql.WriteString(b.from)
+	for i, p := range b.where {
+		if i == 0 {
+			sql.WriteString(" WHERE ")
+		} else {
+			sql.WriteString(" AND ")
+		}
+		args = appendNumbered(&sql, p.sql, p.args, args)
+	}
+
+	if b.order != "" {
+		sql.WriteString(" ORDER BY " + b.order)
+	}
+	if b.limit != nil {
+		args = append(args, *b.limit)
+		fmt.Fprintf(&sql, " LIMIT $%d", len(args))
+	}
+	if b.offset != nil {
+		args = append(args, *b.offset)
+		fmt.Fprintf(&sql, " OFFSET $%d", len(args))
+	}
+
+	return sql.String(), args
+}
+
+// appendNumbered writes clause to sql with each ? replaced by the next
+// placeholder number, and returns args with the clause's arguments added.
+func appendNumbered(sql *strings.Builder, clause string, clauseArgs, args []interface{}) []interface{} {
+	next := 0
+	for _, r := range clause {
+		if r != '?' {
+			sql.WriteRune(r)
+			continue
+		}
+		args = append(args, clauseArgs[next])
+		next++
+		fmt.Fprintf(sql, "$%d", len(args))
+	}
+	return args
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database/golden"
+	"salesagency/internal/tenant"
+)
+
+func TestLeadFilterQuery(t *testing.T) {
+	agency := tenant.WithAgency(context.Background(), "agency-1")
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	minScore, source, company, text, replied := 0.6, "import", "50%_off", "cto fintech", false
+	limit, offset := 20, 40
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		filter *model.LeadFilterInput
+	}{
+		{name: "lead_filter_none", ctx: agency},
+		{name: "lead_filter_system", ctx: tenant.WithSystem(context.Background())},
+		{name: "lead_filter_client_portal", ctx: tenant.WithClients(agency, []string{"client-1", "client-2"})},
+		{
+			name: "lead_filter_all",
+			ctx:  agency,
+			filter: &model.LeadFilterInput{
+				Status:            []model.LeadStatus{model.LeadStatusNew, model.LeadStatusEngaged},
+				MinIntentScore:    &minScore,
+				Tags:              []string{"vip"},
+				ExcludeTags:       []string{"churned"},
+				Source:            &source,
+				CompanyContains:   &company,
+				LastContactAfter:  &since,
+				LastContactBefore: &until,
+				CreatedAfter:      &since,
+				CreatedBefore:     &until,
+				Text:              &text,
+				Replied:           &replied,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := leadFilterQuery(tt.ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, args := q.orderBy("created_at DESC").page(&limit, &offset).build()
+			golden.Query(t, tt.name, query, args)
+		})
+	}
+}
+
+func TestLeadFilterQueryRequiresTenant(t *testing.T) {
+	if _, err := leadFilterQuery(context.Background(), nil); !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Fatalf("got error %v, want %v", err, tenant.ErrMissingTenant)
+	}
+}
+
+func TestSelectBuilderCopies(t *testing.T) {
+	base := selectFrom("SELECT id FROM leads", where("agency_id = ?", "agency-1"))
+	want, _ := base.build()
+
+	limit := 10
+	extended, args := base.and(where("status = ?", "NEW")).orderBy("id").page(&limit, nil).build()
+	if got, _ := base.build(); got != want {
+		t.Errorf("extending the query changed the base to %q, want %q", got, want)
+	}
+	golden.Query(t, "select_builder_extended", extended, args)
+}
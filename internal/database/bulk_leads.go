@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// bulkChunkSize is how many leads each statement of a bulk operation
+// changes.
+const bulkChunkSize = 500
+
+// uuidPattern matches well-formed IDs. Bulk operations leave other IDs out
+// rather than failing the statement they would be part of.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// BulkUpdateLeads sets the patch's fields on the leads, keeping the fields
+// it leaves out. It returns the IDs of the leads it changed; the others were
+// not found.
+func (db *DB) BulkUpdateLeads(ctx context.Context, ids []string, patch *model.LeadPatchInput) ([]string, error) {
+	query := `UPDATE leads SET company = COALESCE($1, company), position = COALESCE($2, position), 
+              source = COALESCE($3, source), notes = COALESCE($4, notes), 
+              deal_value = COALESCE($5, deal_value), timezone = COALESCE($6, timezone), 
+              language = COALESCE($7, language), updated_at = $8, version = version + 1 
+              WHERE id = ANY($9::uuid[]) AND (agency_id = $10 OR $10 IS NULL) AND deleted_at IS NULL 
+              RETURNING id`
+
+	now := time.Now()
+	return db.bulkUpdate(ctx, ids, recordingChanges(ctx, now, func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error) {
+		return queryIDs(ctx, tx, query,
+			patch.Company, patch.Position, patch.Source, patch.Notes, patch.DealValue, patch.Timezone, patch.Language,
+			now, pq.Array(chunk), agencyID,
+		)
+	}))
+}
+
+// BulkTagLeads adds and removes tags on the leads, ignoring case. Added
+// tags take the agency's spelling, and those it doesn't have yet join its
+// taxonomy. A tag both added and removed ends up removed. It returns the IDs
+// of the leads it changed.
+func (db *DB) BulkTagLeads(ctx context.Context, ids, addTags, removeTags []string) ([]string, error) {
+	addQuery := `INSERT INTO tags (agency_id, name) 
+              SELECT DISTINCT l.agency_id, a.tag FROM leads l, unnest($1::text[]) AS a(tag) 
+              WHERE l.id = ANY($2::uuid[]) AND (l.agency_id = $3 OR $3 IS NULL) 
+              ON CONFLICT (agency_id, lower(name)) DO NOTHING`
+
+	// Tags keep the order they were first added in.
+	query := `UPDATE leads SET tags = ARRAY( 
+                SELECT u.tag FROM unnest(COALESCE(tags, '{}') || ARRAY( 
+                    SELECT t.name FROM unnest($1::text[]) WITH ORDINALITY AS a(tag, n) 
+                    JOIN tags t ON t.agency_id = leads.agency_id AND lower(t.name) = lower(a.tag) 
+                    ORDER BY a.n)) WITH ORDINALITY AS u(tag, n) 
+                WHERE lower(u.tag) <> ALL($2::text[]) GROUP BY u.tag ORDER BY min(u.n)), 
+              updated_at = $3, version = version + 1 
+              WHERE id = ANY($4::uuid[]) AND (agency_id = $5 OR $5 IS NULL) AND deleted_at IS NULL 
+              RETURNING id`
+
+	addTags, _ = normalizeTags(addTags)
+	_, removeKeys := normalizeTags(removeTags)
+
+	now := time.Now()
+	return db.bulkUpdate(ctx, ids, recordingChanges(ctx, now, func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error) {
+		if len(addTags) > 0 {
+			if _, err := tx.ExecContext(ctx, addQuery, pq.Array(addTags), pq.Array(chunk), agencyID); err != nil {
+				return nil, err
+			}
+		}
+		return queryIDs(ctx, tx, query, pq.Array(addTags), pq.Array(removeKeys), now, pq.Array(chunk), agencyID)
+	}))
+}
+
+// GetLeadStatuses returns the status of each of the leads that exists.
+func (db *DB) GetLeadStatuses(ctx context.Context, ids []string) (map[string]model.LeadStatus, error) {
+	query := `SELECT id, status FROM leads 
+              WHERE id = ANY($1::uuid[]) AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]model.LeadStatus, len(ids))
+	for _, chunk := range chunkIDs(validIDs(ids)) {
+		rows, err := db.conn.QueryContext(ctx, query, pq.Array(chunk), agencyID)
+		if err != nil {
+			return nil, fmt.Errorf("error querying lead statuses: %w", err)
+		}
+
+		for rows.Next() {
+			var id string
+			var status model.LeadStatus
+			if err := rows.Scan(&id, &status); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("error scanning lead status row: %w", err)
+			}
+			statuses[id] = status
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating lead status rows: %w", err)
+		}
+	}
+
+	return statuses, nil
+}
+
+// BulkTransitionLeadStatus moves each lead in from, which maps lead IDs to
+// the status they are expected to be in, to the status to, and records the
+// changes in lead_status_history. It returns the IDs of the leads it moved;
+// the others were no longer in the expected status.
+func (db *DB) BulkTransitionLeadStatus(ctx context.Context, from map[string]model.LeadStatus, to model.LeadStatus, reason, changedBy *string) ([]string, error) {
+	query := `UPDATE leads l SET status = $1, updated_at = $2, version = l.version + 1 
+              FROM unnest($3::uuid[], $4::text[]) AS c(id, status) 
+              WHERE l.id = c.id AND l.status = c.status AND (l.agency_id = $5 OR $5 IS NULL) AND l.deleted_at IS NULL 
+              RETURNING l.id`
+
+	history := `INSERT INTO lead_status_history (lead_id, from_status, to_status, reason, changed_by, created_at) 
+              SELECT c.id, c.status, $3, $4, $5, $6 
+              FROM unnest($1::uuid[], $2::text[]) AS c(id, status)`
+
+	ids := make([]string, 0, len(from))
+	for id := range from {
+		ids = append(ids, id)
+	}
+
+	now := time.Now()
+	return db.bulkUpdate(ctx, ids, func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error) {
+		statuses := make([]string, len(chunk))
+		for i, id := range chunk {
+			statuses[i] = string(from[id])
+		}
+
+		moved, err := queryIDs(ctx, tx, query, to, now, pq.Array(chunk), pq.Array(statuses), agencyID)
+		if err != nil || len(moved) == 0 {
+			return moved, err
+		}
+
+		movedFrom := make([]string, len(moved))
+		for i, id := range moved {
+			movedFrom[i] = string(from[id])
+		}
+		if _, err := tx.ExecContext(ctx, history, pq.Array(moved), pq.Array(movedFrom), to, reason, changedBy, now); err != nil {
+			return nil, fmt.Errorf("error recording lead status history: %w", err)
+		}
+
+		// The update above is the projection of these events.
+		toStatus, err := jsonValue(to)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]leadEvent, len(moved))
+		for i, id := range moved {
+			fromStatus, err := jsonValue(from[id])
+			if err != nil {
+				return nil, err
+			}
+			events[i] = leadEvent{leadID: id, typ: model.LeadEventTypeStatusChanged, field: statusField, from: fromStatus, to: toStatus}
+		}
+		if err := appendLeadEvents(ctx, tx, events, now); err != nil {
+			return nil, err
+		}
+
+		if to == model.LeadStatusWon {
+			if err := convertCampaignLeads(ctx, tx, moved, now); err != nil {
+				return nil, err
+			}
+		}
+
+		return moved, nil
+	})
+}
+
+// bulkUpdate runs update on the well-formed IDs among ids, a chunk at a time,
+// in one transaction, and returns the IDs of the leads it changed.
+func (db *DB) bulkUpdate(ctx context.Context, ids []string, update func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error)) ([]string, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changed []string
+	for _, chunk := range chunkIDs(validIDs(ids)) {
+		chunkChanged, err := update(tx, chunk, agencyID)
+		if err != nil {
+			return nil, fmt.Errorf("error updating leads: %w", err)
+		}
+		changed = append(changed, chunkChanged...)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidateLeads(ctx, changed)
+	return changed, nil
+}
+
+func (db *DB) invalidateLeads(ctx context.Context, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = leadCacheKey(id)
+	}
+	db.invalidate(ctx, keys...)
+}
+
+// recordingChanges wraps a bulk update so that the changes it makes to each
+// lead are recorded as events.
+func recordingChanges(ctx context.Context, at time.Time, update func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error)) func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error) {
+	return func(tx *sql.Tx, chunk []string, agencyID interface{}) ([]string, error) {
+		before, err := lockLeads(ctx, tx, "id = ANY($1::uuid[]) AND (agency_id = $2 OR $2 IS NULL)", pq.Array(chunk), agencyID)
+		if err != nil {
+			return nil, err
+		}
+
+		changed, err := update(tx, chunk, agencyID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := recordLeadChanges(ctx, tx, before, changed, at); err != nil {
+			return nil, err
+		}
+		return changed, nil
+	}
+}
+
+// validIDs returns the well-formed IDs among ids, without duplicates.
+func validIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if uuidPattern.MatchString(id) && !seen[id] {
+			seen[id] = true
+			valid = append(valid, id)
+		}
+	}
+	return valid
+}
+
+func chunkIDs(ids []string) [][]string {
+	var chunks [][]string
+	for len(ids) > bulkChunkSize {
+		chunks = append(chunks, ids[:bulkChunkSize])
+		ids = ids[bulkChunkSize:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+
+	"salesagency/internal/cache"
+	"salesagency/internal/logging"
+)
+
+// cachedRecord stores a row together with its agency so cached reads can
+// apply the same tenant check as the query they replace.
+type cachedRecord[T any] struct {
+	AgencyID string
+	Value    *T
+}
+
+// SetCache enables read-through caching of leads, clients and AI agents by
+// ID. Writes to those tables invalidate the affected keys.
+func (db *DB) SetCache(c *cache.Cache) {
+	db.cache = c
+}
+
+func leadCacheKey(id string) string    { return "lead:" + id }
+func clientCacheKey(id string) string  { return "client:" + id }
+func aiAgentCacheKey(id string) string { return "ai_agent:" + id }
+
+// cacheGet returns the cached row for key. A row belonging to another agency
+// is reported as found but nil, matching what the tenant-scoped query
+// would return. Cache failures are logged and treated as misses.
+func cacheGet[T any](ctx context.Context, db *DB, key string, agencyID interface{}) (*T, bool) {
+	var record cachedRecord[T]
+	found, err := db.cache.Get(ctx, key, &record)
+	if err != nil {
+		logging.FromContext(ctx).Warn("cache read failed", "key", key, "error", err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	if agencyID != nil && agencyID != record.AgencyID {
+		return nil, true
+	}
+	return record.Value, true
+}
+
+func cacheSet[T any](ctx context.Context, db *DB, key, agencyID string, value *T) {
+	err := db.cache.Set(ctx, key, cachedRecord[T]{AgencyID: agencyID, Value: value})
+	if err != nil {
+		logging.FromContext(ctx).Warn("cache write failed", "key", key, "error", err)
+	}
+}
+
+func (db *DB) invalidate(ctx context.Context, keys ...string) {
+	if err := db.cache.Delete(ctx, keys...); err != nil {
+		logging.FromContext(ctx).Warn("cache invalidation failed", "keys", keys, "error", err)
+	}
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// CreateMeeting records a booked meeting's interaction with when the meeting
+// takes place, and bumps the lead's last_contact, in one transaction.
+// meeting's InteractionID and LeadID are set from the interaction.
+func (db *DB) CreateMeeting(ctx context.Context, interaction *model.Interaction, meeting *Meeting) (*model.Interaction, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+
+	if err = recordLeadContact(ctx, tx, interaction.Lead.ID, interaction.Timestamp); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO meetings (interaction_id, starts_at, ends_at, calendar_id, booked_by, url) 
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, query, interaction.ID, meeting.StartsAt, meeting.EndsAt, meeting.CalendarID, meeting.BookedBy, meeting.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error creating meeting: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	meeting.InteractionID = interaction.ID
+	meeting.LeadID = interaction.Lead.ID
+	return interaction, nil
+}
+
+// calendarEventsQuery lists the events in [$1, $2). Sequence sends and agent
+// runs are grouped into one event per sequence or agent and day in time zone
+// $3.
+const calendarEventsQuery = `SELECT 'CAMPAIGN_START', c.id::text || ':start', c.name, c.start_date, NULL::timestamptz, 1, 
+                  c.id, NULL::uuid, NULL::uuid, NULL::uuid 
+              FROM campaigns c 
+              WHERE c.start_date >= $1 AND c.start_date < $2 AND c.status <> 'CANCELLED' AND (c.agency_id = $4 OR $4 IS NULL) 
+              UNION ALL 
+              SELECT 'CAMPAIGN_END', c.id::text || ':end', c.name, c.end_date, NULL, 1, 
+                  c.id, NULL, NULL, NULL 
+              FROM campaigns c 
+              WHERE c.end_date >= $1 AND c.end_date < $2 AND c.status <> 'CANCELLED' AND (c.agency_id = $4 OR $4 IS NULL) 
+              UNION ALL 
+              SELECT 'SEQUENCE_SEND', s.id::text || ':' || (e.next_run_at AT TIME ZONE $3)::date, s.name, MIN(e.next_run_at), MAX(e.next_run_at), COUNT(*), 
+                  s.campaign_id, NULL, NULL, s.id 
+              FROM sequence_enrollments e JOIN sequences s ON s.id = e.sequence_id 
+              WHERE e.status = 'ACTIVE' AND e.next_run_at >= $1 AND e.next_run_at < $2 AND (s.agency_id = $4 OR $4 IS NULL) 
+              GROUP BY s.id, s.name, s.campaign_id, (e.next_run_at AT TIME ZONE $3)::date 
+              UNION ALL 
+              SELECT 'AGENT_RUN', a.id::text || ':' || (r.scheduled_for AT TIME ZONE $3)::date, a.name, MIN(r.scheduled_for), MAX(r.scheduled_for), COUNT(*), 
+                  NULL, NULL, a.id, NULL 
+              FROM agent_runs r JOIN ai_agents a ON a.id = r.agent_id 
+              WHERE r.scheduled_for >= $1 AND r.scheduled_for < $2 AND r.status <> 'CANCELLED' AND (a.agency_id = $4 OR $4 IS NULL) 
+              GROUP BY a.id, a.name, (r.scheduled_for AT TIME ZONE $3)::date 
+              UNION ALL 
+              SELECT 'MEETING', i.id::text, l.name, m.starts_at, m.ends_at, 1, 
+                  NULL, l.id, i.ai_agent_id, NULL 
+              FROM meetings m JOIN interactions i ON i.id = m.interaction_id JOIN leads l ON l.id = i.lead_id 
+              WHERE m.starts_at >= $1 AND m.starts_at < $2 AND m.cancelled_at IS NULL AND i.status <> 'FAILED' AND l.deleted_at IS NULL 
+              AND (l.agency_id = $4 OR $4 IS NULL) 
+              ORDER BY 4, 1, 2`
+
+// GetCalendarEvents returns the campaign starts and ends, scheduled sequence
+// sends, agent runs and meetings in [from, to), earliest first. Runs that
+// schedules will queue later are not included; see GetAgentScheduleTimes.
+func (db *DB) GetCalendarEvents(ctx context.Context, from, to time.Time, timezone string) ([]*model.CalendarEvent, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryReplica(ctx, calendarEventsQuery, from, to, timezone, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying calendar events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*model.CalendarEvent{}
+	for rows.Next() {
+		var event model.CalendarEvent
+		var end sql.NullTime
+		var campaignID, leadID, agentID, sequenceID sql.NullString
+
+		err := rows.Scan(
+			&event.Kind, &event.ID, &event.Title, &event.Start, &end, &event.Count,
+			&campaignID, &leadID, &agentID, &sequenceID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning calendar event row: %w", err)
+		}
+
+		if end.Valid {
+			event.End = &end.Time
+		}
+		event.CampaignID = nullString(campaignID)
+		event.LeadID = nullString(leadID)
+		event.AIAgentID = nullString(agentID)
+		event.SequenceID = nullString(sequenceID)
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating calendar event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// AgentScheduleTime is an enabled agent schedule, for projecting the runs it
+// will queue.
+type AgentScheduleTime struct {
+	AgentID   string
+	AgentName string
+	Cron      string
+	NextRunAt time.Time
+}
+
+// GetAgentScheduleTimes returns the enabled schedules of the agency's agents.
+func (db *DB) GetAgentScheduleTimes(ctx context.Context) ([]*AgentScheduleTime, error) {
+	query := `SELECT a.id, a.name, s.cron, s.next_run_at 
+              FROM agent_schedules s JOIN ai_agents a ON a.id = s.agent_id 
+              WHERE s.enabled AND (a.agency_id = $1 OR $1 IS NULL) 
+              ORDER BY s.next_run_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryReplica(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*AgentScheduleTime
+	for rows.Next() {
+		var schedule AgentScheduleTime
+		if err := rows.Scan(&schedule.AgentID, &schedule.AgentName, &schedule.Cron, &schedule.NextRunAt); err != nil {
+			return nil, fmt.Errorf("error scanning agent schedule row: %w", err)
+		}
+		schedules = append(schedules, &schedule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent schedule rows: %w", err)
+	}
+
+	return schedules, nil
+}
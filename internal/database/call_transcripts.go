@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const callTranscriptColumns = `t.interaction_id, t.attachment_id, t.status, t.text, t.key_points, t.attempts, t.last_error, t.created_at, t.completed_at`
+
+// TranscriptJob is a claimed transcript and the recording to transcribe:
+// the attachment stored under StorageKey or, when it is nil, RecordingURL.
+type TranscriptJob struct {
+	InteractionID string
+	LeadID        string
+	AgencyID      string
+	Attempts      int
+	StorageKey    *string
+	Filename      string
+	ContentType   string
+	RecordingURL  *string
+}
+
+// QueueTranscript (re)starts transcribing the CALL interaction's recording:
+// the attachment, or the call's recording URL when attachmentID is nil. A
+// previous transcript is discarded.
+func (db *DB) QueueTranscript(ctx context.Context, interactionID string, attachmentID *string) (*model.CallTranscript, error) {
+	query := `INSERT INTO call_transcripts (interaction_id, attachment_id) 
+              VALUES ($1, $2) 
+              ON CONFLICT (interaction_id) DO UPDATE 
+              SET attachment_id = EXCLUDED.attachment_id, status = 'PENDING', text = NULL, key_points = NULL, 
+              attempts = 0, last_error = NULL, next_attempt_at = now(), created_at = now(), completed_at = NULL`
+
+	if _, err := db.conn.ExecContext(ctx, query, interactionID, attachmentID); err != nil {
+		return nil, fmt.Errorf("error queueing transcript: %w", err)
+	}
+
+	return db.GetCallTranscript(ctx, interactionID)
+}
+
+// queueRecordingTranscript starts transcribing the recording URL of the
+// calls matched by where, unless their transcript was already started.
+func queueRecordingTranscript(ctx context.Context, exec execer, where string, args ...interface{}) error {
+	query := `INSERT INTO call_transcripts (interaction_id) 
+              SELECT interaction_id FROM calls WHERE recording_url IS NOT NULL AND ` + where + ` 
+              ON CONFLICT (interaction_id) DO NOTHING`
+
+	if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error queueing transcript: %w", err)
+	}
+
+	return nil
+}
+
+// GetCallTranscript returns nil when the interaction has no transcript.
+func (db *DB) GetCallTranscript(ctx context.Context, interactionID string) (*model.CallTranscript, error) {
+	query := `SELECT ` + callTranscriptColumns + ` FROM call_transcripts t 
+              JOIN interactions i ON i.id = t.interaction_id 
+              JOIN leads l ON l.id = i.lead_id 
+              WHERE t.interaction_id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript, err := scanCallTranscript(db.conn.QueryRowContext(ctx, query, interactionID, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching transcript: %w", err)
+	}
+
+	return transcript, nil
+}
+
+// ClaimTranscripts returns up to limit pending transcripts that are due and
+// counts the attempt. Each is hidden from other claims for lease, as with
+// ClaimOutboxMessages.
+func (db *DB) ClaimTranscripts(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*TranscriptJob, error) {
+	query := `WITH claimed AS ( 
+                  UPDATE call_transcripts SET attempts = attempts + 1, next_attempt_at = $1 
+                  WHERE interaction_id IN ( 
+                      SELECT interaction_id FROM call_transcripts 
+                      WHERE status = 'PENDING' AND next_attempt_at <= $2 
+                      ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED 
+                  ) 
+                  RETURNING interaction_id, attachment_id, attempts 
+              ) 
+              SELECT c.interaction_id, i.lead_id, l.agency_id, c.attempts, a.storage_key, a.filename, a.content_type, ca.recording_url 
+              FROM claimed c 
+              JOIN interactions i ON i.id = c.interaction_id 
+              JOIN leads l ON l.id = i.lead_id 
+              LEFT JOIN attachments a ON a.id = c.attachment_id 
+              LEFT JOIN calls ca ON ca.interaction_id = c.interaction_id`
+
+	rows, err := db.conn.QueryContext(ctx, query, now.Add(lease), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*TranscriptJob
+	for rows.Next() {
+		var job TranscriptJob
+		var storageKey, filename, contentType, recordingURL sql.NullString
+
+		err := rows.Scan(
+			&job.InteractionID, &job.LeadID, &job.AgencyID, &job.Attempts, &storageKey, &filename, &contentType, &recordingURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning transcript row: %w", err)
+		}
+
+		job.StorageKey = nullString(storageKey)
+		job.Filename = filename.String
+		job.ContentType = contentType.String
+		job.RecordingURL = nullString(recordingURL)
+		jobs = append(jobs, &job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transcript rows: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// CompleteTranscript stores the transcript and appends notes, when not
+// empty, to the lead's notes.
+func (db *DB) CompleteTranscript(ctx context.Context, job *TranscriptJob, text string, keyPoints []string, notes string, at time.Time) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE call_transcripts SET status = 'COMPLETED', text = $1, key_points = $2, last_error = NULL, completed_at = $3 
+              WHERE interaction_id = $4`
+
+	if _, err := tx.ExecContext(ctx, query, text, pq.Array(keyPoints), at, job.InteractionID); err != nil {
+		return fmt.Errorf("error completing transcript: %w", err)
+	}
+
+	if notes != "" {
+		query = `UPDATE leads SET notes = CASE WHEN coalesce(notes, '') = '' THEN $1 ELSE notes || E'\n\n' || $1 END, 
+              updated_at = $2, version = version + 1 
+              WHERE id = $3 AND deleted_at IS NULL`
+
+		if _, err := tx.ExecContext(ctx, query, notes, at, job.LeadID); err != nil {
+			return fmt.Errorf("error updating lead notes: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(job.LeadID))
+
+	return nil
+}
+
+// RetryTranscript records a failed attempt and schedules the next one.
+func (db *DB) RetryTranscript(ctx context.Context, interactionID, lastError string, at time.Time) error {
+	query := `UPDATE call_transcripts SET last_error = $1, next_attempt_at = $2 WHERE interaction_id = $3`
+
+	if _, err := db.conn.ExecContext(ctx, query, lastError, at, interactionID); err != nil {
+		return fmt.Errorf("error rescheduling transcript: %w", err)
+	}
+	return nil
+}
+
+// FailTranscript records a failed attempt and stops retrying.
+func (db *DB) FailTranscript(ctx context.Context, interactionID, lastError string, at time.Time) error {
+	query := `UPDATE call_transcripts SET status = 'FAILED', last_error = $1, completed_at = $2 WHERE interaction_id = $3`
+
+	if _, err := db.conn.ExecContext(ctx, query, lastError, at, interactionID); err != nil {
+		return fmt.Errorf("error failing transcript: %w", err)
+	}
+	return nil
+}
+
+func scanCallTranscript(row interface{ Scan(...interface{}) error }) (*model.CallTranscript, error) {
+	var transcript model.CallTranscript
+	var attachmentID, text, lastError sql.NullString
+	var keyPoints []string
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&transcript.InteractionID, &attachmentID, &transcript.Status, &text, pq.Array(&keyPoints),
+		&transcript.Attempts, &lastError, &transcript.CreatedAt, &completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript.AttachmentID = nullString(attachmentID)
+	transcript.Text = nullString(text)
+	transcript.LastError = nullString(lastError)
+	transcript.KeyPoints = keyPoints
+	if transcript.KeyPoints == nil {
+		transcript.KeyPoints = []string{}
+	}
+	if completedAt.Valid {
+		transcript.CompletedAt = &completedAt.Time
+	}
+
+	return &transcript, nil
+}
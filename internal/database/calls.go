@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// ErrDuplicateCall is returned when a provider call has already been logged.
+var ErrDuplicateCall = errors.New("call has already been logged")
+
+// CallStats counts an agent's outbound calls.
+type CallStats struct {
+	Made      int
+	Connected int
+}
+
+// CreateCall records interaction and its call details together, and starts
+// transcribing the call's recording if it has one.
+func (db *DB) CreateCall(ctx context.Context, interaction *model.Interaction, call *model.Call) (*model.Call, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+
+	if err = recordLeadContact(ctx, tx, interaction.Lead.ID, interaction.Timestamp); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO calls (interaction_id, outcome, duration_seconds, recording_url, provider, provider_call_id, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	call.InteractionID = interaction.ID
+	_, err = tx.ExecContext(
+		ctx, query, call.InteractionID, call.Outcome, call.DurationSeconds, call.RecordingURL,
+		call.Provider, call.ProviderCallID, call.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateCall
+		}
+		return nil, fmt.Errorf("error creating call: %w", err)
+	}
+
+	if call.RecordingURL != nil {
+		if err := queueRecordingTranscript(ctx, tx, "interaction_id = $1", call.InteractionID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	return call, nil
+}
+
+// UpdateProviderCall fills in details a provider reports after the call was
+// logged, such as a recording that becomes available later, which is then
+// transcribed. Nil arguments leave the stored value alone. It reports
+// whether the call exists.
+func (db *DB) UpdateProviderCall(ctx context.Context, provider, providerCallID string, outcome *model.CallOutcome, durationSeconds *int, recordingURL *string) (bool, error) {
+	query := `UPDATE calls SET outcome = COALESCE($1, outcome), duration_seconds = COALESCE($2, duration_seconds), 
+              recording_url = COALESCE($3, recording_url), updated_at = $4 
+              WHERE provider = $5 AND provider_call_id = $6`
+
+	result, err := db.conn.ExecContext(ctx, query, outcome, durationSeconds, recordingURL, time.Now(), provider, providerCallID)
+	if err != nil {
+		return false, fmt.Errorf("error updating call: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 && recordingURL != nil {
+		err := queueRecordingTranscript(ctx, db.conn, "provider = $1 AND provider_call_id = $2", provider, providerCallID)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetCallByInteractionID returns nil when the interaction is not a call.
+func (db *DB) GetCallByInteractionID(ctx context.Context, interactionID string) (*model.Call, error) {
+	query := `SELECT interaction_id, outcome, duration_seconds, recording_url, provider, provider_call_id, created_at 
+              FROM calls WHERE interaction_id = $1`
+
+	var call model.Call
+	var duration sql.NullInt64
+	var recordingURL, providerCallID sql.NullString
+
+	err := db.conn.QueryRowContext(ctx, query, interactionID).Scan(
+		&call.InteractionID, &call.Outcome, &duration, &recordingURL, &call.Provider, &providerCallID, &call.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching call: %w", err)
+	}
+
+	if duration.Valid {
+		seconds := int(duration.Int64)
+		call.DurationSeconds = &seconds
+	}
+	if recordingURL.Valid {
+		call.RecordingURL = &recordingURL.String
+	}
+	if providerCallID.Valid {
+		call.ProviderCallID = &providerCallID.String
+	}
+
+	return &call, nil
+}
+
+// GetAgentCallStats counts the outbound calls attributed to the agent and
+// how many of them connected.
+func (db *DB) GetAgentCallStats(ctx context.Context, aiAgentID string) (*CallStats, error) {
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE c.outcome = $1) 
+              FROM calls c JOIN interactions i ON i.id = c.interaction_id 
+              WHERE i.ai_agent_id = $2 AND i.direction = $3`
+
+	var stats CallStats
+	err := db.conn.QueryRowContext(ctx, query, model.CallOutcomeConnected, aiAgentID, model.InteractionDirectionOutbound).Scan(&stats.Made, &stats.Connected)
+	if err != nil {
+		return nil, fmt.Errorf("error counting agent calls: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// GetLeadLatestAIAgentID returns the agent most recently assigned to the
+// lead, or "" when it has none.
+func (db *DB) GetLeadLatestAIAgentID(ctx context.Context, leadID string) (string, error) {
+	query := `SELECT ai_agent_id FROM lead_ai_agent 
+              WHERE lead_id = $1 ORDER BY assigned_at DESC LIMIT 1`
+
+	var id string
+	if err := db.conn.QueryRowContext(ctx, query, leadID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error fetching lead agent: %w", err)
+	}
+
+	return id, nil
+}
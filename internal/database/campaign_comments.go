@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+func (db *DB) CreateCampaignComment(ctx context.Context, comment *model.CampaignComment) (*model.CampaignComment, error) {
+	query := `INSERT INTO campaign_comments (agency_id, campaign_id, author_id, body, created_at) 
+              VALUES ($1, $2, $3, $4, $5) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(ctx, query, agencyID, comment.CampaignID, comment.AuthorID, comment.Body, comment.CreatedAt).Scan(&comment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating campaign comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// GetCampaignComments returns the campaign's comments, oldest first.
+func (db *DB) GetCampaignComments(ctx context.Context, campaignID string) ([]*model.CampaignComment, error) {
+	query := `SELECT id, campaign_id, author_id, body, created_at FROM campaign_comments 
+              WHERE campaign_id = $1 AND (agency_id = $2 OR $2 IS NULL) 
+              ORDER BY created_at, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []*model.CampaignComment{}
+	for rows.Next() {
+		var comment model.CampaignComment
+		var authorID sql.NullString
+
+		if err := rows.Scan(&comment.ID, &comment.CampaignID, &authorID, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning campaign comment row: %w", err)
+		}
+
+		comment.AuthorID = nullString(authorID)
+		comments = append(comments, &comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign comment rows: %w", err)
+	}
+
+	return comments, nil
+}
+
+// ApproveCampaign records the user's approval of the campaign, replacing an
+// earlier one. It reports false when the campaign does not exist.
+func (db *DB) ApproveCampaign(ctx context.Context, campaignID string, userID *string, at time.Time) (bool, error) {
+	query := `INSERT INTO campaign_approvals (campaign_id, approved_by, approved_at) 
+              SELECT id, $2, $3 FROM campaigns WHERE id = $1 AND (agency_id = $4 OR $4 IS NULL) 
+              ON CONFLICT (campaign_id) DO UPDATE SET approved_by = EXCLUDED.approved_by, approved_at = EXCLUDED.approved_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, campaignID, userID, at, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error approving campaign: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetCampaignApproval returns nil when the campaign has not been approved.
+func (db *DB) GetCampaignApproval(ctx context.Context, campaignID string) (*model.CampaignApproval, error) {
+	query := `SELECT a.campaign_id, a.approved_by, a.approved_at FROM campaign_approvals a 
+              JOIN campaigns c ON c.id = a.campaign_id 
+              WHERE a.campaign_id = $1 AND (c.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var approval model.CampaignApproval
+	var approvedBy sql.NullString
+
+	err = db.conn.QueryRowContext(ctx, query, campaignID, agencyID).Scan(&approval.CampaignID, &approvedBy, &approval.ApprovedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching campaign approval: %w", err)
+	}
+
+	approval.ApprovedByID = nullString(approvedBy)
+	return &approval, nil
+}
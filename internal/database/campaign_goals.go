@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const campaignGoalColumns = `g.campaign_id, g.metric, g.target, g.days, g.starts_at, g.at_risk_since, g.created_at, g.updated_at`
+
+// GoalActuals is what a campaign achieved over a period, counted as its
+// metrics are: from the interactions sent from its templates or by its AI
+// agents.
+type GoalActuals struct {
+	// ContactedLeads counts the leads that were sent a message.
+	ContactedLeads int
+	Replies        int
+	MeetingsBooked int
+	// Conversions counts the contacted leads that were won in the period.
+	Conversions int
+}
+
+// SetCampaignGoal creates or replaces the campaign's goal for the goal's
+// metric, clearing its at-risk mark. It returns nil when the campaign does
+// not exist.
+func (db *DB) SetCampaignGoal(ctx context.Context, goal *model.CampaignGoal) (*model.CampaignGoal, error) {
+	query := `INSERT INTO campaign_goals (campaign_id, metric, target, days, starts_at, created_at) 
+              SELECT c.id, $2, $3, $4, $5, $6 FROM campaigns c 
+              WHERE c.id = $1 AND (c.agency_id = $7 OR $7 IS NULL) 
+              ON CONFLICT (campaign_id, metric) DO UPDATE 
+              SET target = EXCLUDED.target, days = EXCLUDED.days, starts_at = EXCLUDED.starts_at, 
+                  at_risk_since = NULL, updated_at = EXCLUDED.created_at 
+              RETURNING created_at, updated_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedAt sql.NullTime
+	err = db.conn.QueryRowContext(
+		ctx, query, goal.CampaignID, goal.Metric, goal.Target, goal.Days, goal.StartsAt, time.Now(), agencyID,
+	).Scan(&goal.CreatedAt, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error setting campaign goal: %w", err)
+	}
+
+	goal.AtRiskSince = nil
+	if updatedAt.Valid {
+		goal.UpdatedAt = &updatedAt.Time
+	}
+	return goal, nil
+}
+
+// DeleteCampaignGoal reports whether the campaign had a goal for metric.
+func (db *DB) DeleteCampaignGoal(ctx context.Context, campaignID string, metric model.CampaignGoalMetric) (bool, error) {
+	query := `DELETE FROM campaign_goals g USING campaigns c 
+              WHERE g.campaign_id = c.id AND g.campaign_id = $1 AND g.metric = $2 AND (c.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, campaignID, metric, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting campaign goal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) GetCampaignGoals(ctx context.Context, campaignID string) ([]*model.CampaignGoal, error) {
+	query := `SELECT ` + campaignGoalColumns + ` 
+              FROM campaign_goals g JOIN campaigns c ON c.id = g.campaign_id 
+              WHERE g.campaign_id = $1 AND (c.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY g.metric`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryCampaignGoals(ctx, query, campaignID, agencyID)
+}
+
+// GetPacedCampaignGoals returns the goals of ACTIVE campaigns whose window
+// is open at now.
+func (db *DB) GetPacedCampaignGoals(ctx context.Context, now time.Time) ([]*model.CampaignGoal, error) {
+	query := `SELECT ` + campaignGoalColumns + ` 
+              FROM campaign_goals g JOIN campaigns c ON c.id = g.campaign_id 
+              WHERE c.status = $1 AND g.starts_at <= $2 AND g.starts_at + make_interval(days => g.days) > $2 
+              AND (c.agency_id = $3 OR $3 IS NULL) 
+              ORDER BY g.campaign_id, g.metric`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryCampaignGoals(ctx, query, model.CampaignStatusActive, now, agencyID)
+}
+
+func (db *DB) queryCampaignGoals(ctx context.Context, query string, args ...interface{}) ([]*model.CampaignGoal, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []*model.CampaignGoal{}
+	for rows.Next() {
+		var goal model.CampaignGoal
+		var atRiskSince, updatedAt sql.NullTime
+		err := rows.Scan(
+			&goal.CampaignID, &goal.Metric, &goal.Target, &goal.Days, &goal.StartsAt,
+			&atRiskSince, &goal.CreatedAt, &updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign goal row: %w", err)
+		}
+		if atRiskSince.Valid {
+			goal.AtRiskSince = &atRiskSince.Time
+		}
+		if updatedAt.Valid {
+			goal.UpdatedAt = &updatedAt.Time
+		}
+		goals = append(goals, &goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign goal rows: %w", err)
+	}
+
+	return goals, nil
+}
+
+// MarkCampaignGoalAtRisk sets or, with a nil at, clears the goal's at-risk
+// mark. It reports whether the mark changed, so that a goal is only
+// notified when it starts to slip.
+func (db *DB) MarkCampaignGoalAtRisk(ctx context.Context, campaignID string, metric model.CampaignGoalMetric, at *time.Time) (bool, error) {
+	query := `UPDATE campaign_goals SET at_risk_since = $3 
+              WHERE campaign_id = $1 AND metric = $2 AND (at_risk_since IS NULL) <> ($3::timestamptz IS NULL)`
+
+	result, err := db.conn.ExecContext(ctx, query, campaignID, metric, at)
+	if err != nil {
+		return false, fmt.Errorf("error marking campaign goal at risk: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetGoalActuals counts what the campaign achieved in [start, end].
+func (db *DB) GetGoalActuals(ctx context.Context, campaignID string, start, end time.Time) (*GoalActuals, error) {
+	query := `WITH campaign_interactions AS ( 
+                  SELECT DISTINCT i.id, i.lead_id, i.type, i.status, i.direction 
+                  FROM campaigns c 
+                  JOIN interactions i ON i.timestamp >= $2 AND i.timestamp <= $3 
+                  WHERE c.id = $1 AND (c.agency_id = $4 OR $4 IS NULL) 
+                  AND (i.template_id IN (SELECT id FROM message_templates WHERE campaign_id = c.id) 
+                      OR i.ai_agent_id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = c.id)) 
+              ), 
+              contacted AS ( 
+                  SELECT DISTINCT lead_id FROM campaign_interactions 
+                  WHERE direction = 'OUTBOUND' AND type <> 'MEETING' 
+                  AND status NOT IN ` + unsentStatuses + ` 
+              ) 
+              SELECT (SELECT COUNT(*) FROM contacted), 
+                  (SELECT COUNT(*) FROM campaign_interactions WHERE status = 'RESPONDED'), 
+                  (SELECT COUNT(*) FROM campaign_interactions WHERE type = 'MEETING'), 
+                  (SELECT COUNT(DISTINCT h.lead_id) FROM lead_status_history h JOIN contacted ct ON ct.lead_id = h.lead_id 
+                      WHERE h.to_status = 'WON' AND h.created_at >= $2 AND h.created_at <= $3)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, campaignID, start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign goal actuals: %w", err)
+	}
+	defer rows.Close()
+
+	var actuals GoalActuals
+	if rows.Next() {
+		if err := rows.Scan(&actuals.ContactedLeads, &actuals.Replies, &actuals.MeetingsBooked, &actuals.Conversions); err != nil {
+			return nil, fmt.Errorf("error scanning campaign goal actuals: %w", err)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign goal actuals: %w", err)
+	}
+
+	return &actuals, nil
+}
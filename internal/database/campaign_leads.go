@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const campaignLeadColumns = `cl.campaign_id, cl.lead_id, cl.status, cl.enrolled_by, cl.enrolled_at, cl.updated_at`
+
+// EnrollCampaignLeads enrolls the leads in the campaign and returns their
+// enrollments in the order given. Leads that had exited the campaign are
+// enrolled again; those already in it are left as they are. It returns nil
+// when any of the leads does not exist.
+func (db *DB) EnrollCampaignLeads(ctx context.Context, campaignID string, leadIDs []string, enrolledBy *string) ([]*model.CampaignLead, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRowContext(
+		ctx, "SELECT COUNT(*) FROM leads WHERE id = ANY($1) AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL",
+		pq.Array(leadIDs), agencyID,
+	).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("error checking leads: %w", err)
+	}
+	if found != len(leadIDs) {
+		return nil, nil
+	}
+
+	query := `INSERT INTO campaign_leads (campaign_id, lead_id, status, enrolled_by, enrolled_at) 
+              SELECT $1::uuid, unnest($2::uuid[]), $3, $4::uuid, $5::timestamptz 
+              ON CONFLICT (campaign_id, lead_id) DO UPDATE SET 
+              status = EXCLUDED.status, enrolled_by = EXCLUDED.enrolled_by, 
+              enrolled_at = EXCLUDED.enrolled_at, updated_at = EXCLUDED.enrolled_at 
+              WHERE campaign_leads.status = 'EXITED'`
+
+	_, err = tx.ExecContext(ctx, query, campaignID, pq.Array(leadIDs), model.CampaignLeadStatusEnrolled, enrolledBy, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("error enrolling campaign leads: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+campaignLeadColumns+` FROM campaign_leads cl 
+              WHERE cl.campaign_id = $1 AND cl.lead_id = ANY($2::uuid[]) 
+              ORDER BY array_position($2::uuid[], cl.lead_id)`, campaignID, pq.Array(leadIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign leads: %w", err)
+	}
+	enrollments, err := scanCampaignLeads(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return enrollments, nil
+}
+
+// ExitCampaignLead takes the lead out of the campaign. It returns nil when
+// the lead is not enrolled.
+func (db *DB) ExitCampaignLead(ctx context.Context, campaignID, leadID string) (*model.CampaignLead, error) {
+	query := `UPDATE campaign_leads cl SET status = $3, updated_at = $4 
+              FROM campaigns c 
+              WHERE c.id = cl.campaign_id AND cl.campaign_id = $1 AND cl.lead_id = $2 
+              AND (c.agency_id = $5 OR $5 IS NULL) 
+              RETURNING ` + campaignLeadColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := scanCampaignLead(db.conn.QueryRowContext(
+		ctx, query, campaignID, leadID, model.CampaignLeadStatusExited, time.Now(), agencyID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error removing lead from campaign: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+// GetCampaignLeads lists the campaign's enrollments of live leads, most
+// recently enrolled first.
+func (db *DB) GetCampaignLeads(ctx context.Context, campaignID string, status *model.CampaignLeadStatus, limit, offset *int) ([]*model.CampaignLead, error) {
+	query := `SELECT ` + campaignLeadColumns + ` 
+              FROM campaign_leads cl JOIN leads l ON l.id = cl.lead_id 
+              WHERE cl.campaign_id = $1 AND (l.agency_id = $2 OR $2 IS NULL) AND l.deleted_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{campaignID, agencyID}
+	argCount := 3
+
+	if status != nil {
+		query += fmt.Sprintf(" AND cl.status = $%d", argCount)
+		args = append(args, *status)
+		argCount++
+	}
+
+	query += " ORDER BY cl.enrolled_at DESC, cl.lead_id"
+	if limit != nil {
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, *limit)
+		argCount++
+	}
+	if offset != nil {
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, *offset)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign leads: %w", err)
+	}
+
+	return scanCampaignLeads(rows)
+}
+
+// GetLeadCampaigns lists the lead's campaign enrollments, most recent first.
+func (db *DB) GetLeadCampaigns(ctx context.Context, leadID string) ([]*model.CampaignLead, error) {
+	query := `SELECT ` + campaignLeadColumns + ` 
+              FROM campaign_leads cl JOIN campaigns c ON c.id = cl.campaign_id 
+              WHERE cl.lead_id = $1 AND (c.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY cl.enrolled_at DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, leadID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead campaigns: %w", err)
+	}
+
+	return scanCampaignLeads(rows)
+}
+
+// MarkCampaignLeadContacted records that the campaign sent the lead a
+// message, enrolling the lead if needed. Enrollments past ENROLLED are left
+// as they are.
+func (db *DB) MarkCampaignLeadContacted(ctx context.Context, campaignID, leadID string, at time.Time) error {
+	query := `INSERT INTO campaign_leads (campaign_id, lead_id, status, enrolled_at) 
+              VALUES ($1, $2, $3, $4) 
+              ON CONFLICT (campaign_id, lead_id) DO UPDATE SET status = EXCLUDED.status, updated_at = EXCLUDED.enrolled_at 
+              WHERE campaign_leads.status = $5`
+
+	_, err := db.conn.ExecContext(
+		ctx, query, campaignID, leadID, model.CampaignLeadStatusInProgress, at, model.CampaignLeadStatusEnrolled,
+	)
+	if err != nil {
+		return fmt.Errorf("error marking campaign lead contacted: %w", err)
+	}
+
+	return nil
+}
+
+// MarkCampaignLeadsReplied moves the lead's IN_PROGRESS enrollments to
+// REPLIED.
+func (db *DB) MarkCampaignLeadsReplied(ctx context.Context, leadID string, at time.Time) error {
+	return setLeadCampaignStatus(ctx, db.conn, []string{leadID}, []model.CampaignLeadStatus{model.CampaignLeadStatusInProgress}, model.CampaignLeadStatusReplied, at)
+}
+
+// convertCampaignLeads marks the leads' open enrollments CONVERTED once the
+// leads are won.
+func convertCampaignLeads(ctx context.Context, exec execer, leadIDs []string, at time.Time) error {
+	from := []model.CampaignLeadStatus{
+		model.CampaignLeadStatusEnrolled, model.CampaignLeadStatusInProgress, model.CampaignLeadStatusReplied,
+	}
+	return setLeadCampaignStatus(ctx, exec, leadIDs, from, model.CampaignLeadStatusConverted, at)
+}
+
+func setLeadCampaignStatus(ctx context.Context, exec execer, leadIDs []string, from []model.CampaignLeadStatus, to model.CampaignLeadStatus, at time.Time) error {
+	statuses := make([]string, len(from))
+	for i, status := range from {
+		statuses[i] = string(status)
+	}
+
+	query := `UPDATE campaign_leads SET status = $1, updated_at = $2 WHERE lead_id = ANY($3::uuid[]) AND status = ANY($4)`
+
+	if _, err := exec.ExecContext(ctx, query, to, at, pq.Array(leadIDs), pq.Array(statuses)); err != nil {
+		return fmt.Errorf("error updating campaign lead status: %w", err)
+	}
+
+	return nil
+}
+
+func scanCampaignLeads(rows *sql.Rows) ([]*model.CampaignLead, error) {
+	defer rows.Close()
+
+	enrollments := []*model.CampaignLead{}
+	for rows.Next() {
+		enrollment, err := scanCampaignLead(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign lead row: %w", err)
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign lead rows: %w", err)
+	}
+
+	return enrollments, nil
+}
+
+func scanCampaignLead(row interface{ Scan(...interface{}) error }) (*model.CampaignLead, error) {
+	var enrollment model.CampaignLead
+	var enrolledBy sql.NullString
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&enrollment.CampaignID, &enrollment.LeadID, &enrollment.Status, &enrolledBy, &enrollment.EnrolledAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrolledBy.Valid {
+		enrollment.EnrolledByID = &enrolledBy.String
+	}
+	if updatedAt.Valid {
+		enrollment.UpdatedAt = &updatedAt.Time
+	}
+
+	return &enrollment, nil
+}
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// campaignInteractions selects the interactions attributed to each campaign
+// listed by the uuid[] placeholder campaigns, or to every campaign when it is
+// NULL: those sent from one of its templates or by one of its AI agents while
+// the campaign was running.
+func campaignInteractions(campaigns string) string {
+	return `SELECT DISTINCT c.id AS campaign_id, i.id, i.lead_id, i.type, i.channel, i.status, i.direction 
+                  FROM campaigns c 
+                  JOIN interactions i ON i.timestamp >= c.start_date 
+                      AND (c.end_date IS NULL OR i.timestamp <= c.end_date) 
+                  WHERE (` + campaigns + `::uuid[] IS NULL OR c.id = ANY(` + campaigns + `)) 
+                  AND (i.template_id IN (SELECT id FROM message_templates WHERE campaign_id = c.id) 
+                      OR i.ai_agent_id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = c.id))`
+}
+
+// messageSent matches the campaign interactions ci that count as messages
+// sent.
+const messageSent = `ci.direction = 'OUTBOUND' AND ci.type <> 'MEETING' 
+                  AND ci.status NOT IN ` + unsentStatuses
+
+// campaignCounts counts, over campaign interactions ci and their leads l, the
+// leads reached, messages sent, replies and won leads.
+var campaignCounts = `COUNT(DISTINCT ci.lead_id), 
+                  COUNT(ci.id) FILTER (WHERE ` + messageSent + `), 
+                  COUNT(ci.id) FILTER (WHERE ci.status = 'RESPONDED'), 
+                  COUNT(DISTINCT l.id) FILTER (WHERE l.status = 'WON')`
+
+// campaignMetricsQuery rolls up the interactions attributed to each campaign.
+// $1 lists the campaigns, or is NULL for all of them.
+var campaignMetricsQuery = `WITH campaign_interactions AS ( 
+                  ` + campaignInteractions("$1") + ` 
+              ) 
+              SELECT c.id, COALESCE(c.budget, 0), 
+                  COUNT(DISTINCT ci.lead_id), 
+                  COUNT(ci.id), 
+                  COUNT(ci.id) FILTER (WHERE ` + messageSent + `), 
+                  COUNT(ci.id) FILTER (WHERE ci.status = 'RESPONDED'), 
+                  COUNT(ci.id) FILTER (WHERE ci.type = 'MEETING'), 
+                  COUNT(DISTINCT l.id) FILTER (WHERE l.status = 'WON') 
+              FROM campaigns c 
+              LEFT JOIN campaign_interactions ci ON ci.campaign_id = c.id 
+              LEFT JOIN leads l ON l.id = ci.lead_id 
+              WHERE ($1::uuid[] IS NULL OR c.id = ANY($1)) AND (c.agency_id = $2 OR $2 IS NULL) 
+              GROUP BY c.id, c.budget`
+
+// campaignChannelMetricsQuery breaks the metrics of the campaigns listed by
+// $1 down by channel, busiest first.
+var campaignChannelMetricsQuery = `WITH campaign_interactions AS ( 
+                  ` + campaignInteractions("$1") + ` 
+              ) 
+              SELECT ci.campaign_id, ci.channel, ` + campaignCounts + ` 
+              FROM campaign_interactions ci 
+              JOIN campaigns c ON c.id = ci.campaign_id 
+              LEFT JOIN leads l ON l.id = ci.lead_id 
+              WHERE c.agency_id = $2 OR $2 IS NULL 
+              GROUP BY ci.campaign_id, ci.channel 
+              ORDER BY ci.campaign_id, 4 DESC, ci.channel`
+
+// inTargetAudience matches the leads l that belong to the target audience t:
+// their industry, from their "industry" custom field or else the one they
+// were routed to the campaign with, is the audience's, and their position
+// names its decision-maker role, when it has one. Both compare ignoring case.
+const inTargetAudience = `lower(t.industry) = lower(COALESCE(l.custom_fields->>'industry', ( 
+                      SELECT a.industry FROM lead_assignment_log a 
+                      WHERE a.lead_id = l.id AND a.campaign_id = t.campaign_id AND a.industry IS NOT NULL 
+                      ORDER BY a.created_at DESC LIMIT 1 
+                  ))) 
+                  AND (t.decision_maker_role IS NULL 
+                      OR strpos(lower(COALESCE(l.position, '')), lower(t.decision_maker_role)) > 0)`
+
+// campaignAudienceMetricsQuery breaks the metrics of the campaigns listed by
+// $1 down by their target audiences. A lead in several of a campaign's
+// audiences counts towards each.
+var campaignAudienceMetricsQuery = `WITH campaign_interactions AS ( 
+                  ` + campaignInteractions("$1") + ` 
+              ), audience_interactions AS ( 
+                  SELECT t.id AS target_id, ci.* 
+                  FROM target_audiences t 
+                  JOIN campaign_interactions ci ON ci.campaign_id = t.campaign_id 
+                  JOIN leads l ON l.id = ci.lead_id 
+                  WHERE ` + inTargetAudience + ` 
+              ) 
+              SELECT t.campaign_id, t.id, ` + campaignCounts + ` 
+              FROM target_audiences t 
+              JOIN campaigns c ON c.id = t.campaign_id 
+              LEFT JOIN audience_interactions ci ON ci.target_id = t.id 
+              LEFT JOIN leads l ON l.id = ci.lead_id 
+              WHERE t.campaign_id = ANY($1) AND (c.agency_id = $2 OR $2 IS NULL) 
+              GROUP BY t.campaign_id, t.id 
+              ORDER BY t.campaign_id, t.created_at, t.id`
+
+func (db *DB) GetCampaignMetrics(ctx context.Context, campaignID string) (*model.CampaignMetrics, error) {
+	metrics, err := db.GetCampaignMetricsByCampaignIDs(ctx, []string{campaignID})
+	if err != nil {
+		return nil, err
+	}
+	return metrics[campaignID], nil
+}
+
+// GetCampaignMetricsByCampaignIDs computes lifetime metrics for each campaign
+// on demand. Campaigns outside the caller's agency are omitted.
+func (db *DB) GetCampaignMetricsByCampaignIDs(ctx context.Context, campaignIDs []string) (map[string]*model.CampaignMetrics, error) {
+	if len(campaignIDs) == 0 {
+		return map[string]*model.CampaignMetrics{}, nil
+	}
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, campaignMetricsQuery, pq.Array(campaignIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign metrics: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	metricsByCampaign := make(map[string]*model.CampaignMetrics, len(campaignIDs))
+	for rows.Next() {
+		var metrics model.CampaignMetrics
+		var campaignID string
+
+		err := rows.Scan(
+			&campaignID, &metrics.Cost, &metrics.LeadsGenerated, &metrics.Interactions,
+			&metrics.MessagesSent, &metrics.Replies, &metrics.MeetingsBooked, &metrics.Conversions,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign metrics row: %w", err)
+		}
+
+		metrics.ID = campaignID
+		metrics.Campaign = &model.Campaign{ID: campaignID}
+		metrics.Period = "lifetime"
+		metrics.CreatedAt = now
+
+		if metrics.LeadsGenerated > 0 {
+			metrics.ConversionRate = float64(metrics.Conversions) / float64(metrics.LeadsGenerated)
+			spendPerLead := metrics.Cost / float64(metrics.LeadsGenerated)
+			metrics.SpendPerLead = &spendPerLead
+		}
+		// Deal values are not tracked, so ROI stays at zero until they are.
+
+		metricsByCampaign[campaignID] = &metrics
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign metrics rows: %w", err)
+	}
+
+	return metricsByCampaign, nil
+}
+
+// GetCampaignChannelMetricsByCampaignIDs breaks each campaign's lifetime
+// metrics down by the channels it reached leads on. Campaigns without
+// interactions, or outside the caller's agency, get none.
+func (db *DB) GetCampaignChannelMetricsByCampaignIDs(ctx context.Context, campaignIDs []string) (map[string][]*model.ChannelMetrics, error) {
+	metricsByCampaign := make(map[string][]*model.ChannelMetrics, len(campaignIDs))
+	if len(campaignIDs) == 0 {
+		return metricsByCampaign, nil
+	}
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, campaignChannelMetricsQuery, pq.Array(campaignIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign channel metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for _, campaignID := range campaignIDs {
+		metricsByCampaign[campaignID] = []*model.ChannelMetrics{}
+	}
+	for rows.Next() {
+		var metrics model.ChannelMetrics
+		var campaignID string
+		err := rows.Scan(
+			&campaignID, &metrics.Channel, &metrics.LeadsGenerated, &metrics.MessagesSent, &metrics.Replies, &metrics.Conversions,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign channel metrics row: %w", err)
+		}
+		metrics.ReplyRate, metrics.ConversionRate = campaignRates(metrics.LeadsGenerated, metrics.MessagesSent, metrics.Replies, metrics.Conversions)
+		metricsByCampaign[campaignID] = append(metricsByCampaign[campaignID], &metrics)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign channel metrics rows: %w", err)
+	}
+
+	return metricsByCampaign, nil
+}
+
+// GetCampaignAudienceMetricsByCampaignIDs breaks each campaign's lifetime
+// metrics down by its target audiences, oldest first. Each carries only the
+// ID of its audience. Campaigns outside the caller's agency get none.
+func (db *DB) GetCampaignAudienceMetricsByCampaignIDs(ctx context.Context, campaignIDs []string) (map[string][]*model.AudienceMetrics, error) {
+	metricsByCampaign := make(map[string][]*model.AudienceMetrics, len(campaignIDs))
+	if len(campaignIDs) == 0 {
+		return metricsByCampaign, nil
+	}
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, campaignAudienceMetricsQuery, pq.Array(campaignIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign audience metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for _, campaignID := range campaignIDs {
+		metricsByCampaign[campaignID] = []*model.AudienceMetrics{}
+	}
+	for rows.Next() {
+		var metrics model.AudienceMetrics
+		var campaignID, targetID string
+		err := rows.Scan(
+			&campaignID, &targetID, &metrics.LeadsGenerated, &metrics.MessagesSent, &metrics.Replies, &metrics.Conversions,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign audience metrics row: %w", err)
+		}
+		metrics.Audience = &model.TargetAudience{ID: targetID}
+		metrics.ReplyRate, metrics.ConversionRate = campaignRates(metrics.LeadsGenerated, metrics.MessagesSent, metrics.Replies, metrics.Conversions)
+		metricsByCampaign[campaignID] = append(metricsByCampaign[campaignID], &metrics)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign audience metrics rows: %w", err)
+	}
+
+	return metricsByCampaign, nil
+}
+
+// campaignRates returns replies per message sent and conversions per lead,
+// or zero when there are none to divide by.
+func campaignRates(leads, sent, replies, conversions int) (replyRate, conversionRate float64) {
+	if sent > 0 {
+		replyRate = float64(replies) / float64(sent)
+	}
+	if leads > 0 {
+		conversionRate = float64(conversions) / float64(leads)
+	}
+	return replyRate, conversionRate
+}
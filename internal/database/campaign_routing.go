@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const campaignRoutingColumns = `r.campaign_id, c.name, r.weight, r.industries, r.regions, r.enabled, r.credit, r.created_at, r.updated_at`
+
+const assignmentLogColumns = `a.id, a.lead_id, a.campaign_id, a.reason, a.industry, a.timezone, a.candidates, a.created_at`
+
+// GetCampaignRouting returns the campaign's routing, or nil when it takes no
+// inbound leads.
+func (db *DB) GetCampaignRouting(ctx context.Context, campaignID string) (*model.CampaignRouting, error) {
+	query := `SELECT ` + campaignRoutingColumns + ` FROM campaign_routing r JOIN campaigns c ON c.id = r.campaign_id 
+              WHERE r.campaign_id = $1 AND (r.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routing, err := scanCampaignRouting(db.conn.QueryRowContext(ctx, query, campaignID, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return routing, err
+}
+
+// GetCampaignRoutings lists the routing of every campaign that takes inbound
+// leads, by campaign name.
+func (db *DB) GetCampaignRoutings(ctx context.Context) ([]*model.CampaignRouting, error) {
+	query := `SELECT ` + campaignRoutingColumns + ` FROM campaign_routing r JOIN campaigns c ON c.id = r.campaign_id 
+              WHERE (r.agency_id = $1 OR $1 IS NULL) 
+              ORDER BY c.name, r.campaign_id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign routing: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCampaignRoutings(rows)
+}
+
+// SetCampaignRouting creates or replaces the campaign's routing. A new weight
+// restarts the campaign's round-robin credit. It returns nil when the
+// campaign does not exist.
+func (db *DB) SetCampaignRouting(ctx context.Context, routing *model.CampaignRouting) (*model.CampaignRouting, error) {
+	query := `INSERT INTO campaign_routing (campaign_id, agency_id, weight, industries, regions, enabled, created_at) 
+              SELECT c.id, c.agency_id, $2, $3, $4, $5, $6 FROM campaigns c 
+              WHERE c.id = $1 AND (c.agency_id = $7 OR $7 IS NULL) 
+              ON CONFLICT (campaign_id) DO UPDATE 
+              SET weight = EXCLUDED.weight, industries = EXCLUDED.industries, regions = EXCLUDED.regions, 
+                  enabled = EXCLUDED.enabled, updated_at = EXCLUDED.created_at, 
+                  credit = CASE WHEN campaign_routing.weight = EXCLUDED.weight THEN campaign_routing.credit ELSE 0 END`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.conn.ExecContext(
+		ctx, query, routing.CampaignID, routing.Weight, pq.Array(routing.Industries), pq.Array(routing.Regions),
+		routing.Enabled, time.Now(), agencyID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error setting campaign routing: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return db.GetCampaignRouting(ctx, routing.CampaignID)
+}
+
+// DeleteCampaignRouting stops the campaign taking inbound leads.
+func (db *DB) DeleteCampaignRouting(ctx context.Context, campaignID string) (bool, error) {
+	query := "DELETE FROM campaign_routing WHERE campaign_id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, campaignID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting campaign routing: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DistributeLead enrolls the lead in the campaign choose picks from the
+// routing of the agency's active campaigns, saves the credits choose leaves
+// them with and logs the choice. The routing is locked meanwhile, so
+// concurrent captures take turns. choose returns nil to leave the lead
+// undistributed, and an entry without a campaign to log that none took it.
+func (db *DB) DistributeLead(ctx context.Context, leadID string, choose func(routes []*model.CampaignRouting) *model.AssignmentLogEntry) (*model.AssignmentLogEntry, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Campaigns of archived clients are paused, so only deleted clients
+	// need leaving out.
+	query := `SELECT ` + campaignRoutingColumns + ` FROM campaign_routing r JOIN campaigns c ON c.id = r.campaign_id 
+              WHERE r.agency_id = $1 AND r.enabled AND c.status = $2 
+              AND NOT EXISTS (SELECT 1 FROM clients cl WHERE cl.id = c.client_id AND cl.deleted_at IS NOT NULL) 
+              ORDER BY r.created_at, r.campaign_id 
+              FOR UPDATE OF r`
+
+	rows, err := tx.QueryContext(ctx, query, agencyID, model.CampaignStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("error locking campaign routing: %w", err)
+	}
+	routes, err := scanCampaignRoutings(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	credits := make(map[string]int, len(routes))
+	for _, route := range routes {
+		credits[route.CampaignID] = route.Credit
+	}
+
+	entry := choose(routes)
+	if entry == nil {
+		return nil, nil
+	}
+
+	var changedIDs []string
+	var changedCredits []int64
+	for _, route := range routes {
+		if route.Credit != credits[route.CampaignID] {
+			changedIDs = append(changedIDs, route.CampaignID)
+			changedCredits = append(changedCredits, int64(route.Credit))
+		}
+	}
+	if len(changedIDs) > 0 {
+		_, err = tx.ExecContext(ctx, `UPDATE campaign_routing r SET credit = c.credit 
+              FROM unnest($1::uuid[], $2::int[]) AS c(campaign_id, credit) 
+              WHERE r.campaign_id = c.campaign_id`, pq.Array(changedIDs), pq.Array(changedCredits))
+		if err != nil {
+			return nil, fmt.Errorf("error saving campaign routing credit: %w", err)
+		}
+	}
+
+	now := time.Now()
+	if entry.CampaignID != nil {
+		_, err = tx.ExecContext(ctx, `INSERT INTO campaign_leads (campaign_id, lead_id, status, enrolled_at) 
+              VALUES ($1, $2, $3, $4) 
+              ON CONFLICT (campaign_id, lead_id) DO NOTHING`, *entry.CampaignID, leadID, model.CampaignLeadStatusEnrolled, now)
+		if err != nil {
+			return nil, fmt.Errorf("error enrolling campaign lead: %w", err)
+		}
+	}
+
+	candidates, err := json.Marshal(entry.Candidates)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding assignment candidates: %w", err)
+	}
+
+	entry.LeadID = leadID
+	entry.CreatedAt = now
+	err = tx.QueryRowContext(
+		ctx, `INSERT INTO lead_assignment_log (agency_id, lead_id, campaign_id, reason, industry, timezone, candidates, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+              RETURNING id`,
+		agencyID, leadID, entry.CampaignID, entry.Reason, entry.Industry, entry.Timezone, string(candidates), now,
+	).Scan(&entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error logging lead assignment: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetAssignmentLog lists the distribution of inbound leads, newest first,
+// narrowed to a lead or a campaign when they are given.
+func (db *DB) GetAssignmentLog(ctx context.Context, leadID, campaignID *string, limit, offset int) ([]*model.AssignmentLogEntry, error) {
+	query := `SELECT ` + assignmentLogColumns + ` FROM lead_assignment_log a 
+              WHERE (a.agency_id = $1 OR $1 IS NULL) AND (a.lead_id = $2 OR $2 IS NULL) AND (a.campaign_id = $3 OR $3 IS NULL) 
+              ORDER BY a.created_at DESC, a.id 
+              LIMIT $4 OFFSET $5`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID, leadID, campaignID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error querying assignment log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.AssignmentLogEntry{}
+	for rows.Next() {
+		var entry model.AssignmentLogEntry
+		var campaignID, industry, timezone sql.NullString
+		var candidates []byte
+
+		err := rows.Scan(
+			&entry.ID, &entry.LeadID, &campaignID, &entry.Reason, &industry, &timezone, &candidates, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning assignment log row: %w", err)
+		}
+
+		if err := json.Unmarshal(candidates, &entry.Candidates); err != nil {
+			return nil, fmt.Errorf("error decoding assignment candidates: %w", err)
+		}
+		entry.CampaignID = nullString(campaignID)
+		entry.Industry = nullString(industry)
+		entry.Timezone = nullString(timezone)
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assignment log rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+func scanCampaignRoutings(rows *sql.Rows) ([]*model.CampaignRouting, error) {
+	routings := []*model.CampaignRouting{}
+	for rows.Next() {
+		routing, err := scanCampaignRouting(rows)
+		if err != nil {
+			return nil, err
+		}
+		routings = append(routings, routing)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign routing rows: %w", err)
+	}
+
+	return routings, nil
+}
+
+func scanCampaignRouting(row interface{ Scan(...interface{}) error }) (*model.CampaignRouting, error) {
+	var routing model.CampaignRouting
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&routing.CampaignID, &routing.CampaignName, &routing.Weight, pq.Array(&routing.Industries),
+		pq.Array(&routing.Regions), &routing.Enabled, &routing.Credit, &routing.CreatedAt, &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning campaign routing row: %w", err)
+	}
+
+	if updatedAt.Valid {
+		routing.UpdatedAt = &updatedAt.Time
+	}
+
+	return &routing, nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+)
+
+// CampaignBudget is a campaign's budget and what has been spent against it.
+type CampaignBudget struct {
+	CampaignID string
+	Name       string
+	Status     model.CampaignStatus
+	Budget     *float64
+	Spent      float64
+}
+
+// RecordCampaignSpend adds an entry to the campaign's spend ledger and
+// returns the campaign's budget with the entry included. The campaign row is
+// locked while recording so concurrent entries see each other's totals. It
+// returns nil when the campaign does not exist.
+func (db *DB) RecordCampaignSpend(ctx context.Context, spend *model.CampaignSpend) (*CampaignBudget, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	budget := CampaignBudget{CampaignID: spend.CampaignID}
+	var amount sql.NullFloat64
+	err = tx.QueryRowContext(
+		ctx, "SELECT name, status, budget FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) FOR UPDATE",
+		spend.CampaignID, agencyID,
+	).Scan(&budget.Name, &budget.Status, &amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error locking campaign: %w", err)
+	}
+	if amount.Valid {
+		budget.Budget = &amount.Float64
+	}
+
+	query := `INSERT INTO campaign_spend (campaign_id, interaction_id, kind, amount, description, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
+              RETURNING id`
+
+	err = tx.QueryRowContext(
+		ctx, query, spend.CampaignID, spend.InteractionID, spend.Kind, spend.Amount, spend.Description, spend.CreatedAt,
+	).Scan(&spend.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error recording campaign spend: %w", err)
+	}
+
+	err = tx.QueryRowContext(
+		ctx, "SELECT COALESCE(SUM(amount), 0) FROM campaign_spend WHERE campaign_id = $1", spend.CampaignID,
+	).Scan(&budget.Spent)
+	if err != nil {
+		return nil, fmt.Errorf("error totalling campaign spend: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return &budget, nil
+}
+
+// GetCampaignBudget returns the campaign's budget and spend to date, or nil
+// when the campaign does not exist.
+func (db *DB) GetCampaignBudget(ctx context.Context, campaignID string) (*CampaignBudget, error) {
+	query := `SELECT c.name, c.status, c.budget, COALESCE((SELECT SUM(amount) FROM campaign_spend WHERE campaign_id = c.id), 0) 
+              FROM campaigns c WHERE c.id = $1 AND (c.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := CampaignBudget{CampaignID: campaignID}
+	var amount sql.NullFloat64
+	err = db.conn.QueryRowContext(ctx, query, campaignID, agencyID).Scan(&budget.Name, &budget.Status, &amount, &budget.Spent)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching campaign budget: %w", err)
+	}
+	if amount.Valid {
+		budget.Budget = &amount.Float64
+	}
+
+	return &budget, nil
+}
+
+// GetCampaignSpend returns the campaign's most recent spend entries, newest
+// first.
+func (db *DB) GetCampaignSpend(ctx context.Context, campaignID string, limit int) ([]*model.CampaignSpend, error) {
+	query := `SELECT s.id, s.campaign_id, s.interaction_id, s.kind, s.amount, s.description, s.created_at 
+              FROM campaign_spend s JOIN campaigns c ON c.id = s.campaign_id 
+              WHERE s.campaign_id = $1 AND (c.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY s.created_at DESC, s.id 
+              LIMIT $3`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID, agencyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign spend: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.CampaignSpend{}
+	for rows.Next() {
+		var spend model.CampaignSpend
+		var interactionID, description sql.NullString
+
+		err := rows.Scan(
+			&spend.ID, &spend.CampaignID, &interactionID, &spend.Kind, &spend.Amount, &description, &spend.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign spend row: %w", err)
+		}
+		if interactionID.Valid {
+			spend.InteractionID = &interactionID.String
+		}
+		if description.Valid {
+			spend.Description = &description.String
+		}
+
+		entries = append(entries, &spend)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign spend rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ClaimBudgetAlert reports whether the spend alert for the campaign's current
+// budget still has to be sent and, if so, marks it sent and enqueues alert,
+// unless it is nil, in the same transaction. Changing the budget allows a new
+// alert.
+func (db *DB) ClaimBudgetAlert(ctx context.Context, campaignID string, budget float64, alert *model.OutboxMessage) (bool, error) {
+	query := `UPDATE campaigns SET budget_alerted = $1 
+              WHERE id = $2 AND budget_alerted IS DISTINCT FROM $1 
+              RETURNING agency_id`
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var agencyID string
+	if err := tx.QueryRowContext(ctx, query, budget, campaignID).Scan(&agencyID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error claiming budget alert: %w", err)
+	}
+
+	if alert != nil {
+		if err := enqueueOutbox(ctx, tx, agencyID, alert); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing budget alert: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,557 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+var ErrDuplicateCampaignTemplate = errors.New("a campaign template with this name already exists")
+
+// CampaignBlueprint is everything needed to set a campaign up again: its
+// settings, target audiences, own message templates, sequences, A/B split
+// and agents, but none of its leads or results. The campaign's own templates
+// are referred to by their index in Messages, so a blueprint can be stored
+// and replayed after the original templates are gone.
+type CampaignBlueprint struct {
+	Description *string  `json:"description,omitempty"`
+	Budget      *float64 `json:"budget,omitempty"`
+	// Currency is the budget's. Blueprints saved before campaigns had a
+	// currency leave it empty and take the agency's base currency.
+	Currency string `json:"currency,omitempty"`
+	// Duration is the time from start to end date, nil for campaigns
+	// without an end date.
+	Duration        *time.Duration      `json:"duration,omitempty"`
+	SendWindow      *string             `json:"sendWindow,omitempty"`
+	DefaultLanguage *string             `json:"defaultLanguage,omitempty"`
+	Targets         []BlueprintTarget   `json:"targets"`
+	Messages        []BlueprintMessage  `json:"messages"`
+	Sequences       []BlueprintSequence `json:"sequences"`
+	Variants        []BlueprintVariant  `json:"variants"`
+	AIAgentIDs      []string            `json:"aiAgentIds"`
+}
+
+type BlueprintTarget struct {
+	Name              string   `json:"name"`
+	Industry          string   `json:"industry"`
+	CompanySize       *string  `json:"companySize,omitempty"`
+	Location          *string  `json:"location,omitempty"`
+	DecisionMakerRole *string  `json:"decisionMakerRole,omitempty"`
+	PainPoints        []string `json:"painPoints,omitempty"`
+}
+
+type BlueprintMessage struct {
+	Name      string        `json:"name"`
+	Content   string        `json:"content"`
+	Variables []string      `json:"variables,omitempty"`
+	Channel   model.Channel `json:"channel"`
+	Purpose   string        `json:"purpose"`
+	AIAgentID *string       `json:"aiAgentId,omitempty"`
+	// Translations maps locales to the content in them.
+	Translations map[string]string `json:"translations,omitempty"`
+}
+
+type BlueprintSequence struct {
+	Name  string          `json:"name"`
+	Steps []BlueprintStep `json:"steps"`
+}
+
+// BlueprintStep sends the campaign's own template Message or the agency's
+// shared template TemplateID; a step with neither is a task.
+type BlueprintStep struct {
+	Day        int           `json:"day"`
+	Channel    model.Channel `json:"channel"`
+	Message    *int          `json:"message,omitempty"`
+	TemplateID *string       `json:"templateId,omitempty"`
+	Task       *string       `json:"task,omitempty"`
+}
+
+type BlueprintVariant struct {
+	Message int `json:"message"`
+	Weight  int `json:"weight"`
+}
+
+// GetCampaignBlueprint returns nil when the campaign does not exist.
+func (db *DB) GetCampaignBlueprint(ctx context.Context, campaignID string) (*CampaignBlueprint, error) {
+	query := `SELECT description, budget, currency, start_date, end_date, send_window, default_language 
+              FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bp := &CampaignBlueprint{
+		Targets:    []BlueprintTarget{},
+		Messages:   []BlueprintMessage{},
+		Sequences:  []BlueprintSequence{},
+		Variants:   []BlueprintVariant{},
+		AIAgentIDs: []string{},
+	}
+	var description, sendWindow, defaultLanguage sql.NullString
+	var budget sql.NullFloat64
+	var startDate time.Time
+	var endDate sql.NullTime
+	err = db.conn.QueryRowContext(ctx, query, campaignID, agencyID).Scan(&description, &budget, &bp.Currency, &startDate, &endDate, &sendWindow, &defaultLanguage)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching campaign: %w", err)
+	}
+	if description.Valid {
+		bp.Description = &description.String
+	}
+	if budget.Valid {
+		bp.Budget = &budget.Float64
+	}
+	if endDate.Valid {
+		duration := endDate.Time.Sub(startDate)
+		bp.Duration = &duration
+	}
+	if sendWindow.Valid {
+		bp.SendWindow = &sendWindow.String
+	}
+	bp.DefaultLanguage = nullString(defaultLanguage)
+
+	if err := db.readBlueprintTargets(ctx, campaignID, bp); err != nil {
+		return nil, err
+	}
+	messages, err := db.readBlueprintMessages(ctx, campaignID, bp)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.readBlueprintSequences(ctx, campaignID, messages, bp); err != nil {
+		return nil, err
+	}
+	if err := db.readBlueprintVariants(ctx, campaignID, messages, bp); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, "SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = $1 ORDER BY ai_agent_id", campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign agents: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning campaign agent row: %w", err)
+		}
+		bp.AIAgentIDs = append(bp.AIAgentIDs, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign agent rows: %w", err)
+	}
+
+	return bp, nil
+}
+
+func (db *DB) readBlueprintTargets(ctx context.Context, campaignID string, bp *CampaignBlueprint) error {
+	query := `SELECT name, industry, company_size, location, decision_maker_role, pain_points 
+              FROM target_audiences WHERE campaign_id = $1 ORDER BY created_at, id`
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID)
+	if err != nil {
+		return fmt.Errorf("error querying target audiences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var target BlueprintTarget
+		var companySize, location, decisionMakerRole sql.NullString
+		var painPoints pq.StringArray
+		if err := rows.Scan(&target.Name, &target.Industry, &companySize, &location, &decisionMakerRole, &painPoints); err != nil {
+			return fmt.Errorf("error scanning target audience row: %w", err)
+		}
+		target.CompanySize = nullString(companySize)
+		target.Location = nullString(location)
+		target.DecisionMakerRole = nullString(decisionMakerRole)
+		target.PainPoints = painPoints
+		bp.Targets = append(bp.Targets, target)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating target audience rows: %w", err)
+	}
+	return nil
+}
+
+// readBlueprintMessages adds the campaign's own templates, with their
+// translations, and returns the index of each by template id.
+func (db *DB) readBlueprintMessages(ctx context.Context, campaignID string, bp *CampaignBlueprint) (map[string]int, error) {
+	query := `SELECT id, name, content, variables, channel, purpose, ai_agent_id 
+              FROM message_templates WHERE campaign_id = $1 ORDER BY created_at, id`
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying message templates: %w", err)
+	}
+	defer rows.Close()
+
+	indexes := map[string]int{}
+	for rows.Next() {
+		var id string
+		var message BlueprintMessage
+		var variables pq.StringArray
+		var aiAgentID sql.NullString
+		if err := rows.Scan(&id, &message.Name, &message.Content, &variables, &message.Channel, &message.Purpose, &aiAgentID); err != nil {
+			return nil, fmt.Errorf("error scanning message template row: %w", err)
+		}
+		message.Variables = variables
+		message.AIAgentID = nullString(aiAgentID)
+		indexes[id] = len(bp.Messages)
+		bp.Messages = append(bp.Messages, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message template rows: %w", err)
+	}
+
+	query = `SELECT t.template_id, t.locale, t.content 
+              FROM message_template_translations t JOIN message_templates m ON m.id = t.template_id 
+              WHERE m.campaign_id = $1 ORDER BY t.locale`
+
+	translations, err := db.conn.QueryContext(ctx, query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying template translations: %w", err)
+	}
+	defer translations.Close()
+
+	for translations.Next() {
+		var templateID, locale, content string
+		if err := translations.Scan(&templateID, &locale, &content); err != nil {
+			return nil, fmt.Errorf("error scanning template translation row: %w", err)
+		}
+		i, ok := indexes[templateID]
+		if !ok {
+			continue
+		}
+		if bp.Messages[i].Translations == nil {
+			bp.Messages[i].Translations = map[string]string{}
+		}
+		bp.Messages[i].Translations[locale] = content
+	}
+
+	if err = translations.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating template translation rows: %w", err)
+	}
+	return indexes, nil
+}
+
+func (db *DB) readBlueprintSequences(ctx context.Context, campaignID string, messages map[string]int, bp *CampaignBlueprint) error {
+	sequences, err := db.GetSequencesByCampaignID(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+
+	for _, sequence := range sequences {
+		steps, err := db.GetSequenceSteps(ctx, sequence.ID)
+		if err != nil {
+			return err
+		}
+
+		copied := BlueprintSequence{Name: sequence.Name, Steps: make([]BlueprintStep, 0, len(steps))}
+		for _, step := range steps {
+			s := BlueprintStep{Day: step.Day, Channel: step.Channel, Task: step.Task}
+			if step.TemplateID != nil {
+				if i, ok := messages[*step.TemplateID]; ok {
+					s.Message = &i
+				} else {
+					s.TemplateID = step.TemplateID
+				}
+			}
+			copied.Steps = append(copied.Steps, s)
+		}
+		bp.Sequences = append(bp.Sequences, copied)
+	}
+
+	return nil
+}
+
+func (db *DB) readBlueprintVariants(ctx context.Context, campaignID string, messages map[string]int, bp *CampaignBlueprint) error {
+	variants, err := db.GetCampaignVariants(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range variants {
+		// Variants can only use the campaign's own templates.
+		if i, ok := messages[variant.TemplateID]; ok {
+			bp.Variants = append(bp.Variants, BlueprintVariant{Message: i, Weight: variant.Weight})
+		}
+	}
+
+	return nil
+}
+
+// CreateCampaignFromBlueprint inserts the campaign with the audiences,
+// templates, sequences, A/B split and agents of bp, in one transaction. The
+// campaign's own fields are taken from campaign, not bp. Agents and shared
+// templates that no longer exist in the agency are left out.
+func (db *DB) CreateCampaignFromBlueprint(ctx context.Context, campaign *model.Campaign, bp *CampaignBlueprint) (*model.Campaign, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO campaigns (name, description, client_id, start_date, end_date, 
+              status, budget, currency, send_window, default_language, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, ` + campaignCurrency("$8", "$12") + `, $9, $10, $11, $12) 
+              RETURNING id, currency`
+	err = tx.QueryRowContext(
+		ctx, query, campaign.Name, campaign.Description, campaign.ClientID, campaign.StartDate,
+		campaign.EndDate, campaign.Status, campaign.Budget, campaign.Currency, bp.SendWindow, bp.DefaultLanguage,
+		campaign.CreatedAt, agencyID,
+	).Scan(&campaign.ID, &campaign.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("error creating campaign: %w", err)
+	}
+
+	query = `INSERT INTO target_audiences (campaign_id, name, industry, company_size, location, 
+              decision_maker_role, pain_points, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	for _, target := range bp.Targets {
+		_, err := tx.ExecContext(
+			ctx, query, campaign.ID, target.Name, target.Industry, target.CompanySize, target.Location,
+			target.DecisionMakerRole, pq.Array(target.PainPoints), campaign.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error creating target audience: %w", err)
+		}
+	}
+
+	query = `INSERT INTO message_templates (agency_id, name, content, variables, channel, purpose, 
+              ai_agent_id, campaign_id, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, 
+              (SELECT id FROM ai_agents WHERE id = $7 AND agency_id = $1), $8, $9) 
+              RETURNING id`
+	templateIDs := make([]string, len(bp.Messages))
+	for i, message := range bp.Messages {
+		err := tx.QueryRowContext(
+			ctx, query, agencyID, message.Name, message.Content, pq.Array(message.Variables), message.Channel,
+			message.Purpose, message.AIAgentID, campaign.ID, campaign.CreatedAt,
+		).Scan(&templateIDs[i])
+		if err != nil {
+			return nil, fmt.Errorf("error creating message template: %w", err)
+		}
+		if err := insertTemplateVersion(ctx, tx, templateIDs[i], nil); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `INSERT INTO message_template_translations (template_id, locale, content, created_at) 
+              VALUES ($1, $2, $3, $4)`
+	for i, message := range bp.Messages {
+		for locale, content := range message.Translations {
+			if _, err := tx.ExecContext(ctx, query, templateIDs[i], locale, content, campaign.CreatedAt); err != nil {
+				return nil, fmt.Errorf("error creating template translation: %w", err)
+			}
+		}
+	}
+
+	for _, sequence := range bp.Sequences {
+		if err := insertBlueprintSequence(ctx, tx, agencyID, campaign, sequence, templateIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `INSERT INTO campaign_variants (campaign_id, template_id, weight, created_at) 
+              VALUES ($1, $2, $3, $4)`
+	for _, variant := range bp.Variants {
+		if variant.Message < 0 || variant.Message >= len(templateIDs) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, query, campaign.ID, templateIDs[variant.Message], variant.Weight, campaign.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error creating campaign variant: %w", err)
+		}
+	}
+
+	if len(bp.AIAgentIDs) > 0 {
+		query = `INSERT INTO campaign_ai_agent (campaign_id, ai_agent_id) 
+              SELECT $1, id FROM ai_agents WHERE id = ANY($2::uuid[]) AND agency_id = $3`
+		if _, err := tx.ExecContext(ctx, query, campaign.ID, pq.Array(bp.AIAgentIDs), agencyID); err != nil {
+			return nil, fmt.Errorf("error assigning campaign agents: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return campaign, nil
+}
+
+func insertBlueprintSequence(ctx context.Context, tx *sql.Tx, agencyID string, campaign *model.Campaign, sequence BlueprintSequence, templateIDs []string) error {
+	var sequenceID string
+	err := tx.QueryRowContext(
+		ctx, "INSERT INTO sequences (campaign_id, name, created_at, agency_id) VALUES ($1, $2, $3, $4) RETURNING id",
+		campaign.ID, sequence.Name, campaign.CreatedAt, agencyID,
+	).Scan(&sequenceID)
+	if err != nil {
+		return fmt.Errorf("error creating sequence: %w", err)
+	}
+
+	query := `INSERT INTO sequence_steps (sequence_id, position, day, channel, template_id, task) 
+              VALUES ($1, $2, $3, $4, (SELECT id FROM message_templates WHERE id = $5 AND agency_id = $6), $7)`
+	for i, step := range sequence.Steps {
+		templateID := step.TemplateID
+		if step.Message != nil && *step.Message >= 0 && *step.Message < len(templateIDs) {
+			templateID = &templateIDs[*step.Message]
+		}
+		if _, err := tx.ExecContext(ctx, query, sequenceID, i, step.Day, step.Channel, templateID, agencyID, step.Task); err != nil {
+			return fmt.Errorf("error creating sequence step: %w", err)
+		}
+	}
+
+	return nil
+}
+
+const campaignTemplateColumns = `id, name, description, blueprint, source_campaign_id, created_by, created_at`
+
+func (db *DB) GetCampaignTemplateByID(ctx context.Context, id string) (*model.CampaignTemplate, *CampaignBlueprint, error) {
+	query := `SELECT ` + campaignTemplateColumns + ` FROM campaign_templates 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, bp, err := scanCampaignTemplate(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("error fetching campaign template: %w", err)
+	}
+
+	return template, bp, nil
+}
+
+func (db *DB) GetCampaignTemplates(ctx context.Context) ([]*model.CampaignTemplate, error) {
+	query := `SELECT ` + campaignTemplateColumns + ` FROM campaign_templates 
+              WHERE (agency_id = $1 OR $1 IS NULL) 
+              ORDER BY name, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*model.CampaignTemplate{}
+	for rows.Next() {
+		template, _, err := scanCampaignTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign template row: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign template rows: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (db *DB) CreateCampaignTemplate(ctx context.Context, template *model.CampaignTemplate, bp *CampaignBlueprint) (*model.CampaignTemplate, error) {
+	query := `INSERT INTO campaign_templates (agency_id, name, description, blueprint, 
+              source_campaign_id, created_by, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(bp)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding campaign blueprint: %w", err)
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, template.Name, template.Description, encoded,
+		template.SourceCampaignID, template.CreatedByID, template.CreatedAt,
+	).Scan(&template.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateCampaignTemplate
+		}
+		return nil, fmt.Errorf("error creating campaign template: %w", err)
+	}
+
+	fillTemplateCounts(template, bp)
+	return template, nil
+}
+
+func (db *DB) DeleteCampaignTemplate(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM campaign_templates WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting campaign template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func scanCampaignTemplate(row interface{ Scan(...interface{}) error }) (*model.CampaignTemplate, *CampaignBlueprint, error) {
+	var template model.CampaignTemplate
+	var description, sourceCampaignID, createdBy sql.NullString
+	var blueprint []byte
+
+	err := row.Scan(&template.ID, &template.Name, &description, &blueprint, &sourceCampaignID, &createdBy, &template.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bp CampaignBlueprint
+	if err := json.Unmarshal(blueprint, &bp); err != nil {
+		return nil, nil, fmt.Errorf("error decoding campaign blueprint: %w", err)
+	}
+
+	template.Description = nullString(description)
+	template.SourceCampaignID = nullString(sourceCampaignID)
+	template.CreatedByID = nullString(createdBy)
+	fillTemplateCounts(&template, &bp)
+
+	return &template, &bp, nil
+}
+
+func fillTemplateCounts(template *model.CampaignTemplate, bp *CampaignBlueprint) {
+	template.TargetCount = len(bp.Targets)
+	template.MessageCount = len(bp.Messages)
+	template.SequenceCount = len(bp.Sequences)
+	template.AIAgentCount = len(bp.AIAgentIDs)
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+)
+
+// VariantCounts is the number of distinct leads each stage of the funnel
+// reached for one A/B test variant.
+type VariantCounts struct {
+	Variant     *model.CampaignVariant
+	Leads       int
+	Opens       int
+	Replies     int
+	Conversions int
+}
+
+// GetCampaignVariants returns the variants of a campaign's A/B test, oldest
+// first.
+func (db *DB) GetCampaignVariants(ctx context.Context, campaignID string) ([]*model.CampaignVariant, error) {
+	query := `SELECT v.id, v.campaign_id, v.template_id, v.weight, v.created_at 
+              FROM campaign_variants v JOIN campaigns c ON c.id = v.campaign_id 
+              WHERE v.campaign_id = $1 AND (c.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY v.created_at, v.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []*model.CampaignVariant{}
+	for rows.Next() {
+		var variant model.CampaignVariant
+		err := rows.Scan(&variant.ID, &variant.CampaignID, &variant.TemplateID, &variant.Weight, &variant.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign variant row: %w", err)
+		}
+		variants = append(variants, &variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign variant rows: %w", err)
+	}
+
+	return variants, nil
+}
+
+// SaveCampaignVariants sets the traffic weight of each given template in the
+// campaign's A/B test. Variants left out are kept with a weight of zero so
+// the interactions already attributed to them still count in the results.
+func (db *DB) SaveCampaignVariants(ctx context.Context, campaignID string, variants []*model.CampaignVariant) ([]*model.CampaignVariant, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the campaign so concurrent saves don't interleave their resets.
+	err = tx.QueryRowContext(
+		ctx, "SELECT id FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) FOR UPDATE",
+		campaignID, agencyID,
+	).Scan(&campaignID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error locking campaign: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE campaign_variants SET weight = 0 WHERE campaign_id = $1", campaignID); err != nil {
+		return nil, fmt.Errorf("error resetting campaign variant weights: %w", err)
+	}
+
+	query := `INSERT INTO campaign_variants (campaign_id, template_id, weight) 
+              VALUES ($1, $2, $3) 
+              ON CONFLICT (campaign_id, template_id) DO UPDATE SET weight = EXCLUDED.weight`
+
+	for _, variant := range variants {
+		if _, err := tx.ExecContext(ctx, query, campaignID, variant.TemplateID, variant.Weight); err != nil {
+			return nil, fmt.Errorf("error saving campaign variant: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return db.GetCampaignVariants(ctx, campaignID)
+}
+
+// GetLeadCampaignVariant returns the variant the lead was first sent in the
+// campaign, or nil if it has not been assigned one yet.
+func (db *DB) GetLeadCampaignVariant(ctx context.Context, campaignID, leadID string) (*model.CampaignVariant, error) {
+	query := `SELECT v.id, v.campaign_id, v.template_id, v.weight, v.created_at 
+              FROM interactions i 
+              JOIN campaign_variants v ON v.id = i.variant_id 
+              JOIN campaigns c ON c.id = v.campaign_id 
+              WHERE v.campaign_id = $1 AND i.lead_id = $2 AND (c.agency_id = $3 OR $3 IS NULL) 
+              ORDER BY i.timestamp, i.id LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var variant model.CampaignVariant
+	err = db.conn.QueryRowContext(ctx, query, campaignID, leadID, agencyID).Scan(
+		&variant.ID, &variant.CampaignID, &variant.TemplateID, &variant.Weight, &variant.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching lead campaign variant: %w", err)
+	}
+
+	return &variant, nil
+}
+
+// GetCampaignVariantCounts counts, per variant, the leads that were sent a
+// message, opened it, replied and were won. Leads count once per stage however
+// many messages they received.
+func (db *DB) GetCampaignVariantCounts(ctx context.Context, campaignID string) ([]*VariantCounts, error) {
+	query := `SELECT v.id, v.campaign_id, v.template_id, v.weight, v.created_at, 
+                  COUNT(DISTINCT i.lead_id) FILTER (WHERE i.status NOT IN ` + unsentStatuses + `), 
+                  COUNT(DISTINCT i.lead_id) FILTER (WHERE i.status IN ('OPENED', 'RESPONDED')), 
+                  COUNT(DISTINCT i.lead_id) FILTER (WHERE i.status = 'RESPONDED'), 
+                  COUNT(DISTINCT l.id) FILTER (WHERE i.status NOT IN ` + unsentStatuses + ` AND l.status = 'WON') 
+              FROM campaign_variants v 
+              JOIN campaigns c ON c.id = v.campaign_id 
+              LEFT JOIN interactions i ON i.variant_id = v.id 
+              LEFT JOIN leads l ON l.id = i.lead_id 
+              WHERE v.campaign_id = $1 AND (c.agency_id = $2 OR $2 IS NULL) 
+              GROUP BY v.id 
+              ORDER BY v.created_at, v.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, campaignID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaign variant counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*VariantCounts
+	for rows.Next() {
+		var variant model.CampaignVariant
+		c := VariantCounts{Variant: &variant}
+
+		err := rows.Scan(
+			&variant.ID, &variant.CampaignID, &variant.TemplateID, &variant.Weight, &variant.CreatedAt,
+			&c.Leads, &c.Opens, &c.Replies, &c.Conversions,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign variant counts row: %w", err)
+		}
+		counts = append(counts, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign variant counts rows: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,339 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// TransitionCampaignStatus moves a campaign from one status to another and
+// applies agentStatus to the campaign's agents in the same transaction. It
+// returns false when the campaign is no longer in the expected status.
+//
+// When pausing, agents that are still assigned to another ACTIVE campaign are
+// left running.
+func (db *DB) TransitionCampaignStatus(ctx context.Context, id string, from, to model.CampaignStatus, agentStatus *model.AgentStatus) (bool, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `UPDATE campaigns SET status = $1, updated_at = $2 
+              WHERE id = $3 AND status = $4 AND (agency_id = $5 OR $5 IS NULL)`
+
+	result, err := tx.ExecContext(ctx, query, to, now, id, from, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating campaign status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	var agentIDs []string
+	if agentStatus != nil {
+		var agentQuery string
+		if *agentStatus == model.AgentStatusActive {
+			agentQuery = `UPDATE ai_agents SET status = $1, updated_at = $2 
+                          WHERE id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = $3) 
+                          AND status = $4 
+                          RETURNING id`
+			agentIDs, err = queryIDs(ctx, tx, agentQuery, model.AgentStatusActive, now, id, model.AgentStatusPaused)
+		} else {
+			agentQuery = `UPDATE ai_agents SET status = $1, updated_at = $2 
+                          WHERE id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = $3) 
+                          AND status = $4 
+                          AND NOT EXISTS (
+                              SELECT 1 FROM campaign_ai_agent ca 
+                              JOIN campaigns c ON c.id = ca.campaign_id 
+                              WHERE ca.ai_agent_id = ai_agents.id AND c.id <> $3 AND c.status = $5
+                          ) 
+                          RETURNING id`
+			agentIDs, err = queryIDs(ctx, tx, agentQuery, *agentStatus, now, id, model.AgentStatusActive, model.CampaignStatusActive)
+		}
+		if err != nil {
+			return false, fmt.Errorf("error updating campaign agents: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	keys := make([]string, 0, len(agentIDs))
+	for _, agentID := range agentIDs {
+		keys = append(keys, aiAgentCacheKey(agentID))
+	}
+	db.invalidate(ctx, keys...)
+
+	return true, nil
+}
+
+// GetDueScheduledCampaignIDs skips campaigns of archived clients, which stay
+// SCHEDULED until the client is reactivated.
+func (db *DB) GetDueScheduledCampaignIDs(ctx context.Context, now time.Time) ([]string, error) {
+	query := `SELECT id FROM campaigns 
+              WHERE status = $1 AND start_date <= $2 AND (agency_id = $3 OR $3 IS NULL) 
+              AND (client_id IS NULL OR client_id NOT IN (SELECT id FROM clients WHERE status = $4))`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, model.CampaignStatusScheduled, now, agencyID, model.ClientStatusArchived)
+	if err != nil {
+		return nil, fmt.Errorf("error querying scheduled campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning campaign id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (db *DB) GetCampaignsByFilter(ctx context.Context, filter *model.CampaignFilterInput, limit *int, offset *int) ([]*model.Campaign, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := selectFrom(`SELECT id, name, description, client_id, start_date, end_date, 
+              status, budget, currency, created_at, updated_at 
+              FROM campaigns`, inTenant("agency_id", agencyID), inClients(ctx, "client_id"))
+
+	if filter != nil {
+		q = q.and(
+			oneOf("status", filter.Status),
+			equals("client_id", filter.ClientID),
+			containsText("name", filter.NameContains),
+			atLeast("start_date", filter.StartDateAfter),
+			atMost("start_date", filter.StartDateBefore),
+			atLeast("end_date", filter.EndDateAfter),
+			atMost("end_date", filter.EndDateBefore),
+		)
+	}
+
+	query, args := q.orderBy("start_date DESC").page(limit, offset).build()
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []*model.Campaign
+	for rows.Next() {
+		var campaign model.Campaign
+		var description, clientID sql.NullString
+		var endDate, updatedAt sql.NullTime
+		var budget sql.NullFloat64
+
+		err := rows.Scan(
+			&campaign.ID, &campaign.Name, &description, &clientID, &campaign.StartDate,
+			&endDate, &campaign.Status, &budget, &campaign.Currency, &campaign.CreatedAt, &updatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign row: %w", err)
+		}
+
+		if description.Valid {
+			campaign.Description = &description.String
+		}
+		if clientID.Valid {
+			campaign.ClientID = &clientID.String
+		}
+		if endDate.Valid {
+			campaign.EndDate = &endDate.Time
+		}
+		if budget.Valid {
+			campaign.Budget = &budget.Float64
+		}
+		if updatedAt.Valid {
+			campaign.UpdatedAt = &updatedAt.Time
+		}
+
+		campaigns = append(campaigns, &campaign)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign rows: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+func (db *DB) CreateCampaign(ctx context.Context, campaign *model.Campaign) (*model.Campaign, error) {
+	query := `INSERT INTO campaigns (name, description, client_id, start_date, end_date, 
+              status, budget, currency, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, ` + campaignCurrency("$8", "$10") + `, $9, $10) 
+              RETURNING id, currency`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, campaign.Name, campaign.Description, campaign.ClientID, campaign.StartDate,
+		campaign.EndDate, campaign.Status, campaign.Budget, campaign.Currency, campaign.CreatedAt, agencyID,
+	).Scan(&campaign.ID, &campaign.Currency)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating campaign: %w", err)
+	}
+
+	return campaign, nil
+}
+
+// UpdateCampaign saves the campaign's editable fields. Status changes go
+// through TransitionCampaignStatus instead.
+func (db *DB) UpdateCampaign(ctx context.Context, campaign *model.Campaign) (*model.Campaign, error) {
+	query := `UPDATE campaigns SET 
+              name = $1, description = $2, client_id = $3, start_date = $4, 
+              end_date = $5, budget = $6, updated_at = $7 
+              WHERE id = $8 AND (agency_id = $9 OR $9 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.conn.ExecContext(
+		ctx, query, campaign.Name, campaign.Description, campaign.ClientID, campaign.StartDate,
+		campaign.EndDate, campaign.Budget, campaign.UpdatedAt, campaign.ID, agencyID,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error updating campaign: %w", err)
+	}
+
+	return campaign, nil
+}
+
+func (db *DB) DeleteCampaign(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting campaign: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) GetCampaignsByAIAgentID(ctx context.Context, aiAgentID string) ([]*model.Campaign, error) {
+	query := `SELECT c.id, c.name, c.description, c.client_id, c.start_date, c.end_date, 
+              c.status, c.budget, c.currency, c.created_at, c.updated_at 
+              FROM campaigns c 
+              JOIN campaign_ai_agent ca ON ca.campaign_id = c.id 
+              WHERE ca.ai_agent_id = $1 AND (c.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY c.start_date DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, aiAgentID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying agent campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []*model.Campaign
+	for rows.Next() {
+		var campaign model.Campaign
+		var description, clientID sql.NullString
+		var endDate, updatedAt sql.NullTime
+		var budget sql.NullFloat64
+
+		err := rows.Scan(
+			&campaign.ID, &campaign.Name, &description, &clientID, &campaign.StartDate,
+			&endDate, &campaign.Status, &budget, &campaign.Currency, &campaign.CreatedAt, &updatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning campaign row: %w", err)
+		}
+
+		if description.Valid {
+			campaign.Description = &description.String
+		}
+		if clientID.Valid {
+			campaign.ClientID = &clientID.String
+		}
+		if endDate.Valid {
+			campaign.EndDate = &endDate.Time
+		}
+		if budget.Valid {
+			campaign.Budget = &budget.Float64
+		}
+		if updatedAt.Valid {
+			campaign.UpdatedAt = &updatedAt.Time
+		}
+
+		campaigns = append(campaigns, &campaign)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating campaign rows: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// Roles of chat messages.
+const (
+	ChatRoleVisitor = "VISITOR"
+	ChatRoleAgent   = "AGENT"
+)
+
+// ChatSession is a conversation with a visitor in the website chat widget.
+type ChatSession struct {
+	ID        string
+	AgencyID  string
+	ClientID  string
+	AIAgentID *string
+	// LeadID and InteractionID are set once the visitor's contact details
+	// were captured.
+	LeadID        *string
+	InteractionID *string
+	Referrer      *string
+	LandingPage   *string
+	CreatedAt     time.Time
+}
+
+type ChatMessage struct {
+	Role      string
+	Body      string
+	CreatedAt time.Time
+}
+
+// CreateChatSession records a new session for the agency in ctx and sets its
+// ID.
+func (db *DB) CreateChatSession(ctx context.Context, session *ChatSession) (*ChatSession, error) {
+	query := `INSERT INTO chat_sessions (agency_id, client_id, ai_agent_id, referrer, landing_page, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $6) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session.AgencyID = agencyID
+
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, session.ClientID, session.AIAgentID, session.Referrer, session.LandingPage, session.CreatedAt,
+	).Scan(&session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating chat session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetChatSession returns nil when there is no such session. Sessions are
+// looked up by their ID alone, which visitors hold in place of a login, so
+// it ignores tenants.
+func (db *DB) GetChatSession(ctx context.Context, id string) (*ChatSession, error) {
+	query := `SELECT id, agency_id, client_id, ai_agent_id, lead_id, interaction_id, referrer, landing_page, created_at 
+              FROM chat_sessions WHERE id = $1`
+
+	var session ChatSession
+	var aiAgentID, leadID, interactionID, referrer, landingPage sql.NullString
+
+	err := db.conn.QueryRowContext(ctx, query, id).Scan(
+		&session.ID, &session.AgencyID, &session.ClientID, &aiAgentID, &leadID, &interactionID, &referrer,
+		&landingPage, &session.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching chat session: %w", err)
+	}
+
+	session.AIAgentID = nullString(aiAgentID)
+	session.LeadID = nullString(leadID)
+	session.InteractionID = nullString(interactionID)
+	session.Referrer = nullString(referrer)
+	session.LandingPage = nullString(landingPage)
+
+	return &session, nil
+}
+
+// GetChatMessages returns the session's messages, oldest first.
+func (db *DB) GetChatMessages(ctx context.Context, sessionID string) ([]*ChatMessage, error) {
+	query := "SELECT role, body, created_at FROM chat_messages WHERE session_id = $1 ORDER BY id"
+
+	rows, err := db.conn.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying chat messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*ChatMessage{}
+	for rows.Next() {
+		var message ChatMessage
+		if err := rows.Scan(&message.Role, &message.Body, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning chat message row: %w", err)
+		}
+		messages = append(messages, &message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chat message rows: %w", err)
+	}
+
+	return messages, nil
+}
+
+// AddChatMessages appends messages to the session. When the session has
+// become an interaction, transcript replaces the interaction's message in
+// the same transaction, so the lead's history follows the chat.
+func (db *DB) AddChatMessages(ctx context.Context, session *ChatSession, transcript string, messages ...*ChatMessage) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, message := range messages {
+		_, err := tx.ExecContext(
+			ctx, "INSERT INTO chat_messages (session_id, role, body, created_at) VALUES ($1, $2, $3, $4)",
+			session.ID, message.Role, message.Body, message.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("error adding chat message: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE chat_sessions SET updated_at = $1 WHERE id = $2", time.Now(), session.ID); err != nil {
+		return fmt.Errorf("error updating chat session: %w", err)
+	}
+
+	if session.InteractionID != nil {
+		_, err := tx.ExecContext(ctx, "UPDATE interactions SET message = $1, updated_at = now() WHERE id = $2", transcript, *session.InteractionID)
+		if err != nil {
+			return fmt.Errorf("error updating chat transcript: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CaptureChatLead records interaction, the chat's transcript, against the
+// lead the visitor became and links both to the session, in one
+// transaction. It reports false when the session already has a lead.
+func (db *DB) CaptureChatLead(ctx context.Context, session *ChatSession, interaction *model.Interaction) (bool, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(
+		ctx, "UPDATE chat_sessions SET lead_id = $1, interaction_id = $2, updated_at = $3 WHERE id = $4 AND lead_id IS NULL",
+		interaction.Lead.ID, interaction.ID, time.Now(), session.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error linking chat session to lead: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if err = recordLeadContact(ctx, tx, interaction.Lead.ID, interaction.Timestamp); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	session.LeadID = &interaction.Lead.ID
+	session.InteractionID = &interaction.ID
+	return true, nil
+}
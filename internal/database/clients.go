@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// UpdateClient saves client if it is still at client.Version and returns
+// ErrVersionConflict otherwise.
+func (db *DB) UpdateClient(ctx context.Context, client *model.Client) (*model.Client, error) {
+	query := `UPDATE clients SET 
+              name = $1, industry = $2, website = $3, contact_person = $4, email = $5, 
+              phone = $6, address = $7, start_date = $8, status = $9, notes = $10, 
+              updated_at = $11, version = version + 1 
+              WHERE id = $12 AND (agency_id = $13 OR $13 IS NULL) AND deleted_at IS NULL AND version = $14 
+              RETURNING version`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, client.Name, client.Industry, client.Website, client.ContactPerson,
+		client.Email, client.Phone, client.Address, client.StartDate, client.Status,
+		client.Notes, client.UpdatedAt, client.ID, agencyID, client.Version,
+	).Scan(&client.Version)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVersionConflict
+		}
+		return nil, fmt.Errorf("error updating client: %w", err)
+	}
+
+	db.invalidate(ctx, clientCacheKey(client.ID))
+
+	return client, nil
+}
+
+// DeleteClient soft-deletes a client.
+func (db *DB) DeleteClient(ctx context.Context, id string) (bool, error) {
+	query := `UPDATE clients SET deleted_at = $1 
+              WHERE id = $2 AND (agency_id = $3 OR $3 IS NULL) AND deleted_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, time.Now(), id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting client: %w", err)
+	}
+
+	db.invalidate(ctx, clientCacheKey(id))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetClientServices replaces the services assigned to a client.
+func (db *DB) SetClientServices(ctx context.Context, clientID string, serviceIDs []string) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM client_service WHERE client_id = $1", clientID); err != nil {
+		return fmt.Errorf("error clearing client services: %w", err)
+	}
+
+	query := "INSERT INTO client_service (client_id, service_id) VALUES ($1, $2)"
+	for _, serviceID := range serviceIDs {
+		_, err = tx.ExecContext(ctx, query, clientID, serviceID)
+		if err != nil {
+			return fmt.Errorf("error assigning service to client: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveClient marks a client ARCHIVED, pauses its ACTIVE campaigns and
+// removes every agent from its campaigns in one transaction. Agents left
+// without another ACTIVE campaign are paused too. It returns false when the
+// client does not exist or is already archived.
+func (db *DB) ArchiveClient(ctx context.Context, id string) (bool, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `UPDATE clients SET status = $1, updated_at = $2, version = version + 1 
+              WHERE id = $3 AND status <> $1 AND (agency_id = $4 OR $4 IS NULL) AND deleted_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, query, model.ClientStatusArchived, now, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error archiving client: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	query = `UPDATE campaigns SET status = $1, updated_at = $2 
+             WHERE client_id = $3 AND status = $4`
+
+	_, err = tx.ExecContext(ctx, query, model.CampaignStatusPaused, now, id, model.CampaignStatusActive)
+	if err != nil {
+		return false, fmt.Errorf("error pausing client campaigns: %w", err)
+	}
+
+	query = `DELETE FROM campaign_ai_agent 
+             WHERE campaign_id IN (SELECT id FROM campaigns WHERE client_id = $1) 
+             RETURNING ai_agent_id`
+
+	unassigned, err := queryIDs(ctx, tx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("error unassigning client agents: %w", err)
+	}
+
+	query = `UPDATE ai_agents SET status = $1, updated_at = $2 
+             WHERE id = ANY($3) AND status = $4 
+             AND NOT EXISTS ( 
+                 SELECT 1 FROM campaign_ai_agent ca 
+                 JOIN campaigns c ON c.id = ca.campaign_id 
+                 WHERE ca.ai_agent_id = ai_agents.id AND c.status = $5 
+             ) 
+             RETURNING id`
+
+	pausedAgents, err := queryIDs(ctx, tx, query, model.AgentStatusPaused, now, pq.Array(unassigned),
+		model.AgentStatusActive, model.CampaignStatusActive)
+	if err != nil {
+		return false, fmt.Errorf("error pausing client agents: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	keys := []string{clientCacheKey(id)}
+	for _, agentID := range pausedAgents {
+		keys = append(keys, aiAgentCacheKey(agentID))
+	}
+	db.invalidate(ctx, keys...)
+
+	return true, nil
+}
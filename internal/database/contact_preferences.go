@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// GetLeadContactPreferences returns nil when the lead has none.
+func (db *DB) GetLeadContactPreferences(ctx context.Context, leadID string) (*model.ContactPreferences, error) {
+	query := `SELECT p.preferred_channel, p.quiet_hours_start, p.quiet_hours_end 
+              FROM lead_contact_preferences p JOIN leads l ON l.id = p.lead_id 
+              WHERE p.lead_id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var channel, start, end sql.NullString
+	err = db.conn.QueryRowContext(ctx, query, leadID, agencyID).Scan(&channel, &start, &end)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching lead contact preferences: %w", err)
+	}
+
+	prefs := &model.ContactPreferences{}
+	if channel.Valid {
+		preferred := model.Channel(channel.String)
+		prefs.PreferredChannel = &preferred
+	}
+	if start.Valid && end.Valid {
+		prefs.QuietHours = &model.QuietHours{Start: start.String, End: end.String}
+	}
+
+	return prefs, nil
+}
+
+// SetLeadContactPreferences replaces the lead's preferences. It reports
+// whether the lead exists.
+func (db *DB) SetLeadContactPreferences(ctx context.Context, leadID string, prefs *model.ContactPreferences) (bool, error) {
+	query := `INSERT INTO lead_contact_preferences (lead_id, preferred_channel, quiet_hours_start, quiet_hours_end, updated_at) 
+              SELECT id, $2, $3, $4, $5 FROM leads WHERE id = $1 AND (agency_id = $6 OR $6 IS NULL) 
+              ON CONFLICT (lead_id) DO UPDATE SET 
+              preferred_channel = EXCLUDED.preferred_channel, quiet_hours_start = EXCLUDED.quiet_hours_start, 
+              quiet_hours_end = EXCLUDED.quiet_hours_end, updated_at = EXCLUDED.updated_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var start, end *string
+	if prefs.QuietHours != nil {
+		start, end = &prefs.QuietHours.Start, &prefs.QuietHours.End
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, leadID, prefs.PreferredChannel, start, end, time.Now(), agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error saving lead contact preferences: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetCampaignFallbackRules returns the campaign's channel fallback rules in
+// the order they are tried.
+func (db *DB) GetCampaignFallbackRules(ctx context.Context, campaignID string) ([]*model.ChannelFallbackRule, error) {
+	query := "SELECT fallback_rules FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	if err := db.conn.QueryRowContext(ctx, query, campaignID, agencyID).Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching campaign fallback rules: %w", err)
+	}
+
+	rules := []*model.ChannelFallbackRule{}
+	if raw == nil {
+		return rules, nil
+	}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("error decoding campaign fallback rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SetCampaignFallbackRules replaces the campaign's fallback rules; an empty
+// list removes them. It reports whether the campaign exists.
+func (db *DB) SetCampaignFallbackRules(ctx context.Context, campaignID string, rules []*model.ChannelFallbackRule) (bool, error) {
+	query := `UPDATE campaigns SET fallback_rules = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var encoded []byte
+	if len(rules) > 0 {
+		if encoded, err = json.Marshal(rules); err != nil {
+			return false, fmt.Errorf("error encoding campaign fallback rules: %w", err)
+		}
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, encoded, time.Now(), campaignID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating campaign fallback rules: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// CountOutboundByStatus counts the messages sent to the lead on channel that
+// ended in status, such as BOUNCED.
+func (db *DB) CountOutboundByStatus(ctx context.Context, leadID string, channel model.Channel, status model.InteractionStatus) (int, error) {
+	query := `SELECT COUNT(*) FROM interactions 
+              WHERE lead_id = $1 AND channel = $2 AND status = $3 AND direction = $4`
+
+	var count int
+	err := db.conn.QueryRowContext(ctx, query, leadID, channel, status, model.InteractionDirectionOutbound).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting lead messages: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoExchangeRate is returned by totals in the agency's base currency when
+// an amount is in a currency without an exchange rate.
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+// toBaseCurrency converts amount, in currency, to base per the latest
+// exchange rates. It is NULL when either currency has no rate. Its SQL
+// joins exchange_rates as from_rate and base_rate.
+func toBaseCurrency(amount, currency, base string) string {
+	return `CASE WHEN ` + currency + ` = ` + base + ` THEN ` + amount +
+		` ELSE ` + amount + ` * base_rate.per_euro / from_rate.per_euro END`
+}
+
+// exchangeRateJoins joins the rates toBaseCurrency uses.
+func exchangeRateJoins(currency, base string) string {
+	return `LEFT JOIN exchange_rates from_rate ON from_rate.currency = ` + currency + ` 
+              LEFT JOIN exchange_rates base_rate ON base_rate.currency = ` + base + ` `
+}
+
+// campaignCurrency is the currency a new campaign is created in: the one
+// given, or else the base currency of the agency.
+func campaignCurrency(currency, agencyID string) string {
+	return `COALESCE(NULLIF(` + currency + `, ''), (SELECT base_currency FROM agencies WHERE id = ` + agencyID + `))`
+}
+
+// noExchangeRate returns ErrNoExchangeRate naming the currencies, or nil
+// when there are none.
+func noExchangeRate(currencies []string) error {
+	if len(currencies) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w for %s", ErrNoExchangeRate, strings.Join(currencies, ", "))
+}
+
+// GetBaseCurrency returns the currency the agency's totals are reported in.
+func (db *DB) GetBaseCurrency(ctx context.Context) (string, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var currency string
+	err = db.conn.QueryRowContext(ctx, "SELECT base_currency FROM agencies WHERE id = $1", agencyID).Scan(&currency)
+	if err != nil {
+		return "", fmt.Errorf("error fetching base currency: %w", err)
+	}
+
+	return currency, nil
+}
+
+// GetClientBaseCurrency returns the base currency of the client's agency,
+// for work that runs across agencies.
+func (db *DB) GetClientBaseCurrency(ctx context.Context, clientID string) (string, error) {
+	query := `SELECT a.base_currency FROM clients c JOIN agencies a ON a.id = c.agency_id 
+              WHERE c.id = $1 AND (c.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var currency string
+	if err := db.conn.QueryRowContext(ctx, query, clientID, agencyID).Scan(&currency); err != nil {
+		return "", fmt.Errorf("error fetching base currency: %w", err)
+	}
+
+	return currency, nil
+}
+
+func (db *DB) SetBaseCurrency(ctx context.Context, currency string) error {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE agencies SET base_currency = $1, updated_at = $2 WHERE id = $3"
+	if _, err := db.conn.ExecContext(ctx, query, currency, time.Now(), agencyID); err != nil {
+		return fmt.Errorf("error setting base currency: %w", err)
+	}
+
+	return nil
+}
+
+// GetExchangeRates returns the latest rates, as units of each currency one
+// euro buys, and the day the oldest of them is from.
+func (db *DB) GetExchangeRates(ctx context.Context) (map[string]float64, time.Time, error) {
+	rows, err := db.conn.QueryContext(ctx, "SELECT currency, per_euro, as_of FROM exchange_rates")
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error querying exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := map[string]float64{}
+	var oldest time.Time
+	for rows.Next() {
+		var currency string
+		var rate float64
+		var asOf time.Time
+		if err := rows.Scan(&currency, &rate, &asOf); err != nil {
+			return nil, time.Time{}, fmt.Errorf("error scanning exchange rate row: %w", err)
+		}
+		rates[currency] = rate
+		if oldest.IsZero() || asOf.Before(oldest) {
+			oldest = asOf
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("error iterating exchange rate rows: %w", err)
+	}
+
+	return rates, oldest, nil
+}
+
+// SaveExchangeRates replaces the rates of the given currencies with those
+// published on asOf. Currencies left out keep their last rate.
+func (db *DB) SaveExchangeRates(ctx context.Context, rates map[string]float64, asOf time.Time) error {
+	query := `INSERT INTO exchange_rates (currency, per_euro, as_of, updated_at) 
+              VALUES ($1, $2, $3, now()) 
+              ON CONFLICT (currency) DO UPDATE 
+              SET per_euro = EXCLUDED.per_euro, as_of = EXCLUDED.as_of, updated_at = EXCLUDED.updated_at`
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for currency, rate := range rates {
+		if _, err := tx.ExecContext(ctx, query, currency, rate, asOf); err != nil {
+			return fmt.Errorf("error saving exchange rate: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
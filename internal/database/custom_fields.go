@@ -0,0 +1,340 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+var ErrDuplicateCustomField = errors.New("a custom field with this key already exists")
+
+// CustomFieldValues are a lead's custom field values by definition key, as
+// decoded from JSON: strings, float64s and bools.
+type CustomFieldValues map[string]interface{}
+
+const customFieldColumns = `id, key, label, type, options, pattern, min, max, created_at, updated_at`
+
+func (db *DB) GetCustomFieldDefinitions(ctx context.Context) ([]*model.CustomFieldDefinition, error) {
+	query := `SELECT ` + customFieldColumns + ` FROM custom_field_definitions 
+              WHERE (agency_id = $1 OR $1 IS NULL) 
+              ORDER BY key, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryCustomFieldDefinitions(ctx, query, agencyID)
+}
+
+// GetLeadCustomFieldDefinitions returns the definitions of the lead's
+// agency, or none when the lead does not exist.
+func (db *DB) GetLeadCustomFieldDefinitions(ctx context.Context, leadID string) ([]*model.CustomFieldDefinition, error) {
+	query := `SELECT d.id, d.key, d.label, d.type, d.options, d.pattern, d.min, d.max, d.created_at, d.updated_at 
+              FROM custom_field_definitions d 
+              JOIN leads l ON l.agency_id = d.agency_id 
+              WHERE l.id = $1 AND (l.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY d.key`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryCustomFieldDefinitions(ctx, query, leadID, agencyID)
+}
+
+func (db *DB) GetCustomFieldDefinitionByID(ctx context.Context, id string) (*model.CustomFieldDefinition, error) {
+	query := `SELECT ` + customFieldColumns + ` FROM custom_field_definitions 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := scanCustomFieldDefinition(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching custom field: %w", err)
+	}
+
+	return def, nil
+}
+
+func (db *DB) queryCustomFieldDefinitions(ctx context.Context, query string, args ...interface{}) ([]*model.CustomFieldDefinition, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying custom fields: %w", err)
+	}
+	defer rows.Close()
+
+	defs := []*model.CustomFieldDefinition{}
+	for rows.Next() {
+		def, err := scanCustomFieldDefinition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning custom field row: %w", err)
+		}
+		defs = append(defs, def)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating custom field rows: %w", err)
+	}
+
+	return defs, nil
+}
+
+func (db *DB) CreateCustomFieldDefinition(ctx context.Context, def *model.CustomFieldDefinition) (*model.CustomFieldDefinition, error) {
+	query := `INSERT INTO custom_field_definitions (agency_id, key, label, type, options, pattern, min, max, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, def.Key, def.Label, def.Type, pq.Array(def.Options), def.Pattern, def.Min, def.Max, def.CreatedAt,
+	).Scan(&def.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateCustomField
+		}
+		return nil, fmt.Errorf("error creating custom field: %w", err)
+	}
+
+	return def, nil
+}
+
+// UpdateCustomFieldDefinition saves the definition's label and rules; its key
+// and type never change. Values already stored are not rechecked.
+func (db *DB) UpdateCustomFieldDefinition(ctx context.Context, def *model.CustomFieldDefinition) (bool, error) {
+	query := `UPDATE custom_field_definitions SET label = $1, options = $2, pattern = $3, min = $4, max = $5, updated_at = $6 
+              WHERE id = $7 AND (agency_id = $8 OR $8 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(
+		ctx, query, def.Label, pq.Array(def.Options), def.Pattern, def.Min, def.Max, def.UpdatedAt, def.ID, agencyID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error updating custom field: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteCustomFieldDefinition deletes the definition and the values stored
+// for it on the agency's leads.
+func (db *DB) DeleteCustomFieldDefinition(ctx context.Context, id string) (bool, error) {
+	query := `DELETE FROM custom_field_definitions 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) 
+              RETURNING agency_id, key`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var defAgencyID, key string
+	if err := tx.QueryRowContext(ctx, query, id, agencyID).Scan(&defAgencyID, &key); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error deleting custom field: %w", err)
+	}
+
+	ids, err := queryIDs(ctx, tx, `UPDATE leads SET custom_fields = custom_fields - $1::text 
+              WHERE agency_id = $2 AND custom_fields ->> $1::text IS NOT NULL 
+              RETURNING id`, key, defAgencyID)
+	if err != nil {
+		return false, fmt.Errorf("error removing custom field values: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	keys := make([]string, len(ids))
+	for i, leadID := range ids {
+		keys[i] = leadCacheKey(leadID)
+	}
+	db.invalidate(ctx, keys...)
+
+	return true, nil
+}
+
+func (db *DB) GetLeadCustomFields(ctx context.Context, leadID string) (CustomFieldValues, error) {
+	query := `SELECT custom_fields FROM leads WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	if err := db.conn.QueryRowContext(ctx, query, leadID, agencyID).Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching lead custom fields: %w", err)
+	}
+
+	return decodeCustomFields(raw)
+}
+
+// SetLeadCustomFields stores set on the lead, replacing values of the same
+// keys, and removes the values of the keys in remove. Values must already be
+// checked against their definitions. It reports whether the lead exists.
+func (db *DB) SetLeadCustomFields(ctx context.Context, leadID string, set CustomFieldValues, remove []string) (bool, error) {
+	query := `UPDATE leads SET custom_fields = (custom_fields || $1::jsonb) - $2::text[], 
+              updated_at = $3, version = version + 1 
+              WHERE id = $4 AND (agency_id = $5 OR $5 IS NULL) AND deleted_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if set == nil {
+		set = CustomFieldValues{}
+	}
+	values, err := json.Marshal(set)
+	if err != nil {
+		return false, fmt.Errorf("error encoding custom fields: %w", err)
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, values, pq.Array(remove), time.Now(), leadID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating lead custom fields: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(leadID))
+
+	return rowsAffected > 0, nil
+}
+
+func decodeCustomFields(raw []byte) (CustomFieldValues, error) {
+	values := CustomFieldValues{}
+	if len(raw) == 0 {
+		return values, nil
+	}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("error decoding custom fields: %w", err)
+	}
+	return values, nil
+}
+
+// customFieldMatches filters leads on one custom field. Values that parse as
+// numbers are compared numerically with NUMBER fields and as text with
+// others; ordering operators compare text, which suits DATE fields.
+func customFieldMatches(filter *model.CustomFieldFilterInput) (*predicate, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	key := filter.Key
+
+	switch filter.Op {
+	case model.CustomFieldOpExists:
+		return where("leads.custom_fields ->> ? IS NOT NULL", key), nil
+	case model.CustomFieldOpNotExists:
+		return where("leads.custom_fields ->> ? IS NULL", key), nil
+	}
+
+	if filter.Value == nil {
+		return nil, fmt.Errorf("customField %s needs a value", filter.Op)
+	}
+	value := strings.TrimSpace(*filter.Value)
+
+	var op string
+	switch filter.Op {
+	case model.CustomFieldOpContains:
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+		return where("leads.custom_fields ->> ? ILIKE '%' || ? || '%'", key, escaped), nil
+	case model.CustomFieldOpEq:
+		op = "="
+	case model.CustomFieldOpNeq:
+		op = "IS DISTINCT FROM"
+	case model.CustomFieldOpGt:
+		op = ">"
+	case model.CustomFieldOpGte:
+		op = ">="
+	case model.CustomFieldOpLt:
+		op = "<"
+	case model.CustomFieldOpLte:
+		op = "<="
+	default:
+		return nil, fmt.Errorf("invalid customField op %s", filter.Op)
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return where("leads.custom_fields ->> ? "+op+" ?", key, value), nil
+	}
+	return where(
+		"CASE WHEN jsonb_typeof(leads.custom_fields -> ?) = 'number' THEN (leads.custom_fields ->> ?)::numeric "+op+" ? "+
+			"ELSE leads.custom_fields ->> ? "+op+" ? END",
+		key, key, number, key, value,
+	), nil
+}
+
+func scanCustomFieldDefinition(row interface{ Scan(...interface{}) error }) (*model.CustomFieldDefinition, error) {
+	var def model.CustomFieldDefinition
+	var options []string
+	var pattern sql.NullString
+	var min, max sql.NullFloat64
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&def.ID, &def.Key, &def.Label, &def.Type, pq.Array(&options), &pattern, &min, &max, &def.CreatedAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	def.Options = options
+	def.Pattern = nullString(pattern)
+	if min.Valid {
+		def.Min = &min.Float64
+	}
+	if max.Valid {
+		def.Max = &max.Float64
+	}
+	if updatedAt.Valid {
+		def.UpdatedAt = &updatedAt.Time
+	}
+
+	return &def, nil
+}
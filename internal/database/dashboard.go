@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// sentMessageFilter matches outbound messages that actually went out, as
+// counted by campaignMetricsQuery.
+const sentMessageFilter = `i.direction = 'OUTBOUND' AND i.type <> 'MEETING' AND i.status NOT IN ` + unsentStatuses
+
+// ActivityCounts totals the agency's activity over a time range.
+type ActivityCounts struct {
+	NewLeads       int
+	MessagesSent   int
+	Replies        int
+	MeetingsBooked int
+	Conversions    int
+}
+
+// GetLeadStageCounts returns how many live leads are in each stage.
+func (db *DB) GetLeadStageCounts(ctx context.Context) ([]*model.StageCount, error) {
+	query := `SELECT status, COUNT(*) FROM leads 
+              WHERE deleted_at IS NULL AND (agency_id = $1 OR $1 IS NULL) 
+              GROUP BY status`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead stage counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*model.StageCount
+	for rows.Next() {
+		var count model.StageCount
+		if err := rows.Scan(&count.Status, &count.Count); err != nil {
+			return nil, fmt.Errorf("error scanning lead stage count row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead stage count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetFunnelReach takes the leads created in [start, end) and counts them by
+// the furthest of stages they have been in, now or in their status history.
+// Leads that were never in any of stages count toward the first.
+func (db *DB) GetFunnelReach(ctx context.Context, stages []model.LeadStatus, start, end time.Time) (map[model.LeadStatus]int, error) {
+	query := `SELECT COALESCE(GREATEST( 
+                  array_position($1::text[], l.status), 
+                  (SELECT MAX(array_position($1::text[], h.to_status)) FROM lead_status_history h WHERE h.lead_id = l.id) 
+              ), 1), COUNT(*) 
+              FROM leads l 
+              WHERE l.created_at >= $2 AND l.created_at < $3 AND l.deleted_at IS NULL 
+              AND (l.agency_id = $4 OR $4 IS NULL) 
+              GROUP BY 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(stages))
+	for i, stage := range stages {
+		names[i] = string(stage)
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, pq.Array(names), start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead funnel: %w", err)
+	}
+	defer rows.Close()
+
+	reach := make(map[model.LeadStatus]int)
+	for rows.Next() {
+		var position, count int
+		if err := rows.Scan(&position, &count); err != nil {
+			return nil, fmt.Errorf("error scanning lead funnel row: %w", err)
+		}
+		reach[stages[position-1]] += count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead funnel rows: %w", err)
+	}
+
+	return reach, nil
+}
+
+// GetMessagesByChannel counts the messages sent in [start, end) on each
+// channel, busiest first.
+func (db *DB) GetMessagesByChannel(ctx context.Context, start, end time.Time) ([]*model.ChannelCount, error) {
+	query := `SELECT i.channel, COUNT(*) 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE ` + sentMessageFilter + ` 
+              AND i.timestamp >= $1 AND i.timestamp < $2 AND (l.agency_id = $3 OR $3 IS NULL) 
+              GROUP BY i.channel 
+              ORDER BY COUNT(*) DESC, i.channel`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying messages by channel: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []*model.ChannelCount{}
+	for rows.Next() {
+		var count model.ChannelCount
+		if err := rows.Scan(&count.Channel, &count.Count); err != nil {
+			return nil, fmt.Errorf("error scanning channel count row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating channel count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetActivityCounts totals new leads, messages sent, replies, meetings and
+// leads won in [start, end).
+func (db *DB) GetActivityCounts(ctx context.Context, start, end time.Time) (*ActivityCounts, error) {
+	query := `SELECT 
+                  (SELECT COUNT(*) FROM leads l 
+                      WHERE l.created_at >= $1 AND l.created_at < $2 AND l.deleted_at IS NULL 
+                      AND (l.agency_id = $3 OR $3 IS NULL)), 
+                  COUNT(*) FILTER (WHERE ` + sentMessageFilter + `), 
+                  COUNT(*) FILTER (WHERE i.direction = 'INBOUND'), 
+                  COUNT(*) FILTER (WHERE i.type = 'MEETING'), 
+                  (SELECT COUNT(DISTINCT h.lead_id) FROM lead_status_history h JOIN leads l ON l.id = h.lead_id 
+                      WHERE h.to_status = 'WON' AND h.created_at >= $1 AND h.created_at < $2 
+                      AND (l.agency_id = $3 OR $3 IS NULL)) 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.timestamp >= $1 AND i.timestamp < $2 AND (l.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying activity counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts ActivityCounts
+	if rows.Next() {
+		err := rows.Scan(&counts.NewLeads, &counts.MessagesSent, &counts.Replies, &counts.MeetingsBooked, &counts.Conversions)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning activity counts: %w", err)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating activity counts: %w", err)
+	}
+
+	return &counts, nil
+}
+
+// CountActiveAIAgents returns how many of the agency's AI agents are active.
+func (db *DB) CountActiveAIAgents(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM ai_agents WHERE status = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, model.AgentStatusActive, agencyID)
+	if err != nil {
+		return 0, fmt.Errorf("error counting active AI agents: %w", err)
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("error scanning active AI agent count: %w", err)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating active AI agent count: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetTopCampaigns ranks campaigns by the leads they won and then the replies
+// they drew from interactions in [start, end). Attribution follows
+// campaignMetricsQuery.
+func (db *DB) GetTopCampaigns(ctx context.Context, start, end time.Time, limit int) ([]*model.TopCampaign, error) {
+	query := `WITH campaign_interactions AS ( 
+                  SELECT DISTINCT c.id AS campaign_id, i.id, i.lead_id, i.type, i.status, i.direction 
+                  FROM campaigns c 
+                  JOIN interactions i ON i.timestamp >= c.start_date 
+                      AND (c.end_date IS NULL OR i.timestamp <= c.end_date) 
+                  WHERE (c.agency_id = $3 OR $3 IS NULL) 
+                  AND i.timestamp >= $1 AND i.timestamp < $2 
+                  AND (i.template_id IN (SELECT id FROM message_templates WHERE campaign_id = c.id) 
+                      OR i.ai_agent_id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = c.id)) 
+              ) 
+              SELECT ci.campaign_id, 
+                  COUNT(DISTINCT ci.lead_id), 
+                  COUNT(ci.id) FILTER (WHERE ci.direction = 'OUTBOUND' AND ci.type <> 'MEETING' 
+                      AND ci.status NOT IN ` + unsentStatuses + `), 
+                  COUNT(ci.id) FILTER (WHERE ci.status = 'RESPONDED'), 
+                  COUNT(ci.id) FILTER (WHERE ci.type = 'MEETING'), 
+                  COUNT(DISTINCT l.id) FILTER (WHERE l.status = 'WON') 
+              FROM campaign_interactions ci 
+              JOIN leads l ON l.id = ci.lead_id 
+              GROUP BY ci.campaign_id 
+              ORDER BY 6 DESC, 4 DESC, ci.campaign_id 
+              LIMIT $4`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, start, end, agencyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying top campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	campaigns := []*model.TopCampaign{}
+	for rows.Next() {
+		var campaign model.TopCampaign
+
+		err := rows.Scan(
+			&campaign.CampaignID, &campaign.LeadsContacted, &campaign.MessagesSent, &campaign.Replies,
+			&campaign.MeetingsBooked, &campaign.Conversions,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning top campaign row: %w", err)
+		}
+
+		campaigns = append(campaigns, &campaign)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating top campaign rows: %w", err)
+	}
+
+	return campaigns, nil
+}
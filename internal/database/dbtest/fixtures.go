@@ -0,0 +1,125 @@
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/tenant"
+)
+
+// Agency creates an agency and returns a context scoped to it, which the
+// fixture builders below create their rows in.
+func (db *DB) Agency() (context.Context, string) {
+	db.t.Helper()
+
+	var agencyID string
+	err := db.SQL.QueryRow("INSERT INTO agencies (name) VALUES ($1) RETURNING id", db.name("Agency")).Scan(&agencyID)
+	if err != nil {
+		db.t.Fatalf("error creating agency: %v", err)
+	}
+	return tenant.WithAgency(context.Background(), agencyID), agencyID
+}
+
+// Client creates an active client with made-up details, after applying opts
+// to it.
+func (db *DB) Client(ctx context.Context, opts ...func(*model.Client)) *model.Client {
+	db.t.Helper()
+
+	name := db.name("Client")
+	client := &model.Client{
+		Name:          name,
+		Industry:      "Software",
+		ContactPerson: "Contact of " + name,
+		Email:         db.email("client"),
+		StartDate:     time.Now().Truncate(24 * time.Hour),
+		Status:        model.ClientStatusActive,
+		CreatedAt:     time.Now(),
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	created, err := db.CreateClient(ctx, client)
+	if err != nil {
+		db.t.Fatalf("error creating client fixture: %v", err)
+	}
+	return created
+}
+
+// Campaign creates an active campaign, after applying opts to it. Use
+// ForClient to give it a client.
+func (db *DB) Campaign(ctx context.Context, opts ...func(*model.Campaign)) *model.Campaign {
+	db.t.Helper()
+
+	campaign := &model.Campaign{
+		Name:      db.name("Campaign"),
+		StartDate: time.Now().Add(-24 * time.Hour),
+		Status:    model.CampaignStatusActive,
+		CreatedAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(campaign)
+	}
+
+	created, err := db.CreateCampaign(ctx, campaign)
+	if err != nil {
+		db.t.Fatalf("error creating campaign fixture: %v", err)
+	}
+	return created
+}
+
+// ForClient makes a campaign fixture the client's.
+func ForClient(client *model.Client) func(*model.Campaign) {
+	return func(c *model.Campaign) {
+		c.ClientID = &client.ID
+	}
+}
+
+// Lead creates a new lead with a unique email address, after applying opts
+// to it.
+func (db *DB) Lead(ctx context.Context, opts ...func(*model.Lead)) *model.Lead {
+	db.t.Helper()
+
+	lead := &model.Lead{
+		Name:        db.name("Lead"),
+		Email:       db.email("lead"),
+		Status:      model.LeadStatusNew,
+		IntentScore: 0.5,
+		CreatedAt:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(lead)
+	}
+
+	created, err := db.CreateLead(ctx, lead)
+	if err != nil {
+		db.t.Fatalf("error creating lead fixture: %v", err)
+	}
+	return created
+}
+
+// Enroll adds leads to a campaign.
+func (db *DB) Enroll(ctx context.Context, campaign *model.Campaign, leads ...*model.Lead) {
+	db.t.Helper()
+
+	leadIDs := make([]string, len(leads))
+	for i, lead := range leads {
+		leadIDs[i] = lead.ID
+	}
+	if _, err := db.EnrollCampaignLeads(ctx, campaign.ID, leadIDs, nil); err != nil {
+		db.t.Fatalf("error enrolling campaign lead fixtures: %v", err)
+	}
+}
+
+// name numbers fixture names so each one is unique within the test.
+func (db *DB) name(kind string) string {
+	db.n++
+	return fmt.Sprintf("%s %d", kind, db.n)
+}
+
+func (db *DB) email(kind string) string {
+	db.n++
+	return fmt.Sprintf("%s-%d@example.com", kind, db.n)
+}
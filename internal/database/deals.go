@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const dealColumns = `id, name, lead_id, client_id, campaign_id, value, currency, stage, 
+              expected_close_date, closed_at, created_by, created_at, updated_at`
+
+// openDealStages are the stages counted as pipeline.
+var openDealStages = []model.DealStage{
+	model.DealStageProspecting, model.DealStageQualified, model.DealStageProposal, model.DealStageNegotiation,
+}
+
+func (db *DB) GetDealByID(ctx context.Context, id string) (*model.Deal, error) {
+	query := `SELECT ` + dealColumns + ` FROM deals WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deal, err := scanDeal(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching deal: %w", err)
+	}
+
+	return deal, nil
+}
+
+// GetDeals returns the deals matching filter, most recently created first.
+func (db *DB) GetDeals(ctx context.Context, filter *model.DealFilterInput, limit, offset *int) ([]*model.Deal, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := selectFrom(`SELECT `+dealColumns+` FROM deals`, inTenant("agency_id", agencyID))
+	if filter != nil {
+		q = q.and(
+			oneOf("stage", filter.Stage),
+			equals("lead_id", filter.LeadID),
+			equals("client_id", filter.ClientID),
+			equals("campaign_id", filter.CampaignID),
+			atLeast("expected_close_date", filter.ExpectedCloseAfter),
+			atMost("expected_close_date", filter.ExpectedCloseBefore),
+		)
+	}
+	query, args := q.orderBy("created_at DESC, id").page(limit, offset).build()
+
+	rows, err := db.queryReplica(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying deals: %w", err)
+	}
+	defer rows.Close()
+
+	deals := []*model.Deal{}
+	for rows.Next() {
+		deal, err := scanDeal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning deal row: %w", err)
+		}
+		deals = append(deals, deal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deal rows: %w", err)
+	}
+
+	return deals, nil
+}
+
+func (db *DB) CreateDeal(ctx context.Context, deal *model.Deal) (*model.Deal, error) {
+	query := `INSERT INTO deals (agency_id, name, lead_id, client_id, campaign_id, value, currency, stage, 
+              expected_close_date, closed_at, created_by, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, deal.Name, deal.LeadID, deal.ClientID, deal.CampaignID, deal.Value, deal.Currency,
+		deal.Stage, deal.ExpectedCloseDate, deal.ClosedAt, deal.CreatedByID, deal.CreatedAt,
+	).Scan(&deal.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating deal: %w", err)
+	}
+
+	return deal, nil
+}
+
+// UpdateDeal saves every field of the deal but its creator. It returns nil
+// when the deal does not exist.
+func (db *DB) UpdateDeal(ctx context.Context, deal *model.Deal) (*model.Deal, error) {
+	query := `UPDATE deals SET name = $1, lead_id = $2, client_id = $3, campaign_id = $4, value = $5, 
+              currency = $6, stage = $7, expected_close_date = $8, closed_at = $9, updated_at = $10 
+              WHERE id = $11 AND (agency_id = $12 OR $12 IS NULL) 
+              RETURNING ` + dealColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanDeal(db.conn.QueryRowContext(
+		ctx, query, deal.Name, deal.LeadID, deal.ClientID, deal.CampaignID, deal.Value, deal.Currency,
+		deal.Stage, deal.ExpectedCloseDate, deal.ClosedAt, deal.UpdatedAt, deal.ID, agencyID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error updating deal: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (db *DB) DeleteDeal(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM deals WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting deal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetClientPipelineValue totals the client's open deals in each currency.
+func (db *DB) GetClientPipelineValue(ctx context.Context, clientID string) ([]*model.MoneyTotal, error) {
+	return db.dealTotals(ctx, "client_id", clientID, openDealStages)
+}
+
+// GetCampaignRevenue totals the won deals attributed to the campaign in each
+// currency.
+func (db *DB) GetCampaignRevenue(ctx context.Context, campaignID string) ([]*model.MoneyTotal, error) {
+	return db.dealTotals(ctx, "campaign_id", campaignID, []model.DealStage{model.DealStageWon})
+}
+
+func (db *DB) dealTotals(ctx context.Context, column, id string, stages []model.DealStage) ([]*model.MoneyTotal, error) {
+	query := `SELECT currency, SUM(value) FROM deals 
+              WHERE ` + column + ` = $1 AND stage = ANY($2) AND (agency_id = $3 OR $3 IS NULL) 
+              GROUP BY currency ORDER BY currency`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(stages))
+	for i, stage := range stages {
+		names[i] = string(stage)
+	}
+
+	rows, err := db.queryReplica(ctx, query, id, pq.Array(names), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error totalling deals: %w", err)
+	}
+	defer rows.Close()
+
+	totals := []*model.MoneyTotal{}
+	for rows.Next() {
+		var total model.MoneyTotal
+		if err := rows.Scan(&total.Currency, &total.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning deal total row: %w", err)
+		}
+		totals = append(totals, &total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deal total rows: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetClientPipelineTotal totals the client's open deals in the agency's base
+// currency. It returns nil when the client does not exist.
+func (db *DB) GetClientPipelineTotal(ctx context.Context, clientID string) (*model.Money, error) {
+	return db.dealTotal(ctx, "clients", "client_id", clientID, openDealStages)
+}
+
+// GetCampaignRevenueTotal totals the won deals attributed to the campaign in
+// the agency's base currency. It returns nil when the campaign does not
+// exist.
+func (db *DB) GetCampaignRevenueTotal(ctx context.Context, campaignID string) (*model.Money, error) {
+	return db.dealTotal(ctx, "campaigns", "campaign_id", campaignID, []model.DealStage{model.DealStageWon})
+}
+
+// dealTotal converts the deals of a row of table, which column of deals
+// refers to, to the agency's base currency and adds them up.
+func (db *DB) dealTotal(ctx context.Context, table, column, id string, stages []model.DealStage) (*model.Money, error) {
+	query := `SELECT a.base_currency, COALESCE(SUM(` + toBaseCurrency("d.value", "d.currency", "a.base_currency") + `), 0), 
+                  COALESCE(array_agg(DISTINCT d.currency) FILTER (WHERE d.currency <> a.base_currency 
+                      AND (from_rate.per_euro IS NULL OR base_rate.per_euro IS NULL)), '{}') 
+              FROM ` + table + ` o 
+              JOIN agencies a ON a.id = o.agency_id 
+              LEFT JOIN deals d ON d.` + column + ` = o.id AND d.stage = ANY($2) 
+              ` + exchangeRateJoins("d.currency", "a.base_currency") + ` 
+              WHERE o.id = $1 AND (o.agency_id = $3 OR $3 IS NULL) 
+              GROUP BY a.base_currency`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(stages))
+	for i, stage := range stages {
+		names[i] = string(stage)
+	}
+
+	var currency string
+	var amount float64
+	var missing []string
+	err = db.conn.QueryRowContext(ctx, query, id, pq.Array(names), agencyID).Scan(&currency, &amount, pq.Array(&missing))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error totalling deals: %w", err)
+	}
+	if err := noExchangeRate(missing); err != nil {
+		return nil, err
+	}
+
+	total := model.NewMoney(amount, currency)
+	return &total, nil
+}
+
+func scanDeal(row interface{ Scan(...interface{}) error }) (*model.Deal, error) {
+	var deal model.Deal
+	var leadID, clientID, campaignID, createdBy sql.NullString
+	var expectedCloseDate, closedAt, updatedAt sql.NullTime
+
+	err := row.Scan(
+		&deal.ID, &deal.Name, &leadID, &clientID, &campaignID, &deal.Value, &deal.Currency, &deal.Stage,
+		&expectedCloseDate, &closedAt, &createdBy, &deal.CreatedAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deal.LeadID = nullString(leadID)
+	deal.ClientID = nullString(clientID)
+	deal.CampaignID = nullString(campaignID)
+	deal.CreatedByID = nullString(createdBy)
+	if expectedCloseDate.Valid {
+		deal.ExpectedCloseDate = &expectedCloseDate.Time
+	}
+	if closedAt.Valid {
+		deal.ClosedAt = &closedAt.Time
+	}
+	if updatedAt.Valid {
+		deal.UpdatedAt = &updatedAt.Time
+	}
+
+	return &deal, nil
+}
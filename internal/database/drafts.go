@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// Draft is a message written by an agent in dry-run mode, stored as a
+// PENDING_REVIEW interaction until someone approves or rejects it.
+type Draft struct {
+	Interaction *model.Interaction
+	Subject     string
+}
+
+// CreateDraftInteraction records a PENDING_REVIEW interaction for a message
+// that is sent only once approved. As with QueueInteraction, the lead's
+// last_contact is left alone until the message goes out.
+func (db *DB) CreateDraftInteraction(ctx context.Context, interaction *model.Interaction, subject string) (*model.Interaction, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO interaction_drafts (interaction_id, subject) VALUES ($1, $2)", interaction.ID, subject)
+	if err != nil {
+		return nil, fmt.Errorf("error creating draft: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return interaction, nil
+}
+
+// GetDraft returns the draft stored with an interaction, or nil when the
+// interaction does not exist or was not drafted for review. Approved and
+// rejected drafts are returned too; check the interaction's status.
+func (db *DB) GetDraft(ctx context.Context, interactionID string) (*Draft, error) {
+	subject, err := db.GetDraftSubject(ctx, interactionID)
+	if err != nil || subject == nil {
+		return nil, err
+	}
+
+	interaction, err := db.GetInteractionByID(ctx, interactionID)
+	if err != nil || interaction == nil {
+		return nil, err
+	}
+
+	return &Draft{Interaction: interaction, Subject: *subject}, nil
+}
+
+// GetDraftSubject returns the subject of a drafted interaction, or nil when
+// the interaction was not drafted for review.
+func (db *DB) GetDraftSubject(ctx context.Context, interactionID string) (*string, error) {
+	query := `SELECT d.subject FROM interaction_drafts d 
+              JOIN interactions i ON i.id = d.interaction_id JOIN leads l ON l.id = i.lead_id 
+              WHERE d.interaction_id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject string
+	if err := db.conn.QueryRowContext(ctx, query, interactionID, agencyID).Scan(&subject); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching draft: %w", err)
+	}
+
+	return &subject, nil
+}
+
+// ReviewDraft moves a PENDING_REVIEW interaction to status and records who
+// reviewed it; notes, when set, replace the interaction's notes. It reports
+// false when the interaction was not awaiting review, so two reviewers
+// cannot both approve a draft.
+func (db *DB) ReviewDraft(ctx context.Context, interactionID string, status model.InteractionStatus, reviewedBy, notes *string) (bool, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE interactions i SET status = $1, notes = COALESCE($2, i.notes), updated_at = now() 
+              FROM leads l 
+              WHERE i.id = $3 AND i.status = $4 AND l.id = i.lead_id AND (l.agency_id = $5 OR $5 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, status, notes, interactionID, model.InteractionStatusPendingReview, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error reviewing draft: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(
+		ctx, "UPDATE interaction_drafts SET reviewed_by = $1, reviewed_at = $2 WHERE interaction_id = $3",
+		reviewedBy, time.Now(), interactionID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error recording draft review: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// CompleteDraft records the outcome of sending an approved draft.
+func (db *DB) CompleteDraft(ctx context.Context, interaction *model.Interaction) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := completeInteraction(ctx, tx, interaction); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	return nil
+}
+
+// GetLeadsAwaitingOutreach returns up to limit NEW leads assigned to the
+// agent that have never been messaged, oldest assignment first. Drafts count
+// as messages, including rejected ones, so a lead is drafted at most once.
+// Leads handed off to a sales rep are left to the rep.
+func (db *DB) GetLeadsAwaitingOutreach(ctx context.Context, aiAgentID string, limit int) ([]string, error) {
+	query := `SELECT l.id FROM leads l 
+              JOIN lead_ai_agent laa ON laa.lead_id = l.id 
+              WHERE laa.ai_agent_id = $1 AND l.status = $2 AND l.deleted_at IS NULL 
+              AND (l.agency_id = $3 OR $3 IS NULL) 
+              AND NOT EXISTS (SELECT 1 FROM interactions i WHERE i.lead_id = l.id AND i.direction = $4) 
+              AND NOT EXISTS (SELECT 1 FROM lead_handoffs h WHERE h.lead_id = l.id AND h.resolved_at IS NULL) 
+              ORDER BY laa.assigned_at LIMIT $5`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, aiAgentID, model.LeadStatusNew, agencyID, model.InteractionDirectionOutbound, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying leads awaiting outreach: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning lead row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead rows: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// GetRecentTemplateSend returns the latest outbound message from the
+// template to the lead on channel that was sent at or after since, or is
+// queued to be sent, or nil when there is none. Messages that failed or
+// never left review do not count.
+func (db *DB) GetRecentTemplateSend(ctx context.Context, leadID, templateID string, channel model.Channel, since time.Time) (*model.Interaction, error) {
+	query := `SELECT i.id, i.status, i.timestamp, i.created_at 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.lead_id = $1 AND i.template_id = $2 AND i.channel = $3 AND i.direction = $4 
+              AND i.status = ANY($5) AND i.timestamp >= $6 AND (l.agency_id = $7 OR $7 IS NULL) 
+              ORDER BY i.timestamp DESC LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := pq.Array([]string{
+		string(model.InteractionStatusScheduled), string(model.InteractionStatusQueued),
+		string(model.InteractionStatusSent), string(model.InteractionStatusDelivered),
+		string(model.InteractionStatusOpened), string(model.InteractionStatusResponded),
+		string(model.InteractionStatusBounced),
+	})
+
+	interaction := model.Interaction{Lead: &model.Lead{ID: leadID}, Channel: channel, Direction: model.InteractionDirectionOutbound}
+	err = db.conn.QueryRowContext(
+		ctx, query, leadID, templateID, channel, model.InteractionDirectionOutbound, sent, since, agencyID,
+	).Scan(&interaction.ID, &interaction.Status, &interaction.Timestamp, &interaction.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching recent template send: %w", err)
+	}
+
+	return &interaction, nil
+}
+
+// CreateSuppressedInteraction records a message that was not sent, keeping
+// its subject. The lead's last_contact is left alone.
+func (db *DB) CreateSuppressedInteraction(ctx context.Context, interaction *model.Interaction, subject string) (*model.Interaction, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+	if err := setInteractionSubject(ctx, tx, interaction.ID, subject); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return interaction, nil
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// EmailEventCounts counts the opens and clicks of one email.
+type EmailEventCounts struct {
+	Opens  int
+	Clicks int
+}
+
+// EmailStats counts an agent's outbound emails and what became of them.
+// Opened counts emails opened or clicked at least once.
+type EmailStats struct {
+	Sent    int
+	Opened  int
+	Clicked int
+	Bounced int
+}
+
+func (db *DB) RecordEmailEvent(ctx context.Context, interactionID string, eventType model.EmailEventType, url, detail *string) error {
+	query := `INSERT INTO email_events (interaction_id, type, url, detail) VALUES ($1, $2, $3, $4)`
+
+	if _, err := db.conn.ExecContext(ctx, query, interactionID, eventType, url, detail); err != nil {
+		return fmt.Errorf("error recording email event: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetEmailEvents(ctx context.Context, interactionID string) ([]*model.EmailEvent, error) {
+	query := `SELECT id, type, url, detail, created_at FROM email_events 
+              WHERE interaction_id = $1 ORDER BY created_at`
+
+	rows, err := db.conn.QueryContext(ctx, query, interactionID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying email events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*model.EmailEvent{}
+	for rows.Next() {
+		var event model.EmailEvent
+		var url, detail sql.NullString
+		if err := rows.Scan(&event.ID, &event.Type, &url, &detail, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning email event row: %w", err)
+		}
+		event.URL = nullString(url)
+		event.Detail = nullString(detail)
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating email event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (db *DB) CountEmailEvents(ctx context.Context, interactionID string) (*EmailEventCounts, error) {
+	query := `SELECT COUNT(*) FILTER (WHERE type = $2), COUNT(*) FILTER (WHERE type = $3) 
+              FROM email_events WHERE interaction_id = $1`
+
+	var counts EmailEventCounts
+	err := db.conn.QueryRowContext(ctx, query, interactionID, model.EmailEventTypeOpen, model.EmailEventTypeClick).Scan(&counts.Opens, &counts.Clicks)
+	if err != nil {
+		return nil, fmt.Errorf("error counting email events: %w", err)
+	}
+
+	return &counts, nil
+}
+
+// GetAgentEmailStats counts the emails attributed to the agent that reached
+// the provider, and how many of them were opened, clicked or bounced.
+func (db *DB) GetAgentEmailStats(ctx context.Context, aiAgentID string) (*EmailStats, error) {
+	query := `SELECT COUNT(*), 
+              COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM email_events e WHERE e.interaction_id = i.id AND e.type = ANY($4))), 
+              COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM email_events e WHERE e.interaction_id = i.id AND e.type = $5)), 
+              COUNT(*) FILTER (WHERE i.status = $6) 
+              FROM interactions i 
+              WHERE i.ai_agent_id = $1 AND i.channel = $2 AND i.direction = $3 AND i.status NOT IN ` + unsentStatuses
+
+	opened := pq.Array([]string{string(model.EmailEventTypeOpen), string(model.EmailEventTypeClick)})
+
+	var stats EmailStats
+	err := db.conn.QueryRowContext(
+		ctx, query, aiAgentID, model.ChannelEmail, model.InteractionDirectionOutbound, opened,
+		model.EmailEventTypeClick, model.InteractionStatusBounced,
+	).Scan(&stats.Sent, &stats.Opened, &stats.Clicked, &stats.Bounced)
+	if err != nil {
+		return nil, fmt.Errorf("error counting agent emails: %w", err)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// exportBatchSize is the number of leads read per query when exporting.
+const exportBatchSize = 1000
+
+// EachLeadByFilter calls fn for every lead matching filter, newest first.
+// Leads are read in batches, each continuing after the last lead of the
+// previous one, so large exports are never held in memory. Iteration stops
+// at the first error fn returns. fn also receives the lead's custom fields.
+func (db *DB) EachLeadByFilter(ctx context.Context, filter *model.LeadFilterInput, fn func(*model.Lead, CustomFieldValues) error) error {
+	q, err := leadFilterQuery(ctx, filter, "custom_fields")
+	if err != nil {
+		return err
+	}
+	batchSize := exportBatchSize
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	for {
+		batch := q
+		if afterCreatedAt != nil {
+			batch = batch.and(where("(created_at, id) < (?, ?)", *afterCreatedAt, afterID))
+		}
+		batchQuery, batchArgs := batch.orderBy("created_at DESC, id DESC").page(&batchSize, nil).build()
+
+		leads, customFields, err := db.queryLeadBatch(ctx, batchQuery, batchArgs)
+		if err != nil {
+			return err
+		}
+
+		for i, lead := range leads {
+			if err := fn(lead, customFields[i]); err != nil {
+				return err
+			}
+		}
+		if len(leads) < exportBatchSize {
+			return nil
+		}
+
+		last := leads[len(leads)-1]
+		afterCreatedAt, afterID = &last.CreatedAt, last.ID
+	}
+}
+
+func (db *DB) queryLeadBatch(ctx context.Context, query string, args []interface{}) ([]*model.Lead, []CustomFieldValues, error) {
+	rows, err := db.queryReplica(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error querying leads: %w", err)
+	}
+	defer rows.Close()
+
+	leads := make([]*model.Lead, 0, exportBatchSize)
+	customFields := make([]CustomFieldValues, 0, exportBatchSize)
+	for rows.Next() {
+		var raw []byte
+		lead, err := scanFilteredLead(rows, &raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		values, err := decodeCustomFields(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		leads = append(leads, lead)
+		customFields = append(customFields, values)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating lead rows: %w", err)
+	}
+
+	return leads, customFields, nil
+}
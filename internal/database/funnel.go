@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// FunnelCohort is how a group of leads moved through the funnel stages.
+type FunnelCohort struct {
+	// Key is the cohort's source or campaign ID; nil for the leads without
+	// one, and for the single cohort of an ungrouped funnel.
+	Key *string
+	// Reach counts leads by the furthest stage they have been in.
+	Reach map[model.LeadStatus]int
+	// MedianDays is the median time, in days, leads spent in each stage
+	// before moving on. Stages no lead has left yet are missing.
+	MedianDays map[model.LeadStatus]float64
+}
+
+// funnelCohortKey is the cohort key of a filtered lead f, and the join it
+// needs.
+type funnelCohortKey struct{ key, join string }
+
+var funnelCohorts = map[model.FunnelCohortBy]funnelCohortKey{
+	model.FunnelCohortBySource:   {key: "f.source"},
+	model.FunnelCohortByCampaign: {key: "cl.campaign_id::text", join: " LEFT JOIN campaign_leads cl ON cl.lead_id = f.id"},
+}
+
+// GetConversionFunnel follows the leads matching filter and created in
+// [start, end) through stages, grouped by cohortBy or as one cohort when it
+// is nil. A lead's stays run from its creation, in the status it was created
+// with, and from each change in its status history; each lasts until the
+// next change, which the LEAD window gives. Reach counts leads by the
+// furthest of stages any stay was in, leads never in one counting toward
+// the first. Leads enrolled in several campaigns belong to each campaign's
+// cohort.
+func (db *DB) GetConversionFunnel(ctx context.Context, filter *model.LeadFilterInput, stages []model.LeadStatus, start, end time.Time, cohortBy *model.FunnelCohortBy) ([]*FunnelCohort, error) {
+	q, err := leadFilterQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	leads, args := q.and(atLeast("created_at", &start), where("created_at < ?", end)).build()
+
+	cohort := funnelCohortKey{key: "NULL::text"}
+	if cohortBy != nil {
+		var ok bool
+		if cohort, ok = funnelCohorts[*cohortBy]; !ok {
+			return nil, fmt.Errorf("invalid funnel cohort %s", *cohortBy)
+		}
+	}
+
+	names := make([]string, len(stages))
+	for i, stage := range stages {
+		names[i] = string(stage)
+	}
+	args = append(args, pq.Array(names))
+	stagesArg := fmt.Sprintf("$%d::text[]", len(args))
+
+	query := `WITH cohort_leads AS ( 
+                  SELECT f.id, f.status, f.created_at, ` + cohort.key + ` AS cohort 
+                  FROM (` + leads + `) AS f` + cohort.join + ` 
+              ), 
+              stays AS ( 
+                  SELECT s.id, s.cohort, s.status, 
+                      EXTRACT(EPOCH FROM LEAD(s.entered_at) OVER (PARTITION BY s.id, s.cohort ORDER BY s.entered_at) - s.entered_at) / 86400 AS days 
+                  FROM ( 
+                      SELECT c.id, c.cohort, COALESCE((SELECT h.from_status FROM lead_status_history h 
+                          WHERE h.lead_id = c.id ORDER BY h.created_at LIMIT 1), c.status) AS status, c.created_at AS entered_at 
+                      FROM cohort_leads c 
+                      UNION ALL 
+                      SELECT c.id, c.cohort, h.to_status, h.created_at 
+                      FROM cohort_leads c JOIN lead_status_history h ON h.lead_id = c.id 
+                  ) AS s 
+              ), 
+              reach AS ( 
+                  SELECT id, cohort, COALESCE(MAX(array_position(` + stagesArg + `, status)), 1) AS position 
+                  FROM stays GROUP BY id, cohort 
+              ) 
+              SELECT cohort, position, COUNT(*), NULL::float8 FROM reach GROUP BY cohort, position 
+              UNION ALL 
+              SELECT cohort, array_position(` + stagesArg + `, status), 0, 
+                  percentile_cont(0.5) WITHIN GROUP (ORDER BY days) 
+              FROM stays 
+              WHERE days IS NOT NULL AND array_position(` + stagesArg + `, status) IS NOT NULL 
+              GROUP BY cohort, status`
+
+	rows, err := db.queryAnalytics(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying conversion funnel: %w", err)
+	}
+	defer rows.Close()
+
+	var cohorts []*FunnelCohort
+	byKey := make(map[string]*FunnelCohort)
+	for rows.Next() {
+		var key sql.NullString
+		var position, count int
+		var median sql.NullFloat64
+		if err := rows.Scan(&key, &position, &count, &median); err != nil {
+			return nil, fmt.Errorf("error scanning conversion funnel row: %w", err)
+		}
+
+		// PostgreSQL text cannot hold NUL, so it stands for the NULL key.
+		mapKey := "\x00"
+		if key.Valid {
+			mapKey = key.String
+		}
+		c, ok := byKey[mapKey]
+		if !ok {
+			c = &FunnelCohort{Key: nullString(key), Reach: make(map[model.LeadStatus]int), MedianDays: make(map[model.LeadStatus]float64)}
+			byKey[mapKey] = c
+			cohorts = append(cohorts, c)
+		}
+
+		// Median rows carry the median; reach rows leave it NULL.
+		if median.Valid {
+			c.MedianDays[stages[position-1]] = median.Float64
+		} else {
+			c.Reach[stages[position-1]] += count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversion funnel rows: %w", err)
+	}
+
+	return cohorts, nil
+}
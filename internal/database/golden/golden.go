@@ -0,0 +1,95 @@
+// Package golden compares what a test produced, such as the SQL a query
+// builder wrote, with a file kept under the test package's testdata
+// directory. Run the tests with -update to rewrite the files from what they
+// produce now, then review the diff.
+package golden
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from test output")
+
+// Assert fails t unless got matches testdata/<name>.golden.
+func Assert(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("error creating golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("error writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s does not exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("error reading golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// Query fails t unless the query and its arguments match
+// testdata/<name>.golden. The file holds the query, then one line per
+// argument:
+//
+//	SELECT ... WHERE l.status = $1 LIMIT $2
+//
+//	$1 = "NEW"
+//	$2 = 20
+func Query(t testing.TB, name, query string, args []interface{}) {
+	t.Helper()
+	Assert(t, name, []byte(FormatQuery(query, args)))
+}
+
+// FormatQuery writes a query and its arguments the way Query compares them.
+// Arguments that are driver.Valuers, such as pq arrays, are written as the
+// value sent to the server.
+func FormatQuery(query string, args []interface{}) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(query))
+	b.WriteString("\n")
+	if len(args) > 0 {
+		b.WriteString("\n")
+	}
+	for i, arg := range args {
+		fmt.Fprintf(&b, "$%d = %s\n", i+1, formatArg(arg))
+	}
+	return b.String()
+}
+
+func formatArg(arg interface{}) string {
+	if v, ok := arg.(driver.Valuer); ok {
+		value, err := v.Value()
+		if err != nil {
+			return fmt.Sprintf("<error: %v>", err)
+		}
+		arg = value
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IntegrationHook is an instant trigger subscription: events of its kind
+// are posted to TargetURL.
+type IntegrationHook struct {
+	ID        string
+	AgencyID  string
+	APIKeyID  string
+	Event     string
+	TargetURL string
+	CreatedAt time.Time
+}
+
+// IntegrationReply is a lead's reply, with the lead's contact details, as
+// the integrations API returns it.
+type IntegrationReply struct {
+	ID        string
+	LeadID    string
+	LeadName  string
+	LeadEmail string
+	Channel   string
+	Message   *string
+	RepliedAt time.Time
+}
+
+func (db *DB) CreateIntegrationHook(ctx context.Context, hook *IntegrationHook) (*IntegrationHook, error) {
+	query := `INSERT INTO integration_hooks (agency_id, api_key_id, event, target_url, created_at) 
+              VALUES ($1, $2, $3, $4, $5) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(ctx, query, agencyID, hook.APIKeyID, hook.Event, hook.TargetURL, hook.CreatedAt).Scan(&hook.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating integration hook: %w", err)
+	}
+	hook.AgencyID = agencyID
+
+	return hook, nil
+}
+
+// DeleteIntegrationHook removes a hook made with the given key.
+func (db *DB) DeleteIntegrationHook(ctx context.Context, id, apiKeyID string) (bool, error) {
+	query := `DELETE FROM integration_hooks WHERE id = $1 AND api_key_id = $2 AND (agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, apiKeyID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting integration hook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ExpireIntegrationHook removes a hook whose target is gone, whichever key
+// made it.
+func (db *DB) ExpireIntegrationHook(ctx context.Context, id string) error {
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM integration_hooks WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error deleting integration hook: %w", err)
+	}
+	return nil
+}
+
+// GetIntegrationHooksForLead returns the hooks for event in the lead's
+// agency whose key is not revoked.
+func (db *DB) GetIntegrationHooksForLead(ctx context.Context, leadID, event string) ([]*IntegrationHook, error) {
+	query := `SELECT h.id, h.agency_id, h.api_key_id, h.event, h.target_url, h.created_at 
+              FROM integration_hooks h 
+              JOIN leads l ON l.agency_id = h.agency_id 
+              JOIN api_keys k ON k.id = h.api_key_id 
+              WHERE l.id = $1 AND h.event = $2 AND k.revoked_at IS NULL 
+              ORDER BY h.created_at`
+
+	rows, err := db.conn.QueryContext(ctx, query, leadID, event)
+	if err != nil {
+		return nil, fmt.Errorf("error querying integration hooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []*IntegrationHook
+	for rows.Next() {
+		var hook IntegrationHook
+
+		if err := rows.Scan(&hook.ID, &hook.AgencyID, &hook.APIKeyID, &hook.Event, &hook.TargetURL, &hook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning integration hook row: %w", err)
+		}
+
+		hooks = append(hooks, &hook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating integration hook rows: %w", err)
+	}
+
+	return hooks, nil
+}
+
+const integrationReplyColumns = `i.id, l.id, l.name, l.email, i.channel, i.message, i.timestamp`
+
+// GetIntegrationReplies returns up to limit replies received at or after
+// since, newest first.
+func (db *DB) GetIntegrationReplies(ctx context.Context, since *time.Time, limit int) ([]*IntegrationReply, error) {
+	query := `SELECT ` + integrationReplyColumns + ` 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.direction = 'INBOUND' AND l.deleted_at IS NULL 
+              AND (i.timestamp >= $1 OR $1 IS NULL) AND (l.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY i.timestamp DESC LIMIT $3`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryReplica(ctx, query, since, agencyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying replies: %w", err)
+	}
+	defer rows.Close()
+
+	var replies []*IntegrationReply
+	for rows.Next() {
+		reply, err := scanIntegrationReply(rows)
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, reply)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reply rows: %w", err)
+	}
+
+	return replies, nil
+}
+
+// GetIntegrationReply returns nil when the interaction is not an inbound
+// reply.
+func (db *DB) GetIntegrationReply(ctx context.Context, interactionID string) (*IntegrationReply, error) {
+	query := `SELECT ` + integrationReplyColumns + ` 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.id = $1 AND i.direction = 'INBOUND' AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := scanIntegrationReply(db.conn.QueryRowContext(ctx, query, interactionID, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func scanIntegrationReply(row interface{ Scan(...interface{}) error }) (*IntegrationReply, error) {
+	var reply IntegrationReply
+	var message sql.NullString
+
+	err := row.Scan(&reply.ID, &reply.LeadID, &reply.LeadName, &reply.LeadEmail, &reply.Channel, &message, &reply.RepliedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning reply row: %w", err)
+	}
+
+	if message.Valid {
+		reply.Message = &message.String
+	}
+
+	return &reply, nil
+}
+
+// AppendLeadNote adds note to the end of the lead's notes. It reports
+// whether the lead exists.
+func (db *DB) AppendLeadNote(ctx context.Context, leadID, note string, at time.Time) (bool, error) {
+	query := `UPDATE leads SET notes = CASE WHEN coalesce(notes, '') = '' THEN $1 ELSE notes || E'\n\n' || $1 END, 
+              updated_at = $2, version = version + 1 
+              WHERE id = $3 AND deleted_at IS NULL AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, note, at, leadID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating lead notes: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(leadID))
+
+	return rowsAffected > 0, nil
+}
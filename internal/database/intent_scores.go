@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+func (db *DB) GetLeadIDsAfter(ctx context.Context, afterID string, limit int) ([]string, error) {
+	query := `SELECT id FROM leads 
+              WHERE id::text > $1 AND (agency_id = $3 OR $3 IS NULL) AND deleted_at IS NULL 
+              ORDER BY id::text LIMIT $2`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, afterID, limit, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead ids: %w", err)
+	}
+	defer rows.Close()
+
+	var leadIDs []string
+	for rows.Next() {
+		var leadID string
+		if err := rows.Scan(&leadID); err != nil {
+			return nil, fmt.Errorf("error scanning lead id: %w", err)
+		}
+		leadIDs = append(leadIDs, leadID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead ids: %w", err)
+	}
+
+	return leadIDs, nil
+}
+
+// IntentScoreChange is a lead whose intent score moved from Previous to
+// Lead.IntentScore.
+type IntentScoreChange struct {
+	Lead     *model.Lead
+	Previous float64
+}
+
+// UpdateIntentScores writes new scores for the given leads, recording a
+// history row for each score that actually changed, and returns the
+// changes.
+func (db *DB) UpdateIntentScores(ctx context.Context, scores map[string]float64, source string) ([]*IntentScoreChange, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	leadIDs := make([]string, 0, len(scores))
+	for leadID := range scores {
+		leadIDs = append(leadIDs, leadID)
+	}
+
+	query := `SELECT id, intent_score FROM leads 
+              WHERE id = ANY($1) AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL 
+              FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(leadIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error locking leads: %w", err)
+	}
+
+	previous := make(map[string]float64, len(scores))
+	for rows.Next() {
+		var leadID string
+		var score float64
+		if err := rows.Scan(&leadID, &score); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning lead score: %w", err)
+		}
+		previous[leadID] = score
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead scores: %w", err)
+	}
+
+	now := time.Now()
+	var changedIDs []string
+	for leadID, score := range scores {
+		old, ok := previous[leadID]
+		if !ok || old == score {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE leads SET intent_score = $1, updated_at = $2, version = version + 1 WHERE id = $3", score, now, leadID)
+		if err != nil {
+			return nil, fmt.Errorf("error updating intent score: %w", err)
+		}
+
+		if err = insertIntentScoreHistory(ctx, tx, leadID, score, &old, source, now); err != nil {
+			return nil, err
+		}
+
+		changedIDs = append(changedIDs, leadID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	keys := make([]string, 0, len(changedIDs))
+	for _, leadID := range changedIDs {
+		keys = append(keys, leadCacheKey(leadID))
+	}
+	db.invalidate(ctx, keys...)
+
+	changes := make([]*IntentScoreChange, 0, len(changedIDs))
+	for _, leadID := range changedIDs {
+		lead, err := db.GetLeadByID(ctx, leadID)
+		if err != nil {
+			return nil, err
+		}
+		if lead != nil {
+			changes = append(changes, &IntentScoreChange{Lead: lead, Previous: previous[leadID]})
+		}
+	}
+
+	return changes, nil
+}
+
+func (db *DB) RecordIntentScore(ctx context.Context, leadID string, score float64, previous *float64, source string) error {
+	return insertIntentScoreHistory(ctx, db.conn, leadID, score, previous, source, time.Now())
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertIntentScoreHistory(ctx context.Context, exec execer, leadID string, score float64, previous *float64, source string, at time.Time) error {
+	query := `INSERT INTO intent_score_history (lead_id, score, previous_score, source, created_at) 
+              VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := exec.ExecContext(ctx, query, leadID, score, previous, source, at)
+	if err != nil {
+		return fmt.Errorf("error recording intent score history: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetIntentScoreHistory(ctx context.Context, leadID string, limit *int) ([]*model.IntentScoreEntry, error) {
+	query := `SELECT id, score, previous_score, source, created_at 
+              FROM intent_score_history WHERE lead_id = $1 ORDER BY created_at DESC`
+
+	args := []interface{}{leadID}
+	if limit != nil {
+		query += " LIMIT $2"
+		args = append(args, *limit)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying intent score history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*model.IntentScoreEntry
+	for rows.Next() {
+		var entry model.IntentScoreEntry
+		var previous sql.NullFloat64
+
+		err := rows.Scan(&entry.ID, &entry.Score, &previous, &entry.Source, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning intent score history row: %w", err)
+		}
+
+		if previous.Valid {
+			entry.PreviousScore = &previous.Float64
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating intent score history rows: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// unsentStatuses lists the statuses of interactions that never went out:
+// booked meetings and follow-up tasks, queued or failed messages, drafts and
+// suppressed duplicates. Queries match sent messages with status NOT IN
+// unsentStatuses.
+const unsentStatuses = `('SCHEDULED', 'QUEUED', 'FAILED', 'PENDING_REVIEW', 'REJECTED', 'SUPPRESSED')`
+
+// CreateInteraction records an interaction and bumps the lead's last_contact
+// in the same transaction.
+func (db *DB) CreateInteraction(ctx context.Context, interaction *model.Interaction) (*model.Interaction, error) {
+	return db.CreateSentInteraction(ctx, interaction, "")
+}
+
+// CreateSentInteraction records an outbound message as CreateInteraction
+// does, keeping the subject it was sent with so it can be resent.
+func (db *DB) CreateSentInteraction(ctx context.Context, interaction *model.Interaction, subject string) (*model.Interaction, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+	if err := setInteractionSubject(ctx, tx, interaction.ID, subject); err != nil {
+		return nil, err
+	}
+
+	if err = recordLeadContact(ctx, tx, interaction.Lead.ID, interaction.Timestamp); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	return interaction, nil
+}
+
+func insertInteraction(ctx context.Context, tx *sql.Tx, interaction *model.Interaction) error {
+	if interaction.Direction == "" {
+		interaction.Direction = model.InteractionDirectionOutbound
+	}
+
+	var aiAgentID, templateID, variantID *string
+	if interaction.AIAgent != nil {
+		aiAgentID = &interaction.AIAgent.ID
+	}
+	if interaction.Template != nil {
+		templateID = &interaction.Template.ID
+	}
+	if interaction.Variant != nil {
+		variantID = &interaction.Variant.ID
+	}
+
+	// The agent's current persona and the template's current version are
+	// recorded with the interaction.
+	query := `INSERT INTO interactions (lead_id, type, channel, message, ai_agent_id, template_id, variant_id, 
+              timestamp, response, status, direction, external_id, notes, created_at, persona_id, template_version_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, 
+              (SELECT id FROM agent_personas WHERE agent_id = $5 ORDER BY version DESC LIMIT 1), 
+              (SELECT v.id FROM message_template_versions v JOIN message_templates t 
+                  ON t.id = v.template_id AND t.version = v.version WHERE t.id = $6)) 
+              RETURNING id`
+
+	err := tx.QueryRowContext(
+		ctx, query, interaction.Lead.ID, interaction.Type, interaction.Channel, interaction.Message,
+		aiAgentID, templateID, variantID, interaction.Timestamp, interaction.Response, interaction.Status,
+		interaction.Direction, interaction.ExternalID, interaction.Notes, interaction.CreatedAt,
+	).Scan(&interaction.ID)
+
+	if err != nil {
+		return fmt.Errorf("error creating interaction: %w", err)
+	}
+
+	return nil
+}
+
+// setInteractionSubject records the subject of an outbound message; messages
+// without one, such as SMS, keep none.
+func setInteractionSubject(ctx context.Context, tx *sql.Tx, id, subject string) error {
+	if subject == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE interactions SET subject = $1, updated_at = now() WHERE id = $2", subject, id); err != nil {
+		return fmt.Errorf("error recording interaction subject: %w", err)
+	}
+	return nil
+}
+
+// GetInteractionSubject returns the subject an outbound message was sent,
+// queued or drafted with, or nil when it had none.
+func (db *DB) GetInteractionSubject(ctx context.Context, interactionID string) (*string, error) {
+	query := `SELECT COALESCE(i.subject, d.subject, '') FROM interactions i 
+              JOIN leads l ON l.id = i.lead_id LEFT JOIN interaction_drafts d ON d.interaction_id = i.id 
+              WHERE i.id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject string
+	if err := db.conn.QueryRowContext(ctx, query, interactionID, agencyID).Scan(&subject); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching interaction subject: %w", err)
+	}
+
+	if subject == "" {
+		return nil, nil
+	}
+	return &subject, nil
+}
+
+// SetInteractionReplyIntent records what an inbound reply asks for.
+func (db *DB) SetInteractionReplyIntent(ctx context.Context, id string, intent model.ReplyIntent) error {
+	query := `UPDATE interactions i SET reply_intent = $1, updated_at = now() 
+              FROM leads l 
+              WHERE i.id = $2 AND l.id = i.lead_id AND i.direction = $3 AND (l.agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, intent, id, model.InteractionDirectionInbound, agencyID); err != nil {
+		return fmt.Errorf("error recording reply intent: %w", err)
+	}
+	return nil
+}
+
+// GetInteractionReplyIntent returns what an inbound reply asks for, or nil
+// when it was not classified.
+func (db *DB) GetInteractionReplyIntent(ctx context.Context, interactionID string) (*model.ReplyIntent, error) {
+	query := `SELECT i.reply_intent FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var intent sql.NullString
+	if err := db.conn.QueryRowContext(ctx, query, interactionID, agencyID).Scan(&intent); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching reply intent: %w", err)
+	}
+
+	if !intent.Valid {
+		return nil, nil
+	}
+	replyIntent := model.ReplyIntent(intent.String)
+	return &replyIntent, nil
+}
+
+// GetReplyIntentsByInteractionIDs batches GetInteractionReplyIntent for the
+// dataloader. Interactions that were not classified are left out.
+func (db *DB) GetReplyIntentsByInteractionIDs(ctx context.Context, interactionIDs []string) (map[string]*model.ReplyIntent, error) {
+	query := `SELECT i.id, i.reply_intent FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.id = ANY($1) AND i.reply_intent IS NOT NULL AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(interactionIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying reply intents: %w", err)
+	}
+	defer rows.Close()
+
+	intents := make(map[string]*model.ReplyIntent, len(interactionIDs))
+	for rows.Next() {
+		var id string
+		var intent model.ReplyIntent
+		if err := rows.Scan(&id, &intent); err != nil {
+			return nil, fmt.Errorf("error scanning reply intent: %w", err)
+		}
+		intents[id] = &intent
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reply intent rows: %w", err)
+	}
+
+	return intents, nil
+}
+
+func (db *DB) GetInteractionByID(ctx context.Context, id string) (*model.Interaction, error) {
+	query := `SELECT i.id, i.lead_id, i.type, i.channel, i.message, i.ai_agent_id, i.template_id, 
+              i.timestamp, i.response, i.status, i.direction, i.external_id, i.notes, i.created_at 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var interaction model.Interaction
+	var leadID string
+	var aiAgentID, templateID, message, response, externalID, notes sql.NullString
+
+	err = db.conn.QueryRowContext(ctx, query, id, agencyID).Scan(
+		&interaction.ID, &leadID, &interaction.Type, &interaction.Channel,
+		&message, &aiAgentID, &templateID, &interaction.Timestamp,
+		&response, &interaction.Status, &interaction.Direction, &externalID, &notes, &interaction.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching interaction: %w", err)
+	}
+
+	interaction.Lead = &model.Lead{ID: leadID}
+
+	if message.Valid {
+		interaction.Message = &message.String
+	}
+	if response.Valid {
+		interaction.Response = &response.String
+	}
+	if externalID.Valid {
+		interaction.ExternalID = &externalID.String
+	}
+	if notes.Valid {
+		interaction.Notes = &notes.String
+	}
+
+	return &interaction, nil
+}
+
+// GetInteractionByExternalID finds the interaction created for a provider
+// message, such as a Twilio message SID.
+func (db *DB) GetInteractionByExternalID(ctx context.Context, externalID string) (*model.Interaction, error) {
+	query := `SELECT i.id, i.lead_id, i.type, i.channel, i.status, i.direction, i.timestamp, i.created_at 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.external_id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var interaction model.Interaction
+	var leadID string
+
+	err = db.conn.QueryRowContext(ctx, query, externalID, agencyID).Scan(
+		&interaction.ID, &leadID, &interaction.Type, &interaction.Channel, &interaction.Status,
+		&interaction.Direction, &interaction.Timestamp, &interaction.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching interaction: %w", err)
+	}
+
+	interaction.Lead = &model.Lead{ID: leadID}
+
+	return &interaction, nil
+}
+
+func (db *DB) UpdateInteractionStatus(ctx context.Context, id string, status model.InteractionStatus, notes *string) error {
+	query := `UPDATE interactions SET status = $1, notes = COALESCE($2, notes), updated_at = now() WHERE id = $3`
+
+	_, err := db.conn.ExecContext(ctx, query, status, notes, id)
+	if err != nil {
+		return fmt.Errorf("error updating interaction status: %w", err)
+	}
+
+	return nil
+}
+
+// MarkInteractionResponded records a reply against a specific outbound
+// interaction, such as the email a reply was threaded to.
+func (db *DB) MarkInteractionResponded(ctx context.Context, id string, response string) error {
+	query := `UPDATE interactions SET status = $1, response = $2, updated_at = now() WHERE id = $3`
+
+	_, err := db.conn.ExecContext(ctx, query, model.InteractionStatusResponded, response, id)
+	if err != nil {
+		return fmt.Errorf("error marking interaction responded: %w", err)
+	}
+
+	return nil
+}
+
+// MarkLatestOutboundResponded records a reply against the most recent sent,
+// delivered or opened outbound interaction with the lead on channel.
+func (db *DB) MarkLatestOutboundResponded(ctx context.Context, leadID string, channel model.Channel, response string) error {
+	query := `UPDATE interactions SET status = $1, response = $2, updated_at = now() 
+              WHERE id = (
+                  SELECT id FROM interactions 
+                  WHERE lead_id = $3 AND channel = $4 AND direction = $5 AND status IN ($6, $7, $8) 
+                  ORDER BY timestamp DESC LIMIT 1
+              )`
+
+	_, err := db.conn.ExecContext(
+		ctx, query, model.InteractionStatusResponded, response, leadID, channel,
+		model.InteractionDirectionOutbound, model.InteractionStatusSent, model.InteractionStatusDelivered,
+		model.InteractionStatusOpened,
+	)
+	if err != nil {
+		return fmt.Errorf("error marking interaction responded: %w", err)
+	}
+
+	return nil
+}
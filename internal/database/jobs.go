@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const jobColumns = `id, kind, status, progress, total, result, error, created_by, created_at, started_at, finished_at`
+
+// QueuedJob is a job with what it needs to run: its input, and the agency,
+// role and clients of the user who started it.
+type QueuedJob struct {
+	Job       *model.Job
+	Input     []byte
+	AgencyID  string
+	Role      string
+	ClientIDs []string
+}
+
+// CreateJob queues a job for the agency in ctx.
+func (db *DB) CreateJob(ctx context.Context, job *QueuedJob) (*model.Job, error) {
+	query := `INSERT INTO jobs (agency_id, kind, status, input, created_by, created_by_role, created_by_client_ids, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clientIDs := job.ClientIDs
+	if clientIDs == nil {
+		clientIDs = []string{}
+	}
+
+	j := job.Job
+	j.Status = model.JobStatusQueued
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, j.Kind, j.Status, string(job.Input), j.CreatedByID, job.Role, pq.Array(clientIDs), j.CreatedAt,
+	).Scan(&j.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating job: %w", err)
+	}
+
+	job.AgencyID = agencyID
+	return j, nil
+}
+
+func (db *DB) GetJobByID(ctx context.Context, id string) (*model.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := scanJob(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimQueuedJobs marks up to limit of the oldest queued jobs of kind as
+// RUNNING and returns them.
+func (db *DB) ClaimQueuedJobs(ctx context.Context, kind model.JobKind, limit int) ([]*QueuedJob, error) {
+	query := `UPDATE jobs SET status = $1, started_at = $2 
+              WHERE id IN ( 
+                  SELECT id FROM jobs 
+                  WHERE status = $3 AND kind = $4 
+                  ORDER BY created_at 
+                  LIMIT $5 
+                  FOR UPDATE SKIP LOCKED 
+              ) 
+              RETURNING ` + jobColumns + `, input, agency_id, created_by_role, created_by_client_ids`
+
+	rows, err := db.conn.QueryContext(ctx, query, model.JobStatusRunning, time.Now(), model.JobStatusQueued, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*QueuedJob
+	for rows.Next() {
+		var queued QueuedJob
+		job, err := scanJob(rows, &queued.Input, &queued.AgencyID, &queued.Role, pq.Array(&queued.ClientIDs))
+		if err != nil {
+			return nil, fmt.Errorf("error scanning job row: %w", err)
+		}
+		queued.Job = job
+		jobs = append(jobs, &queued)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job rows: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// SetJobProgress records how much of a running job is done; total is nil
+// when it is not known.
+func (db *DB) SetJobProgress(ctx context.Context, id string, progress int, total *int) error {
+	query := `UPDATE jobs SET progress = $1, total = $2 WHERE id = $3`
+
+	if _, err := db.conn.ExecContext(ctx, query, progress, total, id); err != nil {
+		return fmt.Errorf("error updating job progress: %w", err)
+	}
+	return nil
+}
+
+// FinishJob records the outcome of a job.
+func (db *DB) FinishJob(ctx context.Context, job *model.Job) error {
+	query := `UPDATE jobs SET status = $1, progress = $2, total = $3, result = $4, error = $5, finished_at = $6 
+              WHERE id = $7`
+
+	_, err := db.conn.ExecContext(ctx, query, job.Status, job.Progress, job.Total, job.Result, job.Error, job.FinishedAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("error finishing job: %w", err)
+	}
+	return nil
+}
+
+// ReleaseJob puts a claimed job that was not started back in the queue.
+func (db *DB) ReleaseJob(ctx context.Context, id string) error {
+	query := `UPDATE jobs SET status = $1, started_at = NULL WHERE id = $2 AND status = $3`
+
+	if _, err := db.conn.ExecContext(ctx, query, model.JobStatusQueued, id, model.JobStatusRunning); err != nil {
+		return fmt.Errorf("error releasing job: %w", err)
+	}
+	return nil
+}
+
+// FailStaleJobs fails the jobs still RUNNING that started before
+// startedBefore, whose worker must have stopped without finishing them, and
+// returns how many there were. They are not run again, as they may have
+// been partly applied.
+func (db *DB) FailStaleJobs(ctx context.Context, startedBefore time.Time, message string) (int, error) {
+	query := `UPDATE jobs SET status = $1, error = $2, finished_at = $3 
+              WHERE status = $4 AND started_at < $5`
+
+	result, err := db.conn.ExecContext(ctx, query, model.JobStatusFailed, message, time.Now(), model.JobStatusRunning, startedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("error failing stale jobs: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// scanJob scans the jobColumns, then into extra.
+func scanJob(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*model.Job, error) {
+	var job model.Job
+	var total sql.NullInt64
+	var result, jobError, createdBy sql.NullString
+	var startedAt, finishedAt sql.NullTime
+
+	dest := []interface{}{
+		&job.ID, &job.Kind, &job.Status, &job.Progress, &total, &result, &jobError,
+		&createdBy, &job.CreatedAt, &startedAt, &finishedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if total.Valid {
+		t := int(total.Int64)
+		job.Total = &t
+	}
+	job.Result = nullString(result)
+	job.Error = nullString(jobError)
+	job.CreatedByID = nullString(createdBy)
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,491 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+)
+
+// ErrInvalidLeadEventCursor is returned for a cursor not taken from a lead
+// event.
+var ErrInvalidLeadEventCursor = errors.New("invalid lead event cursor")
+
+// leadEventField is a lead field whose changes are recorded as events. name
+// is the field's name in the schema, which events and CREATED snapshots use.
+type leadEventField struct {
+	name   string
+	column string
+	value  func(lead *model.Lead) interface{}
+}
+
+var leadEventFields = []leadEventField{
+	{"name", "name", func(l *model.Lead) interface{} { return l.Name }},
+	{"email", "email", func(l *model.Lead) interface{} { return l.Email }},
+	{"phone", "phone", func(l *model.Lead) interface{} { return l.Phone }},
+	{"company", "company", func(l *model.Lead) interface{} { return l.Company }},
+	{"position", "position", func(l *model.Lead) interface{} { return l.Position }},
+	{"status", "status", func(l *model.Lead) interface{} { return l.Status }},
+	{"intentScore", "intent_score", func(l *model.Lead) interface{} { return l.IntentScore }},
+	{"tags", "tags", func(l *model.Lead) interface{} {
+		if l.Tags == nil {
+			return []string{}
+		}
+		return l.Tags
+	}},
+	{"source", "source", func(l *model.Lead) interface{} { return l.Source }},
+	{"notes", "notes", func(l *model.Lead) interface{} { return l.Notes }},
+	{"dealValue", "deal_value", func(l *model.Lead) interface{} { return l.DealValue }},
+	{"timezone", "timezone", func(l *model.Lead) interface{} { return l.Timezone }},
+	{"language", "language", func(l *model.Lead) interface{} { return l.Language }},
+}
+
+// leadEventColumns maps field names to the columns their events project to.
+var leadEventColumns = func() map[string]string {
+	columns := map[string]string{lastContactField: "last_contact"}
+	for _, f := range leadEventFields {
+		columns[f.name] = f.column
+	}
+	return columns
+}()
+
+const (
+	statusField      = "status"
+	aiAgentField     = "aiAgent"
+	lastContactField = "lastContact"
+)
+
+// leadEvent is an event yet to be appended. from and to hold JSON, nil for
+// null.
+type leadEvent struct {
+	leadID string
+	typ    model.LeadEventType
+	field  string
+	from   *string
+	to     *string
+}
+
+const lockedLeadColumns = `id, name, email, phone, company, position, status, intent_score, 
+              tags, source, last_contact, next_follow_up, notes, deal_value, timezone, language, created_at, updated_at, deleted_at, version`
+
+// lockLeads locks the live leads matching where until tx ends and returns
+// them by ID.
+func lockLeads(ctx context.Context, tx *sql.Tx, where string, args ...interface{}) (map[string]*model.Lead, error) {
+	query := `SELECT ` + lockedLeadColumns + ` FROM leads 
+              WHERE deleted_at IS NULL AND ` + where + ` 
+              FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error locking leads: %w", err)
+	}
+	defer rows.Close()
+
+	leads := make(map[string]*model.Lead)
+	for rows.Next() {
+		lead, err := scanFilteredLead(rows)
+		if err != nil {
+			return nil, err
+		}
+		leads[lead.ID] = lead
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead rows: %w", err)
+	}
+
+	return leads, nil
+}
+
+// leadChanges returns the events that turn before into after, one for each
+// field whose value differs.
+func leadChanges(before, after *model.Lead) ([]leadEvent, error) {
+	var events []leadEvent
+	for _, f := range leadEventFields {
+		from, err := jsonValue(f.value(before))
+		if err != nil {
+			return nil, err
+		}
+		to, err := jsonValue(f.value(after))
+		if err != nil {
+			return nil, err
+		}
+		if equalJSON(from, to) {
+			continue
+		}
+
+		typ := model.LeadEventTypeFieldChanged
+		if f.name == statusField {
+			typ = model.LeadEventTypeStatusChanged
+		}
+		events = append(events, leadEvent{leadID: after.ID, typ: typ, field: f.name, from: from, to: to})
+	}
+	return events, nil
+}
+
+// leadSnapshot returns the CREATED event for a lead just saved.
+func leadSnapshot(lead *model.Lead) (leadEvent, error) {
+	fields := make(map[string]interface{}, len(leadEventFields))
+	for _, f := range leadEventFields {
+		fields[f.name] = f.value(lead)
+	}
+	to, err := jsonValue(fields)
+	if err != nil {
+		return leadEvent{}, err
+	}
+	return leadEvent{leadID: lead.ID, typ: model.LeadEventTypeCreated, to: to}, nil
+}
+
+func jsonValue(v interface{}) (*string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding lead event value: %w", err)
+	}
+	if string(b) == "null" {
+		return nil, nil
+	}
+	s := string(b)
+	return &s, nil
+}
+
+func equalJSON(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// applyLeadEvents appends the events and projects them onto the leads
+// table. Field and status changes bump the lead's version. CREATED and
+// ASSIGNED events are only appended: the rows they describe are written by
+// their callers.
+func applyLeadEvents(ctx context.Context, exec execer, events []leadEvent, at time.Time) error {
+	if err := appendLeadEvents(ctx, exec, events, at); err != nil {
+		return err
+	}
+
+	var leadIDs []string
+	changes := make(map[string]map[string]json.RawMessage)
+	for _, e := range events {
+		switch e.typ {
+		case model.LeadEventTypeFieldChanged, model.LeadEventTypeStatusChanged, model.LeadEventTypeContacted:
+		default:
+			continue
+		}
+		if changes[e.leadID] == nil {
+			changes[e.leadID] = make(map[string]json.RawMessage)
+			leadIDs = append(leadIDs, e.leadID)
+		}
+		value := json.RawMessage("null")
+		if e.to != nil {
+			value = json.RawMessage(*e.to)
+		}
+		changes[e.leadID][leadEventColumns[e.field]] = value
+	}
+
+	for _, id := range leadIDs {
+		if err := projectLeadChanges(ctx, exec, id, changes[id], at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectLeadChanges sets the lead's columns to the values in changes, read
+// into the leads row type so that each is converted to its column's type.
+func projectLeadChanges(ctx context.Context, exec execer, leadID string, changes map[string]json.RawMessage, at time.Time) error {
+	record, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("error encoding lead changes: %w", err)
+	}
+	args := []interface{}{string(record), leadID}
+
+	var sets []string
+	fieldsChanged := false
+	for _, column := range append(fieldColumns(), "last_contact") {
+		if _, ok := changes[column]; !ok {
+			continue
+		}
+		sets = append(sets, column+" = r."+column)
+		fieldsChanged = fieldsChanged || column != "last_contact"
+	}
+	// Contact alone is not a change to the lead's own fields.
+	if fieldsChanged {
+		sets = append(sets, "updated_at = $3", "version = l.version + 1")
+		args = append(args, at)
+	}
+
+	query := `UPDATE leads l SET ` + strings.Join(sets, ", ") + ` 
+              FROM jsonb_populate_record(NULL::leads, $1::jsonb) r 
+              WHERE l.id = $2`
+
+	if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateLead
+		}
+		return fmt.Errorf("error projecting lead events: %w", err)
+	}
+	return nil
+}
+
+func fieldColumns() []string {
+	columns := make([]string, len(leadEventFields))
+	for i, f := range leadEventFields {
+		columns[i] = f.column
+	}
+	return columns
+}
+
+// appendLeadEvents records the events, in order, as made by the current
+// user at at.
+func appendLeadEvents(ctx context.Context, exec execer, events []leadEvent, at time.Time) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO lead_events (agency_id, lead_id, type, field, old_value, new_value, actor_id, occurred_at) 
+              SELECT l.agency_id, l.id, e.type, e.field, e.old_value::jsonb, e.new_value::jsonb, $6, $7 
+              FROM unnest($1::uuid[], $2::text[], $3::text[], $4::text[], $5::text[]) WITH ORDINALITY 
+                  AS e(lead_id, type, field, old_value, new_value, n) 
+              JOIN leads l ON l.id = e.lead_id 
+              ORDER BY e.n`
+
+	leadIDs := make([]string, len(events))
+	types := make([]string, len(events))
+	fields := make([]sql.NullString, len(events))
+	from := make([]sql.NullString, len(events))
+	to := make([]sql.NullString, len(events))
+	for i, e := range events {
+		leadIDs[i] = e.leadID
+		types[i] = string(e.typ)
+		fields[i] = sql.NullString{String: e.field, Valid: e.field != ""}
+		if e.from != nil {
+			from[i] = sql.NullString{String: *e.from, Valid: true}
+		}
+		if e.to != nil {
+			to[i] = sql.NullString{String: *e.to, Valid: true}
+		}
+	}
+
+	var actorID *string
+	if user := auth.UserFromContext(ctx); user != nil {
+		actorID = &user.ID
+	}
+
+	_, err := exec.ExecContext(
+		ctx, query, pq.Array(leadIDs), pq.Array(types), pq.Array(fields), pq.Array(from), pq.Array(to), actorID, at,
+	)
+	if err != nil {
+		return fmt.Errorf("error appending lead events: %w", err)
+	}
+	return nil
+}
+
+// recordLeadChanges appends the events that turned before into the leads'
+// rows as they are now. Set-based writes, which change many leads in one
+// statement, are their own projection and record their events after.
+func recordLeadChanges(ctx context.Context, tx *sql.Tx, before map[string]*model.Lead, ids []string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	after, err := lockLeads(ctx, tx, "id = ANY($1::uuid[])", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	var events []leadEvent
+	for _, id := range ids {
+		if before[id] == nil || after[id] == nil {
+			continue
+		}
+		changes, err := leadChanges(before[id], after[id])
+		if err != nil {
+			return err
+		}
+		events = append(events, changes...)
+	}
+	return appendLeadEvents(ctx, tx, events, at)
+}
+
+// recordLeadContact records that the lead was contacted at, which sets its
+// last contact.
+func recordLeadContact(ctx context.Context, exec execer, leadID string, at time.Time) error {
+	to, err := jsonValue(at)
+	if err != nil {
+		return err
+	}
+	event := leadEvent{leadID: leadID, typ: model.LeadEventTypeContacted, field: lastContactField, to: to}
+	return applyLeadEvents(ctx, exec, []leadEvent{event}, at)
+}
+
+// recordLeadAssignment records that the lead was assigned to toAgentID,
+// moving it from fromAgentID when that is not empty, by the handoff rule
+// when one is given.
+func recordLeadAssignment(ctx context.Context, exec execer, leadID, fromAgentID, toAgentID string, handoffRuleID *string, at time.Time) error {
+	event := leadEvent{leadID: leadID, typ: model.LeadEventTypeAssigned, field: aiAgentField}
+	var err error
+	if fromAgentID != "" {
+		if event.from, err = jsonValue(fromAgentID); err != nil {
+			return err
+		}
+	}
+	if event.to, err = jsonValue(toAgentID); err != nil {
+		return err
+	}
+	if err = recordAgentOwnership(ctx, exec, leadID, fromAgentID, toAgentID, handoffRuleID, at); err != nil {
+		return err
+	}
+	return appendLeadEvents(ctx, exec, []leadEvent{event}, at)
+}
+
+const leadEventSelect = `SELECT e.id, e.lead_id, e.type, e.field, e.old_value::text, e.new_value::text, e.actor_id, 
+              e.occurred_at, e.tx_id::text FROM lead_events e `
+
+// GetLeadEvents returns the lead's timeline, newest first.
+func (db *DB) GetLeadEvents(ctx context.Context, leadID string, limit *int) ([]*model.LeadEvent, error) {
+	query := leadEventSelect + `WHERE e.lead_id = $1 AND (e.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY e.id DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{leadID, agencyID}
+	if limit != nil {
+		query += " LIMIT $3"
+		args = append(args, *limit)
+	}
+
+	return db.queryLeadEvents(ctx, query, args...)
+}
+
+// GetLeadEventsAfter returns up to limit events following the one whose
+// cursor is after, or from the first event when after is empty, in the
+// order their transactions began. Only events of transactions that began
+// before every one still running are returned, so an event committed later
+// never lands before a cursor already handed out.
+func (db *DB) GetLeadEventsAfter(ctx context.Context, after string, limit int) ([]*model.LeadEvent, error) {
+	query := leadEventSelect + `WHERE (e.tx_id, e.id) > ($1::xid8, $2) 
+              AND e.tx_id < pg_snapshot_xmin(pg_current_snapshot()) 
+              AND (e.agency_id = $3 OR $3 IS NULL) 
+              ORDER BY e.tx_id, e.id 
+              LIMIT $4`
+
+	txID, id, err := parseLeadEventCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.queryLeadEvents(ctx, query, txID, id, agencyID, limit)
+}
+
+// parseLeadEventCursor splits a cursor into the event's transaction and ID.
+// The empty cursor comes before every event.
+func parseLeadEventCursor(cursor string) (string, int64, error) {
+	if cursor == "" {
+		return "0", 0, nil
+	}
+	txID, rawID, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return "", 0, ErrInvalidLeadEventCursor
+	}
+	if _, err := strconv.ParseUint(txID, 10, 64); err != nil {
+		return "", 0, ErrInvalidLeadEventCursor
+	}
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidLeadEventCursor
+	}
+	return txID, id, nil
+}
+
+// GetLeadStageCountsAsOf counts the leads in each status at the given time,
+// replaying their events. Leads deleted by then are left out.
+func (db *DB) GetLeadStageCountsAsOf(ctx context.Context, at time.Time) ([]*model.StageCount, error) {
+	query := `SELECT s.status, COUNT(*) FROM ( 
+                  SELECT DISTINCT ON (e.lead_id) 
+                      CASE e.type WHEN 'CREATED' THEN e.new_value->>'status' ELSE e.new_value #>> '{}' END AS status 
+                  FROM lead_events e JOIN leads l ON l.id = e.lead_id 
+                  WHERE e.type IN ('CREATED', 'STATUS_CHANGED') AND e.occurred_at <= $1 
+                  AND (l.deleted_at IS NULL OR l.deleted_at > $1) AND (e.agency_id = $2 OR $2 IS NULL) 
+                  ORDER BY e.lead_id, e.occurred_at DESC, e.id DESC 
+              ) s 
+              GROUP BY s.status`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, at, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead stage counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []*model.StageCount{}
+	for rows.Next() {
+		var count model.StageCount
+		if err := rows.Scan(&count.Status, &count.Count); err != nil {
+			return nil, fmt.Errorf("error scanning lead stage count row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead stage count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (db *DB) queryLeadEvents(ctx context.Context, query string, args ...interface{}) ([]*model.LeadEvent, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*model.LeadEvent{}
+	for rows.Next() {
+		var event model.LeadEvent
+		var id int64
+		var txID string
+		var field, from, to, actorID sql.NullString
+
+		err := rows.Scan(&id, &event.LeadID, &event.Type, &field, &from, &to, &actorID, &event.OccurredAt, &txID)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning lead event row: %w", err)
+		}
+
+		event.ID = strconv.FormatInt(id, 10)
+		event.Cursor = txID + "." + event.ID
+		event.Field = nullString(field)
+		event.From = nullString(from)
+		event.To = nullString(to)
+		event.ActorID = nullString(actorID)
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead event rows: %w", err)
+	}
+
+	return events, nil
+}
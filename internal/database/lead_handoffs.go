@@ -0,0 +1,312 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+var ErrLeadHandedOff = errors.New("lead is already handed off to a sales rep")
+
+// SalesRepRoles are the roles of users leads can be escalated to.
+var SalesRepRoles = []model.UserRole{
+	model.UserRoleSalesRep,
+	model.UserRoleBdr,
+	model.UserRoleManager,
+	model.UserRoleAgencyManager,
+}
+
+const leadHandoffColumns = `id, lead_id, rep_id, escalated_by, reason, created_at, picked_up_at, resolved_at`
+
+func (db *DB) CreateLeadHandoff(ctx context.Context, handoff *model.LeadHandoff) (*model.LeadHandoff, error) {
+	query := `INSERT INTO lead_handoffs (agency_id, lead_id, rep_id, escalated_by, reason, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(ctx, query, agencyID, handoff.LeadID, handoff.RepID, handoff.EscalatedByID, handoff.Reason, handoff.CreatedAt).Scan(&handoff.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrLeadHandedOff
+		}
+		return nil, fmt.Errorf("error creating lead handoff: %w", err)
+	}
+	handoff.Status = model.HandoffStatusPending
+
+	return handoff, nil
+}
+
+func (db *DB) GetLeadHandoffByID(ctx context.Context, id string) (*model.LeadHandoff, error) {
+	query := `SELECT ` + leadHandoffColumns + ` FROM lead_handoffs 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	handoff, err := scanLeadHandoff(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return handoff, err
+}
+
+// GetLeadHandoffs lists handoffs, newest first, optionally only those of one
+// rep or in one status.
+func (db *DB) GetLeadHandoffs(ctx context.Context, repID *string, status *model.HandoffStatus, limit, offset *int) ([]*model.LeadHandoff, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := selectFrom(`SELECT `+leadHandoffColumns+` FROM lead_handoffs`, inTenant("agency_id", agencyID), equals("rep_id", repID))
+	if status != nil {
+		q = q.and(handoffStatusIs(*status))
+	}
+	query, args := q.orderBy("created_at DESC, id").page(limit, offset).build()
+
+	rows, err := db.queryReplica(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	handoffs := []*model.LeadHandoff{}
+	for rows.Next() {
+		handoff, err := scanLeadHandoff(rows)
+		if err != nil {
+			return nil, err
+		}
+		handoffs = append(handoffs, handoff)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead handoff rows: %w", err)
+	}
+
+	return handoffs, nil
+}
+
+// GetOpenLeadHandoffsByLeadIDs returns the open handoff of each lead that
+// has one.
+func (db *DB) GetOpenLeadHandoffsByLeadIDs(ctx context.Context, leadIDs []string) (map[string]*model.LeadHandoff, error) {
+	query := `SELECT ` + leadHandoffColumns + ` FROM lead_handoffs 
+              WHERE lead_id = ANY($1) AND resolved_at IS NULL AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(leadIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	handoffs := make(map[string]*model.LeadHandoff, len(leadIDs))
+	for rows.Next() {
+		handoff, err := scanLeadHandoff(rows)
+		if err != nil {
+			return nil, err
+		}
+		handoffs[handoff.LeadID] = handoff
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead handoff rows: %w", err)
+	}
+
+	return handoffs, nil
+}
+
+// IsLeadHandedOff reports whether the lead has an open handoff, during which
+// AI outreach to it is paused.
+func (db *DB) IsLeadHandedOff(ctx context.Context, leadID string) (bool, error) {
+	query := `SELECT EXISTS ( 
+                  SELECT 1 FROM lead_handoffs 
+                  WHERE lead_id = $1 AND resolved_at IS NULL AND (agency_id = $2 OR $2 IS NULL) 
+              )`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var handedOff bool
+	if err := db.conn.QueryRowContext(ctx, query, leadID, agencyID).Scan(&handedOff); err != nil {
+		return false, fmt.Errorf("error checking lead handoff: %w", err)
+	}
+
+	return handedOff, nil
+}
+
+// GetAvailableSalesRep returns the ID of the agency's active sales rep with
+// the fewest open handoffs, or "" when it has none. Ties go to the rep who
+// was handed a lead least recently.
+func (db *DB) GetAvailableSalesRep(ctx context.Context) (string, error) {
+	query := `SELECT u.id FROM users u 
+              LEFT JOIN lead_handoffs h ON h.rep_id = u.id AND h.resolved_at IS NULL 
+              WHERE u.role = ANY($1) AND u.status = $2 AND u.agency_id = $3 
+              GROUP BY u.id 
+              ORDER BY COUNT(h.id), (SELECT max(created_at) FROM lead_handoffs WHERE rep_id = u.id) NULLS FIRST, u.id 
+              LIMIT 1`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	roles := make([]string, len(SalesRepRoles))
+	for i, role := range SalesRepRoles {
+		roles[i] = string(role)
+	}
+
+	var repID string
+	err = db.conn.QueryRowContext(ctx, query, pq.Array(roles), model.UserStatusActive, agencyID).Scan(&repID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error finding available sales rep: %w", err)
+	}
+
+	return repID, nil
+}
+
+// PickUpLeadHandoff marks a pending handoff as picked up. It returns nil when
+// the handoff does not exist or is no longer pending.
+func (db *DB) PickUpLeadHandoff(ctx context.Context, id string, at time.Time) (*model.LeadHandoff, error) {
+	query := `UPDATE lead_handoffs SET picked_up_at = $2 
+              WHERE id = $1 AND picked_up_at IS NULL AND resolved_at IS NULL AND (agency_id = $3 OR $3 IS NULL) 
+              RETURNING ` + leadHandoffColumns
+
+	return db.updateLeadHandoff(ctx, query, id, at)
+}
+
+// ResolveLeadHandoff closes an open handoff, which resumes AI outreach to the
+// lead. A handoff resolved before it was picked up counts as picked up then.
+// It returns nil when the handoff does not exist or is already resolved.
+func (db *DB) ResolveLeadHandoff(ctx context.Context, id string, at time.Time) (*model.LeadHandoff, error) {
+	query := `UPDATE lead_handoffs SET picked_up_at = COALESCE(picked_up_at, $2), resolved_at = $2 
+              WHERE id = $1 AND resolved_at IS NULL AND (agency_id = $3 OR $3 IS NULL) 
+              RETURNING ` + leadHandoffColumns
+
+	return db.updateLeadHandoff(ctx, query, id, at)
+}
+
+func (db *DB) updateLeadHandoff(ctx context.Context, query, id string, at time.Time) (*model.LeadHandoff, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	handoff, err := scanLeadHandoff(db.conn.QueryRowContext(ctx, query, id, at, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return handoff, err
+}
+
+// GetRepLeads returns the leads matching filter that have an open handoff to
+// the rep.
+func (db *DB) GetRepLeads(ctx context.Context, repID string, filter *model.LeadFilterInput, limit, offset *int) ([]*model.Lead, error) {
+	q, err := leadFilterQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	q = q.and(where("id IN (SELECT lead_id FROM lead_handoffs WHERE rep_id = ? AND resolved_at IS NULL)", repID))
+
+	return db.listLeads(ctx, q, limit, offset)
+}
+
+// GetHandoffSLA measures how quickly the handoffs made in [from, to),
+// optionally only those of one rep, were picked up against target. Handoffs
+// still waiting at now count against target for as long as they have waited.
+func (db *DB) GetHandoffSLA(ctx context.Context, repID *string, from, to, now time.Time, target time.Duration) (*model.HandoffSLA, error) {
+	query := `SELECT COUNT(*), COUNT(picked_up_at), 
+              COUNT(*) FILTER (WHERE EXTRACT(EPOCH FROM COALESCE(picked_up_at, $4) - created_at) > $5), 
+              COUNT(*) FILTER (WHERE EXTRACT(EPOCH FROM picked_up_at - created_at) <= $5), 
+              AVG(EXTRACT(EPOCH FROM picked_up_at - created_at)) 
+              FROM lead_handoffs 
+              WHERE created_at >= $1 AND created_at < $2 AND (rep_id = $3 OR $3 IS NULL) AND (agency_id = $6 OR $6 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sla := &model.HandoffSLA{TargetSeconds: int(target / time.Second)}
+	var withinTarget int
+	var avgPickupTime sql.NullFloat64
+
+	err = db.conn.QueryRowContext(ctx, query, from, to, repID, now, target.Seconds(), agencyID).Scan(
+		&sla.Escalations, &sla.PickedUp, &sla.Breached, &withinTarget, &avgPickupTime,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error measuring handoff SLA: %w", err)
+	}
+
+	sla.Waiting = sla.Escalations - sla.PickedUp
+	if avgPickupTime.Valid {
+		sla.AvgPickupTime = &avgPickupTime.Float64
+	}
+	if sla.PickedUp > 0 {
+		sla.WithinTargetRate = float64(withinTarget) / float64(sla.PickedUp)
+	}
+
+	return sla, nil
+}
+
+// handoffStatusIs matches handoffs in status.
+func handoffStatusIs(status model.HandoffStatus) *predicate {
+	switch status {
+	case model.HandoffStatusPending:
+		return where("picked_up_at IS NULL AND resolved_at IS NULL")
+	case model.HandoffStatusPickedUp:
+		return where("picked_up_at IS NOT NULL AND resolved_at IS NULL")
+	default:
+		return where("resolved_at IS NOT NULL")
+	}
+}
+
+func scanLeadHandoff(row interface{ Scan(...interface{}) error }) (*model.LeadHandoff, error) {
+	var handoff model.LeadHandoff
+	var escalatedBy sql.NullString
+	var pickedUpAt, resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&handoff.ID, &handoff.LeadID, &handoff.RepID, &escalatedBy, &handoff.Reason,
+		&handoff.CreatedAt, &pickedUpAt, &resolvedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning lead handoff row: %w", err)
+	}
+
+	handoff.EscalatedByID = nullString(escalatedBy)
+	handoff.Status = model.HandoffStatusPending
+	if pickedUpAt.Valid {
+		handoff.PickedUpAt = &pickedUpAt.Time
+		handoff.Status = model.HandoffStatusPickedUp
+	}
+	if resolvedAt.Valid {
+		handoff.ResolvedAt = &resolvedAt.Time
+		handoff.Status = model.HandoffStatusResolved
+	}
+
+	return &handoff, nil
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// TransitionLeadStatus moves a lead from one status to another and records
+// the change as an event and in lead_status_history, with why the lead was
+// lost when it is moving to LOST. It returns false when the lead is no
+// longer in the expected status.
+func (db *DB) TransitionLeadStatus(ctx context.Context, id string, from, to model.LeadStatus, reason *string, lossReason *model.LossReason, changedBy *string) (bool, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockLeads(ctx, tx, "id = $1 AND (agency_id = $2 OR $2 IS NULL)", id, agencyID)
+	if err != nil {
+		return false, err
+	}
+	lead := current[id]
+	if lead == nil || lead.Status != from {
+		return false, nil
+	}
+
+	now := time.Now()
+	moved := *lead
+	moved.Status = to
+	changes, err := leadChanges(lead, &moved)
+	if err != nil {
+		return false, err
+	}
+	if err = applyLeadEvents(ctx, tx, changes, now); err != nil {
+		return false, err
+	}
+
+	if err = insertLeadStatusHistory(ctx, tx, id, from, to, reason, lossReason, changedBy, now); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(id))
+
+	return true, nil
+}
+
+// RecordLeadStatusChange logs a status change made through a full lead
+// update rather than TransitionLeadStatus.
+func (db *DB) RecordLeadStatusChange(ctx context.Context, leadID string, from, to model.LeadStatus, reason, changedBy *string) error {
+	return insertLeadStatusHistory(ctx, db.conn, leadID, from, to, reason, nil, changedBy, time.Now())
+}
+
+func insertLeadStatusHistory(ctx context.Context, exec execer, leadID string, from, to model.LeadStatus, reason *string, lossReason *model.LossReason, changedBy *string, at time.Time) error {
+	query := `INSERT INTO lead_status_history (lead_id, from_status, to_status, reason, loss_reason, changed_by, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := exec.ExecContext(ctx, query, leadID, from, to, reason, lossReason, changedBy, at)
+	if err != nil {
+		return fmt.Errorf("error recording lead status history: %w", err)
+	}
+
+	if to == model.LeadStatusWon {
+		return convertCampaignLeads(ctx, exec, []string{leadID}, at)
+	}
+
+	return nil
+}
+
+func (db *DB) GetLeadStatusHistory(ctx context.Context, leadID string) ([]*model.LeadStatusChange, error) {
+	query := `SELECT id, lead_id, from_status, to_status, reason, loss_reason, changed_by, created_at 
+              FROM lead_status_history WHERE lead_id = $1 ORDER BY created_at DESC`
+
+	rows, err := db.conn.QueryContext(ctx, query, leadID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead status history: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*model.LeadStatusChange
+	for rows.Next() {
+		var change model.LeadStatusChange
+		var reason, lossReason, changedBy sql.NullString
+
+		err := rows.Scan(
+			&change.ID, &change.LeadID, &change.FromStatus, &change.ToStatus,
+			&reason, &lossReason, &changedBy, &change.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning lead status history row: %w", err)
+		}
+
+		if reason.Valid {
+			change.Reason = &reason.String
+		}
+		if lossReason.Valid {
+			r := model.LossReason(lossReason.String)
+			change.LossReason = &r
+		}
+		if changedBy.Valid {
+			change.ChangedByID = &changedBy.String
+		}
+
+		changes = append(changes, &change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead status history rows: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetLeadLossReason returns why the lead was last marked LOST, or nil when
+// no reason was given.
+func (db *DB) GetLeadLossReason(ctx context.Context, leadID string) (*model.LossReason, error) {
+	query := `SELECT loss_reason FROM lead_status_history 
+              WHERE lead_id = $1 AND to_status = $2 
+              ORDER BY created_at DESC LIMIT 1`
+
+	var lossReason sql.NullString
+	err := db.conn.QueryRowContext(ctx, query, leadID, model.LeadStatusLost).Scan(&lossReason)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching lead loss reason: %w", err)
+	}
+	if !lossReason.Valid {
+		return nil, nil
+	}
+
+	r := model.LossReason(lossReason.String)
+	return &r, nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+// ErrDuplicateLead is returned when a write would give the agency two live
+// leads with the same email or phone.
+var ErrDuplicateLead = errors.New("a lead with this email or phone already exists")
+
+// leadConflictTargets are the unique indexes UpsertLead can match on.
+var leadConflictTargets = map[model.LeadMatchKey]string{
+	model.LeadMatchKeyEmail: "(agency_id, lower(email)) WHERE deleted_at IS NULL",
+	model.LeadMatchKeyPhone: "(agency_id, phone) WHERE deleted_at IS NULL AND phone IS NOT NULL",
+}
+
+// leadMatchConditions find the live lead each of leadConflictTargets matches,
+// with the agency as $1 and the lead's email or phone as $2.
+var leadMatchConditions = map[model.LeadMatchKey]string{
+	model.LeadMatchKeyEmail: "agency_id = $1 AND lower(email) = lower($2)",
+	model.LeadMatchKeyPhone: "agency_id = $1 AND phone = $2",
+}
+
+// UpsertLead creates the lead, or updates the live lead with the same email
+// or phone, in a single statement. On update, optional fields left empty
+// keep their value, and status and intent score are left alone so that
+// changes to them keep going through their history. Either way the change is
+// recorded as events. It reports whether the lead was created.
+func (db *DB) UpsertLead(ctx context.Context, lead *model.Lead, matchOn model.LeadMatchKey) (*model.Lead, bool, error) {
+	target, ok := leadConflictTargets[matchOn]
+	if !ok {
+		return nil, false, fmt.Errorf("invalid lead match key %s", matchOn)
+	}
+
+	query := `INSERT INTO leads (name, email, phone, company, position, status, intent_score, 
+              tags, source, notes, deal_value, timezone, language, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) 
+              ON CONFLICT ` + target + ` DO UPDATE SET 
+              name = EXCLUDED.name, email = EXCLUDED.email, phone = COALESCE(EXCLUDED.phone, leads.phone), 
+              company = COALESCE(EXCLUDED.company, leads.company), position = COALESCE(EXCLUDED.position, leads.position), 
+              tags = COALESCE(EXCLUDED.tags, leads.tags), source = COALESCE(EXCLUDED.source, leads.source), 
+              notes = COALESCE(EXCLUDED.notes, leads.notes), deal_value = COALESCE(EXCLUDED.deal_value, leads.deal_value), 
+              timezone = COALESCE(EXCLUDED.timezone, leads.timezone), language = COALESCE(EXCLUDED.language, leads.language), 
+              updated_at = $16, version = leads.version + 1 
+              RETURNING id, xmax = 0`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var match interface{} = lead.Email
+	if matchOn == model.LeadMatchKeyPhone {
+		match = lead.Phone
+	}
+	before, err := lockLeads(ctx, tx, leadMatchConditions[matchOn], agencyID, match)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if lead.Tags, err = resolveTags(ctx, tx, agencyID, lead.Tags); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	var id string
+	var created bool
+	err = tx.QueryRowContext(
+		ctx, query, lead.Name, lead.Email, lead.Phone, lead.Company, lead.Position,
+		lead.Status, lead.IntentScore, lead.Tags, lead.Source, lead.Notes, lead.DealValue, lead.Timezone, lead.Language, lead.CreatedAt, agencyID,
+		now,
+	).Scan(&id, &created)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, false, ErrDuplicateLead
+		}
+		return nil, false, fmt.Errorf("error upserting lead: %w", err)
+	}
+
+	if created {
+		err = recordLeadCreated(ctx, tx, id)
+	} else {
+		err = recordLeadChanges(ctx, tx, before, []string{id}, now)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(id))
+
+	upserted, err := db.GetLeadByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if upserted == nil {
+		return nil, false, fmt.Errorf("upserted lead %s not found", id)
+	}
+
+	return upserted, created, nil
+}
+
+// recordLeadCreated appends the CREATED event of a lead just inserted.
+func recordLeadCreated(ctx context.Context, tx *sql.Tx, id string) error {
+	saved, err := lockLeads(ctx, tx, "id = $1", id)
+	if err != nil {
+		return err
+	}
+	lead := saved[id]
+	if lead == nil {
+		return fmt.Errorf("upserted lead %s not found", id)
+	}
+
+	event, err := leadSnapshot(lead)
+	if err != nil {
+		return err
+	}
+	return appendLeadEvents(ctx, tx, []leadEvent{event}, lead.CreatedAt)
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
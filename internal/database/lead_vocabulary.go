@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetLeadTags returns the tags used on the agency's live leads, most used
+// first.
+func (db *DB) GetLeadTags(ctx context.Context, limit int) ([]string, error) {
+	query := `SELECT tag FROM leads, unnest(tags) AS tag 
+              WHERE (agency_id = $1 OR $1 IS NULL) AND deleted_at IS NULL 
+              GROUP BY tag ORDER BY COUNT(*) DESC, tag LIMIT $2`
+	return db.leadValues(ctx, query, limit, "tags")
+}
+
+// GetLeadSources returns the sources of the agency's live leads, most used
+// first.
+func (db *DB) GetLeadSources(ctx context.Context, limit int) ([]string, error) {
+	query := `SELECT source FROM leads 
+              WHERE (agency_id = $1 OR $1 IS NULL) AND deleted_at IS NULL AND source IS NOT NULL 
+              GROUP BY source ORDER BY COUNT(*) DESC, source LIMIT $2`
+	return db.leadValues(ctx, query, limit, "sources")
+}
+
+func (db *DB) leadValues(ctx context.Context, query string, limit int, what string) ([]string, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryReplica(ctx, query, agencyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("error scanning lead %s row: %w", what, err)
+		}
+		values = append(values, value)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead %s rows: %w", what, err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// GetLeadIDByPhone matches an inbound phone number against lead phone
+// numbers by their last ten digits, ignoring formatting. When several leads
+// share a number the most recently contacted one wins.
+func (db *DB) GetLeadIDByPhone(ctx context.Context, digits string) (string, error) {
+	query := `SELECT id FROM leads 
+              WHERE right(regexp_replace(phone, '\D', '', 'g'), 10) = right($1, 10) 
+              AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL 
+              ORDER BY last_contact DESC NULLS LAST LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = db.conn.QueryRowContext(ctx, query, digits, agencyID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error fetching lead by phone: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetLeadIDByEmail matches an address case-insensitively. When several leads
+// share it the most recently contacted one wins.
+func (db *DB) GetLeadIDByEmail(ctx context.Context, email string) (string, error) {
+	query := `SELECT id FROM leads 
+              WHERE lower(email) = lower($1) 
+              AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL 
+              ORDER BY last_contact DESC NULLS LAST LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = db.conn.QueryRowContext(ctx, query, email, agencyID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error fetching lead by email: %w", err)
+	}
+
+	return id, nil
+}
+
+func (db *DB) SetLeadNextFollowUp(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE leads SET next_follow_up = $1 
+              WHERE id = $2 AND (agency_id = $3 OR $3 IS NULL) AND deleted_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, at, id, agencyID); err != nil {
+		return fmt.Errorf("error updating lead next follow-up: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(id))
+
+	return nil
+}
+
+// GetLeadsByIDs returns the live leads among ids, in the order given.
+func (db *DB) GetLeadsByIDs(ctx context.Context, ids []string) ([]*model.Lead, error) {
+	query := `SELECT id, name, email, phone, company, position, status, intent_score, 
+              tags, source, last_contact, next_follow_up, notes, deal_value, timezone, language, created_at, updated_at, version 
+              FROM leads WHERE id = ANY($1) AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL 
+              ORDER BY array_position($1, id)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(ids), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying leads: %w", err)
+	}
+	defer rows.Close()
+
+	leads := []*model.Lead{}
+	for rows.Next() {
+		var lead model.Lead
+		var tagsArray []sql.NullString
+		var updatedAt, lastContact, nextFollowUp sql.NullTime
+		var phone, company, position, source, notes, timezone, language sql.NullString
+		var dealValue sql.NullFloat64
+
+		err := rows.Scan(
+			&lead.ID, &lead.Name, &lead.Email, &phone, &company, &position, &lead.Status, &lead.IntentScore,
+			pq.Array(&tagsArray), &source, &lastContact, &nextFollowUp, &notes, &dealValue, &timezone, &language, &lead.CreatedAt, &updatedAt,
+			&lead.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning lead row: %w", err)
+		}
+
+		if phone.Valid {
+			lead.Phone = &phone.String
+		}
+		if company.Valid {
+			lead.Company = &company.String
+		}
+		if position.Valid {
+			lead.Position = &position.String
+		}
+		if source.Valid {
+			lead.Source = &source.String
+		}
+		if notes.Valid {
+			lead.Notes = &notes.String
+		}
+		if dealValue.Valid {
+			lead.DealValue = &dealValue.Float64
+		}
+		if timezone.Valid {
+			lead.Timezone = &timezone.String
+		}
+		if language.Valid {
+			lead.Language = &language.String
+		}
+		if lastContact.Valid {
+			lead.LastContact = &lastContact.Time
+		}
+		if nextFollowUp.Valid {
+			lead.NextFollowUp = &nextFollowUp.Time
+		}
+		if updatedAt.Valid {
+			lead.UpdatedAt = &updatedAt.Time
+		}
+
+		lead.Tags = make([]string, 0, len(tagsArray))
+		for _, tag := range tagsArray {
+			if tag.Valid {
+				lead.Tags = append(lead.Tags, tag.String)
+			}
+		}
+
+		leads = append(leads, &lead)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead rows: %w", err)
+	}
+
+	return leads, nil
+}
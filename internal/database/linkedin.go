@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const linkedInProfileColumns = `p.profile_url, p.connection_status, p.requested_at, p.connected_at`
+
+// SetLinkedInProfile sets the lead's LinkedIn profile. Changing the URL
+// resets the connection status. It returns nil when the lead does not
+// exist.
+func (db *DB) SetLinkedInProfile(ctx context.Context, leadID, profileURL string) (*model.LinkedInProfile, error) {
+	query := `INSERT INTO linkedin_profiles AS p (lead_id, profile_url, connection_status) 
+              SELECT id, $2, $3 FROM leads 
+              WHERE id = $1 AND (agency_id = $4 OR $4 IS NULL) AND deleted_at IS NULL 
+              ON CONFLICT (lead_id) DO UPDATE SET 
+              profile_url = EXCLUDED.profile_url, 
+              connection_status = CASE WHEN p.profile_url = EXCLUDED.profile_url THEN p.connection_status ELSE EXCLUDED.connection_status END, 
+              requested_at = CASE WHEN p.profile_url = EXCLUDED.profile_url THEN p.requested_at END, 
+              connected_at = CASE WHEN p.profile_url = EXCLUDED.profile_url THEN p.connected_at END, 
+              updated_at = now() 
+              RETURNING ` + linkedInProfileColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := scanLinkedInProfile(db.conn.QueryRowContext(
+		ctx, query, leadID, profileURL, model.LinkedInConnectionStatusNone, agencyID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error setting LinkedIn profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// GetLinkedInProfile returns the lead's LinkedIn profile, or nil when it has
+// none.
+func (db *DB) GetLinkedInProfile(ctx context.Context, leadID string) (*model.LinkedInProfile, error) {
+	query := `SELECT ` + linkedInProfileColumns + ` 
+              FROM linkedin_profiles p JOIN leads l ON l.id = p.lead_id 
+              WHERE p.lead_id = $1 AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := scanLinkedInProfile(db.conn.QueryRowContext(ctx, query, leadID, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching LinkedIn profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// GetLeadIDByLinkedInProfile matches a normalized profile URL. When several
+// leads share it the most recently contacted one wins.
+func (db *DB) GetLeadIDByLinkedInProfile(ctx context.Context, profileURL string) (string, error) {
+	query := `SELECT l.id FROM linkedin_profiles p JOIN leads l ON l.id = p.lead_id 
+              WHERE p.profile_url = $1 AND (l.agency_id = $2 OR $2 IS NULL) AND l.deleted_at IS NULL 
+              ORDER BY l.last_contact DESC NULLS LAST LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = db.conn.QueryRowContext(ctx, query, profileURL, agencyID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("error fetching lead by LinkedIn profile: %w", err)
+	}
+
+	return id, nil
+}
+
+// MarkLinkedInRequested records that a connection request was sent to the
+// lead, unless one already was.
+func (db *DB) MarkLinkedInRequested(ctx context.Context, leadID string, at time.Time) error {
+	return db.setLinkedInConnection(ctx, leadID, model.LinkedInConnectionStatusNone, model.LinkedInConnectionStatusPending, "requested_at", at)
+}
+
+// MarkLinkedInConnected records that the lead accepted our connection
+// request.
+func (db *DB) MarkLinkedInConnected(ctx context.Context, leadID string, at time.Time) error {
+	return db.setLinkedInConnection(ctx, leadID, model.LinkedInConnectionStatusPending, model.LinkedInConnectionStatusConnected, "connected_at", at)
+}
+
+func (db *DB) setLinkedInConnection(ctx context.Context, leadID string, from, to model.LinkedInConnectionStatus, column string, at time.Time) error {
+	query := `UPDATE linkedin_profiles p SET connection_status = $3, ` + column + ` = $4, updated_at = now() 
+              FROM leads l 
+              WHERE l.id = p.lead_id AND p.lead_id = $1 AND p.connection_status = $2 
+              AND (l.agency_id = $5 OR $5 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, leadID, from, to, at, agencyID); err != nil {
+		return fmt.Errorf("error updating LinkedIn connection: %w", err)
+	}
+
+	return nil
+}
+
+// CountLinkedInSends counts the LinkedIn messages sent since the given time
+// by an AI agent, or without one when agentID is nil. Failed and queued
+// messages are not counted.
+func (db *DB) CountLinkedInSends(ctx context.Context, agentID *string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE i.channel = 'LINKEDIN' AND i.direction = 'OUTBOUND' AND i.status NOT IN ` + unsentStatuses + ` 
+              AND i.ai_agent_id IS NOT DISTINCT FROM $1 AND i.timestamp >= $2 
+              AND (l.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := db.conn.QueryRowContext(ctx, query, agentID, since, agencyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting LinkedIn sends: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanLinkedInProfile(row interface{ Scan(...interface{}) error }) (*model.LinkedInProfile, error) {
+	var profile model.LinkedInProfile
+	var requestedAt, connectedAt sql.NullTime
+
+	if err := row.Scan(&profile.ProfileURL, &profile.ConnectionStatus, &requestedAt, &connectedAt); err != nil {
+		return nil, err
+	}
+
+	if requestedAt.Valid {
+		profile.RequestedAt = &requestedAt.Time
+	}
+	if connectedAt.Valid {
+		profile.ConnectedAt = &connectedAt.Time
+	}
+
+	return &profile, nil
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// Meeting is a meeting booked with a lead, keyed by its MEETING interaction.
+type Meeting struct {
+	InteractionID string
+	LeadID        string
+	AgencyID      string
+	// ExternalID is the calendar provider's booking ID.
+	ExternalID *string
+	// CalendarID is nil for meetings on the default calendar booked before
+	// calendars were recorded.
+	CalendarID *string
+	BookedBy   *string
+	URL        *string
+	StartsAt   time.Time
+	EndsAt     time.Time
+	// Sequence counts the invites sent after the first, as the SEQUENCE of
+	// the latest one.
+	Sequence    int
+	CancelledAt *time.Time
+}
+
+// GetMeeting returns the meeting booked as the given interaction, or nil
+// when there is none or its lead was deleted.
+func (db *DB) GetMeeting(ctx context.Context, interactionID string) (*Meeting, error) {
+	query := `SELECT m.interaction_id, i.lead_id, l.agency_id, i.external_id, m.calendar_id, m.booked_by, m.url, 
+              m.starts_at, m.ends_at, m.sequence, m.cancelled_at 
+              FROM meetings m JOIN interactions i ON i.id = m.interaction_id JOIN leads l ON l.id = i.lead_id 
+              WHERE m.interaction_id = $1 AND l.deleted_at IS NULL AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var meeting Meeting
+	var externalID, calendarID, bookedBy, url sql.NullString
+	var cancelledAt sql.NullTime
+
+	err = db.conn.QueryRowContext(ctx, query, interactionID, agencyID).Scan(
+		&meeting.InteractionID, &meeting.LeadID, &meeting.AgencyID, &externalID, &calendarID, &bookedBy, &url,
+		&meeting.StartsAt, &meeting.EndsAt, &meeting.Sequence, &cancelledAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching meeting: %w", err)
+	}
+
+	meeting.ExternalID = nullString(externalID)
+	meeting.CalendarID = nullString(calendarID)
+	meeting.BookedBy = nullString(bookedBy)
+	meeting.URL = nullString(url)
+	if cancelledAt.Valid {
+		meeting.CancelledAt = &cancelledAt.Time
+	}
+
+	return &meeting, nil
+}
+
+// RescheduleMeeting stores meeting's new time, booking, link and sequence,
+// moves the lead's next follow-up along with it when it was the meeting's
+// start, previousStart, and enqueues invite, all in one transaction. It
+// returns false when the meeting was cancelled, or changed again since its
+// sequence was read.
+func (db *DB) RescheduleMeeting(ctx context.Context, meeting *Meeting, previousStart time.Time, invite *model.OutboxMessage) (bool, error) {
+	query := `UPDATE meetings SET starts_at = $2, ends_at = $3, url = $4, sequence = $5 
+              WHERE interaction_id = $1 AND cancelled_at IS NULL AND sequence < $5`
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, meeting.InteractionID, meeting.StartsAt, meeting.EndsAt, meeting.URL, meeting.Sequence)
+	if err != nil {
+		return false, fmt.Errorf("error rescheduling meeting: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE interactions SET external_id = $2, updated_at = now() WHERE id = $1", meeting.InteractionID, meeting.ExternalID)
+	if err != nil {
+		return false, fmt.Errorf("error updating meeting booking: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE leads SET next_follow_up = $2 WHERE id = $1 AND next_follow_up = $3", meeting.LeadID, meeting.StartsAt, previousStart)
+	if err != nil {
+		return false, fmt.Errorf("error updating lead next follow-up: %w", err)
+	}
+
+	if invite != nil {
+		if err := enqueueOutbox(ctx, tx, meeting.AgencyID, invite); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(meeting.LeadID))
+
+	return true, nil
+}
+
+// CancelMeeting marks meeting cancelled at its CancelledAt with its new
+// sequence, clears the lead's next follow-up when it was the meeting's start,
+// and enqueues invite, all in one transaction. It returns false when the
+// meeting was already cancelled, or changed since its sequence was read.
+func (db *DB) CancelMeeting(ctx context.Context, meeting *Meeting, invite *model.OutboxMessage) (bool, error) {
+	query := `UPDATE meetings SET cancelled_at = $2, sequence = $3 
+              WHERE interaction_id = $1 AND cancelled_at IS NULL AND sequence < $3`
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, meeting.InteractionID, meeting.CancelledAt, meeting.Sequence)
+	if err != nil {
+		return false, fmt.Errorf("error cancelling meeting: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE leads SET next_follow_up = NULL WHERE id = $1 AND next_follow_up = $2", meeting.LeadID, meeting.StartsAt)
+	if err != nil {
+		return false, fmt.Errorf("error clearing lead next follow-up: %w", err)
+	}
+
+	if invite != nil {
+		if err := enqueueOutbox(ctx, tx, meeting.AgencyID, invite); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(meeting.LeadID))
+
+	return true, nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"salesagency/internal/database/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// MigrationStatus describes the schema version recorded in the database and
+// the migrations that ship with the binary.
+type MigrationStatus struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// migrator is a migrate.Migrate over a dedicated connection from the pool.
+type migrator struct {
+	*migrate.Migrate
+	conn *sql.Conn
+}
+
+// Close restores the connection's statement timeout before the connection
+// goes back to the pool.
+func (m *migrator) Close() (error, error) {
+	m.conn.ExecContext(context.Background(), "RESET statement_timeout")
+	return m.Migrate.Close()
+}
+
+// newMigrator runs migrations over a dedicated connection from the pool so
+// closing the migrator leaves the pool open. The connection has no statement
+// timeout until the migrator is closed.
+func (db *DB) newMigrator(ctx context.Context) (*migrator, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+	// Migrations that rewrite large tables may run past the statement
+	// timeout queries get.
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error clearing statement timeout: %w", err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.ExecContext(context.Background(), "RESET statement_timeout")
+		conn.Close()
+		return nil, fmt.Errorf("error creating migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		conn.ExecContext(context.Background(), "RESET statement_timeout")
+		driver.Close()
+		return nil, fmt.Errorf("error creating migrator: %w", err)
+	}
+
+	return &migrator{Migrate: m, conn: conn}, nil
+}
+
+// MigrateUp applies all pending migrations.
+func (db *DB) MigrateUp(ctx context.Context) error {
+	m, err := db.newMigrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("error applying migrations: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateDown rolls back the given number of migrations.
+func (db *DB) MigrateDown(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+
+	m, err := db.newMigrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("error rolling back migrations: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	m, err := db.newMigrator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	status := &MigrationStatus{}
+	status.Version, status.Dirty, err = m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	names, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("error listing migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prefix, description, _ := strings.Cut(strings.TrimSuffix(name, ".up.sql"), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		status.Migrations = append(status.Migrations, Migration{
+			Version: uint(version),
+			Name:    description,
+			Applied: uint(version) <= status.Version,
+		})
+	}
+
+	return status, nil
+}
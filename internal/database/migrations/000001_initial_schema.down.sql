@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS lead_status_history;
+DROP TABLE IF EXISTS intent_score_history;
+DROP TABLE IF EXISTS interactions;
+DROP TABLE IF EXISTS message_templates;
+DROP TABLE IF EXISTS target_audiences;
+DROP TABLE IF EXISTS campaign_ai_agent;
+DROP TABLE IF EXISTS campaigns;
+DROP TABLE IF EXISTS agent_runs;
+DROP TABLE IF EXISTS agent_schedules;
+DROP TABLE IF EXISTS agent_stats;
+DROP TABLE IF EXISTS lead_ai_agent;
+DROP TABLE IF EXISTS ai_agents;
+DROP TABLE IF EXISTS leads;
+DROP TABLE IF EXISTS client_service;
+DROP TABLE IF EXISTS services;
+DROP TABLE IF EXISTS user_client;
+DROP TABLE IF EXISTS clients;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS agencies;
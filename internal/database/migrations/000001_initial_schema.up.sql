@@ -0,0 +1,263 @@
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE agencies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL,
+    phone TEXT,
+    position TEXT,
+    status TEXT NOT NULL DEFAULT 'ACTIVE',
+    password_hash TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
+
+CREATE TABLE clients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    industry TEXT NOT NULL,
+    website TEXT,
+    contact_person TEXT NOT NULL,
+    email TEXT NOT NULL,
+    phone TEXT,
+    address TEXT,
+    start_date TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX clients_agency_id_idx ON clients (agency_id);
+
+CREATE TABLE user_client (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, client_id)
+);
+
+CREATE TABLE services (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    price NUMERIC(12, 2) NOT NULL,
+    features TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE client_service (
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services (id) ON DELETE CASCADE,
+    PRIMARY KEY (client_id, service_id)
+);
+
+CREATE TABLE leads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    phone TEXT,
+    company TEXT,
+    position TEXT,
+    status TEXT NOT NULL DEFAULT 'NEW',
+    intent_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    source TEXT,
+    last_contact TIMESTAMPTZ,
+    next_follow_up TIMESTAMPTZ,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX leads_agency_id_idx ON leads (agency_id);
+CREATE INDEX leads_status_idx ON leads (status);
+
+CREATE TABLE ai_agents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    purpose TEXT NOT NULL,
+    description TEXT,
+    status TEXT NOT NULL,
+    last_run TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX ai_agents_agency_id_idx ON ai_agents (agency_id);
+
+CREATE TABLE lead_ai_agent (
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    ai_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (lead_id, ai_agent_id)
+);
+
+CREATE TABLE agent_stats (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    leads_engaged INTEGER NOT NULL DEFAULT 0,
+    messages_delivered INTEGER NOT NULL DEFAULT 0,
+    response_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    conversion_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    avg_response_time DOUBLE PRECISION NOT NULL DEFAULT 0,
+    period TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX agent_stats_agent_id_idx ON agent_stats (agent_id, created_at DESC);
+
+CREATE TABLE agent_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    cron TEXT NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX agent_schedules_next_run_at_idx ON agent_schedules (next_run_at) WHERE enabled;
+
+CREATE TABLE agent_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    schedule_id UUID REFERENCES agent_schedules (id) ON DELETE SET NULL,
+    status TEXT NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX agent_runs_agent_id_idx ON agent_runs (agent_id, created_at DESC);
+CREATE INDEX agent_runs_queue_idx ON agent_runs (scheduled_for) WHERE status = 'QUEUED';
+
+CREATE TABLE campaigns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    client_id UUID REFERENCES clients (id) ON DELETE SET NULL,
+    start_date TIMESTAMPTZ NOT NULL,
+    end_date TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'DRAFT',
+    budget NUMERIC(12, 2),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX campaigns_agency_id_idx ON campaigns (agency_id);
+CREATE INDEX campaigns_client_id_idx ON campaigns (client_id);
+CREATE INDEX campaigns_scheduled_idx ON campaigns (start_date) WHERE status = 'SCHEDULED';
+
+CREATE TABLE campaign_ai_agent (
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    ai_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    PRIMARY KEY (campaign_id, ai_agent_id)
+);
+
+CREATE INDEX campaign_ai_agent_ai_agent_id_idx ON campaign_ai_agent (ai_agent_id);
+
+CREATE TABLE target_audiences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    industry TEXT NOT NULL,
+    company_size TEXT,
+    location TEXT,
+    decision_maker_role TEXT,
+    pain_points TEXT[],
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX target_audiences_campaign_id_idx ON target_audiences (campaign_id);
+
+CREATE TABLE message_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    variables TEXT[],
+    channel TEXT NOT NULL,
+    purpose TEXT NOT NULL,
+    ai_agent_id UUID REFERENCES ai_agents (id) ON DELETE SET NULL,
+    campaign_id UUID REFERENCES campaigns (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX message_templates_agency_id_idx ON message_templates (agency_id);
+CREATE INDEX message_templates_campaign_id_idx ON message_templates (campaign_id);
+
+CREATE TABLE interactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    message TEXT,
+    ai_agent_id UUID REFERENCES ai_agents (id) ON DELETE SET NULL,
+    template_id UUID REFERENCES message_templates (id) ON DELETE SET NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    response TEXT,
+    status TEXT NOT NULL,
+    direction TEXT NOT NULL DEFAULT 'OUTBOUND',
+    external_id TEXT,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX interactions_lead_id_idx ON interactions (lead_id, timestamp DESC);
+CREATE INDEX interactions_external_id_idx ON interactions (external_id) WHERE external_id IS NOT NULL;
+
+CREATE TABLE intent_score_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    previous_score DOUBLE PRECISION,
+    source TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX intent_score_history_lead_id_idx ON intent_score_history (lead_id, created_at DESC);
+
+CREATE TABLE lead_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT,
+    changed_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX lead_status_history_lead_id_idx ON lead_status_history (lead_id, created_at DESC);
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX api_keys_agency_id_idx ON api_keys (agency_id);
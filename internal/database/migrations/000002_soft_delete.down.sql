@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS clients_deleted_at_idx;
+DROP INDEX IF EXISTS leads_deleted_at_idx;
+
+ALTER TABLE clients DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE leads DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,5 @@
+ALTER TABLE leads ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE clients ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX leads_deleted_at_idx ON leads (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX clients_deleted_at_idx ON clients (deleted_at) WHERE deleted_at IS NOT NULL;
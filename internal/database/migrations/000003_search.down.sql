@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS interactions_search_idx;
+DROP INDEX IF EXISTS campaigns_search_idx;
+DROP INDEX IF EXISTS clients_search_idx;
+DROP INDEX IF EXISTS leads_search_idx;
+
+ALTER TABLE interactions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE clients DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE leads DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,31 @@
+ALTER TABLE leads ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(email, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(company, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(position, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(notes, '')), 'C')
+) STORED;
+
+ALTER TABLE clients ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(contact_person, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(email, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(industry, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(notes, '')), 'C')
+) STORED;
+
+ALTER TABLE campaigns ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+ALTER TABLE interactions ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(message, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(response, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(notes, '')), 'C')
+) STORED;
+
+CREATE INDEX leads_search_idx ON leads USING GIN (search_vector);
+CREATE INDEX clients_search_idx ON clients USING GIN (search_vector);
+CREATE INDEX campaigns_search_idx ON campaigns USING GIN (search_vector);
+CREATE INDEX interactions_search_idx ON interactions USING GIN (search_vector);
@@ -0,0 +1,16 @@
+-- Suppression list. Email opt-outs are keyed by the lowercased address and
+-- phone opt-outs by the last ten digits of the number, the same way inbound
+-- messages are matched to leads.
+CREATE TABLE opt_outs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    email TEXT,
+    phone TEXT,
+    channel TEXT NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (email IS NOT NULL OR phone IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX opt_outs_email_idx ON opt_outs (agency_id, email, channel) WHERE email IS NOT NULL;
+CREATE UNIQUE INDEX opt_outs_phone_idx ON opt_outs (agency_id, phone, channel) WHERE phone IS NOT NULL;
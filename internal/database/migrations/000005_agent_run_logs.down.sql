@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS agent_run_logs;
+
+ALTER TABLE agent_runs DROP COLUMN IF EXISTS error_count;
+ALTER TABLE agent_runs DROP COLUMN IF EXISTS messages_sent;
+ALTER TABLE agent_runs DROP COLUMN IF EXISTS leads_processed;
@@ -0,0 +1,13 @@
+ALTER TABLE agent_runs ADD COLUMN leads_processed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE agent_runs ADD COLUMN messages_sent INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE agent_runs ADD COLUMN error_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE agent_run_logs (
+    id BIGSERIAL PRIMARY KEY,
+    run_id UUID NOT NULL REFERENCES agent_runs (id) ON DELETE CASCADE,
+    level TEXT NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX agent_run_logs_run_id_idx ON agent_run_logs (run_id, id);
@@ -0,0 +1 @@
+ALTER TABLE leads DROP COLUMN IF EXISTS deal_value;
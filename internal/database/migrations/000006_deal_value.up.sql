@@ -0,0 +1 @@
+ALTER TABLE leads ADD COLUMN deal_value NUMERIC(12, 2);
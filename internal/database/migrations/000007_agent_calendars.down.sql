@@ -0,0 +1 @@
+ALTER TABLE ai_agents DROP COLUMN IF EXISTS calendar_id;
@@ -0,0 +1 @@
+ALTER TABLE ai_agents ADD COLUMN calendar_id TEXT;
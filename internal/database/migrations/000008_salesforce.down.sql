@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS sync_errors;
+
+ALTER TABLE leads DROP COLUMN IF EXISTS salesforce_synced_at;
+ALTER TABLE leads DROP COLUMN IF EXISTS salesforce_opportunity_id;
+ALTER TABLE leads DROP COLUMN IF EXISTS salesforce_lead_id;
+
+DROP TABLE IF EXISTS salesforce_connections;
@@ -0,0 +1,31 @@
+-- OAuth tokens of each agency's Salesforce org, encrypted by the application.
+CREATE TABLE salesforce_connections (
+    agency_id UUID PRIMARY KEY REFERENCES agencies (id) ON DELETE CASCADE,
+    instance_url TEXT NOT NULL,
+    access_token TEXT NOT NULL,
+    refresh_token TEXT NOT NULL,
+    connected_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    connected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE leads ADD COLUMN salesforce_lead_id TEXT;
+ALTER TABLE leads ADD COLUMN salesforce_opportunity_id TEXT;
+ALTER TABLE leads ADD COLUMN salesforce_synced_at TIMESTAMPTZ;
+
+-- Problems found while syncing leads to external CRMs. At most one error of
+-- each kind is open per lead, or per agency for errors not tied to a lead.
+CREATE TABLE sync_errors (
+    id BIGSERIAL PRIMARY KEY,
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    lead_id UUID REFERENCES leads (id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX sync_errors_open_idx ON sync_errors (agency_id, provider, kind, (COALESCE(lead_id::text, '')))
+    WHERE resolved_at IS NULL;
+CREATE INDEX sync_errors_agency_id_idx ON sync_errors (agency_id, detected_at DESC);
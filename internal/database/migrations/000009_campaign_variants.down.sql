@@ -0,0 +1,3 @@
+ALTER TABLE interactions DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS campaign_variants;
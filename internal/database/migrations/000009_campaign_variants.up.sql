@@ -0,0 +1,14 @@
+-- Message templates competing in a campaign's A/B test. Weights are relative
+-- shares of traffic; a weight of zero stops new leads being assigned.
+CREATE TABLE campaign_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    template_id UUID NOT NULL REFERENCES message_templates (id) ON DELETE CASCADE,
+    weight INTEGER NOT NULL CHECK (weight >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (campaign_id, template_id)
+);
+
+ALTER TABLE interactions ADD COLUMN variant_id UUID REFERENCES campaign_variants (id) ON DELETE SET NULL;
+
+CREATE INDEX interactions_variant_id_idx ON interactions (variant_id) WHERE variant_id IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Client-specific intent scoring rules. The rules themselves are JSON
+-- validated by the scoring package; each client has at most one active set.
+CREATE TABLE scoring_rulesets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    rules JSONB NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX scoring_rulesets_client_id_idx ON scoring_rulesets (client_id);
+CREATE UNIQUE INDEX scoring_rulesets_active_idx ON scoring_rulesets (client_id) WHERE active;
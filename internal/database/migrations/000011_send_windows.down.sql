@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS outbound_queue;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS send_window;
+ALTER TABLE leads DROP COLUMN IF EXISTS timezone;
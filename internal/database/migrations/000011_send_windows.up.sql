@@ -0,0 +1,18 @@
+-- IANA time zone of the lead, e.g. Europe/Berlin, used for send windows.
+ALTER TABLE leads ADD COLUMN timezone TEXT;
+
+-- Hours in the lead's local time during which a campaign may send, as JSON
+-- validated by the sendwindow package. NULL sends at any time.
+ALTER TABLE campaigns ADD COLUMN send_window JSONB;
+
+-- Outbound messages held back until the send window opens. The interaction
+-- stays SCHEDULED until the message is released.
+CREATE TABLE outbound_queue (
+    interaction_id UUID PRIMARY KEY REFERENCES interactions (id) ON DELETE CASCADE,
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    subject TEXT NOT NULL DEFAULT '',
+    release_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX outbound_queue_release_at_idx ON outbound_queue (release_at);
@@ -0,0 +1,3 @@
+ALTER TABLE campaigns DROP COLUMN IF EXISTS budget_alerted;
+
+DROP TABLE IF EXISTS campaign_spend;
@@ -0,0 +1,16 @@
+-- Ledger of what a campaign has cost: outbound messages, enrichment calls and
+-- other spend recorded against it.
+CREATE TABLE campaign_spend (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    interaction_id UUID REFERENCES interactions (id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    amount NUMERIC(12, 4) NOT NULL CHECK (amount >= 0),
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX campaign_spend_campaign_id_idx ON campaign_spend (campaign_id, created_at);
+
+-- The budget the spend alert was last sent for, so each budget alerts once.
+ALTER TABLE campaigns ADD COLUMN budget_alerted NUMERIC(12, 2);
@@ -0,0 +1,18 @@
+-- Files attached to a lead or an interaction. The content lives in object
+-- storage under storage_key.
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    lead_id UUID REFERENCES leads (id) ON DELETE CASCADE,
+    interaction_id UUID REFERENCES interactions (id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    uploaded_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((lead_id IS NULL) <> (interaction_id IS NULL))
+);
+
+CREATE INDEX attachments_lead_id_idx ON attachments (lead_id) WHERE lead_id IS NOT NULL;
+CREATE INDEX attachments_interaction_id_idx ON attachments (interaction_id) WHERE interaction_id IS NOT NULL;
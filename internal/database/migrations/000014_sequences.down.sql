@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sequence_enrollments;
+DROP TABLE IF EXISTS sequence_steps;
+DROP TABLE IF EXISTS sequences;
@@ -0,0 +1,41 @@
+-- Multi-step outreach sequences. Each step runs a number of days after the
+-- lead was enrolled: automated channels send the step's template, others
+-- create a follow-up task for a rep.
+CREATE TABLE sequences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX sequences_campaign_id_idx ON sequences (campaign_id);
+
+CREATE TABLE sequence_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sequence_id UUID NOT NULL REFERENCES sequences (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    day INTEGER NOT NULL CHECK (day >= 0),
+    channel TEXT NOT NULL,
+    template_id UUID REFERENCES message_templates (id) ON DELETE SET NULL,
+    task TEXT,
+    UNIQUE (sequence_id, position)
+);
+
+-- current_step is the position of the next step to run, so it also counts
+-- the steps already run.
+CREATE TABLE sequence_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sequence_id UUID NOT NULL REFERENCES sequences (id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    current_step INTEGER NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMPTZ,
+    enrolled_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    UNIQUE (sequence_id, lead_id)
+);
+
+CREATE INDEX sequence_enrollments_next_run_at_idx ON sequence_enrollments (next_run_at) WHERE status = 'ACTIVE';
+CREATE INDEX sequence_enrollments_lead_id_idx ON sequence_enrollments (lead_id);
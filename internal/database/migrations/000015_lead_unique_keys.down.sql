@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS leads_agency_phone_key;
+DROP INDEX IF EXISTS leads_agency_email_key;
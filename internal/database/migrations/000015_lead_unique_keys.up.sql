@@ -0,0 +1,5 @@
+-- Live leads are unique per agency by email, ignoring case, and by phone.
+-- Soft-deleted leads are left out so a lead can be re-created after deletion.
+-- Duplicates already in the table must be merged or deleted before this runs.
+CREATE UNIQUE INDEX leads_agency_email_key ON leads (agency_id, lower(email)) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX leads_agency_phone_key ON leads (agency_id, phone) WHERE deleted_at IS NULL AND phone IS NOT NULL;
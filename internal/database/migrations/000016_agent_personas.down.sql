@@ -0,0 +1,2 @@
+ALTER TABLE interactions DROP COLUMN IF EXISTS persona_id;
+DROP TABLE IF EXISTS agent_personas;
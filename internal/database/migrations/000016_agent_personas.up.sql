@@ -0,0 +1,18 @@
+-- Personas shape how an AI agent writes. Every update adds a new version, so
+-- interactions keep pointing at the persona that was current when they were
+-- recorded.
+CREATE TABLE agent_personas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    tone TEXT,
+    language TEXT,
+    signature TEXT,
+    dos TEXT[] NOT NULL DEFAULT '{}',
+    donts TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (agent_id, version)
+);
+
+ALTER TABLE interactions ADD COLUMN persona_id UUID REFERENCES agent_personas (id) ON DELETE SET NULL;
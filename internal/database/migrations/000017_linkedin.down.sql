@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS interactions_linkedin_agent_idx;
+DROP TABLE IF EXISTS linkedin_profiles;
@@ -0,0 +1,17 @@
+-- A lead's LinkedIn profile and where the connection with it stands. The
+-- first LinkedIn message to a lead is sent as a connection request, later
+-- ones as InMail.
+CREATE TABLE linkedin_profiles (
+    lead_id UUID PRIMARY KEY REFERENCES leads (id) ON DELETE CASCADE,
+    profile_url TEXT NOT NULL,
+    connection_status TEXT NOT NULL DEFAULT 'NONE',
+    requested_at TIMESTAMPTZ,
+    connected_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX linkedin_profiles_profile_url_idx ON linkedin_profiles (profile_url);
+
+-- Daily send caps count each agent's LinkedIn messages.
+CREATE INDEX interactions_linkedin_agent_idx ON interactions (ai_agent_id, timestamp) WHERE channel = 'LINKEDIN' AND direction = 'OUTBOUND';
@@ -0,0 +1,4 @@
+-- Versions for optimistic concurrency: edits name the version they were made
+-- against and fail when the row has moved on.
+ALTER TABLE leads ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE clients ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- Which leads a campaign is working, and how far each has got. Leads that
+-- leave a campaign are kept as EXITED.
+CREATE TABLE campaign_leads (
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'ENROLLED',
+    enrolled_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    enrolled_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (campaign_id, lead_id)
+);
+
+CREATE INDEX campaign_leads_lead_id_idx ON campaign_leads (lead_id);
@@ -0,0 +1,14 @@
+-- Saved lead filters. lead_count is refreshed periodically so segment sizes
+-- can be shown without running every filter.
+CREATE TABLE segments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    lead_count INTEGER,
+    counted_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    UNIQUE (agency_id, name)
+);
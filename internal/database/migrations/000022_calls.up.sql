@@ -0,0 +1,13 @@
+-- Call details for CALL interactions, logged by hand or reported by a VoIP
+-- provider. Provider call IDs make provider webhooks idempotent.
+CREATE TABLE calls (
+    interaction_id UUID PRIMARY KEY REFERENCES interactions (id) ON DELETE CASCADE,
+    outcome TEXT NOT NULL,
+    duration_seconds INTEGER,
+    recording_url TEXT,
+    provider TEXT NOT NULL,
+    provider_call_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    UNIQUE (provider, provider_call_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE leads DROP COLUMN IF EXISTS forgotten_at;
+ALTER TABLE agencies DROP COLUMN IF EXISTS lead_retention_days;
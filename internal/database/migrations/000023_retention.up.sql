@@ -0,0 +1,6 @@
+-- Leads with no activity for lead_retention_days are purged. NULL keeps
+-- leads indefinitely.
+ALTER TABLE agencies ADD COLUMN lead_retention_days INTEGER CHECK (lead_retention_days > 0);
+
+-- Set when a lead's personal data was erased. Forgotten leads stay deleted.
+ALTER TABLE leads ADD COLUMN forgotten_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+ALTER TABLE agencies DROP COLUMN IF EXISTS capture_key;
+DROP TABLE IF EXISTS lead_attribution;
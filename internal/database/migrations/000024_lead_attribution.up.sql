@@ -0,0 +1,21 @@
+-- Where a lead came from. The first capture of a lead is kept, so later
+-- form submissions do not overwrite the original touch.
+CREATE TABLE lead_attribution (
+    lead_id UUID PRIMARY KEY REFERENCES leads (id) ON DELETE CASCADE,
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    utm_source TEXT,
+    utm_medium TEXT,
+    utm_campaign TEXT,
+    utm_term TEXT,
+    utm_content TEXT,
+    referrer TEXT,
+    landing_page TEXT,
+    campaign_id UUID REFERENCES campaigns (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX lead_attribution_agency_id_created_at_idx ON lead_attribution (agency_id, created_at);
+
+-- Identifies the agency on public lead capture forms. It is embedded in web
+-- pages, so it is stored as is and can be rotated.
+ALTER TABLE agencies ADD COLUMN capture_key TEXT UNIQUE;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS lead_ai_agent_ai_agent_id_idx;
+ALTER TABLE ai_agents DROP COLUMN IF EXISTS max_active_leads;
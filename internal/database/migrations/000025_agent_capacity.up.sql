@@ -0,0 +1,4 @@
+-- The most leads an agent works at once; NULL means no limit.
+ALTER TABLE ai_agents ADD COLUMN max_active_leads INTEGER CHECK (max_active_leads > 0);
+
+CREATE INDEX lead_ai_agent_ai_agent_id_idx ON lead_ai_agent (ai_agent_id, assigned_at);
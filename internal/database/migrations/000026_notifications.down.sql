@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS slack_webhook_url;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
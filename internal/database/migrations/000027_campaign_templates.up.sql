@@ -0,0 +1,15 @@
+-- Reusable campaign setups that agencies instantiate for new clients.
+-- blueprint holds the audiences, messages, sequences, A/B split and agents
+-- of the campaign the template was saved from, but none of its leads or
+-- results.
+CREATE TABLE campaign_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    blueprint JSONB NOT NULL,
+    source_campaign_id UUID REFERENCES campaigns (id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (agency_id, name)
+);
@@ -0,0 +1,24 @@
+-- Sales opportunities and their value. A deal stays when its lead, client
+-- or campaign is deleted so revenue reports keep adding up. closed_at is set
+-- when the deal is WON or LOST.
+CREATE TABLE deals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    lead_id UUID REFERENCES leads (id) ON DELETE SET NULL,
+    client_id UUID REFERENCES clients (id) ON DELETE SET NULL,
+    campaign_id UUID REFERENCES campaigns (id) ON DELETE SET NULL,
+    value NUMERIC(12, 2) NOT NULL CHECK (value >= 0),
+    currency CHAR(3) NOT NULL,
+    stage TEXT NOT NULL,
+    expected_close_date DATE,
+    closed_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX deals_agency_id_idx ON deals (agency_id, created_at DESC);
+CREATE INDEX deals_lead_id_idx ON deals (lead_id);
+CREATE INDEX deals_client_id_idx ON deals (client_id);
+CREATE INDEX deals_campaign_id_idx ON deals (campaign_id);
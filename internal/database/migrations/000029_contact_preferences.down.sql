@@ -0,0 +1,2 @@
+ALTER TABLE campaigns DROP COLUMN IF EXISTS fallback_rules;
+DROP TABLE IF EXISTS lead_contact_preferences;
@@ -0,0 +1,15 @@
+-- How each lead wants to be contacted. Quiet hours are HH:MM local times in
+-- the lead's time zone during which no message is sent; they may run past
+-- midnight.
+CREATE TABLE lead_contact_preferences (
+    lead_id UUID PRIMARY KEY REFERENCES leads (id) ON DELETE CASCADE,
+    preferred_channel TEXT,
+    quiet_hours_start TEXT,
+    quiet_hours_end TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+);
+
+-- Rules the sequence engine follows to switch a step to another channel,
+-- as a JSON array. NULL means steps always use their own channel.
+ALTER TABLE campaigns ADD COLUMN fallback_rules JSONB;
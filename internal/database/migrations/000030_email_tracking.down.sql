@@ -0,0 +1,2 @@
+ALTER TABLE opt_outs DROP COLUMN IF EXISTS suppression;
+DROP TABLE IF EXISTS email_events;
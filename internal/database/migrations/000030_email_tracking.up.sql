@@ -0,0 +1,16 @@
+-- Opens, clicks, bounces and spam complaints reported for outbound emails.
+-- url is the clicked link; detail is the provider's bounce reason.
+CREATE TABLE email_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    interaction_id UUID NOT NULL REFERENCES interactions (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    url TEXT,
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX email_events_interaction_id_idx ON email_events (interaction_id, type);
+
+-- Set on email opt-outs made because the address bounced or its owner
+-- complained, rather than because the lead unsubscribed.
+ALTER TABLE opt_outs ADD COLUMN suppression TEXT;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS interaction_drafts;
+ALTER TABLE ai_agents DROP COLUMN IF EXISTS dry_run;
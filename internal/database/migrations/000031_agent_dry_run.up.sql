@@ -0,0 +1,12 @@
+-- Agents in dry-run mode store their messages as PENDING_REVIEW interactions
+-- instead of sending them.
+ALTER TABLE ai_agents ADD COLUMN dry_run BOOLEAN NOT NULL DEFAULT false;
+
+-- What is needed to send a draft once approved, and who reviewed it.
+CREATE TABLE interaction_drafts (
+    interaction_id UUID PRIMARY KEY REFERENCES interactions (id) ON DELETE CASCADE,
+    subject TEXT NOT NULL DEFAULT '',
+    reviewed_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
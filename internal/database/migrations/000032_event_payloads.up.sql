@@ -0,0 +1,10 @@
+-- Events relayed between server instances that are too large for a NOTIFY
+-- payload. Rows are only needed until every instance has read them and are
+-- deleted after a minute.
+CREATE TABLE event_payloads (
+    id BIGSERIAL PRIMARY KEY,
+    payload BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX event_payloads_created_at_idx ON event_payloads (created_at);
@@ -0,0 +1,21 @@
+-- Lead attributes an agency defines for itself, such as "fleet size".
+-- options apply to SELECT fields, pattern to TEXT fields, and min and max to
+-- NUMBER fields.
+CREATE TABLE custom_field_definitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    label TEXT NOT NULL,
+    type TEXT NOT NULL,
+    options TEXT[],
+    pattern TEXT,
+    min DOUBLE PRECISION,
+    max DOUBLE PRECISION,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    UNIQUE (agency_id, key)
+);
+
+-- Values by definition key: numbers and booleans as JSON numbers and
+-- booleans, everything else as strings.
+ALTER TABLE leads ADD COLUMN custom_fields JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS agent_send_counts;
+DROP TABLE IF EXISTS agent_send_throttles;
@@ -0,0 +1,23 @@
+-- Daily send limits per agent and channel. The limit starts at daily_limit
+-- and grows by ramp_per_day each day since started_at, up to
+-- max_daily_limit when set, so new sender domains warm up gradually.
+CREATE TABLE agent_send_throttles (
+    ai_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    channel TEXT NOT NULL,
+    daily_limit INTEGER NOT NULL CHECK (daily_limit > 0),
+    ramp_per_day INTEGER NOT NULL DEFAULT 0 CHECK (ramp_per_day >= 0),
+    max_daily_limit INTEGER CHECK (max_daily_limit >= daily_limit),
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (ai_agent_id, channel)
+);
+
+-- Messages sent per agent, channel and UTC day, counted for throttled
+-- channels only.
+CREATE TABLE agent_send_counts (
+    ai_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    channel TEXT NOT NULL,
+    day DATE NOT NULL,
+    sent INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (ai_agent_id, channel, day)
+);
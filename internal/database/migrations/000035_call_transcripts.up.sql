@@ -0,0 +1,21 @@
+-- Transcripts of call recordings, made in the background. The recording is
+-- an audio attachment of the CALL interaction or, when attachment_id is
+-- NULL, the recording URL of the call.
+CREATE TABLE call_transcripts (
+    interaction_id UUID PRIMARY KEY REFERENCES interactions (id) ON DELETE CASCADE,
+    attachment_id UUID REFERENCES attachments (id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    text TEXT,
+    key_points TEXT[],
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ,
+    search_vector tsvector GENERATED ALWAYS AS (
+        to_tsvector('english', coalesce(text, ''))
+    ) STORED
+);
+
+CREATE INDEX call_transcripts_search_idx ON call_transcripts USING GIN (search_vector);
+CREATE INDEX call_transcripts_pending_idx ON call_transcripts (next_attempt_at) WHERE status = 'PENDING';
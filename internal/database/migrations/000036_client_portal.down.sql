@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS campaign_approvals;
+DROP TABLE IF EXISTS campaign_comments;
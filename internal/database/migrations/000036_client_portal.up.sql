@@ -0,0 +1,18 @@
+-- Comments client portal users leave on their campaigns.
+CREATE TABLE campaign_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX campaign_comments_campaign_id_idx ON campaign_comments (campaign_id, created_at);
+
+-- A client's sign-off on a campaign. Approving again replaces the row.
+CREATE TABLE campaign_approvals (
+    campaign_id UUID PRIMARY KEY REFERENCES campaigns (id) ON DELETE CASCADE,
+    approved_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    approved_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,15 @@
+CREATE TABLE agent_stats (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    leads_engaged INTEGER NOT NULL DEFAULT 0,
+    messages_delivered INTEGER NOT NULL DEFAULT 0,
+    response_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    conversion_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    avg_response_time DOUBLE PRECISION NOT NULL DEFAULT 0,
+    period TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX agent_stats_agent_id_idx ON agent_stats (agent_id, created_at DESC);
+
+DROP TABLE IF EXISTS agent_daily_stats;
@@ -0,0 +1,18 @@
+-- One row per AI agent and UTC day, rebuilt by the agent stats rollup job.
+-- Counts add up across days; rates are derived when days are rolled up.
+CREATE TABLE agent_daily_stats (
+    agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    leads_engaged INTEGER NOT NULL DEFAULT 0,
+    messages_delivered INTEGER NOT NULL DEFAULT 0,
+    replies INTEGER NOT NULL DEFAULT 0,
+    conversions INTEGER NOT NULL DEFAULT 0,
+    -- Total time the day's replies took to arrive after the agent's message.
+    response_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (agent_id, day)
+);
+
+-- Replaced by agent_daily_stats. Nothing but placeholder rows was ever
+-- written to it.
+DROP TABLE agent_stats;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS integration_hooks;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS integration;
@@ -0,0 +1,15 @@
+-- Keys made for a no-code tool such as Zapier only work on the integrations
+-- API.
+ALTER TABLE api_keys ADD COLUMN integration TEXT;
+
+-- Instant trigger subscriptions. Revoking the key that made a hook stops it.
+CREATE TABLE integration_hooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    api_key_id UUID NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    target_url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX integration_hooks_agency_id_idx ON integration_hooks (agency_id, event);
@@ -0,0 +1,16 @@
+-- Leads escalated from AI outreach to a human sales rep. A lead has at most
+-- one open handoff; AI outreach to it is paused until it is resolved.
+CREATE TABLE lead_handoffs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    rep_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    escalated_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    picked_up_at TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX lead_handoffs_open_lead_id_idx ON lead_handoffs (lead_id) WHERE resolved_at IS NULL;
+CREATE INDEX lead_handoffs_rep_id_idx ON lead_handoffs (rep_id, created_at);
@@ -0,0 +1,9 @@
+-- When each booked meeting takes place. The MEETING interaction records when
+-- it was booked.
+CREATE TABLE meetings (
+    interaction_id UUID PRIMARY KEY REFERENCES interactions (id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX meetings_starts_at_idx ON meetings (starts_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sla_checks;
+DROP TABLE IF EXISTS slas;
@@ -0,0 +1,39 @@
+-- Response time commitments made to clients, such as answering inbound
+-- replies within 4 working hours. working_hours is a send window as JSON,
+-- validated by the sendwindow package; without one the clock always runs.
+-- Replies and escalations are checked from enabled_at on, which is null
+-- while the SLA is disabled.
+CREATE TABLE slas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    target_minutes INTEGER NOT NULL CHECK (target_minutes > 0),
+    working_hours JSONB,
+    enabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX slas_client_id_idx ON slas (client_id);
+
+-- One reply or escalation an SLA applies to. due_at is computed from the
+-- SLA's working hours when the check is created; breached_at is set when
+-- the evaluation job finds it answered late or still unanswered past due.
+CREATE TABLE sla_checks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sla_id UUID NOT NULL REFERENCES slas (id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    interaction_id UUID REFERENCES interactions (id) ON DELETE CASCADE,
+    handoff_id UUID REFERENCES lead_handoffs (id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL,
+    due_at TIMESTAMPTZ NOT NULL,
+    responded_at TIMESTAMPTZ,
+    breached_at TIMESTAMPTZ,
+    UNIQUE (sla_id, interaction_id),
+    UNIQUE (sla_id, handoff_id)
+);
+
+CREATE INDEX sla_checks_sla_id_idx ON sla_checks (sla_id, started_at);
+CREATE INDEX sla_checks_open_idx ON sla_checks (due_at) WHERE responded_at IS NULL OR breached_at IS NULL;
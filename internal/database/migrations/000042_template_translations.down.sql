@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS message_template_translations;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS default_language;
+ALTER TABLE leads DROP COLUMN IF EXISTS language;
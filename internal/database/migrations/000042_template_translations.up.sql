@@ -0,0 +1,18 @@
+-- Language of the lead as a locale such as "de" or "pt-BR", used to pick
+-- template translations.
+ALTER TABLE leads ADD COLUMN language TEXT;
+
+-- Locale a campaign's templates are sent in when a lead's language has no
+-- translation.
+ALTER TABLE campaigns ADD COLUMN default_language TEXT;
+
+-- Content of a message template in another locale. The template's own
+-- content is sent when no translation applies.
+CREATE TABLE message_template_translations (
+    template_id UUID NOT NULL REFERENCES message_templates (id) ON DELETE CASCADE,
+    locale TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (template_id, locale)
+);
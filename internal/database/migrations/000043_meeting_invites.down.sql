@@ -0,0 +1,5 @@
+ALTER TABLE meetings DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE meetings DROP COLUMN IF EXISTS sequence;
+ALTER TABLE meetings DROP COLUMN IF EXISTS url;
+ALTER TABLE meetings DROP COLUMN IF EXISTS booked_by;
+ALTER TABLE meetings DROP COLUMN IF EXISTS calendar_id;
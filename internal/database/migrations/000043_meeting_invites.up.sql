@@ -0,0 +1,15 @@
+-- What a booked meeting needs to be rescheduled or cancelled from the links
+-- in its invite: the calendar it was booked on, who booked it, its call
+-- link, and the SEQUENCE of the last invite sent, which calendar apps use to
+-- tell updates apart.
+ALTER TABLE meetings ADD COLUMN calendar_id TEXT;
+ALTER TABLE meetings ADD COLUMN booked_by UUID REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE meetings ADD COLUMN url TEXT;
+ALTER TABLE meetings ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE meetings ADD COLUMN cancelled_at TIMESTAMPTZ;
+
+-- Meetings booked so far were booked on their agent's calendar, if it had
+-- one; the rest are on the default calendar, left as NULL.
+UPDATE meetings m SET calendar_id = a.calendar_id
+FROM interactions i JOIN ai_agents a ON a.id = i.ai_agent_id
+WHERE i.id = m.interaction_id AND a.calendar_id IS NOT NULL;
@@ -0,0 +1,18 @@
+UPDATE interactions SET status = 'DELIVERED' WHERE status = 'SENT';
+
+ALTER TABLE outbound_queue DROP COLUMN IF EXISTS attempts;
+
+-- The queue held only campaign messages before; the others are given up.
+UPDATE interactions i SET status = 'FAILED', notes = 'removed from the queue'
+FROM outbound_queue q
+WHERE q.interaction_id = i.id AND q.campaign_id IS NULL;
+DELETE FROM outbound_queue WHERE campaign_id IS NULL;
+ALTER TABLE outbound_queue ALTER COLUMN campaign_id SET NOT NULL;
+
+ALTER TABLE outbound_queue ADD COLUMN subject TEXT NOT NULL DEFAULT '';
+
+UPDATE outbound_queue q SET subject = i.subject
+FROM interactions i
+WHERE i.id = q.interaction_id AND i.subject IS NOT NULL;
+
+ALTER TABLE interactions DROP COLUMN IF EXISTS subject;
@@ -0,0 +1,19 @@
+-- Outbound messages are SENT once the provider accepts them and DELIVERED
+-- once it reports they arrived. Messages sent so far were marked DELIVERED
+-- on acceptance and are left as they are.
+
+-- The subject an outbound message was sent with, so failed messages can be
+-- resent. It moves here from the queue, which now holds messages outside
+-- campaigns too.
+ALTER TABLE interactions ADD COLUMN subject TEXT;
+
+UPDATE interactions i SET subject = q.subject
+FROM outbound_queue q
+WHERE q.interaction_id = i.id AND q.subject <> '';
+
+ALTER TABLE outbound_queue DROP COLUMN subject;
+ALTER TABLE outbound_queue ALTER COLUMN campaign_id DROP NOT NULL;
+
+-- Failed attempts to send a queued message, which is retried with backoff
+-- while the failures are transient.
+ALTER TABLE outbound_queue ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,28 @@
+-- Conversations with visitors of a client's website through the chat
+-- widget. A lead is created once the visitor leaves their contact details,
+-- and the transcript becomes its first interaction.
+CREATE TABLE chat_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    ai_agent_id UUID REFERENCES ai_agents (id) ON DELETE SET NULL,
+    lead_id UUID REFERENCES leads (id) ON DELETE SET NULL,
+    interaction_id UUID REFERENCES interactions (id) ON DELETE SET NULL,
+    referrer TEXT,
+    landing_page TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX chat_sessions_lead_id_idx ON chat_sessions (lead_id);
+
+-- VISITOR messages and the AGENT's replies, in order.
+CREATE TABLE chat_messages (
+    id BIGSERIAL PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES chat_sessions (id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX chat_messages_session_id_idx ON chat_messages (session_id, id);
@@ -0,0 +1,3 @@
+ALTER TABLE interactions DROP COLUMN IF EXISTS template_version_id;
+DROP TABLE IF EXISTS message_template_versions;
+ALTER TABLE message_templates DROP COLUMN IF EXISTS version;
@@ -0,0 +1,26 @@
+-- Every create, edit and revert of a message template saves a version, so
+-- interactions keep pointing at the content they were sent with.
+ALTER TABLE message_templates ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE message_template_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID NOT NULL REFERENCES message_templates (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    variables TEXT[],
+    channel TEXT NOT NULL,
+    purpose TEXT NOT NULL,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (template_id, version)
+);
+
+-- Existing templates start their history at their current content.
+INSERT INTO message_template_versions (template_id, version, name, content, variables, channel, purpose, created_at)
+SELECT id, 1, name, content, variables, channel, purpose, COALESCE(updated_at, created_at)
+FROM message_templates;
+
+-- Interactions recorded before now may have been sent with earlier content,
+-- so they are left without a version.
+ALTER TABLE interactions ADD COLUMN template_version_id UUID REFERENCES message_template_versions (id) ON DELETE SET NULL;
@@ -0,0 +1,22 @@
+-- Mutations run in the background. The user who started a job is kept with
+-- it, so it runs with their permissions; created_by has no foreign key
+-- because jobs may be started with an API key.
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'QUEUED',
+    input JSONB NOT NULL,
+    result JSONB,
+    error TEXT,
+    progress INTEGER NOT NULL DEFAULT 0,
+    total INTEGER,
+    created_by TEXT,
+    created_by_role TEXT NOT NULL,
+    created_by_client_ids TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX jobs_status_idx ON jobs (status, kind, created_at);
@@ -0,0 +1,14 @@
+-- What a campaign aims for, such as 30 meetings in 60 days. at_risk_since
+-- is set while the pacing monitor projects the goal to be missed, so each
+-- slip is notified once.
+CREATE TABLE campaign_goals (
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    target INTEGER NOT NULL,
+    days INTEGER NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    at_risk_since TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (campaign_id, metric)
+);
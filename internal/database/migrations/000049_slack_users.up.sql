@@ -0,0 +1,11 @@
+-- Slack users linked to an account with /lead link. Slash commands run as
+-- the linked user.
+CREATE TABLE slack_users (
+    team_id TEXT NOT NULL,
+    slack_user_id TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (team_id, slack_user_id)
+);
+
+CREATE INDEX slack_users_user_id_idx ON slack_users (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE campaigns DROP COLUMN IF EXISTS sending_identity_id;
+DROP TABLE IF EXISTS sending_identities;
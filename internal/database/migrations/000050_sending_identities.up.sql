@@ -0,0 +1,29 @@
+-- The addresses a client's campaigns email leads from. Mail goes out from an
+-- identity only once its domain and DKIM key are verified in DNS; a client
+-- without identities sends from the agency's own address.
+CREATE TABLE sending_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    from_name TEXT NOT NULL,
+    from_email TEXT NOT NULL,
+    domain TEXT NOT NULL,
+    dkim_selector TEXT NOT NULL,
+    verification_token TEXT NOT NULL,
+    domain_status TEXT NOT NULL DEFAULT 'PENDING',
+    dkim_status TEXT NOT NULL DEFAULT 'PENDING',
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    last_checked_at TIMESTAMPTZ,
+    verified_at TIMESTAMPTZ,
+    check_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX sending_identities_client_email_idx ON sending_identities (client_id, lower(from_email));
+CREATE UNIQUE INDEX sending_identities_client_default_idx ON sending_identities (client_id) WHERE is_default;
+CREATE INDEX sending_identities_pending_idx ON sending_identities (created_at)
+    WHERE domain_status = 'PENDING' OR dkim_status = 'PENDING';
+
+-- Campaigns without an identity of their own send from the client's default.
+ALTER TABLE campaigns ADD COLUMN sending_identity_id UUID REFERENCES sending_identities (id) ON DELETE SET NULL;
@@ -0,0 +1,53 @@
+-- Every change to a lead, in the order it was made. The leads table is the
+-- projection of these events: writes append them and apply them in the same
+-- transaction, so history can be replayed as of any time.
+CREATE TABLE lead_events (
+    id BIGSERIAL PRIMARY KEY,
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    field TEXT,
+    old_value JSONB,
+    new_value JSONB,
+    -- A user or an API key, so not a foreign key.
+    actor_id UUID,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- Consumers read events in commit order by transaction, which ids alone
+    -- do not give: a transaction can commit after a later one's events.
+    tx_id xid8 NOT NULL DEFAULT pg_current_xact_id()
+);
+
+CREATE INDEX lead_events_lead_id_idx ON lead_events (lead_id, id);
+CREATE INDEX lead_events_agency_occurred_at_idx ON lead_events (agency_id, occurred_at);
+CREATE INDEX lead_events_tx_id_idx ON lead_events (tx_id, id);
+
+-- Existing leads start from what is known of them: a snapshot at creation,
+-- with the status they held before their first recorded change, then their
+-- status history, assignments and last contact.
+INSERT INTO lead_events (agency_id, lead_id, type, field, old_value, new_value, actor_id, occurred_at)
+SELECT e.agency_id, e.lead_id, e.type, e.field, e.old_value, e.new_value, e.actor_id, e.occurred_at
+FROM (
+    SELECT l.agency_id, l.id AS lead_id, 'CREATED' AS type, NULL AS field, NULL::jsonb AS old_value,
+        jsonb_build_object(
+            'name', l.name, 'email', l.email, 'phone', l.phone, 'company', l.company, 'position', l.position,
+            'status', COALESCE(
+                (SELECT h.from_status FROM lead_status_history h WHERE h.lead_id = l.id ORDER BY h.created_at LIMIT 1),
+                l.status
+            ),
+            'intentScore', l.intent_score, 'tags', to_jsonb(l.tags), 'source', l.source, 'notes', l.notes,
+            'dealValue', l.deal_value, 'timezone', l.timezone, 'language', l.language
+        ) AS new_value,
+        NULL::uuid AS actor_id, l.created_at AS occurred_at, 0 AS rank
+    FROM leads l
+    UNION ALL
+    SELECT l.agency_id, h.lead_id, 'STATUS_CHANGED', 'status', to_jsonb(h.from_status), to_jsonb(h.to_status),
+        h.changed_by, h.created_at, 1
+    FROM lead_status_history h JOIN leads l ON l.id = h.lead_id
+    UNION ALL
+    SELECT l.agency_id, a.lead_id, 'ASSIGNED', 'aiAgent', NULL, to_jsonb(a.ai_agent_id::text), NULL, a.assigned_at, 1
+    FROM lead_ai_agent a JOIN leads l ON l.id = a.lead_id
+    UNION ALL
+    SELECT l.agency_id, l.id, 'CONTACTED', 'lastContact', NULL, to_jsonb(l.last_contact), NULL, l.last_contact, 1
+    FROM leads l WHERE l.last_contact IS NOT NULL
+) e
+ORDER BY e.occurred_at, e.rank;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lead_assignment_log;
+DROP TABLE IF EXISTS campaign_routing;
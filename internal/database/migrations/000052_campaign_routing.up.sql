@@ -0,0 +1,34 @@
+-- How inbound leads captured from web forms are shared out among the
+-- agency's active campaigns: each new lead goes to one of the campaigns whose
+-- rules it meets, by weighted round robin. credit is the campaign's running
+-- balance in the round robin.
+CREATE TABLE campaign_routing (
+    campaign_id UUID PRIMARY KEY REFERENCES campaigns (id) ON DELETE CASCADE,
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    weight INTEGER NOT NULL CHECK (weight > 0),
+    industries TEXT[] NOT NULL DEFAULT '{}',
+    regions TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    credit INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX campaign_routing_agency_id_idx ON campaign_routing (agency_id);
+
+-- Why each inbound lead landed where it did, with every campaign considered.
+CREATE TABLE lead_assignment_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    campaign_id UUID REFERENCES campaigns (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    industry TEXT,
+    timezone TEXT,
+    candidates JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX lead_assignment_log_agency_created_at_idx ON lead_assignment_log (agency_id, created_at);
+CREATE INDEX lead_assignment_log_lead_id_idx ON lead_assignment_log (lead_id);
+CREATE INDEX lead_assignment_log_campaign_id_idx ON lead_assignment_log (campaign_id, created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS warehouse_exports;
+DROP INDEX IF EXISTS interactions_changed_at_idx;
+DROP INDEX IF EXISTS leads_changed_at_idx;
+ALTER TABLE interactions DROP COLUMN IF EXISTS updated_at;
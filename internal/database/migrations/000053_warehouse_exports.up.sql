@@ -0,0 +1,16 @@
+-- Interactions change after they are stored, as messages are sent, delivered
+-- and replied to, so they get an updated_at like leads for exports to the
+-- data warehouse to pick changes up by.
+ALTER TABLE interactions ADD COLUMN updated_at TIMESTAMPTZ;
+
+CREATE INDEX leads_changed_at_idx ON leads (GREATEST(created_at, updated_at, deleted_at));
+CREATE INDEX interactions_changed_at_idx ON interactions (COALESCE(updated_at, created_at));
+
+-- How far each dataset has been exported to the data warehouse. Incremental
+-- exports take the rows changed after exported_until, and a dataset's row
+-- is locked while it is exported.
+CREATE TABLE warehouse_exports (
+    dataset TEXT PRIMARY KEY,
+    exported_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE sequence_enrollments DROP COLUMN IF EXISTS nurture;
+DROP TABLE IF EXISTS nurture_policies;
+ALTER TABLE lead_status_history DROP COLUMN IF EXISTS loss_reason;
@@ -0,0 +1,20 @@
+-- Why a lead was lost, given when it was marked LOST.
+ALTER TABLE lead_status_history ADD COLUMN loss_reason TEXT;
+
+-- The sequence that nurtures the leads of a campaign once they are lost:
+-- those lost for loss_reason, or for a reason without its own policy when
+-- loss_reason is NULL.
+CREATE TABLE nurture_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    campaign_id UUID NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    loss_reason TEXT,
+    sequence_id UUID NOT NULL REFERENCES sequences (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX nurture_policies_campaign_reason_idx ON nurture_policies (campaign_id, COALESCE(loss_reason, ''));
+
+-- Enrollments started by a nurture policy. A reply to one reopens its lead.
+ALTER TABLE sequence_enrollments ADD COLUMN nurture BOOLEAN NOT NULL DEFAULT false;
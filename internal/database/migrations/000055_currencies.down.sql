@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE deals ALTER COLUMN value TYPE NUMERIC(12, 2);
+ALTER TABLE services ALTER COLUMN price TYPE NUMERIC(12, 2);
+ALTER TABLE campaigns ALTER COLUMN budget TYPE NUMERIC(12, 2), ALTER COLUMN budget_alerted TYPE NUMERIC(12, 2);
+ALTER TABLE deals DROP CONSTRAINT IF EXISTS deals_currency_check;
+ALTER TABLE services DROP COLUMN IF EXISTS currency;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS currency;
+ALTER TABLE agencies DROP COLUMN IF EXISTS base_currency;
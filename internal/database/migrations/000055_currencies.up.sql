@@ -0,0 +1,29 @@
+-- The currency an agency's totals across currencies are reported in.
+ALTER TABLE agencies ADD COLUMN base_currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (base_currency ~ '^[A-Z]{3}$');
+
+-- A campaign's budget and spend are in its currency, and a service's price in
+-- its own. Existing campaigns take their agency's.
+ALTER TABLE campaigns ADD COLUMN currency CHAR(3);
+UPDATE campaigns c SET currency = a.base_currency FROM agencies a WHERE a.id = c.agency_id;
+ALTER TABLE campaigns ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE campaigns ADD CONSTRAINT campaigns_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE services ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+UPDATE deals SET currency = upper(currency) WHERE currency <> upper(currency);
+ALTER TABLE deals ADD CONSTRAINT deals_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Room for currencies with three minor digits, such as KWD.
+ALTER TABLE campaigns ALTER COLUMN budget TYPE NUMERIC(15, 3), ALTER COLUMN budget_alerted TYPE NUMERIC(15, 3);
+ALTER TABLE services ALTER COLUMN price TYPE NUMERIC(15, 3);
+ALTER TABLE deals ALTER COLUMN value TYPE NUMERIC(15, 3);
+
+-- The latest exchange rates, as units of each currency one euro buys.
+CREATE TABLE exchange_rates (
+    currency CHAR(3) PRIMARY KEY,
+    per_euro NUMERIC(20, 10) NOT NULL CHECK (per_euro > 0),
+    as_of DATE NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO exchange_rates (currency, per_euro, as_of) VALUES ('EUR', 1, CURRENT_DATE);
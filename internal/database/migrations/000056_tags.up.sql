@@ -0,0 +1,50 @@
+-- The agency's tag taxonomy. Leads keep their tags by name, and every name
+-- on a lead is a tag here in its canonical case.
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (name <> ''),
+    color TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX tags_agency_name_idx ON tags (agency_id, lower(name));
+CREATE INDEX tags_agency_name_prefix_idx ON tags (agency_id, lower(name) text_pattern_ops);
+
+-- Usage counts look leads up by tag.
+CREATE INDEX leads_tags_idx ON leads USING gin (tags);
+
+-- Existing tags are named the way most leads spell them.
+INSERT INTO tags (agency_id, name)
+SELECT DISTINCT ON (t.agency_id, lower(t.tag)) t.agency_id, t.tag
+FROM (
+    SELECT l.agency_id, btrim(u.tag) AS tag, count(*) AS uses
+    FROM leads l, unnest(l.tags) AS u(tag)
+    WHERE btrim(u.tag) <> ''
+    GROUP BY l.agency_id, btrim(u.tag)
+) t
+ORDER BY t.agency_id, lower(t.tag), t.uses DESC, t.tag;
+
+-- Leads are then respelled, dropping the duplicates that leaves, and each
+-- change is recorded as it would have been by UpdateLead.
+CREATE TEMPORARY TABLE canonical_lead_tags AS
+SELECT l.id, l.agency_id, l.tags AS old_tags, ARRAY(
+    SELECT t.name FROM unnest(l.tags) WITH ORDINALITY AS u(tag, n)
+    JOIN tags t ON t.agency_id = l.agency_id AND lower(t.name) = lower(btrim(u.tag))
+    GROUP BY t.name ORDER BY min(u.n)
+) AS new_tags
+FROM leads l
+WHERE cardinality(l.tags) > 0;
+
+DELETE FROM canonical_lead_tags WHERE old_tags = new_tags;
+
+UPDATE leads l SET tags = c.new_tags, updated_at = now(), version = l.version + 1
+FROM canonical_lead_tags c WHERE c.id = l.id;
+
+INSERT INTO lead_events (agency_id, lead_id, type, field, old_value, new_value)
+SELECT c.agency_id, c.id, 'FIELD_CHANGED', 'tags', to_jsonb(c.old_tags), to_jsonb(c.new_tags)
+FROM canonical_lead_tags c
+ORDER BY c.id;
+
+DROP TABLE canonical_lead_tags;
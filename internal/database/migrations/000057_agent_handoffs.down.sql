@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lead_agent_history;
+DROP TABLE IF EXISTS agent_handoff_rules;
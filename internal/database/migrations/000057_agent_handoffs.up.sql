@@ -0,0 +1,43 @@
+-- Rules that hand a lead from one AI agent to another once it meets the
+-- rule's conditions, such as a prospecting agent handing qualified leads to
+-- a nurturing one. A rule with both conditions needs both to hold.
+CREATE TABLE agent_handoff_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies (id) ON DELETE CASCADE,
+    from_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    to_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    status TEXT,
+    min_intent_score DOUBLE PRECISION CHECK (min_intent_score BETWEEN 0 AND 1),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    CHECK (from_agent_id <> to_agent_id),
+    CHECK (status IS NOT NULL OR min_intent_score IS NOT NULL)
+);
+
+CREATE INDEX agent_handoff_rules_from_agent_idx ON agent_handoff_rules (from_agent_id, created_at);
+
+-- Each AI agent a lead has been assigned to, from when until when. The rule
+-- that handed the lead to the agent is kept while the rule exists.
+CREATE TABLE lead_agent_history (
+    id BIGSERIAL PRIMARY KEY,
+    lead_id UUID NOT NULL REFERENCES leads (id) ON DELETE CASCADE,
+    ai_agent_id UUID NOT NULL REFERENCES ai_agents (id) ON DELETE CASCADE,
+    handoff_rule_id UUID REFERENCES agent_handoff_rules (id) ON DELETE SET NULL,
+    assigned_at TIMESTAMPTZ NOT NULL,
+    unassigned_at TIMESTAMPTZ
+);
+
+CREATE INDEX lead_agent_history_lead_id_idx ON lead_agent_history (lead_id, assigned_at);
+
+-- Past assignments are rebuilt from the lead events: an agent owns the lead
+-- from the event assigning it until the first later one moving the lead
+-- away from it.
+INSERT INTO lead_agent_history (lead_id, ai_agent_id, assigned_at, unassigned_at)
+SELECT e.lead_id, a.id, e.occurred_at, (
+    SELECT min(x.occurred_at) FROM lead_events x
+    WHERE x.lead_id = e.lead_id AND x.type = 'ASSIGNED' AND x.id > e.id AND x.old_value #>> '{}' = a.id::text
+)
+FROM lead_events e JOIN ai_agents a ON a.id::text = e.new_value #>> '{}'
+WHERE e.type = 'ASSIGNED'
+ORDER BY e.id;
@@ -0,0 +1,4 @@
+-- What an inbound reply asks for, as classified by the LLM provider. NULL
+-- for outbound interactions and replies that were not classified.
+ALTER TABLE interactions ADD COLUMN reply_intent TEXT
+    CHECK (reply_intent IN ('INTERESTED', 'NOT_NOW', 'UNSUBSCRIBE', 'WRONG_PERSON', 'OUT_OF_OFFICE'));
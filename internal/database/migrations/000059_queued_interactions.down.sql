@@ -0,0 +1,3 @@
+ALTER TABLE interactions DROP CONSTRAINT IF EXISTS interactions_status_check;
+
+UPDATE interactions SET status = 'SCHEDULED' WHERE status = 'QUEUED';
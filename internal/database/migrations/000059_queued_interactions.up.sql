@@ -0,0 +1,9 @@
+-- Outbound messages waiting to be sent are QUEUED rather than SCHEDULED,
+-- which is left to booked meetings and follow-up tasks.
+UPDATE interactions i SET status = 'QUEUED'
+FROM outbound_queue q
+WHERE q.interaction_id = i.id AND i.status = 'SCHEDULED';
+
+ALTER TABLE interactions ADD CONSTRAINT interactions_status_check
+    CHECK (status IN ('PENDING_REVIEW', 'SCHEDULED', 'QUEUED', 'SENT', 'DELIVERED', 'OPENED', 'RESPONDED',
+                      'FAILED', 'BOUNCED', 'REJECTED', 'SUPPRESSED'));
@@ -0,0 +1,8 @@
+// Package migrations holds the versioned SQL schema. Files are named
+// <version>_<description>.up.sql and .down.sql and applied in version order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const notificationColumns = `id, user_id, kind, title, body, subject_id, read_at, created_at`
+
+// NotificationRecipient is an active user of an agency with their
+// preference for one kind of notification.
+type NotificationRecipient struct {
+	UserID          string
+	Name            string
+	Email           string
+	Role            string
+	SlackWebhookURL *string
+	InApp           bool
+	ByEmail         bool
+	BySlack         bool
+}
+
+// GetNotificationRecipients returns the agency's active users with their
+// preference for kind; users without one get in-app notifications only.
+func (db *DB) GetNotificationRecipients(ctx context.Context, agencyID string, kind model.NotificationKind) ([]*NotificationRecipient, error) {
+	query := `SELECT u.id, u.name, u.email, u.role, u.slack_webhook_url, 
+              COALESCE(p.in_app, TRUE), COALESCE(p.email, FALSE), COALESCE(p.slack, FALSE) 
+              FROM users u LEFT JOIN notification_preferences p ON p.user_id = u.id AND p.kind = $1 
+              WHERE u.agency_id = $2 AND u.status = 'ACTIVE' 
+              ORDER BY u.id`
+
+	rows, err := db.conn.QueryContext(ctx, query, kind, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying notification recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := []*NotificationRecipient{}
+	for rows.Next() {
+		var r NotificationRecipient
+		var slackWebhookURL sql.NullString
+		if err := rows.Scan(&r.UserID, &r.Name, &r.Email, &r.Role, &slackWebhookURL, &r.InApp, &r.ByEmail, &r.BySlack); err != nil {
+			return nil, fmt.Errorf("error scanning notification recipient row: %w", err)
+		}
+		r.SlackWebhookURL = nullString(slackWebhookURL)
+		recipients = append(recipients, &r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification recipient rows: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// CreateNotification stores notification, unless it is nil, and enqueues its
+// deliveries in the same transaction.
+func (db *DB) CreateNotification(ctx context.Context, agencyID string, notification *model.Notification, deliveries []*model.OutboxMessage) (*model.Notification, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if notification != nil {
+		query := `INSERT INTO notifications (agency_id, user_id, kind, title, body, subject_id, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7) 
+              RETURNING id`
+		err := tx.QueryRowContext(
+			ctx, query, agencyID, notification.UserID, notification.Kind, notification.Title, notification.Body,
+			notification.SubjectID, notification.CreatedAt,
+		).Scan(&notification.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error creating notification: %w", err)
+		}
+	}
+
+	for _, msg := range deliveries {
+		if err := enqueueOutbox(ctx, tx, agencyID, msg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing notification: %w", err)
+	}
+
+	return notification, nil
+}
+
+// GetNotifications lists the user's notifications, newest first.
+func (db *DB) GetNotifications(ctx context.Context, userID string, unreadOnly bool, limit, offset *int) ([]*model.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications 
+              WHERE user_id = $1 AND ($2 = FALSE OR read_at IS NULL) 
+              ORDER BY created_at DESC, id`
+
+	args := []interface{}{userID, unreadOnly}
+	argCount := 3
+	if limit != nil {
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, *limit)
+		argCount++
+	}
+
+	if offset != nil {
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, *offset)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*model.Notification{}
+	for rows.Next() {
+		var n model.Notification
+		var subjectID sql.NullString
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Title, &n.Body, &subjectID, &readAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning notification row: %w", err)
+		}
+		n.SubjectID = nullString(subjectID)
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, &n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification rows: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (db *DB) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+
+	var count int
+	if err := db.conn.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting unread notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// MarkNotificationsRead marks the user's notifications with the given ids
+// read, or all of them when ids is empty, and returns how many changed.
+func (db *DB) MarkNotificationsRead(ctx context.Context, userID string, ids []string, at time.Time) (int, error) {
+	query := `UPDATE notifications SET read_at = $1 
+              WHERE user_id = $2 AND read_at IS NULL 
+              AND (cardinality($3::uuid[]) = 0 OR id = ANY($3::uuid[]))`
+
+	if ids == nil {
+		ids = []string{}
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, at, userID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("error marking notifications read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetNotificationPreferences returns the preferences the user has set; kinds
+// they have not set are missing.
+func (db *DB) GetNotificationPreferences(ctx context.Context, userID string) ([]*model.NotificationPreference, error) {
+	query := `SELECT kind, in_app, email, slack FROM notification_preferences WHERE user_id = $1 ORDER BY kind`
+
+	rows, err := db.conn.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	preferences := []*model.NotificationPreference{}
+	for rows.Next() {
+		var p model.NotificationPreference
+		if err := rows.Scan(&p.Kind, &p.InApp, &p.Email, &p.Slack); err != nil {
+			return nil, fmt.Errorf("error scanning notification preference row: %w", err)
+		}
+		preferences = append(preferences, &p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification preference rows: %w", err)
+	}
+
+	return preferences, nil
+}
+
+func (db *DB) SetNotificationPreference(ctx context.Context, userID string, preference *model.NotificationPreference) error {
+	query := `INSERT INTO notification_preferences (user_id, kind, in_app, email, slack, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
+              ON CONFLICT (user_id, kind) DO UPDATE 
+              SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, slack = EXCLUDED.slack, updated_at = EXCLUDED.updated_at`
+
+	_, err := db.conn.ExecContext(ctx, query, userID, preference.Kind, preference.InApp, preference.Email, preference.Slack, time.Now())
+	if err != nil {
+		return fmt.Errorf("error setting notification preference: %w", err)
+	}
+
+	return nil
+}
+
+// GetSlackWebhookURL returns "" when the user has none.
+func (db *DB) GetSlackWebhookURL(ctx context.Context, userID string) (string, error) {
+	var url sql.NullString
+	err := db.conn.QueryRowContext(ctx, "SELECT slack_webhook_url FROM users WHERE id = $1", userID).Scan(&url)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("error fetching slack webhook url: %w", err)
+	}
+
+	return url.String, nil
+}
+
+// SetSlackWebhookURL sets the incoming webhook Slack notifications for the
+// user are posted to; nil clears it.
+func (db *DB) SetSlackWebhookURL(ctx context.Context, userID string, url *string) error {
+	query := `UPDATE users SET slack_webhook_url = $1, updated_at = $2 WHERE id = $3`
+	if _, err := db.conn.ExecContext(ctx, query, url, time.Now(), userID); err != nil {
+		return fmt.Errorf("error setting slack webhook url: %w", err)
+	}
+
+	return nil
+}
+
+// GetLeadAgencyID returns "" when the lead does not exist.
+func (db *DB) GetLeadAgencyID(ctx context.Context, leadID string) (string, error) {
+	return db.agencyIDOf(ctx, "SELECT agency_id FROM leads WHERE id = $1", leadID)
+}
+
+// GetCampaignAgencyID returns "" when the campaign does not exist.
+func (db *DB) GetCampaignAgencyID(ctx context.Context, campaignID string) (string, error) {
+	return db.agencyIDOf(ctx, "SELECT agency_id FROM campaigns WHERE id = $1", campaignID)
+}
+
+// GetAIAgentAgencyID returns "" when the agent does not exist.
+func (db *DB) GetAIAgentAgencyID(ctx context.Context, agentID string) (string, error) {
+	return db.agencyIDOf(ctx, "SELECT agency_id FROM ai_agents WHERE id = $1", agentID)
+}
+
+func (db *DB) agencyIDOf(ctx context.Context, query, id string) (string, error) {
+	var agencyID sql.NullString
+	err := db.conn.QueryRowContext(ctx, query, id).Scan(&agencyID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("error fetching agency: %w", err)
+	}
+
+	return agencyID.String, nil
+}
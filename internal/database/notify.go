@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// eventPayloadTTL is how long StoreEventPayload keeps payloads for listeners
+// to read.
+const eventPayloadTTL = time.Minute
+
+// Listen opens a dedicated connection listening on the given Postgres
+// channels. It reconnects by itself; a nil notification on the listener's
+// Notify channel means notifications may have been missed meanwhile.
+func (db *DB) Listen(channels ...string) (*pq.Listener, error) {
+	listener := pq.NewListener(db.url, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("Database listener connection problem", "error", err)
+		}
+	})
+
+	for _, channel := range channels {
+		if err := listener.Listen(channel); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("error listening on %s: %w", channel, err)
+		}
+	}
+
+	return listener, nil
+}
+
+// Notify sends a notification to every connection listening on channel,
+// including listeners of this process. Payloads must stay under 8000 bytes.
+func (db *DB) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := db.conn.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("error sending notification: %w", err)
+	}
+	return nil
+}
+
+// StoreEventPayload keeps a payload too large for a notification for
+// eventPayloadTTL and returns the ID to read it back with. Expired payloads
+// are deleted along the way.
+func (db *DB) StoreEventPayload(ctx context.Context, payload []byte) (int64, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM event_payloads WHERE created_at < $1", time.Now().Add(-eventPayloadTTL)); err != nil {
+		return 0, fmt.Errorf("error deleting expired event payloads: %w", err)
+	}
+
+	var id int64
+	if err = tx.QueryRowContext(ctx, "INSERT INTO event_payloads (payload) VALUES ($1) RETURNING id", payload).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error storing event payload: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetEventPayload returns a payload stored by StoreEventPayload, or nil once
+// it has expired.
+func (db *DB) GetEventPayload(ctx context.Context, id int64) ([]byte, error) {
+	var payload []byte
+	err := db.conn.QueryRowContext(ctx, "SELECT payload FROM event_payloads WHERE id = $1", id).Scan(&payload)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching event payload: %w", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const nurturePolicyColumns = `p.id, p.campaign_id, p.loss_reason, p.sequence_id, p.created_at, p.updated_at`
+
+// GetNurturePolicies lists the campaign's nurture policies, the one for any
+// reason last.
+func (db *DB) GetNurturePolicies(ctx context.Context, campaignID string) ([]*model.NurturePolicy, error) {
+	query := `SELECT ` + nurturePolicyColumns + ` FROM nurture_policies p 
+              WHERE p.campaign_id = $1 AND (p.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY p.loss_reason NULLS LAST`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying nurture policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []*model.NurturePolicy{}
+	for rows.Next() {
+		policy, err := scanNurturePolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning nurture policy row: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating nurture policy rows: %w", err)
+	}
+
+	return policies, nil
+}
+
+// SetNurturePolicy creates the campaign's policy for the loss reason, or
+// points the existing one at another sequence.
+func (db *DB) SetNurturePolicy(ctx context.Context, policy *model.NurturePolicy) (*model.NurturePolicy, error) {
+	query := `INSERT INTO nurture_policies (agency_id, campaign_id, loss_reason, sequence_id, created_at) 
+              VALUES ($1, $2, $3, $4, $5) 
+              ON CONFLICT (campaign_id, COALESCE(loss_reason, '')) DO UPDATE 
+              SET sequence_id = EXCLUDED.sequence_id, updated_at = EXCLUDED.created_at 
+              RETURNING id, created_at, updated_at`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedAt sql.NullTime
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, policy.CampaignID, policy.LossReason, policy.SequenceID, time.Now(),
+	).Scan(&policy.ID, &policy.CreatedAt, &updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error setting nurture policy: %w", err)
+	}
+
+	if updatedAt.Valid {
+		policy.UpdatedAt = &updatedAt.Time
+	}
+
+	return policy, nil
+}
+
+func (db *DB) DeleteNurturePolicy(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM nurture_policies WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting nurture policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// FindNurturePolicy returns the policy that nurtures the lead once it is lost
+// for lossReason, or nil when none does. Policies for the reason come before
+// those for any reason, and among campaigns the lead is in, the one it joined
+// last wins. Completed and cancelled campaigns nurture no one.
+func (db *DB) FindNurturePolicy(ctx context.Context, leadID string, lossReason *model.LossReason) (*model.NurturePolicy, error) {
+	query := `SELECT ` + nurturePolicyColumns + ` FROM nurture_policies p 
+              JOIN campaign_leads cl ON cl.campaign_id = p.campaign_id AND cl.lead_id = $1 
+              JOIN campaigns c ON c.id = p.campaign_id 
+              WHERE (p.loss_reason = $2 OR p.loss_reason IS NULL) AND c.status NOT IN ($3, $4) 
+              AND (p.agency_id = $5 OR $5 IS NULL) 
+              ORDER BY p.loss_reason IS NULL, cl.enrolled_at DESC, p.id 
+              LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := scanNurturePolicy(db.conn.QueryRowContext(
+		ctx, query, leadID, lossReason, model.CampaignStatusCompleted, model.CampaignStatusCancelled, agencyID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding nurture policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+func scanNurturePolicy(row interface{ Scan(...interface{}) error }) (*model.NurturePolicy, error) {
+	var policy model.NurturePolicy
+	var lossReason sql.NullString
+	var updatedAt sql.NullTime
+
+	err := row.Scan(&policy.ID, &policy.CampaignID, &lossReason, &policy.SequenceID, &policy.CreatedAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lossReason.Valid {
+		r := model.LossReason(lossReason.String)
+		policy.LossReason = &r
+	}
+	if updatedAt.Valid {
+		policy.UpdatedAt = &updatedAt.Time
+	}
+
+	return &policy, nil
+}
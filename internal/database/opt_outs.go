@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// optOutMatch joins opt_outs o to leads l on the lead's email or phone.
+const optOutMatch = `o.agency_id = l.agency_id 
+              AND (o.email = lower(l.email) OR o.phone = right(regexp_replace(l.phone, '\D', '', 'g'), 10))`
+
+// isPhoneChannel reports whether opt-outs for channel are keyed by phone
+// number rather than email address.
+func isPhoneChannel(channel model.Channel) bool {
+	return channel == model.ChannelSms || channel == model.ChannelWhatsapp || channel == model.ChannelPhone
+}
+
+// OptOutLead suppresses channel for the lead's email address or phone number
+// within the lead's agency, so other leads sharing the contact are covered
+// too. It returns false when the lead does not exist or has no phone number
+// for a phone channel; opting out twice is not an error.
+func (db *DB) OptOutLead(ctx context.Context, leadID string, channel model.Channel, reason string) (bool, error) {
+	query := `INSERT INTO opt_outs (agency_id, email, phone, channel, reason) 
+              SELECT agency_id, lower(email), NULL, $2, $3 FROM leads 
+              WHERE id = $1 AND (agency_id = $4 OR $4 IS NULL)`
+	if isPhoneChannel(channel) {
+		query = `INSERT INTO opt_outs (agency_id, email, phone, channel, reason) 
+              SELECT agency_id, NULL, right(regexp_replace(phone, '\D', '', 'g'), 10), $2, $3 FROM leads 
+              WHERE id = $1 AND (agency_id = $4 OR $4 IS NULL) AND regexp_replace(phone, '\D', '', 'g') <> ''`
+	}
+	query += " ON CONFLICT DO NOTHING"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var reasonArg interface{}
+	if reason != "" {
+		reasonArg = reason
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, leadID, channel, reasonArg, agencyID); err != nil {
+		return false, fmt.Errorf("error recording opt-out: %w", err)
+	}
+
+	return db.IsOptedOut(ctx, leadID, channel)
+}
+
+func (db *DB) IsOptedOut(ctx context.Context, leadID string, channel model.Channel) (bool, error) {
+	query := `SELECT EXISTS ( 
+                  SELECT 1 FROM leads l JOIN opt_outs o ON ` + optOutMatch + ` 
+                  WHERE l.id = $1 AND o.channel = $2 AND (l.agency_id = $3 OR $3 IS NULL) 
+              )`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var optedOut bool
+	if err := db.conn.QueryRowContext(ctx, query, leadID, channel, agencyID).Scan(&optedOut); err != nil {
+		return false, fmt.Errorf("error checking opt-out: %w", err)
+	}
+
+	return optedOut, nil
+}
+
+func (db *DB) GetOptedOutChannelsByLeadIDs(ctx context.Context, leadIDs []string) (map[string][]model.Channel, error) {
+	query := `SELECT DISTINCT l.id, o.channel 
+              FROM leads l JOIN opt_outs o ON ` + optOutMatch + ` 
+              WHERE l.id = ANY($1) AND (l.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY l.id, o.channel`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(leadIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying opt-outs: %w", err)
+	}
+	defer rows.Close()
+
+	channelsByLead := make(map[string][]model.Channel, len(leadIDs))
+	for _, leadID := range leadIDs {
+		channelsByLead[leadID] = []model.Channel{}
+	}
+	for rows.Next() {
+		var leadID string
+		var channel model.Channel
+		if err := rows.Scan(&leadID, &channel); err != nil {
+			return nil, fmt.Errorf("error scanning opt-out row: %w", err)
+		}
+		channelsByLead[leadID] = append(channelsByLead[leadID], channel)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating opt-out rows: %w", err)
+	}
+
+	return channelsByLead, nil
+}
+
+// SuppressLeadEmail opts the lead's email address out of email because it
+// bounced or its owner complained. It returns false when the lead does not
+// exist; an address that is already opted out keeps its original reason.
+func (db *DB) SuppressLeadEmail(ctx context.Context, leadID string, reason model.EmailSuppressionReason, detail string) (bool, error) {
+	query := `INSERT INTO opt_outs (agency_id, email, phone, channel, reason, suppression) 
+              SELECT agency_id, lower(email), NULL, $2, $3, $4 FROM leads 
+              WHERE id = $1 AND (agency_id = $5 OR $5 IS NULL) 
+              ON CONFLICT DO NOTHING`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var detailArg interface{}
+	if detail != "" {
+		detailArg = detail
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, leadID, model.ChannelEmail, detailArg, reason, agencyID); err != nil {
+		return false, fmt.Errorf("error recording email suppression: %w", err)
+	}
+
+	return db.IsOptedOut(ctx, leadID, model.ChannelEmail)
+}
+
+// GetLeadEmailSuppression returns why the lead's email address is
+// suppressed, or nil when it is not, or was opted out by the lead.
+func (db *DB) GetLeadEmailSuppression(ctx context.Context, leadID string) (*model.EmailSuppression, error) {
+	query := `SELECT o.suppression, o.reason, o.created_at 
+              FROM leads l JOIN opt_outs o ON ` + optOutMatch + ` 
+              WHERE l.id = $1 AND o.channel = $2 AND o.suppression IS NOT NULL AND (l.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suppression model.EmailSuppression
+	var detail sql.NullString
+	err = db.conn.QueryRowContext(ctx, query, leadID, model.ChannelEmail, agencyID).Scan(
+		&suppression.Reason, &detail, &suppression.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching email suppression: %w", err)
+	}
+	suppression.Detail = nullString(detail)
+
+	return &suppression, nil
+}
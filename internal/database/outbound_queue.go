@@ -0,0 +1,298 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// queueLease is how long a claimed message stays hidden from other claims,
+// so a release that crashes midway is retried instead of lost.
+const queueLease = 5 * time.Minute
+
+// QueuedMessage is an outbound message held until its send window opens, or
+// until it is tried again after failing. Interaction carries only the IDs of
+// its lead, template, agent and variant.
+type QueuedMessage struct {
+	Interaction *model.Interaction
+	// CampaignID is empty for messages outside campaigns.
+	CampaignID string
+	Subject    string
+	// Attempts counts the times sending the message failed transiently.
+	Attempts int
+}
+
+// GetCampaignSendWindow returns the campaign's send window as JSON, or nil
+// when it may send at any time.
+func (db *DB) GetCampaignSendWindow(ctx context.Context, campaignID string) (*string, error) {
+	query := "SELECT send_window FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var window sql.NullString
+	if err := db.conn.QueryRowContext(ctx, query, campaignID, agencyID).Scan(&window); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching campaign send window: %w", err)
+	}
+
+	if !window.Valid {
+		return nil, nil
+	}
+	return &window.String, nil
+}
+
+// SetCampaignSendWindow replaces the campaign's send window; nil removes it.
+// It reports whether the campaign exists.
+func (db *DB) SetCampaignSendWindow(ctx context.Context, campaignID string, window *string) (bool, error) {
+	query := `UPDATE campaigns SET send_window = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, window, time.Now(), campaignID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating campaign send window: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// QueueInteraction records a QUEUED interaction for a message that is
+// sent later, together with what is needed to send it. campaignID is empty
+// for messages outside campaigns, and attempts counts the failed sends so far.
+// The lead's last_contact is left alone until the message goes out.
+func (db *DB) QueueInteraction(ctx context.Context, interaction *model.Interaction, campaignID, subject string, releaseAt time.Time, attempts int) (*model.Interaction, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+	if err := setInteractionSubject(ctx, tx, interaction.ID, subject); err != nil {
+		return nil, err
+	}
+
+	var campaign *string
+	if campaignID != "" {
+		campaign = &campaignID
+	}
+	_, err = tx.ExecContext(
+		ctx, "INSERT INTO outbound_queue (interaction_id, campaign_id, release_at, attempts) VALUES ($1, $2, $3, $4)",
+		interaction.ID, campaign, releaseAt, attempts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error queueing interaction: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return interaction, nil
+}
+
+// ClaimQueuedMessages returns up to limit messages due for release and
+// leases them for queueLease. Callers must complete, reschedule or retry each
+// one before the lease runs out. It is meant for system jobs and ignores
+// tenants.
+func (db *DB) ClaimQueuedMessages(ctx context.Context, now time.Time, limit int) ([]*QueuedMessage, error) {
+	query := `WITH claimed AS ( 
+                  UPDATE outbound_queue SET release_at = $1 
+                  WHERE interaction_id IN ( 
+                      SELECT interaction_id FROM outbound_queue WHERE release_at <= $2 
+                      ORDER BY release_at LIMIT $3 FOR UPDATE SKIP LOCKED 
+                  ) 
+                  RETURNING interaction_id, campaign_id, attempts 
+              ) 
+              SELECT i.id, i.lead_id, i.type, i.channel, i.message, i.ai_agent_id, i.template_id, i.variant_id, 
+              i.status, i.direction, i.timestamp, i.created_at, c.campaign_id, COALESCE(i.subject, ''), c.attempts 
+              FROM claimed c JOIN interactions i ON i.id = c.interaction_id`
+
+	rows, err := db.conn.QueryContext(ctx, query, now.Add(queueLease), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming queued messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*QueuedMessage{}
+	for rows.Next() {
+		message, err := scanQueuedMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning queued message row: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating queued message rows: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetOutboundMessage returns an interaction with what is needed to send it
+// again: its campaign, through its template, and the subject it was sent
+// with. It returns nil when there is no such interaction or its lead was
+// deleted.
+func (db *DB) GetOutboundMessage(ctx context.Context, interactionID string) (*QueuedMessage, error) {
+	query := `SELECT i.id, i.lead_id, i.type, i.channel, i.message, i.ai_agent_id, i.template_id, i.variant_id, 
+              i.status, i.direction, i.timestamp, i.created_at, t.campaign_id, COALESCE(i.subject, d.subject, ''), 0 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              LEFT JOIN message_templates t ON t.id = i.template_id 
+              LEFT JOIN interaction_drafts d ON d.interaction_id = i.id 
+              WHERE i.id = $1 AND l.deleted_at IS NULL AND (l.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := scanQueuedMessage(db.conn.QueryRowContext(ctx, query, interactionID, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching outbound message: %w", err)
+	}
+
+	return message, nil
+}
+
+func scanQueuedMessage(row interface{ Scan(...interface{}) error }) (*QueuedMessage, error) {
+	var interaction model.Interaction
+	var message QueuedMessage
+	var leadID string
+	var body, aiAgentID, templateID, variantID, campaignID sql.NullString
+
+	err := row.Scan(
+		&interaction.ID, &leadID, &interaction.Type, &interaction.Channel, &body, &aiAgentID, &templateID,
+		&variantID, &interaction.Status, &interaction.Direction, &interaction.Timestamp, &interaction.CreatedAt,
+		&campaignID, &message.Subject, &message.Attempts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction.Lead = &model.Lead{ID: leadID}
+	if body.Valid {
+		interaction.Message = &body.String
+	}
+	if aiAgentID.Valid {
+		interaction.AIAgent = &model.AIAgent{ID: aiAgentID.String}
+	}
+	if templateID.Valid {
+		interaction.Template = &model.MessageTemplate{ID: templateID.String}
+	}
+	if variantID.Valid {
+		interaction.Variant = &model.CampaignVariant{ID: variantID.String}
+	}
+
+	message.Interaction = &interaction
+	message.CampaignID = campaignID.String
+	return &message, nil
+}
+
+// RescheduleQueuedMessage moves a claimed message to a later release time.
+func (db *DB) RescheduleQueuedMessage(ctx context.Context, interactionID string, releaseAt time.Time) error {
+	_, err := db.conn.ExecContext(ctx, "UPDATE outbound_queue SET release_at = $1 WHERE interaction_id = $2", releaseAt, interactionID)
+	if err != nil {
+		return fmt.Errorf("error rescheduling queued message: %w", err)
+	}
+	return nil
+}
+
+// RetryQueuedMessage puts a claimed message that failed transiently back in
+// the queue until releaseAt, counting the attempt and keeping the failure as
+// the interaction's notes.
+func (db *DB) RetryQueuedMessage(ctx context.Context, interaction *model.Interaction, releaseAt time.Time) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx, "UPDATE outbound_queue SET release_at = $1, attempts = attempts + 1 WHERE interaction_id = $2",
+		releaseAt, interaction.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error retrying queued message: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE interactions SET notes = $1, updated_at = now() WHERE id = $2", interaction.Notes, interaction.ID); err != nil {
+		return fmt.Errorf("error updating queued interaction notes: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteQueuedMessage records the outcome of sending a queued message and
+// removes it from the queue. A sent message counts as contact with the lead.
+func (db *DB) CompleteQueuedMessage(ctx context.Context, interaction *model.Interaction) error {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := completeInteraction(ctx, tx, interaction); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM outbound_queue WHERE interaction_id = $1", interaction.ID); err != nil {
+		return fmt.Errorf("error removing queued message: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	return nil
+}
+
+// completeInteraction records the outcome of sending an interaction that was
+// stored before it was sent. A sent message counts as contact with the lead.
+func completeInteraction(ctx context.Context, tx *sql.Tx, interaction *model.Interaction) error {
+	query := `UPDATE interactions SET status = $1, timestamp = $2, external_id = $3, notes = $4, updated_at = now() 
+              WHERE id = $5`
+
+	_, err := tx.ExecContext(
+		ctx, query, interaction.Status, interaction.Timestamp, interaction.ExternalID, interaction.Notes, interaction.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating sent interaction: %w", err)
+	}
+
+	if interaction.Status == model.InteractionStatusSent {
+		if err = recordLeadContact(ctx, tx, interaction.Lead.ID, interaction.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
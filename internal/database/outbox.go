@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const outboxColumns = `id, kind, payload, attempts, last_error, created_at, delivered_at, dead_at`
+
+// EnqueueOutbox adds msg to the outbox on its own. Side effects of a change
+// should be enqueued in the change's transaction instead.
+func (db *DB) EnqueueOutbox(ctx context.Context, msg *model.OutboxMessage) error {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+	return enqueueOutbox(ctx, db.conn, agencyID, msg)
+}
+
+func enqueueOutbox(ctx context.Context, exec execer, agencyID interface{}, msg *model.OutboxMessage) error {
+	query := `INSERT INTO outbox (agency_id, kind, payload) VALUES ($1, $2, $3)`
+
+	if _, err := exec.ExecContext(ctx, query, agencyID, msg.Kind, msg.Payload); err != nil {
+		return fmt.Errorf("error enqueueing outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimOutboxMessages returns up to limit messages that are due and counts
+// the attempt. Each claimed message is hidden from other claims for lease;
+// if it is not settled by then, as when the relay crashes, it is claimed
+// again.
+func (db *DB) ClaimOutboxMessages(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.OutboxMessage, error) {
+	query := `UPDATE outbox SET attempts = attempts + 1, next_attempt_at = $1 
+              WHERE id IN ( 
+                  SELECT id FROM outbox 
+                  WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= $2 
+                  ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED 
+              ) 
+              RETURNING ` + outboxColumns
+
+	rows, err := db.conn.QueryContext(ctx, query, now.Add(lease), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming outbox messages: %w", err)
+	}
+
+	return scanOutboxMessages(rows)
+}
+
+func (db *DB) MarkOutboxDelivered(ctx context.Context, id string, at time.Time) error {
+	if _, err := db.conn.ExecContext(ctx, "UPDATE outbox SET delivered_at = $1 WHERE id = $2", at, id); err != nil {
+		return fmt.Errorf("error marking outbox message delivered: %w", err)
+	}
+	return nil
+}
+
+// RetryOutboxMessage records a failed attempt and schedules the next one.
+func (db *DB) RetryOutboxMessage(ctx context.Context, id, lastError string, at time.Time) error {
+	query := `UPDATE outbox SET last_error = $1, next_attempt_at = $2 WHERE id = $3`
+
+	if _, err := db.conn.ExecContext(ctx, query, lastError, at, id); err != nil {
+		return fmt.Errorf("error rescheduling outbox message: %w", err)
+	}
+	return nil
+}
+
+// DeadLetterOutboxMessage records a failed attempt and stops retrying.
+func (db *DB) DeadLetterOutboxMessage(ctx context.Context, id, lastError string, at time.Time) error {
+	query := `UPDATE outbox SET last_error = $1, dead_at = $2 WHERE id = $3`
+
+	if _, err := db.conn.ExecContext(ctx, query, lastError, at, id); err != nil {
+		return fmt.Errorf("error dead-lettering outbox message: %w", err)
+	}
+	return nil
+}
+
+// GetDeadOutboxMessages lists dead-lettered messages, most recent first.
+func (db *DB) GetDeadOutboxMessages(ctx context.Context, limit int) ([]*model.OutboxMessage, error) {
+	query := `SELECT ` + outboxColumns + ` FROM outbox 
+              WHERE dead_at IS NOT NULL AND (agency_id = $1 OR $1 IS NULL) 
+              ORDER BY dead_at DESC LIMIT $2`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying dead outbox messages: %w", err)
+	}
+
+	return scanOutboxMessages(rows)
+}
+
+// RequeueOutboxMessage gives a dead-lettered message a fresh set of attempts.
+// It returns nil when there is no such dead-lettered message.
+func (db *DB) RequeueOutboxMessage(ctx context.Context, id string) (*model.OutboxMessage, error) {
+	query := `UPDATE outbox SET dead_at = NULL, attempts = 0, next_attempt_at = now() 
+              WHERE id = $1 AND dead_at IS NOT NULL AND (agency_id = $2 OR $2 IS NULL) 
+              RETURNING ` + outboxColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := scanOutboxMessage(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error requeueing outbox message: %w", err)
+	}
+
+	return msg, nil
+}
+
+func scanOutboxMessages(rows *sql.Rows) ([]*model.OutboxMessage, error) {
+	defer rows.Close()
+
+	messages := []*model.OutboxMessage{}
+	for rows.Next() {
+		msg, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning outbox message row: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox message rows: %w", err)
+	}
+
+	return messages, nil
+}
+
+func scanOutboxMessage(row interface{ Scan(...interface{}) error }) (*model.OutboxMessage, error) {
+	var msg model.OutboxMessage
+	var lastError sql.NullString
+	var deliveredAt, deadAt sql.NullTime
+
+	err := row.Scan(
+		&msg.ID, &msg.Kind, &msg.Payload, &msg.Attempts, &lastError, &msg.CreatedAt, &deliveredAt, &deadAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastError.Valid {
+		msg.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		msg.DeliveredAt = &deliveredAt.Time
+	}
+	if deadAt.Valid {
+		msg.DeadAt = &deadAt.Time
+	}
+
+	return &msg, nil
+}
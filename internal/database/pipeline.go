@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// GetPipelineStages aggregates live leads by status. With clientID or
+// campaignID only leads attributed to the client's campaigns, or to the
+// campaign, count; attribution follows campaignMetricsQuery. Time in stage is
+// measured from the lead's latest move into its current status. Each stage
+// lists up to leadsPerStage lead IDs, highest intent first, or all of them
+// when leadsPerStage is nil. Statuses without leads are omitted.
+func (db *DB) GetPipelineStages(ctx context.Context, clientID, campaignID *string, leadsPerStage *int) ([]*model.PipelineStage, error) {
+	query := `WITH board AS ( 
+                  SELECT l.id, l.status, l.deal_value, l.intent_score, 
+                      COALESCE((SELECT max(h.created_at) FROM lead_status_history h 
+                          WHERE h.lead_id = l.id AND h.to_status = l.status), l.created_at) AS entered_at 
+                  FROM leads l 
+                  WHERE (l.agency_id = $1 OR $1 IS NULL) AND l.deleted_at IS NULL 
+                  AND (($2::uuid IS NULL AND $3::uuid IS NULL) OR l.id IN ( 
+                      SELECT i.lead_id FROM campaigns c 
+                      JOIN interactions i ON i.timestamp >= c.start_date 
+                          AND (c.end_date IS NULL OR i.timestamp <= c.end_date) 
+                      WHERE (c.client_id = $2 OR $2 IS NULL) AND (c.id = $3 OR $3 IS NULL) 
+                      AND (i.template_id IN (SELECT id FROM message_templates WHERE campaign_id = c.id) 
+                          OR i.ai_agent_id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = c.id)) 
+                  )) 
+              ) 
+              SELECT status, COUNT(*), COALESCE(SUM(deal_value), 0), 
+                  AVG(EXTRACT(EPOCH FROM now() - entered_at)) / 86400, 
+                  (array_agg(id::text ORDER BY intent_score DESC, entered_at))[1:COALESCE($4::int, COUNT(*)::int)] 
+              FROM board 
+              GROUP BY status`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, agencyID, clientID, campaignID, leadsPerStage)
+	if err != nil {
+		return nil, fmt.Errorf("error querying pipeline: %w", err)
+	}
+	defer rows.Close()
+
+	var stages []*model.PipelineStage
+	for rows.Next() {
+		var stage model.PipelineStage
+		var averageDays float64
+
+		err := rows.Scan(&stage.Status, &stage.Count, &stage.PotentialValue, &averageDays, pq.Array(&stage.LeadIDs))
+		if err != nil {
+			return nil, fmt.Errorf("error scanning pipeline row: %w", err)
+		}
+		stage.AverageDaysInStage = &averageDays
+
+		stages = append(stages, &stage)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pipeline rows: %w", err)
+	}
+
+	return stages, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// replicaRetryAfter is how long reads stay on the primary after the replica
+// failed before it is tried again.
+const replicaRetryAfter = 30 * time.Second
+
+type replica struct {
+	conn *sql.DB
+	// downUntil is the Unix time in nanoseconds until which the replica is
+	// skipped.
+	downUntil atomic.Int64
+}
+
+func (r *replica) available() bool {
+	return time.Now().UnixNano() >= r.downUntil.Load()
+}
+
+func (r *replica) markDown(err error) {
+	previous := r.downUntil.Load()
+	if r.downUntil.CompareAndSwap(previous, time.Now().Add(replicaRetryAfter).UnixNano()) && previous == 0 {
+		slog.Warn("Database replica unavailable, reading from primary", "retry_after", replicaRetryAfter.String(), "error", err)
+	}
+}
+
+func (r *replica) markUp() {
+	if r.downUntil.Swap(0) != 0 {
+		slog.Info("Database replica available again")
+	}
+}
+
+// queryReplica runs a read-only query on the replica when one is configured,
+// and on the primary otherwise or while the replica is unreachable. Only use
+// it for reads that tolerate replication lag, such as metrics and listings;
+// reads that must see the caller's own writes belong on db.conn.
+func (db *DB) queryReplica(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r := db.replica; r != nil && r.available() {
+		rows, err := r.conn.QueryContext(ctx, query, args...)
+		if err == nil {
+			r.markUp()
+			return rows, nil
+		}
+		if ctx.Err() != nil || !isConnectionError(err) {
+			return nil, err
+		}
+		r.markDown(err)
+	}
+
+	return db.conn.QueryContext(ctx, query, args...)
+}
+
+// isConnectionError reports whether err means the server could not be
+// reached or refused the connection, rather than rejecting the query.
+func isConnectionError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return true
+	}
+	switch pqErr.Code.Class() {
+	case "08": // connection_exception
+		return true
+	case "57": // operator_intervention, e.g. the server is shutting down
+		return pqErr.Code != "57014" // query_canceled
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// clientInteractionsCTE selects the interactions attributed to the campaigns
+// of client $1 that happened in [$2, $3). Attribution follows
+// campaignMetricsQuery.
+const clientInteractionsCTE = `WITH client_interactions AS ( 
+                  SELECT DISTINCT c.id AS campaign_id, i.id, i.lead_id, i.ai_agent_id, i.type, i.status, i.direction 
+                  FROM campaigns c 
+                  JOIN interactions i ON i.timestamp >= c.start_date 
+                      AND (c.end_date IS NULL OR i.timestamp <= c.end_date) 
+                  WHERE c.client_id = $1 AND (c.agency_id = $4 OR $4 IS NULL) 
+                  AND i.timestamp >= $2 AND i.timestamp < $3 
+                  AND (i.template_id IN (SELECT id FROM message_templates WHERE campaign_id = c.id) 
+                      OR i.ai_agent_id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = c.id)) 
+              ) `
+
+// reportSpend is a campaign's budget prorated by how much of the campaign
+// fell inside the period [$2, $3); campaigns without an end date are treated
+// as ending with the period.
+const reportSpend = `COALESCE(c.budget * EXTRACT(EPOCH FROM LEAST(COALESCE(c.end_date, $3), $3) - GREATEST(c.start_date, $2)) 
+                      / NULLIF(EXTRACT(EPOCH FROM COALESCE(c.end_date, $3) - c.start_date), 0), c.budget, 0)`
+
+// GetClientReportCampaigns returns per-campaign results for every campaign of
+// the client that ran during [start, end). Spend is reportSpend, in the
+// campaign's currency and in the agency's base currency. It returns
+// ErrNoExchangeRate when a campaign's spend cannot be converted.
+func (db *DB) GetClientReportCampaigns(ctx context.Context, clientID string, start, end time.Time) ([]*model.ClientReportCampaign, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := clientInteractionsCTE + `
+              SELECT c.id, c.name, c.status, 
+                  COUNT(DISTINCT ci.lead_id), 
+                  COUNT(ci.id) FILTER (WHERE ci.direction = 'OUTBOUND' AND ci.type <> 'MEETING' 
+                      AND ci.status NOT IN ` + unsentStatuses + `), 
+                  COUNT(ci.id) FILTER (WHERE ci.status = 'RESPONDED'), 
+                  COUNT(ci.id) FILTER (WHERE ci.type = 'MEETING'), 
+                  COUNT(DISTINCT l.id) FILTER (WHERE l.status = 'WON'), 
+                  ` + reportSpend + `, c.currency, 
+                  ` + toBaseCurrency(reportSpend, "c.currency", "a.base_currency") + ` 
+              FROM campaigns c 
+              JOIN agencies a ON a.id = c.agency_id 
+              ` + exchangeRateJoins("c.currency", "a.base_currency") + ` 
+              LEFT JOIN client_interactions ci ON ci.campaign_id = c.id 
+              LEFT JOIN leads l ON l.id = ci.lead_id 
+              WHERE c.client_id = $1 AND (c.agency_id = $4 OR $4 IS NULL) 
+              AND c.start_date < $3 AND (c.end_date IS NULL OR c.end_date >= $2) 
+              AND c.status NOT IN ('DRAFT', 'CANCELLED') 
+              GROUP BY c.id, c.name, c.status, c.budget, c.currency, c.start_date, c.end_date, 
+                  a.base_currency, from_rate.per_euro, base_rate.per_euro 
+              ORDER BY c.start_date`
+
+	rows, err := db.queryAnalytics(ctx, query, clientID, start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying client report campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []*model.ClientReportCampaign
+	var missing []string
+	for rows.Next() {
+		var campaign model.ClientReportCampaign
+		var baseSpend sql.NullFloat64
+
+		err := rows.Scan(
+			&campaign.CampaignID, &campaign.Name, &campaign.Status, &campaign.LeadsGenerated,
+			&campaign.MessagesSent, &campaign.Replies, &campaign.MeetingsBooked, &campaign.Conversions,
+			&campaign.Spend, &campaign.Currency, &baseSpend,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning client report campaign row: %w", err)
+		}
+
+		if !baseSpend.Valid && campaign.Spend != 0 && !slices.Contains(missing, campaign.Currency) {
+			missing = append(missing, campaign.Currency)
+		}
+		campaign.BaseSpend = baseSpend.Float64
+
+		campaigns = append(campaigns, &campaign)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating client report campaign rows: %w", err)
+	}
+
+	if err := noExchangeRate(missing); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// GetClientReportAgents returns activity during [start, end) for each AI
+// agent that worked on the client's campaigns.
+func (db *DB) GetClientReportAgents(ctx context.Context, clientID string, start, end time.Time) ([]*model.ClientReportAgent, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := clientInteractionsCTE + `
+              SELECT a.id, a.name, 
+                  COUNT(DISTINCT ci.lead_id), 
+                  COUNT(DISTINCT ci.id) FILTER (WHERE ci.direction = 'OUTBOUND' AND ci.type <> 'MEETING' 
+                      AND ci.status NOT IN ` + unsentStatuses + `), 
+                  COUNT(DISTINCT ci.id) FILTER (WHERE ci.status = 'RESPONDED'), 
+                  COUNT(DISTINCT ci.id) FILTER (WHERE ci.type = 'MEETING') 
+              FROM client_interactions ci 
+              JOIN ai_agents a ON a.id = ci.ai_agent_id 
+              GROUP BY a.id, a.name 
+              ORDER BY a.name`
+
+	rows, err := db.queryAnalytics(ctx, query, clientID, start, end, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying client report agents: %w", err)
+	}
+	defer rows.Close()
+
+	var agents []*model.ClientReportAgent
+	for rows.Next() {
+		var agent model.ClientReportAgent
+
+		err := rows.Scan(
+			&agent.AgentID, &agent.Name, &agent.LeadsEngaged,
+			&agent.MessagesSent, &agent.Replies, &agent.MeetingsBooked,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning client report agent row: %w", err)
+		}
+
+		agents = append(agents, &agent)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating client report agent rows: %w", err)
+	}
+
+	return agents, nil
+}
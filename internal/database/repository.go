@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+)
+
+// LeadRepository stores leads. Like DB, implementations scope every call to
+// the tenant in ctx and return nil, without an error, for leads that do not
+// exist.
+type LeadRepository interface {
+	CreateLead(ctx context.Context, lead *model.Lead) (*model.Lead, error)
+	GetLeadByID(ctx context.Context, id string) (*model.Lead, error)
+	GetLeadsByFilter(ctx context.Context, filter *model.LeadFilterInput, limit *int, offset *int) ([]*model.Lead, error)
+	GetLeadsByIDs(ctx context.Context, ids []string) ([]*model.Lead, error)
+	GetLeadsByAIAgentID(ctx context.Context, aiAgentID string) ([]*model.Lead, error)
+	GetLeadIDsAfter(ctx context.Context, afterID string, limit int) ([]string, error)
+	GetPipelineStages(ctx context.Context, clientID, campaignID *string, leadsPerStage *int) ([]*model.PipelineStage, error)
+	UpdateLead(ctx context.Context, lead *model.Lead) (*model.Lead, error)
+	UpsertLead(ctx context.Context, lead *model.Lead, matchOn model.LeadMatchKey) (*model.Lead, bool, error)
+	BulkUpdateLeads(ctx context.Context, ids []string, patch *model.LeadPatchInput) ([]string, error)
+	BulkTagLeads(ctx context.Context, ids, addTags, removeTags []string) ([]string, error)
+	DeleteLead(ctx context.Context, id string) (bool, error)
+	RestoreLead(ctx context.Context, id string) (*model.Lead, error)
+	PurgeLead(ctx context.Context, id string) (bool, error)
+	AssignLeadToAIAgent(ctx context.Context, leadID string, aiAgentID string) (*model.Lead, error)
+	GetInteractionsByLeadIDs(ctx context.Context, leadIDs []string) (map[string][]*model.Interaction, error)
+	GetLeadStatuses(ctx context.Context, ids []string) (map[string]model.LeadStatus, error)
+	TransitionLeadStatus(ctx context.Context, id string, from, to model.LeadStatus, reason *string, lossReason *model.LossReason, changedBy *string) (bool, error)
+	BulkTransitionLeadStatus(ctx context.Context, from map[string]model.LeadStatus, to model.LeadStatus, reason, changedBy *string) ([]string, error)
+	RecordLeadStatusChange(ctx context.Context, leadID string, from, to model.LeadStatus, reason, changedBy *string) error
+	RecordIntentScore(ctx context.Context, leadID string, score float64, previous *float64, source string) error
+	UpdateIntentScores(ctx context.Context, scores map[string]float64, source string) ([]*IntentScoreChange, error)
+}
+
+// ClientRepository stores clients and the services they buy.
+type ClientRepository interface {
+	CreateClient(ctx context.Context, client *model.Client) (*model.Client, error)
+	GetClientByID(ctx context.Context, id string) (*model.Client, error)
+	GetClientsByStatus(ctx context.Context, status *model.ClientStatus, limit *int, offset *int) ([]*model.Client, error)
+	GetClientIDsByUserID(ctx context.Context, userID string) ([]string, error)
+	UpdateClient(ctx context.Context, client *model.Client) (*model.Client, error)
+	DeleteClient(ctx context.Context, id string) (bool, error)
+	RestoreClient(ctx context.Context, id string) (*model.Client, error)
+	ArchiveClient(ctx context.Context, id string) (bool, error)
+	GetServicesByClientID(ctx context.Context, clientID string) ([]*model.Service, error)
+	AssignServicesToClient(ctx context.Context, clientID string, serviceIDs []string) error
+	SetClientServices(ctx context.Context, clientID string, serviceIDs []string) error
+}
+
+// CampaignRepository stores campaigns. Status changes go through
+// TransitionCampaignStatus on DB, which the campaign service owns.
+type CampaignRepository interface {
+	CreateCampaign(ctx context.Context, campaign *model.Campaign) (*model.Campaign, error)
+	GetCampaignByID(ctx context.Context, id string) (*model.Campaign, error)
+	GetCampaignsByFilter(ctx context.Context, filter *model.CampaignFilterInput, limit *int, offset *int) ([]*model.Campaign, error)
+	GetCampaignsByAIAgentID(ctx context.Context, aiAgentID string) ([]*model.Campaign, error)
+	GetCampaignsByClientID(ctx context.Context, clientID string) ([]*model.Campaign, error)
+	GetCampaignsByClientIDs(ctx context.Context, clientIDs []string) (map[string][]*model.Campaign, error)
+	GetTargetsByCampaignIDs(ctx context.Context, campaignIDs []string) (map[string][]*model.TargetAudience, error)
+	UpdateCampaign(ctx context.Context, campaign *model.Campaign) (*model.Campaign, error)
+	DeleteCampaign(ctx context.Context, id string) (bool, error)
+}
+
+// APIKeyRepository checks the API keys the REST API authenticates with.
+type APIKeyRepository interface {
+	AuthenticateAPIKey(ctx context.Context, keyHash string) (*model.APIKey, string, error)
+}
+
+// Repositories are the storage the GraphQL and REST APIs, their dataloaders,
+// the pipeline and intent scoring read and write leads, clients and
+// campaigns through. DB provides them on PostgreSQL; another backend, such
+// as SQLite for local development and tests or CockroachDB, provides its
+// own to stand in for it.
+type Repositories struct {
+	Leads     LeadRepository
+	Clients   ClientRepository
+	Campaigns CampaignRepository
+	APIKeys   APIKeyRepository
+}
+
+var (
+	_ LeadRepository     = (*DB)(nil)
+	_ ClientRepository   = (*DB)(nil)
+	_ CampaignRepository = (*DB)(nil)
+	_ APIKeyRepository   = (*DB)(nil)
+)
+
+// Repositories returns db's repositories.
+func (db *DB) Repositories() Repositories {
+	return Repositories{Leads: db, Clients: db, Campaigns: db, APIKeys: db}
+}
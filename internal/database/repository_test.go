@@ -0,0 +1,120 @@
+package database_test
+
+import (
+	"testing"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database/dbtest"
+	"salesagency/internal/tenant"
+)
+
+func TestMain(m *testing.M) { dbtest.Main(m) }
+
+func TestLeadsAreScopedToTheirAgency(t *testing.T) {
+	t.Parallel()
+	db := dbtest.Open(t)
+	ctx, _ := db.Agency()
+	other, _ := db.Agency()
+
+	lead := db.Lead(ctx)
+	db.Lead(other)
+
+	if got, err := db.GetLeadByID(other, lead.ID); err != nil || got != nil {
+		t.Fatalf("GetLeadByID from another agency = %v, %v; want nil, nil", got, err)
+	}
+
+	leads, err := db.GetLeadsByFilter(ctx, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leads) != 1 || leads[0].ID != lead.ID {
+		t.Fatalf("GetLeadsByFilter returned %d leads, want only %s", len(leads), lead.ID)
+	}
+}
+
+func TestLeadsAreScopedToPortalClients(t *testing.T) {
+	t.Parallel()
+	db := dbtest.Open(t)
+	ctx, _ := db.Agency()
+
+	client := db.Client(ctx)
+	enrolled := db.Lead(ctx)
+	db.Lead(ctx)
+	db.Enroll(ctx, db.Campaign(ctx, dbtest.ForClient(client)), enrolled)
+
+	portal := tenant.WithClients(ctx, []string{client.ID})
+	leads, err := db.GetLeadsByFilter(portal, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leads) != 1 || leads[0].ID != enrolled.ID {
+		t.Fatalf("GetLeadsByFilter for the client returned %d leads, want only %s", len(leads), enrolled.ID)
+	}
+}
+
+func TestLeadFilterMatchesStatusAndScore(t *testing.T) {
+	t.Parallel()
+	db := dbtest.Open(t)
+	ctx, _ := db.Agency()
+
+	hot := db.Lead(ctx, func(l *model.Lead) { l.Status = model.LeadStatusEngaged; l.IntentScore = 0.9 })
+	db.Lead(ctx, func(l *model.Lead) { l.Status = model.LeadStatusEngaged; l.IntentScore = 0.2 })
+	db.Lead(ctx, func(l *model.Lead) { l.IntentScore = 0.9 })
+
+	minScore := 0.5
+	leads, err := db.GetLeadsByFilter(ctx, &model.LeadFilterInput{
+		Status:         []model.LeadStatus{model.LeadStatusEngaged},
+		MinIntentScore: &minScore,
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leads) != 1 || leads[0].ID != hot.ID {
+		t.Fatalf("GetLeadsByFilter returned %d leads, want only %s", len(leads), hot.ID)
+	}
+}
+
+func TestClientsAreScopedToTheirAgency(t *testing.T) {
+	t.Parallel()
+	db := dbtest.Open(t)
+	ctx, _ := db.Agency()
+	other, _ := db.Agency()
+
+	client := db.Client(ctx)
+	db.Client(other)
+
+	if got, err := db.GetClientByID(other, client.ID); err != nil || got != nil {
+		t.Fatalf("GetClientByID from another agency = %v, %v; want nil, nil", got, err)
+	}
+
+	clients, err := db.GetClientsByStatus(ctx, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 1 || clients[0].ID != client.ID {
+		t.Fatalf("GetClientsByStatus returned %d clients, want only %s", len(clients), client.ID)
+	}
+}
+
+func TestCampaignsAreScopedToTheirAgency(t *testing.T) {
+	t.Parallel()
+	db := dbtest.Open(t)
+	ctx, _ := db.Agency()
+	other, _ := db.Agency()
+
+	client := db.Client(ctx)
+	campaign := db.Campaign(ctx, dbtest.ForClient(client))
+	db.Campaign(other)
+
+	if got, err := db.GetCampaignByID(other, campaign.ID); err != nil || got != nil {
+		t.Fatalf("GetCampaignByID from another agency = %v, %v; want nil, nil", got, err)
+	}
+
+	campaigns, err := db.GetCampaignsByFilter(ctx, &model.CampaignFilterInput{ClientID: &client.ID}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(campaigns) != 1 || campaigns[0].ID != campaign.ID {
+		t.Fatalf("GetCampaignsByFilter returned %d campaigns, want only %s", len(campaigns), campaign.ID)
+	}
+}
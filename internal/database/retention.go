@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ForgottenLeadName replaces the name of a lead whose personal data was
+// erased.
+const ForgottenLeadName = "Forgotten lead"
+
+// ExpiredLead is a lead past its agency's retention period.
+type ExpiredLead struct {
+	ID       string
+	AgencyID string
+}
+
+// ForgetLead erases the lead's personal data and deletes its attachments,
+// returning their storage keys so the files can be removed too. The lead,
+// its interactions and its history are kept without the personal data, so
+// aggregate metrics do not change. The lead is soft-deleted and cannot be
+// restored. It reports false when the lead does not exist.
+func (db *DB) ForgetLead(ctx context.Context, id string, at time.Time) ([]string, bool, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The email only has to be unique and undeliverable.
+	query := `UPDATE leads SET name = $1, email = 'forgotten-' || id || '@invalid', phone = NULL, company = NULL, 
+              position = NULL, notes = NULL, tags = '{}', timezone = NULL, language = NULL, salesforce_lead_id = NULL, 
+              deleted_at = COALESCE(deleted_at, $2), forgotten_at = $2, updated_at = $2, version = version + 1 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	result, err := tx.ExecContext(ctx, query, ForgottenLeadName, at, id, agencyID)
+	if err != nil {
+		return nil, false, fmt.Errorf("error anonymizing lead: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, false, nil
+	}
+
+	statements := []struct {
+		query  string
+		action string
+	}{
+		{"UPDATE interactions SET message = NULL, subject = NULL, response = NULL, notes = NULL, updated_at = now() WHERE lead_id = $1", "anonymizing interactions"},
+		{"UPDATE calls SET recording_url = NULL WHERE interaction_id IN (SELECT id FROM interactions WHERE lead_id = $1)", "anonymizing calls"},
+		{"DELETE FROM call_transcripts WHERE interaction_id IN (SELECT id FROM interactions WHERE lead_id = $1)", "deleting call transcripts"},
+		{"DELETE FROM outbound_queue WHERE interaction_id IN (SELECT id FROM interactions WHERE lead_id = $1)", "deleting queued messages"},
+		{"UPDATE lead_status_history SET reason = NULL WHERE lead_id = $1", "anonymizing status history"},
+		// Status changes are kept for reporting; the lead's other values are not.
+		{`UPDATE lead_events SET old_value = NULL, 
+              new_value = CASE type WHEN 'CREATED' THEN jsonb_build_object('status', new_value->'status') END 
+              WHERE lead_id = $1 AND type IN ('CREATED', 'FIELD_CHANGED')`, "anonymizing lead events"},
+		{"UPDATE sync_errors SET message = 'redacted' WHERE lead_id = $1", "anonymizing sync errors"},
+		{"DELETE FROM linkedin_profiles WHERE lead_id = $1", "deleting LinkedIn profile"},
+		{"UPDATE lead_attribution SET referrer = NULL, landing_page = NULL WHERE lead_id = $1", "anonymizing attribution"},
+		{"DELETE FROM notifications WHERE subject_id = $1", "deleting notifications"},
+		{"DELETE FROM chat_sessions WHERE lead_id = $1", "deleting chat sessions"},
+	}
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s.query, id); err != nil {
+			return nil, false, fmt.Errorf("error %s: %w", s.action, err)
+		}
+	}
+
+	rows, err := tx.QueryContext(
+		ctx, `DELETE FROM attachments 
+              WHERE lead_id = $1 OR interaction_id IN (SELECT id FROM interactions WHERE lead_id = $1) 
+              RETURNING storage_key`, id,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("error deleting attachments: %w", err)
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, false, fmt.Errorf("error scanning attachment key row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, false, fmt.Errorf("error iterating attachment key rows: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(id))
+
+	return keys, true, nil
+}
+
+// GetLeadRetentionDays returns the agency's retention period, or nil when
+// leads are kept indefinitely.
+func (db *DB) GetLeadRetentionDays(ctx context.Context) (*int, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var days sql.NullInt64
+	if err := db.conn.QueryRowContext(ctx, "SELECT lead_retention_days FROM agencies WHERE id = $1", agencyID).Scan(&days); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching lead retention: %w", err)
+	}
+
+	if !days.Valid {
+		return nil, nil
+	}
+	n := int(days.Int64)
+	return &n, nil
+}
+
+// SetLeadRetentionDays sets the agency's retention period; nil disables it.
+func (db *DB) SetLeadRetentionDays(ctx context.Context, days *int) error {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE agencies SET lead_retention_days = $1, updated_at = $2 WHERE id = $3"
+	if _, err := db.conn.ExecContext(ctx, query, days, time.Now(), agencyID); err != nil {
+		return fmt.Errorf("error setting lead retention: %w", err)
+	}
+
+	return nil
+}
+
+// GetExpiredLeads returns up to limit leads, across all agencies, whose last
+// activity is older than their agency's retention period. Activity is the
+// latest of creation, update and contact.
+func (db *DB) GetExpiredLeads(ctx context.Context, now time.Time, limit int) ([]*ExpiredLead, error) {
+	query := `SELECT l.id, l.agency_id FROM leads l JOIN agencies a ON a.id = l.agency_id 
+              WHERE a.lead_retention_days IS NOT NULL 
+              AND GREATEST(l.created_at, l.updated_at, l.last_contact) < $1 - a.lead_retention_days * interval '1 day' 
+              ORDER BY l.agency_id, l.id LIMIT $2`
+
+	rows, err := db.conn.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying expired leads: %w", err)
+	}
+	defer rows.Close()
+
+	var leads []*ExpiredLead
+	for rows.Next() {
+		var lead ExpiredLead
+		if err := rows.Scan(&lead.ID, &lead.AgencyID); err != nil {
+			return nil, fmt.Errorf("error scanning expired lead row: %w", err)
+		}
+		leads = append(leads, &lead)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired lead rows: %w", err)
+	}
+
+	return leads, nil
+}
@@ -0,0 +1,314 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// SalesforceConnection holds an agency's Salesforce OAuth tokens. The tokens
+// are stored as given, so callers encrypt them first.
+type SalesforceConnection struct {
+	AgencyID     string
+	InstanceURL  string
+	AccessToken  string
+	RefreshToken string
+	ConnectedBy  *string
+	ConnectedAt  time.Time
+	UpdatedAt    time.Time
+}
+
+// SalesforceLead is a lead with the IDs of the Salesforce records synced
+// from it.
+type SalesforceLead struct {
+	Lead          *model.Lead
+	AgencyID      string
+	LeadID        *string
+	OpportunityID *string
+	// Changed is set when the lead was changed since it was last pushed.
+	Changed bool
+}
+
+const salesforceConnectionColumns = `agency_id, instance_url, access_token, refresh_token, connected_by, connected_at, updated_at`
+
+func scanSalesforceConnection(row interface{ Scan(...interface{}) error }) (*SalesforceConnection, error) {
+	var conn SalesforceConnection
+	var connectedBy sql.NullString
+	err := row.Scan(&conn.AgencyID, &conn.InstanceURL, &conn.AccessToken, &conn.RefreshToken, &connectedBy, &conn.ConnectedAt, &conn.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if connectedBy.Valid {
+		conn.ConnectedBy = &connectedBy.String
+	}
+	return &conn, nil
+}
+
+// SaveSalesforceConnection connects the current agency to a Salesforce org,
+// replacing any previous connection.
+func (db *DB) SaveSalesforceConnection(ctx context.Context, instanceURL, accessToken, refreshToken string, connectedBy *string) (*SalesforceConnection, error) {
+	query := `INSERT INTO salesforce_connections (agency_id, instance_url, access_token, refresh_token, connected_by) 
+              VALUES ($1, $2, $3, $4, $5) 
+              ON CONFLICT (agency_id) DO UPDATE SET instance_url = EXCLUDED.instance_url, 
+                  access_token = EXCLUDED.access_token, refresh_token = EXCLUDED.refresh_token, 
+                  connected_by = EXCLUDED.connected_by, connected_at = now(), updated_at = now() 
+              RETURNING ` + salesforceConnectionColumns
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := scanSalesforceConnection(db.conn.QueryRowContext(ctx, query, agencyID, instanceURL, accessToken, refreshToken, connectedBy))
+	if err != nil {
+		return nil, fmt.Errorf("error saving salesforce connection: %w", err)
+	}
+	return conn, nil
+}
+
+// GetSalesforceConnection returns the agency's connection, or nil when it
+// has not connected Salesforce.
+func (db *DB) GetSalesforceConnection(ctx context.Context, agencyID string) (*SalesforceConnection, error) {
+	query := `SELECT ` + salesforceConnectionColumns + ` FROM salesforce_connections 
+              WHERE agency_id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	tenantID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := scanSalesforceConnection(db.conn.QueryRowContext(ctx, query, agencyID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting salesforce connection: %w", err)
+	}
+	return conn, nil
+}
+
+// GetSalesforceConnections lists every connected agency.
+func (db *DB) GetSalesforceConnections(ctx context.Context) ([]*SalesforceConnection, error) {
+	query := `SELECT ` + salesforceConnectionColumns + ` FROM salesforce_connections 
+              WHERE (agency_id = $1 OR $1 IS NULL) ORDER BY agency_id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying salesforce connections: %w", err)
+	}
+	defer rows.Close()
+
+	var conns []*SalesforceConnection
+	for rows.Next() {
+		conn, err := scanSalesforceConnection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning salesforce connection row: %w", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating salesforce connection rows: %w", err)
+	}
+
+	return conns, nil
+}
+
+func (db *DB) UpdateSalesforceAccessToken(ctx context.Context, agencyID, accessToken string) error {
+	query := `UPDATE salesforce_connections SET access_token = $1, updated_at = now() 
+              WHERE agency_id = $2 AND (agency_id = $3 OR $3 IS NULL)`
+
+	tenantID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, accessToken, agencyID, tenantID); err != nil {
+		return fmt.Errorf("error updating salesforce access token: %w", err)
+	}
+	return nil
+}
+
+// DeleteSalesforceConnection disconnects the current agency. Salesforce IDs
+// stay on its leads so that reconnecting the same org does not duplicate
+// records.
+func (db *DB) DeleteSalesforceConnection(ctx context.Context) (bool, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM salesforce_connections WHERE agency_id = $1`, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting salesforce connection: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+const salesforceLeadColumns = `id, agency_id, name, email, phone, company, position, status, source, notes, deal_value, 
+              salesforce_lead_id, salesforce_opportunity_id, 
+              (salesforce_synced_at IS NULL OR COALESCE(updated_at, created_at) > salesforce_synced_at)`
+
+func scanSalesforceLead(rows *sql.Rows) (*SalesforceLead, error) {
+	var lead model.Lead
+	var sf SalesforceLead
+	var phone, company, position, source, notes, sfLeadID, sfOpportunityID sql.NullString
+	var dealValue sql.NullFloat64
+
+	err := rows.Scan(
+		&lead.ID, &sf.AgencyID, &lead.Name, &lead.Email, &phone, &company, &position, &lead.Status, &source, &notes, &dealValue,
+		&sfLeadID, &sfOpportunityID, &sf.Changed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning lead row: %w", err)
+	}
+
+	if phone.Valid {
+		lead.Phone = &phone.String
+	}
+	if company.Valid {
+		lead.Company = &company.String
+	}
+	if position.Valid {
+		lead.Position = &position.String
+	}
+	if source.Valid {
+		lead.Source = &source.String
+	}
+	if notes.Valid {
+		lead.Notes = &notes.String
+	}
+	if dealValue.Valid {
+		lead.DealValue = &dealValue.Float64
+	}
+	if sfLeadID.Valid {
+		sf.LeadID = &sfLeadID.String
+	}
+	if sfOpportunityID.Valid {
+		sf.OpportunityID = &sfOpportunityID.String
+	}
+
+	sf.Lead = &lead
+	return &sf, nil
+}
+
+func (db *DB) querySalesforceLeads(ctx context.Context, query string, args ...interface{}) ([]*SalesforceLead, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying leads: %w", err)
+	}
+	defer rows.Close()
+
+	var leads []*SalesforceLead
+	for rows.Next() {
+		lead, err := scanSalesforceLead(rows)
+		if err != nil {
+			return nil, err
+		}
+		leads = append(leads, lead)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead rows: %w", err)
+	}
+
+	return leads, nil
+}
+
+func (db *DB) GetSalesforceLead(ctx context.Context, leadID string) (*SalesforceLead, error) {
+	query := `SELECT ` + salesforceLeadColumns + ` FROM leads 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	leads, err := db.querySalesforceLeads(ctx, query, leadID, agencyID)
+	if err != nil || len(leads) == 0 {
+		return nil, err
+	}
+	return leads[0], nil
+}
+
+// GetLeadsPendingSalesforceSync pages through the agency's leads that were
+// changed since they were last pushed, or that are WON without an
+// opportunity, in ID order after afterID.
+func (db *DB) GetLeadsPendingSalesforceSync(ctx context.Context, agencyID, afterID string, limit int) ([]*SalesforceLead, error) {
+	query := `SELECT ` + salesforceLeadColumns + ` FROM leads 
+              WHERE agency_id = $1 AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL 
+              AND (salesforce_synced_at IS NULL OR COALESCE(updated_at, created_at) > salesforce_synced_at 
+                  OR (status = $3 AND salesforce_opportunity_id IS NULL)) 
+              AND id::text > $4 
+              ORDER BY id::text 
+              LIMIT $5`
+
+	tenantID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.querySalesforceLeads(ctx, query, agencyID, tenantID, model.LeadStatusWon, afterID, limit)
+}
+
+// GetSalesforceLinkedLeads pages through the agency's leads that have a
+// Salesforce lead, in ID order after afterID.
+func (db *DB) GetSalesforceLinkedLeads(ctx context.Context, agencyID, afterID string, limit int) ([]*SalesforceLead, error) {
+	query := `SELECT ` + salesforceLeadColumns + ` FROM leads 
+              WHERE agency_id = $1 AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NULL 
+              AND salesforce_lead_id IS NOT NULL AND id::text > $3 
+              ORDER BY id::text 
+              LIMIT $4`
+
+	tenantID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.querySalesforceLeads(ctx, query, agencyID, tenantID, afterID, limit)
+}
+
+// MarkLeadSyncedToSalesforce records the Salesforce lead pushed at syncedAt.
+// updated_at is left alone so the push itself does not count as a change.
+func (db *DB) MarkLeadSyncedToSalesforce(ctx context.Context, leadID, salesforceLeadID string, syncedAt time.Time) error {
+	query := `UPDATE leads SET salesforce_lead_id = $1, salesforce_synced_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, salesforceLeadID, syncedAt, leadID, agencyID); err != nil {
+		return fmt.Errorf("error marking lead synced: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) SetLeadSalesforceOpportunity(ctx context.Context, leadID, opportunityID string) error {
+	query := `UPDATE leads SET salesforce_opportunity_id = $1 
+              WHERE id = $2 AND (agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, opportunityID, leadID, agencyID); err != nil {
+		return fmt.Errorf("error setting salesforce opportunity: %w", err)
+	}
+	return nil
+}
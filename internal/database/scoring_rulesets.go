@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+const scoringRulesetColumns = `id, client_id, name, rules, active, created_at, updated_at`
+
+func (db *DB) GetScoringRulesetByID(ctx context.Context, id string) (*model.ScoringRuleset, error) {
+	query := `SELECT ` + scoringRulesetColumns + ` FROM scoring_rulesets 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleset, err := scanScoringRuleset(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching scoring ruleset: %w", err)
+	}
+
+	return ruleset, nil
+}
+
+func (db *DB) GetScoringRulesets(ctx context.Context, clientID *string) ([]*model.ScoringRuleset, error) {
+	query := `SELECT ` + scoringRulesetColumns + ` FROM scoring_rulesets 
+              WHERE (client_id = $1 OR $1 IS NULL) AND (agency_id = $2 OR $2 IS NULL) 
+              ORDER BY created_at DESC, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, clientID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying scoring rulesets: %w", err)
+	}
+	defer rows.Close()
+
+	rulesets := []*model.ScoringRuleset{}
+	for rows.Next() {
+		ruleset, err := scanScoringRuleset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning scoring ruleset row: %w", err)
+		}
+		rulesets = append(rulesets, ruleset)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scoring ruleset rows: %w", err)
+	}
+
+	return rulesets, nil
+}
+
+// CreateScoringRuleset stores a new, inactive ruleset.
+func (db *DB) CreateScoringRuleset(ctx context.Context, ruleset *model.ScoringRuleset) (*model.ScoringRuleset, error) {
+	query := `INSERT INTO scoring_rulesets (client_id, name, rules, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, ruleset.ClientID, ruleset.Name, ruleset.Rules, ruleset.CreatedAt, agencyID,
+	).Scan(&ruleset.ID)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating scoring ruleset: %w", err)
+	}
+
+	return ruleset, nil
+}
+
+func (db *DB) UpdateScoringRuleset(ctx context.Context, ruleset *model.ScoringRuleset) (*model.ScoringRuleset, error) {
+	query := `UPDATE scoring_rulesets SET name = $1, rules = $2, updated_at = $3 
+              WHERE id = $4 AND (agency_id = $5 OR $5 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.conn.ExecContext(ctx, query, ruleset.Name, ruleset.Rules, ruleset.UpdatedAt, ruleset.ID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating scoring ruleset: %w", err)
+	}
+
+	return db.GetScoringRulesetByID(ctx, ruleset.ID)
+}
+
+func (db *DB) DeleteScoringRuleset(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM scoring_rulesets WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting scoring ruleset: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetScoringRulesetActive activates or deactivates a ruleset. Activating one
+// deactivates the client's other rulesets in the same transaction.
+func (db *DB) SetScoringRulesetActive(ctx context.Context, id string, active bool) (*model.ScoringRuleset, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var clientID string
+	err = tx.QueryRowContext(
+		ctx, "SELECT client_id FROM scoring_rulesets WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) FOR UPDATE",
+		id, agencyID,
+	).Scan(&clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error locking scoring ruleset: %w", err)
+	}
+
+	if active {
+		_, err = tx.ExecContext(
+			ctx, "UPDATE scoring_rulesets SET active = false WHERE client_id = $1 AND active AND id <> $2",
+			clientID, id,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error deactivating scoring rulesets: %w", err)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE scoring_rulesets SET active = $1 WHERE id = $2", active, id); err != nil {
+		return nil, fmt.Errorf("error updating scoring ruleset: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return db.GetScoringRulesetByID(ctx, id)
+}
+
+// GetActiveScoringRulesetsByLeadIDs returns, for each lead, the active
+// ruleset of the client whose campaign most recently reached it, counting
+// only clients that have one. Attribution follows campaignMetricsQuery.
+func (db *DB) GetActiveScoringRulesetsByLeadIDs(ctx context.Context, leadIDs []string) (map[string]*model.ScoringRuleset, error) {
+	query := `SELECT DISTINCT ON (i.lead_id) i.lead_id, r.id, r.client_id, r.name, r.rules, r.active, r.created_at, r.updated_at 
+              FROM interactions i 
+              JOIN campaigns c ON i.timestamp >= c.start_date 
+                  AND (c.end_date IS NULL OR i.timestamp <= c.end_date) 
+              JOIN scoring_rulesets r ON r.client_id = c.client_id AND r.active 
+              WHERE i.lead_id = ANY($1) AND (r.agency_id = $2 OR $2 IS NULL) 
+              AND (i.template_id IN (SELECT id FROM message_templates WHERE campaign_id = c.id) 
+                  OR i.ai_agent_id IN (SELECT ai_agent_id FROM campaign_ai_agent WHERE campaign_id = c.id)) 
+              ORDER BY i.lead_id, i.timestamp DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(leadIDs), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying lead scoring rulesets: %w", err)
+	}
+	defer rows.Close()
+
+	rulesets := make(map[string]*model.ScoringRuleset)
+	for rows.Next() {
+		var leadID string
+		var ruleset model.ScoringRuleset
+		var updatedAt sql.NullTime
+
+		err := rows.Scan(
+			&leadID, &ruleset.ID, &ruleset.ClientID, &ruleset.Name, &ruleset.Rules, &ruleset.Active,
+			&ruleset.CreatedAt, &updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning lead scoring ruleset row: %w", err)
+		}
+		if updatedAt.Valid {
+			ruleset.UpdatedAt = &updatedAt.Time
+		}
+
+		rulesets[leadID] = &ruleset
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead scoring ruleset rows: %w", err)
+	}
+
+	return rulesets, nil
+}
+
+func scanScoringRuleset(row interface{ Scan(...interface{}) error }) (*model.ScoringRuleset, error) {
+	var ruleset model.ScoringRuleset
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&ruleset.ID, &ruleset.ClientID, &ruleset.Name, &ruleset.Rules, &ruleset.Active,
+		&ruleset.CreatedAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if updatedAt.Valid {
+		ruleset.UpdatedAt = &updatedAt.Time
+	}
+
+	return &ruleset, nil
+}
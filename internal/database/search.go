@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+const headlineOptions = `'MaxFragments=2, MaxWords=20, MinWords=5, StartSel=<mark>, StopSel=</mark>'`
+
+// searchSources select (type, id, rank, snippet) for each searchable table.
+// They share the tsquery q, the agency in $2 and skip soft-deleted leads and
+// clients. Interactions also match on their call transcript, unless they
+// already match themselves.
+var searchSources = map[model.SearchType]string{
+	model.SearchTypeLead: `SELECT 'LEAD', l.id::text, ts_rank(l.search_vector, q.query), 
+              ts_headline('english', concat_ws(' ', l.name, l.email, l.company, l.position, l.notes), q.query, ` + headlineOptions + `) 
+              FROM leads l, q 
+              WHERE l.search_vector @@ q.query AND (l.agency_id = $2 OR $2 IS NULL) AND l.deleted_at IS NULL`,
+	model.SearchTypeClient: `SELECT 'CLIENT', c.id::text, ts_rank(c.search_vector, q.query), 
+              ts_headline('english', concat_ws(' ', c.name, c.contact_person, c.email, c.industry, c.notes), q.query, ` + headlineOptions + `) 
+              FROM clients c, q 
+              WHERE c.search_vector @@ q.query AND (c.agency_id = $2 OR $2 IS NULL) AND c.deleted_at IS NULL`,
+	model.SearchTypeCampaign: `SELECT 'CAMPAIGN', c.id::text, ts_rank(c.search_vector, q.query), 
+              ts_headline('english', concat_ws(' ', c.name, c.description), q.query, ` + headlineOptions + `) 
+              FROM campaigns c, q 
+              WHERE c.search_vector @@ q.query AND (c.agency_id = $2 OR $2 IS NULL)`,
+	model.SearchTypeInteraction: `SELECT 'INTERACTION', i.id::text, ts_rank(i.search_vector, q.query), 
+              ts_headline('english', concat_ws(' ', i.message, i.response, i.notes), q.query, ` + headlineOptions + `) 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id, q 
+              WHERE i.search_vector @@ q.query AND (l.agency_id = $2 OR $2 IS NULL) AND l.deleted_at IS NULL 
+              UNION ALL 
+              SELECT 'INTERACTION', i.id::text, ts_rank(t.search_vector, q.query), 
+              ts_headline('english', t.text, q.query, ` + headlineOptions + `) 
+              FROM call_transcripts t JOIN interactions i ON i.id = t.interaction_id JOIN leads l ON l.id = i.lead_id, q 
+              WHERE t.search_vector @@ q.query AND NOT i.search_vector @@ q.query 
+              AND (l.agency_id = $2 OR $2 IS NULL) AND l.deleted_at IS NULL`,
+}
+
+// Search runs a web-style query (quoted phrases, OR, -exclusions) across the
+// given record types and returns the best matches first. An empty types list
+// searches everything.
+func (db *DB) Search(ctx context.Context, text string, types []model.SearchType, limit int) ([]*model.SearchHit, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(types) == 0 {
+		types = model.AllSearchType
+	}
+
+	var branches []string
+	seen := make(map[model.SearchType]bool, len(types))
+	for _, searchType := range types {
+		source, ok := searchSources[searchType]
+		if !ok || seen[searchType] {
+			continue
+		}
+		seen[searchType] = true
+		branches = append(branches, source)
+	}
+	if len(branches) == 0 {
+		return nil, nil
+	}
+
+	query := `WITH q AS (SELECT websearch_to_tsquery('english', $1) AS query) 
+              SELECT type, id, rank, snippet FROM (` + strings.Join(branches, " UNION ALL ") + `) AS hits (type, id, rank, snippet) 
+              ORDER BY rank DESC LIMIT $3`
+
+	rows, err := db.conn.QueryContext(ctx, query, text, agencyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []*model.SearchHit
+	for rows.Next() {
+		var hit model.SearchHit
+		var snippet sql.NullString
+
+		if err := rows.Scan(&hit.Type, &hit.ResultID, &hit.Rank, &snippet); err != nil {
+			return nil, fmt.Errorf("error scanning search hit: %w", err)
+		}
+
+		if snippet.Valid && snippet.String != "" {
+			hit.Snippet = &snippet.String
+		}
+
+		hits = append(hits, &hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search hits: %w", err)
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/tenant"
+)
+
+var ErrDuplicateSegment = errors.New("a segment with this name already exists")
+
+const segmentColumns = `id, name, filter, lead_count, counted_at, created_by, created_at`
+
+func (db *DB) GetSegmentByID(ctx context.Context, id string) (*model.Segment, error) {
+	query := `SELECT ` + segmentColumns + ` FROM segments 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	segment, err := scanSegment(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching segment: %w", err)
+	}
+
+	return segment, nil
+}
+
+func (db *DB) GetSegments(ctx context.Context) ([]*model.Segment, error) {
+	query := `SELECT ` + segmentColumns + ` FROM segments 
+              WHERE (agency_id = $1 OR $1 IS NULL) 
+              ORDER BY name, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying segments: %w", err)
+	}
+	defer rows.Close()
+
+	segments := []*model.Segment{}
+	for rows.Next() {
+		segment, err := scanSegment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning segment row: %w", err)
+		}
+		segments = append(segments, segment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating segment rows: %w", err)
+	}
+
+	return segments, nil
+}
+
+func (db *DB) CreateSegment(ctx context.Context, segment *model.Segment) (*model.Segment, error) {
+	query := `INSERT INTO segments (agency_id, name, filter, created_by, created_at) 
+              VALUES ($1, $2, $3, $4, $5) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := json.Marshal(segment.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding segment filter: %w", err)
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, segment.Name, filter, segment.CreatedByID, segment.CreatedAt,
+	).Scan(&segment.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateSegment
+		}
+		return nil, fmt.Errorf("error creating segment: %w", err)
+	}
+
+	return segment, nil
+}
+
+func (db *DB) DeleteSegment(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM segments WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting segment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// CountLeadsByFilter counts the leads GetLeadsByFilter would return without
+// pagination.
+func (db *DB) CountLeadsByFilter(ctx context.Context, filter *model.LeadFilterInput) (int, error) {
+	q, err := leadFilterQuery(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	query, args := q.build()
+
+	var count int
+	if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+query+") AS filtered", args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting leads: %w", err)
+	}
+
+	return count, nil
+}
+
+// RefreshSegmentCount recounts the segment's leads and stores the count.
+func (db *DB) RefreshSegmentCount(ctx context.Context, segment *model.Segment, at time.Time) error {
+	count, err := db.CountLeadsByFilter(ctx, segment.Filter)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE segments SET lead_count = $1, counted_at = $2 WHERE id = $3"
+	if _, err := db.conn.ExecContext(ctx, query, count, at, segment.ID); err != nil {
+		return fmt.Errorf("error updating segment count: %w", err)
+	}
+
+	segment.LeadCount = &count
+	segment.CountedAt = &at
+	return nil
+}
+
+// RefreshSegmentCounts recounts every segment of every agency and returns
+// how many were refreshed. Each segment is counted within its own agency.
+func (db *DB) RefreshSegmentCounts(ctx context.Context, at time.Time) (int, error) {
+	rows, err := db.conn.QueryContext(ctx, "SELECT agency_id, id, filter FROM segments ORDER BY agency_id, id")
+	if err != nil {
+		return 0, fmt.Errorf("error querying segments: %w", err)
+	}
+
+	type agencySegment struct {
+		agencyID string
+		segment  *model.Segment
+	}
+	var segments []agencySegment
+	for rows.Next() {
+		var s agencySegment
+		var filter []byte
+		s.segment = &model.Segment{Filter: &model.LeadFilterInput{}}
+		if err := rows.Scan(&s.agencyID, &s.segment.ID, &filter); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning segment row: %w", err)
+		}
+		if err := json.Unmarshal(filter, s.segment.Filter); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error decoding segment filter: %w", err)
+		}
+		segments = append(segments, s)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, fmt.Errorf("error iterating segment rows: %w", err)
+	}
+
+	refreshed := 0
+	for _, s := range segments {
+		if err := db.RefreshSegmentCount(tenant.WithAgency(ctx, s.agencyID), s.segment, at); err != nil {
+			return refreshed, err
+		}
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+func scanSegment(row interface{ Scan(...interface{}) error }) (*model.Segment, error) {
+	var segment model.Segment
+	var filter []byte
+	var leadCount sql.NullInt64
+	var countedAt sql.NullTime
+	var createdBy sql.NullString
+
+	err := row.Scan(&segment.ID, &segment.Name, &filter, &leadCount, &countedAt, &createdBy, &segment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	segment.Filter = &model.LeadFilterInput{}
+	if err := json.Unmarshal(filter, segment.Filter); err != nil {
+		return nil, fmt.Errorf("error decoding segment filter: %w", err)
+	}
+	if leadCount.Valid {
+		count := int(leadCount.Int64)
+		segment.LeadCount = &count
+	}
+	if countedAt.Valid {
+		segment.CountedAt = &countedAt.Time
+	}
+	if createdBy.Valid {
+		segment.CreatedByID = &createdBy.String
+	}
+
+	return &segment, nil
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+func (db *DB) GetAIAgentSendThrottles(ctx context.Context, agentID string) ([]*model.SendThrottle, error) {
+	query := `SELECT t.channel, t.daily_limit, t.ramp_per_day, t.max_daily_limit, t.started_at 
+              FROM agent_send_throttles t 
+              JOIN ai_agents a ON a.id = t.ai_agent_id 
+              WHERE t.ai_agent_id = $1 AND (a.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY t.channel`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, agentID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying send throttles: %w", err)
+	}
+	defer rows.Close()
+
+	throttles := []*model.SendThrottle{}
+	for rows.Next() {
+		throttle, err := scanSendThrottle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning send throttle row: %w", err)
+		}
+		throttles = append(throttles, throttle)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating send throttle rows: %w", err)
+	}
+
+	return throttles, nil
+}
+
+// GetAIAgentSendThrottle returns nil when the agent's sends on channel are
+// not throttled.
+func (db *DB) GetAIAgentSendThrottle(ctx context.Context, agentID string, channel model.Channel) (*model.SendThrottle, error) {
+	query := `SELECT t.channel, t.daily_limit, t.ramp_per_day, t.max_daily_limit, t.started_at 
+              FROM agent_send_throttles t 
+              JOIN ai_agents a ON a.id = t.ai_agent_id 
+              WHERE t.ai_agent_id = $1 AND t.channel = $2 AND (a.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	throttle, err := scanSendThrottle(db.conn.QueryRowContext(ctx, query, agentID, channel, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching send throttle: %w", err)
+	}
+
+	return throttle, nil
+}
+
+// SetAIAgentSendThrottle creates or replaces the agent's throttle on the
+// throttle's channel. A replaced throttle keeps ramping from when it was
+// first set. It reports whether the agent exists.
+func (db *DB) SetAIAgentSendThrottle(ctx context.Context, agentID string, throttle *model.SendThrottle) (bool, error) {
+	query := `INSERT INTO agent_send_throttles (ai_agent_id, channel, daily_limit, ramp_per_day, max_daily_limit, started_at) 
+              SELECT id, $2, $3, $4, $5, $6 FROM ai_agents WHERE id = $1 AND (agency_id = $7 OR $7 IS NULL) 
+              ON CONFLICT (ai_agent_id, channel) DO UPDATE 
+              SET daily_limit = EXCLUDED.daily_limit, ramp_per_day = EXCLUDED.ramp_per_day, 
+              max_daily_limit = EXCLUDED.max_daily_limit, updated_at = EXCLUDED.started_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(
+		ctx, query, agentID, throttle.Channel, throttle.DailyLimit, throttle.RampPerDay, throttle.MaxDailyLimit, time.Now(), agencyID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error setting send throttle: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) DeleteAIAgentSendThrottle(ctx context.Context, agentID string, channel model.Channel) (bool, error) {
+	query := `DELETE FROM agent_send_throttles t USING ai_agents a 
+              WHERE a.id = t.ai_agent_id AND t.ai_agent_id = $1 AND t.channel = $2 AND (a.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, agentID, channel, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting send throttle: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetAIAgentSendCounts returns how many messages the agent sent on each
+// throttled channel on day.
+func (db *DB) GetAIAgentSendCounts(ctx context.Context, agentID string, day time.Time) (map[model.Channel]int, error) {
+	query := `SELECT channel, sent FROM agent_send_counts WHERE ai_agent_id = $1 AND day = $2`
+
+	rows, err := db.conn.QueryContext(ctx, query, agentID, day)
+	if err != nil {
+		return nil, fmt.Errorf("error querying send counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[model.Channel]int)
+	for rows.Next() {
+		var channel model.Channel
+		var sent int
+		if err := rows.Scan(&channel, &sent); err != nil {
+			return nil, fmt.Errorf("error scanning send count row: %w", err)
+		}
+		counts[channel] = sent
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating send count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// ReserveSend counts one more message by the agent on channel on day unless
+// limit messages were already counted, and reports whether it was counted.
+// Concurrent senders never exceed the limit between them.
+func (db *DB) ReserveSend(ctx context.Context, agentID string, channel model.Channel, day time.Time, limit int) (bool, error) {
+	query := `INSERT INTO agent_send_counts (ai_agent_id, channel, day, sent) 
+              VALUES ($1, $2, $3, 1) 
+              ON CONFLICT (ai_agent_id, channel, day) DO UPDATE 
+              SET sent = agent_send_counts.sent + 1 
+              WHERE agent_send_counts.sent < $4 
+              RETURNING sent`
+
+	if limit <= 0 {
+		return false, nil
+	}
+
+	var sent int
+	err := db.conn.QueryRowContext(ctx, query, agentID, channel, day, limit).Scan(&sent)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reserving send: %w", err)
+	}
+
+	return true, nil
+}
+
+// UnreserveSend gives back a message counted by ReserveSend that was not
+// sent after all.
+func (db *DB) UnreserveSend(ctx context.Context, agentID string, channel model.Channel, day time.Time) error {
+	query := `UPDATE agent_send_counts SET sent = sent - 1 
+              WHERE ai_agent_id = $1 AND channel = $2 AND day = $3 AND sent > 0`
+
+	if _, err := db.conn.ExecContext(ctx, query, agentID, channel, day); err != nil {
+		return fmt.Errorf("error unreserving send: %w", err)
+	}
+
+	return nil
+}
+
+func scanSendThrottle(row interface{ Scan(...interface{}) error }) (*model.SendThrottle, error) {
+	var throttle model.SendThrottle
+	var maxDailyLimit sql.NullInt64
+
+	err := row.Scan(&throttle.Channel, &throttle.DailyLimit, &throttle.RampPerDay, &maxDailyLimit, &throttle.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxDailyLimit.Valid {
+		max := int(maxDailyLimit.Int64)
+		throttle.MaxDailyLimit = &max
+	}
+
+	return &throttle, nil
+}
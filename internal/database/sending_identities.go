@@ -0,0 +1,313 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// ErrDuplicateSendingIdentity is returned when the client already sends from
+// the address.
+var ErrDuplicateSendingIdentity = errors.New("the client already has a sending identity with this address")
+
+const sendingIdentityColumns = `s.id, s.client_id, s.from_name, s.from_email, s.domain, s.dkim_selector, s.verification_token, 
+              s.domain_status, s.dkim_status, s.is_default, s.last_checked_at, s.verified_at, s.check_error, s.created_at, s.updated_at`
+
+func (db *DB) GetSendingIdentityByID(ctx context.Context, id string) (*model.SendingIdentity, error) {
+	query := `SELECT ` + sendingIdentityColumns + ` FROM sending_identities s 
+              WHERE s.id = $1 AND (s.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := scanSendingIdentity(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return identity, err
+}
+
+// GetSendingIdentities lists the client's sending identities, the default
+// first.
+func (db *DB) GetSendingIdentities(ctx context.Context, clientID string) ([]*model.SendingIdentity, error) {
+	query := `SELECT ` + sendingIdentityColumns + ` FROM sending_identities s 
+              WHERE s.client_id = $1 AND (s.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY s.is_default DESC, s.from_email, s.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.querySendingIdentities(ctx, query, clientID, agencyID)
+}
+
+// GetPendingSendingIdentities lists the identities whose domain or DKIM key
+// is still to be verified, oldest first.
+func (db *DB) GetPendingSendingIdentities(ctx context.Context) ([]*model.SendingIdentity, error) {
+	query := `SELECT ` + sendingIdentityColumns + ` FROM sending_identities s 
+              WHERE (s.domain_status = 'PENDING' OR s.dkim_status = 'PENDING') AND (s.agency_id = $1 OR $1 IS NULL) 
+              ORDER BY s.created_at, s.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.querySendingIdentities(ctx, query, agencyID)
+}
+
+// GetCampaignSendingIdentity returns the identity the campaign emails leads
+// from: its own, or else its client's default. It returns nil when neither
+// is set, and the campaign sends from the agency's address.
+func (db *DB) GetCampaignSendingIdentity(ctx context.Context, campaignID string) (*model.SendingIdentity, error) {
+	query := `SELECT ` + sendingIdentityColumns + ` 
+              FROM campaigns c JOIN sending_identities s ON s.id = COALESCE( 
+                  c.sending_identity_id, 
+                  (SELECT d.id FROM sending_identities d WHERE d.client_id = c.client_id AND d.is_default) 
+              ) 
+              WHERE c.id = $1 AND (c.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := scanSendingIdentity(db.conn.QueryRowContext(ctx, query, campaignID, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return identity, err
+}
+
+// SetCampaignSendingIdentity makes the campaign send from identityID, or
+// with nil from its client's default. It reports whether the campaign
+// exists.
+func (db *DB) SetCampaignSendingIdentity(ctx context.Context, campaignID string, identityID *string) (bool, error) {
+	query := `UPDATE campaigns SET sending_identity_id = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, identityID, time.Now(), campaignID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error setting campaign sending identity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// CreateSendingIdentity saves a new, unverified identity. The client's first
+// identity becomes its default, as does any created with IsDefault.
+func (db *DB) CreateSendingIdentity(ctx context.Context, identity *model.SendingIdentity) (*model.SendingIdentity, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if identity.IsDefault {
+		if err := clearDefaultSendingIdentity(ctx, tx, identity.ClientID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `INSERT INTO sending_identities (agency_id, client_id, from_name, from_email, domain, dkim_selector, 
+              verification_token, domain_status, dkim_status, is_default, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 
+                  $10 OR NOT EXISTS (SELECT 1 FROM sending_identities WHERE client_id = $2 AND is_default), $11) 
+              RETURNING id, is_default`
+
+	err = tx.QueryRowContext(
+		ctx, query, agencyID, identity.ClientID, identity.FromName, identity.FromEmail, identity.Domain,
+		identity.DkimSelector, identity.VerificationToken, identity.DomainStatus, identity.DkimStatus,
+		identity.IsDefault, identity.CreatedAt,
+	).Scan(&identity.ID, &identity.IsDefault)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateSendingIdentity
+		}
+		return nil, fmt.Errorf("error creating sending identity: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return identity, nil
+}
+
+// SetDefaultSendingIdentity makes the identity its client's default in
+// place of any other. It returns nil when the identity does not exist.
+func (db *DB) SetDefaultSendingIdentity(ctx context.Context, id string) (*model.SendingIdentity, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var clientID string
+	err = tx.QueryRowContext(
+		ctx, "SELECT client_id FROM sending_identities WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) FOR UPDATE",
+		id, agencyID,
+	).Scan(&clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error locking sending identity: %w", err)
+	}
+
+	if err := clearDefaultSendingIdentity(ctx, tx, clientID); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE sending_identities s SET is_default = true, updated_at = $1 
+              WHERE s.id = $2 
+              RETURNING ` + sendingIdentityColumns
+
+	identity, err := scanSendingIdentity(tx.QueryRowContext(ctx, query, time.Now(), id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return identity, nil
+}
+
+func clearDefaultSendingIdentity(ctx context.Context, tx *sql.Tx, clientID string) error {
+	query := `UPDATE sending_identities SET is_default = false, updated_at = $1 
+              WHERE client_id = $2 AND is_default`
+
+	if _, err := tx.ExecContext(ctx, query, time.Now(), clientID); err != nil {
+		return fmt.Errorf("error clearing default sending identity: %w", err)
+	}
+	return nil
+}
+
+// SaveSendingIdentityCheck records the outcome of checking the identity's
+// DNS records.
+func (db *DB) SaveSendingIdentityCheck(ctx context.Context, identity *model.SendingIdentity) error {
+	query := `UPDATE sending_identities SET domain_status = $1, dkim_status = $2, last_checked_at = $3, 
+              verified_at = $4, check_error = $5 
+              WHERE id = $6 AND (agency_id = $7 OR $7 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.conn.ExecContext(
+		ctx, query, identity.DomainStatus, identity.DkimStatus, identity.LastCheckedAt, identity.VerifiedAt,
+		identity.CheckError, identity.ID, agencyID,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving sending identity check: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSendingIdentity deletes the identity. Campaigns that sent from it
+// fall back to their client's default.
+func (db *DB) DeleteSendingIdentity(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM sending_identities WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting sending identity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) querySendingIdentities(ctx context.Context, query string, args ...interface{}) ([]*model.SendingIdentity, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sending identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []*model.SendingIdentity{}
+	for rows.Next() {
+		identity, err := scanSendingIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sending identity rows: %w", err)
+	}
+
+	return identities, nil
+}
+
+func scanSendingIdentity(row interface{ Scan(...interface{}) error }) (*model.SendingIdentity, error) {
+	var identity model.SendingIdentity
+	var lastCheckedAt, verifiedAt, updatedAt sql.NullTime
+	var checkError sql.NullString
+
+	err := row.Scan(
+		&identity.ID, &identity.ClientID, &identity.FromName, &identity.FromEmail, &identity.Domain,
+		&identity.DkimSelector, &identity.VerificationToken, &identity.DomainStatus, &identity.DkimStatus,
+		&identity.IsDefault, &lastCheckedAt, &verifiedAt, &checkError, &identity.CreatedAt, &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning sending identity row: %w", err)
+	}
+
+	if lastCheckedAt.Valid {
+		identity.LastCheckedAt = &lastCheckedAt.Time
+	}
+	if verifiedAt.Valid {
+		identity.VerifiedAt = &verifiedAt.Time
+	}
+	identity.CheckError = nullString(checkError)
+	if updatedAt.Valid {
+		identity.UpdatedAt = &updatedAt.Time
+	}
+
+	return &identity, nil
+}
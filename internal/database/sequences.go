@@ -0,0 +1,587 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// enrollmentLease is how long a claimed enrollment stays hidden from other
+// claims while its step runs.
+const enrollmentLease = 5 * time.Minute
+
+const sequenceColumns = `id, campaign_id, name, created_at, updated_at`
+
+const enrollmentColumns = `e.id, e.sequence_id, e.lead_id, e.status, e.current_step, e.next_run_at, e.enrolled_at, e.updated_at, e.nurture`
+
+// SequenceStepCount is the number of enrollments in a status that have run
+// Steps steps of their sequence.
+type SequenceStepCount struct {
+	Steps  int
+	Status model.EnrollmentStatus
+	Count  int
+}
+
+// CreateSequence inserts the sequence and its steps, numbering the steps in
+// the order given.
+func (db *DB) CreateSequence(ctx context.Context, sequence *model.Sequence, steps []*model.SequenceStep) (*model.Sequence, error) {
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx, "INSERT INTO sequences (campaign_id, name, created_at, agency_id) VALUES ($1, $2, $3, $4) RETURNING id",
+		sequence.CampaignID, sequence.Name, sequence.CreatedAt, agencyID,
+	).Scan(&sequence.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating sequence: %w", err)
+	}
+
+	if err := insertSequenceSteps(ctx, tx, sequence.ID, steps); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// UpdateSequence renames the sequence and replaces its steps. It returns nil
+// when the sequence does not exist.
+func (db *DB) UpdateSequence(ctx context.Context, sequence *model.Sequence, steps []*model.SequenceStep) (*model.Sequence, error) {
+	query := `UPDATE sequences SET name = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL) 
+              RETURNING ` + sequenceColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated, err := scanSequence(tx.QueryRowContext(ctx, query, sequence.Name, sequence.UpdatedAt, sequence.ID, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error updating sequence: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM sequence_steps WHERE sequence_id = $1", updated.ID); err != nil {
+		return nil, fmt.Errorf("error deleting sequence steps: %w", err)
+	}
+
+	if err := insertSequenceSteps(ctx, tx, updated.ID, steps); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+func insertSequenceSteps(ctx context.Context, tx *sql.Tx, sequenceID string, steps []*model.SequenceStep) error {
+	query := `INSERT INTO sequence_steps (sequence_id, position, day, channel, template_id, task) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
+              RETURNING id`
+
+	for i, step := range steps {
+		step.SequenceID = sequenceID
+		step.Position = i
+		err := tx.QueryRowContext(
+			ctx, query, sequenceID, step.Position, step.Day, step.Channel, step.TemplateID, step.Task,
+		).Scan(&step.ID)
+		if err != nil {
+			return fmt.Errorf("error creating sequence step: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteSequence(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM sequences WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting sequence: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) GetSequenceByID(ctx context.Context, id string) (*model.Sequence, error) {
+	query := `SELECT ` + sequenceColumns + ` FROM sequences 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sequence, err := scanSequence(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
+func (db *DB) GetSequencesByCampaignID(ctx context.Context, campaignID string) ([]*model.Sequence, error) {
+	query := `SELECT ` + sequenceColumns + ` FROM sequences 
+              WHERE campaign_id = $1 AND (agency_id = $2 OR $2 IS NULL) 
+              ORDER BY created_at, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, campaignID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sequences: %w", err)
+	}
+	defer rows.Close()
+
+	sequences := []*model.Sequence{}
+	for rows.Next() {
+		sequence, err := scanSequence(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sequence row: %w", err)
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sequence rows: %w", err)
+	}
+
+	return sequences, nil
+}
+
+// GetSequenceSteps returns the sequence's steps in the order they run.
+func (db *DB) GetSequenceSteps(ctx context.Context, sequenceID string) ([]*model.SequenceStep, error) {
+	query := `SELECT st.id, st.sequence_id, st.position, st.day, st.channel, st.template_id, st.task 
+              FROM sequence_steps st JOIN sequences s ON s.id = st.sequence_id 
+              WHERE st.sequence_id = $1 AND (s.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY st.position`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, sequenceID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sequence steps: %w", err)
+	}
+	defer rows.Close()
+
+	steps := []*model.SequenceStep{}
+	for rows.Next() {
+		var step model.SequenceStep
+		var templateID, task sql.NullString
+
+		err := rows.Scan(&step.ID, &step.SequenceID, &step.Position, &step.Day, &step.Channel, &templateID, &task)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sequence step row: %w", err)
+		}
+
+		if templateID.Valid {
+			step.TemplateID = &templateID.String
+		}
+		if task.Valid {
+			step.Task = &task.String
+		}
+
+		steps = append(steps, &step)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sequence step rows: %w", err)
+	}
+
+	return steps, nil
+}
+
+// CreateSequenceEnrollment enrolls a lead in a sequence. It returns nil when
+// the lead is already enrolled.
+func (db *DB) CreateSequenceEnrollment(ctx context.Context, enrollment *model.SequenceEnrollment) (*model.SequenceEnrollment, error) {
+	query := `INSERT INTO sequence_enrollments (sequence_id, lead_id, status, current_step, next_run_at, enrolled_at, nurture) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7) 
+              ON CONFLICT (sequence_id, lead_id) DO NOTHING 
+              RETURNING id`
+
+	err := db.conn.QueryRowContext(
+		ctx, query, enrollment.SequenceID, enrollment.LeadID, enrollment.Status, enrollment.CurrentStep,
+		enrollment.NextRunAt, enrollment.EnrolledAt, enrollment.Nurture,
+	).Scan(&enrollment.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error creating sequence enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+// RestartSequenceEnrollment enrolls the lead as CreateSequenceEnrollment
+// does, or starts its finished enrollment in the sequence over, since a lead
+// is enrolled in a sequence at most once. It returns nil when the lead is
+// still working through the sequence.
+func (db *DB) RestartSequenceEnrollment(ctx context.Context, enrollment *model.SequenceEnrollment) (*model.SequenceEnrollment, error) {
+	query := `INSERT INTO sequence_enrollments (sequence_id, lead_id, status, current_step, next_run_at, enrolled_at, nurture) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7) 
+              ON CONFLICT (sequence_id, lead_id) DO UPDATE 
+              SET status = EXCLUDED.status, current_step = EXCLUDED.current_step, next_run_at = EXCLUDED.next_run_at, 
+                  enrolled_at = EXCLUDED.enrolled_at, nurture = EXCLUDED.nurture, updated_at = EXCLUDED.enrolled_at 
+              WHERE sequence_enrollments.status <> $8 
+              RETURNING id`
+
+	err := db.conn.QueryRowContext(
+		ctx, query, enrollment.SequenceID, enrollment.LeadID, enrollment.Status, enrollment.CurrentStep,
+		enrollment.NextRunAt, enrollment.EnrolledAt, enrollment.Nurture, model.EnrollmentStatusActive,
+	).Scan(&enrollment.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error restarting sequence enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+// HasActiveNurture reports whether the lead is LOST and working through a
+// nurture sequence.
+func (db *DB) HasActiveNurture(ctx context.Context, leadID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM sequence_enrollments e JOIN leads l ON l.id = e.lead_id 
+              WHERE e.lead_id = $1 AND e.nurture AND e.status = $2 AND l.status = $3)`
+
+	var nurtured bool
+	err := db.conn.QueryRowContext(ctx, query, leadID, model.EnrollmentStatusActive, model.LeadStatusLost).Scan(&nurtured)
+	if err != nil {
+		return false, fmt.Errorf("error checking lead nurture: %w", err)
+	}
+
+	return nurtured, nil
+}
+
+func (db *DB) GetSequenceEnrollmentByID(ctx context.Context, id string) (*model.SequenceEnrollment, error) {
+	query := `SELECT ` + enrollmentColumns + ` 
+              FROM sequence_enrollments e JOIN sequences s ON s.id = e.sequence_id 
+              WHERE e.id = $1 AND (s.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := scanEnrollment(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching sequence enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+// GetSequenceEnrollments returns the sequence's enrollments, optionally only
+// those in one status.
+func (db *DB) GetSequenceEnrollments(ctx context.Context, sequenceID string, status *model.EnrollmentStatus) ([]*model.SequenceEnrollment, error) {
+	query := `SELECT ` + enrollmentColumns + ` 
+              FROM sequence_enrollments e JOIN sequences s ON s.id = e.sequence_id 
+              WHERE e.sequence_id = $1 AND (e.status = $2 OR $2 IS NULL) AND (s.agency_id = $3 OR $3 IS NULL) 
+              ORDER BY e.enrolled_at, e.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, sequenceID, status, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sequence enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	enrollments := []*model.SequenceEnrollment{}
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sequence enrollment row: %w", err)
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sequence enrollment rows: %w", err)
+	}
+
+	return enrollments, nil
+}
+
+// ClaimDueEnrollments returns up to limit active enrollments whose next step
+// is due and leases them for enrollmentLease. It is meant for system jobs and
+// ignores tenants.
+func (db *DB) ClaimDueEnrollments(ctx context.Context, now time.Time, limit int) ([]*model.SequenceEnrollment, error) {
+	query := `WITH claimed AS ( 
+                  UPDATE sequence_enrollments SET next_run_at = $1 
+                  WHERE id IN ( 
+                      SELECT id FROM sequence_enrollments WHERE status = $2 AND next_run_at <= $3 
+                      ORDER BY next_run_at LIMIT $4 FOR UPDATE SKIP LOCKED 
+                  ) 
+                  RETURNING * 
+              ) 
+              SELECT ` + enrollmentColumns + ` FROM claimed e`
+
+	rows, err := db.conn.QueryContext(ctx, query, now.Add(enrollmentLease), model.EnrollmentStatusActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming sequence enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	enrollments := []*model.SequenceEnrollment{}
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sequence enrollment row: %w", err)
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sequence enrollment rows: %w", err)
+	}
+
+	return enrollments, nil
+}
+
+// UpdateSequenceEnrollment saves the enrollment's progress. Only active
+// enrollments change, so a reply recorded meanwhile is never overwritten;
+// it reports whether the enrollment was updated.
+func (db *DB) UpdateSequenceEnrollment(ctx context.Context, enrollment *model.SequenceEnrollment) (bool, error) {
+	query := `UPDATE sequence_enrollments SET status = $1, current_step = $2, next_run_at = $3, updated_at = $4 
+              WHERE id = $5 AND status = $6`
+
+	result, err := db.conn.ExecContext(
+		ctx, query, enrollment.Status, enrollment.CurrentStep, enrollment.NextRunAt, enrollment.UpdatedAt,
+		enrollment.ID, model.EnrollmentStatusActive,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error updating sequence enrollment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// HaltLeadEnrollments moves the lead's active enrollments to status and
+// returns how many there were.
+func (db *DB) HaltLeadEnrollments(ctx context.Context, leadID string, status model.EnrollmentStatus) (int, error) {
+	query := `UPDATE sequence_enrollments SET status = $1, next_run_at = NULL, updated_at = $2 
+              WHERE lead_id = $3 AND status = $4`
+
+	result, err := db.conn.ExecContext(ctx, query, status, time.Now(), leadID, model.EnrollmentStatusActive)
+	if err != nil {
+		return 0, fmt.Errorf("error halting sequence enrollments: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// PostponeLeadEnrollments holds the lead's active enrollments back until at
+// least until and returns how many it postponed.
+func (db *DB) PostponeLeadEnrollments(ctx context.Context, leadID string, until time.Time) (int, error) {
+	query := `UPDATE sequence_enrollments SET next_run_at = GREATEST(next_run_at, $1), updated_at = $2 
+              WHERE lead_id = $3 AND status = $4`
+
+	result, err := db.conn.ExecContext(ctx, query, until, time.Now(), leadID, model.EnrollmentStatusActive)
+	if err != nil {
+		return 0, fmt.Errorf("error postponing sequence enrollments: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// CountActiveEnrollments returns how many leads are still working through
+// the sequence.
+func (db *DB) CountActiveEnrollments(ctx context.Context, sequenceID string) (int, error) {
+	query := `SELECT COUNT(*) FROM sequence_enrollments e JOIN sequences s ON s.id = e.sequence_id 
+              WHERE e.sequence_id = $1 AND e.status = $2 AND (s.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := db.conn.QueryRowContext(ctx, query, sequenceID, model.EnrollmentStatusActive, agencyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting sequence enrollments: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetSequenceStepCounts groups the sequence's enrollments by status and the
+// number of steps they have run.
+func (db *DB) GetSequenceStepCounts(ctx context.Context, sequenceID string) ([]*SequenceStepCount, error) {
+	query := `SELECT e.current_step, e.status, COUNT(*) 
+              FROM sequence_enrollments e JOIN sequences s ON s.id = e.sequence_id 
+              WHERE e.sequence_id = $1 AND (s.agency_id = $2 OR $2 IS NULL) 
+              GROUP BY e.current_step, e.status`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, sequenceID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sequence step counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*SequenceStepCount
+	for rows.Next() {
+		var count SequenceStepCount
+		if err := rows.Scan(&count.Steps, &count.Status, &count.Count); err != nil {
+			return nil, fmt.Errorf("error scanning sequence step count row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sequence step count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// HasReplySince reports whether the lead sent any inbound message at or
+// after since. Out-of-office replies don't count.
+func (db *DB) HasReplySince(ctx context.Context, leadID string, since time.Time) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM interactions 
+              WHERE lead_id = $1 AND direction = $2 AND timestamp >= $3 AND reply_intent IS DISTINCT FROM $4)`
+
+	var replied bool
+	err := db.conn.QueryRowContext(ctx, query, leadID, model.InteractionDirectionInbound, since, model.ReplyIntentOutOfOffice).Scan(&replied)
+	if err != nil {
+		return false, fmt.Errorf("error checking lead replies: %w", err)
+	}
+
+	return replied, nil
+}
+
+// CreateFollowUpTask records a SCHEDULED interaction for a rep to carry out
+// and moves the lead's next follow-up to it.
+func (db *DB) CreateFollowUpTask(ctx context.Context, interaction *model.Interaction) (*model.Interaction, error) {
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE leads SET next_follow_up = $1 WHERE id = $2", interaction.Timestamp, interaction.Lead.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating lead next follow-up: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(interaction.Lead.ID))
+
+	return interaction, nil
+}
+
+func scanSequence(row interface{ Scan(...interface{}) error }) (*model.Sequence, error) {
+	var sequence model.Sequence
+	var updatedAt sql.NullTime
+
+	if err := row.Scan(&sequence.ID, &sequence.CampaignID, &sequence.Name, &sequence.CreatedAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	if updatedAt.Valid {
+		sequence.UpdatedAt = &updatedAt.Time
+	}
+
+	return &sequence, nil
+}
+
+func scanEnrollment(row interface{ Scan(...interface{}) error }) (*model.SequenceEnrollment, error) {
+	var enrollment model.SequenceEnrollment
+	var nextRunAt, updatedAt sql.NullTime
+
+	err := row.Scan(
+		&enrollment.ID, &enrollment.SequenceID, &enrollment.LeadID, &enrollment.Status, &enrollment.CurrentStep,
+		&nextRunAt, &enrollment.EnrolledAt, &updatedAt, &enrollment.Nurture,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if nextRunAt.Valid {
+		enrollment.NextRunAt = &nextRunAt.Time
+	}
+	if updatedAt.Valid {
+		enrollment.UpdatedAt = &updatedAt.Time
+	}
+
+	return &enrollment, nil
+}
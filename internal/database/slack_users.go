@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// SlackUser is the account a Slack user is linked to.
+type SlackUser struct {
+	UserID   string
+	Email    string
+	Role     model.UserRole
+	Status   model.UserStatus
+	AgencyID string
+}
+
+// GetSlackUser is used before any tenant is known, and is therefore not
+// scoped to an agency. It returns nil when the Slack user is not linked.
+func (db *DB) GetSlackUser(ctx context.Context, teamID, slackUserID string) (*SlackUser, error) {
+	query := `SELECT u.id, u.email, u.role, u.status, u.agency_id
+              FROM slack_users s JOIN users u ON u.id = s.user_id
+              WHERE s.team_id = $1 AND s.slack_user_id = $2`
+
+	var user SlackUser
+	err := db.conn.QueryRowContext(ctx, query, teamID, slackUserID).Scan(
+		&user.UserID, &user.Email, &user.Role, &user.Status, &user.AgencyID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching slack user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// LinkSlackUser links the Slack user to userID, replacing any earlier link.
+func (db *DB) LinkSlackUser(ctx context.Context, teamID, slackUserID, userID string) error {
+	query := `INSERT INTO slack_users (team_id, slack_user_id, user_id, created_at)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (team_id, slack_user_id) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at`
+
+	if _, err := db.conn.ExecContext(ctx, query, teamID, slackUserID, userID, time.Now()); err != nil {
+		return fmt.Errorf("error linking slack user: %w", err)
+	}
+
+	return nil
+}
+
+// UnlinkSlackUser reports whether the Slack user was linked.
+func (db *DB) UnlinkSlackUser(ctx context.Context, teamID, slackUserID string) (bool, error) {
+	query := `DELETE FROM slack_users WHERE team_id = $1 AND slack_user_id = $2`
+
+	result, err := db.conn.ExecContext(ctx, query, teamID, slackUserID)
+	if err != nil {
+		return false, fmt.Errorf("error unlinking slack user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,382 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+const slaColumns = `id, client_id, name, kind, target_minutes, working_hours, enabled_at, created_at, updated_at`
+
+const slaCheckColumns = `k.id, k.sla_id, k.lead_id, k.interaction_id, k.handoff_id, k.started_at, k.due_at, k.responded_at, k.breached_at`
+
+// clientLeadsQuery selects the leads enrolled in a campaign of client $3,
+// which are the leads generated for it.
+const clientLeadsQuery = `SELECT cl.lead_id FROM campaign_leads cl JOIN campaigns c ON c.id = cl.campaign_id WHERE c.client_id = $3`
+
+// newSLAChecksQueries select, per SLA kind, the replies or escalations of
+// SLA $1's client from $2 on that have no check yet, oldest first.
+var newSLAChecksQueries = map[model.SLAKind]string{
+	model.SLAKindInboundReply: `SELECT i.lead_id, i.id, NULL::uuid, i.timestamp 
+              FROM interactions i 
+              WHERE i.direction = 'INBOUND' AND i.timestamp >= $2 AND i.lead_id IN (` + clientLeadsQuery + `) 
+              AND NOT EXISTS (SELECT 1 FROM sla_checks k WHERE k.sla_id = $1 AND k.interaction_id = i.id) 
+              ORDER BY i.timestamp 
+              LIMIT $4`,
+	model.SLAKindEscalationPickup: `SELECT h.lead_id, NULL::uuid, h.id, h.created_at 
+              FROM lead_handoffs h 
+              WHERE h.created_at >= $2 AND h.lead_id IN (` + clientLeadsQuery + `) 
+              AND NOT EXISTS (SELECT 1 FROM sla_checks k WHERE k.sla_id = $1 AND k.handoff_id = h.id) 
+              ORDER BY h.created_at 
+              LIMIT $4`,
+}
+
+func (db *DB) GetSLAByID(ctx context.Context, id string) (*model.SLA, error) {
+	query := `SELECT ` + slaColumns + ` FROM slas 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sla, err := scanSLA(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sla, err
+}
+
+// GetSLAs lists the SLAs of one client, or of every client, by name.
+func (db *DB) GetSLAs(ctx context.Context, clientID *string) ([]*model.SLA, error) {
+	query := `SELECT ` + slaColumns + ` FROM slas 
+              WHERE (client_id = $1 OR $1 IS NULL) AND (agency_id = $2 OR $2 IS NULL) 
+              ORDER BY name, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, clientID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying SLAs: %w", err)
+	}
+	defer rows.Close()
+
+	slas := []*model.SLA{}
+	for rows.Next() {
+		sla, err := scanSLA(rows)
+		if err != nil {
+			return nil, err
+		}
+		slas = append(slas, sla)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA rows: %w", err)
+	}
+
+	return slas, nil
+}
+
+func (db *DB) CreateSLA(ctx context.Context, sla *model.SLA) (*model.SLA, error) {
+	query := `INSERT INTO slas (agency_id, client_id, name, kind, target_minutes, working_hours, enabled_at, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+              RETURNING id`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.conn.QueryRowContext(
+		ctx, query, agencyID, sla.ClientID, sla.Name, sla.Kind, sla.TargetMinutes, sla.WorkingHours,
+		sla.EnabledAt, sla.CreatedAt,
+	).Scan(&sla.ID)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating SLA: %w", err)
+	}
+
+	return sla, nil
+}
+
+// UpdateSLA saves the SLA's settings other than its client and kind.
+// Enabling a disabled SLA sets enabled_at, so replies and escalations from
+// while it was off are not checked. It returns nil when the SLA does not
+// exist.
+func (db *DB) UpdateSLA(ctx context.Context, sla *model.SLA) (*model.SLA, error) {
+	query := `UPDATE slas SET name = $1, target_minutes = $2, working_hours = $3, 
+              enabled_at = CASE WHEN $4 THEN COALESCE(enabled_at, $5) END, updated_at = $5 
+              WHERE id = $6 AND (agency_id = $7 OR $7 IS NULL) 
+              RETURNING ` + slaColumns
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanSLA(db.conn.QueryRowContext(
+		ctx, query, sla.Name, sla.TargetMinutes, sla.WorkingHours, sla.Enabled, sla.UpdatedAt,
+		sla.ID, agencyID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return updated, err
+}
+
+// DeleteSLA deletes the SLA with its checks.
+func (db *DB) DeleteSLA(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM slas WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting SLA: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetNewSLAChecks returns up to limit of the enabled SLA's replies or
+// escalations that have no check yet, without their due time.
+func (db *DB) GetNewSLAChecks(ctx context.Context, sla *model.SLA, limit int) ([]*model.SLACheck, error) {
+	query, ok := newSLAChecksQueries[sla.Kind]
+	if !ok || sla.EnabledAt == nil {
+		return nil, nil
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, sla.ID, *sla.EnabledAt, sla.ClientID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying new SLA checks: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []*model.SLACheck
+	for rows.Next() {
+		check := model.SLACheck{SLAID: sla.ID}
+		var interactionID, handoffID sql.NullString
+		if err := rows.Scan(&check.LeadID, &interactionID, &handoffID, &check.StartedAt); err != nil {
+			return nil, fmt.Errorf("error scanning new SLA check row: %w", err)
+		}
+		check.InteractionID = nullString(interactionID)
+		check.HandoffID = nullString(handoffID)
+		checks = append(checks, &check)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating new SLA check rows: %w", err)
+	}
+
+	return checks, nil
+}
+
+// CreateSLAChecks stores checks, skipping those another replica stored
+// first.
+func (db *DB) CreateSLAChecks(ctx context.Context, checks []*model.SLACheck) error {
+	query := `INSERT INTO sla_checks (sla_id, lead_id, interaction_id, handoff_id, started_at, due_at) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
+              ON CONFLICT DO NOTHING`
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, check := range checks {
+		_, err := tx.ExecContext(ctx, query, check.SLAID, check.LeadID, check.InteractionID, check.HandoffID, check.StartedAt, check.DueAt)
+		if err != nil {
+			return fmt.Errorf("error creating SLA check: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveSLAChecks records when unanswered checks were answered: a reply by
+// the first message sent to the lead after it, an escalation by its pickup.
+// It returns how many checks were answered.
+func (db *DB) ResolveSLAChecks(ctx context.Context) (int, error) {
+	replies := `UPDATE sla_checks k SET responded_at = r.at 
+              FROM ( 
+                  SELECT o.id, MIN(i.timestamp) AS at 
+                  FROM sla_checks o JOIN interactions i ON i.lead_id = o.lead_id AND i.timestamp > o.started_at 
+                  WHERE o.responded_at IS NULL AND o.interaction_id IS NOT NULL AND ` + sentMessageFilter + ` 
+                  GROUP BY o.id 
+              ) r 
+              WHERE k.id = r.id`
+	escalations := `UPDATE sla_checks k SET responded_at = h.picked_up_at 
+              FROM lead_handoffs h 
+              WHERE h.id = k.handoff_id AND k.responded_at IS NULL AND h.picked_up_at IS NOT NULL`
+
+	resolved := 0
+	for _, query := range []string{replies, escalations} {
+		result, err := db.conn.ExecContext(ctx, query)
+		if err != nil {
+			return resolved, fmt.Errorf("error resolving SLA checks: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return resolved, fmt.Errorf("error getting rows affected: %w", err)
+		}
+		resolved += int(rowsAffected)
+	}
+
+	return resolved, nil
+}
+
+// FlagSLABreaches marks the checks answered after they were due, or still
+// unanswered at now past due, as breached at now, and returns them.
+func (db *DB) FlagSLABreaches(ctx context.Context, now time.Time) ([]*model.SLACheck, error) {
+	query := `UPDATE sla_checks k SET breached_at = $1 
+              WHERE k.breached_at IS NULL AND (k.responded_at > k.due_at OR (k.responded_at IS NULL AND k.due_at <= $1)) 
+              RETURNING ` + slaCheckColumns
+
+	return db.listSLAChecks(ctx, query, now)
+}
+
+// GetSLACompliance counts, for each SLA of the client, the checks started in
+// [from, to) that were met or breached. Checks neither answered nor past due,
+// or not evaluated yet, are in neither count.
+func (db *DB) GetSLACompliance(ctx context.Context, clientID string, from, to time.Time) ([]*model.SLACompliance, error) {
+	query := `SELECT s.id, COUNT(k.id), 
+              COUNT(k.id) FILTER (WHERE k.responded_at <= k.due_at), 
+              COUNT(k.id) FILTER (WHERE k.breached_at IS NOT NULL) 
+              FROM slas s 
+              LEFT JOIN sla_checks k ON k.sla_id = s.id AND k.started_at >= $2 AND k.started_at < $3 
+              WHERE s.client_id = $1 AND (s.agency_id = $4 OR $4 IS NULL) 
+              GROUP BY s.id, s.name 
+              ORDER BY s.name, s.id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.queryAnalytics(ctx, query, clientID, from, to, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying SLA compliance: %w", err)
+	}
+	defer rows.Close()
+
+	compliance := []*model.SLACompliance{}
+	for rows.Next() {
+		var c model.SLACompliance
+		if err := rows.Scan(&c.SLAID, &c.Checks, &c.Met, &c.Breached); err != nil {
+			return nil, fmt.Errorf("error scanning SLA compliance row: %w", err)
+		}
+		compliance = append(compliance, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA compliance rows: %w", err)
+	}
+
+	return compliance, nil
+}
+
+// GetWorstSLABreaches returns up to limit of the breached checks of the
+// client's SLAs started in [from, to), those answered latest after they were
+// due first. Unanswered checks count as answered at now.
+func (db *DB) GetWorstSLABreaches(ctx context.Context, clientID string, from, to, now time.Time, limit int) ([]*model.SLACheck, error) {
+	query := `SELECT ` + slaCheckColumns + ` 
+              FROM sla_checks k JOIN slas s ON s.id = k.sla_id 
+              WHERE s.client_id = $1 AND (s.agency_id = $2 OR $2 IS NULL) 
+              AND k.started_at >= $3 AND k.started_at < $4 AND k.breached_at IS NOT NULL 
+              ORDER BY COALESCE(k.responded_at, $5) - k.due_at DESC, k.id 
+              LIMIT $6`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.listSLAChecks(ctx, query, clientID, agencyID, from, to, now, limit)
+}
+
+func (db *DB) listSLAChecks(ctx context.Context, query string, args ...interface{}) ([]*model.SLACheck, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying SLA checks: %w", err)
+	}
+	defer rows.Close()
+
+	checks := []*model.SLACheck{}
+	for rows.Next() {
+		var check model.SLACheck
+		var interactionID, handoffID sql.NullString
+		var respondedAt, breachedAt sql.NullTime
+
+		err := rows.Scan(
+			&check.ID, &check.SLAID, &check.LeadID, &interactionID, &handoffID,
+			&check.StartedAt, &check.DueAt, &respondedAt, &breachedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning SLA check row: %w", err)
+		}
+
+		check.InteractionID = nullString(interactionID)
+		check.HandoffID = nullString(handoffID)
+		if respondedAt.Valid {
+			check.RespondedAt = &respondedAt.Time
+		}
+		if breachedAt.Valid {
+			check.BreachedAt = &breachedAt.Time
+		}
+		checks = append(checks, &check)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating SLA check rows: %w", err)
+	}
+
+	return checks, nil
+}
+
+func scanSLA(row interface{ Scan(...interface{}) error }) (*model.SLA, error) {
+	var sla model.SLA
+	var workingHours sql.NullString
+	var enabledAt, updatedAt sql.NullTime
+
+	err := row.Scan(
+		&sla.ID, &sla.ClientID, &sla.Name, &sla.Kind, &sla.TargetMinutes, &workingHours,
+		&enabledAt, &sla.CreatedAt, &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning SLA row: %w", err)
+	}
+
+	sla.WorkingHours = nullString(workingHours)
+	if enabledAt.Valid {
+		sla.Enabled = true
+		sla.EnabledAt = &enabledAt.Time
+	}
+	if updatedAt.Valid {
+		sla.UpdatedAt = &updatedAt.Time
+	}
+
+	return &sla, nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"salesagency/internal/logging"
+)
+
+// slowQueryConnector wraps a driver connector so that queries taking longer
+// than threshold are logged with the caller's request context.
+type slowQueryConnector struct {
+	driver.Connector
+	threshold time.Duration
+}
+
+func (c slowQueryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryConn{Conn: conn, threshold: c.threshold}, nil
+}
+
+type slowQueryConn struct {
+	driver.Conn
+	threshold time.Duration
+}
+
+func (c *slowQueryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.observe(ctx, query, start, err)
+	return rows, err
+}
+
+func (c *slowQueryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.observe(ctx, query, start, err)
+	return result, err
+}
+
+func (c *slowQueryConn) observe(ctx context.Context, query string, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	if elapsed := time.Since(start); elapsed >= c.threshold {
+		logging.FromContext(ctx).Warn("slow database query", "duration_ms", elapsed.Milliseconds(), "query", query)
+	}
+}
+
+func (c *slowQueryConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *slowQueryConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *slowQueryConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *slowQueryConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *slowQueryConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
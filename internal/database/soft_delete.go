@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"salesagency/graph/model"
+)
+
+type includeDeletedKey struct{}
+
+// WithDeleted returns a context in which lead and client lookups also return
+// soft-deleted rows.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+func includeDeleted(ctx context.Context) bool {
+	included, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return included
+}
+
+// notDeleted returns the condition that hides soft-deleted rows, or nothing
+// when the context asks for them.
+func notDeleted(ctx context.Context, column string) string {
+	if includeDeleted(ctx) {
+		return ""
+	}
+	return " AND " + column + " IS NULL"
+}
+
+func (db *DB) RestoreLead(ctx context.Context, id string) (*model.Lead, error) {
+	query := `UPDATE leads SET deleted_at = NULL 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NOT NULL AND forgotten_at IS NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateLead
+		}
+		return nil, fmt.Errorf("error restoring lead: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return db.GetLeadByID(ctx, id)
+}
+
+// PurgeLead permanently removes a lead along with its interactions and
+// history, whether or not it was soft-deleted first.
+func (db *DB) PurgeLead(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM leads WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error purging lead: %w", err)
+	}
+
+	db.invalidate(ctx, leadCacheKey(id))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (db *DB) RestoreClient(ctx context.Context, id string) (*model.Client, error) {
+	query := `UPDATE clients SET deleted_at = NULL 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) AND deleted_at IS NOT NULL`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring client: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return db.GetClientByID(ctx, id)
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// RecordSyncError opens an error of kind for the lead, or for the agency when
+// leadID is nil. An error of the same kind that is already open is updated
+// with the new message instead of being duplicated.
+func (db *DB) RecordSyncError(ctx context.Context, agencyID string, leadID *string, provider string, kind model.SyncErrorKind, message string) error {
+	query := `INSERT INTO sync_errors (agency_id, lead_id, provider, kind, message) 
+              SELECT $1::uuid, $2::uuid, $3, $4, $5 WHERE $1::uuid = $6 OR $6 IS NULL 
+              ON CONFLICT (agency_id, provider, kind, (COALESCE(lead_id::text, ''))) WHERE resolved_at IS NULL 
+              DO UPDATE SET message = EXCLUDED.message, detected_at = now()`
+
+	tenantID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, agencyID, leadID, provider, kind, message, tenantID); err != nil {
+		return fmt.Errorf("error recording sync error: %w", err)
+	}
+	return nil
+}
+
+// ResolveSyncErrors closes the open errors of the given kinds for the lead,
+// or for the agency when leadID is nil.
+func (db *DB) ResolveSyncErrors(ctx context.Context, agencyID string, leadID *string, provider string, kinds ...model.SyncErrorKind) error {
+	query := `UPDATE sync_errors SET resolved_at = now() 
+              WHERE agency_id = $1 AND lead_id IS NOT DISTINCT FROM $2 AND provider = $3 AND kind = ANY($4) 
+              AND resolved_at IS NULL AND (agency_id = $5 OR $5 IS NULL)`
+
+	tenantID, err := tenantArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = string(kind)
+	}
+
+	if _, err := db.conn.ExecContext(ctx, query, agencyID, leadID, provider, pq.Array(names), tenantID); err != nil {
+		return fmt.Errorf("error resolving sync errors: %w", err)
+	}
+	return nil
+}
+
+// ResolveSyncError closes a single error by hand.
+func (db *DB) ResolveSyncError(ctx context.Context, id string) (*model.SyncError, error) {
+	query := `UPDATE sync_errors SET resolved_at = COALESCE(resolved_at, now()) 
+              WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL) 
+              RETURNING id, lead_id, provider, kind, message, detected_at, resolved_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	syncError, err := scanSyncError(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error resolving sync error: %w", err)
+	}
+	return syncError, nil
+}
+
+// GetSyncErrors lists the agency's sync errors, newest first. resolved
+// filters by state when set.
+func (db *DB) GetSyncErrors(ctx context.Context, resolved *bool, limit, offset *int) ([]*model.SyncError, error) {
+	query := `SELECT id, lead_id, provider, kind, message, detected_at, resolved_at 
+              FROM sync_errors WHERE (agency_id = $1 OR $1 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{agencyID}
+	argCount := 2
+
+	if resolved != nil {
+		if *resolved {
+			query += " AND resolved_at IS NOT NULL"
+		} else {
+			query += " AND resolved_at IS NULL"
+		}
+	}
+
+	query += " ORDER BY detected_at DESC, id DESC"
+	if limit != nil {
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, *limit)
+		argCount++
+	}
+
+	if offset != nil {
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, *offset)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sync errors: %w", err)
+	}
+	defer rows.Close()
+
+	syncErrors := []*model.SyncError{}
+	for rows.Next() {
+		syncError, err := scanSyncError(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sync error row: %w", err)
+		}
+		syncErrors = append(syncErrors, syncError)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sync error rows: %w", err)
+	}
+
+	return syncErrors, nil
+}
+
+func scanSyncError(row interface{ Scan(...interface{}) error }) (*model.SyncError, error) {
+	var syncError model.SyncError
+	var leadID sql.NullString
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(&syncError.ID, &leadID, &syncError.Provider, &syncError.Kind, &syncError.Message, &syncError.DetectedAt, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if leadID.Valid {
+		syncError.LeadID = &leadID.String
+	}
+	if resolvedAt.Valid {
+		syncError.ResolvedAt = &resolvedAt.Time
+	}
+	return &syncError, nil
+}
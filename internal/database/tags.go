@@ -0,0 +1,423 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateTag = errors.New("a tag with this name already exists")
+
+// tagColumns counts the live leads carrying each tag from leads_tags_idx.
+const tagColumns = `t.id, t.name, t.color, 
+              (SELECT count(*) FROM leads l WHERE l.agency_id = t.agency_id AND l.tags @> ARRAY[t.name] AND l.deleted_at IS NULL), 
+              t.created_at, t.updated_at`
+
+func scanTag(row interface{ Scan(...interface{}) error }) (*model.Tag, error) {
+	var tag model.Tag
+	var color sql.NullString
+	var updatedAt sql.NullTime
+	if err := row.Scan(&tag.ID, &tag.Name, &color, &tag.UsageCount, &tag.CreatedAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	tag.Color = nullString(color)
+	if updatedAt.Valid {
+		tag.UpdatedAt = &updatedAt.Time
+	}
+	return &tag, nil
+}
+
+func (db *DB) GetTagByID(ctx context.Context, id string) (*model.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags t 
+              WHERE t.id = $1 AND (t.agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := scanTag(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// GetTags returns the tags whose names start with prefix, ignoring case,
+// the most used first. prefix is matched literally: % and _ are not
+// wildcards.
+func (db *DB) GetTags(ctx context.Context, prefix string, limit int) ([]*model.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags t 
+              WHERE (t.agency_id = $1 OR $1 IS NULL) AND lower(t.name) LIKE $2 || '%' 
+              ORDER BY 4 DESC, lower(t.name), t.id 
+              LIMIT $3`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(prefix))
+	rows, err := db.conn.QueryContext(ctx, query, agencyID, escaped, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag rows: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (db *DB) CreateTag(ctx context.Context, name string, color *string) (*model.Tag, error) {
+	query := `INSERT INTO tags (agency_id, name, color, created_at) 
+              VALUES ($1, $2, $3, $4) 
+              RETURNING id, created_at`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &model.Tag{Name: name, Color: color}
+	err = db.conn.QueryRowContext(ctx, query, agencyID, name, color, time.Now()).Scan(&tag.ID, &tag.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateTag
+		}
+		return nil, fmt.Errorf("error creating tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// SetTagColor sets or, when color is nil, clears the tag's color.
+func (db *DB) SetTagColor(ctx context.Context, id string, color *string) (*model.Tag, error) {
+	query := `UPDATE tags SET color = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, color, time.Now(), id, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error setting tag color: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("error getting rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	return db.GetTagByID(ctx, id)
+}
+
+// RenameTag renames the tag and respells it on every lead that carries it.
+// Only the case of a name can change to one the agency already has; to
+// fold one tag into another, use MergeTags.
+func (db *DB) RenameTag(ctx context.Context, id, name string) (*model.Tag, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	locked, err := lockTags(ctx, tx, agencyID, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	tag, ok := locked[id]
+	if !ok {
+		return nil, nil
+	}
+	if tag.name == name {
+		return db.GetTagByID(ctx, id)
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, "UPDATE tags SET name = $1, updated_at = $2 WHERE id = $3", name, now, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateTag
+		}
+		return nil, fmt.Errorf("error renaming tag: %w", err)
+	}
+
+	changed, err := replaceLeadTags(ctx, tx, tag.agencyID, []string{tag.name}, name, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidateLeads(ctx, changed)
+	return db.GetTagByID(ctx, id)
+}
+
+// MergeTags replaces the source tags with the target on every lead that
+// carries them, then deletes the sources. Sources that don't exist are
+// ignored; it returns nil when the target doesn't.
+func (db *DB) MergeTags(ctx context.Context, sourceIDs []string, targetID string) (*model.Tag, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	locked, err := lockTags(ctx, tx, agencyID, append([]string{targetID}, sourceIDs...))
+	if err != nil {
+		return nil, err
+	}
+	target, ok := locked[targetID]
+	if !ok {
+		return nil, nil
+	}
+
+	var names, ids []string
+	for id, tag := range locked {
+		// Tags of another agency can only be seen without a tenant.
+		if id != targetID && tag.agencyID == target.agencyID {
+			names = append(names, tag.name)
+			ids = append(ids, id)
+		}
+	}
+
+	now := time.Now()
+	changed, err := replaceLeadTags(ctx, tx, target.agencyID, names, target.name, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM tags WHERE id = ANY($1::uuid[])", pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("error deleting merged tags: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidateLeads(ctx, changed)
+	return db.GetTagByID(ctx, targetID)
+}
+
+// DeleteTag deletes the tag and removes it from every lead that carries it.
+func (db *DB) DeleteTag(ctx context.Context, id string) (bool, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	locked, err := lockTags(ctx, tx, agencyID, []string{id})
+	if err != nil {
+		return false, err
+	}
+	tag, ok := locked[id]
+	if !ok {
+		return false, nil
+	}
+
+	changed, err := replaceLeadTags(ctx, tx, tag.agencyID, []string{tag.name}, "", time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM tags WHERE id = $1", id); err != nil {
+		return false, fmt.Errorf("error deleting tag: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	db.invalidateLeads(ctx, changed)
+	return true, nil
+}
+
+type lockedTag struct {
+	agencyID string
+	name     string
+}
+
+// lockTags locks the tags with the ids until tx ends and returns them by ID.
+func lockTags(ctx context.Context, tx *sql.Tx, agencyID interface{}, ids []string) (map[string]lockedTag, error) {
+	query := `SELECT id, agency_id, name FROM tags 
+              WHERE id = ANY($1::uuid[]) AND (agency_id = $2 OR $2 IS NULL) 
+              FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(validIDs(ids)), agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error locking tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string]lockedTag, len(ids))
+	for rows.Next() {
+		var id string
+		var tag lockedTag
+		if err := rows.Scan(&id, &tag.agencyID, &tag.name); err != nil {
+			return nil, fmt.Errorf("error scanning tag row: %w", err)
+		}
+		tags[id] = tag
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag rows: %w", err)
+	}
+
+	return tags, nil
+}
+
+// replaceLeadTags replaces the names with to, or removes them when to is
+// empty, on the agency's leads and returns the IDs of those it changed.
+// Deleted leads are changed too, so that they are restored with tags the
+// agency still has, but only changes to live leads are recorded as events.
+func replaceLeadTags(ctx context.Context, tx *sql.Tx, agencyID string, names []string, to string, at time.Time) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	// Tags keep the order they were first added in.
+	query := `UPDATE leads SET tags = ARRAY( 
+                SELECT CASE WHEN u.tag = ANY($1::text[]) THEN $2 ELSE u.tag END 
+                FROM unnest(tags) WITH ORDINALITY AS u(tag, n) 
+                WHERE $2 <> '' OR u.tag <> ALL($1::text[]) 
+                GROUP BY 1 ORDER BY min(u.n)), 
+              updated_at = $3, version = version + 1 
+              WHERE agency_id = $4 AND tags && $1::text[] 
+              RETURNING id`
+
+	before, err := lockLeads(ctx, tx, "agency_id = $1 AND tags && $2::text[]", agencyID, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := queryIDs(ctx, tx, query, pq.Array(names), to, at, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating lead tags: %w", err)
+	}
+
+	if err := recordLeadChanges(ctx, tx, before, changed, at); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// resolveTags returns the tags as the agency spells them, trimmed and
+// without duplicates, adding those it doesn't have yet to its taxonomy. It
+// returns nil for nil tags.
+func resolveTags(ctx context.Context, tx *sql.Tx, agencyID string, tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	names, keys := normalizeTags(tags)
+	if len(names) == 0 {
+		return names, nil
+	}
+
+	query := `INSERT INTO tags (agency_id, name) 
+              SELECT $1, unnest($2::text[]) 
+              ON CONFLICT (agency_id, lower(name)) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, agencyID, pq.Array(names)); err != nil {
+		return nil, fmt.Errorf("error adding tags: %w", err)
+	}
+
+	query = "SELECT name FROM tags WHERE agency_id = $1 AND lower(name) = ANY($2::text[])"
+	rows, err := tx.QueryContext(ctx, query, agencyID, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("error querying tags: %w", err)
+	}
+	defer rows.Close()
+
+	canonical := make(map[string]string, len(keys))
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("error scanning tag row: %w", err)
+		}
+		canonical[strings.ToLower(name)] = name
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag rows: %w", err)
+	}
+
+	for i, key := range keys {
+		if name, ok := canonical[key]; ok {
+			names[i] = name
+		}
+	}
+	return names, nil
+}
+
+// normalizeTags trims the tags and drops empty ones and those that differ
+// from an earlier one only by case. It also returns them in lower case, the
+// form they are compared in.
+func normalizeTags(tags []string) (names, keys []string) {
+	names = make([]string, 0, len(tags))
+	keys = make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, tag)
+		keys = append(keys, key)
+	}
+	return names, keys
+}
+
+// leadAgencyID returns the agency of the lead, for writes made without a
+// tenant.
+func leadAgencyID(ctx context.Context, tx *sql.Tx, leadID string) (string, error) {
+	var agencyID string
+	if err := tx.QueryRowContext(ctx, "SELECT agency_id FROM leads WHERE id = $1", leadID).Scan(&agencyID); err != nil {
+		return "", fmt.Errorf("error fetching lead agency: %w", err)
+	}
+	return agencyID, nil
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/templates"
+
+	"github.com/lib/pq"
+)
+
+// GetTemplateTranslations returns the template's translations ordered by
+// locale.
+func (db *DB) GetTemplateTranslations(ctx context.Context, templateID string) ([]*model.TemplateTranslation, error) {
+	query := `SELECT t.template_id, t.locale, t.content, t.created_at, t.updated_at 
+              FROM message_template_translations t JOIN message_templates m ON m.id = t.template_id 
+              WHERE t.template_id = $1 AND (m.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY t.locale`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, templateID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying template translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := []*model.TemplateTranslation{}
+	for rows.Next() {
+		var translation model.TemplateTranslation
+		var updatedAt sql.NullTime
+		err := rows.Scan(&translation.TemplateID, &translation.Locale, &translation.Content, &translation.CreatedAt, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning template translation row: %w", err)
+		}
+		if updatedAt.Valid {
+			translation.UpdatedAt = &updatedAt.Time
+		}
+		translations = append(translations, &translation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating template translation rows: %w", err)
+	}
+	return translations, nil
+}
+
+// GetTemplateTranslationContent returns the template's content in the first
+// of locales it has a translation for, or nil when it has none of them.
+func (db *DB) GetTemplateTranslationContent(ctx context.Context, templateID string, locales []string) (*string, error) {
+	if len(locales) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT t.content 
+              FROM message_template_translations t JOIN message_templates m ON m.id = t.template_id 
+              WHERE t.template_id = $1 AND t.locale = ANY($2::text[]) AND (m.agency_id = $3 OR $3 IS NULL) 
+              ORDER BY array_position($2::text[], t.locale) 
+              LIMIT 1`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var content string
+	err = db.conn.QueryRowContext(ctx, query, templateID, pq.Array(locales), agencyID).Scan(&content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching template translation: %w", err)
+	}
+	return &content, nil
+}
+
+// GetLocalizedTemplateContent returns the template's content to send in
+// language: its translation for the language, or else for the default
+// language of the template's campaign, or else its own content.
+func (db *DB) GetLocalizedTemplateContent(ctx context.Context, template *model.MessageTemplate, language *string) (string, error) {
+	var campaignLanguage *string
+	if template.Campaign != nil {
+		var err error
+		campaignLanguage, err = db.GetCampaignDefaultLanguage(ctx, template.Campaign.ID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	content, err := db.GetTemplateTranslationContent(ctx, template.ID, templates.Locales(language, campaignLanguage))
+	if err != nil {
+		return "", err
+	}
+	if content == nil {
+		return template.Content, nil
+	}
+	return *content, nil
+}
+
+// SetTemplateTranslation adds the template's translation for its locale,
+// replacing any it already has. It returns nil when the template does not
+// exist.
+func (db *DB) SetTemplateTranslation(ctx context.Context, translation *model.TemplateTranslation) (*model.TemplateTranslation, error) {
+	query := `INSERT INTO message_template_translations (template_id, locale, content, created_at) 
+              SELECT id, $2, $3, $4 FROM message_templates WHERE id = $1 AND (agency_id = $5 OR $5 IS NULL) 
+              ON CONFLICT (template_id, locale) DO UPDATE SET content = EXCLUDED.content, updated_at = EXCLUDED.created_at 
+              RETURNING created_at, updated_at`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedAt sql.NullTime
+	err = db.conn.QueryRowContext(
+		ctx, query, translation.TemplateID, translation.Locale, translation.Content, translation.CreatedAt, agencyID,
+	).Scan(&translation.CreatedAt, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error saving template translation: %w", err)
+	}
+	if updatedAt.Valid {
+		translation.UpdatedAt = &updatedAt.Time
+	}
+
+	return translation, nil
+}
+
+// DeleteTemplateTranslation reports whether the template had a translation
+// for locale.
+func (db *DB) DeleteTemplateTranslation(ctx context.Context, templateID, locale string) (bool, error) {
+	query := `DELETE FROM message_template_translations t USING message_templates m 
+              WHERE m.id = t.template_id AND t.template_id = $1 AND t.locale = $2 
+              AND (m.agency_id = $3 OR $3 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, templateID, locale, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting template translation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetCampaignDefaultLanguage returns the locale the campaign's templates
+// fall back to, or nil when it has none.
+func (db *DB) GetCampaignDefaultLanguage(ctx context.Context, campaignID string) (*string, error) {
+	query := "SELECT default_language FROM campaigns WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var language sql.NullString
+	if err := db.conn.QueryRowContext(ctx, query, campaignID, agencyID).Scan(&language); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching campaign default language: %w", err)
+	}
+	return nullString(language), nil
+}
+
+// SetCampaignDefaultLanguage replaces the campaign's default language; nil
+// removes it. It reports whether the campaign exists.
+func (db *DB) SetCampaignDefaultLanguage(ctx context.Context, campaignID string, language *string) (bool, error) {
+	query := `UPDATE campaigns SET default_language = $1, updated_at = $2 
+              WHERE id = $3 AND (agency_id = $4 OR $4 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, language, time.Now(), campaignID, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error updating campaign default language: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
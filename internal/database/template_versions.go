@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+const templateVersionColumns = `v.id, v.template_id, v.version, v.name, v.content, v.variables, v.channel, v.purpose, v.created_by, v.created_at`
+
+// insertTemplateVersion saves the template's current state as the version
+// it is at.
+func insertTemplateVersion(ctx context.Context, tx *sql.Tx, templateID string, createdBy *string) error {
+	query := `INSERT INTO message_template_versions (template_id, version, name, content, variables, channel, purpose, 
+              created_by, created_at) 
+              SELECT id, version, name, content, variables, channel, purpose, $2, COALESCE(updated_at, created_at) 
+              FROM message_templates WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, templateID, createdBy); err != nil {
+		return fmt.Errorf("error saving message template version: %w", err)
+	}
+	return nil
+}
+
+// GetTemplateVersions returns every version of the template, newest first.
+func (db *DB) GetTemplateVersions(ctx context.Context, templateID string) ([]*model.TemplateVersion, error) {
+	query := `SELECT ` + templateVersionColumns + ` 
+              FROM message_template_versions v JOIN message_templates t ON t.id = v.template_id 
+              WHERE v.template_id = $1 AND (t.agency_id = $2 OR $2 IS NULL) 
+              ORDER BY v.version DESC`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, templateID, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying message template versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*model.TemplateVersion{}
+	for rows.Next() {
+		version, err := scanTemplateVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning message template version row: %w", err)
+		}
+		versions = append(versions, version)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message template version rows: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetTemplateVersion returns nil when the template has no such version.
+func (db *DB) GetTemplateVersion(ctx context.Context, templateID string, version int) (*model.TemplateVersion, error) {
+	query := `SELECT ` + templateVersionColumns + ` 
+              FROM message_template_versions v JOIN message_templates t ON t.id = v.template_id 
+              WHERE v.template_id = $1 AND v.version = $3 AND (t.agency_id = $2 OR $2 IS NULL)`
+
+	return db.getTemplateVersion(ctx, query, templateID, version)
+}
+
+// GetInteractionTemplateVersion returns the version its template was at
+// when the interaction was recorded.
+func (db *DB) GetInteractionTemplateVersion(ctx context.Context, interactionID string) (*model.TemplateVersion, error) {
+	query := `SELECT ` + templateVersionColumns + ` 
+              FROM interactions i JOIN message_template_versions v ON v.id = i.template_version_id 
+              JOIN message_templates t ON t.id = v.template_id 
+              WHERE i.id = $1 AND (t.agency_id = $2 OR $2 IS NULL)`
+
+	return db.getTemplateVersion(ctx, query, interactionID)
+}
+
+func (db *DB) getTemplateVersion(ctx context.Context, query, id string, args ...interface{}) (*model.TemplateVersion, error) {
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := scanTemplateVersion(db.conn.QueryRowContext(ctx, query, append([]interface{}{id, agencyID}, args...)...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching message template version: %w", err)
+	}
+
+	return version, nil
+}
+
+func scanTemplateVersion(row interface{ Scan(...interface{}) error }) (*model.TemplateVersion, error) {
+	var version model.TemplateVersion
+	var createdBy sql.NullString
+
+	err := row.Scan(
+		&version.ID, &version.TemplateID, &version.Version, &version.Name, &version.Content,
+		pq.Array(&version.Variables), &version.Channel, &version.Purpose, &createdBy, &version.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	version.CreatedByID = nullString(createdBy)
+	if version.Variables == nil {
+		version.Variables = []string{}
+	}
+
+	return &version, nil
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"salesagency/graph/model"
+
+	"github.com/lib/pq"
+)
+
+func (db *DB) GetMessageTemplateByID(ctx context.Context, id string) (*model.MessageTemplate, error) {
+	query := `SELECT id, name, content, variables, channel, purpose, ai_agent_id, 
+              campaign_id, version, created_at, updated_at 
+              FROM message_templates WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var template model.MessageTemplate
+	var variables []sql.NullString
+	var aiAgentID, campaignID sql.NullString
+	var updatedAt sql.NullTime
+
+	err = db.conn.QueryRowContext(ctx, query, id, agencyID).Scan(
+		&template.ID, &template.Name, &template.Content, &variables, &template.Channel,
+		&template.Purpose, &aiAgentID, &campaignID, &template.Version, &template.CreatedAt, &updatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching message template: %w", err)
+	}
+
+	if aiAgentID.Valid {
+		template.AIAgent = &model.AIAgent{ID: aiAgentID.String}
+	}
+	if campaignID.Valid {
+		template.Campaign = &model.Campaign{ID: campaignID.String}
+	}
+	if updatedAt.Valid {
+		template.UpdatedAt = &updatedAt.Time
+	}
+
+	template.Variables = make([]string, 0, len(variables))
+	for _, variable := range variables {
+		if variable.Valid {
+			template.Variables = append(template.Variables, variable.String)
+		}
+	}
+
+	return &template, nil
+}
+
+// CreateMessageTemplate saves template with its first version, created by
+// createdBy.
+func (db *DB) CreateMessageTemplate(ctx context.Context, template *model.MessageTemplate, createdBy *string) (*model.MessageTemplate, error) {
+	query := `INSERT INTO message_templates (name, content, variables, channel, purpose, 
+              ai_agent_id, campaign_id, created_at, agency_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+              RETURNING id, version`
+
+	agencyID, err := tenantIDForInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx, query, template.Name, template.Content, pq.Array(template.Variables), template.Channel,
+		template.Purpose, templateAIAgentID(template), templateCampaignID(template), template.CreatedAt, agencyID,
+	).Scan(&template.ID, &template.Version)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating message template: %w", err)
+	}
+
+	if err := insertTemplateVersion(ctx, tx, template.ID, createdBy); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return template, nil
+}
+
+// UpdateMessageTemplate saves template as its next version, edited by
+// editedBy. It returns nil when the template does not exist.
+func (db *DB) UpdateMessageTemplate(ctx context.Context, template *model.MessageTemplate, editedBy *string) (*model.MessageTemplate, error) {
+	query := `UPDATE message_templates SET 
+              name = $1, content = $2, variables = $3, channel = $4, purpose = $5, 
+              ai_agent_id = $6, campaign_id = $7, updated_at = $8, version = version + 1 
+              WHERE id = $9 AND (agency_id = $10 OR $10 IS NULL) 
+              RETURNING version`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The row lock taken by the update serializes version numbers.
+	err = tx.QueryRowContext(
+		ctx, query, template.Name, template.Content, pq.Array(template.Variables), template.Channel,
+		template.Purpose, templateAIAgentID(template), templateCampaignID(template), template.UpdatedAt,
+		template.ID, agencyID,
+	).Scan(&template.Version)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error updating message template: %w", err)
+	}
+
+	if err := insertTemplateVersion(ctx, tx, template.ID, editedBy); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return template, nil
+}
+
+func (db *DB) DeleteMessageTemplate(ctx context.Context, id string) (bool, error) {
+	query := "DELETE FROM message_templates WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)"
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, query, id, agencyID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting message template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func templateAIAgentID(template *model.MessageTemplate) *string {
+	if template.AIAgent == nil {
+		return nil
+	}
+	return &template.AIAgent.ID
+}
+
+func templateCampaignID(template *model.MessageTemplate) *string {
+	if template.Campaign == nil {
+		return nil
+	}
+	return &template.Campaign.ID
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/internal/tenant"
+)
+
+var errTenantRequired = errors.New("creating records requires an agency context")
+
+// tenantArg returns the query argument used by tenant predicates of the form
+// `(agency_id = $n OR $n IS NULL)`. It is nil for system contexts so the
+// predicate matches every agency.
+func tenantArg(ctx context.Context) (interface{}, error) {
+	agencyID, err := tenant.AgencyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if agencyID == "" {
+		return nil, nil
+	}
+	return agencyID, nil
+}
+
+// tenantIDForInsert returns the agency new rows must belong to.
+func tenantIDForInsert(ctx context.Context) (string, error) {
+	agencyID, err := tenant.AgencyID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if agencyID == "" {
+		return "", errTenantRequired
+	}
+	return agencyID, nil
+}
+
+// inClients limits rows to those whose column holds one of the clients ctx
+// is limited to. It matches every row when ctx is not limited to clients.
+func inClients(ctx context.Context, column string) *predicate {
+	clientIDs, ok := tenant.ClientIDs(ctx)
+	if !ok {
+		return nil
+	}
+	return where(column+" = ANY(?)", pq.Array(clientIDs))
+}
+
+// leadOfClients limits rows to those whose column holds a lead enrolled in a
+// campaign of the clients ctx is limited to, which are the leads generated
+// for them.
+func leadOfClients(ctx context.Context, column string) *predicate {
+	clientIDs, ok := tenant.ClientIDs(ctx)
+	if !ok {
+		return nil
+	}
+	return where(column+" IN (SELECT cl.lead_id FROM campaign_leads cl JOIN campaigns c ON c.id = cl.campaign_id WHERE c.client_id = ANY(?))", pq.Array(clientIDs))
+}
+
+// leadVisibleToClients reports whether the lead passes leadOfClients.
+func (db *DB) leadVisibleToClients(ctx context.Context, leadID string) (bool, error) {
+	clientIDs, ok := tenant.ClientIDs(ctx)
+	if !ok {
+		return true, nil
+	}
+
+	query := `SELECT EXISTS (SELECT 1 FROM campaign_leads cl JOIN campaigns c ON c.id = cl.campaign_id 
+              WHERE cl.lead_id = $1 AND c.client_id = ANY($2))`
+
+	var visible bool
+	if err := db.conn.QueryRowContext(ctx, query, leadID, pq.Array(clientIDs)).Scan(&visible); err != nil {
+		return false, fmt.Errorf("error checking lead visibility: %w", err)
+	}
+	return visible, nil
+}
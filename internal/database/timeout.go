@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// dataSource adds the default statement timeout to connStr, which pq sends
+// to the server as a run-time parameter for every connection it opens.
+func dataSource(connStr string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return connStr, nil
+	}
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		var err error
+		if connStr, err = pq.ParseURL(connStr); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s statement_timeout=%d", connStr, milliseconds(timeout)), nil
+}
+
+// statementTimeout returns how long the server may run a statement for ctx:
+// timeout, or less when ctx's deadline comes first. Zero means no limit
+// other than the connection's default.
+func statementTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	if timeout == 0 || remaining < timeout {
+		return remaining, nil
+	}
+	return timeout, nil
+}
+
+// beginWithTimeout begins a transaction on conn whose statements the server
+// cancels once they run past timeout, or past ctx's deadline when that comes
+// first. The server then frees the connection even when the client has
+// stopped waiting without cancelling the query.
+func beginWithTimeout(ctx context.Context, conn *sql.DB, opts *sql.TxOptions, timeout time.Duration) (*sql.Tx, error) {
+	limit, err := statementTimeout(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	if limit > 0 {
+		_, err := tx.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, true)", strconv.FormatInt(milliseconds(limit), 10))
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("error setting statement timeout: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// milliseconds rounds d up, since a statement timeout of zero turns the
+// timeout off.
+func milliseconds(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+// analyticsRows are the rows of a query run by queryAnalytics. Closing them
+// also ends the read-only transaction the query ran in.
+type analyticsRows struct {
+	*sql.Rows
+	tx *sql.Tx
+}
+
+func (r *analyticsRows) Close() error {
+	err := r.Rows.Close()
+	r.tx.Rollback()
+	return err
+}
+
+// queryAnalytics runs a report or metrics query like queryReplica, but under
+// the analytics statement timeout, so that one runaway query cannot hold a
+// connection for minutes.
+func (db *DB) queryAnalytics(ctx context.Context, query string, args ...interface{}) (*analyticsRows, error) {
+	if r := db.replica; r != nil && r.available() {
+		rows, err := db.queryWithTimeout(ctx, r.conn, query, args...)
+		if err == nil {
+			r.markUp()
+			return rows, nil
+		}
+		if ctx.Err() != nil || !isConnectionError(err) {
+			return nil, err
+		}
+		r.markDown(err)
+	}
+
+	return db.queryWithTimeout(ctx, db.conn, query, args...)
+}
+
+func (db *DB) queryWithTimeout(ctx context.Context, conn *sql.DB, query string, args ...interface{}) (*analyticsRows, error) {
+	tx, err := beginWithTimeout(ctx, conn, &sql.TxOptions{ReadOnly: true}, db.analyticsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &analyticsRows{Rows: rows, tx: tx}, nil
+}
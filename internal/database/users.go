@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"salesagency/graph/model"
+)
+
+type UserCredentials struct {
+	PasswordHash string
+	AgencyID     string
+}
+
+// GetUserCredentialsByEmail is used during login, before any tenant is known,
+// and is therefore not scoped to an agency.
+func (db *DB) GetUserCredentialsByEmail(ctx context.Context, email string) (*model.User, *UserCredentials, error) {
+	query := `SELECT id, name, email, role, phone, position, status, password_hash, 
+              agency_id, created_at, updated_at 
+              FROM users WHERE lower(email) = lower($1)`
+
+	var user model.User
+	var credentials UserCredentials
+	var phone, position sql.NullString
+	var updatedAt sql.NullTime
+
+	err := db.conn.QueryRowContext(ctx, query, email).Scan(
+		&user.ID, &user.Name, &user.Email, &user.Role, &phone, &position,
+		&user.Status, &credentials.PasswordHash, &credentials.AgencyID, &user.CreatedAt, &updatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("error fetching user: %w", err)
+	}
+
+	if phone.Valid {
+		user.Phone = &phone.String
+	}
+	if position.Valid {
+		user.Position = &position.String
+	}
+	if updatedAt.Valid {
+		user.UpdatedAt = &updatedAt.Time
+	}
+
+	return &user, &credentials, nil
+}
+
+const userColumns = `id, name, email, role, phone, position, status, created_at, updated_at`
+
+func (db *DB) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	query := `SELECT ` + userColumns + ` 
+              FROM users WHERE id = $1 AND (agency_id = $2 OR $2 IS NULL)`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := scanUser(db.conn.QueryRowContext(ctx, query, id, agencyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return user, err
+}
+
+// GetUsersByRole returns the agency's users with any of roles, by name,
+// optionally only those in status.
+func (db *DB) GetUsersByRole(ctx context.Context, roles []model.UserRole, status *model.UserStatus) ([]*model.User, error) {
+	query := `SELECT ` + userColumns + ` 
+              FROM users WHERE role = ANY($1) AND (status = $2 OR $2 IS NULL) AND (agency_id = $3 OR $3 IS NULL) 
+              ORDER BY name, id`
+
+	agencyID, err := tenantArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(names), status, agencyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user rows: %w", err)
+	}
+
+	return users, nil
+}
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*model.User, error) {
+	var user model.User
+	var phone, position sql.NullString
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID, &user.Name, &user.Email, &user.Role, &phone, &position,
+		&user.Status, &user.CreatedAt, &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning user row: %w", err)
+	}
+
+	user.Phone = nullString(phone)
+	user.Position = nullString(position)
+	if updatedAt.Valid {
+		user.UpdatedAt = &updatedAt.Time
+	}
+
+	return &user, nil
+}
+
+func (db *DB) GetClientIDsByUserID(ctx context.Context, userID string) ([]string, error) {
+	query := "SELECT client_id FROM user_client WHERE user_id = $1"
+
+	rows, err := db.conn.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying user clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clientIDs []string
+	for rows.Next() {
+		var clientID string
+		if err := rows.Scan(&clientID); err != nil {
+			return nil, fmt.Errorf("error scanning user client row: %w", err)
+		}
+		clientIDs = append(clientIDs, clientID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user client rows: %w", err)
+	}
+
+	return clientIDs, nil
+}
@@ -0,0 +1,8 @@
+package database
+
+import "errors"
+
+// ErrVersionConflict is returned when a lead or client was changed, or
+// deleted, after the version the caller read. Every write to the fields a
+// user can edit bumps the version.
+var ErrVersionConflict = errors.New("the record was changed by someone else")
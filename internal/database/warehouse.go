@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// The datasets exported to the data warehouse.
+const (
+	WarehouseLeads           = "leads"
+	WarehouseInteractions    = "interactions"
+	WarehouseCampaignMetrics = "campaign_metrics"
+)
+
+// warehouseQuery selects a dataset's rows as JSON objects.
+type warehouseQuery struct {
+	query string
+	// args returns the query's arguments for the rows changed after since,
+	// or for every row when since is nil, up to until.
+	args func(since *time.Time, until time.Time) []interface{}
+}
+
+func changedRows(since *time.Time, until time.Time) []interface{} {
+	return []interface{}{since, until}
+}
+
+var warehouseQueries = map[string]warehouseQuery{
+	WarehouseLeads: {
+		query: `SELECT to_jsonb(l) - 'search_vector' FROM leads l 
+              WHERE GREATEST(l.created_at, l.updated_at, l.deleted_at) <= $2 
+              AND ($1::timestamptz IS NULL OR GREATEST(l.created_at, l.updated_at, l.deleted_at) > $1) 
+              ORDER BY GREATEST(l.created_at, l.updated_at, l.deleted_at), l.id`,
+		args: changedRows,
+	},
+	WarehouseInteractions: {
+		query: `SELECT (to_jsonb(i) - 'search_vector') || jsonb_build_object('agency_id', l.agency_id) 
+              FROM interactions i JOIN leads l ON l.id = i.lead_id 
+              WHERE COALESCE(i.updated_at, i.created_at) <= $2 
+              AND ($1::timestamptz IS NULL OR COALESCE(i.updated_at, i.created_at) > $1) 
+              ORDER BY COALESCE(i.updated_at, i.created_at), i.id`,
+		args: changedRows,
+	},
+	// Campaign metrics are computed rather than stored, so every export
+	// takes all of them as of until.
+	WarehouseCampaignMetrics: {
+		query: `SELECT to_jsonb(m) || jsonb_build_object('agency_id', c.agency_id, 'name', c.name, 'status', c.status, 'as_of', $3::timestamptz) 
+              FROM (` + campaignMetricsQuery + `) AS m(campaign_id, cost, leads_generated, interactions, messages_sent, replies, meetings_booked, conversions) 
+              JOIN campaigns c ON c.id = m.campaign_id 
+              ORDER BY m.campaign_id`,
+		args: func(since *time.Time, until time.Time) []interface{} {
+			return []interface{}{nil, nil, until}
+		},
+	},
+}
+
+// ExportWarehouseDataset passes write the dataset's rows changed after its
+// watermark, or every row when full is set, up to until, oldest change
+// first. Once flush has succeeded the watermark moves to until. The dataset
+// is locked meanwhile; when another export holds it, ExportWarehouseDataset
+// returns false without calling write.
+func (db *DB) ExportWarehouseDataset(ctx context.Context, dataset string, full bool, until time.Time, write func(row []byte) error, flush func() error) (bool, error) {
+	q, ok := warehouseQueries[dataset]
+	if !ok {
+		return false, fmt.Errorf("unknown warehouse dataset %q", dataset)
+	}
+
+	_, err := db.conn.ExecContext(ctx, "INSERT INTO warehouse_exports (dataset) VALUES ($1) ON CONFLICT (dataset) DO NOTHING", dataset)
+	if err != nil {
+		return false, fmt.Errorf("error creating warehouse watermark: %w", err)
+	}
+
+	tx, err := db.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Exports of large tables run well past the default statement timeout.
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('statement_timeout', '0', true)"); err != nil {
+		return false, fmt.Errorf("error setting statement timeout: %w", err)
+	}
+
+	var watermark sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT exported_until FROM warehouse_exports WHERE dataset = $1 FOR UPDATE SKIP LOCKED", dataset).Scan(&watermark)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error locking warehouse watermark: %w", err)
+	}
+
+	var since *time.Time
+	if watermark.Valid && !full {
+		since = &watermark.Time
+	}
+
+	rows, err := tx.QueryContext(ctx, q.query, q.args(since, until)...)
+	if err != nil {
+		return false, fmt.Errorf("error querying warehouse %s: %w", dataset, err)
+	}
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("error scanning warehouse %s row: %w", dataset, err)
+		}
+		if err := write(row); err != nil {
+			rows.Close()
+			return false, err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating warehouse %s rows: %w", dataset, err)
+	}
+
+	if err := flush(); err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE warehouse_exports SET exported_until = $1, updated_at = now() WHERE dataset = $2", until, dataset)
+	if err != nil {
+		return false, fmt.Errorf("error moving warehouse watermark: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return true, nil
+}
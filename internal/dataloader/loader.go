@@ -0,0 +1,123 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWait     = 2 * time.Millisecond
+	defaultMaxBatch = 100
+)
+
+// BatchFunc fetches values for a set of keys in one round trip. Keys missing
+// from the returned map resolve to the zero value of V.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader coalesces Load calls made within a short window into a single
+// BatchFunc call and memoizes results for its lifetime, which is one GraphQL
+// response.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*thunk[V]
+	batch *batch[K, V]
+}
+
+type thunk[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	thunks []*thunk[V]
+}
+
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     defaultWait,
+		maxBatch: defaultMaxBatch,
+		cache:    make(map[K]*thunk[V]),
+	}
+}
+
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if t, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return t.result(ctx)
+	}
+
+	t := &thunk[V]{done: make(chan struct{})}
+	l.cache[key] = t
+
+	if l.batch == nil {
+		l.batch = &batch[K, V]{}
+		go l.dispatchAfterWait(ctx, l.batch)
+	}
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.thunks = append(l.batch.thunks, t)
+
+	if len(l.batch.keys) >= l.maxBatch {
+		full := l.batch
+		l.batch = nil
+		go l.dispatch(ctx, full)
+	}
+	l.mu.Unlock()
+
+	return t.result(ctx)
+}
+
+func (l *Loader[K, V]) dispatchAfterWait(ctx context.Context, b *batch[K, V]) {
+	time.Sleep(l.wait)
+
+	l.mu.Lock()
+	if l.batch != b {
+		// Already dispatched because it reached maxBatch.
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.dispatch(ctx, b)
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	values, err := l.fetch(ctx, b.keys)
+
+	if err != nil {
+		l.mu.Lock()
+		for _, key := range b.keys {
+			delete(l.cache, key)
+		}
+		l.mu.Unlock()
+	}
+
+	for i, key := range b.keys {
+		t := b.thunks[i]
+		if err != nil {
+			t.err = err
+		} else {
+			t.value = values[key]
+		}
+		close(t.done)
+	}
+}
+
+func (t *thunk[V]) result(ctx context.Context) (V, error) {
+	select {
+	case <-t.done:
+		return t.value, t.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,82 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+type contextKey struct{}
+
+type Loaders struct {
+	InteractionsByLeadID  *Loader[string, []*model.Interaction]
+	CampaignsByClientID   *Loader[string, []*model.Campaign]
+	TargetsByCampaignID   *Loader[string, []*model.TargetAudience]
+	StatsByAgentID        *Loader[string, *database.AgentDayStats]
+	MetricsByCampaignID   *Loader[string, *model.CampaignMetrics]
+	ChannelsByCampaignID  *Loader[string, []*model.ChannelMetrics]
+	AudiencesByCampaignID *Loader[string, []*model.AudienceMetrics]
+	OptOutsByLeadID       *Loader[string, []model.Channel]
+	HandoffsByLeadID      *Loader[string, *model.LeadHandoff]
+	IntentByReplyID       *Loader[string, *model.ReplyIntent]
+}
+
+// NewLoaders returns loaders that read leads, clients and campaigns through
+// store and everything else from db.
+func NewLoaders(db *database.DB, store database.Repositories) *Loaders {
+	return &Loaders{
+		InteractionsByLeadID:  NewLoader(store.Leads.GetInteractionsByLeadIDs),
+		CampaignsByClientID:   NewLoader(store.Campaigns.GetCampaignsByClientIDs),
+		TargetsByCampaignID:   NewLoader(store.Campaigns.GetTargetsByCampaignIDs),
+		StatsByAgentID:        NewLoader(db.GetAgentStatsTotals),
+		MetricsByCampaignID:   NewLoader(db.GetCampaignMetricsByCampaignIDs),
+		ChannelsByCampaignID:  NewLoader(db.GetCampaignChannelMetricsByCampaignIDs),
+		AudiencesByCampaignID: NewLoader(db.GetCampaignAudienceMetricsByCampaignIDs),
+		OptOutsByLeadID:       NewLoader(db.GetOptedOutChannelsByLeadIDs),
+		HandoffsByLeadID:      NewLoader(db.GetOpenLeadHandoffsByLeadIDs),
+		IntentByReplyID:       NewLoader(db.GetReplyIntentsByInteractionIDs),
+	}
+}
+
+// ErrNoLoaders is returned by For outside a GraphQL operation run by a server
+// using Extension.
+var ErrNoLoaders = errors.New("no dataloaders in context")
+
+// Extension attaches a fresh set of loaders to every GraphQL response so
+// batching and memoization never leak data between operations. That holds
+// for operations sharing a websocket too, and for each event of a
+// subscription, which would otherwise see what earlier events loaded.
+type Extension struct {
+	DB    *database.DB
+	Store database.Repositories
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = Extension{}
+
+func (Extension) ExtensionName() string {
+	return "Dataloaders"
+}
+
+func (Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	return next(context.WithValue(ctx, contextKey{}, NewLoaders(e.DB, e.Store)))
+}
+
+// For returns the loaders of the current response, or ErrNoLoaders.
+func For(ctx context.Context) (*Loaders, error) {
+	loaders, ok := ctx.Value(contextKey{}).(*Loaders)
+	if !ok {
+		return nil, ErrNoLoaders
+	}
+	return loaders, nil
+}
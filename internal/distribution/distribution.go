@@ -0,0 +1,196 @@
+// Package distribution shares the inbound leads captured from web forms out
+// among the agency's active campaigns. Each new lead goes to one of the
+// campaigns whose rules it meets, by smooth weighted round robin: every
+// eligible campaign earns its weight in credit, the one with the most takes
+// the lead and gives up the total weight of those eligible.
+package distribution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/database"
+	"salesagency/internal/sendwindow"
+)
+
+// MaxWeight bounds campaign weights, so that credits stay small.
+const MaxWeight = 1000
+
+var (
+	ErrInvalidWeight   = fmt.Errorf("weight must be between 1 and %d", MaxWeight)
+	ErrInvalidRegion   = errors.New("regions must be time zones or areas of them, such as Europe or America/New_York")
+	ErrInvalidIndustry = errors.New("industries must not be empty")
+)
+
+// areas are the first parts of IANA time zone names, which a region may
+// name to take every zone in them.
+var areas = map[string]bool{
+	"Africa": true, "America": true, "Antarctica": true, "Asia": true, "Atlantic": true,
+	"Australia": true, "Europe": true, "Indian": true, "Pacific": true,
+}
+
+// Inbound is what the form said about the lead beyond its fields.
+type Inbound struct {
+	// CampaignID is the campaign the form named, which takes the lead
+	// without weighing the others.
+	CampaignID *string
+	Industry   *string
+}
+
+type Service struct {
+	db *database.DB
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// SetRouting checks the routing and saves it for its campaign.
+func (s *Service) SetRouting(ctx context.Context, routing *model.CampaignRouting) (*model.CampaignRouting, error) {
+	if routing.Weight < 1 || routing.Weight > MaxWeight {
+		return nil, ErrInvalidWeight
+	}
+
+	industries := make([]string, 0, len(routing.Industries))
+	for _, industry := range routing.Industries {
+		industry = strings.TrimSpace(industry)
+		if industry == "" {
+			return nil, ErrInvalidIndustry
+		}
+		industries = append(industries, industry)
+	}
+	routing.Industries = industries
+
+	regions := make([]string, 0, len(routing.Regions))
+	for _, region := range routing.Regions {
+		region = strings.TrimSpace(region)
+		if !areas[region] && sendwindow.CheckTimezone(region) != nil {
+			return nil, ErrInvalidRegion
+		}
+		regions = append(regions, region)
+	}
+	routing.Regions = regions
+
+	saved, err := s.db.SetCampaignRouting(ctx, routing)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, campaign.ErrNotFound
+	}
+	return saved, nil
+}
+
+// Distribute places a newly captured lead in a campaign and logs why. It
+// returns nil, leaving the lead alone, when the agency routes inbound leads
+// to none of its active campaigns.
+func (s *Service) Distribute(ctx context.Context, lead *model.Lead, inbound Inbound) (*model.AssignmentLogEntry, error) {
+	return s.db.DistributeLead(ctx, lead.ID, func(routes []*model.CampaignRouting) *model.AssignmentLogEntry {
+		return choose(lead, inbound, routes)
+	})
+}
+
+// choose picks the campaign for the lead among routes, updating the credits
+// of those eligible.
+func choose(lead *model.Lead, inbound Inbound, routes []*model.CampaignRouting) *model.AssignmentLogEntry {
+	if len(routes) == 0 {
+		return nil
+	}
+
+	entry := &model.AssignmentLogEntry{
+		Industry:   inbound.Industry,
+		Timezone:   lead.Timezone,
+		Candidates: make([]*model.AssignmentCandidate, 0, len(routes)),
+	}
+	if inbound.CampaignID != nil {
+		entry.CampaignID = inbound.CampaignID
+		entry.Reason = model.AssignmentReasonFormCampaign
+		return entry
+	}
+
+	var eligible []*model.CampaignRouting
+	candidates := make(map[string]*model.AssignmentCandidate, len(routes))
+	for _, route := range routes {
+		candidate := &model.AssignmentCandidate{
+			CampaignID:   route.CampaignID,
+			CampaignName: route.CampaignName,
+			Weight:       route.Weight,
+		}
+		if reason := ineligible(route, inbound.Industry, lead.Timezone); reason != "" {
+			candidate.Reason = &reason
+		} else {
+			candidate.Eligible = true
+			eligible = append(eligible, route)
+		}
+		candidates[route.CampaignID] = candidate
+		entry.Candidates = append(entry.Candidates, candidate)
+	}
+	if len(eligible) == 0 {
+		entry.Reason = model.AssignmentReasonNoEligibleCampaign
+		return entry
+	}
+
+	// Ties go to the campaign routed longest, which routes are ordered by.
+	var chosen *model.CampaignRouting
+	total := 0
+	for _, route := range eligible {
+		route.Credit += route.Weight
+		total += route.Weight
+		credit := route.Credit
+		candidates[route.CampaignID].Credit = &credit
+		if chosen == nil || route.Credit > chosen.Credit {
+			chosen = route
+		}
+	}
+	chosen.Credit -= total
+
+	entry.CampaignID = &chosen.CampaignID
+	entry.Reason = model.AssignmentReasonWeighted
+	return entry
+}
+
+// ineligible returns why the campaign cannot take a lead of the industry and
+// time zone, or "" when it can.
+func ineligible(route *model.CampaignRouting, industry, timezone *string) string {
+	if len(route.Industries) > 0 {
+		if industry == nil {
+			return "no industry given"
+		}
+		if !matchesIndustry(route.Industries, *industry) {
+			return fmt.Sprintf("industry %q is not one of %s", *industry, strings.Join(route.Industries, ", "))
+		}
+	}
+	if len(route.Regions) > 0 {
+		if timezone == nil {
+			return "time zone unknown"
+		}
+		if !inRegion(route.Regions, *timezone) {
+			return fmt.Sprintf("time zone %s is outside %s", *timezone, strings.Join(route.Regions, ", "))
+		}
+	}
+	return ""
+}
+
+func matchesIndustry(industries []string, industry string) bool {
+	for _, i := range industries {
+		if strings.EqualFold(i, strings.TrimSpace(industry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// inRegion reports whether the time zone is one of regions or in one of
+// their areas.
+func inRegion(regions []string, timezone string) bool {
+	for _, region := range regions {
+		if timezone == region || strings.HasPrefix(timezone, region+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"salesagency/internal/database"
+)
+
+// bridgeChannel is the Postgres channel events are relayed on.
+const bridgeChannel = "salesagency_events"
+
+// maxInlinePayload is the largest encoded payload sent inside a
+// notification. Base64 adds a third, which keeps notifications under
+// Postgres's 8000 byte limit; larger payloads are stored in the database.
+const maxInlinePayload = 5000
+
+const bridgeBufferSize = 256
+
+// listenerPingInterval is how often an idle listener checks its connection.
+const listenerPingInterval = 90 * time.Second
+
+// RegisterPayload lets a Bridge relay payloads of the given values' types.
+// Events with other payloads reach subscribers on their own instance only.
+func RegisterPayload(payloads ...interface{}) {
+	for _, payload := range payloads {
+		gob.Register(payload)
+	}
+}
+
+// Bridge relays events between server instances through Postgres
+// LISTEN/NOTIFY, so subscribers receive events published on any instance.
+// Delivery is best effort, as with the Broker: events published while an
+// instance is reconnecting are lost to it.
+type Bridge struct {
+	broker   *Broker
+	db       *database.DB
+	instance string
+	outbox   chan Event
+}
+
+type envelope struct {
+	Instance string `json:"i"`
+	Topic    string `json:"t"`
+	Payload  []byte `json:"p,omitempty"`
+	// Ref is the ID of a payload too large to inline.
+	Ref int64 `json:"r,omitempty"`
+}
+
+// NewBridge starts handing events published on broker to the bridge; they
+// are relayed once Run is called.
+func NewBridge(broker *Broker, db *database.DB) *Bridge {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	b := &Bridge{
+		broker:   broker,
+		db:       db,
+		instance: hex.EncodeToString(id),
+		outbox:   make(chan Event, bridgeBufferSize),
+	}
+	broker.setRelay(b.enqueue)
+	return b
+}
+
+func (b *Bridge) enqueue(event Event) {
+	select {
+	case b.outbox <- event:
+	default:
+		slog.Warn("events: bridge buffer full, event not relayed", "topic", event.Topic)
+	}
+}
+
+// Run relays events until ctx is cancelled.
+func (b *Bridge) Run(ctx context.Context) error {
+	listener, err := b.db.Listen(bridgeChannel)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	go b.send(ctx)
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// nil follows a reconnect; whatever was sent meanwhile is lost.
+			if notification != nil {
+				b.receive(ctx, notification.Extra)
+			}
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
+
+func (b *Bridge) send(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-b.outbox:
+			if err := b.notify(ctx, event); err != nil {
+				slog.Warn("events: error relaying event", "topic", event.Topic, "error", err)
+			}
+		}
+	}
+}
+
+func (b *Bridge) notify(ctx context.Context, event Event) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&event.Payload); err != nil {
+		return err
+	}
+
+	env := envelope{Instance: b.instance, Topic: event.Topic}
+	if buf.Len() > maxInlinePayload {
+		ref, err := b.db.StoreEventPayload(ctx, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		env.Ref = ref
+	} else {
+		env.Payload = buf.Bytes()
+	}
+
+	msg, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return b.db.Notify(ctx, bridgeChannel, string(msg))
+}
+
+func (b *Bridge) receive(ctx context.Context, msg string) {
+	var env envelope
+	if err := json.Unmarshal([]byte(msg), &env); err != nil {
+		slog.Warn("events: invalid relayed event", "error", err)
+		return
+	}
+	// Postgres notifies the sender too.
+	if env.Instance == b.instance {
+		return
+	}
+
+	data := env.Payload
+	if env.Ref != 0 {
+		var err error
+		if data, err = b.db.GetEventPayload(ctx, env.Ref); err != nil || data == nil {
+			slog.Warn("events: relayed event payload unavailable", "topic", env.Topic, "error", err)
+			return
+		}
+	}
+
+	var payload interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&payload); err != nil {
+		slog.Warn("events: error decoding relayed event", "topic", env.Topic, "error", err)
+		return
+	}
+
+	b.broker.publishRemote(Event{Topic: env.Topic, Payload: payload})
+}
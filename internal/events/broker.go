@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	TopicLeadCreated = "lead.created"
+	TopicLeadUpdated = "lead.updated"
+	TopicLeadReplied = "lead.replied"
+	// TopicLeadIntentScoreChanged carries a *database.IntentScoreChange.
+	TopicLeadIntentScoreChanged = "lead.intent_score_changed"
+
+	TopicAgentRunLog = "agent_run.log"
+
+	// TopicNotificationCreated carries a *model.Notification.
+	TopicNotificationCreated = "notification.created"
+)
+
+const subscriberBufferSize = 16
+
+type Event struct {
+	Topic   string
+	Payload interface{}
+	// Remote is set on events published on another server instance and
+	// relayed by a Bridge.
+	Remote bool
+}
+
+// Broker is an in-memory pub/sub used to fan events out to GraphQL subscriptions.
+// Slow subscribers drop events rather than blocking publishers.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan Event]struct{}
+	// relay, when set, is handed every event published on this instance.
+	relay func(Event)
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+func (b *Broker) Subscribe(ctx context.Context, topic string) <-chan Event {
+	ch := make(chan Event, subscriberBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan Event]struct{})
+	}
+	b.subscribers[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers[topic], ch)
+		if len(b.subscribers[topic]) == 0 {
+			delete(b.subscribers, topic)
+		}
+		b.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch
+}
+
+func (b *Broker) Publish(topic string, payload interface{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	event := Event{Topic: topic, Payload: payload}
+	b.deliver(event)
+	if b.relay != nil {
+		b.relay(event)
+	}
+}
+
+func (b *Broker) setRelay(relay func(Event)) {
+	b.mu.Lock()
+	b.relay = relay
+	b.mu.Unlock()
+}
+
+// publishRemote delivers an event relayed from another instance to local
+// subscribers only.
+func (b *Broker) publishRemote(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	event.Remote = true
+	b.deliver(event)
+}
+
+// deliver must be called with b.mu held.
+func (b *Broker) deliver(event Event) {
+	for ch := range b.subscribers[event.Topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/customfields"
+	"salesagency/internal/database"
+)
+
+// record is an exported lead with its custom field values.
+type record struct {
+	*model.Lead
+	customFields database.CustomFieldValues
+}
+
+type column struct {
+	header  string
+	numeric bool
+	value   func(l record) string
+}
+
+var columns = []column{
+	{header: "ID", value: func(l record) string { return l.ID }},
+	{header: "Name", value: func(l record) string { return l.Name }},
+	{header: "Email", value: func(l record) string { return l.Email }},
+	{header: "Phone", value: func(l record) string { return str(l.Phone) }},
+	{header: "Company", value: func(l record) string { return str(l.Company) }},
+	{header: "Position", value: func(l record) string { return str(l.Position) }},
+	{header: "Status", value: func(l record) string { return string(l.Status) }},
+	{header: "Intent score", numeric: true, value: func(l record) string {
+		return strconv.FormatFloat(l.IntentScore, 'f', -1, 64)
+	}},
+	{header: "Deal value", numeric: true, value: func(l record) string {
+		if l.DealValue == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*l.DealValue, 'f', 2, 64)
+	}},
+	{header: "Tags", value: func(l record) string { return strings.Join(l.Tags, "; ") }},
+	{header: "Source", value: func(l record) string { return str(l.Source) }},
+	{header: "Last contact", value: func(l record) string { return timestamp(l.LastContact) }},
+	{header: "Next follow-up", value: func(l record) string { return timestamp(l.NextFollowUp) }},
+	{header: "Notes", value: func(l record) string { return str(l.Notes) }},
+	{header: "Created at", value: func(l record) string { return timestamp(&l.CreatedAt) }},
+}
+
+// columnsFor adds a column per custom field to the lead columns, after them.
+func columnsFor(defs []*model.CustomFieldDefinition) []column {
+	cols := make([]column, len(columns), len(columns)+len(defs))
+	copy(cols, columns)
+	for _, def := range defs {
+		key := def.Key
+		cols = append(cols, column{
+			header:  def.Label,
+			numeric: def.Type == model.CustomFieldTypeNumber,
+			value:   func(l record) string { return customfields.Format(l.customFields[key]) },
+		})
+	}
+	return cols
+}
+
+func headers(columns []column) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.header
+	}
+	return row
+}
+
+func values(columns []column, l record) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.value(l)
+	}
+	return row
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(row []string) error {
+	for i, value := range row {
+		row[i] = neutralizeFormula(value)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// neutralizeFormula stops spreadsheet applications from evaluating values
+// such as "=HYPERLINK(...)" that leads may have entered themselves. Numbers,
+// including phone numbers like "+15551234567", are left alone.
+func neutralizeFormula(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if isNumber(value) {
+		return value
+	}
+	return "'" + value
+}
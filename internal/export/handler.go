@@ -0,0 +1,99 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+// Path is where Handler is mounted.
+const Path = "/exports/leads"
+
+type rowWriter interface {
+	WriteRow(row []string) error
+	Close() error
+}
+
+// Handler serves export links, streaming the matching leads as they are
+// read. A failure after the download has started aborts the connection so
+// that a truncated file is not mistaken for a complete one.
+func Handler(db *database.DB, signer *Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		req, err := signer.Verify(r.URL.Query().Get("token"))
+		if err == nil && req.AgencyID == "" {
+			err = ErrInvalidToken
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		ctx := tenant.WithAgency(r.Context(), req.AgencyID)
+		ctx = logging.With(ctx, "user_id", req.UserID, "export_format", string(req.Format))
+
+		filename := "leads-" + time.Now().UTC().Format("20060102-150405")
+		switch req.Format {
+		case model.ExportFormatXlsx:
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			filename += ".xlsx"
+		default:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			filename += ".csv"
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Header().Set("Cache-Control", "no-store")
+
+		rows := 0
+		err = func() error {
+			defs, err := db.GetCustomFieldDefinitions(ctx)
+			if err != nil {
+				return err
+			}
+			cols := columnsFor(defs)
+
+			out, err := newRowWriter(w, req.Format, cols)
+			if err != nil {
+				return err
+			}
+			if err := out.WriteRow(headers(cols)); err != nil {
+				return err
+			}
+			err = db.EachLeadByFilter(ctx, req.Filter, func(lead *model.Lead, customFields database.CustomFieldValues) error {
+				rows++
+				return out.WriteRow(values(cols, record{Lead: lead, customFields: customFields}))
+			})
+			if err != nil {
+				return err
+			}
+			return out.Close()
+		}()
+		if err != nil {
+			logging.FromContext(ctx).Error("error exporting leads", "rows", rows, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		logging.FromContext(ctx).Info("exported leads", "rows", rows)
+	})
+}
+
+func newRowWriter(w io.Writer, format model.ExportFormat, columns []column) (rowWriter, error) {
+	if format != model.ExportFormatXlsx {
+		return newCSVWriter(w), nil
+	}
+
+	numeric := make([]bool, len(columns))
+	for i, c := range columns {
+		numeric[i] = c.numeric
+	}
+	return newXLSXWriter(w, numeric)
+}
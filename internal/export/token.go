@@ -0,0 +1,82 @@
+// Package export streams filtered leads as CSV or XLSX downloads behind
+// signed, expiring links.
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired export link")
+
+// Request describes an export. It is carried in the link itself, so nothing
+// is stored until the file is downloaded.
+type Request struct {
+	AgencyID  string                 `json:"agency"`
+	UserID    string                 `json:"user,omitempty"`
+	Filter    *model.LeadFilterInput `json:"filter,omitempty"`
+	Format    model.ExportFormat     `json:"format"`
+	ExpiresAt time.Time              `json:"expires"`
+}
+
+// Signer creates and verifies export links.
+type Signer struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+// NewSigner signs links with secret. Links are valid for ttl and are
+// relative when baseURL is empty.
+func NewSigner(secret, baseURL string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/"), ttl: ttl}
+}
+
+// URL returns a download link for req and the time it expires.
+func (s *Signer) URL(req Request) (string, time.Time, error) {
+	req.ExpiresAt = time.Now().Add(s.ttl).UTC().Truncate(time.Second)
+
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(encoded)
+	token := payload + "." + s.sign(payload)
+
+	return s.baseURL + Path + "?token=" + url.QueryEscape(token), req.ExpiresAt, nil
+}
+
+// Verify returns the export a token was issued for.
+func (s *Signer) Verify(token string) (*Request, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return nil, ErrInvalidToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var req Request
+	if err := json.Unmarshal(decoded, &req); err != nil || !req.Format.IsValid() {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().After(req.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	return &req, nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
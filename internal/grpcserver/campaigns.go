@@ -0,0 +1,51 @@
+package grpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/grpcserver/pb"
+)
+
+type campaignService struct {
+	pb.UnimplementedCampaignServiceServer
+	db *database.DB
+}
+
+func (s *campaignService) GetCampaign(ctx context.Context, req *pb.GetCampaignRequest) (*pb.Campaign, error) {
+	campaign, err := s.db.GetCampaignByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if campaign == nil {
+		return nil, status.Error(codes.NotFound, "campaign not found")
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user.IsClientScoped() && (campaign.ClientID == nil || !user.CanAccessClient(*campaign.ClientID)) {
+		return nil, status.Error(codes.PermissionDenied, auth.ErrForbidden.Error())
+	}
+
+	return campaignToProto(campaign), nil
+}
+
+func (s *campaignService) ListCampaignsByClient(ctx context.Context, req *pb.ListCampaignsByClientRequest) (*pb.ListCampaignsResponse, error) {
+	if user := auth.UserFromContext(ctx); !user.CanAccessClient(req.GetClientId()) {
+		return nil, status.Error(codes.PermissionDenied, auth.ErrForbidden.Error())
+	}
+
+	campaigns, err := s.db.GetCampaignsByClientID(ctx, req.GetClientId())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	resp := &pb.ListCampaignsResponse{Campaigns: make([]*pb.Campaign, 0, len(campaigns))}
+	for _, campaign := range campaigns {
+		resp.Campaigns = append(resp.Campaigns, campaignToProto(campaign))
+	}
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/grpcserver/pb"
+)
+
+type clientService struct {
+	pb.UnimplementedClientServiceServer
+	db *database.DB
+}
+
+func (s *clientService) GetClient(ctx context.Context, req *pb.GetClientRequest) (*pb.Client, error) {
+	if user := auth.UserFromContext(ctx); !user.CanAccessClient(req.GetId()) {
+		return nil, status.Error(codes.PermissionDenied, auth.ErrForbidden.Error())
+	}
+
+	client, err := s.db.GetClientByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if client == nil {
+		return nil, status.Error(codes.NotFound, "client not found")
+	}
+	return clientToProto(client), nil
+}
+
+func (s *clientService) ListClients(ctx context.Context, req *pb.ListClientsRequest) (*pb.ListClientsResponse, error) {
+	var clientStatus *model.ClientStatus
+	if req.Status != nil {
+		parsed := model.ClientStatus(req.GetStatus())
+		if !parsed.IsValid() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid client status %q", req.GetStatus())
+		}
+		clientStatus = &parsed
+	}
+
+	clients, err := s.db.GetClientsByStatus(ctx, clientStatus, optionalInt(req.Limit), optionalInt(req.Offset))
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	user := auth.UserFromContext(ctx)
+	resp := &pb.ListClientsResponse{Clients: make([]*pb.Client, 0, len(clients))}
+	for _, client := range clients {
+		if user.CanAccessClient(client.ID) {
+			resp.Clients = append(resp.Clients, clientToProto(client))
+		}
+	}
+	return resp, nil
+}
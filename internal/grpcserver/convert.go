@@ -0,0 +1,80 @@
+package grpcserver
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"salesagency/graph/model"
+	"salesagency/internal/grpcserver/pb"
+)
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func optionalInt(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+func leadToProto(lead *model.Lead) *pb.Lead {
+	return &pb.Lead{
+		Id:           lead.ID,
+		Name:         lead.Name,
+		Email:        lead.Email,
+		Phone:        lead.Phone,
+		Company:      lead.Company,
+		Position:     lead.Position,
+		Status:       string(lead.Status),
+		IntentScore:  lead.IntentScore,
+		Tags:         lead.Tags,
+		Source:       lead.Source,
+		Notes:        lead.Notes,
+		LastContact:  timestamp(lead.LastContact),
+		NextFollowUp: timestamp(lead.NextFollowUp),
+		CreatedAt:    timestamppb.New(lead.CreatedAt),
+		UpdatedAt:    timestamp(lead.UpdatedAt),
+		Timezone:     lead.Timezone,
+		Language:     lead.Language,
+	}
+}
+
+func clientToProto(client *model.Client) *pb.Client {
+	return &pb.Client{
+		Id:            client.ID,
+		Name:          client.Name,
+		Industry:      client.Industry,
+		Website:       client.Website,
+		ContactPerson: client.ContactPerson,
+		Email:         client.Email,
+		Phone:         client.Phone,
+		Address:       client.Address,
+		StartDate:     timestamppb.New(client.StartDate),
+		Status:        string(client.Status),
+		Notes:         client.Notes,
+		CreatedAt:     timestamppb.New(client.CreatedAt),
+		UpdatedAt:     timestamp(client.UpdatedAt),
+	}
+}
+
+func campaignToProto(campaign *model.Campaign) *pb.Campaign {
+	return &pb.Campaign{
+		Id:          campaign.ID,
+		Name:        campaign.Name,
+		Description: campaign.Description,
+		ClientId:    campaign.ClientID,
+		StartDate:   timestamppb.New(campaign.StartDate),
+		EndDate:     timestamp(campaign.EndDate),
+		Status:      string(campaign.Status),
+		Budget:      campaign.Budget,
+		CreatedAt:   timestamppb.New(campaign.CreatedAt),
+		UpdatedAt:   timestamp(campaign.UpdatedAt),
+	}
+}
@@ -0,0 +1,3 @@
+package grpcserver
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=salesagency --go-grpc_out=../.. --go-grpc_opt=module=salesagency salesagency/v1/salesagency.proto
@@ -0,0 +1,227 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/grpcserver/pb"
+	"salesagency/internal/pipeline"
+	"salesagency/internal/scoring"
+	"salesagency/internal/sendwindow"
+	"salesagency/internal/templates"
+	"salesagency/internal/validation"
+)
+
+type leadService struct {
+	pb.UnimplementedLeadServiceServer
+	db       *database.DB
+	events   *events.Broker
+	pipeline *pipeline.Service
+	scoring  *scoring.Engine
+}
+
+func (s *leadService) GetLead(ctx context.Context, req *pb.GetLeadRequest) (*pb.Lead, error) {
+	lead, err := s.db.GetLeadByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if lead == nil {
+		return nil, status.Error(codes.NotFound, "lead not found")
+	}
+	return leadToProto(lead), nil
+}
+
+func (s *leadService) ListLeads(ctx context.Context, req *pb.ListLeadsRequest) (*pb.ListLeadsResponse, error) {
+	filter := &model.LeadFilterInput{
+		MinIntentScore: req.MinIntentScore,
+		Tags:           req.GetTags(),
+		Source:         req.Source,
+	}
+	for _, value := range req.GetStatus() {
+		leadStatus := model.LeadStatus(value)
+		if !leadStatus.IsValid() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid lead status %q", value)
+		}
+		filter.Status = append(filter.Status, leadStatus)
+	}
+
+	leads, err := s.db.GetLeadsByFilter(ctx, filter, optionalInt(req.Limit), optionalInt(req.Offset))
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	resp := &pb.ListLeadsResponse{Leads: make([]*pb.Lead, 0, len(leads))}
+	for _, lead := range leads {
+		resp.Leads = append(resp.Leads, leadToProto(lead))
+	}
+	return resp, nil
+}
+
+func (s *leadService) CreateLead(ctx context.Context, req *pb.CreateLeadRequest) (*pb.Lead, error) {
+	if err := requireRole(ctx, auth.RoleSalesRep); err != nil {
+		return nil, err
+	}
+
+	input := req.GetLead()
+	if input == nil {
+		return nil, status.Error(codes.InvalidArgument, "lead is required")
+	}
+
+	lead := &model.Lead{
+		Name:        input.GetName(),
+		Email:       input.GetEmail(),
+		Phone:       input.Phone,
+		Company:     input.Company,
+		Position:    input.Position,
+		Status:      model.LeadStatusNew,
+		IntentScore: 0.5,
+		Tags:        input.GetTags(),
+		Source:      input.Source,
+		Notes:       input.Notes,
+		CreatedAt:   time.Now(),
+	}
+	if err := applyLeadInput(lead, input); err != nil {
+		return nil, err
+	}
+
+	created, err := s.db.CreateLead(ctx, lead)
+	if errors.Is(err, database.ErrDuplicateLead) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	s.events.Publish(events.TopicLeadCreated, created)
+
+	return leadToProto(created), nil
+}
+
+func (s *leadService) UpdateLead(ctx context.Context, req *pb.UpdateLeadRequest) (*pb.Lead, error) {
+	if err := requireRole(ctx, auth.RoleSalesRep); err != nil {
+		return nil, err
+	}
+
+	input := req.GetLead()
+	if input == nil {
+		return nil, status.Error(codes.InvalidArgument, "lead is required")
+	}
+
+	lead, err := s.db.GetLeadByID(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if lead == nil {
+		return nil, status.Error(codes.NotFound, "lead not found")
+	}
+
+	previousScore, previousStatus := lead.IntentScore, lead.Status
+	lead.Name = input.GetName()
+	lead.Email = input.GetEmail()
+	if input.Phone != nil {
+		lead.Phone = input.Phone
+	}
+	if input.Company != nil {
+		lead.Company = input.Company
+	}
+	if input.Position != nil {
+		lead.Position = input.Position
+	}
+	if input.Tags != nil {
+		lead.Tags = input.Tags
+	}
+	if input.Source != nil {
+		lead.Source = input.Source
+	}
+	if input.Notes != nil {
+		lead.Notes = input.Notes
+	}
+	if err := applyLeadInput(lead, input); err != nil {
+		return nil, err
+	}
+	if err := pipeline.Validate(previousStatus, lead.Status); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	now := time.Now()
+	lead.UpdatedAt = &now
+
+	updated, err := s.db.UpdateLead(ctx, lead)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if errors.Is(err, database.ErrDuplicateLead) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	if updated.IntentScore != previousScore {
+		if err := s.scoring.RecordManual(ctx, updated, previousScore); err != nil {
+			return nil, internalError(err)
+		}
+	}
+
+	if updated.Status != previousStatus {
+		user := auth.UserFromContext(ctx)
+		err = s.db.RecordLeadStatusChange(ctx, updated.ID, previousStatus, updated.Status, nil, &user.ID)
+		if err != nil {
+			return nil, internalError(err)
+		}
+		s.pipeline.StatusChanged(ctx, updated.ID, updated.Status)
+	}
+
+	s.events.Publish(events.TopicLeadUpdated, updated)
+
+	return leadToProto(updated), nil
+}
+
+func (s *leadService) DeleteLead(ctx context.Context, req *pb.DeleteLeadRequest) (*pb.DeleteLeadResponse, error) {
+	if err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.db.DeleteLead(ctx, req.GetId())
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return &pb.DeleteLeadResponse{Deleted: deleted}, nil
+}
+
+// applyLeadInput copies the validated enum and score fields from input.
+func applyLeadInput(lead *model.Lead, input *pb.LeadInput) error {
+	if input.Status != nil {
+		leadStatus := model.LeadStatus(input.GetStatus())
+		if !leadStatus.IsValid() {
+			return status.Errorf(codes.InvalidArgument, "invalid lead status %q", input.GetStatus())
+		}
+		lead.Status = leadStatus
+	}
+	if input.IntentScore != nil {
+		lead.IntentScore = input.GetIntentScore()
+	}
+	if input.Timezone != nil {
+		if err := sendwindow.CheckTimezone(input.GetTimezone()); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		lead.Timezone = input.Timezone
+	}
+	if input.Language != nil {
+		lead.Language = input.Language
+	}
+	if err := validation.Lead(lead); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	sendwindow.DetectTimezone(lead)
+	templates.DetectLanguage(lead)
+	return nil
+}
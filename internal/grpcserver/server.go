@@ -0,0 +1,70 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/grpcserver/pb"
+	"salesagency/internal/pipeline"
+	"salesagency/internal/scoring"
+)
+
+// New builds a gRPC server exposing the lead, client and campaign services on
+// top of the same database layer as the GraphQL API. Every call must carry a
+// bearer token in the "authorization" metadata key.
+func New(db *database.DB, broker *events.Broker, tokens *auth.TokenService, pipelineService *pipeline.Service, scoringEngine *scoring.Engine) *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(authInterceptor(tokens)))
+
+	pb.RegisterLeadServiceServer(server, &leadService{db: db, events: broker, pipeline: pipelineService, scoring: scoringEngine})
+	pb.RegisterClientServiceServer(server, &clientService{db: db})
+	pb.RegisterCampaignServiceServer(server, &campaignService{db: db})
+	reflection.Register(server)
+
+	return server
+}
+
+func authInterceptor(tokens *auth.TokenService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// Reflection is served on a stream and never reaches this interceptor.
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, auth.ErrUnauthenticated.Error())
+		}
+
+		user, err := tokens.Parse(strings.TrimPrefix(values[0], "Bearer "))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(auth.WithUser(ctx, user), req)
+	}
+}
+
+func requireRole(ctx context.Context, role auth.Role) error {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return status.Error(codes.Unauthenticated, auth.ErrUnauthenticated.Error())
+	}
+	if !user.HasRole(role) {
+		return status.Error(codes.PermissionDenied, auth.ErrForbidden.Error())
+	}
+	return nil
+}
+
+func internalError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,225 @@
+// Package identity manages the addresses agencies email leads from on behalf
+// of their clients. A sending identity's domain is proven by a TXT record
+// holding its verification token, and its DKIM key by the record at its
+// selector; mail is only sent from it once both are found in DNS.
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+)
+
+// VerificationWindow is how long an identity's records are polled for
+// before it is marked FAILED. verifySendingIdentity still checks it after.
+const VerificationWindow = 72 * time.Hour
+
+// DefaultDKIMSelector is the selector identities are created with unless
+// the email provider uses another.
+const DefaultDKIMSelector = "s1"
+
+const (
+	// verificationLabel is prefixed to the domain to name the TXT record
+	// holding the verification token.
+	verificationLabel = "_salesagency"
+	tokenPrefix       = "salesagency-verification="
+)
+
+var (
+	ErrInvalidAddress  = errors.New("from email must be a valid address")
+	ErrInvalidSelector = errors.New("DKIM selector must be a DNS label")
+	ErrWrongClient     = errors.New("the sending identity belongs to another client")
+)
+
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Resolver looks up TXT records. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type Service struct {
+	db  *database.DB
+	dns Resolver
+	now func() time.Time
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db, dns: net.DefaultResolver, now: time.Now}
+}
+
+// Create saves a new identity for the client, pending verification. The
+// domain is the from address's.
+func (s *Service) Create(ctx context.Context, identity *model.SendingIdentity) (*model.SendingIdentity, error) {
+	addr, err := mail.ParseAddress(identity.FromEmail)
+	if err != nil || addr.Name != "" {
+		return nil, ErrInvalidAddress
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at < 0 {
+		return nil, ErrInvalidAddress
+	}
+	identity.FromEmail = addr.Address
+	identity.Domain = strings.ToLower(addr.Address[at+1:])
+
+	identity.DkimSelector = strings.ToLower(strings.TrimSpace(identity.DkimSelector))
+	if identity.DkimSelector == "" {
+		identity.DkimSelector = DefaultDKIMSelector
+	}
+	if !dnsLabel.MatchString(identity.DkimSelector) {
+		return nil, ErrInvalidSelector
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("error generating verification token: %w", err)
+	}
+	identity.VerificationToken = hex.EncodeToString(token)
+	identity.DomainStatus = model.DomainCheckStatusPending
+	identity.DkimStatus = model.DomainCheckStatusPending
+	identity.CreatedAt = s.now()
+
+	return s.db.CreateSendingIdentity(ctx, identity)
+}
+
+// Records returns the DNS records the client publishes to verify the
+// identity. The DKIM key itself comes from the email provider.
+func Records(identity *model.SendingIdentity) []*model.DNSRecord {
+	return []*model.DNSRecord{
+		{Type: "TXT", Name: verificationName(identity), Value: tokenPrefix + identity.VerificationToken},
+		{Type: "TXT", Name: dkimName(identity), Value: "v=DKIM1; k=rsa; p=<public key from your email provider>"},
+	}
+}
+
+func verificationName(identity *model.SendingIdentity) string {
+	return verificationLabel + "." + identity.Domain
+}
+
+func dkimName(identity *model.SendingIdentity) string {
+	return identity.DkimSelector + "._domainkey." + identity.Domain
+}
+
+// CheckPending checks every identity still pending verification and returns
+// how many became verified.
+func (s *Service) CheckPending(ctx context.Context) (int, error) {
+	pending, err := s.db.GetPendingSendingIdentities(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	verified := 0
+	for _, identity := range pending {
+		checked, err := s.Check(ctx, identity)
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to check sending identity", "sending_identity_id", identity.ID, "error", err)
+			continue
+		}
+		if checked.Verified() {
+			verified++
+		}
+	}
+	return verified, nil
+}
+
+// Check looks up the identity's records and saves the outcome. A record not
+// found leaves its status PENDING within VerificationWindow and FAILED
+// after; a lookup that fails for another reason, such as a timeout, leaves
+// the status as it was.
+func (s *Service) Check(ctx context.Context, identity *model.SendingIdentity) (*model.SendingIdentity, error) {
+	now := s.now()
+	expired := now.Sub(identity.CreatedAt) > VerificationWindow
+
+	var problems []string
+	check := func(status *model.DomainCheckStatus, name string, match func(string) bool, missing string) {
+		found, err := s.hasTXT(ctx, name, match)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("looking up %s: %v", name, err))
+		case found:
+			*status = model.DomainCheckStatusVerified
+		default:
+			problems = append(problems, missing)
+			*status = model.DomainCheckStatusPending
+			if expired {
+				*status = model.DomainCheckStatusFailed
+			}
+		}
+	}
+
+	token := tokenPrefix + identity.VerificationToken
+	check(&identity.DomainStatus, verificationName(identity), func(record string) bool {
+		return strings.TrimSpace(record) == token
+	}, fmt.Sprintf("no TXT record at %s holds the verification token", verificationName(identity)))
+	check(&identity.DkimStatus, dkimName(identity), isDKIMKey,
+		fmt.Sprintf("no DKIM key is published at %s", dkimName(identity)))
+
+	identity.LastCheckedAt = &now
+	identity.CheckError = nil
+	if len(problems) > 0 {
+		problem := strings.Join(problems, "; ")
+		identity.CheckError = &problem
+	}
+	switch {
+	case !identity.Verified():
+		identity.VerifiedAt = nil
+	case identity.VerifiedAt == nil:
+		identity.VerifiedAt = &now
+	}
+
+	if err := s.db.SaveSendingIdentityCheck(ctx, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// hasTXT reports whether a TXT record at name matches. A name that does not
+// exist has no records rather than failing the lookup.
+func (s *Service) hasTXT(ctx context.Context, name string, match func(string) bool) (bool, error) {
+	records, err := s.dns.LookupTXT(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, record := range records {
+		if match(record) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isDKIMKey reports whether a TXT record publishes a DKIM public key: its
+// tags include v=DKIM1, if any version is given, and a non-empty p.
+func isDKIMKey(record string) bool {
+	key := false
+	for _, tag := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(name) {
+		case "v":
+			if value != "DKIM1" {
+				return false
+			}
+		case "p":
+			key = value != ""
+		}
+	}
+	return key
+}
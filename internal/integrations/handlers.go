@@ -0,0 +1,233 @@
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/sendwindow"
+	"salesagency/internal/templates"
+	"salesagency/internal/validation"
+)
+
+// me answers the connection test integrations run when a key is added,
+// labelling the connection with the key's name.
+func (a *api) me(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Context().Value(apiKeyContextKey{}).(*model.APIKey)
+	writeJSON(w, http.StatusOK, Me{
+		ID:          apiKey.ID,
+		Name:        apiKey.Name,
+		Role:        string(apiKey.Role),
+		Integration: string(*apiKey.Integration),
+	})
+}
+
+func (a *api) newLeads(w http.ResponseWriter, r *http.Request) {
+	since, err := sinceParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := pollLimit
+	leads, err := a.db.GetLeadsByFilter(r.Context(), &model.LeadFilterInput{CreatedAfter: since}, &limit, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]Lead, 0, len(leads))
+	for _, lead := range leads {
+		resp = append(resp, leadFromModel(lead))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *api) newReplies(w http.ResponseWriter, r *http.Request) {
+	since, err := sinceParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	replies, err := a.db.GetIntegrationReplies(r.Context(), since, pollLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]Reply, 0, len(replies))
+	for _, reply := range replies {
+		resp = append(resp, replyFromDB(reply))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// subscribe creates a hook for an instant trigger. Zapier calls it when a
+// zap is turned on.
+func (a *api) subscribe(w http.ResponseWriter, r *http.Request) {
+	var input HookInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !hookEvents[input.Event] {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown event %q; use %s or %s", input.Event, EventNewLead, EventLeadReplied))
+		return
+	}
+	target, err := url.Parse(input.TargetURL)
+	if err != nil || target.Scheme != "https" || target.Host == "" {
+		writeError(w, http.StatusBadRequest, errors.New("targetUrl must be an https URL"))
+		return
+	}
+
+	hook, err := a.db.CreateIntegrationHook(r.Context(), &database.IntegrationHook{
+		APIKeyID:  auth.UserFromContext(r.Context()).ID,
+		Event:     input.Event,
+		TargetURL: target.String(),
+		CreatedAt: a.now(),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, Hook{ID: hook.ID, Event: hook.Event, TargetURL: hook.TargetURL, CreatedAt: hook.CreatedAt})
+}
+
+// unsubscribe deletes a hook made with the same key. Zapier calls it when a
+// zap is turned off.
+func (a *api) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	deleted, err := a.db.DeleteIntegrationHook(r.Context(), chi.URLParam(r, "id"), auth.UserFromContext(r.Context()).ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, errHookNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createLead creates a lead or updates the one with the same email, so a
+// zap that runs twice does not duplicate the lead.
+func (a *api) createLead(w http.ResponseWriter, r *http.Request) {
+	var input LeadInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lead := &model.Lead{
+		Name:        input.Name,
+		Email:       input.Email,
+		Phone:       input.Phone,
+		Company:     input.Company,
+		Position:    input.Position,
+		Tags:        input.Tags,
+		Source:      input.Source,
+		Notes:       input.Notes,
+		Status:      model.LeadStatusNew,
+		IntentScore: 0.5,
+		CreatedAt:   a.now(),
+	}
+	if err := validation.Lead(lead); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sendwindow.DetectTimezone(lead)
+	templates.DetectLanguage(lead)
+
+	upserted, created, err := a.db.UpsertLead(r.Context(), lead, model.LeadMatchKeyEmail)
+	if errors.Is(err, database.ErrDuplicateLead) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		a.events.Publish(events.TopicLeadCreated, upserted)
+	} else {
+		a.events.Publish(events.TopicLeadUpdated, upserted)
+	}
+
+	writeJSON(w, status, leadFromModel(upserted))
+}
+
+func (a *api) addNote(w http.ResponseWriter, r *http.Request) {
+	var input NoteInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	note := strings.TrimSpace(input.Note)
+	switch {
+	case note == "":
+		writeError(w, http.StatusBadRequest, errors.New("note is required"))
+		return
+	case utf8.RuneCountInString(note) > validation.MaxTextLength:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("note must be at most %d characters", validation.MaxTextLength))
+		return
+	}
+
+	ctx := r.Context()
+	var leadID string
+	switch {
+	case input.LeadID != nil && *input.LeadID != "":
+		leadID = *input.LeadID
+	case input.Email != nil && *input.Email != "":
+		id, err := a.db.GetLeadIDByEmail(ctx, strings.TrimSpace(*input.Email))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		leadID = id
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("leadId or email is required"))
+		return
+	}
+	if leadID == "" {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+
+	found, err := a.db.AppendLeadNote(ctx, leadID, note, a.now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+
+	lead, err := a.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if lead == nil {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+
+	a.events.Publish(events.TopicLeadUpdated, lead)
+
+	writeJSON(w, http.StatusOK, leadFromModel(lead))
+}
@@ -0,0 +1,128 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/outbox"
+	"salesagency/internal/tenant"
+)
+
+// KindHook messages post an instant trigger's object to a hook.
+const KindHook = "integration_hook"
+
+type hookPayload struct {
+	HookID string          `json:"hookId"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// Run queues a delivery to every subscribed hook as leads are created and
+// reply, until ctx is cancelled. Deliveries go through the outbox, so they
+// are retried when the hook fails.
+func Run(ctx context.Context, db *database.DB, broker *events.Broker) {
+	created := broker.Subscribe(ctx, events.TopicLeadCreated)
+	replied := broker.Subscribe(ctx, events.TopicLeadReplied)
+	ctx = tenant.WithSystem(ctx)
+
+	for {
+		var event events.Event
+		var ok bool
+		select {
+		case event, ok = <-created:
+		case event, ok = <-replied:
+		}
+		if !ok {
+			return
+		}
+
+		// The instance the event happened on delivers it.
+		if event.Remote {
+			continue
+		}
+
+		var err error
+		switch payload := event.Payload.(type) {
+		case *model.Lead:
+			err = queueHooks(ctx, db, payload.ID, EventNewLead, leadFromModel(payload))
+		case *model.Interaction:
+			var reply *database.IntegrationReply
+			if reply, err = db.GetIntegrationReply(ctx, payload.ID); err == nil && reply != nil {
+				err = queueHooks(ctx, db, reply.LeadID, EventLeadReplied, replyFromDB(reply))
+			}
+		}
+		if err != nil {
+			slog.Warn("integrations: error queueing hook deliveries", "topic", event.Topic, "error", err)
+		}
+	}
+}
+
+// queueHooks queues body for each hook subscribed to event in the lead's
+// agency.
+func queueHooks(ctx context.Context, db *database.DB, leadID, event string, body interface{}) error {
+	hooks, err := db.GetIntegrationHooksForLead(ctx, leadID, event)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding hook body: %w", err)
+	}
+
+	for _, hook := range hooks {
+		payload, err := json.Marshal(hookPayload{HookID: hook.ID, URL: hook.TargetURL, Body: encoded})
+		if err != nil {
+			return fmt.Errorf("error encoding hook payload: %w", err)
+		}
+		msg := &model.OutboxMessage{Kind: KindHook, Payload: string(payload)}
+		if err := db.EnqueueOutbox(tenant.WithAgency(ctx, hook.AgencyID), msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HookHandler delivers KindHook messages. A 410 Gone response means the
+// subscriber has gone away, as in Zapier's REST hooks, and deletes the hook
+// instead of retrying. Any other response than 2xx is a failure.
+func HookHandler(db *database.DB, timeout time.Duration) outbox.Handler {
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload hookPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("error decoding hook payload: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, payload.URL, bytes.NewReader(payload.Body))
+		if err != nil {
+			return fmt.Errorf("error building hook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusGone:
+			return db.ExpireIntegrationHook(ctx, payload.HookID)
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+		}
+		return nil
+	}
+}
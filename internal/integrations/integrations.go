@@ -0,0 +1,156 @@
+// Package integrations serves the API no-code tools such as Zapier and Make
+// connect to under /api/integrations: polling triggers, instant triggers
+// delivered to subscribed webhooks, and actions. Payloads are flat JSON
+// objects with an id, and polling triggers list newest first, as Zapier
+// expects.
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+)
+
+// Path is where the handler New returns is mounted.
+const Path = "/api/integrations"
+
+const apiKeyHeader = "X-API-Key"
+
+// pollLimit is how many items a polling trigger returns. Zapier dedupes by
+// id, so a poll may repeat items it has seen.
+const pollLimit = 100
+
+var (
+	errLeadNotFound  = errors.New("lead not found")
+	errHookNotFound  = errors.New("hook not found")
+	errNotIntegrated = errors.New("only integration keys work on /api/integrations")
+)
+
+type apiKeyContextKey struct{}
+
+type api struct {
+	db     *database.DB
+	events *events.Broker
+	now    func() time.Time
+}
+
+// New returns the integrations handler. The schema describing its triggers
+// and actions is served unauthenticated at /schema.
+func New(db *database.DB, broker *events.Broker) http.Handler {
+	a := &api{db: db, events: broker, now: time.Now}
+
+	router := chi.NewRouter()
+	router.Get("/schema", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, schema)
+	})
+	router.Group(func(router chi.Router) {
+		router.Use(apiKeyAuth(db))
+		router.Get("/me", a.me)
+		router.Get("/new-leads", a.newLeads)
+		router.Get("/new-replies", a.newReplies)
+		router.Post("/hooks", requireRole(auth.RoleSalesRep, a.subscribe))
+		router.Delete("/hooks/{id}", requireRole(auth.RoleSalesRep, a.unsubscribe))
+		router.Post("/actions/create-lead", requireRole(auth.RoleSalesRep, a.createLead))
+		router.Post("/actions/add-note", requireRole(auth.RoleSalesRep, a.addNote))
+	})
+
+	return router
+}
+
+// apiKeyAuth resolves the X-API-Key header to a User carrying the key's role
+// and agency. Only keys made for an integration are accepted.
+func apiKeyAuth(db *database.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(apiKeyHeader)
+			if key == "" {
+				writeError(w, http.StatusUnauthorized, auth.ErrUnauthenticated)
+				return
+			}
+
+			apiKey, agencyID, err := db.AuthenticateAPIKey(r.Context(), auth.HashAPIKey(key))
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if apiKey == nil {
+				writeError(w, http.StatusUnauthorized, auth.ErrInvalidAPIKey)
+				return
+			}
+			if apiKey.Integration == nil {
+				writeError(w, http.StatusUnauthorized, errNotIntegrated)
+				return
+			}
+
+			user := &auth.User{
+				ID:       apiKey.ID,
+				Role:     auth.Role(apiKey.Role),
+				AgencyID: agencyID,
+			}
+			ctx := context.WithValue(auth.WithUser(r.Context(), user), apiKeyContextKey{}, apiKey)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func requireRole(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.UserFromContext(r.Context()).HasRole(role) {
+			writeError(w, http.StatusForbidden, auth.ErrForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error encoding integrations API response", "error", err)
+	}
+}
+
+// writeError reports err to the caller. Server errors are logged and replaced
+// with a generic message so database details do not leak to integrations.
+func writeError(w http.ResponseWriter, status int, err error) {
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		slog.Error("integrations API error", "error", err)
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.New("invalid request body: " + err.Error())
+	}
+	return nil
+}
+
+// sinceParam reads the optional since query parameter, an RFC 3339 time.
+func sinceParam(r *http.Request) (*time.Time, error) {
+	value := r.URL.Query().Get("since")
+	if value == "" {
+		return nil, nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("since must be an RFC 3339 time such as 2024-01-02T15:04:05Z")
+	}
+	return &since, nil
+}
@@ -0,0 +1,116 @@
+package integrations
+
+import "net/http"
+
+// field describes an input or output field the way Zapier's platform
+// declares them, so an app definition can be built from the schema as is.
+type field struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+	List     bool   `json:"list,omitempty"`
+	HelpText string `json:"helpText,omitempty"`
+}
+
+type trigger struct {
+	Key          string  `json:"key"`
+	Noun         string  `json:"noun"`
+	Label        string  `json:"label"`
+	PollURL      string  `json:"pollUrl"`
+	HookEvent    string  `json:"hookEvent"`
+	OutputFields []field `json:"outputFields"`
+}
+
+type action struct {
+	Key          string  `json:"key"`
+	Noun         string  `json:"noun"`
+	Label        string  `json:"label"`
+	Method       string  `json:"method"`
+	URL          string  `json:"url"`
+	InputFields  []field `json:"inputFields"`
+	OutputFields []field `json:"outputFields"`
+}
+
+var leadFields = []field{
+	{Key: "id", Label: "Lead ID", Type: "string"},
+	{Key: "name", Label: "Name", Type: "string"},
+	{Key: "email", Label: "Email", Type: "string"},
+	{Key: "phone", Label: "Phone", Type: "string"},
+	{Key: "company", Label: "Company", Type: "string"},
+	{Key: "position", Label: "Position", Type: "string"},
+	{Key: "status", Label: "Status", Type: "string"},
+	{Key: "intentScore", Label: "Intent Score", Type: "number"},
+	{Key: "tags", Label: "Tags", Type: "string", List: true},
+	{Key: "source", Label: "Source", Type: "string"},
+	{Key: "notes", Label: "Notes", Type: "text"},
+	{Key: "createdAt", Label: "Created At", Type: "datetime"},
+	{Key: "updatedAt", Label: "Updated At", Type: "datetime"},
+}
+
+// schema is served at /schema. Paths are relative to Path.
+var schema = struct {
+	Authentication interface{} `json:"authentication"`
+	Hooks          interface{} `json:"hooks"`
+	Triggers       []trigger   `json:"triggers"`
+	Actions        []action    `json:"actions"`
+}{
+	Authentication: map[string]string{
+		"type":    "custom",
+		"header":  apiKeyHeader,
+		"testUrl": "/me",
+		"label":   "{{name}}",
+	},
+	// Subscribing takes {"event", "targetUrl"} and returns the hook's id.
+	Hooks: map[string]string{
+		"subscribeUrl":   "/hooks",
+		"unsubscribeUrl": "/hooks/{id}",
+	},
+	Triggers: []trigger{
+		{
+			Key: "new_lead", Noun: "Lead", Label: "New Lead",
+			PollURL: "/new-leads", HookEvent: EventNewLead,
+			OutputFields: leadFields,
+		},
+		{
+			Key: "lead_replied", Noun: "Reply", Label: "Lead Replied",
+			PollURL: "/new-replies", HookEvent: EventLeadReplied,
+			OutputFields: []field{
+				{Key: "id", Label: "Reply ID", Type: "string"},
+				{Key: "leadId", Label: "Lead ID", Type: "string"},
+				{Key: "leadName", Label: "Lead Name", Type: "string"},
+				{Key: "leadEmail", Label: "Lead Email", Type: "string"},
+				{Key: "channel", Label: "Channel", Type: "string"},
+				{Key: "message", Label: "Message", Type: "text"},
+				{Key: "repliedAt", Label: "Replied At", Type: "datetime"},
+			},
+		},
+	},
+	Actions: []action{
+		{
+			Key: "create_lead", Noun: "Lead", Label: "Create or Update Lead",
+			Method: http.MethodPost, URL: "/actions/create-lead",
+			InputFields: []field{
+				{Key: "name", Label: "Name", Type: "string", Required: true},
+				{Key: "email", Label: "Email", Type: "string", Required: true, HelpText: "A lead with this email is updated instead of duplicated."},
+				{Key: "phone", Label: "Phone", Type: "string", HelpText: "With the country code, such as +14155550123."},
+				{Key: "company", Label: "Company", Type: "string"},
+				{Key: "position", Label: "Position", Type: "string"},
+				{Key: "tags", Label: "Tags", Type: "string", List: true},
+				{Key: "source", Label: "Source", Type: "string"},
+				{Key: "notes", Label: "Notes", Type: "text"},
+			},
+			OutputFields: leadFields,
+		},
+		{
+			Key: "add_note", Noun: "Note", Label: "Add Note to Lead",
+			Method: http.MethodPost, URL: "/actions/add-note",
+			InputFields: []field{
+				{Key: "leadId", Label: "Lead ID", Type: "string", HelpText: "Leave empty to find the lead by email."},
+				{Key: "email", Label: "Lead Email", Type: "string"},
+				{Key: "note", Label: "Note", Type: "text", Required: true},
+			},
+			OutputFields: leadFields,
+		},
+	},
+}
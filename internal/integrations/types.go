@@ -0,0 +1,117 @@
+package integrations
+
+import (
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+// Instant trigger events hooks subscribe to.
+const (
+	EventNewLead     = "new_lead"
+	EventLeadReplied = "lead_replied"
+)
+
+var hookEvents = map[string]bool{EventNewLead: true, EventLeadReplied: true}
+
+// Lead is the lead object triggers and actions return.
+type Lead struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Phone       *string    `json:"phone"`
+	Company     *string    `json:"company"`
+	Position    *string    `json:"position"`
+	Status      string     `json:"status"`
+	IntentScore float64    `json:"intentScore"`
+	Tags        []string   `json:"tags"`
+	Source      *string    `json:"source"`
+	Notes       *string    `json:"notes"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+// Reply is a lead's reply as triggers return it.
+type Reply struct {
+	ID        string    `json:"id"`
+	LeadID    string    `json:"leadId"`
+	LeadName  string    `json:"leadName"`
+	LeadEmail string    `json:"leadEmail"`
+	Channel   string    `json:"channel"`
+	Message   *string   `json:"message"`
+	RepliedAt time.Time `json:"repliedAt"`
+}
+
+// LeadInput creates a lead, or updates the lead with the same email.
+type LeadInput struct {
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	Phone    *string  `json:"phone,omitempty"`
+	Company  *string  `json:"company,omitempty"`
+	Position *string  `json:"position,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Source   *string  `json:"source,omitempty"`
+	Notes    *string  `json:"notes,omitempty"`
+}
+
+// NoteInput adds a note to the lead with the given id or, without one, the
+// given email.
+type NoteInput struct {
+	LeadID *string `json:"leadId,omitempty"`
+	Email  *string `json:"email,omitempty"`
+	Note   string  `json:"note"`
+}
+
+type HookInput struct {
+	Event     string `json:"event"`
+	TargetURL string `json:"targetUrl"`
+}
+
+type Hook struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	TargetURL string    `json:"targetUrl"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Me struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Role        string `json:"role"`
+	Integration string `json:"integration"`
+}
+
+func leadFromModel(lead *model.Lead) Lead {
+	tags := lead.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return Lead{
+		ID:          lead.ID,
+		Name:        lead.Name,
+		Email:       lead.Email,
+		Phone:       lead.Phone,
+		Company:     lead.Company,
+		Position:    lead.Position,
+		Status:      string(lead.Status),
+		IntentScore: lead.IntentScore,
+		Tags:        tags,
+		Source:      lead.Source,
+		Notes:       lead.Notes,
+		CreatedAt:   lead.CreatedAt,
+		UpdatedAt:   lead.UpdatedAt,
+	}
+}
+
+func replyFromDB(reply *database.IntegrationReply) Reply {
+	return Reply{
+		ID:        reply.ID,
+		LeadID:    reply.LeadID,
+		LeadName:  reply.LeadName,
+		LeadEmail: reply.LeadEmail,
+		Channel:   reply.Channel,
+		Message:   reply.Message,
+		RepliedAt: reply.RepliedAt,
+	}
+}
@@ -0,0 +1,279 @@
+// Package jobs runs heavy mutations in the background. A mutation queues a
+// job with its input and returns it at once; workers claim queued jobs from
+// the database and run them as the user who queued them, recording their
+// progress and result for the job query.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+)
+
+// staleAfter is how long past JobTimeout a job may stay RUNNING before it
+// is taken for abandoned by a worker that stopped.
+const staleAfter = 5 * time.Minute
+
+// Handler runs a job of one kind with the input it was queued with, and
+// returns the result to store as JSON.
+type Handler func(ctx context.Context, input json.RawMessage) (interface{}, error)
+
+type Options struct {
+	Workers      int
+	PollInterval time.Duration
+	JobTimeout   time.Duration
+	// Limits caps how many jobs of a kind each process runs at once, so one
+	// kind of job cannot hold every worker.
+	Limits map[model.JobKind]int
+}
+
+// Queue runs queued jobs on a fixed-size worker pool.
+type Queue struct {
+	db       *database.DB
+	opts     Options
+	handlers map[model.JobKind]Handler
+	jobs     chan *database.QueuedJob
+	wg       sync.WaitGroup
+
+	mu sync.Mutex
+	// busy counts the jobs claimed and not yet finished, and running the
+	// same by kind.
+	busy    int
+	running map[model.JobKind]int
+}
+
+func New(db *database.DB, opts Options) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.JobTimeout <= 0 {
+		opts.JobTimeout = 30 * time.Minute
+	}
+
+	return &Queue{
+		db:       db,
+		opts:     opts,
+		handlers: make(map[model.JobKind]Handler),
+		jobs:     make(chan *database.QueuedJob, opts.Workers),
+		running:  make(map[model.JobKind]int),
+	}
+}
+
+// Register sets the handler for jobs of the given kind. Handlers are
+// registered before Start.
+func (q *Queue) Register(kind model.JobKind, h Handler) {
+	q.handlers[kind] = h
+}
+
+// Enqueue queues a job of kind with input, marshalled as JSON, for the user
+// in ctx.
+func (q *Queue) Enqueue(ctx context.Context, kind model.JobKind, input interface{}) (*model.Job, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	if q.handlers[kind] == nil {
+		return nil, fmt.Errorf("no handler for job kind %s", kind)
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding job input: %w", err)
+	}
+
+	return q.db.CreateJob(ctx, &database.QueuedJob{
+		Job:       &model.Job{Kind: kind, CreatedByID: &user.ID, CreatedAt: time.Now()},
+		Input:     data,
+		Role:      string(user.Role),
+		ClientIDs: user.ClientIDs,
+	})
+}
+
+// Start launches the polling loop and workers. They stop when ctx is
+// cancelled; call Wait to block until in-flight jobs have finished.
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(ctx)
+	}
+
+	q.wg.Add(1)
+	go q.poll(ctx)
+}
+
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+func (q *Queue) poll(ctx context.Context) {
+	defer q.wg.Done()
+	defer close(q.jobs)
+
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) tick(ctx context.Context) {
+	stale := time.Now().Add(-q.opts.JobTimeout - staleAfter)
+	if failed, err := q.db.FailStaleJobs(ctx, stale, "job was interrupted"); err != nil {
+		slog.Error("jobs: error failing stale jobs", "error", err)
+	} else if failed > 0 {
+		slog.Warn("jobs: failed interrupted jobs", "count", failed)
+	}
+
+	for _, kind := range model.AllJobKind {
+		if q.handlers[kind] == nil {
+			continue
+		}
+		free := q.free(kind)
+		if free <= 0 {
+			continue
+		}
+
+		jobs, err := q.db.ClaimQueuedJobs(ctx, kind, free)
+		if err != nil {
+			slog.Error("jobs: error claiming queued jobs", "kind", kind, "error", err)
+			return
+		}
+		// The channel holds as many jobs as there are workers, and no more
+		// are claimed than are free, so this never blocks.
+		for _, job := range jobs {
+			q.mu.Lock()
+			q.busy++
+			q.running[kind]++
+			q.mu.Unlock()
+			q.jobs <- job
+		}
+	}
+}
+
+// free returns how many jobs of kind may be claimed now.
+func (q *Queue) free(kind model.JobKind) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	free := q.opts.Workers - q.busy
+	if limit, ok := q.opts.Limits[kind]; ok {
+		free = min(free, limit-q.running[kind])
+	}
+	return free
+}
+
+func (q *Queue) worker(ctx context.Context) {
+	defer q.wg.Done()
+
+	for job := range q.jobs {
+		if ctx.Err() != nil {
+			// Claimed during shutdown but never started.
+			q.release(job)
+		} else {
+			q.run(ctx, job)
+		}
+
+		q.mu.Lock()
+		q.busy--
+		q.running[job.Job.Kind]--
+		q.mu.Unlock()
+	}
+}
+
+func (q *Queue) run(ctx context.Context, queued *database.QueuedJob) {
+	job := queued.Job
+	user := &auth.User{
+		Role:      auth.Role(queued.Role),
+		AgencyID:  queued.AgencyID,
+		ClientIDs: queued.ClientIDs,
+	}
+	if job.CreatedByID != nil {
+		user.ID = *job.CreatedByID
+	}
+
+	p := &progress{db: q.db, job: job}
+	runCtx, cancel := context.WithTimeout(withProgress(auth.WithUser(ctx, user), p), q.opts.JobTimeout)
+	result, err := q.handle(runCtx, queued)
+	cancel()
+
+	q.finish(p, result, err)
+}
+
+// handle runs the job's handler, turning a panic into the job's error so it
+// does not take down the server.
+func (q *Queue) handle(ctx context.Context, queued *database.QueuedJob) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	h := q.handlers[queued.Job.Kind]
+	if h == nil {
+		return nil, fmt.Errorf("no handler for job kind %s", queued.Job.Kind)
+	}
+	return h(ctx, json.RawMessage(queued.Input))
+}
+
+func (q *Queue) finish(p *progress, result interface{}, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job := p.job
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(result); err != nil {
+			err = fmt.Errorf("error encoding job result: %w", err)
+		} else {
+			encoded := string(data)
+			job.Result = &encoded
+		}
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		message := err.Error()
+		job.Status = model.JobStatusFailed
+		job.Error = &message
+	} else {
+		job.Status = model.JobStatusSucceeded
+		if job.Total != nil {
+			job.Progress = *job.Total
+		}
+	}
+
+	// Use a fresh context so results are persisted even during shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := q.db.FinishJob(ctx, job); err != nil {
+		slog.Error("jobs: error finishing job", "job_id", job.ID, "error", err)
+	}
+}
+
+func (q *Queue) release(queued *database.QueuedJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := q.db.ReleaseJob(ctx, queued.Job.ID); err != nil {
+		slog.Error("jobs: error releasing job", "job_id", queued.Job.ID, "error", err)
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+type progress struct {
+	db *database.DB
+
+	mu  sync.Mutex
+	job *model.Job
+}
+
+type progressKey struct{}
+
+func withProgress(ctx context.Context, p *progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// Progress records that done of total items of the job being run in ctx are
+// done. It does nothing outside a job.
+func Progress(ctx context.Context, done, total int) {
+	p, _ := ctx.Value(progressKey{}).(*progress)
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.job.Progress = done
+	p.job.Total = &total
+	if err := p.db.SetJobProgress(ctx, p.job.ID, done, p.job.Total); err != nil {
+		slog.Error("jobs: error recording job progress", "job_id", p.job.ID, "error", err)
+	}
+}
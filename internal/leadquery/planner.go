@@ -0,0 +1,226 @@
+// Package leadquery answers natural-language questions about leads. The LLM
+// only proposes a lead filter; the filter is checked against an allowlist of
+// fields and values before it is run as an ordinary parameterized query.
+package leadquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/llm"
+)
+
+var (
+	ErrEmptyQuestion = errors.New("question is required")
+	ErrLongQuestion  = fmt.Errorf("question must be at most %d characters", maxQuestion)
+	// ErrUninterpretable is returned when the model's answer is not a filter
+	// the planner accepts.
+	ErrUninterpretable = errors.New("could not interpret the question")
+	// ErrUnsupported is returned, with the model's reason, for questions a
+	// lead filter cannot answer.
+	ErrUnsupported = errors.New("question cannot be answered with a lead filter")
+)
+
+const (
+	maxQuestion = 500
+	maxTags     = 20
+	maxValue    = 200
+	// vocabularySize caps how many known tags and sources are shown to the
+	// model.
+	vocabularySize = 100
+)
+
+type Planner struct {
+	db       *database.DB
+	provider llm.Provider
+	now      func() time.Time
+}
+
+func NewPlanner(db *database.DB, provider llm.Provider) *Planner {
+	return &Planner{db: db, provider: provider, now: time.Now}
+}
+
+// Plan translates question into a lead filter. The agency's tags and sources
+// are shown to the model so it can match them by name.
+func (p *Planner) Plan(ctx context.Context, question string) (*model.LeadFilterInput, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, ErrEmptyQuestion
+	}
+	if len([]rune(question)) > maxQuestion {
+		return nil, ErrLongQuestion
+	}
+
+	tags, err := p.db.GetLeadTags(ctx, vocabularySize)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := p.db.GetLeadSources(ctx, vocabularySize)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := p.provider.Complete(ctx, &llm.Request{
+		System:   systemPrompt,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: buildPrompt(question, p.now().UTC(), tags, sources)}},
+		JSON:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error planning lead query: %w", err)
+	}
+
+	return parsePlan(reply)
+}
+
+// plan is every field the model may set. Anything else in its answer is
+// rejected.
+type plan struct {
+	Status            []string `json:"status"`
+	MinIntentScore    *float64 `json:"minIntentScore"`
+	Tags              []string `json:"tags"`
+	ExcludeTags       []string `json:"excludeTags"`
+	Source            *string  `json:"source"`
+	CompanyContains   *string  `json:"companyContains"`
+	Text              *string  `json:"text"`
+	LastContactAfter  *string  `json:"lastContactAfter"`
+	LastContactBefore *string  `json:"lastContactBefore"`
+	CreatedAfter      *string  `json:"createdAfter"`
+	CreatedBefore     *string  `json:"createdBefore"`
+	Replied           *bool    `json:"replied"`
+	Unsupported       *string  `json:"unsupported"`
+}
+
+func parsePlan(reply string) (*model.LeadFilterInput, error) {
+	// Some models wrap the object in prose or a code fence.
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, ErrUninterpretable
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(reply[start : end+1])))
+	decoder.DisallowUnknownFields()
+	var p plan
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUninterpretable, err)
+	}
+
+	if p.Unsupported != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, excerpt(*p.Unsupported))
+	}
+
+	filter := &model.LeadFilterInput{}
+	for _, s := range p.Status {
+		status := model.LeadStatus(strings.ToUpper(strings.TrimSpace(s)))
+		if !status.IsValid() {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrUninterpretable, excerpt(s))
+		}
+		filter.Status = append(filter.Status, status)
+	}
+
+	if p.MinIntentScore != nil {
+		if *p.MinIntentScore < 0 || *p.MinIntentScore > 1 {
+			return nil, fmt.Errorf("%w: intent score must be between 0 and 1", ErrUninterpretable)
+		}
+		filter.MinIntentScore = p.MinIntentScore
+	}
+
+	var err error
+	if filter.Tags, err = parseTags(p.Tags); err != nil {
+		return nil, err
+	}
+	if filter.ExcludeTags, err = parseTags(p.ExcludeTags); err != nil {
+		return nil, err
+	}
+
+	filter.Source = optional(p.Source)
+	filter.CompanyContains = optional(p.CompanyContains)
+	filter.Text = optional(p.Text)
+	filter.Replied = p.Replied
+
+	if filter.LastContactAfter, err = parseDay(p.LastContactAfter, false); err != nil {
+		return nil, err
+	}
+	if filter.LastContactBefore, err = parseDay(p.LastContactBefore, true); err != nil {
+		return nil, err
+	}
+	if filter.CreatedAfter, err = parseDay(p.CreatedAfter, false); err != nil {
+		return nil, err
+	}
+	if filter.CreatedBefore, err = parseDay(p.CreatedBefore, true); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+func parseTags(values []string) ([]string, error) {
+	if len(values) > maxTags {
+		return nil, fmt.Errorf("%w: too many tags", ErrUninterpretable)
+	}
+
+	var tags []string
+	for _, tag := range values {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, truncate(tag))
+		}
+	}
+	return tags, nil
+}
+
+// parseDay reads a YYYY-MM-DD day, or an RFC 3339 time, in UTC. A day is
+// read as its first instant, or as its last when end is set, so that both
+// bounds include the day.
+func parseDay(value *string, end bool) (*time.Time, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil, nil
+	}
+	s := strings.TrimSpace(*value)
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date %q", ErrUninterpretable, excerpt(s))
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func optional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	s := strings.TrimSpace(*value)
+	if s == "" {
+		return nil
+	}
+	s = truncate(s)
+	return &s
+}
+
+func truncate(s string) string {
+	if runes := []rune(s); len(runes) > maxValue {
+		return string(runes[:maxValue])
+	}
+	return s
+}
+
+// excerpt bounds model text quoted in errors.
+func excerpt(s string) string {
+	const max = 100
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max]) + "…"
+	}
+	return s
+}
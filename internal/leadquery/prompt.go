@@ -0,0 +1,37 @@
+package leadquery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const systemPrompt = `You turn questions about an agency's sales leads into a lead filter.
+Respond with a single JSON object and nothing else. It may only have these fields, all optional:
+- "status": array of pipeline stages, from NEW, CONTACTED, ENGAGED, QUALIFIED, MEETING, PROPOSAL, NEGOTIATION, WON, LOST and DORMANT.
+- "minIntentScore": number from 0 to 1, how likely the lead is to buy. High intent is 0.7 or more.
+- "tags": array of tags. A lead matches when it has any of them.
+- "excludeTags": array of tags. A lead matches when it has none of them.
+- "source": where the lead came from, matched exactly.
+- "companyContains": part of the lead's company name.
+- "text": words to search for in the lead's name, email, company, position and notes.
+- "lastContactAfter" and "lastContactBefore": the first and last day the lead was last contacted on, as YYYY-MM-DD.
+- "createdAfter" and "createdBefore": the first and last day the lead was added on, as YYYY-MM-DD.
+- "replied": true for leads that have replied to outreach, false for leads that never have.
+Leave out anything the question does not ask for. Prefer a known tag or source to text when one matches.
+If the question cannot be answered with these fields, respond {"unsupported": "<short reason>"}.`
+
+func buildPrompt(question string, now time.Time, tags, sources []string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Today is %s.\n", now.Format("Monday 2006-01-02"))
+	if len(tags) > 0 {
+		fmt.Fprintf(&b, "Known tags: %s\n", strings.Join(tags, ", "))
+	}
+	if len(sources) > 0 {
+		fmt.Fprintf(&b, "Known sources: %s\n", strings.Join(sources, ", "))
+	}
+	fmt.Fprintf(&b, "\nQuestion: %s\n", question)
+
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicEndpoint     = "https://api.anthropic.com/v1/messages"
+	anthropicVersion      = "2023-06-01"
+	defaultAnthropicModel = "claude-3-5-haiku-latest"
+)
+
+type AnthropicConfig struct {
+	APIKey string
+	Model  string
+}
+
+type Anthropic struct {
+	cfg    AnthropicConfig
+	client *http.Client
+}
+
+func NewAnthropic(cfg AnthropicConfig, client *http.Client) (*Anthropic, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("ANTHROPIC_API_KEY is required for the anthropic provider")
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultAnthropicModel
+	}
+	return &Anthropic{cfg: cfg, client: client}, nil
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func (p *Anthropic) Complete(ctx context.Context, req *Request) (string, error) {
+	payload := anthropicRequest{
+		Model:       p.cfg.Model,
+		System:      req.System,
+		MaxTokens:   maxTokens(req),
+		Temperature: temperature(req),
+	}
+	for _, msg := range req.Messages {
+		payload.Messages = append(payload.Messages, anthropicMessage{Role: string(msg.Role), Content: msg.Content})
+	}
+
+	headers := map[string]string{
+		"x-api-key":         p.cfg.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	var resp anthropicResponse
+	if err := postJSON(ctx, p.client, anthropicEndpoint, headers, payload, &resp); err != nil {
+		return "", fmt.Errorf("anthropic: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("anthropic: response contained no text")
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,148 @@
+// Package llm talks to large language model providers behind a common
+// chat-completion interface.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role
+	Content string
+}
+
+type Request struct {
+	System      string
+	Messages    []Message
+	MaxTokens   int
+	Temperature float64
+	// JSON asks the provider to return a single JSON object when it supports
+	// a structured output mode.
+	JSON bool
+}
+
+// Provider produces the next assistant message for a conversation.
+type Provider interface {
+	Complete(ctx context.Context, req *Request) (string, error)
+}
+
+var ErrNotConfigured = errors.New("llm provider is not configured")
+
+const (
+	defaultMaxTokens = 1024
+	defaultTimeout   = 60 * time.Second
+)
+
+// Config selects and configures the LLM provider.
+type Config struct {
+	// Provider is "openai", "anthropic", "ollama" or empty, which yields a
+	// provider that always fails with ErrNotConfigured.
+	Provider string
+	// Model overrides the provider's default model.
+	Model           string
+	Timeout         time.Duration
+	OpenAIAPIKey    string
+	OpenAIBaseURL   string
+	AnthropicAPIKey string
+	OllamaURL       string
+}
+
+// New builds the provider selected by cfg.Provider.
+func New(cfg Config) (Provider, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider {
+	case "":
+		return disabledProvider{}, nil
+	case "openai":
+		return NewOpenAI(OpenAIConfig{
+			APIKey:  cfg.OpenAIAPIKey,
+			BaseURL: cfg.OpenAIBaseURL,
+			Model:   cfg.Model,
+		}, client)
+	case "anthropic":
+		return NewAnthropic(AnthropicConfig{
+			APIKey: cfg.AnthropicAPIKey,
+			Model:  cfg.Model,
+		}, client)
+	case "ollama":
+		return NewOllama(OllamaConfig{
+			BaseURL: cfg.OllamaURL,
+			Model:   cfg.Model,
+		}, client), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+type disabledProvider struct{}
+
+func (disabledProvider) Complete(ctx context.Context, req *Request) (string, error) {
+	return "", ErrNotConfigured
+}
+
+func maxTokens(req *Request) int {
+	if req.MaxTokens > 0 {
+		return req.MaxTokens
+	}
+	return defaultMaxTokens
+}
+
+// postJSON sends body to url and decodes a successful response into out.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// temperature leaves the provider default in place when unset.
+func temperature(req *Request) *float64 {
+	if req.Temperature == 0 {
+		return nil
+	}
+	return &req.Temperature
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "llama3.1"
+)
+
+type OllamaConfig struct {
+	BaseURL string
+	Model   string
+}
+
+// Ollama runs models on a local Ollama server.
+type Ollama struct {
+	cfg    OllamaConfig
+	client *http.Client
+}
+
+func NewOllama(cfg OllamaConfig, client *http.Client) *Ollama {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOllamaURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOllamaModel
+	}
+	return &Ollama{cfg: cfg, client: client}
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	NumPredict  int      `json:"num_predict"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+}
+
+func (p *Ollama) Complete(ctx context.Context, req *Request) (string, error) {
+	payload := ollamaRequest{
+		Model: p.cfg.Model,
+		Options: ollamaOptions{
+			NumPredict:  maxTokens(req),
+			Temperature: temperature(req),
+		},
+	}
+	if req.System != "" {
+		payload.Messages = append(payload.Messages, ollamaMessage{Role: "system", Content: req.System})
+	}
+	for _, msg := range req.Messages {
+		payload.Messages = append(payload.Messages, ollamaMessage{Role: string(msg.Role), Content: msg.Content})
+	}
+	if req.JSON {
+		payload.Format = "json"
+	}
+
+	url := strings.TrimSuffix(p.cfg.BaseURL, "/") + "/api/chat"
+
+	var resp ollamaResponse
+	if err := postJSON(ctx, p.client, url, nil, payload, &resp); err != nil {
+		return "", fmt.Errorf("ollama: %w", err)
+	}
+
+	return resp.Message.Content, nil
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+type OpenAIConfig struct {
+	APIKey string
+	// BaseURL may point at any OpenAI-compatible endpoint.
+	BaseURL string
+	Model   string
+}
+
+type OpenAI struct {
+	cfg    OpenAIConfig
+	client *http.Client
+}
+
+func NewOpenAI(cfg OpenAIConfig, client *http.Client) (*OpenAI, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("OPENAI_API_KEY is required for the openai provider")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOpenAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOpenAIModel
+	}
+	return &OpenAI{cfg: cfg, client: client}, nil
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	MaxTokens      int                   `json:"max_tokens"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAI) Complete(ctx context.Context, req *Request) (string, error) {
+	payload := openAIRequest{
+		Model:       p.cfg.Model,
+		MaxTokens:   maxTokens(req),
+		Temperature: temperature(req),
+	}
+	if req.System != "" {
+		payload.Messages = append(payload.Messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, msg := range req.Messages {
+		payload.Messages = append(payload.Messages, openAIMessage{Role: string(msg.Role), Content: msg.Content})
+	}
+	if req.JSON {
+		payload.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + p.cfg.APIKey}
+	url := strings.TrimSuffix(p.cfg.BaseURL, "/") + "/chat/completions"
+
+	var resp openAIResponse
+	if err := postJSON(ctx, p.client, url, headers, payload, &resp); err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("openai: response contained no choices")
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"salesagency/internal/auth"
+)
+
+// GraphQL tags the logger passed to resolvers with the operation name, type
+// and user, and logs every query and mutation with its duration and errors.
+// Operations with deferred fragments are logged once the last part is sent,
+// or on the first part with errors. Subscriptions are logged when they start.
+type GraphQL struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = GraphQL{}
+
+func (GraphQL) ExtensionName() string {
+	return "Logging"
+}
+
+func (GraphQL) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (GraphQL) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+
+	args := []any{"operation", oc.OperationName}
+	if oc.Operation != nil {
+		args = append(args, "operation_type", string(oc.Operation.Operation))
+	}
+	if user := auth.UserFromContext(ctx); user != nil {
+		args = append(args, "user_id", user.ID)
+	}
+	ctx = With(ctx, args...)
+
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		FromContext(ctx).Info("graphql subscription started")
+	}
+	return next(ctx)
+}
+
+func (GraphQL) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation == ast.Subscription {
+		return resp
+	}
+
+	if resp != nil && resp.HasNext != nil && *resp.HasNext && len(resp.Errors) == 0 {
+		return resp
+	}
+
+	logger := FromContext(ctx)
+	duration := time.Since(oc.Stats.OperationStart).Milliseconds()
+	if resp == nil || len(resp.Errors) == 0 {
+		logger.Info("graphql operation", "duration_ms", duration)
+		return resp
+	}
+
+	errors := make([]string, len(resp.Errors))
+	for i, err := range resp.Errors {
+		errors[i] = err.Error()
+	}
+	logger.Log(ctx, slog.LevelError, "graphql operation failed", "duration_ms", duration, "errors", errors)
+	return resp
+}
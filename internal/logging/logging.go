@@ -0,0 +1,76 @@
+// Package logging configures the structured logger and carries
+// request-scoped loggers through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+var level = new(slog.LevelVar)
+
+// Config sets up logging.
+type Config struct {
+	// Level is the initial minimum level.
+	Level slog.Level
+	// Format is "json" (the default) or "text".
+	Format string
+}
+
+// Setup makes a JSON or text logger the default for both log/slog and the
+// standard log package.
+func Setup(cfg Config) error {
+	level.Set(cfg.Level)
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch format := strings.ToLower(cfg.Format); format {
+	case "", "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// Level returns the current minimum level.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum level of every logger at runtime.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, err
+	}
+	return l, nil
+}
+
+type contextKey struct{}
+
+// FromContext returns the logger attached to ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With attaches a logger carrying args to ctx, on top of any attributes
+// already attached.
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).With(args...))
+}
@@ -0,0 +1,114 @@
+// Package masking hides personal data from roles that should not see it in
+// full. A Policy names the fields to mask, how, and for which roles; the
+// GraphQL server applies it to every field it resolves.
+package masking
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"salesagency/internal/auth"
+)
+
+// Kind is how a value is masked.
+type Kind string
+
+const (
+	// KindEmail keeps the first character and the domain: j***@acme.com.
+	KindEmail Kind = "EMAIL"
+	// KindPhone keeps the last visibleDigits digits: ***4567.
+	KindPhone Kind = "PHONE"
+	// KindFull hides the whole value.
+	KindFull Kind = "FULL"
+)
+
+const (
+	hidden        = "***"
+	visibleDigits = 4
+)
+
+// Rule masks a field for the roles listed.
+type Rule struct {
+	Mask  Kind        `json:"mask"`
+	Roles []auth.Role `json:"roles"`
+}
+
+// Policy maps fields, named "Type.field" as in the GraphQL schema, to how
+// they are masked.
+type Policy map[string]Rule
+
+// DefaultPolicy masks leads' contact details for client viewers.
+var DefaultPolicy = Policy{
+	"Lead.email": {Mask: KindEmail, Roles: []auth.Role{auth.RoleClientViewer}},
+	"Lead.phone": {Mask: KindPhone, Roles: []auth.Role{auth.RoleClientViewer}},
+}
+
+// ParsePolicy reads a policy from JSON such as
+// {"Lead.email": {"mask": "EMAIL", "roles": ["CLIENT_VIEWER"]}}. An empty
+// string yields DefaultPolicy.
+func ParsePolicy(s string) (Policy, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultPolicy, nil
+	}
+
+	var policy Policy
+	if err := json.Unmarshal([]byte(s), &policy); err != nil {
+		return nil, fmt.Errorf("invalid masking policy: %w", err)
+	}
+	for field, rule := range policy {
+		if typ, name, ok := strings.Cut(field, "."); !ok || typ == "" || name == "" {
+			return nil, fmt.Errorf("invalid masking policy: field %q is not Type.field", field)
+		}
+		switch rule.Mask {
+		case KindEmail, KindPhone, KindFull:
+		default:
+			return nil, fmt.Errorf("invalid masking policy: %s has unknown mask %q", field, rule.Mask)
+		}
+	}
+	return policy, nil
+}
+
+// Rule returns how field of typ is masked for role, if it is.
+func (p Policy) Rule(typ, field string, role auth.Role) (Kind, bool) {
+	rule, ok := p[typ+"."+field]
+	if !ok {
+		return "", false
+	}
+	for _, r := range rule.Roles {
+		if r == role {
+			return rule.Mask, true
+		}
+	}
+	return "", false
+}
+
+// Mask returns value masked as kind. Empty values are left empty.
+func Mask(kind Kind, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	switch kind {
+	case KindEmail:
+		at := strings.LastIndex(value, "@")
+		if at <= 0 {
+			return hidden
+		}
+		first := []rune(value[:at])[0]
+		return string(first) + hidden + value[at:]
+	case KindPhone:
+		digits := make([]rune, 0, len(value))
+		for _, r := range value {
+			if r >= '0' && r <= '9' {
+				digits = append(digits, r)
+			}
+		}
+		if len(digits) <= visibleDigits {
+			return hidden
+		}
+		return hidden + string(digits[len(digits)-visibleDigits:])
+	default:
+		return hidden
+	}
+}
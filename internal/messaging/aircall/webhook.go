@@ -0,0 +1,69 @@
+// Package aircall receives call events from Aircall webhooks.
+package aircall
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"salesagency/internal/logging"
+)
+
+const maxWebhookBody = 1 << 20
+
+// Call is the call resource Aircall sends with call events.
+type Call struct {
+	ID               int64  `json:"id"`
+	Direction        string `json:"direction"` // inbound or outbound
+	Status           string `json:"status"`    // initial, answered or done
+	Duration         int    `json:"duration"`  // seconds, including ringing
+	AnsweredAt       *int64 `json:"answered_at"`
+	MissedCallReason string `json:"missed_call_reason"`
+	RawDigits        string `json:"raw_digits"` // the other party's number
+	Recording        string `json:"recording"`
+	Voicemail        string `json:"voicemail"`
+}
+
+type event struct {
+	Event string `json:"event"`
+	Token string `json:"token"`
+	Data  Call   `json:"data"`
+}
+
+// EventHandler should return nil for calls it cannot match to a lead.
+type EventHandler interface {
+	HandleCallEnded(ctx context.Context, call *Call) error
+}
+
+// WebhookHandler checks the token Aircall includes in every event against
+// the webhook's token and passes call.ended events to events. Other events
+// are acknowledged and ignored.
+func WebhookHandler(token string, events EventHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var e event
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBody)).Decode(&e); err != nil {
+			http.Error(w, "invalid event body", http.StatusBadRequest)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(e.Token), []byte(token)) != 1 {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+
+		if e.Event == "call.ended" {
+			if err := events.HandleCallEnded(r.Context(), &e.Data); err != nil {
+				logging.FromContext(r.Context()).Error("error handling aircall webhook", "call_id", e.Data.ID, "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,85 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Message struct {
+	// MessageID is generated by the sender when empty.
+	MessageID string
+	// From and FromName replace the configured sender when From is set.
+	From     string
+	FromName string
+	To       string
+	ToName   string
+	Subject  string
+	Body     string
+	// HTML is an optional HTML version of Body.
+	HTML        string
+	Attachments []Attachment
+	// Headers are extra message headers such as List-Unsubscribe.
+	Headers map[string]string
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type Result struct {
+	ProviderMessageID string
+}
+
+// Sender delivers a single email through a provider.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) (*Result, error)
+}
+
+var ErrNotConfigured = errors.New("email provider is not configured")
+
+// Config selects and configures the email provider.
+type Config struct {
+	// Provider is "smtp", "sendgrid" or empty, which yields a sender that
+	// always fails with ErrNotConfigured so the server can still start
+	// without email.
+	Provider       string
+	From           string
+	FromName       string
+	SMTP           SMTPConfig
+	SendGridAPIKey string
+}
+
+// New builds the sender selected by cfg.Provider. From and FromName apply to
+// every provider, for messages that do not set their own.
+func New(cfg Config) (Sender, error) {
+	switch cfg.Provider {
+	case "":
+		return disabledSender{}, nil
+	case "smtp":
+		smtp := cfg.SMTP
+		smtp.From, smtp.FromName = cfg.From, cfg.FromName
+		return NewSMTPSender(smtp)
+	case "sendgrid":
+		return NewSendGridSender(cfg.SendGridAPIKey, cfg.From, cfg.FromName)
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+type disabledSender struct{}
+
+func (disabledSender) Send(ctx context.Context, msg *Message) (*Result, error) {
+	return nil, ErrNotConfigured
+}
+
+// sender returns the address msg is sent from: its own, or else from and
+// fromName.
+func sender(msg *Message, from, fromName string) (string, string) {
+	if msg.From != "" {
+		return msg.From, msg.FromName
+	}
+	return from, fromName
+}
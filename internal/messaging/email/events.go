@@ -0,0 +1,123 @@
+package email
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"salesagency/internal/logging"
+)
+
+// DeliveryEventType is what happened to an email after the provider
+// accepted it.
+type DeliveryEventType string
+
+const (
+	// EventDelivered means the recipient's server accepted the email.
+	EventDelivered DeliveryEventType = "delivered"
+	// EventBounced means the recipient's server rejected the email.
+	EventBounced DeliveryEventType = "bounced"
+	// EventDropped means the provider did not attempt delivery, for
+	// example because the address is on its own suppression list.
+	EventDropped DeliveryEventType = "dropped"
+	// EventComplained means the recipient reported the email as spam.
+	EventComplained DeliveryEventType = "complained"
+)
+
+// DeliveryEvent is a delivery, bounce, drop or spam complaint reported
+// through the SendGrid Event Webhook.
+type DeliveryEvent struct {
+	Type DeliveryEventType
+	// MessageID is the Message-ID the email was sent with; it is empty when
+	// the provider did not report it.
+	MessageID string
+	Email     string
+	Reason    string
+	// Permanent is set for bounces that will recur, such as unknown
+	// addresses, as opposed to blocks that may clear.
+	Permanent bool
+}
+
+// DeliveryEventHandler should return nil for events it cannot match to an
+// email; errors make SendGrid retry the whole batch.
+type DeliveryEventHandler interface {
+	HandleDeliveryEvents(ctx context.Context, events []*DeliveryEvent) error
+}
+
+const maxEventsSize = 8 << 20
+
+type sendGridEvent struct {
+	Event      string `json:"event"`
+	Email      string `json:"email"`
+	SMTPID     string `json:"smtp-id"`
+	Reason     string `json:"reason"`
+	BounceType string `json:"type"`
+}
+
+// EventWebhookHandler accepts SendGrid Event Webhook posts. Events other than
+// deliveries, bounces, drops and spam reports are ignored. As with
+// InboundWebhookHandler, the webhook URL must carry token as ?token=, and
+// every request is refused when token is empty.
+func EventWebhookHandler(handler DeliveryEventHandler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) != 1 {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+
+		var raw []sendGridEvent
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventsSize)).Decode(&raw); err != nil {
+			http.Error(w, "invalid event body", http.StatusBadRequest)
+			return
+		}
+
+		var events []*DeliveryEvent
+		for _, e := range raw {
+			if event := parseEvent(e); event != nil {
+				events = append(events, event)
+			}
+		}
+
+		if len(events) > 0 {
+			if err := handler.HandleDeliveryEvents(r.Context(), events); err != nil {
+				logging.FromContext(r.Context()).Error("error handling email delivery events", "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func parseEvent(e sendGridEvent) *DeliveryEvent {
+	event := &DeliveryEvent{
+		MessageID: strings.TrimSpace(e.SMTPID),
+		Email:     strings.TrimSpace(e.Email),
+		Reason:    e.Reason,
+	}
+
+	switch e.Event {
+	case "delivered":
+		event.Type = EventDelivered
+	case "bounce":
+		event.Type = EventBounced
+		// SendGrid reports blocks, which are often temporary, as bounces
+		// of type "blocked".
+		event.Permanent = e.BounceType != "blocked"
+	case "dropped":
+		event.Type = EventDropped
+	case "spamreport":
+		event.Type = EventComplained
+	default:
+		return nil
+	}
+
+	return event
+}
@@ -0,0 +1,131 @@
+package email
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"salesagency/internal/logging"
+)
+
+// InboundEmail is a message received through the SendGrid Inbound Parse
+// webhook.
+type InboundEmail struct {
+	MessageID string
+	From      string
+	FromName  string
+	Subject   string
+	Text      string
+	// InReplyTo and References hold the Message-IDs of earlier messages in
+	// the thread, nearest first.
+	InReplyTo  string
+	References []string
+}
+
+// InboundHandler should return nil for messages it cannot match to a lead;
+// errors make SendGrid retry the delivery.
+type InboundHandler interface {
+	HandleInbound(ctx context.Context, msg *InboundEmail) error
+}
+
+const maxInboundSize = 32 << 20
+
+// InboundWebhookHandler accepts SendGrid Inbound Parse posts. Inbound Parse
+// does not sign requests, so the webhook URL must carry token as ?token=.
+// Every request is refused when token is empty.
+func InboundWebhookHandler(handler InboundHandler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) != 1 {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+		if err := r.ParseMultipartForm(maxInboundSize); err != nil {
+			http.Error(w, "invalid form body", http.StatusBadRequest)
+			return
+		}
+
+		msg, err := parseInbound(r)
+		if err != nil {
+			http.Error(w, "invalid sender", http.StatusBadRequest)
+			return
+		}
+
+		if err := handler.HandleInbound(r.Context(), msg); err != nil {
+			logging.FromContext(r.Context()).Error("error handling inbound email", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func parseInbound(r *http.Request) (*InboundEmail, error) {
+	from, err := mail.ParseAddress(r.FormValue("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &InboundEmail{
+		From:     from.Address,
+		FromName: from.Name,
+		Subject:  r.FormValue("subject"),
+		Text:     r.FormValue("text"),
+	}
+	if msg.Text == "" {
+		msg.Text = stripHTML(r.FormValue("html"))
+	}
+
+	// The raw headers arrive as one field; an empty body is enough for
+	// net/mail to parse them.
+	if raw := r.FormValue("headers"); raw != "" {
+		parsed, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(raw, "\r\n") + "\r\n\r\n"))
+		if err == nil {
+			msg.MessageID = strings.TrimSpace(parsed.Header.Get("Message-ID"))
+			msg.InReplyTo = strings.TrimSpace(parsed.Header.Get("In-Reply-To"))
+			refs := strings.Fields(parsed.Header.Get("References"))
+			for i := len(refs) - 1; i >= 0; i-- {
+				msg.References = append(msg.References, refs[i])
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+var (
+	htmlTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+	quoteHeading = regexp.MustCompile(`(?m)^On .+wrote:\s*$`)
+)
+
+func stripHTML(html string) string {
+	return strings.TrimSpace(htmlTag.ReplaceAllString(html, ""))
+}
+
+// ReplyText returns the new part of a reply, dropping the quoted original
+// and anything after a "On ... wrote:" line or an Outlook separator.
+func ReplyText(text string) string {
+	if loc := quoteHeading.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+	if i := strings.Index(text, "-----Original Message-----"); i >= 0 {
+		text = text[:i]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
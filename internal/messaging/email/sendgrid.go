@@ -0,0 +1,130 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"salesagency/internal/messaging"
+)
+
+const sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+type SendGridSender struct {
+	apiKey   string
+	from     string
+	fromName string
+	client   *http.Client
+}
+
+func NewSendGridSender(apiKey, from, fromName string) (*SendGridSender, error) {
+	if apiKey == "" || from == "" {
+		return nil, errors.New("SENDGRID_API_KEY and EMAIL_FROM are required for the sendgrid provider")
+	}
+	return &SendGridSender{
+		apiKey:   apiKey,
+		from:     from,
+		fromName: fromName,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content  string `json:"content"`
+	Type     string `json:"type"`
+	Filename string `json:"filename"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+func (s *SendGridSender) Send(ctx context.Context, msg *Message) (*Result, error) {
+	// Setting our own Message-ID lets replies be threaded through their
+	// In-Reply-To header, the same as with SMTP.
+	from, fromName := sender(msg, s.from, s.fromName)
+
+	messageID := msg.MessageID
+	if messageID == "" {
+		var err error
+		if messageID, err = NewMessageID(from); err != nil {
+			return nil, err
+		}
+	}
+
+	payload := sendGridRequest{
+		Personalizations: []sendGridPersonalization{
+			{To: []sendGridAddress{{Email: msg.To, Name: msg.ToName}}},
+		},
+		From:    sendGridAddress{Email: from, Name: fromName},
+		Subject: msg.Subject,
+		Content: []sendGridContent{{Type: "text/plain", Value: msg.Body}},
+		Headers: map[string]string{"Message-ID": messageID},
+	}
+	if msg.HTML != "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	for name, value := range msg.Headers {
+		payload.Headers[name] = value
+	}
+	for _, attachment := range msg.Attachments {
+		payload.Attachments = append(payload.Attachments, sendGridAttachment{
+			Content:  base64.StdEncoding.EncodeToString(attachment.Data),
+			Type:     attachment.ContentType,
+			Filename: attachment.Filename,
+		})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding sendgrid request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendGridEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error building sendgrid request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending email via sendgrid: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &messaging.StatusError{
+			Provider: "sendgrid", Status: resp.Status, StatusCode: resp.StatusCode,
+			Detail: string(bytes.TrimSpace(respBody)),
+		}
+	}
+
+	return &Result{ProviderMessageID: messageID}, nil
+}
@@ -0,0 +1,200 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	FromName string
+}
+
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("SMTP_HOST and EMAIL_FROM are required for the smtp provider")
+	}
+	return &SMTPSender{cfg: cfg}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) (*Result, error) {
+	fromAddress, fromName := sender(msg, s.cfg.From, s.cfg.FromName)
+
+	messageID := msg.MessageID
+	if messageID == "" {
+		var err error
+		if messageID, err = NewMessageID(fromAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	from := mail.Address{Name: fromName, Address: fromAddress}
+	to := mail.Address{Name: msg.ToName, Address: msg.To}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	for _, name := range slices.Sorted(maps.Keys(msg.Headers)) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, msg.Headers[name])
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	switch {
+	case len(msg.Attachments) > 0:
+		if err := writeMultipart(&b, msg); err != nil {
+			return nil, err
+		}
+	case msg.HTML != "":
+		var body bytes.Buffer
+		contentType, err := writeAlternative(&body, msg)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "Content-Type: %s\r\n\r\n", contentType)
+		b.Write(body.Bytes())
+	default:
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		b.WriteString(msg.Body)
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, fromAddress, []string{msg.To}, []byte(b.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("error sending email via smtp: %w", err)
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &Result{ProviderMessageID: messageID}, nil
+}
+
+// NewMessageID returns a unique Message-ID on the domain of from, which is
+// an address or a bare domain.
+func NewMessageID(from string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating message id: %w", err)
+	}
+
+	domain := from
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain), nil
+}
+
+// writeMultipart writes a multipart/mixed body holding the text, or the text
+// and HTML alternatives, followed by each attachment, base64-encoded.
+func writeMultipart(b *strings.Builder, msg *Message) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	contentType, content := "text/plain; charset=utf-8", []byte(msg.Body)
+	if msg.HTML != "" {
+		var alternative bytes.Buffer
+		var err error
+		if contentType, err = writeAlternative(&alternative, msg); err != nil {
+			return err
+		}
+		content = alternative.Bytes()
+	}
+
+	text, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return fmt.Errorf("error building email body: %w", err)
+	}
+	text.Write(content)
+
+	for _, attachment := range msg.Attachments {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return fmt.Errorf("error building email attachment: %w", err)
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		io.WriteString(part, encoded)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error building email body: %w", err)
+	}
+
+	fmt.Fprintf(b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
+	b.Write(body.Bytes())
+	return nil
+}
+
+// writeAlternative writes a multipart/alternative body holding the text and
+// the HTML versions of the message, and returns its content type.
+func writeAlternative(w io.Writer, msg *Message) (string, error) {
+	mw := multipart.NewWriter(w)
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Body},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return "", fmt.Errorf("error building email body: %w", err)
+		}
+		io.WriteString(pw, part.content)
+	}
+
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("error building email body: %w", err)
+	}
+
+	return "multipart/alternative; boundary=" + mw.Boundary(), nil
+}
@@ -0,0 +1,29 @@
+// Package messaging holds what the clients of message providers share.
+package messaging
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError is an error response from a provider's API.
+type StatusError struct {
+	Provider   string
+	Status     string
+	StatusCode int
+	// Detail is the provider's explanation, if it gave one.
+	Detail string
+}
+
+func (e *StatusError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%s returned %s", e.Provider, e.Status)
+	}
+	return fmt.Sprintf("%s returned %s: %s", e.Provider, e.Status, e.Detail)
+}
+
+// Temporary reports whether the request may succeed if made again later,
+// because the provider was rate limiting or failing itself.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
@@ -0,0 +1,119 @@
+// Package twilio sends SMS and WhatsApp messages through the Twilio REST API
+// and receives its delivery receipts and inbound replies.
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"salesagency/internal/messaging"
+)
+
+const apiBase = "https://api.twilio.com/2010-04-01"
+
+type Config struct {
+	AccountSID      string
+	AuthToken       string
+	From            string // SMS sender number in E.164 format
+	WhatsAppFrom    string // WhatsApp-enabled sender; WhatsApp is disabled when empty
+	WebhookURL      string // public URL of /webhooks/twilio, used for callbacks and signatures
+	VoiceWebhookURL string // public URL of /webhooks/twilio/voice, used for signatures
+}
+
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+type Message struct {
+	To       string
+	Body     string
+	WhatsApp bool
+}
+
+type Result struct {
+	SID string
+}
+
+var ErrWhatsAppNotConfigured = errors.New("TWILIO_WHATSAPP_FROM is not configured")
+
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.AccountSID == "" || cfg.AuthToken == "" || cfg.From == "" {
+		return nil, errors.New("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM are required for twilio")
+	}
+	return &Client{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+type messageResponse struct {
+	SID string `json:"sid"`
+}
+
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (c *Client) Send(ctx context.Context, msg *Message) (*Result, error) {
+	from, to := c.cfg.From, msg.To
+	if msg.WhatsApp {
+		if c.cfg.WhatsAppFrom == "" {
+			return nil, ErrWhatsAppNotConfigured
+		}
+		from, to = "whatsapp:"+c.cfg.WhatsAppFrom, "whatsapp:"+msg.To
+	}
+
+	form := url.Values{}
+	form.Set("From", from)
+	form.Set("To", to)
+	form.Set("Body", msg.Body)
+	if c.cfg.WebhookURL != "" {
+		form.Set("StatusCallback", c.cfg.WebhookURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", apiBase, c.cfg.AccountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error building twilio request: %w", err)
+	}
+	req.SetBasicAuth(c.cfg.AccountSID, c.cfg.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending message via twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("error reading twilio response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr errorResponse
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, &messaging.StatusError{
+				Provider: "twilio", Status: resp.Status, StatusCode: resp.StatusCode,
+				Detail: fmt.Sprintf("%s (code %d)", apiErr.Message, apiErr.Code),
+			}
+		}
+		return nil, &messaging.StatusError{Provider: "twilio", Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+
+	var result messageResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("error decoding twilio response: %w", err)
+	}
+
+	return &Result{SID: result.SID}, nil
+}
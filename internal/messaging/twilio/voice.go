@@ -0,0 +1,68 @@
+package twilio
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"salesagency/internal/logging"
+)
+
+// CallUpdate is a voice status or recording callback. Status callbacks carry
+// Status; recording callbacks carry RecordingURL.
+type CallUpdate struct {
+	CallSID         string
+	Status          string // completed, busy, no-answer, failed or canceled
+	Direction       string // inbound, outbound-api or outbound-dial
+	From            string
+	To              string
+	DurationSeconds *int
+	AnsweredBy      string // set with answering machine detection, e.g. machine_end_beep
+	RecordingURL    string
+}
+
+// CallHandler should return nil for calls it cannot match to a lead.
+type CallHandler interface {
+	HandleCall(ctx context.Context, update *CallUpdate) error
+}
+
+// VoiceWebhookHandler verifies the X-Twilio-Signature header and passes
+// voice status and recording callbacks to calls.
+func (c *Client) VoiceWebhookHandler(calls CallHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form body", http.StatusBadRequest)
+			return
+		}
+		if !c.validSignature(webhookURL(c.cfg.VoiceWebhookURL, r), r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		update := &CallUpdate{
+			CallSID:      r.PostForm.Get("CallSid"),
+			Status:       r.PostForm.Get("CallStatus"),
+			Direction:    r.PostForm.Get("Direction"),
+			From:         r.PostForm.Get("From"),
+			To:           r.PostForm.Get("To"),
+			AnsweredBy:   r.PostForm.Get("AnsweredBy"),
+			RecordingURL: r.PostForm.Get("RecordingUrl"),
+		}
+		if duration, err := strconv.Atoi(r.PostForm.Get("CallDuration")); err == nil {
+			update.DurationSeconds = &duration
+		}
+
+		if err := calls.HandleCall(r.Context(), update); err != nil {
+			logging.FromContext(r.Context()).Error("error handling twilio voice webhook", "call_sid", update.CallSID, "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte("<Response></Response>"))
+	})
+}
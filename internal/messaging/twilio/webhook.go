@@ -0,0 +1,123 @@
+package twilio
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"salesagency/internal/logging"
+)
+
+// StatusUpdate is a delivery receipt for a message sent through Send.
+type StatusUpdate struct {
+	MessageSID string
+	Status     string // queued, sent, delivered, read, undelivered or failed
+	ErrorCode  string
+}
+
+// InboundMessage is a reply sent to one of our numbers.
+type InboundMessage struct {
+	MessageSID string
+	From       string
+	Body       string
+	WhatsApp   bool
+}
+
+// EventHandler should return nil for events it cannot match to a record;
+// errors are reported to Twilio as failures.
+type EventHandler interface {
+	HandleStatus(ctx context.Context, update *StatusUpdate) error
+	HandleInbound(ctx context.Context, msg *InboundMessage) error
+}
+
+// WebhookHandler verifies the X-Twilio-Signature header and dispatches
+// status callbacks and inbound messages to events.
+func (c *Client) WebhookHandler(events EventHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form body", http.StatusBadRequest)
+			return
+		}
+		if !c.validSignature(webhookURL(c.cfg.WebhookURL, r), r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		var err error
+		if _, inbound := r.PostForm["Body"]; inbound {
+			from, whatsApp := strings.CutPrefix(r.PostForm.Get("From"), "whatsapp:")
+			err = events.HandleInbound(r.Context(), &InboundMessage{
+				MessageSID: r.PostForm.Get("MessageSid"),
+				From:       from,
+				Body:       r.PostForm.Get("Body"),
+				WhatsApp:   whatsApp,
+			})
+		} else {
+			err = events.HandleStatus(r.Context(), &StatusUpdate{
+				MessageSID: r.PostForm.Get("MessageSid"),
+				Status:     r.PostForm.Get("MessageStatus"),
+				ErrorCode:  r.PostForm.Get("ErrorCode"),
+			})
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error handling twilio webhook", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// An empty TwiML response tells Twilio not to send an auto-reply.
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte("<Response></Response>"))
+	})
+}
+
+// webhookURL is the URL Twilio signed: configured when set, since proxies
+// may rewrite the request, and otherwise rebuilt from the request.
+func webhookURL(configured string, r *http.Request) string {
+	if configured != "" {
+		return configured
+	}
+	scheme := "https"
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// validSignature implements Twilio's request validation: an HMAC-SHA1 of the
+// URL followed by each POST parameter name and value, sorted by name.
+func (c *Client) validSignature(webhookURL string, params url.Values, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(webhookURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			b.WriteString(key)
+			b.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(c.cfg.AuthToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,171 @@
+// Package money converts amounts between currencies. Exchange rates are the
+// European Central Bank's daily reference rates, fetched by Refresh and kept
+// in the database, from which each instance caches them for an hour.
+package money
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+// DefaultRatesURL serves the ECB's reference rates for the last working day.
+const DefaultRatesURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+const (
+	cacheTTL     = time.Hour
+	fetchTimeout = 30 * time.Second
+)
+
+var ErrInvalidCurrency = errors.New("currency must be a three-letter ISO 4217 code")
+
+// ParseCurrency accepts an ISO 4217 code in any case.
+func ParseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return code, nil
+}
+
+type Converter struct {
+	db       *database.DB
+	client   *http.Client
+	ratesURL string
+	now      func() time.Time
+
+	mu sync.Mutex
+	// rates are units of each currency one euro buys.
+	rates    map[string]float64
+	loadedAt time.Time
+}
+
+func NewConverter(db *database.DB, ratesURL string) *Converter {
+	if ratesURL == "" {
+		ratesURL = DefaultRatesURL
+	}
+	return &Converter{db: db, client: &http.Client{Timeout: fetchTimeout}, ratesURL: ratesURL, now: time.Now}
+}
+
+// Convert returns m in currency at the latest rates. It returns
+// database.ErrNoExchangeRate when either currency has no rate.
+func (c *Converter) Convert(ctx context.Context, m model.Money, currency string) (model.Money, error) {
+	if m.Currency == currency {
+		return m, nil
+	}
+
+	rates, err := c.cachedRates(ctx)
+	if err != nil {
+		return model.Money{}, err
+	}
+	from, ok := rates[m.Currency]
+	if !ok {
+		return model.Money{}, fmt.Errorf("%w for %s", database.ErrNoExchangeRate, m.Currency)
+	}
+	to, ok := rates[currency]
+	if !ok {
+		return model.Money{}, fmt.Errorf("%w for %s", database.ErrNoExchangeRate, currency)
+	}
+
+	return model.NewMoney(m.Amount()*to/from, currency), nil
+}
+
+// ToBaseCurrency returns m in the base currency of ctx's agency.
+func (c *Converter) ToBaseCurrency(ctx context.Context, m model.Money) (model.Money, error) {
+	base, err := c.db.GetBaseCurrency(ctx)
+	if err != nil {
+		return model.Money{}, err
+	}
+	return c.Convert(ctx, m, base)
+}
+
+func (c *Converter) cachedRates(ctx context.Context) (map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rates != nil && c.now().Sub(c.loadedAt) < cacheTTL {
+		return c.rates, nil
+	}
+
+	rates, _, err := c.db.GetExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.rates, c.loadedAt = rates, c.now()
+	return rates, nil
+}
+
+// ecbEnvelope is the part of the ECB's rates file that holds the rates:
+// one Cube per currency within a Cube for the day.
+type ecbEnvelope struct {
+	Day struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// Refresh fetches the latest rates and saves them, and returns how many it
+// saved. This instance's cache is cleared; others pick the rates up when
+// their caches expire.
+func (c *Converter) Refresh(ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.ratesURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error fetching exchange rates: status %d", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return 0, fmt.Errorf("error decoding exchange rates: %w", err)
+	}
+	asOf, err := time.Parse("2006-01-02", envelope.Day.Time)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding exchange rates: bad date %q", envelope.Day.Time)
+	}
+
+	rates := map[string]float64{"EUR": 1}
+	for _, r := range envelope.Day.Rates {
+		currency, err := ParseCurrency(r.Currency)
+		if err != nil || r.Rate <= 0 {
+			continue
+		}
+		rates[currency] = r.Rate
+	}
+	if len(rates) == 1 {
+		return 0, errors.New("error decoding exchange rates: no rates found")
+	}
+
+	if err := c.db.SaveExchangeRates(ctx, rates, asOf); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.rates = nil
+	c.mu.Unlock()
+
+	return len(rates), nil
+}
@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/messaging/email"
+	"salesagency/internal/outbox"
+)
+
+// KindEmail messages email a notification to a user.
+const KindEmail = "notification_email"
+
+type emailPayload struct {
+	To      string `json:"to"`
+	ToName  string `json:"toName"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Email builds a message that emails n to the given address.
+func Email(to, toName string, n *model.Notification) (*model.OutboxMessage, error) {
+	payload, err := json.Marshal(emailPayload{To: to, ToName: toName, Subject: n.Title, Body: n.Body})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding notification email: %w", err)
+	}
+
+	return &model.OutboxMessage{Kind: KindEmail, Payload: string(payload)}, nil
+}
+
+// EmailHandler delivers KindEmail messages through sender.
+func EmailHandler(sender email.Sender) outbox.Handler {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload emailPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("error decoding notification email: %w", err)
+		}
+
+		_, err := sender.Send(ctx, &email.Message{
+			To:      payload.To,
+			ToName:  payload.ToName,
+			Subject: payload.Subject,
+			Body:    payload.Body,
+		})
+		return err
+	}
+}
+
+// slackPayload is the body of a Slack incoming webhook call.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func slackMessage(n *model.Notification) slackPayload {
+	return slackPayload{Text: fmt.Sprintf("*%s*\n%s", n.Title, n.Body)}
+}
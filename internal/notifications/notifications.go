@@ -0,0 +1,341 @@
+// Package notifications tells an agency's users about events they need to
+// act on: in the app, by email and on Slack, as each user prefers.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/calendar"
+	"salesagency/internal/campaign"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/logging"
+	"salesagency/internal/outbox"
+	"salesagency/internal/tenant"
+)
+
+// HighIntentThreshold is the intent score from which a lead's replies are
+// notified.
+const HighIntentThreshold = 0.7
+
+// audience is the least privileged role notified of each kind.
+var audience = map[model.NotificationKind]auth.Role{
+	model.NotificationKindLeadReplied:     auth.RoleSalesRep,
+	model.NotificationKindBudgetThreshold: auth.RoleManager,
+	model.NotificationKindAgentRunFailed:  auth.RoleManager,
+	model.NotificationKindLeadEscalated:   auth.RoleSalesRep,
+	model.NotificationKindSLABreached:     auth.RoleManager,
+	model.NotificationKindMeetingChanged:  auth.RoleSalesRep,
+	model.NotificationKindGoalAtRisk:      auth.RoleManager,
+}
+
+type Service struct {
+	db     *database.DB
+	events *events.Broker
+	now    func() time.Time
+}
+
+// NewService returns a service that publishes in-app notifications to
+// broker as they are created.
+func NewService(db *database.DB, broker *events.Broker) *Service {
+	return &Service{db: db, events: broker, now: time.Now}
+}
+
+// Notify sends n to each of the agency's users allowed to see its kind, on
+// the channels they chose. Email and Slack copies go through the outbox.
+func (s *Service) Notify(ctx context.Context, agencyID string, n *model.Notification) error {
+	return s.notify(ctx, agencyID, "", n)
+}
+
+// NotifyUser sends n to one of the agency's users, as Notify would.
+func (s *Service) NotifyUser(ctx context.Context, agencyID, userID string, n *model.Notification) error {
+	return s.notify(ctx, agencyID, userID, n)
+}
+
+// notify sends n to the agency's users, or only to userID when it is set.
+func (s *Service) notify(ctx context.Context, agencyID, userID string, n *model.Notification) error {
+	recipients, err := s.db.GetNotificationRecipients(ctx, agencyID, n.Kind)
+	if err != nil {
+		return err
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = s.now()
+	}
+
+	for _, r := range recipients {
+		if userID != "" && r.UserID != userID {
+			continue
+		}
+		user := &auth.User{ID: r.UserID, Role: auth.Role(r.Role)}
+		if user.IsClientScoped() || !user.HasRole(audience[n.Kind]) {
+			continue
+		}
+
+		var deliveries []*model.OutboxMessage
+		if r.ByEmail {
+			msg, err := Email(r.Email, r.Name, n)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, msg)
+		}
+		if r.BySlack && r.SlackWebhookURL != nil {
+			msg, err := outbox.Webhook(*r.SlackWebhookURL, slackMessage(n))
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, msg)
+		}
+
+		var inApp *model.Notification
+		if r.InApp {
+			notification := *n
+			notification.UserID = r.UserID
+			inApp = &notification
+		}
+		if inApp == nil && len(deliveries) == 0 {
+			continue
+		}
+
+		created, err := s.db.CreateNotification(ctx, agencyID, inApp, deliveries)
+		if err != nil {
+			return err
+		}
+		if created != nil {
+			s.events.Publish(events.TopicNotificationCreated, created)
+		}
+	}
+
+	return nil
+}
+
+// LeadReplied notifies a reply from a lead whose intent score is at least
+// HighIntentThreshold. It has the signature of a channels.ReplyHook and
+// should be registered after the hook that rescores replying leads.
+func (s *Service) LeadReplied(ctx context.Context, reply *model.Interaction) {
+	log := logging.FromContext(ctx).With("lead_id", reply.Lead.ID)
+
+	lead, err := s.db.GetLeadByID(ctx, reply.Lead.ID)
+	if err != nil || lead == nil {
+		if err != nil {
+			log.Error("Failed to load lead for reply notification", "error", err)
+		}
+		return
+	}
+	if lead.IntentScore < HighIntentThreshold {
+		return
+	}
+
+	body := fmt.Sprintf("%s replied by %s.", lead.Name, reply.Channel)
+	if reply.Message != nil && *reply.Message != "" {
+		body = fmt.Sprintf("%s replied by %s: %s", lead.Name, reply.Channel, excerpt(*reply.Message))
+	}
+	s.notifyFor(ctx, log, s.db.GetLeadAgencyID, lead.ID, &model.Notification{
+		Kind:      model.NotificationKindLeadReplied,
+		Title:     fmt.Sprintf("High-intent lead %s replied", lead.Name),
+		Body:      body,
+		SubjectID: &lead.ID,
+	})
+}
+
+// BudgetThreshold notifies that a campaign reached the budget alert
+// threshold. It has the signature of a campaign.BudgetAlertHook.
+func (s *Service) BudgetThreshold(ctx context.Context, alert *campaign.BudgetAlert) {
+	body := fmt.Sprintf("%s has spent %.2f of its %.2f budget.", alert.Campaign, alert.SpendToDate, alert.Budget)
+	if alert.Paused {
+		body += " It was paused."
+	}
+	log := logging.FromContext(ctx).With("campaign_id", alert.CampaignID)
+	s.notifyFor(ctx, log, s.db.GetCampaignAgencyID, alert.CampaignID, &model.Notification{
+		Kind:      model.NotificationKindBudgetThreshold,
+		Title:     fmt.Sprintf("Campaign %s has used %.0f%% of its budget", alert.Campaign, alert.Threshold*100),
+		Body:      body,
+		SubjectID: &alert.CampaignID,
+	})
+}
+
+// AgentRunFailed notifies a failed agent run. It has the signature of a
+// scheduler failure hook.
+func (s *Service) AgentRunFailed(ctx context.Context, run *model.AgentRun) {
+	log := logging.FromContext(ctx).With("run_id", run.ID)
+
+	name := run.AgentID
+	agencyID, err := s.db.GetAIAgentAgencyID(ctx, run.AgentID)
+	if err == nil && agencyID != "" {
+		if agent, err := s.db.GetAIAgentByID(tenant.WithAgency(ctx, agencyID), run.AgentID); err == nil && agent != nil {
+			name = agent.Name
+		}
+	}
+
+	body := "The run failed."
+	if run.Error != nil {
+		body = "The run failed: " + excerpt(*run.Error)
+	}
+	s.notifyFor(ctx, log, s.db.GetAIAgentAgencyID, run.AgentID, &model.Notification{
+		Kind:      model.NotificationKindAgentRunFailed,
+		Title:     fmt.Sprintf("Agent %s run failed", name),
+		Body:      body,
+		SubjectID: &run.ID,
+	})
+}
+
+// LeadEscalated tells the rep a lead was handed off to them.
+func (s *Service) LeadEscalated(ctx context.Context, handoff *model.LeadHandoff, lead *model.Lead) {
+	log := logging.FromContext(ctx).With("handoff_id", handoff.ID)
+
+	agencyID, err := s.db.GetLeadAgencyID(ctx, lead.ID)
+	if err != nil {
+		log.Error("Failed to find agency to notify", "error", err)
+		return
+	}
+	if agencyID == "" {
+		return
+	}
+
+	err = s.NotifyUser(ctx, agencyID, handoff.RepID, &model.Notification{
+		Kind:      model.NotificationKindLeadEscalated,
+		Title:     fmt.Sprintf("Lead %s was handed off to you", lead.Name),
+		Body:      fmt.Sprintf("AI outreach to %s is paused until you resolve the handoff. Reason: %s", lead.Name, excerpt(handoff.Reason)),
+		SubjectID: &lead.ID,
+	})
+	if err != nil {
+		log.Error("Failed to send notification", "kind", model.NotificationKindLeadEscalated, "error", err)
+	}
+}
+
+// SLABreached notifies a reply or escalation answered late, or not answered
+// in time, under a client's SLA. It has the signature of an sla.BreachHook.
+func (s *Service) SLABreached(ctx context.Context, check *model.SLACheck) {
+	log := logging.FromContext(ctx).With("sla_check_id", check.ID)
+
+	sla, err := s.db.GetSLAByID(ctx, check.SLAID)
+	if err != nil || sla == nil {
+		if err != nil {
+			log.Error("Failed to load SLA for breach notification", "error", err)
+		}
+		return
+	}
+	lead, err := s.db.GetLeadByID(ctx, check.LeadID)
+	if err != nil || lead == nil {
+		if err != nil {
+			log.Error("Failed to load lead for breach notification", "error", err)
+		}
+		return
+	}
+
+	body := fmt.Sprintf("It was due %s and has not been answered.", check.DueAt.UTC().Format(time.RFC1123))
+	if check.RespondedAt != nil {
+		body = fmt.Sprintf("It was answered %s late.", check.RespondedAt.Sub(check.DueAt).Round(time.Minute))
+	}
+	s.notifyFor(ctx, log, s.db.GetLeadAgencyID, lead.ID, &model.Notification{
+		Kind:      model.NotificationKindSLABreached,
+		Title:     fmt.Sprintf("SLA %s missed for lead %s", sla.Name, lead.Name),
+		Body:      body,
+		SubjectID: &lead.ID,
+	})
+}
+
+// GoalAtRisk notifies a campaign projected to miss one of its goals. It has
+// the signature of a campaign.GoalAtRiskHook.
+func (s *Service) GoalAtRisk(ctx context.Context, risk *campaign.GoalAtRisk) {
+	p := risk.Progress
+	metric := strings.ToLower(strings.ReplaceAll(string(p.Goal.Metric), "_", " "))
+	log := logging.FromContext(ctx).With("campaign_id", risk.CampaignID)
+	s.notifyFor(ctx, log, s.db.GetCampaignAgencyID, risk.CampaignID, &model.Notification{
+		Kind:  model.NotificationKindGoalAtRisk,
+		Title: fmt.Sprintf("Campaign %s is behind on its %s goal", risk.Campaign, metric),
+		Body: fmt.Sprintf("It has %d of %d %s, %.1f expected by now, and is projected to reach %.1f by %s.",
+			p.Actual, p.Goal.Target, metric, p.Expected, p.Projected, p.Goal.EndsAt().UTC().Format("2 Jan 2006")),
+		SubjectID: &risk.CampaignID,
+	})
+}
+
+// MeetingChanged tells the user who booked a meeting that the lead
+// rescheduled or cancelled it, or the agency's sales reps when that user is
+// unknown. It has the signature of a calendar.ChangeHook.
+func (s *Service) MeetingChanged(ctx context.Context, change *calendar.Change) {
+	meeting, lead := change.Meeting, change.Lead
+	log := logging.FromContext(ctx).With("interaction_id", meeting.InteractionID)
+
+	n := &model.Notification{
+		Kind:      model.NotificationKindMeetingChanged,
+		Title:     fmt.Sprintf("%s cancelled their meeting", lead.Name),
+		Body:      fmt.Sprintf("The meeting on %s was cancelled.", meeting.StartsAt.UTC().Format(time.RFC1123)),
+		SubjectID: &lead.ID,
+	}
+	switch {
+	case change.Previous != nil:
+		n.Title = fmt.Sprintf("%s rescheduled their meeting", lead.Name)
+		n.Body = fmt.Sprintf("The meeting on %s was moved to %s.",
+			change.Previous.Start.UTC().Format(time.RFC1123), meeting.StartsAt.UTC().Format(time.RFC1123))
+	case change.Reason != nil:
+		n.Body += " Reason: " + excerpt(*change.Reason)
+	}
+
+	var err error
+	if meeting.BookedBy != nil {
+		err = s.NotifyUser(ctx, meeting.AgencyID, *meeting.BookedBy, n)
+	} else {
+		err = s.Notify(ctx, meeting.AgencyID, n)
+	}
+	if err != nil {
+		log.Error("Failed to send notification", "kind", n.Kind, "error", err)
+	}
+}
+
+// notifyFor sends n to the agency that owns subjectID, logging failures:
+// the event that triggered the notification has already happened.
+func (s *Service) notifyFor(ctx context.Context, log *slog.Logger, agencyOf func(context.Context, string) (string, error), subjectID string, n *model.Notification) {
+	agencyID, err := agencyOf(ctx, subjectID)
+	if err != nil {
+		log.Error("Failed to find agency to notify", "error", err)
+		return
+	}
+	if agencyID == "" {
+		return
+	}
+
+	if err := s.Notify(ctx, agencyID, n); err != nil {
+		log.Error("Failed to send notification", "kind", n.Kind, "error", err)
+	}
+}
+
+// excerpt shortens text for a notification body.
+func excerpt(text string) string {
+	const max = 280
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "…"
+}
+
+// Preferences returns the user's preference for every kind, with the default
+// for kinds they have not set.
+func (s *Service) Preferences(ctx context.Context, userID string) ([]*model.NotificationPreference, error) {
+	set, err := s.db.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := make(map[model.NotificationKind]*model.NotificationPreference, len(set))
+	for _, p := range set {
+		byKind[p.Kind] = p
+	}
+
+	preferences := make([]*model.NotificationPreference, 0, len(model.AllNotificationKind))
+	for _, kind := range model.AllNotificationKind {
+		p, ok := byKind[kind]
+		if !ok {
+			p = &model.NotificationPreference{Kind: kind, InApp: true}
+		}
+		preferences = append(preferences, p)
+	}
+	return preferences, nil
+}
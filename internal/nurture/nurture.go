@@ -0,0 +1,117 @@
+// Package nurture keeps in touch with lost leads. When a lead is marked LOST,
+// the nurture policy of a campaign it is in enrolls it in a long-running
+// sequence, such as quarterly check-ins, chosen by why it was lost. A lead
+// that replies while being nurtured is reopened.
+package nurture
+
+import (
+	"context"
+	"errors"
+
+	"salesagency/graph/model"
+	"salesagency/internal/campaign"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/pipeline"
+	"salesagency/internal/sequence"
+)
+
+var ErrSequenceCampaign = errors.New("nurture sequence belongs to another campaign")
+
+// reactivatedReason is recorded on the status change that reopens a lead.
+const reactivatedReason = "Replied while being nurtured"
+
+type Service struct {
+	db        *database.DB
+	sequences *sequence.Engine
+	pipeline  *pipeline.Service
+}
+
+func NewService(db *database.DB, sequences *sequence.Engine, pipeline *pipeline.Service) *Service {
+	return &Service{db: db, sequences: sequences, pipeline: pipeline}
+}
+
+// SetPolicy makes the campaign nurture the leads it loses for lossReason, or
+// for any reason without its own policy when lossReason is nil, with one of
+// its sequences.
+func (s *Service) SetPolicy(ctx context.Context, campaignID string, lossReason *model.LossReason, sequenceID string) (*model.NurturePolicy, error) {
+	c, err := s.db.GetCampaignByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, campaign.ErrNotFound
+	}
+
+	seq, err := s.db.GetSequenceByID(ctx, sequenceID)
+	if err != nil {
+		return nil, err
+	}
+	if seq == nil {
+		return nil, sequence.ErrNotFound
+	}
+	if seq.CampaignID != campaignID {
+		return nil, ErrSequenceCampaign
+	}
+
+	return s.db.SetNurturePolicy(ctx, &model.NurturePolicy{
+		CampaignID: campaignID,
+		LossReason: lossReason,
+		SequenceID: sequenceID,
+	})
+}
+
+// LeadLost enrolls a lead that was just lost in the sequence its nurture
+// policy names, if any. It is registered as a pipeline.LostHook.
+func (s *Service) LeadLost(ctx context.Context, leadID string, lossReason *model.LossReason) {
+	log := logging.FromContext(ctx)
+
+	policy, err := s.db.FindNurturePolicy(ctx, leadID, lossReason)
+	if err != nil {
+		log.Error("Failed to find nurture policy", "lead_id", leadID, "error", err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+
+	_, err = s.sequences.Nurture(ctx, policy.SequenceID, leadID)
+	switch {
+	case errors.Is(err, sequence.ErrAlreadyEnrolled):
+		log.Info("Lost lead is already in its nurture sequence", "lead_id", leadID, "sequence_id", policy.SequenceID)
+	case err != nil:
+		log.Error("Failed to start lead nurture", "lead_id", leadID, "sequence_id", policy.SequenceID, "error", err)
+	}
+}
+
+// HandleReply reopens a lost lead that replied while being nurtured, unless
+// the reply was classified as out of office. It is registered as a
+// channels.ReplyHook, ahead of the sequence engine's, which ends the
+// nurture.
+func (s *Service) HandleReply(ctx context.Context, reply *model.Interaction) {
+	log := logging.FromContext(ctx)
+
+	intent, err := s.db.GetInteractionReplyIntent(ctx, reply.ID)
+	if err != nil {
+		log.Error("Failed to check reply intent", "lead_id", reply.Lead.ID, "error", err)
+	}
+	if intent != nil && *intent == model.ReplyIntentOutOfOffice {
+		return
+	}
+
+	nurtured, err := s.db.HasActiveNurture(ctx, reply.Lead.ID)
+	if err != nil {
+		log.Error("Failed to check lead nurture", "lead_id", reply.Lead.ID, "error", err)
+		return
+	}
+	if !nurtured {
+		return
+	}
+
+	reason := reactivatedReason
+	if _, err := s.pipeline.Reactivate(ctx, reply.Lead.ID, &reason); err != nil {
+		log.Error("Failed to reactivate nurtured lead", "lead_id", reply.Lead.ID, "error", err)
+		return
+	}
+	log.Info("Reactivated nurtured lead", "lead_id", reply.Lead.ID)
+}
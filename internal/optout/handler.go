@@ -0,0 +1,68 @@
+package optout
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+// Path is where Handler is mounted.
+const Path = "/unsubscribe"
+
+var page = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>Unsubscribe</title></head>
+<body style="font-family: sans-serif; max-width: 32em; margin: 4em auto;">
+{{if .Done}}
+<p>You have been unsubscribed and will not receive further messages from us on this channel.</p>
+{{else}}
+<p>Stop receiving messages from us?</p>
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// Handler serves unsubscribe links. GET shows a confirmation button so link
+// scanners cannot unsubscribe anyone; POST, including RFC 8058 one-click
+// requests from mail clients, records the opt-out.
+func Handler(db *database.DB, signer *Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.FormValue("token")
+		leadID, channel, err := signer.Verify(token)
+		if err != nil {
+			http.Error(w, "invalid unsubscribe link", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			render(w, map[string]interface{}{"Token": token})
+		case http.MethodPost:
+			// Links carry no user, and lead IDs are globally unique.
+			ctx := tenant.WithSystem(r.Context())
+			if _, err := db.OptOutLead(ctx, leadID, channel, "unsubscribe link"); err != nil {
+				logging.FromContext(ctx).Error("error recording opt-out", "lead_id", leadID, "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			render(w, map[string]interface{}{"Done": true})
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func render(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		slog.Error("error rendering unsubscribe page", "error", err)
+	}
+}
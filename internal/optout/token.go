@@ -0,0 +1,67 @@
+// Package optout issues signed unsubscribe links and serves the public page
+// that records opt-outs from them.
+package optout
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// Signer creates and verifies unsubscribe tokens. Tokens never expire, since
+// recipients may unsubscribe from old messages.
+type Signer struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewSigner signs tokens with secret. baseURL is the server's public address,
+// used to build links; links are not generated when it is empty.
+func NewSigner(secret, baseURL string) *Signer {
+	return &Signer{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *Signer) Token(leadID string, channel model.Channel) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(leadID + "|" + string(channel)))
+	return payload + "." + s.sign(payload)
+}
+
+// Verify returns the lead and channel a token was issued for.
+func (s *Signer) Verify(token string) (string, model.Channel, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return "", "", ErrInvalidToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+	leadID, channel, ok := strings.Cut(string(decoded), "|")
+	if !ok || !model.Channel(channel).IsValid() {
+		return "", "", ErrInvalidToken
+	}
+	return leadID, model.Channel(channel), nil
+}
+
+// URL returns the unsubscribe link for a lead and channel, or "" when no
+// public base URL is configured.
+func (s *Signer) URL(leadID string, channel model.Channel) string {
+	if s.baseURL == "" {
+		return ""
+	}
+	return s.baseURL + Path + "?token=" + url.QueryEscape(s.Token(leadID, channel))
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,97 @@
+// Package outbox delivers side effects that were recorded in the outbox
+// table alongside the change that caused them.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+)
+
+const (
+	// MaxAttempts is how many times a message is tried before it is
+	// dead-lettered.
+	MaxAttempts = 10
+
+	batchSize   = 100
+	lease       = 5 * time.Minute
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Handler delivers one message's payload. Delivery is at least once, so
+// handlers must tolerate seeing the same payload again.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type Relay struct {
+	db       *database.DB
+	handlers map[string]Handler
+	now      func() time.Time
+}
+
+func NewRelay(db *database.DB) *Relay {
+	return &Relay{db: db, handlers: make(map[string]Handler), now: time.Now}
+}
+
+// Register sets the handler for messages of the given kind.
+func (r *Relay) Register(kind string, h Handler) {
+	r.handlers[kind] = h
+}
+
+// Run delivers the messages that are due and returns how many were
+// delivered. Failed messages are retried with exponential backoff; messages
+// that fail MaxAttempts times, or have no handler, are dead-lettered.
+func (r *Relay) Run(ctx context.Context) (int, error) {
+	messages, err := r.db.ClaimOutboxMessages(ctx, r.now(), lease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, msg := range messages {
+		log := logging.FromContext(ctx).With("outbox_id", msg.ID, "kind", msg.Kind, "attempt", msg.Attempts)
+
+		err := r.deliver(ctx, msg)
+		switch {
+		case err == nil:
+			err = r.db.MarkOutboxDelivered(ctx, msg.ID, r.now())
+			delivered++
+		case msg.Attempts >= MaxAttempts || r.handlers[msg.Kind] == nil:
+			log.Error("Dead-lettered outbox message", "error", err)
+			err = r.db.DeadLetterOutboxMessage(ctx, msg.ID, err.Error(), r.now())
+		default:
+			log.Warn("Failed to deliver outbox message, will retry", "error", err)
+			err = r.db.RetryOutboxMessage(ctx, msg.ID, err.Error(), r.now().Add(backoff(msg.Attempts)))
+		}
+		if err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+func (r *Relay) deliver(ctx context.Context, msg *model.OutboxMessage) error {
+	h := r.handlers[msg.Kind]
+	if h == nil {
+		return fmt.Errorf("no handler for outbox message kind %q", msg.Kind)
+	}
+	return h(ctx, json.RawMessage(msg.Payload))
+}
+
+// backoff doubles the delay after each attempt, up to maxBackoff.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// KindWebhook messages POST a JSON body to a URL.
+const KindWebhook = "webhook"
+
+type webhookPayload struct {
+	URL  string          `json:"url"`
+	Body json.RawMessage `json:"body"`
+}
+
+// Webhook builds a message that posts body, encoded as JSON, to url.
+func Webhook(url string, body interface{}) (*model.OutboxMessage, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding webhook body: %w", err)
+	}
+
+	payload, err := json.Marshal(webhookPayload{URL: url, Body: encoded})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	return &model.OutboxMessage{Kind: KindWebhook, Payload: string(payload)}, nil
+}
+
+// WebhookHandler delivers KindWebhook messages. Any response other than 2xx
+// is a failure.
+func WebhookHandler(timeout time.Duration) Handler {
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload webhookPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("error decoding webhook payload: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, payload.URL, bytes.NewReader(payload.Body))
+		if err != nil {
+			return fmt.Errorf("error building webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+// Package persisted reads the list of GraphQL operations registered ahead of
+// time. Clients send an operation's hash instead of its text, and the server
+// can refuse operations that are not on the list.
+package persisted
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Manifest maps the SHA-256 hash of each registered operation, hex encoded,
+// to its text.
+type Manifest map[string]string
+
+// Hash returns the key query is registered under.
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load reads a manifest from the JSON file at path, such as
+// {"ecf4edb4...": "query Me { me { id } }"}. Keys must be the hashes of
+// their operations. An empty path yields a nil manifest.
+func Load(path string) (Manifest, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading persisted queries: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid persisted queries: %w", err)
+	}
+	for hash, query := range manifest {
+		if !strings.EqualFold(hash, Hash(query)) {
+			return nil, fmt.Errorf("invalid persisted queries: %s is not the SHA-256 hash of its operation", hash)
+		}
+		if hash != strings.ToLower(hash) {
+			delete(manifest, hash)
+			manifest[strings.ToLower(hash)] = query
+		}
+	}
+	return manifest, nil
+}
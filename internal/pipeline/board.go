@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+)
+
+// Board groups leads by status for a Kanban view. Every status gets a stage,
+// in pipeline order, even when it has no leads. The board's potential value
+// only counts open leads, leaving out WON and LOST.
+func (s *Service) Board(ctx context.Context, clientID, campaignID *string, leadsPerStage *int) (*model.Pipeline, error) {
+	stages, err := s.leads.GetPipelineStages(ctx, clientID, campaignID, leadsPerStage)
+	if err != nil {
+		return nil, err
+	}
+
+	byStatus := make(map[model.LeadStatus]*model.PipelineStage, len(stages))
+	for _, stage := range stages {
+		byStatus[stage.Status] = stage
+	}
+
+	board := &model.Pipeline{}
+	for _, status := range model.AllLeadStatus {
+		stage, ok := byStatus[status]
+		if !ok {
+			stage = &model.PipelineStage{Status: status, LeadIDs: []string{}}
+		}
+		board.Stages = append(board.Stages, stage)
+		board.TotalCount += stage.Count
+		if status != model.LeadStatusWon && status != model.LeadStatusLost {
+			board.PotentialValue += stage.PotentialValue
+		}
+	}
+
+	return board, nil
+}
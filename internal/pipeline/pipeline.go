@@ -0,0 +1,257 @@
+// Package pipeline enforces the order in which leads move through the sales
+// pipeline.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+var (
+	ErrNotFound          = errors.New("lead not found")
+	ErrInvalidTransition = errors.New("invalid lead status transition")
+	ErrLossReason        = errors.New("a loss reason is only given when marking a lead LOST")
+)
+
+// transitions is the lead pipeline:
+//
+//	NEW → CONTACTED → (ENGAGED →) QUALIFIED → MEETING → (PROPOSAL → NEGOTIATION →) WON
+//
+// Any open lead may be marked LOST, and leads that go quiet before the
+// proposal stage may be parked as DORMANT and later re-contacted. WON and LOST
+// are final, except that Reactivate reopens lost leads that re-engage.
+var transitions = map[model.LeadStatus][]model.LeadStatus{
+	model.LeadStatusNew:         {model.LeadStatusContacted, model.LeadStatusLost, model.LeadStatusDormant},
+	model.LeadStatusContacted:   {model.LeadStatusEngaged, model.LeadStatusQualified, model.LeadStatusLost, model.LeadStatusDormant},
+	model.LeadStatusEngaged:     {model.LeadStatusQualified, model.LeadStatusLost, model.LeadStatusDormant},
+	model.LeadStatusQualified:   {model.LeadStatusMeeting, model.LeadStatusLost, model.LeadStatusDormant},
+	model.LeadStatusMeeting:     {model.LeadStatusProposal, model.LeadStatusWon, model.LeadStatusLost, model.LeadStatusDormant},
+	model.LeadStatusProposal:    {model.LeadStatusNegotiation, model.LeadStatusWon, model.LeadStatusLost},
+	model.LeadStatusNegotiation: {model.LeadStatusWon, model.LeadStatusLost},
+	model.LeadStatusDormant:     {model.LeadStatusContacted, model.LeadStatusLost},
+}
+
+func CanTransition(from, to model.LeadStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns ErrInvalidTransition when a lead may not move from one
+// status to the other. Keeping the same status is always allowed.
+func Validate(from, to model.LeadStatus) error {
+	if from == to || CanTransition(from, to) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
+
+// LostHook runs after a lead is marked LOST. lossReason is nil when none was
+// given.
+type LostHook func(ctx context.Context, leadID string, lossReason *model.LossReason)
+
+// StatusHook runs after a lead is moved to a new status.
+type StatusHook func(ctx context.Context, leadID string, to model.LeadStatus)
+
+type Service struct {
+	leads       database.LeadRepository
+	lostHooks   []LostHook
+	statusHooks []StatusHook
+}
+
+func NewService(leads database.LeadRepository) *Service {
+	return &Service{leads: leads}
+}
+
+// OnLost registers a hook to run after each lead marked LOST.
+func (s *Service) OnLost(hook LostHook) {
+	s.lostHooks = append(s.lostHooks, hook)
+}
+
+// OnStatusChange registers a hook to run after each lead moved to a new
+// status, including leads marked LOST.
+func (s *Service) OnStatusChange(hook StatusHook) {
+	s.statusHooks = append(s.statusHooks, hook)
+}
+
+// ChangeStatus moves a lead to a new status and records who changed it and
+// why. changedBy is nil for changes made by the system.
+func (s *Service) ChangeStatus(ctx context.Context, id string, to model.LeadStatus, reason, changedBy *string) (*model.Lead, error) {
+	return s.changeStatus(ctx, id, to, reason, nil, changedBy)
+}
+
+// MarkLost moves a lead to LOST as ChangeStatus does, recording lossReason as
+// why it was lost.
+func (s *Service) MarkLost(ctx context.Context, id string, lossReason *model.LossReason, reason, changedBy *string) (*model.Lead, error) {
+	return s.changeStatus(ctx, id, model.LeadStatusLost, reason, lossReason, changedBy)
+}
+
+func (s *Service) changeStatus(ctx context.Context, id string, to model.LeadStatus, reason *string, lossReason *model.LossReason, changedBy *string) (*model.Lead, error) {
+	lead, err := s.leads.GetLeadByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, ErrNotFound
+	}
+	if !CanTransition(lead.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, lead.Status, to)
+	}
+
+	ok, err := s.leads.TransitionLeadStatus(ctx, id, lead.Status, to, reason, lossReason, changedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: lead status changed concurrently", ErrInvalidTransition)
+	}
+
+	if to == model.LeadStatusLost {
+		s.lost(ctx, id, lossReason)
+	}
+	s.changed(ctx, id, to)
+
+	return s.leads.GetLeadByID(ctx, id)
+}
+
+// Reactivate reopens a LOST lead that has re-engaged, moving it to ENGAGED.
+func (s *Service) Reactivate(ctx context.Context, id string, reason *string) (*model.Lead, error) {
+	ok, err := s.leads.TransitionLeadStatus(ctx, id, model.LeadStatusLost, model.LeadStatusEngaged, reason, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: lead is not LOST", ErrInvalidTransition)
+	}
+	s.changed(ctx, id, model.LeadStatusEngaged)
+
+	return s.leads.GetLeadByID(ctx, id)
+}
+
+// StatusChanged runs the hooks for a lead whose status was changed by an edit
+// of the lead, as changeStatus does. The edit writes and records the change
+// itself, without a loss reason.
+func (s *Service) StatusChanged(ctx context.Context, id string, to model.LeadStatus) {
+	if to == model.LeadStatusLost {
+		s.lost(ctx, id, nil)
+	}
+	s.changed(ctx, id, to)
+}
+
+func (s *Service) lost(ctx context.Context, id string, lossReason *model.LossReason) {
+	for _, hook := range s.lostHooks {
+		hook(ctx, id, lossReason)
+	}
+}
+
+func (s *Service) changed(ctx context.Context, id string, to model.LeadStatus) {
+	for _, hook := range s.statusHooks {
+		hook(ctx, id, to)
+	}
+}
+
+// Path returns the shortest sequence of statuses that takes a lead from one
+// status to another, excluding from. It never passes through LOST or DORMANT
+// and is nil when to cannot be reached.
+func Path(from, to model.LeadStatus) []model.LeadStatus {
+	previous := map[model.LeadStatus]model.LeadStatus{from: from}
+	queue := []model.LeadStatus{from}
+
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+
+		if status == to && status != from {
+			var path []model.LeadStatus
+			for ; status != from; status = previous[status] {
+				path = append([]model.LeadStatus{status}, path...)
+			}
+			return path
+		}
+
+		for _, next := range transitions[status] {
+			if _, seen := previous[next]; seen {
+				continue
+			}
+			if next != to && (next == model.LeadStatusLost || next == model.LeadStatusDormant) {
+				continue
+			}
+			previous[next] = status
+			queue = append(queue, next)
+		}
+	}
+
+	return nil
+}
+
+// ChangeStatuses moves each of the leads to a new status, as ChangeStatus
+// does, in one transaction. It returns the IDs of the leads it moved and why
+// each lead it could not move was left; leads missing from both were not
+// found.
+func (s *Service) ChangeStatuses(ctx context.Context, ids []string, to model.LeadStatus, reason, changedBy *string) ([]string, map[string]error, error) {
+	current, err := s.leads.GetLeadStatuses(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	failures := make(map[string]error)
+	from := make(map[string]model.LeadStatus, len(current))
+	for id, status := range current {
+		if !CanTransition(status, to) {
+			failures[id] = fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, status, to)
+			continue
+		}
+		from[id] = status
+	}
+
+	moved, err := s.leads.BulkTransitionLeadStatus(ctx, from, to, reason, changedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, id := range moved {
+		if to == model.LeadStatusLost {
+			s.lost(ctx, id, nil)
+		}
+		s.changed(ctx, id, to)
+	}
+
+	if len(moved) < len(from) {
+		for _, id := range moved {
+			delete(from, id)
+		}
+		for id := range from {
+			failures[id] = fmt.Errorf("%w: lead status changed concurrently", ErrInvalidTransition)
+		}
+	}
+
+	return moved, failures, nil
+}
+
+// Advance moves a lead to a later status through every intermediate stage on
+// Path, recording each change. A lead that is already there, or past it, is
+// returned unchanged.
+func (s *Service) Advance(ctx context.Context, id string, to model.LeadStatus, reason, changedBy *string) (*model.Lead, error) {
+	lead, err := s.leads.GetLeadByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, ErrNotFound
+	}
+
+	for _, status := range Path(lead.Status, to) {
+		if lead, err = s.ChangeStatus(ctx, id, status, reason, changedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return lead, nil
+}
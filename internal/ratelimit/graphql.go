@@ -0,0 +1,178 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"salesagency/internal/auth"
+)
+
+// Extension applies separate limits to GraphQL queries and mutations, keyed
+// by the authenticated user or API key and by client IP for anonymous
+// callers. Each operation counts once, however many parts its deferred
+// fragments are delivered in, and its first response reports the caller's
+// quota under the "rateLimit" extension. Subscriptions are not limited.
+type Extension struct {
+	Queries   *Limiter
+	Mutations *Limiter
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = Extension{}
+
+func (Extension) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	op := graphql.GetOperationContext(ctx).Operation
+	if op == nil {
+		return next(ctx)
+	}
+
+	var limiter *Limiter
+	switch op.Operation {
+	case ast.Query:
+		limiter = e.Queries
+	case ast.Mutation:
+		limiter = e.Mutations
+	default:
+		return next(ctx)
+	}
+	if limiter == nil || !limiter.limit.Enabled() {
+		return next(ctx)
+	}
+
+	state, _ := ctx.Value(contextKey{}).(*requestState)
+	result := limiter.Allow(callerKey(ctx, state))
+	quota := map[string]interface{}{
+		"limit":     result.Limit,
+		"remaining": result.Remaining,
+	}
+	if result.RetryAfter > 0 {
+		quota["retryAfter"] = retryAfterSeconds(result)
+	}
+
+	if !result.Allowed {
+		if state != nil && state.w != nil {
+			state.w.limited = true
+			state.w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(result)))
+		}
+		return graphql.OneShot(&graphql.Response{
+			Errors: gqlerror.List{{
+				Message:    "rate limit exceeded",
+				Extensions: map[string]interface{}{"code": "RATE_LIMITED"},
+			}},
+			Extensions: map[string]interface{}{"rateLimit": quota},
+		})
+	}
+
+	responses := next(ctx)
+	first := true
+	return func(ctx context.Context) *graphql.Response {
+		resp := responses(ctx)
+		if first && resp != nil {
+			first = false
+			if resp.Extensions == nil {
+				resp.Extensions = map[string]interface{}{}
+			}
+			resp.Extensions["rateLimit"] = quota
+		}
+		return resp
+	}
+}
+
+func callerKey(ctx context.Context, state *requestState) string {
+	if user := auth.UserFromContext(ctx); user != nil {
+		return "user:" + user.ID
+	}
+	if state != nil {
+		return "ip:" + state.ip
+	}
+	return "anonymous"
+}
+
+func retryAfterSeconds(result Result) int {
+	return int(math.Max(1, math.Ceil(result.RetryAfter.Seconds())))
+}
+
+type contextKey struct{}
+
+type requestState struct {
+	ip string
+	w  *responseWriter
+}
+
+// Middleware records the client IP for anonymous callers and lets the
+// extension answer limited requests with 429 and Retry-After. Websocket
+// upgrades keep the original writer, which must stay hijackable.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &requestState{ip: r.RemoteAddr}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			state.ip = host
+		}
+
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			state.w = &responseWriter{ResponseWriter: w}
+			w = state.w
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, state)))
+	})
+}
+
+// responseWriter turns the 200 the GraphQL transports send for a limited
+// operation into a 429.
+type responseWriter struct {
+	http.ResponseWriter
+	limited     bool
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.limited && code == http.StatusOK {
+		code = http.StatusTooManyRequests
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets the multipart transport send deferred fragments as they
+// resolve.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
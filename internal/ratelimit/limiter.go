@@ -0,0 +1,127 @@
+// Package ratelimit throttles GraphQL operations per caller with token
+// buckets.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Burst requests at once, refilled at Burst per Per.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// ParseLimit reads limits written as "<requests>/<unit>" with unit s, m or h,
+// e.g. "120/m". "off" disables limiting and yields the zero Limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "off" {
+		return Limit{}, nil
+	}
+
+	count, unit, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: use <requests>/<s|m|h> or off", s)
+	}
+
+	per := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[unit]
+	if per == 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: unit must be s, m or h", s)
+	}
+	return Limit{Burst: n, Per: per}, nil
+}
+
+func (l Limit) Enabled() bool {
+	return l.Burst > 0
+}
+
+// Result describes the caller's bucket after a request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed; it is
+	// zero when Remaining is positive.
+	RetryAfter time.Duration
+}
+
+// sweepInterval bounds how often idle buckets are dropped.
+const sweepInterval = time.Minute
+
+// Limiter keeps one token bucket per key in memory, so limits apply per
+// server instance.
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{limit: limit, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// Allow takes a token from key's bucket if one is available.
+func (l *Limiter) Allow(key string) Result {
+	if !l.limit.Enabled() {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.rate())
+	b.updated = now
+
+	result := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	}
+	result.Remaining = int(b.tokens)
+	if result.Remaining == 0 {
+		result.RetryAfter = time.Duration((1 - b.tokens) / l.rate() * float64(time.Second))
+	}
+	return result
+}
+
+// rate is the refill rate in tokens per second.
+func (l *Limiter) rate() float64 {
+	return float64(l.limit.Burst) / l.limit.Per.Seconds()
+}
+
+// sweep drops buckets that have been idle long enough to refill completely,
+// since a fresh bucket behaves the same.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
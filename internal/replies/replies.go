@@ -0,0 +1,227 @@
+// Package replies classifies inbound replies from leads with the LLM provider
+// and routes them by what they ask for: unsubscribe requests opt the lead out
+// of the channel, interested leads are escalated to a sales rep, and
+// out-of-office replies push the lead's sequences back until it returns.
+package replies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/llm"
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+const (
+	// classifyTimeout bounds the LLM call, which runs while the provider's
+	// inbound webhook waits. It stays well below the shortest webhook
+	// deadline, Twilio's 15 seconds, past which the reply is posted again and
+	// recorded twice.
+	classifyTimeout = 5 * time.Second
+
+	// OutOfOfficeDelay is how long sequences wait after an out-of-office
+	// reply that gives no return date.
+	OutOfOfficeDelay = 7 * 24 * time.Hour
+	// MaxOutOfOfficeDelay caps how far a return date can push sequences.
+	MaxOutOfOfficeDelay = 60 * 24 * time.Hour
+
+	maxReplyLength    = 4000
+	unsubscribeReason = "replied asking to unsubscribe"
+)
+
+const classifyPrompt = `You classify replies from sales prospects to outreach messages.
+Reply with a JSON object {"intent": "...", "returnDate": "YYYY-MM-DD" or null}. The intent is one of:
+INTERESTED: they want to talk, book a meeting, see pricing or learn more.
+NOT_NOW: they are not interested at the moment or ask to be contacted later.
+UNSUBSCRIBE: they ask not to be contacted again.
+WRONG_PERSON: they are not the right contact or have left the company.
+OUT_OF_OFFICE: an automatic away or vacation reply.
+Set returnDate only for OUT_OF_OFFICE replies that say when the sender is back.`
+
+// EscalationHook runs after an interested lead has been handed off to a rep.
+type EscalationHook func(ctx context.Context, handoff *model.LeadHandoff, lead *model.Lead)
+
+// Classification is what a reply asks for. ReturnsOn is set for
+// out-of-office replies that say when the sender is back.
+type Classification struct {
+	Intent    model.ReplyIntent
+	ReturnsOn *time.Time
+}
+
+type Service struct {
+	db          *database.DB
+	llm         llm.Provider
+	onEscalated []EscalationHook
+	now         func() time.Time
+}
+
+func NewService(db *database.DB, provider llm.Provider) *Service {
+	return &Service{db: db, llm: provider, now: time.Now}
+}
+
+// OnEscalated registers a hook to run after each escalation.
+func (s *Service) OnEscalated(hook EscalationHook) {
+	s.onEscalated = append(s.onEscalated, hook)
+}
+
+// HandleReply classifies the reply, records its intent and routes it. It is
+// registered as a channels.ReplyHook, ahead of the hooks of the sequence
+// engine and nurture, which let out-of-office replies through. Replies are
+// left unclassified when no LLM provider is configured or it fails.
+func (s *Service) HandleReply(ctx context.Context, reply *model.Interaction) {
+	if reply.Message == nil || strings.TrimSpace(*reply.Message) == "" {
+		return
+	}
+	log := logging.FromContext(ctx).With("lead_id", reply.Lead.ID, "interaction_id", reply.ID)
+
+	classifyCtx, cancel := context.WithTimeout(ctx, classifyTimeout)
+	classification, err := s.Classify(classifyCtx, *reply.Message)
+	cancel()
+	if err != nil {
+		if !errors.Is(err, llm.ErrNotConfigured) {
+			log.Warn("Failed to classify reply", "error", err)
+		}
+		return
+	}
+
+	if err := s.db.SetInteractionReplyIntent(ctx, reply.ID, classification.Intent); err != nil {
+		log.Error("Failed to record reply intent", "error", err)
+		return
+	}
+	log.Info("Classified reply", "intent", classification.Intent)
+
+	switch classification.Intent {
+	case model.ReplyIntentUnsubscribe:
+		if _, err := s.db.OptOutLead(ctx, reply.Lead.ID, reply.Channel, unsubscribeReason); err != nil {
+			log.Error("Failed to opt out lead after reply", "error", err)
+		}
+	case model.ReplyIntentInterested:
+		s.escalate(ctx, log, reply)
+	case model.ReplyIntentOutOfOffice:
+		until := s.resumeAt(classification.ReturnsOn)
+		postponed, err := s.db.PostponeLeadEnrollments(ctx, reply.Lead.ID, until)
+		if err != nil {
+			log.Error("Failed to postpone sequences after out-of-office reply", "error", err)
+			return
+		}
+		if postponed > 0 {
+			log.Info("Postponed sequences after out-of-office reply", "enrollments", postponed, "until", until)
+		}
+	}
+}
+
+// Classify asks the LLM provider what the reply asks for.
+func (s *Service) Classify(ctx context.Context, text string) (*Classification, error) {
+	if runes := []rune(text); len(runes) > maxReplyLength {
+		text = string(runes[:maxReplyLength])
+	}
+
+	reply, err := s.llm.Complete(ctx, &llm.Request{
+		System:   classifyPrompt,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: text}},
+		JSON:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Some models wrap the object in prose or a code fence.
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("classification reply is not a JSON object")
+	}
+
+	var result struct {
+		Intent     string  `json:"intent"`
+		ReturnDate *string `json:"returnDate"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("error decoding classification: %w", err)
+	}
+
+	classification := &Classification{Intent: model.ReplyIntent(strings.ToUpper(strings.TrimSpace(result.Intent)))}
+	if !classification.Intent.IsValid() {
+		return nil, fmt.Errorf("unknown reply intent %q", result.Intent)
+	}
+	if classification.Intent == model.ReplyIntentOutOfOffice && result.ReturnDate != nil {
+		// An unreadable date falls back to OutOfOfficeDelay.
+		if date, err := time.Parse("2006-01-02", strings.TrimSpace(*result.ReturnDate)); err == nil {
+			classification.ReturnsOn = &date
+		}
+	}
+	return classification, nil
+}
+
+// resumeAt is when sequences continue after an out-of-office reply: the
+// return date, or OutOfOfficeDelay from now when there is none or it has
+// passed, and no later than MaxOutOfOfficeDelay from now.
+func (s *Service) resumeAt(returnsOn *time.Time) time.Time {
+	now := s.now()
+	if returnsOn == nil || !returnsOn.After(now) {
+		return now.Add(OutOfOfficeDelay)
+	}
+	if latest := now.Add(MaxOutOfOfficeDelay); returnsOn.After(latest) {
+		return latest
+	}
+	return *returnsOn
+}
+
+// escalate hands the lead to the agency's least busy sales rep, unless it
+// is already handed off.
+func (s *Service) escalate(ctx context.Context, log *slog.Logger, reply *model.Interaction) {
+	agencyID, err := s.db.GetLeadAgencyID(ctx, reply.Lead.ID)
+	if err != nil || agencyID == "" {
+		if err != nil {
+			log.Error("Failed to find agency to escalate lead", "error", err)
+		}
+		return
+	}
+	ctx = tenant.WithAgency(ctx, agencyID)
+
+	handedOff, err := s.db.IsLeadHandedOff(ctx, reply.Lead.ID)
+	if err != nil {
+		log.Error("Failed to check lead handoff", "error", err)
+		return
+	}
+	if handedOff {
+		return
+	}
+
+	repID, err := s.db.GetAvailableSalesRep(ctx)
+	if err != nil {
+		log.Error("Failed to find sales rep to escalate lead", "error", err)
+		return
+	}
+	if repID == "" {
+		log.Warn("No active sales rep to escalate interested lead to")
+		return
+	}
+
+	handoff, err := s.db.CreateLeadHandoff(ctx, &model.LeadHandoff{
+		LeadID:    reply.Lead.ID,
+		RepID:     repID,
+		Reason:    fmt.Sprintf("Replied with interest by %s", reply.Channel),
+		CreatedAt: s.now(),
+	})
+	if errors.Is(err, database.ErrLeadHandedOff) {
+		return
+	}
+	if err != nil {
+		log.Error("Failed to escalate interested lead", "error", err)
+		return
+	}
+	log.Info("Escalated interested lead", "handoff_id", handoff.ID, "rep_id", repID)
+
+	for _, hook := range s.onEscalated {
+		hook(ctx, handoff, reply.Lead)
+	}
+}
@@ -0,0 +1,58 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"salesagency/graph/model"
+)
+
+// renderCSV writes the summary, campaign and agent tables one after another,
+// separated by blank rows.
+func renderCSV(report *model.ClientReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"Client", report.Client.Name},
+		{"Period", report.Period},
+		{"Period start", report.PeriodStart.Format("2006-01-02")},
+		{"Period end", report.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02")},
+		{},
+		{"Leads generated", strconv.Itoa(report.LeadsGenerated)},
+		{"Messages sent", strconv.Itoa(report.MessagesSent)},
+		{"Replies", strconv.Itoa(report.Replies)},
+		{"Meetings booked", strconv.Itoa(report.MeetingsBooked)},
+		{"Conversions", strconv.Itoa(report.Conversions)},
+		{"Spend", money(report.Spend, report.Currency)},
+		{},
+		{"Campaign", "Status", "Leads generated", "Messages sent", "Replies", "Meetings booked", "Conversions", "Spend"},
+	}
+	for _, c := range report.Campaigns {
+		rows = append(rows, []string{
+			c.Name, string(c.Status), strconv.Itoa(c.LeadsGenerated), strconv.Itoa(c.MessagesSent),
+			strconv.Itoa(c.Replies), strconv.Itoa(c.MeetingsBooked), strconv.Itoa(c.Conversions), money(c.Spend, c.Currency),
+		})
+	}
+
+	rows = append(rows, []string{}, []string{"Agent", "Leads engaged", "Messages sent", "Replies", "Meetings booked"})
+	for _, a := range report.Agents {
+		rows = append(rows, []string{
+			a.Name, strconv.Itoa(a.LeadsEngaged), strconv.Itoa(a.MessagesSent),
+			strconv.Itoa(a.Replies), strconv.Itoa(a.MeetingsBooked),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("error writing report csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// money writes amount with the currency's minor digits, followed by its
+// code.
+func money(amount float64, currency string) string {
+	return strconv.FormatFloat(amount, 'f', model.MinorDigits(currency), 64) + " " + currency
+}
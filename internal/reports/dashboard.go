@@ -0,0 +1,142 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+// topCampaignLimit is how many campaigns the dashboard ranks.
+const topCampaignLimit = 5
+
+const week = 7 * 24 * time.Hour
+
+// funnelStages are the stages of the conversion funnel, in pipeline order.
+// LOST and DORMANT are exits rather than stages.
+var funnelStages = func() []model.LeadStatus {
+	var stages []model.LeadStatus
+	for _, status := range model.AllLeadStatus {
+		if status != model.LeadStatusLost && status != model.LeadStatusDormant {
+			stages = append(stages, status)
+		}
+	}
+	return stages
+}()
+
+// Dashboard collects the agency-wide KPIs for period. Lead stages are a
+// snapshot of now; the funnel follows the leads created in the period, and
+// the week-over-week deltas compare the period's last seven days, up to now,
+// with the seven days before.
+func (s *Service) Dashboard(ctx context.Context, period Period, now time.Time) (*model.DashboardStats, error) {
+	stats := &model.DashboardStats{Period: period.Label}
+
+	stageCounts, err := s.db.GetLeadStageCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byStatus := make(map[model.LeadStatus]int, len(stageCounts))
+	for _, count := range stageCounts {
+		byStatus[count.Status] = count.Count
+	}
+	for _, status := range model.AllLeadStatus {
+		stats.LeadsByStage = append(stats.LeadsByStage, &model.StageCount{Status: status, Count: byStatus[status]})
+		stats.TotalLeads += byStatus[status]
+	}
+
+	reach, err := s.db.GetFunnelReach(ctx, funnelStages, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	stats.Funnel = funnel(reach)
+
+	stats.MessagesByChannel, err = s.db.GetMessagesByChannel(ctx, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.ActiveAgents, err = s.db.CountActiveAIAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.TopCampaigns, err = s.db.GetTopCampaigns(ctx, period.Start, period.End, topCampaignLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, campaign := range stats.TopCampaigns {
+		campaign.ReplyRate = rate(campaign.Replies, campaign.MessagesSent)
+	}
+
+	end := period.End
+	if now.Before(end) {
+		end = now
+	}
+	current, err := s.db.GetActivityCounts(ctx, end.Add(-week), end)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.db.GetActivityCounts(ctx, end.Add(-2*week), end.Add(-week))
+	if err != nil {
+		return nil, err
+	}
+	stats.WeekOverWeek = weekOverWeek(current, previous)
+
+	return stats, nil
+}
+
+// funnel turns the count of leads by furthest stage reached into the count
+// of leads that reached at least each stage.
+func funnel(reach map[model.LeadStatus]int) []*model.FunnelStage {
+	stages := make([]*model.FunnelStage, len(funnelStages))
+	reached := 0
+	for i := len(funnelStages) - 1; i >= 0; i-- {
+		reached += reach[funnelStages[i]]
+		stages[i] = &model.FunnelStage{Status: funnelStages[i], Count: reached}
+	}
+
+	for i, stage := range stages {
+		if i > 0 && stages[i-1].Count > 0 {
+			r := rate(stage.Count, stages[i-1].Count)
+			stage.ConversionRate = &r
+		}
+		if stages[0].Count > 0 {
+			r := rate(stage.Count, stages[0].Count)
+			stage.OverallRate = &r
+		}
+	}
+
+	return stages
+}
+
+func weekOverWeek(current, previous *database.ActivityCounts) []*model.MetricDelta {
+	pairs := []struct {
+		metric            model.DashboardMetric
+		current, previous int
+	}{
+		{model.DashboardMetricNewLeads, current.NewLeads, previous.NewLeads},
+		{model.DashboardMetricMessagesSent, current.MessagesSent, previous.MessagesSent},
+		{model.DashboardMetricReplies, current.Replies, previous.Replies},
+		{model.DashboardMetricMeetingsBooked, current.MeetingsBooked, previous.MeetingsBooked},
+		{model.DashboardMetricConversions, current.Conversions, previous.Conversions},
+	}
+
+	deltas := make([]*model.MetricDelta, len(pairs))
+	for i, p := range pairs {
+		delta := &model.MetricDelta{Metric: p.metric, Current: p.current, Previous: p.previous}
+		if p.previous > 0 {
+			change := float64(p.current-p.previous) / float64(p.previous)
+			delta.Change = &change
+		}
+		deltas[i] = delta
+	}
+	return deltas
+}
+
+func rate(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) / float64(of)
+}
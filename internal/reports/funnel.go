@@ -0,0 +1,67 @@
+package reports
+
+import (
+	"context"
+	"sort"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+// ConversionFunnel follows the leads matching filter and created in period
+// through the funnel stages, like the dashboard's funnel, with the median
+// time leads spent in each stage. With cohortBy, it also builds the funnel
+// of each source or campaign, largest first.
+func (s *Service) ConversionFunnel(ctx context.Context, filter *model.LeadFilterInput, period Period, cohortBy *model.FunnelCohortBy) (*model.ConversionFunnel, error) {
+	overall, err := s.db.GetConversionFunnel(ctx, filter, funnelStages, period.Start, period.End, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ConversionFunnel{Period: period.Label, Cohorts: []*model.FunnelCohort{}}
+	if len(overall) > 0 {
+		result.Stages = cohortFunnel(overall[0])
+	} else {
+		result.Stages = funnel(nil)
+	}
+	result.TotalLeads = result.Stages[0].Count
+
+	if cohortBy == nil {
+		return result, nil
+	}
+
+	cohorts, err := s.db.GetConversionFunnel(ctx, filter, funnelStages, period.Start, period.End, cohortBy)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cohorts {
+		cohort := &model.FunnelCohort{Stages: cohortFunnel(c)}
+		cohort.TotalLeads = cohort.Stages[0].Count
+		if *cohortBy == model.FunnelCohortByCampaign {
+			cohort.CampaignID = c.Key
+		} else {
+			cohort.Source = c.Key
+		}
+		result.Cohorts = append(result.Cohorts, cohort)
+	}
+	// Leads without a source or campaign come last.
+	sort.SliceStable(result.Cohorts, func(i, j int) bool {
+		a, b := result.Cohorts[i], result.Cohorts[j]
+		if aNone, bNone := a.Source == nil && a.CampaignID == nil, b.Source == nil && b.CampaignID == nil; aNone != bNone {
+			return bNone
+		}
+		return a.TotalLeads > b.TotalLeads
+	})
+
+	return result, nil
+}
+
+func cohortFunnel(cohort *database.FunnelCohort) []*model.FunnelStage {
+	stages := funnel(cohort.Reach)
+	for _, stage := range stages {
+		if days, ok := cohort.MedianDays[stage.Status]; ok {
+			stage.MedianDaysInStage = &days
+		}
+	}
+	return stages
+}
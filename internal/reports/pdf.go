@@ -0,0 +1,123 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"salesagency/graph/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfRowHeight = 7.0
+	pdfNameWidth = 60.0
+)
+
+// renderPDF lays the report out on A4 pages using the core Helvetica font.
+// Core fonts only cover cp1252, so text is translated from UTF-8 first.
+func renderPDF(report *model.ClientReport) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(report.Client.Name+" performance report "+report.Period, true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, tr(report.Client.Name), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Performance report %s (%s to %s)", report.Period,
+		report.PeriodStart.Format("2 Jan 2006"), report.PeriodEnd.AddDate(0, 0, -1).Format("2 Jan 2006")), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	pdfHeading(pdf, "Summary")
+	summary := [][2]string{
+		{"Leads generated", strconv.Itoa(report.LeadsGenerated)},
+		{"Messages sent", strconv.Itoa(report.MessagesSent)},
+		{"Replies", strconv.Itoa(report.Replies)},
+		{"Meetings booked", strconv.Itoa(report.MeetingsBooked)},
+		{"Conversions", strconv.Itoa(report.Conversions)},
+		{"Spend", money(report.Spend, report.Currency)},
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range summary {
+		pdf.CellFormat(pdfNameWidth, pdfRowHeight, row[0], "B", 0, "L", false, 0, "")
+		pdf.CellFormat(40, pdfRowHeight, row[1], "B", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	pdfHeading(pdf, "Campaigns")
+	campaignRows := make([][]string, 0, len(report.Campaigns))
+	for _, c := range report.Campaigns {
+		campaignRows = append(campaignRows, []string{
+			tr(c.Name), string(c.Status), strconv.Itoa(c.LeadsGenerated), strconv.Itoa(c.MessagesSent),
+			strconv.Itoa(c.Replies), strconv.Itoa(c.MeetingsBooked), money(c.Spend, c.Currency),
+		})
+	}
+	pdfTable(pdf, []string{"Campaign", "Status", "Leads", "Sent", "Replies", "Meetings", "Spend"},
+		[]float64{pdfNameWidth, 22, 18, 18, 18, 20, 24}, campaignRows)
+	pdf.Ln(6)
+
+	pdfHeading(pdf, "AI agents")
+	agentRows := make([][]string, 0, len(report.Agents))
+	for _, a := range report.Agents {
+		agentRows = append(agentRows, []string{
+			tr(a.Name), strconv.Itoa(a.LeadsEngaged), strconv.Itoa(a.MessagesSent),
+			strconv.Itoa(a.Replies), strconv.Itoa(a.MeetingsBooked),
+		})
+	}
+	pdfTable(pdf, []string{"Agent", "Leads engaged", "Sent", "Replies", "Meetings"},
+		[]float64{pdfNameWidth, 30, 18, 18, 20}, agentRows)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("error rendering report pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func pdfHeading(pdf *fpdf.Fpdf, text string) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, text, "", 1, "L", false, 0, "")
+}
+
+// pdfTable draws a header row followed by rows; the first column is left
+// aligned and the rest right aligned.
+func pdfTable(pdf *fpdf.Fpdf, header []string, widths []float64, rows [][]string) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for i, title := range header {
+		pdf.CellFormat(widths[i], pdfRowHeight, title, "B", 0, align(i), true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(rows) == 0 {
+		pdf.CellFormat(0, pdfRowHeight, "No activity in this period", "", 1, "L", false, 0, "")
+		return
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			pdf.CellFormat(widths[i], pdfRowHeight, fit(pdf, cell, widths[i]-2), "B", 0, align(i), false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+func align(column int) string {
+	if column == 0 {
+		return "L"
+	}
+	return "R"
+}
+
+// fit shortens text with an ellipsis until it fits in width.
+func fit(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
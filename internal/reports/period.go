@@ -0,0 +1,65 @@
+package reports
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a half-open reporting window [Start, End) in UTC.
+type Period struct {
+	Label string
+	Start time.Time
+	End   time.Time
+}
+
+// ParsePeriod accepts a month ("2025-03"), a quarter ("2025-Q1") or a year
+// ("2025").
+func ParsePeriod(s string) (Period, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	if year, quarter, ok := strings.Cut(s, "-Q"); ok {
+		y, err := strconv.Atoi(year)
+		q, qerr := strconv.Atoi(quarter)
+		if err != nil || qerr != nil || q < 1 || q > 4 {
+			return Period{}, fmt.Errorf("invalid report period %q: quarters are Q1 to Q4", s)
+		}
+		return quarterPeriod(y, q), nil
+	}
+
+	if month, err := time.Parse("2006-01", s); err == nil {
+		return monthPeriod(month), nil
+	}
+
+	if year, err := time.Parse("2006", s); err == nil {
+		return Period{Label: s, Start: year, End: year.AddDate(1, 0, 0)}, nil
+	}
+
+	return Period{}, fmt.Errorf("invalid report period %q: use YYYY-MM, YYYY-Qn or YYYY", s)
+}
+
+// PreviousMonth is the last full calendar month before now.
+func PreviousMonth(now time.Time) Period {
+	now = now.UTC()
+	return monthPeriod(time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// PreviousQuarter is the last full calendar quarter before now.
+func PreviousQuarter(now time.Time) Period {
+	now = now.UTC()
+	q := (int(now.Month())-1)/3 + 1
+	if q == 1 {
+		return quarterPeriod(now.Year()-1, 4)
+	}
+	return quarterPeriod(now.Year(), q-1)
+}
+
+func monthPeriod(month time.Time) Period {
+	return Period{Label: month.Format("2006-01"), Start: month, End: month.AddDate(0, 1, 0)}
+}
+
+func quarterPeriod(year, quarter int) Period {
+	start := time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+	return Period{Label: fmt.Sprintf("%d-Q%d", year, quarter), Start: start, End: start.AddDate(0, 3, 0)}
+}
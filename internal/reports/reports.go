@@ -0,0 +1,122 @@
+// Package reports builds per-client performance reports and renders them as
+// PDF or CSV.
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/messaging/email"
+)
+
+var ErrClientNotFound = errors.New("client not found")
+
+// File is a rendered report.
+type File struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type Service struct {
+	db     *database.DB
+	sender email.Sender
+}
+
+func NewService(db *database.DB, sender email.Sender) *Service {
+	return &Service{db: db, sender: sender}
+}
+
+// Generate collects the client's campaign and agent results for the period.
+func (s *Service) Generate(ctx context.Context, clientID string, period Period) (*model.ClientReport, error) {
+	client, err := s.db.GetClientByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrClientNotFound
+	}
+
+	currency, err := s.db.GetClientBaseCurrency(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns, err := s.db.GetClientReportCampaigns(ctx, clientID, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := s.db.GetClientReportAgents(ctx, clientID, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ClientReport{
+		Client:      client,
+		Period:      period.Label,
+		PeriodStart: period.Start,
+		PeriodEnd:   period.End,
+		Currency:    currency,
+		Campaigns:   campaigns,
+		Agents:      agents,
+	}
+	for _, c := range campaigns {
+		report.LeadsGenerated += c.LeadsGenerated
+		report.MessagesSent += c.MessagesSent
+		report.Replies += c.Replies
+		report.MeetingsBooked += c.MeetingsBooked
+		report.Conversions += c.Conversions
+		report.Spend += c.BaseSpend
+	}
+
+	return report, nil
+}
+
+func Render(report *model.ClientReport, format model.ReportFormat) (*File, error) {
+	switch format {
+	case model.ReportFormatPDF:
+		data, err := renderPDF(report)
+		if err != nil {
+			return nil, err
+		}
+		return &File{Filename: filename(report, "pdf"), ContentType: "application/pdf", Data: data}, nil
+	case model.ReportFormatCSV:
+		data, err := renderCSV(report)
+		if err != nil {
+			return nil, err
+		}
+		return &File{Filename: filename(report, "csv"), ContentType: "text/csv", Data: data}, nil
+	default:
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+func filename(report *model.ClientReport, ext string) string {
+	return fmt.Sprintf("%s-report-%s.%s", slug(report.Client.Name), report.Period, ext)
+}
+
+// slug keeps ASCII letters and digits and joins everything else with dashes.
+func slug(name string) string {
+	var b []byte
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b = append(b, byte(r))
+		case r >= 'A' && r <= 'Z':
+			b = append(b, byte(r-'A'+'a'))
+		case len(b) > 0 && b[len(b)-1] != '-':
+			b = append(b, '-')
+		}
+	}
+	if len(b) > 0 && b[len(b)-1] == '-' {
+		b = b[:len(b)-1]
+	}
+	if len(b) == 0 {
+		return "client"
+	}
+	return string(b)
+}
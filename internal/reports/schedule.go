@@ -0,0 +1,96 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/messaging/email"
+)
+
+// Cadence is how often scheduled reports go out.
+type Cadence string
+
+const (
+	CadenceMonthly   Cadence = "monthly"
+	CadenceQuarterly Cadence = "quarterly"
+)
+
+// Cron returns when reports for the cadence are sent: early on the first day
+// of each month or quarter, covering the period that just ended.
+func (c Cadence) Cron() (string, error) {
+	switch c {
+	case CadenceMonthly:
+		return "0 6 1 * *", nil
+	case CadenceQuarterly:
+		return "0 6 1 1,4,7,10 *", nil
+	default:
+		return "", fmt.Errorf("unknown report cadence %q", c)
+	}
+}
+
+// LastPeriod is the most recent full period for the cadence.
+func (c Cadence) LastPeriod(now time.Time) Period {
+	if c == CadenceQuarterly {
+		return PreviousQuarter(now)
+	}
+	return PreviousMonth(now)
+}
+
+// SendAll emails the period's report, as PDF and CSV attachments, to every
+// active client. A failure for one client is logged and does not stop the
+// rest; it returns how many reports were sent.
+func (s *Service) SendAll(ctx context.Context, period Period) (int, error) {
+	active := model.ClientStatusActive
+	clients, err := s.db.GetClientsByStatus(ctx, &active, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, client := range clients {
+		if err := s.Send(ctx, client.ID, period); err != nil {
+			slog.Error("error sending client report", "period", period.Label, "client_id", client.ID, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Send emails one client's report for the period to the client's contact.
+func (s *Service) Send(ctx context.Context, clientID string, period Period) error {
+	report, err := s.Generate(ctx, clientID, period)
+	if err != nil {
+		return err
+	}
+
+	msg := &email.Message{
+		To:      report.Client.Email,
+		ToName:  report.Client.ContactPerson,
+		Subject: fmt.Sprintf("%s performance report for %s", report.Client.Name, period.Label),
+		Body: fmt.Sprintf("Hi %s,\n\nAttached is your performance report for %s.\n\n"+
+			"Leads generated: %d\nMeetings booked: %d\nSpend: %s\n",
+			report.Client.ContactPerson, period.Label, report.LeadsGenerated, report.MeetingsBooked, money(report.Spend, report.Currency)),
+	}
+
+	for _, format := range []model.ReportFormat{model.ReportFormatPDF, model.ReportFormatCSV} {
+		file, err := Render(report, format)
+		if err != nil {
+			return err
+		}
+		msg.Attachments = append(msg.Attachments, email.Attachment{
+			Filename:    file.Filename,
+			ContentType: file.ContentType,
+			Data:        file.Data,
+		})
+	}
+
+	if _, err := s.sender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("error emailing report: %w", err)
+	}
+	return nil
+}
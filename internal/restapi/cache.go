@@ -0,0 +1,91 @@
+package restapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cacheControl lets callers keep responses but makes them revalidate before
+// each use, which costs them a 304 when nothing has changed.
+const cacheControl = "private, no-cache"
+
+// writeCached writes v with status 200 like writeJSON, adding an ETag that
+// hashes the body and, unless lastModified is zero, a Last-Modified header.
+// When the request's If-None-Match, or failing that its If-Modified-Since,
+// shows the caller already has this body, it answers 304 Not Modified
+// without one.
+func writeCached(w http.ResponseWriter, r *http.Request, v interface{}, lastModified time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error encoding REST API response: %w", err))
+		return
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+	header.Add("Vary", apiKeyHeader)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		slog.Error("error writing REST API response", "error", err)
+	}
+}
+
+// notModified evaluates the request's conditional headers. If-Modified-Since
+// is ignored when If-None-Match is present, as RFC 9110 requires.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagListed(ifNoneMatch, etag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagListed reports whether an If-None-Match header lists etag. Tags are
+// compared weakly, so one a proxy marked W/ still matches.
+func etagListed(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// modifiedAt is when a record last changed: updatedAt, or createdAt if it
+// has never been updated.
+func modifiedAt(createdAt time.Time, updatedAt *time.Time) time.Time {
+	if updatedAt != nil && updatedAt.After(createdAt) {
+		return *updatedAt
+	}
+	return createdAt
+}
@@ -0,0 +1,185 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"salesagency/graph/model"
+	"salesagency/internal/money"
+)
+
+func (a *api) listCampaigns(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := &model.CampaignFilterInput{ClientID: optionalParam(r, "clientId")}
+	for _, value := range r.URL.Query()["status"] {
+		status := model.CampaignStatus(value)
+		if !status.IsValid() {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid campaign status %q", value))
+			return
+		}
+		filter.Status = append(filter.Status, status)
+	}
+
+	campaigns, err := a.store.Campaigns.GetCampaignsByFilter(r.Context(), filter, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]Campaign, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		resp = append(resp, campaignFromModel(campaign))
+	}
+	writeCached(w, r, resp, time.Time{})
+}
+
+func (a *api) getCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := a.store.Campaigns.GetCampaignByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if campaign == nil {
+		writeError(w, http.StatusNotFound, errCampaignNotFound)
+		return
+	}
+	writeCached(w, r, campaignFromModel(campaign), modifiedAt(campaign.CreatedAt, campaign.UpdatedAt))
+}
+
+func (a *api) createCampaign(w http.ResponseWriter, r *http.Request) {
+	var input CampaignInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	campaign := &model.Campaign{
+		Status:    model.CampaignStatusDraft,
+		CreatedAt: time.Now(),
+	}
+	if err := input.apply(campaign); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if input.Currency != nil {
+		currency, err := money.ParseCurrency(*input.Currency)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		campaign.Currency = currency
+	}
+	if !a.clientExists(w, r, campaign.ClientID) {
+		return
+	}
+
+	created, err := a.store.Campaigns.CreateCampaign(r.Context(), campaign)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, campaignFromModel(created))
+}
+
+func (a *api) updateCampaign(w http.ResponseWriter, r *http.Request) {
+	var input CampaignInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	campaign, err := a.store.Campaigns.GetCampaignByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if campaign == nil {
+		writeError(w, http.StatusNotFound, errCampaignNotFound)
+		return
+	}
+
+	if err := input.apply(campaign); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !a.clientExists(w, r, campaign.ClientID) {
+		return
+	}
+	now := time.Now()
+	campaign.UpdatedAt = &now
+
+	updated, err := a.store.Campaigns.UpdateCampaign(r.Context(), campaign)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, campaignFromModel(updated))
+}
+
+func (a *api) deleteCampaign(w http.ResponseWriter, r *http.Request) {
+	deleted, err := a.store.Campaigns.DeleteCampaign(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, errCampaignNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientExists checks that a campaign's client belongs to the caller's
+// agency, writing an error response when it does not.
+func (a *api) clientExists(w http.ResponseWriter, r *http.Request, clientID *string) bool {
+	if clientID == nil {
+		return true
+	}
+	client, err := a.store.Clients.GetClientByID(r.Context(), *clientID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if client == nil {
+		writeError(w, http.StatusBadRequest, errClientNotFound)
+		return false
+	}
+	return true
+}
+
+func (input *CampaignInput) apply(campaign *model.Campaign) error {
+	if input.Name == "" {
+		return errors.New("name is required")
+	}
+	if input.StartDate.IsZero() {
+		return errors.New("startDate is required")
+	}
+	if input.EndDate != nil && input.EndDate.Before(input.StartDate) {
+		return errors.New("endDate must not be before startDate")
+	}
+	campaign.Name = input.Name
+	campaign.StartDate = input.StartDate
+
+	if input.Description != nil {
+		campaign.Description = input.Description
+	}
+	if input.ClientID != nil {
+		campaign.ClientID = input.ClientID
+	}
+	if input.EndDate != nil {
+		campaign.EndDate = input.EndDate
+	}
+	if input.Budget != nil {
+		campaign.Budget = input.Budget
+	}
+	return nil
+}
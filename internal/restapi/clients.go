@@ -0,0 +1,182 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/validation"
+)
+
+func (a *api) listClients(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var status *model.ClientStatus
+	if value := r.URL.Query().Get("status"); value != "" {
+		parsed := model.ClientStatus(value)
+		if !parsed.IsValid() {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid client status %q", value))
+			return
+		}
+		status = &parsed
+	}
+
+	clients, err := a.store.Clients.GetClientsByStatus(r.Context(), status, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]Client, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, clientFromModel(client))
+	}
+	writeCached(w, r, resp, time.Time{})
+}
+
+func (a *api) getClient(w http.ResponseWriter, r *http.Request) {
+	client, err := a.store.Clients.GetClientByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		writeError(w, http.StatusNotFound, errClientNotFound)
+		return
+	}
+	writeCached(w, r, clientFromModel(client), modifiedAt(client.CreatedAt, client.UpdatedAt))
+}
+
+func (a *api) createClient(w http.ResponseWriter, r *http.Request) {
+	var input ClientInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	client := &model.Client{
+		Status:    model.ClientStatusActive,
+		CreatedAt: time.Now(),
+	}
+	if err := input.apply(client); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	created, err := a.store.Clients.CreateClient(r.Context(), client)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, clientFromModel(created))
+}
+
+func (a *api) updateClient(w http.ResponseWriter, r *http.Request) {
+	var input ClientInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	client, err := a.store.Clients.GetClientByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		writeError(w, http.StatusNotFound, errClientNotFound)
+		return
+	}
+	if input.Version != nil && *input.Version != client.Version {
+		writeJSON(w, http.StatusConflict, conflictResponse{Error: database.ErrVersionConflict.Error(), Current: clientFromModel(client)})
+		return
+	}
+
+	if err := input.apply(client); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now()
+	client.UpdatedAt = &now
+
+	updated, err := a.store.Clients.UpdateClient(r.Context(), client)
+	if errors.Is(err, database.ErrVersionConflict) {
+		a.writeClientConflict(w, r, client.ID)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, clientFromModel(updated))
+}
+
+func (a *api) writeClientConflict(w http.ResponseWriter, r *http.Request, id string) {
+	current, err := a.store.Clients.GetClientByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if current == nil {
+		writeError(w, http.StatusNotFound, errClientNotFound)
+		return
+	}
+	writeJSON(w, http.StatusConflict, conflictResponse{Error: database.ErrVersionConflict.Error(), Current: clientFromModel(current)})
+}
+
+func (a *api) deleteClient(w http.ResponseWriter, r *http.Request) {
+	deleted, err := a.store.Clients.DeleteClient(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, errClientNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (input *ClientInput) apply(client *model.Client) error {
+	if input.Name == "" || input.Industry == "" || input.ContactPerson == "" || input.Email == "" {
+		return errors.New("name, industry, contactPerson and email are required")
+	}
+	if input.StartDate.IsZero() {
+		return errors.New("startDate is required")
+	}
+	client.Name = input.Name
+	client.Industry = input.Industry
+	client.ContactPerson = input.ContactPerson
+	client.Email = input.Email
+	client.StartDate = input.StartDate
+
+	if input.Status != nil {
+		status := model.ClientStatus(*input.Status)
+		if !status.IsValid() {
+			return fmt.Errorf("invalid client status %q", *input.Status)
+		}
+		client.Status = status
+	}
+	if input.Website != nil {
+		client.Website = input.Website
+	}
+	if input.Phone != nil {
+		client.Phone = input.Phone
+	}
+	if input.Address != nil {
+		client.Address = input.Address
+	}
+	if input.Notes != nil {
+		client.Notes = input.Notes
+	}
+	return validation.Client(client)
+}
@@ -0,0 +1,267 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/pipeline"
+	"salesagency/internal/sendwindow"
+	"salesagency/internal/templates"
+	"salesagency/internal/validation"
+)
+
+func (a *api) listLeads(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &model.LeadFilterInput{
+		Tags:   query["tag"],
+		Source: optionalParam(r, "source"),
+	}
+	for _, value := range query["status"] {
+		status := model.LeadStatus(value)
+		if !status.IsValid() {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid lead status %q", value))
+			return
+		}
+		filter.Status = append(filter.Status, status)
+	}
+	if value := query.Get("minIntentScore"); value != "" {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid minIntentScore parameter"))
+			return
+		}
+		filter.MinIntentScore = &score
+	}
+
+	leads, err := a.store.Leads.GetLeadsByFilter(r.Context(), filter, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]Lead, 0, len(leads))
+	for _, lead := range leads {
+		resp = append(resp, leadFromModel(lead))
+	}
+	// Lists carry no Last-Modified: a lead that is deleted or leaves the
+	// filter doesn't change the newest updatedAt, so only the ETag is safe.
+	writeCached(w, r, resp, time.Time{})
+}
+
+func (a *api) getLead(w http.ResponseWriter, r *http.Request) {
+	lead, err := a.store.Leads.GetLeadByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if lead == nil {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+	// Sequences and meetings move nextFollowUp without touching updated_at,
+	// so Last-Modified can lag behind those changes; the ETag does not.
+	writeCached(w, r, leadFromModel(lead), modifiedAt(lead.CreatedAt, lead.UpdatedAt))
+}
+
+func (a *api) createLead(w http.ResponseWriter, r *http.Request) {
+	var input LeadInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lead := &model.Lead{
+		Status:      model.LeadStatusNew,
+		IntentScore: 0.5,
+		CreatedAt:   time.Now(),
+	}
+	if err := input.apply(lead); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	created, err := a.store.Leads.CreateLead(r.Context(), lead)
+	if errors.Is(err, database.ErrDuplicateLead) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	a.events.Publish(events.TopicLeadCreated, created)
+
+	writeJSON(w, http.StatusCreated, leadFromModel(created))
+}
+
+func (a *api) updateLead(w http.ResponseWriter, r *http.Request) {
+	var input LeadInput
+	if err := decodeJSON(r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lead, err := a.store.Leads.GetLeadByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if lead == nil {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+	if input.Version != nil && *input.Version != lead.Version {
+		writeJSON(w, http.StatusConflict, conflictResponse{Error: database.ErrVersionConflict.Error(), Current: leadFromModel(lead)})
+		return
+	}
+
+	previousScore, previousStatus := lead.IntentScore, lead.Status
+	if err := input.apply(lead); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := pipeline.Validate(previousStatus, lead.Status); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now()
+	lead.UpdatedAt = &now
+
+	updated, err := a.store.Leads.UpdateLead(r.Context(), lead)
+	if errors.Is(err, database.ErrVersionConflict) {
+		a.writeLeadConflict(w, r, lead.ID)
+		return
+	}
+	if errors.Is(err, database.ErrDuplicateLead) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if updated.IntentScore != previousScore {
+		if err := a.scoring.RecordManual(r.Context(), updated, previousScore); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if updated.Status != previousStatus {
+		err = a.store.Leads.RecordLeadStatusChange(r.Context(), updated.ID, previousStatus, updated.Status, nil, nil)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		a.pipeline.StatusChanged(r.Context(), updated.ID, updated.Status)
+	}
+
+	a.events.Publish(events.TopicLeadUpdated, updated)
+
+	writeJSON(w, http.StatusOK, leadFromModel(updated))
+}
+
+func (a *api) deleteLead(w http.ResponseWriter, r *http.Request) {
+	deleted, err := a.store.Leads.DeleteLead(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *api) writeLeadConflict(w http.ResponseWriter, r *http.Request, id string) {
+	current, err := a.store.Leads.GetLeadByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if current == nil {
+		writeError(w, http.StatusNotFound, errLeadNotFound)
+		return
+	}
+	writeJSON(w, http.StatusConflict, conflictResponse{Error: database.ErrVersionConflict.Error(), Current: leadFromModel(current)})
+}
+
+// apply validates the input and copies it onto lead. Optional fields that are
+// omitted keep their current value.
+func (input *LeadInput) apply(lead *model.Lead) error {
+	if input.Name == "" || input.Email == "" {
+		return errors.New("name and email are required")
+	}
+	lead.Name = input.Name
+	lead.Email = input.Email
+
+	if input.Status != nil {
+		status := model.LeadStatus(*input.Status)
+		if !status.IsValid() {
+			return fmt.Errorf("invalid lead status %q", *input.Status)
+		}
+		lead.Status = status
+	}
+	if input.IntentScore != nil {
+		if *input.IntentScore < 0 || *input.IntentScore > 1 {
+			return errors.New("intentScore must be between 0 and 1")
+		}
+		lead.IntentScore = *input.IntentScore
+	}
+	if input.DealValue != nil {
+		if *input.DealValue < 0 {
+			return errors.New("dealValue must not be negative")
+		}
+		lead.DealValue = input.DealValue
+	}
+	if input.Phone != nil {
+		lead.Phone = input.Phone
+	}
+	if input.Company != nil {
+		lead.Company = input.Company
+	}
+	if input.Position != nil {
+		lead.Position = input.Position
+	}
+	if input.Tags != nil {
+		lead.Tags = input.Tags
+	}
+	if input.Source != nil {
+		lead.Source = input.Source
+	}
+	if input.Notes != nil {
+		lead.Notes = input.Notes
+	}
+	if input.Timezone != nil {
+		if err := sendwindow.CheckTimezone(*input.Timezone); err != nil {
+			return err
+		}
+		lead.Timezone = input.Timezone
+	}
+	if input.Language != nil {
+		lead.Language = input.Language
+	}
+	if err := validation.Lead(lead); err != nil {
+		return err
+	}
+	sendwindow.DetectTimezone(lead)
+	templates.DetectLanguage(lead)
+	return nil
+}
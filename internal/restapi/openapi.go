@@ -0,0 +1,211 @@
+package restapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type object = map[string]interface{}
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// openAPISpec builds an OpenAPI 3 document from the route table, deriving
+// schemas for request and response bodies from their Go types.
+func openAPISpec(routes []route) object {
+	schemas := object{
+		"Error": schemaFor(reflect.TypeOf(errorResponse{}), nil),
+	}
+	paths := object{}
+
+	for _, rt := range routes {
+		operation := object{
+			"tags":    []string{rt.tag},
+			"summary": rt.summary,
+		}
+
+		var parameters []object
+		for _, match := range pathParamPattern.FindAllStringSubmatch(rt.pattern, -1) {
+			parameters = append(parameters, object{
+				"name": match[1], "in": "path", "required": true,
+				"schema": object{"type": "string"},
+			})
+		}
+		for _, param := range rt.query {
+			parameters = append(parameters, object{
+				"name": param.name, "in": "query", "description": param.description,
+				"schema": object{"type": param.kind},
+			})
+		}
+		if rt.method == http.MethodGet {
+			parameters = append(parameters, conditionalParameters(rt)...)
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if rt.request != nil {
+			operation["requestBody"] = object{
+				"required": true,
+				"content": object{
+					"application/json": object{"schema": schemaFor(reflect.TypeOf(rt.request), schemas)},
+				},
+			}
+		}
+
+		success := object{"description": http.StatusText(rt.status)}
+		if rt.response != nil {
+			success["content"] = object{
+				"application/json": object{"schema": schemaFor(reflect.TypeOf(rt.response), schemas)},
+			}
+		}
+		responses := object{strconv.Itoa(rt.status): success}
+		if rt.method == http.MethodGet {
+			success["headers"] = cacheHeaders(rt)
+			responses[strconv.Itoa(http.StatusNotModified)] = object{
+				"description": http.StatusText(http.StatusNotModified),
+				"headers":     cacheHeaders(rt),
+			}
+		}
+		for _, status := range errorStatuses(rt) {
+			responses[strconv.Itoa(status)] = object{
+				"description": http.StatusText(status),
+				"content": object{
+					"application/json": object{"schema": object{"$ref": "#/components/schemas/Error"}},
+				},
+			}
+		}
+		operation["responses"] = responses
+
+		item, ok := paths[rt.pattern].(object)
+		if !ok {
+			item = object{}
+			paths[rt.pattern] = item
+		}
+		item[strings.ToLower(rt.method)] = operation
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "Sales Agency CRM API",
+			"version": "1.0.0",
+		},
+		"servers":  []object{{"url": "/api/v1"}},
+		"security": []object{{"apiKey": []string{}}},
+		"paths":    paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"apiKey": object{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+		},
+	}
+}
+
+// isList reports whether the route returns an array. Lists are served with an
+// ETag but no Last-Modified.
+func isList(rt route) bool {
+	return rt.response != nil && reflect.TypeOf(rt.response).Kind() == reflect.Slice
+}
+
+func conditionalParameters(rt route) []object {
+	parameters := []object{{
+		"name": "If-None-Match", "in": "header",
+		"description": "ETag from an earlier response; answered with 304 if it still matches",
+		"schema":      object{"type": "string"},
+	}}
+	if !isList(rt) {
+		parameters = append(parameters, object{
+			"name": "If-Modified-Since", "in": "header",
+			"description": "Last-Modified from an earlier response; ignored when If-None-Match is given",
+			"schema":      object{"type": "string"},
+		})
+	}
+	return parameters
+}
+
+func cacheHeaders(rt route) object {
+	headers := object{
+		"ETag":          object{"schema": object{"type": "string"}},
+		"Cache-Control": object{"schema": object{"type": "string"}},
+	}
+	if !isList(rt) {
+		headers["Last-Modified"] = object{"schema": object{"type": "string"}}
+	}
+	return headers
+}
+
+func errorStatuses(rt route) []int {
+	statuses := []int{http.StatusUnauthorized}
+	if rt.role != "" {
+		statuses = append(statuses, http.StatusForbidden)
+	}
+	if rt.request != nil || len(rt.query) > 0 {
+		statuses = append(statuses, http.StatusBadRequest)
+	}
+	if strings.Contains(rt.pattern, "{") {
+		statuses = append(statuses, http.StatusNotFound)
+	}
+	return statuses
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the JSON schema for t. Named structs are added to schemas
+// and referenced; pointers become nullable.
+func schemaFor(t reflect.Type, schemas object) object {
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		schema := schemaFor(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; isRef {
+			return object{"allOf": []object{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case t.Kind() == reflect.Slice:
+		return object{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.String:
+		return object{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return object{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return object{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return object{"type": "number"}
+	case t.Kind() == reflect.Struct:
+		properties := object{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaFor(field.Type, schemas)
+			if field.Type.Kind() != reflect.Ptr && !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		schema := object{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		if schemas == nil || t.Name() == "" {
+			return schema
+		}
+		schemas[t.Name()] = schema
+		return object{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return object{}
+	}
+}
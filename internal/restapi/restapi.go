@@ -0,0 +1,176 @@
+// Package restapi serves a JSON REST API under /api/v1 for CRM integrations.
+// It authenticates with API keys rather than the user tokens used by GraphQL.
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/pipeline"
+	"salesagency/internal/scoring"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+var (
+	errLeadNotFound     = errors.New("lead not found")
+	errClientNotFound   = errors.New("client not found")
+	errCampaignNotFound = errors.New("campaign not found")
+	errIntegrationKey   = errors.New("integration keys only work on /api/integrations")
+)
+
+type api struct {
+	store    database.Repositories
+	events   *events.Broker
+	pipeline *pipeline.Service
+	scoring  *scoring.Engine
+}
+
+// New returns the /api/v1 handler, which serves leads, clients and campaigns
+// from store and checks API keys against store.APIKeys. The OpenAPI document
+// describing it is served unauthenticated at /openapi.json.
+func New(store database.Repositories, broker *events.Broker, pipelineService *pipeline.Service, scoringEngine *scoring.Engine) http.Handler {
+	a := &api{store: store, events: broker, pipeline: pipelineService, scoring: scoringEngine}
+	routes := a.routes()
+	spec := openAPISpec(routes)
+
+	router := chi.NewRouter()
+	router.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, no-cache")
+		writeCached(w, r, spec, time.Time{})
+	})
+	router.Group(func(router chi.Router) {
+		router.Use(apiKeyAuth(store.APIKeys))
+		for _, rt := range routes {
+			router.Method(rt.method, rt.pattern, requireRole(rt.role, rt.handler))
+		}
+	})
+
+	return router
+}
+
+// apiKeyAuth resolves the X-API-Key header to a User carrying the key's role
+// and agency.
+func apiKeyAuth(keys database.APIKeyRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(apiKeyHeader)
+			if key == "" {
+				writeError(w, http.StatusUnauthorized, auth.ErrUnauthenticated)
+				return
+			}
+
+			apiKey, agencyID, err := keys.AuthenticateAPIKey(r.Context(), auth.HashAPIKey(key))
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if apiKey == nil {
+				writeError(w, http.StatusUnauthorized, auth.ErrInvalidAPIKey)
+				return
+			}
+			if apiKey.Integration != nil {
+				writeError(w, http.StatusUnauthorized, errIntegrationKey)
+				return
+			}
+
+			user := &auth.User{
+				ID:       apiKey.ID,
+				Role:     auth.Role(apiKey.Role),
+				AgencyID: agencyID,
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func requireRole(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if role != "" && !auth.UserFromContext(r.Context()).HasRole(role) {
+			writeError(w, http.StatusForbidden, auth.ErrForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// conflictResponse answers an update made against an outdated version with
+// the record as it is now.
+type conflictResponse struct {
+	Error   string      `json:"error"`
+	Current interface{} `json:"current"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error encoding REST API response", "error", err)
+	}
+}
+
+// writeError reports err to the caller. Server errors are logged and replaced
+// with a generic message so database details do not leak to integrations.
+func writeError(w http.ResponseWriter, status int, err error) {
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		slog.Error("REST API error", "error", err)
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errors.New("invalid request body: " + err.Error())
+	}
+	return nil
+}
+
+// pagination reads the optional limit and offset query parameters.
+func pagination(r *http.Request) (*int, *int, error) {
+	limit, err := intParam(r, "limit")
+	if err != nil {
+		return nil, nil, err
+	}
+	offset, err := intParam(r, "offset")
+	if err != nil {
+		return nil, nil, err
+	}
+	return limit, offset, nil
+}
+
+func intParam(r *http.Request, name string) (*int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return nil, errors.New("invalid " + name + " parameter")
+	}
+	return &i, nil
+}
+
+func optionalParam(r *http.Request, name string) *string {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
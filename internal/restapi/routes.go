@@ -0,0 +1,117 @@
+package restapi
+
+import (
+	"net/http"
+
+	"salesagency/internal/auth"
+)
+
+// route describes one endpoint. The same table mounts the handlers and
+// generates the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method   string
+	pattern  string
+	tag      string
+	summary  string
+	role     auth.Role // empty when any valid key may call the endpoint
+	query    []queryParam
+	request  interface{}
+	response interface{}
+	status   int
+	handler  http.HandlerFunc
+}
+
+type queryParam struct {
+	name        string
+	kind        string
+	description string
+}
+
+var paginationParams = []queryParam{
+	{"limit", "integer", "Maximum number of results"},
+	{"offset", "integer", "Number of results to skip"},
+}
+
+func (a *api) routes() []route {
+	return []route{
+		{
+			method: http.MethodGet, pattern: "/leads", tag: "Leads", summary: "List leads",
+			query: append([]queryParam{
+				{"status", "string", "Lead status; may be repeated"},
+				{"minIntentScore", "number", "Minimum intent score"},
+				{"tag", "string", "Tag the lead must carry; may be repeated"},
+				{"source", "string", "Lead source"},
+			}, paginationParams...),
+			response: []Lead{}, status: http.StatusOK, handler: a.listLeads,
+		},
+		{
+			method: http.MethodGet, pattern: "/leads/{id}", tag: "Leads", summary: "Get a lead",
+			response: Lead{}, status: http.StatusOK, handler: a.getLead,
+		},
+		{
+			method: http.MethodPost, pattern: "/leads", tag: "Leads", summary: "Create a lead",
+			role: auth.RoleSalesRep, request: LeadInput{}, response: Lead{}, status: http.StatusCreated,
+			handler: a.createLead,
+		},
+		{
+			method: http.MethodPut, pattern: "/leads/{id}", tag: "Leads", summary: "Update a lead",
+			role: auth.RoleSalesRep, request: LeadInput{}, response: Lead{}, status: http.StatusOK,
+			handler: a.updateLead,
+		},
+		{
+			method: http.MethodDelete, pattern: "/leads/{id}", tag: "Leads", summary: "Delete a lead",
+			role: auth.RoleAdmin, status: http.StatusNoContent, handler: a.deleteLead,
+		},
+		{
+			method: http.MethodGet, pattern: "/clients", tag: "Clients", summary: "List clients",
+			query: append([]queryParam{
+				{"status", "string", "Client status"},
+			}, paginationParams...),
+			response: []Client{}, status: http.StatusOK, handler: a.listClients,
+		},
+		{
+			method: http.MethodGet, pattern: "/clients/{id}", tag: "Clients", summary: "Get a client",
+			response: Client{}, status: http.StatusOK, handler: a.getClient,
+		},
+		{
+			method: http.MethodPost, pattern: "/clients", tag: "Clients", summary: "Create a client",
+			role: auth.RoleAgencyManager, request: ClientInput{}, response: Client{}, status: http.StatusCreated,
+			handler: a.createClient,
+		},
+		{
+			method: http.MethodPut, pattern: "/clients/{id}", tag: "Clients", summary: "Update a client",
+			role: auth.RoleAgencyManager, request: ClientInput{}, response: Client{}, status: http.StatusOK,
+			handler: a.updateClient,
+		},
+		{
+			method: http.MethodDelete, pattern: "/clients/{id}", tag: "Clients", summary: "Delete a client",
+			role: auth.RoleAdmin, status: http.StatusNoContent, handler: a.deleteClient,
+		},
+		{
+			method: http.MethodGet, pattern: "/campaigns", tag: "Campaigns", summary: "List campaigns",
+			query: append([]queryParam{
+				{"status", "string", "Campaign status; may be repeated"},
+				{"clientId", "string", "Only campaigns for this client"},
+			}, paginationParams...),
+			response: []Campaign{}, status: http.StatusOK, handler: a.listCampaigns,
+		},
+		{
+			method: http.MethodGet, pattern: "/campaigns/{id}", tag: "Campaigns", summary: "Get a campaign",
+			response: Campaign{}, status: http.StatusOK, handler: a.getCampaign,
+		},
+		{
+			method: http.MethodPost, pattern: "/campaigns", tag: "Campaigns", summary: "Create a draft campaign",
+			role: auth.RoleAgencyManager, request: CampaignInput{}, response: Campaign{}, status: http.StatusCreated,
+			handler: a.createCampaign,
+		},
+		{
+			method: http.MethodPut, pattern: "/campaigns/{id}", tag: "Campaigns", summary: "Update a campaign",
+			role: auth.RoleAgencyManager, request: CampaignInput{}, response: Campaign{}, status: http.StatusOK,
+			handler: a.updateCampaign,
+		},
+		{
+			method: http.MethodDelete, pattern: "/campaigns/{id}", tag: "Campaigns", summary: "Delete a campaign",
+			role: auth.RoleAdmin, status: http.StatusNoContent, handler: a.deleteCampaign,
+		},
+	}
+}
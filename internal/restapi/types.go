@@ -0,0 +1,166 @@
+package restapi
+
+import (
+	"time"
+
+	"salesagency/graph/model"
+)
+
+type Lead struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Phone        *string    `json:"phone"`
+	Company      *string    `json:"company"`
+	Position     *string    `json:"position"`
+	Status       string     `json:"status"`
+	IntentScore  float64    `json:"intentScore"`
+	DealValue    *float64   `json:"dealValue"`
+	Tags         []string   `json:"tags"`
+	Source       *string    `json:"source"`
+	Notes        *string    `json:"notes"`
+	Timezone     *string    `json:"timezone"`
+	Language     *string    `json:"language"`
+	LastContact  *time.Time `json:"lastContact"`
+	NextFollowUp *time.Time `json:"nextFollowUp"`
+	Version      int        `json:"version"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    *time.Time `json:"updatedAt"`
+}
+
+type LeadInput struct {
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	Phone       *string  `json:"phone,omitempty"`
+	Company     *string  `json:"company,omitempty"`
+	Position    *string  `json:"position,omitempty"`
+	Status      *string  `json:"status,omitempty"`
+	IntentScore *float64 `json:"intentScore,omitempty"`
+	DealValue   *float64 `json:"dealValue,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Source      *string  `json:"source,omitempty"`
+	Notes       *string  `json:"notes,omitempty"`
+	Timezone    *string  `json:"timezone,omitempty"`
+	Language    *string  `json:"language,omitempty"`
+	// Version, when given, makes an update fail with 409 if the lead has
+	// changed since that version.
+	Version *int `json:"version,omitempty"`
+}
+
+type Client struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Industry      string     `json:"industry"`
+	Website       *string    `json:"website"`
+	ContactPerson string     `json:"contactPerson"`
+	Email         string     `json:"email"`
+	Phone         *string    `json:"phone"`
+	Address       *string    `json:"address"`
+	StartDate     time.Time  `json:"startDate"`
+	Status        string     `json:"status"`
+	Notes         *string    `json:"notes"`
+	Version       int        `json:"version"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt"`
+}
+
+type ClientInput struct {
+	Name          string    `json:"name"`
+	Industry      string    `json:"industry"`
+	Website       *string   `json:"website,omitempty"`
+	ContactPerson string    `json:"contactPerson"`
+	Email         string    `json:"email"`
+	Phone         *string   `json:"phone,omitempty"`
+	Address       *string   `json:"address,omitempty"`
+	StartDate     time.Time `json:"startDate"`
+	Status        *string   `json:"status,omitempty"`
+	Notes         *string   `json:"notes,omitempty"`
+	// Version works as for LeadInput.
+	Version *int `json:"version,omitempty"`
+}
+
+type Campaign struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	ClientID    *string    `json:"clientId"`
+	StartDate   time.Time  `json:"startDate"`
+	EndDate     *time.Time `json:"endDate"`
+	Status      string     `json:"status"`
+	Budget      *float64   `json:"budget"`
+	Currency    string     `json:"currency"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+// CampaignInput has no status: campaigns are created as drafts and moved
+// through their lifecycle from the GraphQL API. Currency is only read when
+// a campaign is created; it defaults to the agency's base currency.
+type CampaignInput struct {
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	ClientID    *string    `json:"clientId,omitempty"`
+	StartDate   time.Time  `json:"startDate"`
+	EndDate     *time.Time `json:"endDate,omitempty"`
+	Budget      *float64   `json:"budget,omitempty"`
+	Currency    *string    `json:"currency,omitempty"`
+}
+
+func leadFromModel(lead *model.Lead) Lead {
+	return Lead{
+		ID:           lead.ID,
+		Name:         lead.Name,
+		Email:        lead.Email,
+		Phone:        lead.Phone,
+		Company:      lead.Company,
+		Position:     lead.Position,
+		Status:       string(lead.Status),
+		IntentScore:  lead.IntentScore,
+		DealValue:    lead.DealValue,
+		Tags:         lead.Tags,
+		Source:       lead.Source,
+		Notes:        lead.Notes,
+		Timezone:     lead.Timezone,
+		Language:     lead.Language,
+		LastContact:  lead.LastContact,
+		NextFollowUp: lead.NextFollowUp,
+		Version:      lead.Version,
+		CreatedAt:    lead.CreatedAt,
+		UpdatedAt:    lead.UpdatedAt,
+	}
+}
+
+func clientFromModel(client *model.Client) Client {
+	return Client{
+		ID:            client.ID,
+		Name:          client.Name,
+		Industry:      client.Industry,
+		Website:       client.Website,
+		ContactPerson: client.ContactPerson,
+		Email:         client.Email,
+		Phone:         client.Phone,
+		Address:       client.Address,
+		StartDate:     client.StartDate,
+		Status:        string(client.Status),
+		Notes:         client.Notes,
+		Version:       client.Version,
+		CreatedAt:     client.CreatedAt,
+		UpdatedAt:     client.UpdatedAt,
+	}
+}
+
+func campaignFromModel(campaign *model.Campaign) Campaign {
+	return Campaign{
+		ID:          campaign.ID,
+		Name:        campaign.Name,
+		Description: campaign.Description,
+		ClientID:    campaign.ClientID,
+		StartDate:   campaign.StartDate,
+		EndDate:     campaign.EndDate,
+		Status:      string(campaign.Status),
+		Budget:      campaign.Budget,
+		Currency:    campaign.Currency,
+		CreatedAt:   campaign.CreatedAt,
+		UpdatedAt:   campaign.UpdatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+// Package retention erases lead personal data on request and purges leads
+// that have been inactive for longer than their agency keeps them.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/storage"
+	"salesagency/internal/tenant"
+)
+
+const purgeBatchSize = 500
+
+type Service struct {
+	db    *database.DB
+	store storage.Store
+	now   func() time.Time
+}
+
+// NewService returns a service that removes attachment files from store,
+// which may be nil when no file storage is configured.
+func NewService(db *database.DB, store storage.Store) *Service {
+	return &Service{db: db, store: store, now: time.Now}
+}
+
+// ForgetLead erases the lead's personal data and attachment files. It
+// reports false when the lead does not exist.
+func (s *Service) ForgetLead(ctx context.Context, id string) (bool, error) {
+	keys, found, err := s.db.ForgetLead(ctx, id, s.now())
+	if err != nil || !found {
+		return found, err
+	}
+
+	s.deleteFiles(ctx, keys)
+	logging.FromContext(ctx).Info("Forgot lead", "lead_id", id)
+
+	return true, nil
+}
+
+// PurgeExpired permanently deletes every lead past its agency's retention
+// period, with its attachment files, and returns how many were deleted.
+func (s *Service) PurgeExpired(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		leads, err := s.db.GetExpiredLeads(ctx, s.now(), purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, lead := range leads {
+			leadCtx := tenant.WithAgency(ctx, lead.AgencyID)
+
+			keys, err := s.db.GetLeadAttachmentKeys(leadCtx, lead.ID)
+			if err != nil {
+				return purged, err
+			}
+			if _, err := s.db.PurgeLead(leadCtx, lead.ID); err != nil {
+				return purged, err
+			}
+			s.deleteFiles(ctx, keys)
+			purged++
+		}
+
+		if len(leads) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (s *Service) deleteFiles(ctx context.Context, keys []string) {
+	if s.store == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Error("Failed to delete stored file", "key", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"salesagency/internal/database"
+)
+
+// APIError is an error response from the Salesforce REST API.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("salesforce: %d %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("salesforce: %s: %s", e.Code, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// api calls the REST API of one agency's org, refreshing the access token
+// when it has expired.
+type api struct {
+	s           *Service
+	conn        *database.SalesforceConnection
+	accessToken string
+}
+
+// apiFor returns a client for the agency's org, or ErrNotConnected.
+func (s *Service) apiFor(ctx context.Context, agencyID string) (*api, error) {
+	if !s.Enabled() {
+		return nil, ErrNotConfigured
+	}
+
+	conn, err := s.db.GetSalesforceConnection(ctx, agencyID)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
+
+	accessToken, err := s.sealer.open(conn.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &api{s: s, conn: conn, accessToken: accessToken}, nil
+}
+
+func (a *api) do(ctx context.Context, method, path string, body, out interface{}) error {
+	err := a.send(ctx, method, path, body, out)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		return err
+	}
+	if err := a.refresh(ctx); err != nil {
+		return err
+	}
+	return a.send(ctx, method, path, body, out)
+}
+
+func (a *api) refresh(ctx context.Context) error {
+	refreshToken, err := a.s.sealer.open(a.conn.RefreshToken)
+	if err != nil {
+		return err
+	}
+	accessToken, err := a.s.refreshAccessToken(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := a.s.sealer.seal(accessToken)
+	if err != nil {
+		return err
+	}
+	if err := a.s.db.UpdateSalesforceAccessToken(ctx, a.conn.AgencyID, sealed); err != nil {
+		return err
+	}
+	a.accessToken = accessToken
+	return nil
+}
+
+func (a *api) send(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	endpoint := strings.TrimRight(a.conn.InstanceURL, "/") + "/services/data/" + apiVersion + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+a.accessToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("salesforce: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{Status: resp.StatusCode, Message: resp.Status}
+		var errs []struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(respBody, &errs) == nil && len(errs) > 0 {
+			apiErr.Code, apiErr.Message = errs[0].ErrorCode, errs[0].Message
+		}
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding salesforce response: %w", err)
+	}
+	return nil
+}
+
+type createResponse struct {
+	ID string `json:"id"`
+}
+
+func (a *api) create(ctx context.Context, object string, fields map[string]interface{}) (string, error) {
+	var resp createResponse
+	if err := a.do(ctx, http.MethodPost, "/sobjects/"+object, fields, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (a *api) update(ctx context.Context, object, id string, fields map[string]interface{}) error {
+	return a.do(ctx, http.MethodPatch, "/sobjects/"+object+"/"+url.PathEscape(id), fields, nil)
+}
+
+// salesforceLead is the subset of Lead fields compared during reconciliation.
+type salesforceLead struct {
+	ID        string  `json:"Id"`
+	FirstName *string `json:"FirstName"`
+	LastName  string  `json:"LastName"`
+	Email     *string `json:"Email"`
+	Company   string  `json:"Company"`
+}
+
+// getLeads fetches the Salesforce leads with the given IDs. Deleted leads
+// are not returned.
+func (a *api) getLeads(ctx context.Context, ids []string) (map[string]*salesforceLead, error) {
+	quoted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !isSalesforceID(id) {
+			return nil, fmt.Errorf("invalid salesforce ID %q", id)
+		}
+		quoted = append(quoted, "'"+id+"'")
+	}
+	soql := "SELECT Id, FirstName, LastName, Email, Company FROM Lead WHERE Id IN (" + strings.Join(quoted, ",") + ")"
+
+	leads := make(map[string]*salesforceLead, len(ids))
+	path := "/query?q=" + url.QueryEscape(soql)
+	for path != "" {
+		var resp struct {
+			Records        []*salesforceLead `json:"records"`
+			NextRecordsURL string            `json:"nextRecordsUrl"`
+		}
+		if err := a.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, lead := range resp.Records {
+			leads[lead.ID] = lead
+		}
+		// nextRecordsUrl is absolute from /services/data/<version>.
+		path = strings.TrimPrefix(resp.NextRecordsURL, "/services/data/"+apiVersion)
+	}
+
+	return leads, nil
+}
+
+func isSalesforceID(id string) bool {
+	if len(id) != 15 && len(id) != 18 {
+		return false
+	}
+	for _, r := range id {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
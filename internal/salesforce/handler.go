@@ -0,0 +1,57 @@
+package salesforce
+
+import (
+	"html/template"
+	"net/http"
+
+	"salesagency/internal/logging"
+)
+
+// CallbackPath is where Salesforce redirects after the user grants access.
+// It must be registered as the connected app's callback URL.
+const CallbackPath = "/integrations/salesforce/callback"
+
+var page = template.Must(template.New("salesforce").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>Salesforce</title></head>
+<body style="font-family: sans-serif; max-width: 32em; margin: 4em auto;">
+{{if .Error}}
+<p>Salesforce could not be connected: {{.Error}}</p>
+{{else}}
+<p>Salesforce is connected. You can close this window.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// CallbackHandler completes the OAuth flow started by AuthorizeURL.
+func CallbackHandler(s *Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		if denied := query.Get("error"); denied != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			page.Execute(w, map[string]interface{}{"Error": query.Get("error_description")})
+			return
+		}
+		if !s.Enabled() {
+			w.WriteHeader(http.StatusNotFound)
+			page.Execute(w, map[string]interface{}{"Error": ErrNotConfigured.Error()})
+			return
+		}
+
+		if err := s.connect(r.Context(), query.Get("code"), query.Get("state")); err != nil {
+			logging.FromContext(r.Context()).Error("error connecting salesforce", "error", err)
+			status := http.StatusBadGateway
+			if err == errInvalidState {
+				status = http.StatusBadRequest
+			}
+			w.WriteHeader(status)
+			page.Execute(w, map[string]interface{}{"Error": err.Error()})
+			return
+		}
+
+		page.Execute(w, map[string]interface{}{})
+	})
+}
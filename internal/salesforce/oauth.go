@@ -0,0 +1,213 @@
+package salesforce
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/auth"
+	"salesagency/internal/tenant"
+)
+
+// stateTTL bounds how long a user may take to approve access in Salesforce.
+const stateTTL = 15 * time.Minute
+
+var errInvalidState = errors.New("invalid or expired salesforce authorization")
+
+// oauthState identifies who started an authorization, so the callback, which
+// carries no credentials of ours, knows which agency to connect.
+type oauthState struct {
+	AgencyID  string    `json:"agency"`
+	UserID    string    `json:"user,omitempty"`
+	ExpiresAt time.Time `json:"expires"`
+}
+
+// AuthorizeURL returns the Salesforce page on which the current user grants
+// access for their agency. Salesforce then redirects to CallbackPath.
+func (s *Service) AuthorizeURL(ctx context.Context) (string, error) {
+	if !s.Enabled() {
+		return "", ErrNotConfigured
+	}
+
+	agencyID, err := tenant.AgencyID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if agencyID == "" {
+		return "", errors.New("salesforce must be connected within an agency")
+	}
+
+	state := oauthState{AgencyID: agencyID, ExpiresAt: time.Now().Add(stateTTL).UTC()}
+	if user := auth.UserFromContext(ctx); user != nil {
+		state.UserID = user.ID
+	}
+	signed, err := s.signState(state)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {s.cfg.ClientID},
+		"redirect_uri":  {s.cfg.RedirectURL},
+		"scope":         {"api refresh_token"},
+		"prompt":        {"login consent"},
+		"state":         {signed},
+	}
+	return s.cfg.LoginURL + "/services/oauth2/authorize?" + query.Encode(), nil
+}
+
+func (s *Service) signState(state oauthState) (string, error) {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(encoded)
+	return payload + "." + s.stateSignature(payload), nil
+}
+
+func (s *Service) verifyState(signed string) (*oauthState, error) {
+	payload, signature, ok := strings.Cut(signed, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.stateSignature(payload))) {
+		return nil, errInvalidState
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errInvalidState
+	}
+	var state oauthState
+	if err := json.Unmarshal(decoded, &state); err != nil || state.AgencyID == "" || time.Now().After(state.ExpiresAt) {
+		return nil, errInvalidState
+	}
+	return &state, nil
+}
+
+func (s *Service) stateSignature(payload string) string {
+	mac := hmac.New(sha256.New, []byte("salesforce-oauth-state:"+s.cfg.TokenKey))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	InstanceURL  string `json:"instance_url"`
+}
+
+// connect finishes an authorization by exchanging its code for tokens and
+// storing them for the agency in state.
+func (s *Service) connect(ctx context.Context, code, signedState string) error {
+	state, err := s.verifyState(signedState)
+	if err != nil {
+		return err
+	}
+
+	token, err := s.requestToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {s.cfg.RedirectURL},
+	})
+	if err != nil {
+		return err
+	}
+	if token.RefreshToken == "" {
+		return errors.New("salesforce did not issue a refresh token; add the refresh_token scope to the connected app")
+	}
+
+	accessToken, err := s.sealer.seal(token.AccessToken)
+	if err != nil {
+		return err
+	}
+	refreshToken, err := s.sealer.seal(token.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	var connectedBy *string
+	if state.UserID != "" {
+		connectedBy = &state.UserID
+	}
+
+	ctx = tenant.WithAgency(ctx, state.AgencyID)
+	if _, err := s.db.SaveSalesforceConnection(ctx, token.InstanceURL, accessToken, refreshToken, connectedBy); err != nil {
+		return err
+	}
+	return s.db.ResolveSyncErrors(ctx, state.AgencyID, nil, Provider, model.SyncErrorKindConnectionFailed)
+}
+
+func (s *Service) refreshAccessToken(ctx context.Context, refreshToken string) (string, error) {
+	token, err := s.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+func (s *Service) requestToken(ctx context.Context, form url.Values) (*tokenResponse, error) {
+	form.Set("client_id", s.cfg.ClientID)
+	form.Set("client_secret", s.cfg.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.LoginURL+"/services/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error building token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("salesforce: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return nil, fmt.Errorf("salesforce: %s: %s", oauthErr.Error, oauthErr.Description)
+		}
+		return nil, fmt.Errorf("salesforce: token request failed: %s", resp.Status)
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("error decoding token response: %w", err)
+	}
+	return &token, nil
+}
+
+// revoke invalidates a refresh token and the access tokens issued from it.
+func (s *Service) revoke(ctx context.Context, refreshToken string) error {
+	form := url.Values{"token": {refreshToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.LoginURL+"/services/oauth2/revoke", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("salesforce: token revocation failed: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Package salesforce connects agencies to their Salesforce org over OAuth,
+// pushes leads as Salesforce Leads, creates an Opportunity when a lead is
+// won and reconciles the two systems in the background.
+package salesforce
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"salesagency/internal/database"
+)
+
+// Provider identifies Salesforce in sync_errors.
+const Provider = "salesforce"
+
+const (
+	apiVersion      = "v60.0"
+	defaultLoginURL = "https://login.salesforce.com"
+	defaultTimeout  = 30 * time.Second
+)
+
+var (
+	ErrNotConfigured = errors.New("salesforce integration is not configured")
+	ErrNotConnected  = errors.New("salesforce is not connected for this agency")
+)
+
+// Config is the connected app used for every agency.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// LoginURL is https://login.salesforce.com, or https://test.salesforce.com
+	// for sandboxes.
+	LoginURL    string
+	RedirectURL string
+	// TokenKey encrypts stored tokens and signs OAuth state.
+	TokenKey string
+}
+
+type Service struct {
+	db     *database.DB
+	cfg    Config
+	client *http.Client
+	sealer *sealer
+
+	// mu serializes pushes so that a lead changed while it is being created
+	// in Salesforce is not created twice.
+	mu sync.Mutex
+}
+
+// New configures the integration. Without a ClientID the integration is
+// disabled and its methods return ErrNotConfigured.
+func New(db *database.DB, cfg Config) (*Service, error) {
+	if cfg.ClientID == "" {
+		return &Service{db: db}, nil
+	}
+	if cfg.LoginURL == "" {
+		cfg.LoginURL = defaultLoginURL
+	}
+	cfg.LoginURL = strings.TrimRight(cfg.LoginURL, "/")
+
+	return NewService(db, cfg, &http.Client{Timeout: defaultTimeout})
+}
+
+func NewService(db *database.DB, cfg Config, client *http.Client) (*Service, error) {
+	sealer, err := newSealer(cfg.TokenKey)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring salesforce token encryption: %w", err)
+	}
+	return &Service{db: db, cfg: cfg, client: client, sealer: sealer}, nil
+}
+
+// Enabled reports whether a connected app is configured.
+func (s *Service) Enabled() bool {
+	return s.cfg.ClientID != ""
+}
@@ -0,0 +1,359 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+// reconcileBatchSize is how many leads are read, and looked up in
+// Salesforce, at a time during reconciliation.
+const reconcileBatchSize = 200
+
+// unknownCompany fills Salesforce's required Company field for leads
+// without one.
+const unknownCompany = "[not provided]"
+
+// Disconnect revokes the current agency's tokens and forgets them.
+func (s *Service) Disconnect(ctx context.Context) (bool, error) {
+	agencyID, err := tenant.AgencyID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := s.db.GetSalesforceConnection(ctx, agencyID)
+	if err != nil || conn == nil {
+		return false, err
+	}
+	if s.Enabled() {
+		if refreshToken, err := s.sealer.open(conn.RefreshToken); err == nil {
+			if err := s.revoke(ctx, refreshToken); err != nil {
+				logging.FromContext(ctx).Warn("error revoking salesforce token", "error", err)
+			}
+		}
+	}
+
+	return s.db.DeleteSalesforceConnection(ctx)
+}
+
+// Run pushes leads to Salesforce as they are created and updated, until ctx
+// is cancelled. Events the broker drops under load are picked up by
+// Reconcile.
+func (s *Service) Run(ctx context.Context, broker *events.Broker) {
+	if !s.Enabled() {
+		return
+	}
+
+	created := broker.Subscribe(ctx, events.TopicLeadCreated)
+	updated := broker.Subscribe(ctx, events.TopicLeadUpdated)
+	ctx = tenant.WithSystem(ctx)
+
+	for {
+		var event events.Event
+		var ok bool
+		select {
+		case event, ok = <-created:
+		case event, ok = <-updated:
+		}
+		if !ok {
+			return
+		}
+
+		// The instance the lead changed on pushes it.
+		if event.Remote {
+			continue
+		}
+		lead, ok := event.Payload.(*model.Lead)
+		if !ok || lead == nil {
+			continue
+		}
+		if err := s.SyncLead(ctx, lead.ID); err != nil && !errors.Is(err, ErrNotConnected) {
+			slog.Warn("salesforce: error syncing lead", "lead_id", lead.ID, "error", err)
+		}
+	}
+}
+
+// SyncLead pushes a lead to its agency's org. Failures are also recorded as
+// sync errors, which are resolved by the next successful push.
+func (s *Service) SyncLead(ctx context.Context, leadID string) error {
+	if !s.Enabled() {
+		return ErrNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	started := time.Now()
+	lead, err := s.db.GetSalesforceLead(ctx, leadID)
+	if err != nil || lead == nil {
+		return err
+	}
+	if !lead.Changed && (lead.Lead.Status != model.LeadStatusWon || lead.OpportunityID != nil) {
+		return nil
+	}
+
+	api, err := s.apiFor(ctx, lead.AgencyID)
+	if err != nil {
+		return err
+	}
+	return s.push(ctx, api, lead, started)
+}
+
+// push creates or updates the Salesforce lead and, for won leads, creates
+// the opportunity. started is when lead was read, so that changes made
+// while pushing are pushed again later.
+func (s *Service) push(ctx context.Context, api *api, lead *database.SalesforceLead, started time.Time) error {
+	fields := leadFields(lead.Lead)
+
+	var err error
+	if lead.LeadID == nil {
+		var id string
+		if id, err = api.create(ctx, "Lead", fields); err == nil {
+			lead.LeadID = &id
+		}
+	} else if err = api.update(ctx, "Lead", *lead.LeadID, fields); isNotFound(err) {
+		return s.flag(ctx, lead, model.SyncErrorKindMissingInSalesforce,
+			fmt.Sprintf("Salesforce lead %s no longer exists", *lead.LeadID))
+	}
+	if err != nil {
+		if flagErr := s.flag(ctx, lead, model.SyncErrorKindPushFailed, err.Error()); flagErr != nil {
+			return flagErr
+		}
+		return err
+	}
+
+	if err := s.db.MarkLeadSyncedToSalesforce(ctx, lead.Lead.ID, *lead.LeadID, started); err != nil {
+		return err
+	}
+	if err := s.resolve(ctx, lead, model.SyncErrorKindPushFailed, model.SyncErrorKindMissingInSalesforce); err != nil {
+		return err
+	}
+
+	if lead.Lead.Status != model.LeadStatusWon || lead.OpportunityID != nil {
+		return nil
+	}
+
+	opportunityID, err := api.create(ctx, "Opportunity", opportunityFields(lead.Lead, *lead.LeadID))
+	if err != nil {
+		if flagErr := s.flag(ctx, lead, model.SyncErrorKindOpportunityFailed, err.Error()); flagErr != nil {
+			return flagErr
+		}
+		return err
+	}
+	if err := s.db.SetLeadSalesforceOpportunity(ctx, lead.Lead.ID, opportunityID); err != nil {
+		return err
+	}
+	return s.resolve(ctx, lead, model.SyncErrorKindOpportunityFailed)
+}
+
+// Reconcile pushes every lead changed since its last push, including those
+// whose events were missed, then compares the leads of every connected
+// agency with Salesforce and flags mismatches.
+func (s *Service) Reconcile(ctx context.Context) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	conns, err := s.db.GetSalesforceConnections(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, conn := range conns {
+		if err := s.reconcileAgency(ctx, conn.AgencyID); err != nil {
+			errs = append(errs, fmt.Errorf("agency %s: %w", conn.AgencyID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) reconcileAgency(ctx context.Context, agencyID string) error {
+	api, err := s.apiFor(ctx, agencyID)
+	if err != nil {
+		return err
+	}
+
+	// A refresh failure means the org revoked access, which needs someone
+	// to reconnect rather than a retry per lead.
+	if err := api.refresh(ctx); err != nil {
+		if flagErr := s.db.RecordSyncError(ctx, agencyID, nil, Provider, model.SyncErrorKindConnectionFailed, err.Error()); flagErr != nil {
+			return flagErr
+		}
+		return err
+	}
+	if err := s.db.ResolveSyncErrors(ctx, agencyID, nil, Provider, model.SyncErrorKindConnectionFailed); err != nil {
+		return err
+	}
+
+	if err := s.pushPending(ctx, api, agencyID); err != nil {
+		return err
+	}
+	return s.compare(ctx, api, agencyID)
+}
+
+func (s *Service) pushPending(ctx context.Context, api *api, agencyID string) error {
+	afterID := ""
+	for {
+		s.mu.Lock()
+		started := time.Now()
+		leads, err := s.db.GetLeadsPendingSalesforceSync(ctx, agencyID, afterID, reconcileBatchSize)
+		if err == nil {
+			for _, lead := range leads {
+				// Failures are recorded as sync errors; carry on with the
+				// other leads.
+				if pushErr := s.push(ctx, api, lead, started); pushErr != nil && ctx.Err() != nil {
+					err = pushErr
+					break
+				}
+			}
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+		if len(leads) < reconcileBatchSize {
+			return nil
+		}
+		afterID = leads[len(leads)-1].Lead.ID
+	}
+}
+
+func (s *Service) compare(ctx context.Context, api *api, agencyID string) error {
+	afterID := ""
+	for {
+		leads, err := s.db.GetSalesforceLinkedLeads(ctx, agencyID, afterID, reconcileBatchSize)
+		if err != nil || len(leads) == 0 {
+			return err
+		}
+
+		ids := make([]string, len(leads))
+		for i, lead := range leads {
+			ids[i] = *lead.LeadID
+		}
+		remote, err := api.getLeads(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, lead := range leads {
+			if err := s.compareLead(ctx, lead, remote[*lead.LeadID]); err != nil {
+				return err
+			}
+		}
+
+		if len(leads) < reconcileBatchSize {
+			return nil
+		}
+		afterID = leads[len(leads)-1].Lead.ID
+	}
+}
+
+func (s *Service) compareLead(ctx context.Context, lead *database.SalesforceLead, remote *salesforceLead) error {
+	if remote == nil {
+		return s.flag(ctx, lead, model.SyncErrorKindMissingInSalesforce,
+			fmt.Sprintf("Salesforce lead %s no longer exists", *lead.LeadID))
+	}
+	if err := s.resolve(ctx, lead, model.SyncErrorKindMissingInSalesforce); err != nil {
+		return err
+	}
+
+	if mismatches := differences(lead.Lead, remote); len(mismatches) > 0 {
+		return s.flag(ctx, lead, model.SyncErrorKindFieldMismatch, strings.Join(mismatches, "; "))
+	}
+	return s.resolve(ctx, lead, model.SyncErrorKindFieldMismatch)
+}
+
+// differences describes the fields on which a Salesforce lead no longer
+// matches the lead it was pushed from.
+func differences(lead *model.Lead, remote *salesforceLead) []string {
+	var mismatches []string
+
+	first, last := splitName(lead.Name)
+	if deref(remote.FirstName) != first || remote.LastName != last {
+		mismatches = append(mismatches, fmt.Sprintf("name is %q in Salesforce, expected %q",
+			strings.TrimSpace(deref(remote.FirstName)+" "+remote.LastName), lead.Name))
+	}
+	if !strings.EqualFold(deref(remote.Email), lead.Email) {
+		mismatches = append(mismatches, fmt.Sprintf("email is %q in Salesforce, expected %q", deref(remote.Email), lead.Email))
+	}
+	if company := companyOf(lead); remote.Company != company {
+		mismatches = append(mismatches, fmt.Sprintf("company is %q in Salesforce, expected %q", remote.Company, company))
+	}
+
+	return mismatches
+}
+
+func (s *Service) flag(ctx context.Context, lead *database.SalesforceLead, kind model.SyncErrorKind, message string) error {
+	return s.db.RecordSyncError(ctx, lead.AgencyID, &lead.Lead.ID, Provider, kind, message)
+}
+
+func (s *Service) resolve(ctx context.Context, lead *database.SalesforceLead, kinds ...model.SyncErrorKind) error {
+	return s.db.ResolveSyncErrors(ctx, lead.AgencyID, &lead.Lead.ID, Provider, kinds...)
+}
+
+func leadFields(lead *model.Lead) map[string]interface{} {
+	first, last := splitName(lead.Name)
+	return map[string]interface{}{
+		"FirstName":   first,
+		"LastName":    last,
+		"Email":       lead.Email,
+		"Phone":       lead.Phone,
+		"Company":     companyOf(lead),
+		"Title":       lead.Position,
+		"Description": lead.Notes,
+	}
+}
+
+func opportunityFields(lead *model.Lead, salesforceLeadID string) map[string]interface{} {
+	name := companyOf(lead)
+	if name == unknownCompany {
+		name = lead.Name
+	}
+	if runes := []rune(name); len(runes) > 120 {
+		name = string(runes[:120])
+	}
+
+	return map[string]interface{}{
+		"Name":        name,
+		"StageName":   "Closed Won",
+		"CloseDate":   time.Now().UTC().Format("2006-01-02"),
+		"Amount":      lead.DealValue,
+		"Description": fmt.Sprintf("Won lead %s <%s> (Salesforce lead %s)", lead.Name, lead.Email, salesforceLeadID),
+	}
+}
+
+// splitName splits a full name into Salesforce's first and last names; the
+// last word is the last name.
+func splitName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	i := strings.LastIndex(name, " ")
+	if i < 0 {
+		return "", name
+	}
+	return strings.TrimSpace(name[:i]), name[i+1:]
+}
+
+func companyOf(lead *model.Lead) string {
+	if lead.Company == nil || strings.TrimSpace(*lead.Company) == "" {
+		return unknownCompany
+	}
+	return *lead.Company
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
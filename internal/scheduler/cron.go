@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// NextRun returns the first activation of a standard five-field cron
+// expression (or descriptor such as "@daily") strictly after the given time.
+func NextRun(expr string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return schedule.Next(after), nil
+}
+
+// RunCron calls fn at every activation of expr until ctx is cancelled. It is
+// meant for in-process maintenance jobs that do not need run history.
+func RunCron(ctx context.Context, expr string, name string, fn func(ctx context.Context) error) error {
+	if _, err := NextRun(expr, time.Now()); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			next, _ := NextRun(expr, time.Now())
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if err := fn(ctx); err != nil {
+				slog.Error("cron job failed", "job", name, "error", err)
+			}
+		}
+	}()
+
+	return nil
+}
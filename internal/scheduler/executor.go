@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+// AgentExecutor is the default executor. It verifies the agent still exists
+// and is ACTIVE before handing the run to the agent's work function.
+type AgentExecutor struct {
+	DB   *database.DB
+	Work func(ctx context.Context, agent *model.AIAgent, run *model.AgentRun) error
+}
+
+func (e *AgentExecutor) Execute(ctx context.Context, run *model.AgentRun) error {
+	agent, err := e.DB.GetAIAgentByID(ctx, run.AgentID)
+	if err != nil {
+		return err
+	}
+	if agent == nil {
+		return fmt.Errorf("agent %s not found", run.AgentID)
+	}
+	if agent.Status != model.AgentStatusActive {
+		LogFrom(ctx).Warnf("Agent %q is %s", agent.Name, agent.Status)
+		return ErrAgentInactive
+	}
+
+	if e.Work == nil {
+		LogFrom(ctx).Warnf("No work is configured for agents, nothing to do")
+		return nil
+	}
+	return e.Work(ctx, agent, run)
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+)
+
+// RunLog records the progress of an agent run: log lines, which are stored
+// and streamed as they are written, and the run's counters. Executors get it
+// from the context with LogFrom. A nil RunLog discards everything.
+type RunLog struct {
+	db     *database.DB
+	events *events.Broker
+
+	mu  sync.Mutex
+	run *model.AgentRun
+}
+
+type runLogKey struct{}
+
+// LogFrom returns the RunLog of the run being executed, or nil outside a run.
+func LogFrom(ctx context.Context) *RunLog {
+	runLog, _ := ctx.Value(runLogKey{}).(*RunLog)
+	return runLog
+}
+
+func withRunLog(ctx context.Context, runLog *RunLog) context.Context {
+	return context.WithValue(ctx, runLogKey{}, runLog)
+}
+
+func (l *RunLog) Infof(format string, args ...interface{}) {
+	l.write(model.AgentRunLogLevelInfo, format, args...)
+}
+
+func (l *RunLog) Warnf(format string, args ...interface{}) {
+	l.write(model.AgentRunLogLevelWarn, format, args...)
+}
+
+// Errorf logs an error that did not stop the run and counts it.
+func (l *RunLog) Errorf(format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.run.ErrorCount++
+	l.mu.Unlock()
+	l.write(model.AgentRunLogLevelError, format, args...)
+}
+
+func (l *RunLog) LeadProcessed() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.run.LeadsProcessed++
+	l.mu.Unlock()
+}
+
+func (l *RunLog) MessageSent() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.run.MessagesSent++
+	l.mu.Unlock()
+}
+
+func (l *RunLog) write(level model.AgentRunLogLevel, format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+
+	entry := &model.AgentRunLog{
+		RunID:     l.run.ID,
+		Level:     level,
+		Message:   fmt.Sprintf(format, args...),
+		CreatedAt: time.Now(),
+	}
+
+	// The run's context may already be cancelled when its outcome is logged.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := l.db.CreateAgentRunLog(ctx, entry); err != nil {
+		slog.Error("scheduler: error recording run log", "run_id", entry.RunID, "error", err)
+		return
+	}
+	if l.events != nil {
+		l.events.Publish(events.TopicAgentRunLog, entry)
+	}
+}
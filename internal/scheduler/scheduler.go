@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/tenant"
+)
+
+// ErrAgentInactive is returned by executors when the agent is not in a
+// runnable state; such runs are recorded as CANCELLED rather than FAILED.
+var ErrAgentInactive = errors.New("agent is not active")
+
+type Executor interface {
+	Execute(ctx context.Context, run *model.AgentRun) error
+}
+
+type ExecutorFunc func(ctx context.Context, run *model.AgentRun) error
+
+func (f ExecutorFunc) Execute(ctx context.Context, run *model.AgentRun) error {
+	return f(ctx, run)
+}
+
+type Options struct {
+	Workers      int
+	PollInterval time.Duration
+	RunTimeout   time.Duration
+	// Events, when set, receives run log lines as they are written.
+	Events *events.Broker
+	// OnFailure, when set, runs after a run is recorded as FAILED.
+	OnFailure func(ctx context.Context, run *model.AgentRun)
+}
+
+// Scheduler turns due cron schedules into queued agent runs and executes
+// queued runs on a fixed-size worker pool.
+type Scheduler struct {
+	db       *database.DB
+	executor Executor
+	opts     Options
+	runs     chan *model.AgentRun
+	wg       sync.WaitGroup
+}
+
+func New(db *database.DB, executor Executor, opts Options) *Scheduler {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 15 * time.Second
+	}
+	if opts.RunTimeout <= 0 {
+		opts.RunTimeout = 10 * time.Minute
+	}
+
+	return &Scheduler{
+		db:       db,
+		executor: executor,
+		opts:     opts,
+		runs:     make(chan *model.AgentRun),
+	}
+}
+
+// Start launches the polling loop and workers. They stop when ctx is
+// cancelled; call Wait to block until in-flight runs have finished.
+func (s *Scheduler) Start(ctx context.Context) {
+	for i := 0; i < s.opts.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker(ctx)
+	}
+
+	s.wg.Add(1)
+	go s.poll(ctx)
+}
+
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) poll(ctx context.Context) {
+	defer s.wg.Done()
+	defer close(s.runs)
+
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	if _, err := s.db.EnqueueDueScheduledRuns(ctx, time.Now(), NextRun); err != nil {
+		slog.Error("scheduler: error enqueueing scheduled runs", "error", err)
+	}
+
+	runs, err := s.db.ClaimQueuedAgentRuns(ctx, s.opts.Workers)
+	if err != nil {
+		slog.Error("scheduler: error claiming queued runs", "error", err)
+		return
+	}
+
+	for i, run := range runs {
+		select {
+		case s.runs <- run:
+		case <-ctx.Done():
+			// Claimed during shutdown but never started.
+			for _, pending := range runs[i:] {
+				s.finish(s.runLog(pending), time.Now(), ctx.Err())
+			}
+			return
+		}
+	}
+}
+
+func (s *Scheduler) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	for run := range s.runs {
+		started := time.Now()
+		runLog := s.runLog(run)
+		runLog.Infof("Run started")
+
+		runCtx, cancel := context.WithTimeout(withRunLog(ctx, runLog), s.opts.RunTimeout)
+		err := s.executor.Execute(runCtx, run)
+		cancel()
+
+		s.finish(runLog, started, err)
+	}
+}
+
+func (s *Scheduler) runLog(run *model.AgentRun) *RunLog {
+	return &RunLog{db: s.db, events: s.opts.Events, run: run}
+}
+
+func (s *Scheduler) finish(runLog *RunLog, started time.Time, err error) {
+	run := runLog.run
+	finished := time.Now()
+	duration := int(finished.Sub(started).Milliseconds())
+
+	run.FinishedAt = &finished
+	run.DurationMs = &duration
+
+	switch {
+	case err == nil:
+		run.Status = model.AgentRunStatusSucceeded
+		runLog.Infof("Run succeeded: %d leads processed, %d messages sent", run.LeadsProcessed, run.MessagesSent)
+	case errors.Is(err, ErrAgentInactive), errors.Is(err, context.Canceled):
+		run.Status = model.AgentRunStatusCancelled
+		runLog.Warnf("Run cancelled: %v", err)
+	default:
+		run.Status = model.AgentRunStatusFailed
+		runLog.write(model.AgentRunLogLevelError, "Run failed: %v", err)
+	}
+	if err != nil {
+		message := err.Error()
+		run.Error = &message
+	}
+
+	// Use a fresh context so results are persisted even during shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.FinishAgentRun(ctx, run); err != nil {
+		slog.Error("scheduler: error finishing run", "run_id", run.ID, "error", err)
+	}
+
+	if run.Status == model.AgentRunStatusFailed && s.opts.OnFailure != nil {
+		s.opts.OnFailure(tenant.WithSystem(ctx), run)
+	}
+}
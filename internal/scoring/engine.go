@@ -0,0 +1,305 @@
+package scoring
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+)
+
+const (
+	SourceManual    = "MANUAL"
+	SourceRecompute = "RECOMPUTE"
+
+	recomputeBatchSize = 500
+)
+
+// Weights controls how much each signal contributes to the final score. The
+// three weights are expected to sum to 1.
+type Weights struct {
+	Recency    float64
+	Response   float64
+	Engagement float64
+	// RecencyHalfLife is how long it takes the recency signal to decay by half.
+	RecencyHalfLife time.Duration
+}
+
+var DefaultWeights = Weights{
+	Recency:         0.35,
+	Response:        0.40,
+	Engagement:      0.25,
+	RecencyHalfLife: 14 * 24 * time.Hour,
+}
+
+// channelWeights rank how strong a reply on each channel is as a buying signal.
+var channelWeights = map[model.Channel]float64{
+	model.ChannelPhone:     1.0,
+	model.ChannelInPerson:  1.0,
+	model.ChannelLinkedin:  0.8,
+	model.ChannelWhatsapp:  0.8,
+	model.ChannelWebChat:   0.8,
+	model.ChannelSms:       0.7,
+	model.ChannelEmail:     0.6,
+	model.ChannelTwitter:   0.5,
+	model.ChannelFacebook:  0.5,
+	model.ChannelInstagram: 0.5,
+	model.ChannelOther:     0.5,
+}
+
+// ChangeHook runs after a recalculation or a manual edit changed a lead's
+// intent score.
+type ChangeHook func(ctx context.Context, change *database.IntentScoreChange)
+
+type Engine struct {
+	db          *database.DB
+	leads       database.LeadRepository
+	weights     Weights
+	now         func() time.Time
+	changeHooks []ChangeHook
+}
+
+// NewEngine returns an engine that reads scoring rulesets from db and reads
+// and scores leads, with their interactions, through leads.
+func NewEngine(db *database.DB, leads database.LeadRepository, weights Weights) *Engine {
+	return &Engine{db: db, leads: leads, weights: weights, now: time.Now}
+}
+
+// OnChange registers a hook to run for each score changed by Recalculate or
+// RecordManual.
+func (e *Engine) OnChange(hook ChangeHook) {
+	e.changeHooks = append(e.changeHooks, hook)
+}
+
+// Score computes an intent score in [0, 1] from a lead's interactions.
+func (e *Engine) Score(interactions []*model.Interaction) float64 {
+	if len(interactions) == 0 {
+		return 0
+	}
+
+	a := e.measure(interactions)
+	score := e.weights.Recency*a.recency + e.weights.Response*a.responseRate + e.weights.Engagement*a.engagement
+	return math.Round(math.Max(0, math.Min(score, 1))*1000) / 1000
+}
+
+// activity summarises a lead's interactions into the default model's signals.
+type activity struct {
+	latest               time.Time
+	attempted, responded int
+	engagedChannels      map[model.Channel]bool
+
+	recency, responseRate, engagement float64
+}
+
+func (e *Engine) measure(interactions []*model.Interaction) activity {
+	a := activity{engagedChannels: make(map[model.Channel]bool)}
+
+	for _, interaction := range interactions {
+		// Drafts and suppressed duplicates were never sent, not even later.
+		switch interaction.Status {
+		case model.InteractionStatusPendingReview, model.InteractionStatusRejected, model.InteractionStatusSuppressed:
+			continue
+		}
+		if interaction.Timestamp.After(a.latest) {
+			a.latest = interaction.Timestamp
+		}
+		if interaction.Status == model.InteractionStatusScheduled || interaction.Status == model.InteractionStatusQueued {
+			continue
+		}
+
+		a.attempted++
+		if interaction.Status == model.InteractionStatusResponded || interaction.Response != nil {
+			a.responded++
+			a.engagedChannels[interaction.Channel] = true
+		}
+	}
+
+	if !a.latest.IsZero() {
+		age := e.now().Sub(a.latest)
+		if age < 0 {
+			age = 0
+		}
+		a.recency = math.Pow(0.5, age.Hours()/e.weights.RecencyHalfLife.Hours())
+	}
+
+	if a.attempted > 0 {
+		a.responseRate = float64(a.responded) / float64(a.attempted)
+	}
+
+	for channel := range a.engagedChannels {
+		a.engagement += channelWeights[channel]
+	}
+	// Replies on two strong channels already indicate full engagement.
+	a.engagement = math.Min(a.engagement/2, 1)
+
+	return a
+}
+
+// Signals collects the values scoring rules can test for a lead.
+func (e *Engine) Signals(lead *model.Lead, interactions []*model.Interaction) Signals {
+	a := e.measure(interactions)
+
+	s := Signals{
+		Numbers: map[string]float64{
+			"recency":      a.recency,
+			"responseRate": a.responseRate,
+			"engagement":   a.engagement,
+			"interactions": float64(a.attempted),
+			"replies":      float64(a.responded),
+		},
+		Texts: map[string]string{
+			"status": string(lead.Status),
+			"email":  lead.Email,
+		},
+		Lists: map[string][]string{
+			"tags": lead.Tags,
+		},
+	}
+
+	if !a.latest.IsZero() {
+		s.Numbers["daysSinceContact"] = math.Max(e.now().Sub(a.latest).Hours()/24, 0)
+	}
+	if lead.DealValue != nil {
+		s.Numbers["dealValue"] = *lead.DealValue
+	}
+	if lead.Company != nil {
+		s.Texts["company"] = *lead.Company
+	}
+	if lead.Position != nil {
+		s.Texts["position"] = *lead.Position
+	}
+	if lead.Source != nil {
+		s.Texts["source"] = *lead.Source
+	}
+	for channel := range a.engagedChannels {
+		s.Lists["repliedChannels"] = append(s.Lists["repliedChannels"], string(channel))
+	}
+
+	return s
+}
+
+// Simulate scores the lead with rules without saving anything.
+func (e *Engine) Simulate(ctx context.Context, lead *model.Lead, rules string) (float64, []Contribution, error) {
+	rs, err := ParseRuleset(rules)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	interactions, err := e.leads.GetInteractionsByLeadIDs(ctx, []string{lead.ID})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	score, contributions := rs.Evaluate(e.Signals(lead, interactions[lead.ID]))
+	return score, contributions, nil
+}
+
+// Recalculate rescores the given leads, persists changed scores with history,
+// and returns the leads whose score changed. Leads last reached by a campaign
+// of a client with an active ruleset are scored by that ruleset; the rest use
+// the default weights, except leads without interactions, which keep the
+// score they were imported or set with.
+func (e *Engine) Recalculate(ctx context.Context, leadIDs []string) ([]*model.Lead, error) {
+	interactions, err := e.leads.GetInteractionsByLeadIDs(ctx, leadIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesets, err := e.db.GetActiveScoringRulesetsByLeadIDs(ctx, leadIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesetLeads := make(map[string]*model.Lead, len(rulesets))
+	if len(rulesets) > 0 {
+		ids := make([]string, 0, len(rulesets))
+		for leadID := range rulesets {
+			ids = append(ids, leadID)
+		}
+		found, err := e.leads.GetLeadsByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, lead := range found {
+			rulesetLeads[lead.ID] = lead
+		}
+	}
+
+	parsed := make(map[string]*Ruleset)
+	scores := make(map[string]float64, len(leadIDs))
+	for _, leadID := range leadIDs {
+		stored, lead := rulesets[leadID], rulesetLeads[leadID]
+		if stored == nil || lead == nil {
+			// Score has nothing to go on and would reset the score to 0.
+			if len(interactions[leadID]) == 0 {
+				continue
+			}
+			scores[leadID] = e.Score(interactions[leadID])
+			continue
+		}
+
+		rs, ok := parsed[stored.ID]
+		if !ok {
+			if rs, err = ParseRuleset(stored.Rules); err != nil {
+				return nil, fmt.Errorf("scoring ruleset %s: %w", stored.ID, err)
+			}
+			parsed[stored.ID] = rs
+		}
+		scores[leadID], _ = rs.Evaluate(e.Signals(lead, interactions[leadID]))
+	}
+
+	changes, err := e.leads.UpdateIntentScores(ctx, scores, SourceRecompute)
+	if err != nil {
+		return nil, err
+	}
+
+	leads := make([]*model.Lead, 0, len(changes))
+	for _, change := range changes {
+		for _, hook := range e.changeHooks {
+			hook(ctx, change)
+		}
+		leads = append(leads, change.Lead)
+	}
+
+	return leads, nil
+}
+
+// RecordManual records a score set by hand, which lead already carries, and
+// runs the change hooks as Recalculate does.
+func (e *Engine) RecordManual(ctx context.Context, lead *model.Lead, previous float64) error {
+	if err := e.leads.RecordIntentScore(ctx, lead.ID, lead.IntentScore, &previous, SourceManual); err != nil {
+		return err
+	}
+
+	change := &database.IntentScoreChange{Lead: lead, Previous: previous}
+	for _, hook := range e.changeHooks {
+		hook(ctx, change)
+	}
+	return nil
+}
+
+// RecalculateAll rescores every lead in batches and returns how many changed.
+func (e *Engine) RecalculateAll(ctx context.Context) (int, error) {
+	changed := 0
+	afterID := ""
+
+	for {
+		leadIDs, err := e.leads.GetLeadIDsAfter(ctx, afterID, recomputeBatchSize)
+		if err != nil {
+			return changed, err
+		}
+		if len(leadIDs) == 0 {
+			return changed, nil
+		}
+
+		leads, err := e.Recalculate(ctx, leadIDs)
+		if err != nil {
+			return changed, err
+		}
+
+		changed += len(leads)
+		afterID = leadIDs[len(leadIDs)-1]
+	}
+}
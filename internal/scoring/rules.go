@@ -0,0 +1,261 @@
+package scoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Ruleset is a client's own recipe for intent scores, stored as JSON. A
+// lead's score is Base plus the contribution of every rule, clamped to
+// [0, 1].
+//
+// A rule either weights a numeric signal, adding Weight times the signal's
+// value, or tests a condition and adds Points when it holds:
+//
+//	{"signal": "recency", "weight": 0.4}
+//	{"signal": "replies", "op": "gte", "value": 2, "points": 0.2}
+//	{"signal": "tags", "op": "contains", "value": "enterprise", "points": 0.1}
+//	{"signal": "status", "op": "in", "value": ["QUALIFIED", "MEETING"], "points": 0.2}
+//
+// Conditions on a signal the lead has no value for, such as dealValue when
+// none is set, never hold.
+type Ruleset struct {
+	Base  float64 `json:"base"`
+	Rules []Rule  `json:"rules"`
+}
+
+type Rule struct {
+	Name   string          `json:"name,omitempty"`
+	Signal string          `json:"signal"`
+	Op     string          `json:"op,omitempty"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Weight float64         `json:"weight,omitempty"`
+	Points float64         `json:"points,omitempty"`
+
+	number float64
+	texts  []string
+}
+
+type signalKind int
+
+const (
+	numberSignal signalKind = iota
+	textSignal
+	listSignal
+)
+
+// signalKinds lists the signals rules can use. recency, responseRate and
+// engagement are the default model's inputs and lie in [0, 1].
+var signalKinds = map[string]signalKind{
+	"recency":          numberSignal,
+	"responseRate":     numberSignal,
+	"engagement":       numberSignal,
+	"interactions":     numberSignal,
+	"replies":          numberSignal,
+	"daysSinceContact": numberSignal,
+	"dealValue":        numberSignal,
+	"status":           textSignal,
+	"source":           textSignal,
+	"company":          textSignal,
+	"position":         textSignal,
+	"email":            textSignal,
+	"tags":             listSignal,
+	"repliedChannels":  listSignal,
+}
+
+var opsByKind = map[signalKind][]string{
+	numberSignal: {"eq", "neq", "gt", "gte", "lt", "lte"},
+	textSignal:   {"eq", "neq", "contains", "in"},
+	listSignal:   {"contains", "in"},
+}
+
+// ParseRuleset decodes and validates rules written in the Ruleset format.
+func ParseRuleset(rules string) (*Ruleset, error) {
+	dec := json.NewDecoder(strings.NewReader(rules))
+	dec.DisallowUnknownFields()
+
+	var rs Ruleset
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("invalid scoring rules: %w", err)
+	}
+	if dec.More() {
+		return nil, errors.New("invalid scoring rules: unexpected data after the ruleset")
+	}
+
+	for i := range rs.Rules {
+		if err := rs.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid scoring rule %s: %w", rs.Rules[i].label(i), err)
+		}
+	}
+
+	return &rs, nil
+}
+
+func (r *Rule) compile() error {
+	kind, ok := signalKinds[r.Signal]
+	if !ok {
+		return fmt.Errorf("unknown signal %q", r.Signal)
+	}
+
+	if r.Op == "" {
+		if kind != numberSignal {
+			return fmt.Errorf("signal %s is not numeric and needs an op", r.Signal)
+		}
+		if len(r.Value) > 0 || r.Points != 0 {
+			return errors.New("weighted rules take no value or points")
+		}
+		return nil
+	}
+
+	if !containsString(opsByKind[kind], r.Op) {
+		return fmt.Errorf("op %q is not supported for signal %s", r.Op, r.Signal)
+	}
+	if r.Weight != 0 {
+		return errors.New("conditional rules take points, not a weight")
+	}
+	if len(r.Value) == 0 {
+		return errors.New("missing value")
+	}
+
+	value := json.NewDecoder(bytes.NewReader(r.Value))
+	switch {
+	case kind == numberSignal:
+		if err := value.Decode(&r.number); err != nil {
+			return errors.New("value must be a number")
+		}
+	case r.Op == "in":
+		if err := value.Decode(&r.texts); err != nil || len(r.texts) == 0 {
+			return errors.New("value must be a non-empty list of strings")
+		}
+	default:
+		var text string
+		if err := value.Decode(&text); err != nil {
+			return errors.New("value must be a string")
+		}
+		r.texts = []string{text}
+	}
+
+	return nil
+}
+
+func (r *Rule) label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// Signals are a lead's values for the signals rules test. Signals the lead
+// has no value for are absent.
+type Signals struct {
+	Numbers map[string]float64
+	Texts   map[string]string
+	Lists   map[string][]string
+}
+
+// Contribution is what one rule added to a score.
+type Contribution struct {
+	Rule    string
+	Matched bool
+	Points  float64
+}
+
+// Evaluate scores signals against the ruleset and reports each rule's part
+// in the score, in rule order.
+func (rs *Ruleset) Evaluate(signals Signals) (float64, []Contribution) {
+	score := rs.Base
+	contributions := make([]Contribution, len(rs.Rules))
+
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		c := Contribution{Rule: rule.label(i)}
+
+		if rule.Op == "" {
+			if value, ok := signals.Numbers[rule.Signal]; ok {
+				c.Matched = true
+				c.Points = rule.Weight * value
+			}
+		} else if rule.matches(signals) {
+			c.Matched = true
+			c.Points = rule.Points
+		}
+
+		score += c.Points
+		contributions[i] = c
+	}
+
+	return math.Round(math.Max(0, math.Min(score, 1))*1000) / 1000, contributions
+}
+
+func (r *Rule) matches(signals Signals) bool {
+	switch signalKinds[r.Signal] {
+	case numberSignal:
+		value, ok := signals.Numbers[r.Signal]
+		if !ok {
+			return false
+		}
+		switch r.Op {
+		case "eq":
+			return value == r.number
+		case "neq":
+			return value != r.number
+		case "gt":
+			return value > r.number
+		case "gte":
+			return value >= r.number
+		case "lt":
+			return value < r.number
+		case "lte":
+			return value <= r.number
+		}
+
+	case textSignal:
+		value, ok := signals.Texts[r.Signal]
+		if !ok {
+			return false
+		}
+		switch r.Op {
+		case "eq":
+			return strings.EqualFold(value, r.texts[0])
+		case "neq":
+			return !strings.EqualFold(value, r.texts[0])
+		case "contains":
+			return strings.Contains(strings.ToLower(value), strings.ToLower(r.texts[0]))
+		case "in":
+			return containsFold(r.texts, value)
+		}
+
+	case listSignal:
+		// contains and in both hold when any of the lead's values is one
+		// of the rule's.
+		for _, value := range signals.Lists[r.Signal] {
+			if containsFold(r.texts, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
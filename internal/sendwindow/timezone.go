@@ -0,0 +1,108 @@
+package sendwindow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// zonesByCallingCode maps international calling codes to the time zone of
+// countries that have only one. Countries spanning several zones, such as
+// +1, +7, +52, +55, +61 and +62, are left out because the number alone does
+// not say which applies.
+var zonesByCallingCode = map[string]string{
+	"20":  "Africa/Cairo",
+	"27":  "Africa/Johannesburg",
+	"30":  "Europe/Athens",
+	"31":  "Europe/Amsterdam",
+	"32":  "Europe/Brussels",
+	"33":  "Europe/Paris",
+	"36":  "Europe/Budapest",
+	"39":  "Europe/Rome",
+	"40":  "Europe/Bucharest",
+	"41":  "Europe/Zurich",
+	"43":  "Europe/Vienna",
+	"44":  "Europe/London",
+	"45":  "Europe/Copenhagen",
+	"46":  "Europe/Stockholm",
+	"47":  "Europe/Oslo",
+	"48":  "Europe/Warsaw",
+	"49":  "Europe/Berlin",
+	"51":  "America/Lima",
+	"54":  "America/Argentina/Buenos_Aires",
+	"57":  "America/Bogota",
+	"60":  "Asia/Kuala_Lumpur",
+	"63":  "Asia/Manila",
+	"65":  "Asia/Singapore",
+	"66":  "Asia/Bangkok",
+	"81":  "Asia/Tokyo",
+	"82":  "Asia/Seoul",
+	"84":  "Asia/Ho_Chi_Minh",
+	"86":  "Asia/Shanghai",
+	"91":  "Asia/Kolkata",
+	"92":  "Asia/Karachi",
+	"234": "Africa/Lagos",
+	"254": "Africa/Nairobi",
+	"353": "Europe/Dublin",
+	"358": "Europe/Helsinki",
+	"420": "Europe/Prague",
+	"852": "Asia/Hong_Kong",
+	"880": "Asia/Dhaka",
+	"966": "Asia/Riyadh",
+	"971": "Asia/Dubai",
+	"972": "Asia/Jerusalem",
+}
+
+// TimezoneForPhone returns the time zone of an international phone number,
+// written with a leading "+" or "00", or "" when it cannot be told.
+func TimezoneForPhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, phone)
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		return ""
+	}
+
+	// Calling codes are prefix-free, so at most one of these matches.
+	for n := 1; n <= 3 && n <= len(digits); n++ {
+		if zone, ok := zonesByCallingCode[digits[:n]]; ok {
+			return zone
+		}
+	}
+	return ""
+}
+
+// DetectTimezone fills in the lead's time zone from its phone number when
+// none is set.
+func DetectTimezone(lead *model.Lead) {
+	if lead.Timezone != nil && *lead.Timezone != "" || lead.Phone == nil {
+		return
+	}
+	if zone := TimezoneForPhone(*lead.Phone); zone != "" {
+		lead.Timezone = &zone
+	}
+}
+
+// CheckTimezone reports an error unless name is an IANA time zone such as
+// "Europe/Berlin".
+func CheckTimezone(name string) error {
+	if name == "" || name == "Local" {
+		return fmt.Errorf("invalid time zone %q", name)
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid time zone %q", name)
+	}
+	return nil
+}
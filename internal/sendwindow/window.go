@@ -0,0 +1,216 @@
+// Package sendwindow decides when outbound campaign messages may be sent,
+// based on hours in the recipient's local time.
+package sendwindow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zone names must resolve on hosts without zoneinfo
+)
+
+// Window is a campaign's send policy, stored as JSON:
+//
+//	{"start": "09:00", "end": "17:00", "days": ["MONDAY", "FRIDAY"], "timezone": "Europe/London"}
+//
+// Start and end are local times in the lead's time zone, or in Timezone for
+// leads without one. A window ending before it starts runs past midnight and
+// one ending when it starts lasts all day. Days are the days the window opens
+// on; none means every day.
+type Window struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Days     []string `json:"days,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+
+	start, end int
+	days       [7]bool
+	fallback   *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"SUNDAY":    time.Sunday,
+	"MONDAY":    time.Monday,
+	"TUESDAY":   time.Tuesday,
+	"WEDNESDAY": time.Wednesday,
+	"THURSDAY":  time.Thursday,
+	"FRIDAY":    time.Friday,
+	"SATURDAY":  time.Saturday,
+}
+
+// New builds and validates a window.
+func New(start, end string, days []string, timezone string) (*Window, error) {
+	w := &Window{Start: start, End: end, Days: days, Timezone: timezone}
+	if err := w.compile(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Quiet returns the window outside quiet hours from start to end, so that
+// Next skips them. Quiet hours that end when they start are never quiet.
+func Quiet(start, end string) (*Window, error) {
+	if _, err := parseClock(start); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours start: %w", err)
+	}
+	if _, err := parseClock(end); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours end: %w", err)
+	}
+	return New(end, start, nil, "")
+}
+
+// Parse decodes and validates a window stored as JSON.
+func Parse(data string) (*Window, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var w Window
+	if err := dec.Decode(&w); err != nil {
+		return nil, fmt.Errorf("invalid send window: %w", err)
+	}
+	if err := w.compile(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// JSON encodes the window in the form Parse reads.
+func (w *Window) JSON() string {
+	data, _ := json.Marshal(w)
+	return string(data)
+}
+
+func (w *Window) compile() error {
+	var err error
+	if w.start, err = parseClock(w.Start); err != nil {
+		return fmt.Errorf("invalid send window start: %w", err)
+	}
+	if w.end, err = parseClock(w.End); err != nil {
+		return fmt.Errorf("invalid send window end: %w", err)
+	}
+
+	w.days = [7]bool{}
+	for _, name := range w.Days {
+		day, ok := weekdays[strings.ToUpper(name)]
+		if !ok {
+			return fmt.Errorf("invalid send window day %q", name)
+		}
+		w.days[day] = true
+	}
+	if len(w.Days) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	w.fallback = time.UTC
+	if w.Timezone != "" {
+		if w.fallback, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("invalid send window time zone %q", w.Timezone)
+		}
+	}
+
+	return nil
+}
+
+// parseClock returns the minutes since midnight of an "HH:MM" time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("time must be HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Location returns the time zone the window applies in for a lead with the
+// given time zone, falling back to the window's own.
+func (w *Window) Location(timezone *string) *time.Location {
+	if timezone != nil && *timezone != "" {
+		if loc, err := time.LoadLocation(*timezone); err == nil {
+			return loc
+		}
+	}
+	return w.fallback
+}
+
+// Next returns t when the window is open at t in loc, and otherwise the time
+// it next opens.
+func (w *Window) Next(t time.Time, loc *time.Location) time.Time {
+	opens, _, ok := w.span(t, loc)
+	if ok && t.Before(opens) {
+		return opens
+	}
+	return t
+}
+
+// Add returns the time by which d of open time has passed from t, counting
+// only the time the window is open in loc. Gaps of more than a week between
+// openings, which a compiled window never has, end the count early.
+func (w *Window) Add(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	for d > 0 {
+		opens, closes, ok := w.span(t, loc)
+		if !ok {
+			break
+		}
+		if t.Before(opens) {
+			t = opens
+		}
+		if open := closes.Sub(t); open < d {
+			d -= open
+			t = closes
+			continue
+		}
+		return t.Add(d)
+	}
+	return t
+}
+
+// span returns when the first opening of the window that closes after t
+// opens and closes in loc.
+func (w *Window) span(t time.Time, loc *time.Location) (opens, closes time.Time, ok bool) {
+	local := t.In(loc)
+	year, month, day := local.Date()
+
+	// Start from the previous day so a window that opened yesterday and runs
+	// past midnight is found.
+	for offset := -1; offset <= 7; offset++ {
+		date := time.Date(year, month, day+offset, 0, 0, 0, 0, loc)
+		if !w.days[date.Weekday()] {
+			continue
+		}
+
+		opens = time.Date(date.Year(), date.Month(), date.Day(), 0, w.start, 0, 0, loc)
+		closes = time.Date(date.Year(), date.Month(), date.Day(), 0, w.end, 0, 0, loc)
+		if w.end <= w.start {
+			closes = time.Date(date.Year(), date.Month(), date.Day()+1, 0, w.end, 0, 0, loc)
+		}
+
+		if t.Before(closes) {
+			return opens, closes, true
+		}
+	}
+
+	return t, t, false
+}
+
+// maxRounds bounds NextOpen for windows that never overlap.
+const maxRounds = 16
+
+// NextOpen returns the first time from t on when every window is open for a
+// lead in timezone, such as a campaign's send window and the lead's quiet
+// hours. Windows that never overlap give up after a few rounds.
+func NextOpen(t time.Time, timezone *string, windows ...*Window) time.Time {
+	for i := 0; i < maxRounds; i++ {
+		moved := false
+		for _, w := range windows {
+			if next := w.Next(t, w.Location(timezone)); next.After(t) {
+				t = next
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return t
+}
@@ -0,0 +1,472 @@
+// Package sequence runs multi-step outreach sequences: ordered steps, each a
+// number of days after enrollment, that send a template on channels with a
+// provider and create follow-up tasks for reps on the others.
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/channels"
+	"salesagency/internal/database"
+	"salesagency/internal/logging"
+	"salesagency/internal/templates"
+)
+
+var (
+	ErrNotFound           = errors.New("sequence not found")
+	ErrEnrollmentNotFound = errors.New("sequence enrollment not found")
+	ErrAlreadyEnrolled    = errors.New("lead is already enrolled in this sequence")
+	ErrNotActive          = errors.New("sequence enrollment is not active")
+	ErrInUse              = errors.New("sequence has active enrollments")
+	ErrCampaignClosed     = errors.New("campaign is completed or cancelled")
+	ErrInvalidSteps       = errors.New("invalid sequence steps")
+)
+
+// runBatchSize is how many due enrollments RunDue claims at once.
+const runBatchSize = 100
+
+const day = 24 * time.Hour
+
+type Engine struct {
+	db       *database.DB
+	channels *channels.Dispatcher
+	now      func() time.Time
+}
+
+func NewEngine(db *database.DB, dispatcher *channels.Dispatcher) *Engine {
+	return &Engine{db: db, channels: dispatcher, now: time.Now}
+}
+
+// Create saves a new sequence for sequence.CampaignID with steps run in the
+// order given.
+func (e *Engine) Create(ctx context.Context, sequence *model.Sequence, steps []*model.SequenceStep) (*model.Sequence, error) {
+	campaign, err := e.db.GetCampaignByID(ctx, sequence.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("campaign not found")
+	}
+
+	if err := e.validateSteps(ctx, sequence.CampaignID, steps); err != nil {
+		return nil, err
+	}
+
+	sequence.CreatedAt = e.now()
+	return e.db.CreateSequence(ctx, sequence, steps)
+}
+
+// Update renames a sequence and replaces its steps. Sequences that leads are
+// still working through cannot be changed, since their progress is counted
+// in steps.
+func (e *Engine) Update(ctx context.Context, sequence *model.Sequence, steps []*model.SequenceStep) (*model.Sequence, error) {
+	existing, err := e.db.GetSequenceByID(ctx, sequence.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrNotFound
+	}
+
+	active, err := e.db.CountActiveEnrollments(ctx, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, ErrInUse
+	}
+
+	if err := e.validateSteps(ctx, existing.CampaignID, steps); err != nil {
+		return nil, err
+	}
+
+	now := e.now()
+	sequence.UpdatedAt = &now
+	updated, err := e.db.UpdateSequence(ctx, sequence, steps)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrNotFound
+	}
+	return updated, nil
+}
+
+// validateSteps checks that steps are in day order and that each can run:
+// steps on channels with a provider need a template to send, the others a
+// task for the rep. Templates must belong to the campaign and the step's
+// channel.
+func (e *Engine) validateSteps(ctx context.Context, campaignID string, steps []*model.SequenceStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: a sequence needs at least one step", ErrInvalidSteps)
+	}
+
+	for i, step := range steps {
+		n := i + 1
+		if step.Day < 0 {
+			return fmt.Errorf("%w: step %d has a negative day", ErrInvalidSteps, n)
+		}
+		if i > 0 && step.Day < steps[i-1].Day {
+			return fmt.Errorf("%w: step %d runs before step %d", ErrInvalidSteps, n, i)
+		}
+		if step.Task != nil && strings.TrimSpace(*step.Task) == "" {
+			step.Task = nil
+		}
+
+		if e.channels.CanSend(step.Channel) {
+			if step.TemplateID == nil {
+				return fmt.Errorf("%w: step %d on %s needs a template", ErrInvalidSteps, n, step.Channel)
+			}
+		} else if step.Task == nil {
+			return fmt.Errorf("%w: step %d on %s needs a task", ErrInvalidSteps, n, step.Channel)
+		}
+
+		if step.TemplateID == nil {
+			continue
+		}
+		template, err := e.db.GetMessageTemplateByID(ctx, *step.TemplateID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case template == nil:
+			return fmt.Errorf("%w: step %d: message template not found", ErrInvalidSteps, n)
+		case template.Campaign == nil || template.Campaign.ID != campaignID:
+			return fmt.Errorf("%w: step %d: message template belongs to another campaign", ErrInvalidSteps, n)
+		case template.Channel != step.Channel:
+			return fmt.Errorf("%w: step %d: message template is not a %s template", ErrInvalidSteps, n, step.Channel)
+		}
+	}
+
+	return nil
+}
+
+// Enroll starts a lead on a sequence. Its first step runs on the next RunDue
+// once the step's day has come.
+func (e *Engine) Enroll(ctx context.Context, sequenceID, leadID string) (*model.SequenceEnrollment, error) {
+	return e.enroll(ctx, sequenceID, leadID, false)
+}
+
+// Nurture starts a lost lead on a nurture sequence, as Enroll does, starting
+// it over when the lead has been through the sequence before.
+func (e *Engine) Nurture(ctx context.Context, sequenceID, leadID string) (*model.SequenceEnrollment, error) {
+	return e.enroll(ctx, sequenceID, leadID, true)
+}
+
+func (e *Engine) enroll(ctx context.Context, sequenceID, leadID string, nurture bool) (*model.SequenceEnrollment, error) {
+	sequence, err := e.db.GetSequenceByID(ctx, sequenceID)
+	if err != nil {
+		return nil, err
+	}
+	if sequence == nil {
+		return nil, ErrNotFound
+	}
+
+	lead, err := e.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, errors.New("lead not found")
+	}
+
+	campaign, err := e.db.GetCampaignByID(ctx, sequence.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil || closed(campaign.Status) {
+		return nil, ErrCampaignClosed
+	}
+
+	steps, err := e.db.GetSequenceSteps(ctx, sequence.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("%w: a sequence needs at least one step", ErrInvalidSteps)
+	}
+
+	now := e.now()
+	enrollment := &model.SequenceEnrollment{
+		SequenceID: sequence.ID,
+		LeadID:     lead.ID,
+		Status:     model.EnrollmentStatusActive,
+		EnrolledAt: now,
+		Nurture:    nurture,
+	}
+	enrollment.NextRunAt = runAt(enrollment, steps[0], now)
+
+	create := e.db.CreateSequenceEnrollment
+	if nurture {
+		create = e.db.RestartSequenceEnrollment
+	}
+	created, err := create(ctx, enrollment)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, ErrAlreadyEnrolled
+	}
+	return created, nil
+}
+
+// Stop takes a lead off a sequence before it finishes.
+func (e *Engine) Stop(ctx context.Context, enrollmentID string) (*model.SequenceEnrollment, error) {
+	enrollment, err := e.db.GetSequenceEnrollmentByID(ctx, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+	if enrollment.Status != model.EnrollmentStatusActive {
+		return nil, ErrNotActive
+	}
+
+	if err := e.finish(ctx, enrollment, model.EnrollmentStatusStopped); err != nil {
+		return nil, err
+	}
+	if enrollment.Status != model.EnrollmentStatusStopped {
+		return nil, ErrNotActive
+	}
+	return enrollment, nil
+}
+
+// HandleReply halts every sequence the replying lead is enrolled in, unless
+// the reply was classified as out of office. It is registered as a
+// channels.ReplyHook.
+func (e *Engine) HandleReply(ctx context.Context, reply *model.Interaction) {
+	intent, err := e.db.GetInteractionReplyIntent(ctx, reply.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to check reply intent", "lead_id", reply.Lead.ID, "error", err)
+	}
+	if intent != nil && *intent == model.ReplyIntentOutOfOffice {
+		return
+	}
+
+	if _, err := e.db.HaltLeadEnrollments(ctx, reply.Lead.ID, model.EnrollmentStatusReplied); err != nil {
+		logging.FromContext(ctx).Error("Failed to halt sequences after reply", "lead_id", reply.Lead.ID, "error", err)
+	}
+}
+
+// RunDue runs the next step of every enrollment that is due and returns how
+// many steps ran. Steps switch channel as the campaign's fallback rules say.
+// Enrollments of paused campaigns, or of leads handed off to a sales rep,
+// wait; those of completed or cancelled campaigns, or of leads that were
+// deleted or opted out, stop. Leads that replied since enrolling are halted
+// even when the reply was logged by hand.
+func (e *Engine) RunDue(ctx context.Context) (int, error) {
+	ran := 0
+	steps := make(map[string][]*model.SequenceStep)
+	for {
+		due, err := e.db.ClaimDueEnrollments(ctx, e.now(), runBatchSize)
+		if err != nil {
+			return ran, err
+		}
+
+		for _, enrollment := range due {
+			ok, err := e.run(ctx, enrollment, steps)
+			if err != nil {
+				slog.Error("Failed to run sequence step", "enrollment_id", enrollment.ID, "error", err)
+				continue
+			}
+			if ok {
+				ran++
+			}
+		}
+
+		if len(due) < runBatchSize {
+			return ran, nil
+		}
+	}
+}
+
+func (e *Engine) run(ctx context.Context, enrollment *model.SequenceEnrollment, cache map[string][]*model.SequenceStep) (bool, error) {
+	steps, ok := cache[enrollment.SequenceID]
+	if !ok {
+		var err error
+		steps, err = e.db.GetSequenceSteps(ctx, enrollment.SequenceID)
+		if err != nil {
+			return false, err
+		}
+		cache[enrollment.SequenceID] = steps
+	}
+	if enrollment.CurrentStep >= len(steps) {
+		return false, e.finish(ctx, enrollment, model.EnrollmentStatusCompleted)
+	}
+
+	lead, err := e.db.GetLeadByID(ctx, enrollment.LeadID)
+	if err != nil {
+		return false, err
+	}
+	if lead == nil {
+		return false, e.finish(ctx, enrollment, model.EnrollmentStatusStopped)
+	}
+
+	replied, err := e.db.HasReplySince(ctx, lead.ID, enrollment.EnrolledAt)
+	if err != nil {
+		return false, err
+	}
+	if replied {
+		return false, e.finish(ctx, enrollment, model.EnrollmentStatusReplied)
+	}
+
+	handedOff, err := e.db.IsLeadHandedOff(ctx, lead.ID)
+	if err != nil {
+		return false, err
+	}
+	if handedOff {
+		// The claim's lease brings the enrollment back once it runs out.
+		return false, nil
+	}
+
+	sequence, err := e.db.GetSequenceByID(ctx, enrollment.SequenceID)
+	if err != nil {
+		return false, err
+	}
+	if sequence == nil {
+		return false, nil
+	}
+	campaign, err := e.db.GetCampaignByID(ctx, sequence.CampaignID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case campaign == nil || closed(campaign.Status):
+		return false, e.finish(ctx, enrollment, model.EnrollmentStatusStopped)
+	case campaign.Status != model.CampaignStatusActive:
+		// The claim's lease brings the enrollment back once it runs out.
+		return false, nil
+	}
+
+	step, err := e.route(ctx, campaign.ID, lead, steps[enrollment.CurrentStep])
+	if err != nil {
+		return false, err
+	}
+	if err := e.runStep(ctx, lead, step); err != nil {
+		if errors.Is(err, channels.ErrOptedOut) || errors.Is(err, errStepUnavailable) {
+			slog.Info("Stopping sequence enrollment", "enrollment_id", enrollment.ID, "step", step.Position, "reason", err)
+			return false, e.finish(ctx, enrollment, model.EnrollmentStatusStopped)
+		}
+		return false, err
+	}
+
+	now := e.now()
+	enrollment.CurrentStep++
+	enrollment.UpdatedAt = &now
+	if enrollment.CurrentStep < len(steps) {
+		enrollment.NextRunAt = runAt(enrollment, steps[enrollment.CurrentStep], now)
+	} else {
+		enrollment.Status = model.EnrollmentStatusCompleted
+		enrollment.NextRunAt = nil
+	}
+
+	if _, err := e.db.UpdateSequenceEnrollment(ctx, enrollment); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// errStepUnavailable means a step can no longer run, e.g. because its
+// template was deleted.
+var errStepUnavailable = errors.New("sequence step can no longer run")
+
+// runStep sends the step's template when its channel has a provider, and
+// otherwise creates a follow-up task for the lead's rep, with the rendered
+// template, if any, as the message to use.
+func (e *Engine) runStep(ctx context.Context, lead *model.Lead, step *model.SequenceStep) error {
+	var template *model.MessageTemplate
+	var body string
+	if step.TemplateID != nil {
+		var err error
+		template, err = e.db.GetMessageTemplateByID(ctx, *step.TemplateID)
+		if err != nil {
+			return err
+		}
+		if template == nil {
+			return fmt.Errorf("%w: message template not found", errStepUnavailable)
+		}
+		content, err := e.db.GetLocalizedTemplateContent(ctx, template, lead.Language)
+		if err != nil {
+			return err
+		}
+		body, err = templates.Render(content, templates.LeadData(lead))
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.channels.CanSend(step.Channel) {
+		if template == nil {
+			return fmt.Errorf("%w: no message template", errStepUnavailable)
+		}
+		msg := &channels.Outbound{
+			Lead:     lead,
+			Body:     body,
+			Template: template,
+			AIAgent:  template.AIAgent,
+		}
+		if step.Channel == model.ChannelEmail {
+			msg.Subject = template.Name
+		}
+		_, err := e.channels.Send(ctx, step.Channel, msg)
+		return err
+	}
+
+	now := e.now()
+	task := &model.Interaction{
+		Lead:      lead,
+		Type:      e.channels.InteractionType(step.Channel),
+		Channel:   step.Channel,
+		Template:  template,
+		Timestamp: now,
+		Status:    model.InteractionStatusScheduled,
+		Notes:     step.Task,
+		CreatedAt: now,
+	}
+	if template != nil {
+		task.Message = &body
+		task.AIAgent = template.AIAgent
+	}
+	_, err := e.db.CreateFollowUpTask(ctx, task)
+	return err
+}
+
+// finish ends an enrollment. An enrollment halted meanwhile, e.g. by a
+// reply, keeps its status.
+func (e *Engine) finish(ctx context.Context, enrollment *model.SequenceEnrollment, status model.EnrollmentStatus) error {
+	now := e.now()
+	updated := *enrollment
+	updated.Status = status
+	updated.NextRunAt = nil
+	updated.UpdatedAt = &now
+
+	ok, err := e.db.UpdateSequenceEnrollment(ctx, &updated)
+	if err != nil {
+		return err
+	}
+	if ok {
+		*enrollment = updated
+	}
+	return nil
+}
+
+// runAt is when step runs for enrollment: its day counted from enrollment,
+// or now for steps whose day has passed.
+func runAt(enrollment *model.SequenceEnrollment, step *model.SequenceStep, now time.Time) *time.Time {
+	at := enrollment.EnrolledAt.Add(time.Duration(step.Day) * day)
+	if at.Before(now) {
+		at = now
+	}
+	return &at
+}
+
+func closed(status model.CampaignStatus) bool {
+	return status == model.CampaignStatusCompleted || status == model.CampaignStatusCancelled
+}
@@ -0,0 +1,134 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"salesagency/graph/model"
+	"salesagency/internal/logging"
+)
+
+var ErrInvalidFallbackRules = errors.New("invalid channel fallback rules")
+
+// SetFallbackRules replaces the campaign's channel fallback rules. Rules on
+// a channel are tried in the order given. A fallback channel with a provider
+// needs a template of the campaign on that channel to send.
+func (e *Engine) SetFallbackRules(ctx context.Context, campaignID string, rules []*model.ChannelFallbackRule) ([]*model.ChannelFallbackRule, error) {
+	for i, rule := range rules {
+		n := i + 1
+		if !rule.Trigger.IsValid() {
+			return nil, fmt.Errorf("%w: rule %d has an unknown trigger", ErrInvalidFallbackRules, n)
+		}
+		if rule.Channel == rule.FallbackChannel {
+			return nil, fmt.Errorf("%w: rule %d falls back to its own channel", ErrInvalidFallbackRules, n)
+		}
+		if rule.Threshold < 1 {
+			return nil, fmt.Errorf("%w: rule %d needs a threshold of at least 1", ErrInvalidFallbackRules, n)
+		}
+
+		if rule.TemplateID == nil {
+			if e.channels.CanSend(rule.FallbackChannel) {
+				return nil, fmt.Errorf("%w: rule %d on %s needs a template", ErrInvalidFallbackRules, n, rule.FallbackChannel)
+			}
+			continue
+		}
+		template, err := e.db.GetMessageTemplateByID(ctx, *rule.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case template == nil:
+			return nil, fmt.Errorf("%w: rule %d: message template not found", ErrInvalidFallbackRules, n)
+		case template.Campaign == nil || template.Campaign.ID != campaignID:
+			return nil, fmt.Errorf("%w: rule %d: message template belongs to another campaign", ErrInvalidFallbackRules, n)
+		case template.Channel != rule.FallbackChannel:
+			return nil, fmt.Errorf("%w: rule %d: message template is not a %s template", ErrInvalidFallbackRules, n, rule.FallbackChannel)
+		}
+	}
+
+	found, err := e.db.SetCampaignFallbackRules(ctx, campaignID, rules)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("campaign not found")
+	}
+	return rules, nil
+}
+
+// route returns the step to run for lead: step itself, or a copy on the
+// channel the campaign's fallback rules switch it to. A fallback can fall
+// back in turn, but never to a channel already tried.
+func (e *Engine) route(ctx context.Context, campaignID string, lead *model.Lead, step *model.SequenceStep) (*model.SequenceStep, error) {
+	rules, err := e.db.GetCampaignFallbackRules(ctx, campaignID)
+	if err != nil || len(rules) == 0 {
+		return step, err
+	}
+
+	prefs, err := e.db.GetLeadContactPreferences(ctx, lead.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := step
+	tried := map[model.Channel]bool{step.Channel: true}
+	for {
+		rule, err := e.matchRule(ctx, rules, lead, prefs, current.Channel)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil || tried[rule.FallbackChannel] {
+			return current, nil
+		}
+		tried[rule.FallbackChannel] = true
+
+		logging.FromContext(ctx).Info("Falling back to another channel",
+			"lead_id", lead.ID, "from", current.Channel, "to", rule.FallbackChannel, "trigger", rule.Trigger)
+
+		fallback := *step
+		fallback.Channel = rule.FallbackChannel
+		fallback.TemplateID = rule.TemplateID
+		if !e.channels.CanSend(fallback.Channel) && fallback.Task == nil {
+			task := fmt.Sprintf("Contact the lead on %s instead of %s", fallback.Channel, step.Channel)
+			fallback.Task = &task
+		}
+		current = &fallback
+	}
+}
+
+// matchRule returns the first rule on channel that applies to lead, or nil.
+func (e *Engine) matchRule(ctx context.Context, rules []*model.ChannelFallbackRule, lead *model.Lead, prefs *model.ContactPreferences, channel model.Channel) (*model.ChannelFallbackRule, error) {
+	for _, rule := range rules {
+		if rule.Channel != channel {
+			continue
+		}
+
+		var applies bool
+		switch rule.Trigger {
+		case model.FallbackTriggerNotPreferred:
+			applies = prefs != nil && prefs.PreferredChannel != nil && *prefs.PreferredChannel == rule.FallbackChannel
+		case model.FallbackTriggerOptedOut:
+			optedOut, err := e.db.IsOptedOut(ctx, lead.ID, channel)
+			if err != nil {
+				return nil, err
+			}
+			applies = optedOut
+		case model.FallbackTriggerBounced, model.FallbackTriggerFailed:
+			status := model.InteractionStatusBounced
+			if rule.Trigger == model.FallbackTriggerFailed {
+				status = model.InteractionStatusFailed
+			}
+			count, err := e.db.CountOutboundByStatus(ctx, lead.ID, channel, status)
+			if err != nil {
+				return nil, err
+			}
+			applies = count >= rule.Threshold
+		}
+
+		if applies {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
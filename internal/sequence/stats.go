@@ -0,0 +1,60 @@
+package sequence
+
+import (
+	"context"
+
+	"salesagency/graph/model"
+)
+
+// StepStats reports, for each step of a sequence, how many leads reached it
+// and what became of them right after: still waiting for the next step,
+// replied, stopped or completed. DropOff is the share of leads that reached
+// the step and left the sequence after it without completing it, by replying
+// or being stopped.
+func (e *Engine) StepStats(ctx context.Context, sequenceID string) ([]*model.SequenceStepStats, error) {
+	steps, err := e.db.GetSequenceSteps(ctx, sequenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := e.db.GetSequenceStepCounts(ctx, sequenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*model.SequenceStepStats, len(steps))
+	for i, step := range steps {
+		stats[i] = &model.SequenceStepStats{Step: step}
+	}
+
+	for _, count := range counts {
+		// An enrollment that ran n steps reached steps 0 to n-1.
+		for i := 0; i < count.Steps && i < len(stats); i++ {
+			stats[i].Reached += count.Count
+		}
+		if count.Steps == 0 || count.Steps > len(stats) {
+			continue
+		}
+
+		last := stats[count.Steps-1]
+		switch count.Status {
+		case model.EnrollmentStatusActive:
+			last.Active += count.Count
+		case model.EnrollmentStatusReplied:
+			last.Replied += count.Count
+		case model.EnrollmentStatusStopped:
+			last.Stopped += count.Count
+		case model.EnrollmentStatusCompleted:
+			last.Completed += count.Count
+		}
+	}
+
+	for _, s := range stats {
+		if s.Reached > 0 {
+			dropOff := float64(s.Replied+s.Stopped) / float64(s.Reached)
+			s.DropOff = &dropOff
+		}
+	}
+
+	return stats, nil
+}
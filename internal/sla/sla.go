@@ -0,0 +1,114 @@
+// Package sla tracks the response times agencies commit to for their
+// clients, such as answering inbound replies within 4 working hours, and
+// flags the replies and escalations that miss them.
+package sla
+
+import (
+	"context"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/database"
+	"salesagency/internal/sendwindow"
+)
+
+const batchSize = 500
+
+// BreachHook runs for each check once it is flagged as breached.
+type BreachHook func(ctx context.Context, check *model.SLACheck)
+
+// WorkingHours returns the SLA's working hours, or nil when the clock always
+// runs.
+func WorkingHours(sla *model.SLA) (*sendwindow.Window, error) {
+	if sla.WorkingHours == nil {
+		return nil, nil
+	}
+	return sendwindow.Parse(*sla.WorkingHours)
+}
+
+// Due returns when something that started at start must be answered under
+// the SLA: after its target has passed in working hours, which are in the
+// time zone of hours, or UTC without one.
+func Due(sla *model.SLA, hours *sendwindow.Window, start time.Time) time.Time {
+	target := time.Duration(sla.TargetMinutes) * time.Minute
+	if hours == nil {
+		return start.Add(target)
+	}
+	return hours.Add(start, target, hours.Location(nil))
+}
+
+// Worker evaluates the enabled SLAs of every agency.
+type Worker struct {
+	db    *database.DB
+	hooks []BreachHook
+	now   func() time.Time
+}
+
+func NewWorker(db *database.DB) *Worker {
+	return &Worker{db: db, now: time.Now}
+}
+
+// OnBreach registers a hook to run for each breach Run flags.
+func (w *Worker) OnBreach(hook BreachHook) {
+	w.hooks = append(w.hooks, hook)
+}
+
+// Run starts checks for the replies and escalations enabled SLAs apply to,
+// records which checks were answered, and flags those answered late or
+// still unanswered past due. It returns how many breaches it flagged.
+func (w *Worker) Run(ctx context.Context) (int, error) {
+	slas, err := w.db.GetSLAs(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, sla := range slas {
+		if !sla.Enabled {
+			continue
+		}
+		if err := w.startChecks(ctx, sla); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := w.db.ResolveSLAChecks(ctx); err != nil {
+		return 0, err
+	}
+
+	breaches, err := w.db.FlagSLABreaches(ctx, w.now())
+	if err != nil {
+		return 0, err
+	}
+	for _, check := range breaches {
+		for _, hook := range w.hooks {
+			hook(ctx, check)
+		}
+	}
+
+	return len(breaches), nil
+}
+
+// startChecks creates the SLA's checks that do not exist yet, due by its
+// working hours.
+func (w *Worker) startChecks(ctx context.Context, sla *model.SLA) error {
+	hours, err := WorkingHours(sla)
+	if err != nil {
+		return err
+	}
+
+	for {
+		checks, err := w.db.GetNewSLAChecks(ctx, sla, batchSize)
+		if err != nil || len(checks) == 0 {
+			return err
+		}
+		for _, check := range checks {
+			check.DueAt = Due(sla, hours, check.StartedAt)
+		}
+		if err := w.db.CreateSLAChecks(ctx, checks); err != nil {
+			return err
+		}
+
+		if len(checks) < batchSize {
+			return nil
+		}
+	}
+}
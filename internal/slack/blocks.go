@@ -0,0 +1,166 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+)
+
+// recentInteractions is how many of a lead's interactions its card shows.
+const recentInteractions = 3
+
+// excerptLength bounds the message text shown for each interaction.
+const excerptLength = 80
+
+// Action IDs of the buttons on lead cards. Each button's value is the lead
+// ID.
+const (
+	actionShow        = "show_lead"
+	actionAssignAgent = "assign_agent"
+	actionSnoozeDay   = "snooze_1d"
+	actionSnoozeWeek  = "snooze_1w"
+)
+
+var snoozes = map[string]time.Duration{
+	actionSnoozeDay:  24 * time.Hour,
+	actionSnoozeWeek: 7 * 24 * time.Hour,
+}
+
+// message is a Slack message in Block Kit. Messages are only shown to the
+// user who ran the command.
+type message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"`
+	Blocks          []block `json:"blocks,omitempty"`
+}
+
+type block struct {
+	Type     string    `json:"type"`
+	Text     *text     `json:"text,omitempty"`
+	Fields   []text    `json:"fields,omitempty"`
+	Elements []element `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// element is a button in an actions block or text in a context block.
+type element struct {
+	Type     string      `json:"type"`
+	Text     interface{} `json:"text"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+}
+
+func ephemeral(s string) *message {
+	return &message{ResponseType: "ephemeral", Text: s}
+}
+
+func markdown(s string) *text {
+	return &text{Type: "mrkdwn", Text: s}
+}
+
+func button(label, actionID, value string) element {
+	return element{Type: "button", Text: text{Type: "plain_text", Text: label}, ActionID: actionID, Value: value}
+}
+
+// leadCard shows the lead with its intent score and latest interactions,
+// and buttons to assign it an AI agent or snooze its follow-up. note, when
+// set, reports the outcome of the last button pressed.
+func leadCard(lead *model.Lead, interactions []*model.Interaction, note string) *message {
+	heading := "*" + escape(lead.Name) + "*"
+	var role []string
+	if lead.Position != nil && *lead.Position != "" {
+		role = append(role, escape(*lead.Position))
+	}
+	if lead.Company != nil && *lead.Company != "" {
+		role = append(role, escape(*lead.Company))
+	}
+	if len(role) > 0 {
+		heading += " · " + strings.Join(role, " at ")
+	}
+	heading += "\n" + escape(lead.Email)
+
+	fields := []text{
+		*markdown(fmt.Sprintf("*Intent score*\n%.2f", lead.IntentScore)),
+		*markdown(fmt.Sprintf("*Status*\n%s", lead.Status)),
+		*markdown("*Last contact*\n" + formatTime(lead.LastContact)),
+		*markdown("*Next follow-up*\n" + formatTime(lead.NextFollowUp)),
+	}
+
+	blocks := []block{
+		{Type: "section", Text: markdown(heading)},
+		{Type: "section", Fields: fields},
+	}
+
+	if len(interactions) > recentInteractions {
+		interactions = interactions[:recentInteractions]
+	}
+	var lines []string
+	for _, interaction := range interactions {
+		line := fmt.Sprintf("%s · %s %s · %s",
+			interaction.Timestamp.UTC().Format("2 Jan 15:04"), interaction.Direction, interaction.Channel, interaction.Status)
+		if interaction.Message != nil && *interaction.Message != "" {
+			line += " — " + escape(excerpt(*interaction.Message))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No interactions yet.")
+	}
+	blocks = append(blocks, block{Type: "context", Elements: []element{{Type: "mrkdwn", Text: strings.Join(lines, "\n")}}})
+
+	blocks = append(blocks, block{Type: "actions", Elements: []element{
+		button("Assign agent", actionAssignAgent, lead.ID),
+		button("Snooze 1 day", actionSnoozeDay, lead.ID),
+		button("Snooze 1 week", actionSnoozeWeek, lead.ID),
+	}})
+	if note != "" {
+		blocks = append(blocks, block{Type: "context", Elements: []element{{Type: "mrkdwn", Text: note}}})
+	}
+
+	return &message{ResponseType: "ephemeral", Text: lead.Name, Blocks: blocks}
+}
+
+// leadList lets the user pick one of several leads matching a search.
+func leadList(query string, leads []*model.Lead) *message {
+	blocks := []block{{Type: "section", Text: markdown(fmt.Sprintf("Leads matching _%s_:", escape(query)))}}
+	for _, lead := range leads {
+		blocks = append(blocks, block{
+			Type: "actions",
+			Elements: []element{
+				button(truncate(lead.Name+" <"+lead.Email+">", 75), actionShow, lead.ID),
+			},
+		})
+	}
+	return &message{ResponseType: "ephemeral", Text: fmt.Sprintf("%d leads match %s", len(leads), query), Blocks: blocks}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "—"
+	}
+	return t.UTC().Format("2 Jan 2006 15:04 UTC")
+}
+
+func excerpt(s string) string {
+	return truncate(strings.Join(strings.Fields(s), " "), excerptLength)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// escape keeps user text from being read as Slack markup.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,73 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLinkCode = errors.New("invalid or expired link code")
+
+// linkCodeTTL is how long a link code can be used for.
+const linkCodeTTL = 15 * time.Minute
+
+// linkPrefix keeps link codes apart from other tokens signed with the same
+// secret.
+const linkPrefix = "slack|"
+
+// Linker creates and verifies the codes users paste into /lead link to
+// connect their Slack account.
+type Linker struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewLinker signs codes with secret.
+func NewLinker(secret string) *Linker {
+	return &Linker{secret: []byte(secret), now: time.Now}
+}
+
+// Code returns a code linking a Slack account to userID, and the time it
+// expires.
+func (l *Linker) Code(userID string) (string, time.Time) {
+	expiresAt := l.now().Add(linkCodeTTL).UTC().Truncate(time.Second)
+	raw := linkPrefix + userID + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(raw))
+	return payload + "." + l.sign(payload), expiresAt
+}
+
+// Verify returns the user a code was issued to.
+func (l *Linker) Verify(code string) (string, error) {
+	payload, signature, ok := strings.Cut(strings.TrimSpace(code), ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(l.sign(payload))) {
+		return "", ErrInvalidLinkCode
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidLinkCode
+	}
+	rest, ok := strings.CutPrefix(string(decoded), linkPrefix)
+	if !ok {
+		return "", ErrInvalidLinkCode
+	}
+	userID, expires, ok := strings.Cut(rest, "|")
+	if !ok || userID == "" {
+		return "", ErrInvalidLinkCode
+	}
+	seconds, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().After(time.Unix(seconds, 0)) {
+		return "", ErrInvalidLinkCode
+	}
+	return userID, nil
+}
+
+func (l *Linker) sign(payload string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,331 @@
+// Package slack serves the Slack app: the /lead slash command, which looks
+// up a lead by email or name, and the buttons on the lead cards it replies
+// with. Every request is checked against the app's signing secret, and runs
+// as the account the Slack user linked with /lead link.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"salesagency/graph/model"
+	"salesagency/internal/assignment"
+	"salesagency/internal/auth"
+	"salesagency/internal/database"
+	"salesagency/internal/events"
+	"salesagency/internal/logging"
+)
+
+// Path is where Handler is mounted.
+const Path = "/integrations/slack"
+
+const (
+	maxBody = 64 << 10
+	// searchLimit is how many leads a search by name lists.
+	searchLimit = 5
+	// responseURLPrefix is where Slack's response URLs point. Updates are
+	// posted nowhere else.
+	responseURLPrefix = "https://hooks.slack.com/"
+	responseTimeout   = 2 * time.Second
+)
+
+const usage = "Use `/lead jane@acme.com` or `/lead Jane Doe` to look up a lead. " +
+	"Link your account first with `/lead link <code>`, using the code from `createSlackLinkCode`; `/lead unlink` removes the link."
+
+// ErrNotConfigured is returned for link codes when there is no Slack app.
+var ErrNotConfigured = errors.New("the Slack app is not configured")
+
+var (
+	errNotLinked = errors.New("your Slack account is not linked")
+	errFailed    = errors.New("something went wrong")
+)
+
+type handler struct {
+	db         *database.DB
+	events     *events.Broker
+	assignment *assignment.Service
+	linker     *Linker
+	secret     string
+	client     *http.Client
+	now        func() time.Time
+}
+
+// Handler answers slash commands and button presses from the Slack app
+// signed with secret.
+func Handler(db *database.DB, broker *events.Broker, assigner *assignment.Service, linker *Linker, secret string) http.Handler {
+	return &handler{
+		db:         db,
+		events:     broker,
+		assignment: assigner,
+		linker:     linker,
+		secret:     secret,
+		client:     &http.Client{Timeout: responseTimeout},
+		now:        time.Now,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBody))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := verify(h.secret, r.Header, body, h.now()); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Button presses carry a JSON payload; slash commands are plain form
+	// fields.
+	if payload := form.Get("payload"); payload != "" {
+		h.interaction(w, r, payload)
+		return
+	}
+	writeJSON(w, h.command(r.Context(), form.Get("team_id"), form.Get("user_id"), strings.TrimSpace(form.Get("text"))))
+}
+
+func (h *handler) command(ctx context.Context, teamID, slackUserID, text string) *message {
+	log := logging.FromContext(ctx).With("slack_team_id", teamID, "slack_user_id", slackUserID)
+
+	verb, rest, _ := strings.Cut(text, " ")
+	switch strings.ToLower(verb) {
+	case "", "help":
+		return ephemeral(usage)
+	case "link":
+		userID, err := h.linker.Verify(rest)
+		if err != nil {
+			return ephemeral("That link code is invalid or has expired. Get a new one with `createSlackLinkCode`.")
+		}
+		if err := h.db.LinkSlackUser(ctx, teamID, slackUserID, userID); err != nil {
+			log.Error("Failed to link Slack user", "error", err)
+			return ephemeral("Something went wrong. Please try again.")
+		}
+		return ephemeral("Your Slack account is linked. " + usage)
+	case "unlink":
+		if _, err := h.db.UnlinkSlackUser(ctx, teamID, slackUserID); err != nil {
+			log.Error("Failed to unlink Slack user", "error", err)
+			return ephemeral("Something went wrong. Please try again.")
+		}
+		return ephemeral("Your Slack account is no longer linked.")
+	}
+
+	ctx, err := h.authenticate(ctx, teamID, slackUserID)
+	if err != nil {
+		return ephemeral(err.Error() + ". " + usage)
+	}
+
+	msg, err := h.lookup(ctx, text)
+	if err != nil {
+		log.Error("Failed to look up lead", "error", err)
+		return ephemeral("Something went wrong. Please try again.")
+	}
+	return msg
+}
+
+// lookup finds the lead with the address given, or leads matching the text.
+func (h *handler) lookup(ctx context.Context, query string) (*message, error) {
+	if addr, err := mail.ParseAddress(query); err == nil {
+		id, err := h.db.GetLeadIDByEmail(ctx, addr.Address)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return ephemeral(fmt.Sprintf("No lead has the address %s.", escape(addr.Address))), nil
+		}
+		return h.card(ctx, id, "")
+	}
+
+	limit := searchLimit
+	leads, err := h.db.GetLeadsByFilter(ctx, &model.LeadFilterInput{Text: &query}, &limit, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch len(leads) {
+	case 0:
+		return ephemeral(fmt.Sprintf("No leads match _%s_.", escape(query))), nil
+	case 1:
+		return h.card(ctx, leads[0].ID, "")
+	default:
+		return leadList(query, leads), nil
+	}
+}
+
+func (h *handler) card(ctx context.Context, leadID, note string) (*message, error) {
+	lead, err := h.db.GetLeadByID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return ephemeral("That lead no longer exists."), nil
+	}
+	interactions, err := h.db.GetInteractionsByLeadID(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	return leadCard(lead, interactions, note), nil
+}
+
+type interactionPayload struct {
+	Type        string `json:"type"`
+	ResponseURL string `json:"response_url"`
+	Team        struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// interaction handles a button press. Slack only takes the updated card at
+// the press's response URL, so the request itself is answered empty.
+func (h *handler) interaction(w http.ResponseWriter, r *http.Request, raw string) {
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return
+	}
+
+	ctx := r.Context()
+	log := logging.FromContext(ctx).With("slack_team_id", payload.Team.ID, "slack_user_id", payload.User.ID)
+	action := payload.Actions[0]
+
+	ctx, err := h.authenticate(ctx, payload.Team.ID, payload.User.ID)
+	if err != nil {
+		h.respond(ctx, payload.ResponseURL, ephemeral(err.Error()+". "+usage))
+		return
+	}
+	msg, err := h.act(ctx, action.ActionID, action.Value)
+	if err != nil {
+		log.Error("Failed to handle Slack action", "action", action.ActionID, "error", err)
+		msg = ephemeral("Something went wrong. Please try again.")
+	}
+
+	msg.ReplaceOriginal = true
+	h.respond(ctx, payload.ResponseURL, msg)
+}
+
+func (h *handler) act(ctx context.Context, actionID, leadID string) (*message, error) {
+	note := ""
+	switch actionID {
+	case actionShow:
+	case actionAssignAgent:
+		lead, err := h.assignment.AutoAssign(ctx, leadID, nil, model.LoadBalancingLeastLoaded)
+		if errors.Is(err, assignment.ErrNoCapacity) {
+			note = "No active AI agent has room for this lead."
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if lead != nil {
+			h.events.Publish(events.TopicLeadUpdated, lead)
+		}
+		note = "Assigned to an AI agent."
+	default:
+		snooze, ok := snoozes[actionID]
+		if !ok {
+			return nil, fmt.Errorf("unknown action %q", actionID)
+		}
+		at := h.now().Add(snooze)
+		if err := h.db.SetLeadNextFollowUp(ctx, leadID, at); err != nil {
+			return nil, err
+		}
+		if lead, err := h.db.GetLeadByID(ctx, leadID); err == nil && lead != nil {
+			h.events.Publish(events.TopicLeadUpdated, lead)
+		}
+		note = "Follow-up snoozed until " + formatTime(&at) + "."
+	}
+	return h.card(ctx, leadID, note)
+}
+
+// authenticate returns ctx carrying the account the Slack user linked. Only
+// agency staff may use the app: client users would see leads unmasked.
+func (h *handler) authenticate(ctx context.Context, teamID, slackUserID string) (context.Context, error) {
+	linked, err := h.db.GetSlackUser(ctx, teamID, slackUserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to load Slack user", "error", err)
+		return ctx, errFailed
+	}
+	if linked == nil {
+		return ctx, errNotLinked
+	}
+	if linked.Status != model.UserStatusActive {
+		return ctx, auth.ErrInactiveAccount
+	}
+
+	user := &auth.User{
+		ID:       linked.UserID,
+		Email:    linked.Email,
+		Role:     auth.Role(linked.Role),
+		AgencyID: linked.AgencyID,
+	}
+	if !user.HasRole(auth.RoleSalesRep) {
+		return ctx, auth.ErrForbidden
+	}
+	return auth.WithUser(ctx, user), nil
+}
+
+func (h *handler) respond(ctx context.Context, responseURL string, msg *message) {
+	log := logging.FromContext(ctx)
+	if !strings.HasPrefix(responseURL, responseURLPrefix) {
+		log.Warn("Ignored Slack response URL", "url", responseURL)
+		return
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		log.Error("Failed to encode Slack response", "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		log.Error("Failed to build Slack response", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		log.Error("Failed to post Slack response", "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Error("Slack rejected response", "status", resp.StatusCode)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, msg *message) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		slog.Error("error encoding Slack response", "error", err)
+	}
+}
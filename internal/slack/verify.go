@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxClockSkew is how old a request may be, so that a captured request
+// cannot be replayed later.
+const maxClockSkew = 5 * time.Minute
+
+var errInvalidSignature = errors.New("invalid slack signature")
+
+// verify checks the signature Slack computes over each request's timestamp
+// and body with the app's signing secret.
+func verify(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return errInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxClockSkew || age < -maxClockSkew {
+		return errInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+const DefaultMaxBytes = 25 << 20
+
+// DefaultContentTypes are documents, images and call recordings.
+var DefaultContentTypes = []string{
+	"application/pdf",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"text/plain",
+	"text/csv",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"audio/*",
+}
+
+// Limits restrict what may be uploaded.
+type Limits struct {
+	MaxBytes int64
+	// ContentTypes are the allowed media types. "audio/*" allows every
+	// audio type.
+	ContentTypes []string
+}
+
+// Check validates a file's size and returns its media type: the declared
+// one, or the one its extension implies when none was declared.
+func (l Limits) Check(filename, declared string, size int64) (string, error) {
+	if size > l.MaxBytes {
+		return "", fmt.Errorf("%w: %d bytes, the limit is %d", ErrTooLarge, size, l.MaxBytes)
+	}
+
+	contentType := mediaType(declared)
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = mediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))))
+	}
+	if contentType == "" {
+		return "", fmt.Errorf("%w: unknown type", ErrContentType)
+	}
+
+	for _, allowed := range l.ContentTypes {
+		if allowed == contentType || strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*")) {
+			return contentType, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrContentType, contentType)
+}
+
+func mediaType(value string) string {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
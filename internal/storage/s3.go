@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Timeout       = 5 * time.Minute
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// S3Config locates a bucket on AWS S3 or an S3-compatible server such as
+// MinIO.
+type S3Config struct {
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Endpoint is the server's base URL, e.g. http://minio:9000. It
+	// defaults to the AWS endpoint of Region.
+	Endpoint string
+	// PathStyle addresses the bucket in the path rather than the host name,
+	// as MinIO requires.
+	PathStyle bool
+}
+
+// S3 stores objects in one bucket, signing requests with AWS Signature
+// Version 4.
+type S3 struct {
+	cfg    S3Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// Open returns the S3 store for cfg, or nil when cfg is nil and file storage
+// is not configured.
+func Open(cfg *S3Config) (Store, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	return NewS3(*cfg, &http.Client{Timeout: s3Timeout})
+}
+
+func NewS3(cfg S3Config, client *http.Client) (*S3, error) {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 access key ID and secret access key are required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+
+	base, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+
+	return &S3{cfg: cfg, base: base, client: client, now: time.Now}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return fmt.Errorf("error building S3 request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	return s.do(req)
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building S3 request: %w", err)
+	}
+
+	resp, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("error building S3 request: %w", err)
+	}
+	return s.do(req)
+}
+
+// DownloadURL presigns a GET that serves the object as an attachment named
+// filename.
+func (s *S3) DownloadURL(key, filename string, ttl time.Duration) (string, error) {
+	u := s.objectURL(key)
+	now := s.now().UTC()
+
+	query := map[string]string{
+		"X-Amz-Algorithm":              "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":             s.cfg.AccessKeyID + "/" + s.scope(now),
+		"X-Amz-Date":                   now.Format(amzDateFormat),
+		"X-Amz-Expires":                strconv.Itoa(int(ttl.Seconds())),
+		"X-Amz-SignedHeaders":          "host",
+		"response-content-disposition": mime.FormatMediaType("attachment", map[string]string{"filename": filename}),
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	signature := s.signature(now, http.MethodGet, u.EscapedPath(), canonicalQuery, "host:"+u.Host+"\n", "host", unsignedPayload)
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+
+	return u.String(), nil
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.base
+	path, rawPath := "/"+key, "/"+uriEncode(key, false)
+	if s.cfg.PathStyle {
+		path, rawPath = "/"+s.cfg.Bucket+path, "/"+uriEncode(s.cfg.Bucket, true)+rawPath
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimRight(s.base.Path, "/") + path
+	u.RawPath = strings.TrimRight(s.base.EscapedPath(), "/") + rawPath
+	return &u
+}
+
+// do signs and sends req and checks the response status.
+func (s *S3) do(req *http.Request) error {
+	resp, err := s.send(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// send signs and sends req and checks the response status. The caller closes
+// the body of a successful response.
+func (s *S3) send(req *http.Request) (*http.Response, error) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": unsignedPayload,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	canonicalHeaders, signedHeaders := canonicalHeaderList(headers)
+
+	signature := s.signature(now, req.Method, req.URL.EscapedPath(), "", canonicalHeaders, signedHeaders, unsignedPayload)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, s.scope(now), signedHeaders, signature,
+	))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling S3: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("S3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3) signature(t time.Time, method, path, query, canonicalHeaders, signedHeaders, payloadHash string) string {
+	canonicalRequest := strings.Join([]string{method, path, query, canonicalHeaders, signedHeaders, payloadHash}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", t.Format(amzDateFormat), s.scope(t), hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalHeaderList(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+func canonicalQueryString(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = uriEncode(name, true) + "=" + uriEncode(params[name], true)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, as
+// Signature Version 4 requires, keeping slashes unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+// Package storage keeps uploaded files, such as lead and interaction
+// attachments, in an object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotConfigured = errors.New("file storage is not configured")
+	ErrTooLarge      = errors.New("file is too large")
+	ErrContentType   = errors.New("file type is not allowed")
+)
+
+// Store saves and serves objects by key.
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns the object's content, which the caller must close.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// DownloadURL returns a link, valid for ttl, that downloads the object
+	// as filename.
+	DownloadURL(key, filename string, ttl time.Duration) (string, error)
+}
@@ -0,0 +1,35 @@
+package templates
+
+import (
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+// LeadData exposes a lead's fields as lead.* variables. The most common ones
+// are also available without the prefix, e.g. {{company}} or {{firstName}}.
+func LeadData(lead *model.Lead) Data {
+	optional := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+
+	firstName, lastName, _ := strings.Cut(strings.TrimSpace(lead.Name), " ")
+
+	data := Data{
+		"lead.name":      lead.Name,
+		"lead.firstName": firstName,
+		"lead.lastName":  strings.TrimSpace(lastName),
+		"lead.email":     lead.Email,
+		"lead.phone":     optional(lead.Phone),
+		"lead.company":   optional(lead.Company),
+		"lead.position":  optional(lead.Position),
+		"lead.source":    optional(lead.Source),
+	}
+	for _, field := range []string{"name", "firstName", "lastName", "email", "company", "position"} {
+		data[field] = data["lead."+field]
+	}
+	return data
+}
@@ -0,0 +1,155 @@
+package templates
+
+import (
+	"strings"
+
+	"salesagency/graph/model"
+)
+
+// Locales lists the locales to look for translations in, in order: each of
+// the given languages followed by its base language, e.g. "pt-BR" then "pt".
+// Nil and empty languages are skipped.
+func Locales(languages ...*string) []string {
+	var locales []string
+	seen := map[string]bool{}
+	add := func(locale string) {
+		if !seen[locale] {
+			seen[locale] = true
+			locales = append(locales, locale)
+		}
+	}
+
+	for _, language := range languages {
+		if language == nil || *language == "" {
+			continue
+		}
+		add(*language)
+		if base, _, ok := strings.Cut(*language, "-"); ok {
+			add(base)
+		}
+	}
+	return locales
+}
+
+// languagesByCallingCode maps international calling codes to the language
+// most of the country speaks. Countries without one, such as +1, +32, +41
+// and +91, are left out.
+var languagesByCallingCode = map[string]string{
+	"7":   "ru",
+	"20":  "ar",
+	"27":  "en",
+	"30":  "el",
+	"31":  "nl",
+	"33":  "fr",
+	"34":  "es",
+	"36":  "hu",
+	"39":  "it",
+	"40":  "ro",
+	"43":  "de",
+	"44":  "en",
+	"45":  "da",
+	"46":  "sv",
+	"47":  "nb",
+	"48":  "pl",
+	"49":  "de",
+	"51":  "es",
+	"52":  "es",
+	"54":  "es",
+	"55":  "pt-BR",
+	"56":  "es",
+	"57":  "es",
+	"61":  "en",
+	"62":  "id",
+	"64":  "en",
+	"66":  "th",
+	"81":  "ja",
+	"82":  "ko",
+	"84":  "vi",
+	"86":  "zh",
+	"90":  "tr",
+	"351": "pt",
+	"353": "en",
+	"358": "fi",
+	"420": "cs",
+	"966": "ar",
+	"971": "ar",
+	"972": "he",
+}
+
+// languagesByDomain maps country code top-level domains to their language.
+// Domains widely used outside their country, such as .co, .io and .me, are
+// left out.
+var languagesByDomain = map[string]string{
+	"at": "de",
+	"br": "pt-BR",
+	"cl": "es",
+	"cn": "zh",
+	"cz": "cs",
+	"de": "de",
+	"dk": "da",
+	"es": "es",
+	"fi": "fi",
+	"fr": "fr",
+	"gr": "el",
+	"hu": "hu",
+	"it": "it",
+	"jp": "ja",
+	"kr": "ko",
+	"mx": "es",
+	"nl": "nl",
+	"no": "nb",
+	"pl": "pl",
+	"pt": "pt",
+	"ro": "ro",
+	"ru": "ru",
+	"se": "sv",
+	"tr": "tr",
+}
+
+// LanguageForLead guesses the lead's language from the calling code of its
+// phone number, written with a leading "+" or "00", and otherwise from the
+// country domain of its email address. It returns "" when neither tells.
+func LanguageForLead(lead *model.Lead) string {
+	if lead.Phone != nil {
+		digits := strings.Map(func(r rune) rune {
+			switch r {
+			case ' ', '-', '.', '(', ')':
+				return -1
+			}
+			return r
+		}, *lead.Phone)
+
+		switch {
+		case strings.HasPrefix(digits, "+"):
+			digits = digits[1:]
+		case strings.HasPrefix(digits, "00"):
+			digits = digits[2:]
+		default:
+			digits = ""
+		}
+		// Calling codes are prefix-free, so at most one of these matches.
+		for n := 1; n <= 3 && n <= len(digits); n++ {
+			if language, ok := languagesByCallingCode[digits[:n]]; ok {
+				return language
+			}
+		}
+	}
+
+	if at := strings.LastIndex(lead.Email, "@"); at >= 0 {
+		domain := strings.ToLower(lead.Email[at+1:])
+		if dot := strings.LastIndex(domain, "."); dot >= 0 {
+			return languagesByDomain[domain[dot+1:]]
+		}
+	}
+	return ""
+}
+
+// DetectLanguage fills in the lead's language when none is set.
+func DetectLanguage(lead *model.Lead) {
+	if lead.Language != nil && *lead.Language != "" {
+		return
+	}
+	if language := LanguageForLead(lead); language != "" {
+		lead.Language = &language
+	}
+}
@@ -0,0 +1,172 @@
+// Package templates renders message templates. Templates contain
+// {{variable}} placeholders and conditional blocks:
+//
+//	Hi {{lead.firstName}},
+//	{{#if company}}I noticed {{company}} is growing.{{else}}Hope all is well.{{/if}}
+//
+// Conditions are true when the variable has a non-empty value. Unknown
+// variables render as empty strings.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	openDelim  = "{{"
+	closeDelim = "}}"
+)
+
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// Data maps variable names such as "lead.firstName" to their values.
+type Data map[string]string
+
+type node interface {
+	render(b *strings.Builder, data Data)
+}
+
+type textNode string
+
+func (n textNode) render(b *strings.Builder, data Data) {
+	b.WriteString(string(n))
+}
+
+type variableNode string
+
+func (n variableNode) render(b *strings.Builder, data Data) {
+	b.WriteString(data[string(n)])
+}
+
+type ifNode struct {
+	variable  string
+	then      []node
+	otherwise []node
+}
+
+func (n *ifNode) render(b *strings.Builder, data Data) {
+	branch := n.otherwise
+	if data[n.variable] != "" {
+		branch = n.then
+	}
+	for _, child := range branch {
+		child.render(b, data)
+	}
+}
+
+type Template struct {
+	nodes     []node
+	variables []string
+}
+
+// Parse compiles content, reporting unbalanced tags and malformed variable
+// names.
+func Parse(content string) (*Template, error) {
+	t := &Template{}
+	seen := map[string]bool{}
+	addVariable := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			t.variables = append(t.variables, name)
+		}
+	}
+
+	// stack holds the open conditional blocks; target is where parsed nodes
+	// are currently appended.
+	var stack []*ifNode
+	inElse := map[*ifNode]bool{}
+	target := &t.nodes
+
+	rest := content
+	for rest != "" {
+		start := strings.Index(rest, openDelim)
+		if start < 0 {
+			*target = append(*target, textNode(rest))
+			break
+		}
+		if start > 0 {
+			*target = append(*target, textNode(rest[:start]))
+		}
+
+		end := strings.Index(rest[start:], closeDelim)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed tag at offset %d", len(content)-len(rest)+start)
+		}
+		tag := strings.TrimSpace(rest[start+len(openDelim) : start+end])
+		rest = rest[start+end+len(closeDelim):]
+
+		switch {
+		case strings.HasPrefix(tag, "#if "):
+			name := strings.TrimSpace(strings.TrimPrefix(tag, "#if "))
+			if !variablePattern.MatchString(name) {
+				return nil, fmt.Errorf("invalid condition %q", name)
+			}
+			addVariable(name)
+			block := &ifNode{variable: name}
+			*target = append(*target, block)
+			stack = append(stack, block)
+			target = &block.then
+		case tag == "else":
+			if len(stack) == 0 {
+				return nil, errors.New("{{else}} outside of {{#if}}")
+			}
+			block := stack[len(stack)-1]
+			if inElse[block] {
+				return nil, fmt.Errorf("duplicate {{else}} in {{#if %s}}", block.variable)
+			}
+			inElse[block] = true
+			target = &block.otherwise
+		case tag == "/if":
+			if len(stack) == 0 {
+				return nil, errors.New("{{/if}} without matching {{#if}}")
+			}
+			stack = stack[:len(stack)-1]
+			target = &t.nodes
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				target = &parent.then
+				if inElse[parent] {
+					target = &parent.otherwise
+				}
+			}
+		default:
+			if !variablePattern.MatchString(tag) {
+				return nil, fmt.Errorf("invalid variable %q", tag)
+			}
+			addVariable(tag)
+			*target = append(*target, variableNode(tag))
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed {{#if %s}}", stack[len(stack)-1].variable)
+	}
+
+	return t, nil
+}
+
+// Variables returns the variable names referenced by the template in order
+// of first use.
+func (t *Template) Variables() []string {
+	return t.variables
+}
+
+func (t *Template) Render(data Data) string {
+	var b strings.Builder
+	for _, n := range t.nodes {
+		n.render(&b, data)
+	}
+	return b.String()
+}
+
+// Render parses and renders content in one step.
+func Render(content string, data Data) (string, error) {
+	t, err := Parse(content)
+	if err != nil {
+		return "", err
+	}
+	return t.Render(data), nil
+}
@@ -0,0 +1,59 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrMissingTenant = errors.New("no agency in request context")
+
+type contextKey struct{}
+
+type scope struct {
+	agencyID string
+	system   bool
+	// clientIDs is set for client portal users, who only see their clients'
+	// data.
+	clientIDs    []string
+	clientScoped bool
+}
+
+// WithAgency scopes all database access made with ctx to a single agency.
+func WithAgency(ctx context.Context, agencyID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{agencyID: agencyID})
+}
+
+// WithSystem marks ctx as belonging to a trusted background process that
+// operates across all agencies.
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{system: true})
+}
+
+// WithClients further limits ctx to the data of the given clients within its
+// agency. An empty list leaves nothing visible.
+func WithClients(ctx context.Context, clientIDs []string) context.Context {
+	s, _ := ctx.Value(contextKey{}).(scope)
+	s.clientIDs = clientIDs
+	s.clientScoped = true
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// AgencyID returns the agency ctx is scoped to. System contexts return an
+// empty ID; contexts with no scope at all return ErrMissingTenant.
+func AgencyID(ctx context.Context) (string, error) {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	if s.system {
+		return "", nil
+	}
+	return s.agencyID, nil
+}
+
+// ClientIDs returns the clients ctx is limited to by WithClients. ok is false
+// when ctx is not limited to any clients.
+func ClientIDs(ctx context.Context) (clientIDs []string, ok bool) {
+	s, _ := ctx.Value(contextKey{}).(scope)
+	return s.clientIDs, s.clientScoped
+}
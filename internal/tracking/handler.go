@@ -0,0 +1,66 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+
+	"salesagency/internal/logging"
+	"salesagency/internal/tenant"
+)
+
+// OpenPath and ClickPath are where OpenHandler and ClickHandler are mounted.
+const (
+	OpenPath  = "/t/open"
+	ClickPath = "/t/click"
+)
+
+// Recorder stores opens and clicks against the email sent with messageID.
+// Emails it does not know should be ignored without an error.
+type Recorder interface {
+	RecordOpen(ctx context.Context, messageID string) error
+	RecordClick(ctx context.Context, messageID, target string) error
+}
+
+// pixel is a transparent 1x1 GIF.
+var pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// OpenHandler serves the open pixel. It always returns the image, so a
+// failure to record the open never shows as a broken image.
+func OpenHandler(tracker *Tracker, recorder Recorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if messageID, _, err := tracker.verify(r.URL.Query().Get("token")); err == nil {
+			// Links carry no user, and Message-IDs are globally unique.
+			ctx := tenant.WithSystem(r.Context())
+			if err := recorder.RecordOpen(ctx, messageID); err != nil {
+				logging.FromContext(ctx).Error("error recording email open", "message_id", messageID, "error", err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "image/gif")
+		w.Header().Set("Cache-Control", "no-store, max-age=0")
+		w.Write(pixel)
+	})
+}
+
+// ClickHandler records a click and redirects to the link's target. The
+// redirect happens even when recording fails.
+func ClickHandler(tracker *Tracker, recorder Recorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messageID, target, err := tracker.verify(r.URL.Query().Get("token"))
+		if err != nil || target == "" {
+			http.Error(w, "invalid link", http.StatusBadRequest)
+			return
+		}
+
+		ctx := tenant.WithSystem(r.Context())
+		if err := recorder.RecordClick(ctx, messageID, target); err != nil {
+			logging.FromContext(ctx).Error("error recording email click", "message_id", messageID, "error", err)
+		}
+
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+}
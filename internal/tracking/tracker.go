@@ -0,0 +1,118 @@
+// Package tracking adds open and click tracking to outbound emails and serves
+// the public endpoints that record them. Both are keyed by the email's
+// Message-ID, which is stored as its interaction's external ID.
+package tracking
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"salesagency/internal/messaging/email"
+)
+
+var ErrInvalidToken = errors.New("invalid tracking token")
+
+// link matches URLs in plain-text bodies, leaving out trailing punctuation
+// that usually ends the sentence rather than the URL.
+var link = regexp.MustCompile(`https?://[^\s<>"]*[^\s<>".,;:!?)'\]]`)
+
+// Tracker rewrites emails to report opens and clicks. Tokens never expire,
+// since recipients may open old emails.
+type Tracker struct {
+	secret  []byte
+	baseURL string
+	host    string
+}
+
+// NewTracker signs tokens with secret. baseURL is the server's public
+// address; emails are not tracked when it is empty.
+func NewTracker(secret, baseURL string) *Tracker {
+	t := &Tracker{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/")}
+	if parsed, err := url.Parse(t.baseURL); err == nil {
+		t.host = parsed.Hostname()
+	}
+	return t
+}
+
+// Track gives msg a Message-ID, unless it has one, rewrites the links in its
+// body to go through the click endpoint, and adds an HTML version carrying
+// the open pixel. Links added to the body afterwards, such as an unsubscribe
+// footer, are not tracked.
+func (t *Tracker) Track(msg *email.Message) error {
+	if t.baseURL == "" {
+		return nil
+	}
+
+	if msg.MessageID == "" {
+		id, err := email.NewMessageID(t.host)
+		if err != nil {
+			return err
+		}
+		msg.MessageID = id
+	}
+
+	var text, body strings.Builder
+	last := 0
+	for _, loc := range link.FindAllStringIndex(msg.Body, -1) {
+		target := msg.Body[loc[0]:loc[1]]
+		tracked := t.clickURL(msg.MessageID, target)
+
+		text.WriteString(msg.Body[last:loc[0]])
+		text.WriteString(tracked)
+		body.WriteString(html.EscapeString(msg.Body[last:loc[0]]))
+		body.WriteString(`<a href="` + html.EscapeString(tracked) + `">` + html.EscapeString(target) + `</a>`)
+		last = loc[1]
+	}
+	text.WriteString(msg.Body[last:])
+	body.WriteString(html.EscapeString(msg.Body[last:]))
+
+	msg.Body = text.String()
+	msg.HTML = `<div style="white-space: pre-wrap;">` + body.String() + `</div>` +
+		`<img src="` + html.EscapeString(t.openURL(msg.MessageID)) + `" width="1" height="1" alt="" style="display: block;">`
+	return nil
+}
+
+func (t *Tracker) openURL(messageID string) string {
+	return t.baseURL + OpenPath + "?token=" + url.QueryEscape(t.token(messageID, ""))
+}
+
+func (t *Tracker) clickURL(messageID, target string) string {
+	return t.baseURL + ClickPath + "?token=" + url.QueryEscape(t.token(messageID, target))
+}
+
+// token signs the Message-ID and, for clicks, the link's target, so the
+// click endpoint only redirects to links that were in an email.
+func (t *Tracker) token(messageID, target string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(messageID + "\n" + target))
+	return payload + "." + t.sign(payload)
+}
+
+// verify returns the Message-ID and link target a token was issued for.
+func (t *Tracker) verify(token string) (string, string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(t.sign(payload))) {
+		return "", "", ErrInvalidToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+	messageID, target, ok := strings.Cut(string(decoded), "\n")
+	if !ok || messageID == "" {
+		return "", "", ErrInvalidToken
+	}
+	return messageID, target, nil
+}
+
+func (t *Tracker) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,90 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "whisper-1"
+)
+
+type OpenAIConfig struct {
+	APIKey string
+	// BaseURL may point at any OpenAI-compatible endpoint.
+	BaseURL string
+	Model   string
+}
+
+// OpenAI transcribes with OpenAI's audio transcription API.
+type OpenAI struct {
+	cfg    OpenAIConfig
+	client *http.Client
+}
+
+func NewOpenAI(cfg OpenAIConfig, client *http.Client) (*OpenAI, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("OPENAI_API_KEY is required for the openai transcription provider")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOpenAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOpenAIModel
+	}
+	return &OpenAI{cfg: cfg, client: client}, nil
+}
+
+func (p *OpenAI) Transcribe(ctx context.Context, audio io.Reader, filename string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("model", p.cfg.Model); err != nil {
+		return "", err
+	}
+	file, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, audio); err != nil {
+		return "", fmt.Errorf("error reading recording: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(p.cfg.BaseURL, "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return "", fmt.Errorf("error building request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("openai: %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("openai: error decoding response: %w", err)
+	}
+
+	return result.Text, nil
+}
@@ -0,0 +1,57 @@
+// Package transcription turns call recordings into text with a
+// speech-to-text provider, in the background, and summarizes each call's key
+// points into the lead's notes.
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Provider transcribes audio. filename's extension tells the provider the
+// audio format.
+type Provider interface {
+	Transcribe(ctx context.Context, audio io.Reader, filename string) (string, error)
+}
+
+var ErrNotConfigured = errors.New("transcription provider is not configured")
+
+const defaultTimeout = 5 * time.Minute
+
+// Config selects and configures the speech-to-text provider.
+type Config struct {
+	// Provider is "openai" or empty, which turns transcription off.
+	Provider string
+	// Model overrides the provider's default model.
+	Model         string
+	Timeout       time.Duration
+	OpenAIAPIKey  string
+	OpenAIBaseURL string
+}
+
+// New builds the provider selected by cfg.Provider, or returns nil when
+// transcription is off.
+func New(cfg Config) (Provider, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "openai":
+		return NewOpenAI(OpenAIConfig{
+			APIKey:  cfg.OpenAIAPIKey,
+			BaseURL: cfg.OpenAIBaseURL,
+			Model:   cfg.Model,
+		}, client)
+	default:
+		return nil, fmt.Errorf("unknown transcription provider %q", cfg.Provider)
+	}
+}
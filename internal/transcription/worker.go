@@ -0,0 +1,271 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"salesagency/internal/database"
+	"salesagency/internal/llm"
+	"salesagency/internal/logging"
+	"salesagency/internal/storage"
+)
+
+const (
+	// MaxAttempts is how many times a recording is tried before its
+	// transcript is marked FAILED.
+	MaxAttempts = 5
+
+	// maxRecordingSize matches the largest upload speech-to-text APIs accept.
+	maxRecordingSize = 25 << 20
+	maxKeyPoints     = 5
+
+	batchSize   = 10
+	lease       = 10 * time.Minute
+	baseBackoff = time.Minute
+	maxBackoff  = time.Hour
+)
+
+const keyPointsPrompt = `You summarize sales call transcripts for the rep who owns the lead.
+Reply with a JSON object {"keyPoints": [...]} holding at most 5 short key points: the lead's needs, objections, commitments and agreed next steps.
+Write each point as one plain sentence. Reply with {"keyPoints": []} when the call has nothing worth noting.`
+
+// extensions names recordings whose filename does not tell the provider the
+// audio format.
+var extensions = map[string]string{
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/mp4":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"audio/wav":   ".wav",
+	"audio/x-wav": ".wav",
+	"audio/webm":  ".webm",
+	"audio/ogg":   ".ogg",
+	"audio/flac":  ".flac",
+}
+
+// Worker transcribes the queued call recordings.
+type Worker struct {
+	db       *database.DB
+	provider Provider
+	llm      llm.Provider
+	store    storage.Store
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewWorker(db *database.DB, provider Provider, llmProvider llm.Provider, store storage.Store) *Worker {
+	return &Worker{
+		db:       db,
+		provider: provider,
+		llm:      llmProvider,
+		store:    store,
+		client:   &http.Client{Timeout: defaultTimeout},
+		now:      time.Now,
+	}
+}
+
+// Run transcribes the recordings that are due and returns how many were
+// transcribed. Failed recordings are retried with exponential backoff until
+// MaxAttempts.
+func (w *Worker) Run(ctx context.Context) (int, error) {
+	jobs, err := w.db.ClaimTranscripts(ctx, w.now(), lease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	transcribed := 0
+	for _, job := range jobs {
+		log := logging.FromContext(ctx).With("interaction_id", job.InteractionID, "attempt", job.Attempts)
+
+		err := w.transcribe(ctx, job)
+		switch {
+		case err == nil:
+			transcribed++
+		case job.Attempts >= MaxAttempts:
+			log.Error("Failed to transcribe call", "error", err)
+			err = w.db.FailTranscript(ctx, job.InteractionID, err.Error(), w.now())
+		default:
+			log.Warn("Failed to transcribe call, will retry", "error", err)
+			err = w.db.RetryTranscript(ctx, job.InteractionID, err.Error(), w.now().Add(backoff(job.Attempts)))
+		}
+		if err != nil {
+			return transcribed, err
+		}
+	}
+
+	return transcribed, nil
+}
+
+func (w *Worker) transcribe(ctx context.Context, job *database.TranscriptJob) error {
+	audio, name, err := w.open(ctx, job)
+	if err != nil {
+		return err
+	}
+	defer audio.Close()
+
+	text, err := w.provider.Transcribe(ctx, audio, name)
+	if err != nil {
+		return fmt.Errorf("error transcribing recording: %w", err)
+	}
+	text = strings.TrimSpace(text)
+
+	// The transcript is worth keeping even when it cannot be summarized.
+	keyPoints, err := w.keyPoints(ctx, text)
+	if err != nil && !errors.Is(err, llm.ErrNotConfigured) {
+		logging.FromContext(ctx).Warn("Failed to summarize call transcript", "interaction_id", job.InteractionID, "error", err)
+	}
+
+	now := w.now()
+	return w.db.CompleteTranscript(ctx, job, text, keyPoints, notes(keyPoints, now), now)
+}
+
+// open returns the job's recording and a filename whose extension names its
+// format.
+func (w *Worker) open(ctx context.Context, job *database.TranscriptJob) (io.ReadCloser, string, error) {
+	if job.StorageKey != nil {
+		if w.store == nil {
+			return nil, "", storage.ErrNotConfigured
+		}
+		body, err := w.store.Get(ctx, *job.StorageKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("error fetching recording: %w", err)
+		}
+		return limit(body), filename(job.Filename, job.ContentType), nil
+	}
+
+	if job.RecordingURL == nil {
+		return nil, "", errors.New("call has no recording")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *job.RecordingURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("error building recording request: %w", err)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error downloading recording: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("error downloading recording: %s", resp.Status)
+	}
+
+	name := path.Base(req.URL.Path)
+	return limit(resp.Body), filename(name, resp.Header.Get("Content-Type")), nil
+}
+
+// keyPoints asks the LLM for the call's key points. It returns none for an
+// empty transcript.
+func (w *Worker) keyPoints(ctx context.Context, text string) ([]string, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	reply, err := w.llm.Complete(ctx, &llm.Request{
+		System:   keyPointsPrompt,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: text}},
+		JSON:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Some models wrap the object in prose or a code fence.
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("key points reply is not a JSON object")
+	}
+
+	var summary struct {
+		KeyPoints []string `json:"keyPoints"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &summary); err != nil {
+		return nil, fmt.Errorf("error decoding key points: %w", err)
+	}
+
+	var points []string
+	for _, point := range summary.KeyPoints {
+		if point = strings.TrimSpace(point); point != "" {
+			points = append(points, point)
+		}
+		if len(points) == maxKeyPoints {
+			break
+		}
+	}
+	return points, nil
+}
+
+// notes formats key points for the lead's notes.
+func notes(keyPoints []string, at time.Time) string {
+	if len(keyPoints) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Call on %s key points:", at.UTC().Format("2006-01-02"))
+	for _, point := range keyPoints {
+		b.WriteString("\n- ")
+		b.WriteString(point)
+	}
+	return b.String()
+}
+
+func filename(name, contentType string) string {
+	if name == "" || name == "/" || name == "." {
+		name = "recording"
+	}
+	if path.Ext(name) == "" {
+		mediaType, _, _ := strings.Cut(contentType, ";")
+		name += extensions[strings.TrimSpace(mediaType)]
+	}
+	return name
+}
+
+// limit fails reads past maxRecordingSize rather than sending a truncated
+// recording.
+func limit(body io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{&limitedReader{r: body, n: maxRecordingSize}, body}
+}
+
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, storage.ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, storage.ErrTooLarge
+	}
+	return n, err
+}
+
+// backoff doubles the delay after each attempt, up to maxBackoff.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,219 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+
+	"salesagency/graph/model"
+)
+
+// Args checks and normalizes a field's arguments in place, returning Errors
+// listing every problem found. Arguments that are not input types, and
+// input types without rules, are left as they are.
+func Args(args map[string]interface{}) error {
+	c := &checker{}
+	for name, arg := range args {
+		args[name] = c.arg(name, arg)
+	}
+	return c.err()
+}
+
+// arg checks an argument holding an input, a pointer to one or a list of
+// either, and returns it normalized.
+func (c *checker) arg(field string, arg interface{}) interface{} {
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Struct:
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		c.input(field, ptr.Interface())
+		return ptr.Elem().Interface()
+	case reflect.Ptr:
+		if !v.IsNil() {
+			c.input(field, arg)
+		}
+	case reflect.Slice:
+		if kind := v.Type().Elem().Kind(); kind != reflect.Struct && kind != reflect.Ptr {
+			break
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := c.arg(fmt.Sprintf("%s[%d]", field, i), v.Index(i).Interface())
+			v.Index(i).Set(reflect.ValueOf(item))
+		}
+	}
+	return arg
+}
+
+func (c *checker) input(field string, input interface{}) {
+	switch input := input.(type) {
+	case *model.LeadInput:
+		c.lead(field, input)
+	case *model.LeadPatchInput:
+		c.leadPatch(field, input)
+	case *model.LeadFilterInput:
+		c.leadFilter(field, input)
+	case *model.CustomFieldDefinitionInput:
+		c.required(field+".key", &input.Key, MaxNameLength)
+		c.required(field+".label", &input.Label, MaxNameLength)
+		c.list(field+".options", input.Options, MaxNameLength)
+		c.optional(field+".pattern", input.Pattern, MaxNameLength)
+	case *model.CustomFieldValueInput:
+		c.required(field+".key", &input.Key, MaxNameLength)
+		c.optional(field+".value", input.Value, MaxTextLength)
+	case *model.ClientInput:
+		c.client(field, input)
+	case *model.AIAgentInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.required(field+".purpose", &input.Purpose, MaxTextLength)
+		c.optional(field+".description", input.Description, MaxTextLength)
+	case *model.AgentPersonaInput:
+		c.optional(field+".tone", input.Tone, MaxNameLength)
+		c.optional(field+".language", input.Language, MaxNameLength)
+		c.optional(field+".signature", input.Signature, MaxTextLength)
+		c.list(field+".dos", input.Dos, MaxTextLength)
+		c.list(field+".donts", input.Donts, MaxTextLength)
+	case *model.CampaignInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.optional(field+".description", input.Description, MaxTextLength)
+		c.nonNegative(field+".budget", input.Budget)
+	case *model.CampaignOverridesInput:
+		c.notBlank(field+".name", input.Name, MaxNameLength)
+		c.optional(field+".description", input.Description, MaxTextLength)
+		c.nonNegative(field+".budget", input.Budget)
+	case *model.CampaignSpendInput:
+		c.optional(field+".description", input.Description, MaxTextLength)
+	case *model.DealInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.nonNegative(field+".value", &input.Value)
+	case *model.SequenceInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		for i, step := range input.Steps {
+			c.optional(fmt.Sprintf("%s.steps[%d].task", field, i), step.Task, MaxTextLength)
+		}
+	case *model.InteractionInput:
+		c.optional(field+".message", input.Message, MaxContentLength)
+		c.optional(field+".notes", input.Notes, MaxTextLength)
+	case *model.MessageTemplateInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.required(field+".content", &input.Content, MaxContentLength)
+		c.required(field+".purpose", &input.Purpose, MaxTextLength)
+		c.list(field+".variables", input.Variables, MaxNameLength)
+	case *model.TemplateTranslationInput:
+		c.locale(field+".locale", &input.Locale)
+		c.required(field+".content", &input.Content, MaxContentLength)
+	case *model.TrainingProgramInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.required(field+".description", &input.Description, MaxTextLength)
+	case *model.TrainingModuleInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.required(field+".description", &input.Description, MaxTextLength)
+		c.required(field+".content", &input.Content, MaxContentLength)
+	case *model.UserInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.email(field+".email", &input.Email)
+		c.phone(field+".phone", input.Phone)
+		c.optional(field+".position", input.Position, MaxNameLength)
+	case *model.ServiceInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.required(field+".description", &input.Description, MaxTextLength)
+		c.nonNegative(field+".price", &input.Price)
+		c.list(field+".features", input.Features, MaxNameLength)
+	case *model.TargetAudienceInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.required(field+".industry", &input.Industry, MaxNameLength)
+		c.optional(field+".companySize", input.CompanySize, MaxNameLength)
+		c.optional(field+".location", input.Location, MaxNameLength)
+		c.optional(field+".decisionMakerRole", input.DecisionMakerRole, MaxNameLength)
+		c.list(field+".painPoints", input.PainPoints, MaxTextLength)
+	case *model.ScoringRulesetInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		c.length(field+".rules", input.Rules, MaxContentLength)
+	case *model.SLAInput:
+		c.required(field+".name", &input.Name, MaxNameLength)
+		if input.TargetMinutes <= 0 {
+			c.fail(field+".targetMinutes", "must be positive")
+		}
+	}
+}
+
+func (c *checker) lead(field string, input *model.LeadInput) {
+	c.required(field+".name", &input.Name, MaxNameLength)
+	c.email(field+".email", &input.Email)
+	c.phone(field+".phone", input.Phone)
+	c.optional(field+".company", input.Company, MaxNameLength)
+	c.optional(field+".position", input.Position, MaxNameLength)
+	c.between(field+".intentScore", input.IntentScore, 0, 1)
+	c.nonNegative(field+".dealValue", input.DealValue)
+	c.tags(field+".tags", input.Tags)
+	c.optional(field+".source", input.Source, MaxNameLength)
+	c.optional(field+".notes", input.Notes, MaxTextLength)
+	c.optional(field+".timezone", input.Timezone, MaxNameLength)
+	c.locale(field+".language", input.Language)
+}
+
+func (c *checker) leadPatch(field string, input *model.LeadPatchInput) {
+	c.optional(field+".company", input.Company, MaxNameLength)
+	c.optional(field+".position", input.Position, MaxNameLength)
+	c.optional(field+".source", input.Source, MaxNameLength)
+	c.optional(field+".notes", input.Notes, MaxTextLength)
+	c.nonNegative(field+".dealValue", input.DealValue)
+	c.optional(field+".timezone", input.Timezone, MaxNameLength)
+	c.locale(field+".language", input.Language)
+}
+
+func (c *checker) leadFilter(field string, input *model.LeadFilterInput) {
+	c.between(field+".minIntentScore", input.MinIntentScore, 0, 1)
+	c.tags(field+".tags", input.Tags)
+	c.tags(field+".excludeTags", input.ExcludeTags)
+	c.optional(field+".source", input.Source, MaxNameLength)
+	c.optional(field+".companyContains", input.CompanyContains, MaxNameLength)
+	c.optional(field+".text", input.Text, MaxNameLength)
+	if input.CustomField != nil {
+		c.required(field+".customField.key", &input.CustomField.Key, MaxNameLength)
+		c.optional(field+".customField.value", input.CustomField.Value, MaxTextLength)
+	}
+}
+
+func (c *checker) client(field string, input *model.ClientInput) {
+	c.required(field+".name", &input.Name, MaxNameLength)
+	c.required(field+".industry", &input.Industry, MaxNameLength)
+	c.url(field+".website", input.Website)
+	c.required(field+".contactPerson", &input.ContactPerson, MaxNameLength)
+	c.email(field+".email", &input.Email)
+	c.phone(field+".phone", input.Phone)
+	c.optional(field+".address", input.Address, MaxTextLength)
+	c.optional(field+".notes", input.Notes, MaxTextLength)
+}
+
+// Lead checks and normalizes a lead built from input other than GraphQL,
+// such as the REST and gRPC APIs, by the rules of LeadInput.
+func Lead(lead *model.Lead) error {
+	c := &checker{}
+	c.required("name", &lead.Name, MaxNameLength)
+	c.email("email", &lead.Email)
+	c.phone("phone", lead.Phone)
+	c.optional("company", lead.Company, MaxNameLength)
+	c.optional("position", lead.Position, MaxNameLength)
+	c.between("intentScore", &lead.IntentScore, 0, 1)
+	c.nonNegative("dealValue", lead.DealValue)
+	c.tags("tags", lead.Tags)
+	c.optional("source", lead.Source, MaxNameLength)
+	c.optional("notes", lead.Notes, MaxTextLength)
+	c.locale("language", lead.Language)
+	return c.err()
+}
+
+// Client checks and normalizes a client built from input other than
+// GraphQL by the rules of ClientInput.
+func Client(client *model.Client) error {
+	c := &checker{}
+	c.required("name", &client.Name, MaxNameLength)
+	c.required("industry", &client.Industry, MaxNameLength)
+	c.url("website", client.Website)
+	c.required("contactPerson", &client.ContactPerson, MaxNameLength)
+	c.email("email", &client.Email)
+	c.phone("phone", client.Phone)
+	c.optional("address", client.Address, MaxTextLength)
+	c.optional("notes", client.Notes, MaxTextLength)
+	return c.err()
+}
@@ -0,0 +1,258 @@
+// Package validation checks GraphQL input before it reaches the database,
+// normalizing what it can, such as phone numbers to E.164, and reporting
+// every problem found by the field it is on.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Length limits of text fields, in characters.
+const (
+	MaxNameLength  = 200
+	MaxEmailLength = 254
+	MaxURLLength   = 2048
+	MaxTagLength   = 64
+	MaxTags        = 50
+	MaxTextLength  = 10000
+	// MaxContentLength bounds message bodies, template content and other
+	// long-form text.
+	MaxContentLength = 50000
+)
+
+// FieldError is a problem with one input field. Field is the path to it
+// from the argument, such as "input.email" or "rules[2].threshold".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors lists every problem found in a field's arguments.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	problems := make([]string, len(e))
+	for i, err := range e {
+		problems[i] = err.Field + ": " + err.Message
+	}
+	return "invalid input: " + strings.Join(problems, "; ")
+}
+
+// checker collects the problems of the fields it checks. Its methods trim
+// and normalize the values they are given in place.
+type checker struct {
+	errs Errors
+}
+
+func (c *checker) fail(field, format string, args ...interface{}) {
+	c.errs = append(c.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
+
+// required checks a text field that must not be blank.
+func (c *checker) required(field string, value *string, max int) {
+	*value = strings.TrimSpace(*value)
+	if *value == "" {
+		c.fail(field, "is required")
+		return
+	}
+	c.length(field, *value, max)
+}
+
+// optional checks a nullable text field. A blank value is kept, so it can
+// still clear the field.
+func (c *checker) optional(field string, value *string, max int) {
+	if value == nil {
+		return
+	}
+	*value = strings.TrimSpace(*value)
+	c.length(field, *value, max)
+}
+
+// notBlank checks a nullable text field that may be left out but not
+// cleared.
+func (c *checker) notBlank(field string, value *string, max int) {
+	if value != nil {
+		c.required(field, value, max)
+	}
+}
+
+func (c *checker) length(field, value string, max int) {
+	if n := utf8.RuneCountInString(value); n > max {
+		c.fail(field, "must be at most %d characters, not %d", max, n)
+	}
+}
+
+func (c *checker) list(field string, values []string, max int) {
+	for i := range values {
+		c.required(fmt.Sprintf("%s[%d]", field, i), &values[i], max)
+	}
+}
+
+func (c *checker) tags(field string, tags []string) {
+	if len(tags) > MaxTags {
+		c.fail(field, "must have at most %d tags", MaxTags)
+		return
+	}
+	c.list(field, tags, MaxTagLength)
+}
+
+func (c *checker) email(field string, value *string) {
+	c.required(field, value, MaxEmailLength)
+	if *value == "" {
+		return
+	}
+	if !IsEmail(*value) {
+		c.fail(field, "must be an email address such as name@example.com")
+	}
+}
+
+func (c *checker) phone(field string, value *string) {
+	if value == nil {
+		return
+	}
+	*value = strings.TrimSpace(*value)
+	if *value == "" {
+		return
+	}
+	phone, err := NormalizePhone(*value)
+	if err != nil {
+		c.fail(field, "%s", err)
+		return
+	}
+	*value = phone
+}
+
+func (c *checker) locale(field string, value *string) {
+	if value == nil {
+		return
+	}
+	locale, err := NormalizeLocale(*value)
+	if err != nil {
+		c.fail(field, "%s", err)
+		return
+	}
+	*value = locale
+}
+
+func (c *checker) url(field string, value *string) {
+	if value == nil {
+		return
+	}
+	*value = strings.TrimSpace(*value)
+	if *value == "" {
+		return
+	}
+	c.length(field, *value, MaxURLLength)
+	website, err := NormalizeURL(*value)
+	if err != nil {
+		c.fail(field, "%s", err)
+		return
+	}
+	*value = website
+}
+
+// between checks that a number is within [min, max].
+func (c *checker) between(field string, value *float64, min, max float64) {
+	if value != nil && (*value < min || *value > max) {
+		c.fail(field, "must be between %g and %g", min, max)
+	}
+}
+
+func (c *checker) nonNegative(field string, value *float64) {
+	if value != nil && *value < 0 {
+		c.fail(field, "must not be negative")
+	}
+}
+
+// IsEmail reports whether s is a bare email address with a domain name,
+// such as "name@example.com".
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	at := strings.LastIndexByte(s, '@')
+	domain := s[at+1:]
+	return strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// NormalizePhone returns an international phone number in E.164 form, such
+// as "+14155550123". It accepts a leading "+" or "00" and ignores spaces,
+// dashes, dots and parentheses.
+func NormalizePhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, phone)
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		return "", errors.New("must include the country code, such as +14155550123")
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", errors.New("must contain only digits after the country code")
+		}
+	}
+	// E.164 numbers have at most 15 digits; the shortest in use have 8.
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", errors.New("must be an international number of 8 to 15 digits")
+	}
+	return "+" + digits, nil
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-([a-z]{4}|[a-z]{2}|[0-9]{3}))*$`)
+
+// NormalizeLocale returns a language tag such as "de", "pt-BR" or "zh-Hant"
+// in its usual case. It accepts underscores for dashes, as in "pt_br".
+func NormalizeLocale(locale string) (string, error) {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if !localePattern.MatchString(tag) {
+		return "", errors.New("must be a language tag such as de or pt-BR")
+	}
+
+	parts := strings.Split(tag, "-")
+	for i, part := range parts[1:] {
+		switch len(part) {
+		case 2:
+			parts[i+1] = strings.ToUpper(part)
+		case 4:
+			parts[i+1] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// NormalizeURL returns a website as an absolute http or https URL. An
+// address without a scheme, such as "example.com", gets https.
+func NormalizeURL(website string) (string, error) {
+	if !strings.Contains(website, "://") {
+		website = "https://" + website
+	}
+	u, err := url.Parse(website)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(u.Hostname(), ".") {
+		return "", errors.New("must be a website address such as https://example.com")
+	}
+	return u.String(), nil
+}
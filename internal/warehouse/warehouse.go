@@ -0,0 +1,165 @@
+// Package warehouse exports leads, interactions and campaign metrics to S3
+// for loading into a data warehouse. Each run writes every dataset as gzipped
+// NDJSON files, one JSON object per row, under
+//
+//	<prefix>/<dataset>/dt=<date>/<mode>-<time>-<part>.ndjson.gz
+//
+// Incremental runs take the rows created, updated or deleted after the
+// dataset's watermark, the time the last run exported up to; full runs take
+// every row. Campaign metrics are computed on the fly, so both take them all.
+// A run that fails leaves the watermark where it was, so the next one exports
+// the same rows again: loaders should keep the latest version of each row by
+// id.
+package warehouse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"salesagency/internal/database"
+	"salesagency/internal/storage"
+)
+
+const (
+	defaultPartRows = 100000
+	// watermarkLag keeps the rows of transactions still open during a run
+	// out of its window, since they commit with an updated_at from before
+	// the run. Statement timeouts keep such transactions much shorter.
+	watermarkLag = 5 * time.Minute
+)
+
+// Datasets are exported in this order.
+var Datasets = []string{database.WarehouseLeads, database.WarehouseInteractions, database.WarehouseCampaignMetrics}
+
+// Config says where exports go.
+type Config struct {
+	S3 storage.S3Config
+	// Prefix starts every object key, e.g. "warehouse".
+	Prefix string
+	// Full makes scheduled runs export every row rather than the changes
+	// since the last run.
+	Full bool
+	// PartRows caps the rows in one file.
+	PartRows int
+}
+
+// Result says what a run exported of one dataset.
+type Result struct {
+	Dataset string
+	Rows    int
+	Files   int
+	// Skipped is set when another instance was exporting the dataset.
+	Skipped bool
+}
+
+type Exporter struct {
+	db       *database.DB
+	store    storage.Store
+	prefix   string
+	full     bool
+	partRows int
+	now      func() time.Time
+}
+
+func NewExporter(db *database.DB, cfg Config) (*Exporter, error) {
+	store, err := storage.Open(&cfg.S3)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PartRows <= 0 {
+		cfg.PartRows = defaultPartRows
+	}
+	return &Exporter{db: db, store: store, prefix: cfg.Prefix, full: cfg.Full, partRows: cfg.PartRows, now: time.Now}, nil
+}
+
+// Run exports every dataset, in full when full is set or the exporter is
+// configured to. It stops at the first dataset that fails.
+func (e *Exporter) Run(ctx context.Context, full bool) ([]Result, error) {
+	full = full || e.full
+	mode := "incremental"
+	if full {
+		mode = "full"
+	}
+
+	started := e.now().UTC()
+	until := started.Add(-watermarkLag)
+
+	results := make([]Result, 0, len(Datasets))
+	for _, dataset := range Datasets {
+		w := &partWriter{
+			ctx:   ctx,
+			store: e.store,
+			key:   path.Join(e.prefix, dataset, "dt="+started.Format("2006-01-02"), mode+"-"+started.Format("20060102T150405Z")),
+			limit: e.partRows,
+		}
+		exported, err := e.db.ExportWarehouseDataset(ctx, dataset, full, until, w.write, w.flush)
+		if err != nil {
+			return results, fmt.Errorf("error exporting %s: %w", dataset, err)
+		}
+
+		result := Result{Dataset: dataset, Rows: w.rows, Files: w.parts, Skipped: !exported}
+		slog.Info("Exported warehouse dataset", "dataset", dataset, "mode", mode, "rows", result.Rows, "files", result.Files, "skipped", result.Skipped)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// partWriter compresses rows into files of up to limit rows and uploads
+// each as it fills.
+type partWriter struct {
+	ctx   context.Context
+	store storage.Store
+	key   string
+	limit int
+
+	buf   bytes.Buffer
+	gz    *gzip.Writer
+	rows  int
+	parts int
+	// inPart counts the rows of the file being written.
+	inPart int
+}
+
+func (w *partWriter) write(row []byte) error {
+	if w.gz == nil {
+		w.buf.Reset()
+		w.gz = gzip.NewWriter(&w.buf)
+	}
+	if _, err := w.gz.Write(row); err != nil {
+		return err
+	}
+	if _, err := w.gz.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+	w.rows++
+	w.inPart++
+	if w.inPart >= w.limit {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush uploads the file being written, if it has any rows.
+func (w *partWriter) flush() error {
+	if w.gz == nil {
+		return nil
+	}
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s-%05d.ndjson.gz", w.key, w.parts)
+	if err := w.store.Put(w.ctx, key, bytes.NewReader(w.buf.Bytes()), int64(w.buf.Len()), "application/gzip"); err != nil {
+		return fmt.Errorf("error uploading %s: %w", key, err)
+	}
+
+	w.gz = nil
+	w.inPart = 0
+	w.parts++
+	return nil
+}
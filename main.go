@@ -2,78 +2,686 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
+	"github.com/vektah/gqlparser/v2/ast"
 
 	"./graph"
 	"./graph/generated"
+	"./graph/model"
+	"./internal/assignment"
+	"./internal/auth"
+	"./internal/cache"
+	"./internal/calendar"
+	"./internal/calls"
+	"./internal/campaign"
+	"./internal/capture"
+	"./internal/channels"
+	"./internal/channels/linkedin"
+	"./internal/chat"
+	"./internal/config"
+	"./internal/conversation"
 	"./internal/database"
+	"./internal/dataloader"
+	"./internal/distribution"
+	"./internal/events"
+	"./internal/export"
+	"./internal/grpcserver"
+	"./internal/identity"
+	"./internal/integrations"
+	"./internal/jobs"
+	"./internal/leadquery"
+	"./internal/llm"
+	"./internal/logging"
+	"./internal/messaging/aircall"
+	"./internal/messaging/email"
+	"./internal/messaging/twilio"
+	"./internal/money"
+	"./internal/notifications"
+	"./internal/nurture"
+	"./internal/optout"
+	"./internal/outbox"
+	"./internal/pipeline"
+	"./internal/ratelimit"
+	"./internal/replies"
+	"./internal/reports"
+	"./internal/restapi"
+	"./internal/retention"
+	"./internal/salesforce"
+	"./internal/scheduler"
+	"./internal/scoring"
+	"./internal/sequence"
+	"./internal/sla"
+	"./internal/slack"
+	"./internal/storage"
+	"./internal/tenant"
+	"./internal/tracking"
+	"./internal/transcription"
+	"./internal/warehouse"
 )
 
-const defaultPort = "8080"
+const defaultWebhookTimeout = 10 * time.Second
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+	envErr := godotenv.Load()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "warehouse" {
+		os.Exit(runWarehouse(os.Args[2:]))
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("Invalid configuration", err)
+	}
+	if err := logging.Setup(cfg.Logging); err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+	if envErr != nil {
+		slog.Info("No .env file found")
 	}
 
-	db, err := database.Initialize()
+	tokens := auth.NewTokenService(cfg.JWTSecret, cfg.TokenTTL)
+
+	db, err := database.Initialize(cfg.Database)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", err)
 	}
 	defer db.Close()
+	// The APIs read and write leads, clients and campaigns through these,
+	// so another storage backend can stand in for PostgreSQL.
+	store := db.Repositories()
+
+	if cfg.MigrateOnStart {
+		if err := db.MigrateUp(context.Background()); err != nil {
+			fatal("Failed to migrate database", err)
+		}
+	}
+
+	recordCache, err := cache.Open(context.Background(), cfg.Cache)
+	if err != nil {
+		fatal("Failed to configure cache", err)
+	}
+	if recordCache != nil {
+		defer recordCache.Close()
+		db.SetCache(recordCache)
+	}
+
+	emailSender, err := email.New(cfg.Email)
+	if err != nil {
+		fatal("Failed to configure email provider", err)
+	}
+
+	var twilioClient *twilio.Client
+	if cfg.Twilio != nil {
+		twilioClient, err = twilio.NewClient(*cfg.Twilio)
+		if err != nil {
+			fatal("Failed to configure twilio", err)
+		}
+	}
+
+	var linkedinClient *linkedin.Client
+	if cfg.LinkedIn != nil {
+		linkedinClient, err = linkedin.NewClient(*cfg.LinkedIn)
+		if err != nil {
+			fatal("Failed to configure linkedin", err)
+		}
+	}
+
+	llmProvider, err := llm.New(cfg.LLM)
+	if err != nil {
+		fatal("Failed to configure LLM provider", err)
+	}
+
+	calendarProvider, err := calendar.New(cfg.Calendar)
+	if err != nil {
+		fatal("Failed to configure calendar provider", err)
+	}
+
+	broker := events.NewBroker()
+	notificationService := notifications.NewService(db, broker)
+
+	schedulerCtx, stopScheduler := context.WithCancel(tenant.WithSystem(context.Background()))
+	if cfg.EventBridge {
+		events.RegisterPayload(&model.Lead{}, &model.Interaction{}, &model.AgentRunLog{}, &model.Notification{}, &database.IntentScoreChange{})
+		bridge := events.NewBridge(broker, db)
+		go func() {
+			if err := bridge.Run(schedulerCtx); err != nil {
+				slog.Error("Event bridge stopped", "error", err)
+			}
+		}()
+	}
+
+	// The executor's work is set once the dispatcher exists, before the
+	// scheduler starts.
+	agentExecutor := &scheduler.AgentExecutor{DB: db}
+	agentScheduler := scheduler.New(db, agentExecutor, scheduler.Options{
+		Workers:   cfg.SchedulerWorkers,
+		Events:    broker,
+		OnFailure: notificationService.AgentRunFailed,
+	})
+
+	scoringEngine := scoring.NewEngine(db, store.Leads, scoring.DefaultWeights)
+	scoringEngine.OnChange(func(ctx context.Context, change *database.IntentScoreChange) {
+		broker.Publish(events.TopicLeadIntentScoreChanged, change)
+	})
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.IntentScore, "intent score recompute", func(ctx context.Context) error {
+		changed, err := scoringEngine.RecalculateAll(ctx)
+		if err == nil {
+			slog.Info("Recomputed intent scores", "changed", changed)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule intent score recompute", err)
+	}
+
+	relay := outbox.NewRelay(db)
+	relay.Register(outbox.KindWebhook, outbox.WebhookHandler(defaultWebhookTimeout))
+	relay.Register(notifications.KindEmail, notifications.EmailHandler(emailSender))
+	relay.Register(calendar.KindInvite, calendar.InviteHandler(emailSender))
+	relay.Register(integrations.KindHook, integrations.HookHandler(db, defaultWebhookTimeout))
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.Outbox, "outbox relay", func(ctx context.Context) error {
+		delivered, err := relay.Run(ctx)
+		if delivered > 0 {
+			slog.Info("Delivered outbox messages", "delivered", delivered)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule outbox relay", err)
+	}
+	go integrations.Run(schedulerCtx, db, broker)
+
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.SegmentCount, "segment counts", func(ctx context.Context) error {
+		_, err := db.RefreshSegmentCounts(ctx, time.Now())
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule segment counts", err)
+	}
+
+	campaignService := campaign.NewService(db)
+	campaignService.SetMessageCosts(cfg.MessageCosts)
+	if cfg.BudgetAlertWebhookURL != "" {
+		campaignService.SetBudgetAlertWebhook(cfg.BudgetAlertWebhookURL)
+	}
+	campaignService.OnBudgetAlert(notificationService.BudgetThreshold)
+	campaignService.OnGoalAtRisk(notificationService.GoalAtRisk)
+	err = scheduler.RunCron(schedulerCtx, "* * * * *", "campaign activation", func(ctx context.Context) error {
+		_, err := campaignService.ActivateDue(ctx)
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule campaign activation", err)
+	}
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.GoalPacing, "campaign goal pacing", func(ctx context.Context) error {
+		atRisk, err := campaignService.CheckPacing(ctx)
+		if atRisk > 0 {
+			slog.Info("Flagged campaign goals at risk", "at_risk", atRisk)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule campaign goal pacing", err)
+	}
+
+	reportService := reports.NewService(db, emailSender)
+	if cadence := cfg.ClientReportCadence; cadence != "" {
+		reportCron, _ := cadence.Cron()
+		err = scheduler.RunCron(schedulerCtx, reportCron, "client reports", func(ctx context.Context) error {
+			period := cadence.LastPeriod(time.Now())
+			sent, err := reportService.SendAll(ctx, period)
+			if err == nil {
+				slog.Info("Sent client reports", "sent", sent, "period", period.Label)
+			}
+			return err
+		})
+		if err != nil {
+			fatal("Failed to schedule client reports", err)
+		}
+	}
 
 	router := chi.NewRouter()
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
 	router.Use(middleware.RequestID)
+	router.Use(logging.Middleware)
+	router.Use(middleware.Recoverer)
 	router.Use(middleware.RealIP)
-	router.Use(middleware.Timeout(60 * time.Second))
+	router.Use(timeoutUnlessStreaming(60 * time.Second))
+
+	tracker := tracking.NewTracker(cfg.TrackingSecret, cfg.PublicURL)
+	var emailTracker *tracking.Tracker
+	if cfg.EmailTracking {
+		emailTracker = tracker
+	}
+	dispatcher := channels.NewDispatcher(db, channels.Defaults(emailSender, emailTracker, twilioClient)...)
+	if linkedinClient != nil {
+		dispatcher.Register(linkedin.NewChannel(linkedinClient, db))
+	}
+	dispatcher.OnReply(onLeadReply(scoringEngine, broker))
+	// After onLeadReply, so the reply counts towards the intent score.
+	dispatcher.OnReply(notificationService.LeadReplied)
+	dispatcher.OnSend(campaignService.RecordMessageSpend)
+	dispatcher.OnSend(campaignService.TrackMessage)
+	dispatcher.OnReply(campaignService.HandleReply)
+
+	unsubscribe := optout.NewSigner(cfg.UnsubscribeSecret, cfg.PublicURL)
+	if cfg.PublicURL == "" {
+		slog.Warn("PUBLIC_URL not set, outbound emails will not include unsubscribe or meeting links and export links will be relative")
+	}
+	dispatcher.SetUnsubscribeLinks(unsubscribe.URL)
+	dispatcher.SetDuplicateWindow(cfg.DuplicateSendWindow)
+
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.OutboundQueue, "outbound queue release", func(ctx context.Context) error {
+		released, err := dispatcher.ReleaseQueued(ctx)
+		if released > 0 {
+			slog.Info("Released queued outbound messages", "released", released)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule outbound queue release", err)
+	}
+
+	conversationEngine := conversation.NewEngine(db, llmProvider)
+	agentExecutor.Work = conversation.NewOutreach(conversationEngine, dispatcher).Run
+	agentScheduler.Start(schedulerCtx)
+
+	pipelineService := pipeline.NewService(store.Leads)
+	assignmentService := assignment.NewService(db)
+	pipelineService.OnStatusChange(assignmentService.LeadStatusChanged)
+	scoringEngine.OnChange(assignmentService.IntentScoreChanged)
+
+	sequenceEngine := sequence.NewEngine(db, dispatcher)
+	nurtureService := nurture.NewService(db, sequenceEngine, pipelineService)
+	pipelineService.OnLost(nurtureService.LeadLost)
+	replyService := replies.NewService(db, llmProvider)
+	replyService.OnEscalated(notificationService.LeadEscalated)
+	// Before nurture and the sequence engine, which let out-of-office
+	// replies through.
+	dispatcher.OnReply(replyService.HandleReply)
+	// Before the sequence engine ends the lead's nurture on the reply.
+	dispatcher.OnReply(nurtureService.HandleReply)
+	dispatcher.OnReply(sequenceEngine.HandleReply)
+
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.Sequence, "sequence steps", func(ctx context.Context) error {
+		ran, err := sequenceEngine.RunDue(ctx)
+		if ran > 0 {
+			slog.Info("Ran sequence steps", "steps", ran)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule sequence steps", err)
+	}
+
+	exports := export.NewSigner(cfg.ExportSecret, cfg.PublicURL, cfg.ExportLinkTTL)
+
+	salesforceService, err := salesforce.New(db, cfg.Salesforce)
+	if err != nil {
+		fatal("Failed to configure salesforce", err)
+	}
+	if salesforceService.Enabled() {
+		go salesforceService.Run(schedulerCtx, broker)
+
+		if err := scheduler.RunCron(schedulerCtx, cfg.Crons.SalesforceReconcile, "salesforce reconciliation", salesforceService.Reconcile); err != nil {
+			fatal("Failed to schedule salesforce reconciliation", err)
+		}
+	}
+
+	fileStore, err := storage.Open(cfg.S3)
+	if err != nil {
+		fatal("Failed to configure file storage", err)
+	}
+
+	transcriber, err := transcription.New(cfg.Transcription)
+	if err != nil {
+		fatal("Failed to configure transcription provider", err)
+	}
+	if transcriber != nil {
+		transcriptionWorker := transcription.NewWorker(db, transcriber, llmProvider, fileStore)
+		err = scheduler.RunCron(schedulerCtx, cfg.Crons.Transcription, "call transcription", func(ctx context.Context) error {
+			transcribed, err := transcriptionWorker.Run(ctx)
+			if transcribed > 0 {
+				slog.Info("Transcribed calls", "transcribed", transcribed)
+			}
+			return err
+		})
+		if err != nil {
+			fatal("Failed to schedule call transcription", err)
+		}
+	}
+
+	retentionService := retention.NewService(db, fileStore)
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.Retention, "lead retention", func(ctx context.Context) error {
+		purged, err := retentionService.PurgeExpired(ctx)
+		if purged > 0 {
+			slog.Info("Purged leads past retention", "purged", purged)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule lead retention", err)
+	}
+
+	if cfg.Warehouse != nil {
+		warehouseExporter, err := warehouse.NewExporter(db, *cfg.Warehouse)
+		if err != nil {
+			fatal("Failed to configure warehouse export", err)
+		}
+		err = scheduler.RunCron(schedulerCtx, cfg.Crons.WarehouseExport, "warehouse export", func(ctx context.Context) error {
+			_, err := warehouseExporter.Run(ctx, false)
+			return err
+		})
+		if err != nil {
+			fatal("Failed to schedule warehouse export", err)
+		}
+	}
+
+	// Rates are refreshed at startup too, so a new deployment has them before
+	// the ECB next publishes.
+	moneyConverter := money.NewConverter(db, cfg.ExchangeRatesURL)
+	refreshExchangeRates := func(ctx context.Context) error {
+		saved, err := moneyConverter.Refresh(ctx)
+		if saved > 0 {
+			slog.Info("Refreshed exchange rates", "currencies", saved)
+		}
+		return err
+	}
+	go func() {
+		if err := refreshExchangeRates(schedulerCtx); err != nil {
+			slog.Error("Failed to refresh exchange rates", "error", err)
+		}
+	}()
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.ExchangeRates, "exchange rates", refreshExchangeRates)
+	if err != nil {
+		fatal("Failed to schedule exchange rates", err)
+	}
+
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.AgentStats, "agent stats rollup", func(ctx context.Context) error {
+		_, err := db.RollupAgentStats(ctx, time.Now())
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule agent stats rollup", err)
+	}
+
+	slaWorker := sla.NewWorker(db)
+	slaWorker.OnBreach(notificationService.SLABreached)
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.SLA, "SLA evaluation", func(ctx context.Context) error {
+		breached, err := slaWorker.Run(ctx)
+		if breached > 0 {
+			slog.Info("Flagged SLA breaches", "breached", breached)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule SLA evaluation", err)
+	}
+
+	identityService := identity.NewService(db)
+	err = scheduler.RunCron(schedulerCtx, cfg.Crons.SendingIdentity, "sending identity verification", func(ctx context.Context) error {
+		verified, err := identityService.CheckPending(ctx)
+		if verified > 0 {
+			slog.Info("Verified sending identities", "verified", verified)
+		}
+		return err
+	})
+	if err != nil {
+		fatal("Failed to schedule sending identity verification", err)
+	}
 
-	resolver := &graph.Resolver{DB: db}
-	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+	meetingLinks := calendar.NewSigner(cfg.MeetingSecret, cfg.PublicURL)
+	calendarService := calendar.NewService(db, calendarProvider, pipelineService, cfg.CalendarID, calendar.Invites{
+		Organizer:     cfg.Email.From,
+		OrganizerName: cfg.Email.FromName,
+		Links:         meetingLinks,
+	})
+	calendarService.OnChange(notificationService.MeetingChanged)
 
-	router.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	router.Handle("/query", srv)
+	callService := calls.NewService(db)
+	distributionService := distribution.NewService(db)
+	slackLinks := slack.NewLinker(cfg.SlackLinkSecret)
+	jobQueue := jobs.New(db, jobs.Options{
+		Workers:    cfg.JobWorkers,
+		JobTimeout: cfg.JobTimeout,
+		Limits:     cfg.JobLimits,
+	})
+	resolver := &graph.Resolver{
+		DB:            db,
+		Store:         store,
+		Events:        broker,
+		Tokens:        tokens,
+		Channels:      dispatcher,
+		Scoring:       scoringEngine,
+		Campaigns:     campaignService,
+		Sequences:     sequenceEngine,
+		Pipeline:      pipelineService,
+		Nurture:       nurtureService,
+		Conversations: conversationEngine,
+		Reports:       reportService,
+		Calendar:      calendarService,
+		MeetingLinks:  meetingLinks,
+		Exports:       exports,
+		Salesforce:    salesforceService,
+		Calls:         callService,
+		Retention:     retentionService,
+		Assignment:    assignmentService,
+		Distribution:  distributionService,
+		Money:         moneyConverter,
+		Identities:    identityService,
+		Notifier:      notificationService,
+		Jobs:          jobQueue,
+		LeadQueries:   leadquery.NewPlanner(db, llmProvider),
+		HandoffTarget: cfg.HandoffPickupTarget,
+		Storage:       fileStore,
+		UploadLimits:  cfg.UploadLimits,
+		Masking:       cfg.MaskingPolicy,
+	}
+	if cfg.SlackSigningSecret != "" {
+		resolver.SlackLinks = slackLinks
+	}
+	resolver.RegisterJobs(jobQueue)
+	jobQueue.Start(schedulerCtx)
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolver,
+		Directives: generated.DirectiveRoot{HasRole: graph.HasRole},
+	}))
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              websocketAuth(tokens),
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	// Ahead of POST, which would otherwise take the request: sends fields
+	// marked @defer in later parts to clients accepting multipart/mixed.
+	srv.AddTransport(transport.MultipartMixed{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{
+		// Leave room for the operations and map parts next to the file.
+		MaxUploadSize: cfg.UploadLimits.MaxBytes + 1<<20,
+	})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(logging.GraphQL{})
+	srv.Use(dataloader.Extension{DB: db, Store: store})
+	srv.Use(graph.ClientPortal{})
+	srv.Use(graph.FieldMasking{Policy: cfg.MaskingPolicy})
+	srv.Use(graph.Validation{})
+	if cfg.DevMode {
+		srv.Use(extension.Introspection{})
+	}
+	if cfg.PersistedQueries != nil {
+		srv.Use(graph.PersistedQueries{Manifest: cfg.PersistedQueries, AllowUnlisted: cfg.DevMode})
+	}
+	srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](100)})
+	srv.Use(ratelimit.Extension{
+		Queries:   ratelimit.NewLimiter(cfg.QueryLimit),
+		Mutations: ratelimit.NewLimiter(cfg.MutationLimit),
+	})
+
+	router.Group(func(router chi.Router) {
+		router.Use(auth.Middleware(tokens))
+		if cfg.DevMode {
+			router.Handle("/", playground.Handler("GraphQL playground", "/query"))
+		}
+		router.Handle("/query", ratelimit.Middleware(srv))
+	})
+	router.Mount("/api/v1", restapi.New(store, broker, pipelineService, scoringEngine))
+	router.Mount(integrations.Path, integrations.New(db, broker))
+	if twilioClient != nil {
+		router.Handle("/webhooks/twilio", twilioClient.WebhookHandler(channels.TwilioEvents(dispatcher)))
+		router.Handle("/webhooks/twilio/voice", twilioClient.VoiceWebhookHandler(calls.NewEvents(callService)))
+	}
+	if linkedinClient != nil {
+		router.Handle("/webhooks/linkedin", linkedinClient.WebhookHandler(linkedin.Events(dispatcher, db)))
+	}
+	if cfg.SlackSigningSecret != "" {
+		router.Handle(slack.Path, slack.Handler(db, broker, assignmentService, slackLinks, cfg.SlackSigningSecret))
+	}
+	if cfg.AircallWebhookToken != "" {
+		router.Handle("/webhooks/aircall", aircall.WebhookHandler(cfg.AircallWebhookToken, calls.NewEvents(callService)))
+	}
+	if cfg.EmailInboundToken != "" {
+		router.Handle("/webhooks/email", email.InboundWebhookHandler(channels.EmailEvents(dispatcher), cfg.EmailInboundToken))
+	}
+	if cfg.EmailEventsToken != "" {
+		router.Handle("/webhooks/email/events", email.EventWebhookHandler(channels.EmailDeliveryEvents(dispatcher), cfg.EmailEventsToken))
+	}
+	// Served even with tracking off, so links in emails already sent keep
+	// working.
+	router.Handle(tracking.OpenPath, tracking.OpenHandler(tracker, channels.EmailTracking(dispatcher)))
+	router.Handle(tracking.ClickPath, tracking.ClickHandler(tracker, channels.EmailTracking(dispatcher)))
+	router.Handle(capture.Path, capture.Handler(db, distributionService, broker, ratelimit.NewLimiter(cfg.CaptureLimit)))
+	chatHandler := chat.Handler(db, chat.NewService(db, llmProvider, broker), ratelimit.NewLimiter(cfg.ChatLimit))
+	router.Handle(chat.SessionPath, chatHandler)
+	router.Handle(chat.MessagePath, chatHandler)
+	router.Handle(optout.Path, optout.Handler(db, unsubscribe))
+	router.Handle(calendar.ReschedulePath, calendar.Handler(calendarService, meetingLinks))
+	router.Handle(calendar.CancelPath, calendar.Handler(calendarService, meetingLinks))
+	router.Handle(export.Path, export.Handler(db, exports))
+	router.Handle(salesforce.CallbackPath, salesforce.CallbackHandler(salesforceService))
 
 	server := &http.Server{
-		Addr:    ":" + port,
+		Addr:    ":" + cfg.Port,
 		Handler: router,
 	}
 
 	go func() {
-		log.Printf("Server starting on http://localhost:%s/", port)
+		slog.Info("Server starting", "url", "http://localhost:"+cfg.Port+"/")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+			fatal("Server error", err)
+		}
+	}()
+
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		fatal("Failed to listen on gRPC port", err)
+	}
+	grpcServer := grpcserver.New(db, broker, tokens, pipelineService, scoringEngine)
+
+	go func() {
+		slog.Info("gRPC server starting", "port", cfg.GRPCPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			fatal("gRPC server error", err)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", err)
+	}
+	grpcServer.GracefulStop()
+
+	stopScheduler()
+	agentScheduler.Wait()
+	jobQueue.Wait()
+
+	slog.Info("Server exited gracefully")
+}
+
+// onLeadReply rescores a lead that replied, so the reply counts towards its
+// intent score immediately, and notifies leadReplied subscribers.
+func onLeadReply(scoringEngine *scoring.Engine, broker *events.Broker) channels.ReplyHook {
+	return func(ctx context.Context, reply *model.Interaction) {
+		leads, err := scoringEngine.Recalculate(ctx, []string{reply.Lead.ID})
+		if err != nil {
+			logging.FromContext(ctx).Error("Error rescoring lead after reply", "lead_id", reply.Lead.ID, "error", err)
+		}
+		for _, lead := range leads {
+			broker.Publish(events.TopicLeadUpdated, lead)
+		}
+
+		broker.Publish(events.TopicLeadReplied, reply)
 	}
+}
 
-	log.Println("Server exited gracefully")
-}
\ No newline at end of file
+// fatal logs msg with err, if any, and exits.
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
+}
+
+// websocketAuth authenticates subscriptions from the connection_init payload,
+// since browsers cannot set headers on websocket upgrades.
+func websocketAuth(tokens *auth.TokenService) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		tokenString := strings.TrimPrefix(initPayload.Authorization(), "Bearer ")
+		if tokenString == "" {
+			return ctx, &initPayload, nil
+		}
+
+		user, err := tokens.Parse(tokenString)
+		if err != nil {
+			return ctx, nil, err
+		}
+		return auth.WithUser(ctx, user), &initPayload, nil
+	}
+}
+
+// timeoutUnlessStreaming applies the request timeout to everything except
+// websocket upgrades, which carry long-lived subscriptions, and lead export
+// downloads, which can take longer on large agencies.
+func timeoutUnlessStreaming(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := middleware.Timeout(timeout)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.URL.Path == export.Path {
+				next.ServeHTTP(w, r)
+				return
+			}
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
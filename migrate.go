@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"./internal/config"
+	"./internal/database"
+	"./internal/logging"
+)
+
+const migrateUsage = "usage: salesagency migrate up | down [steps] | status"
+
+// runMigrate implements the migrate subcommand and returns the exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	logs, dbConfig, err := config.LoadDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := logging.Setup(logs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Migrations only ever run against the primary.
+	dbConfig.ReplicaURL = ""
+	db, err := database.Initialize(dbConfig)
+	if err != nil {
+		slog.Error("Failed to initialize database", "error", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		err = db.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		err = db.MigrateDown(ctx, steps)
+	case "status":
+		var status *database.MigrationStatus
+		status, err = db.MigrationStatus(ctx)
+		if err == nil {
+			printMigrationStatus(status)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		slog.Error("Migration failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(status *database.MigrationStatus) {
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	fmt.Printf("Schema version: %d%s\n", status.Version, dirty)
+
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		fmt.Printf("  %06d %-40s %s\n", m.Version, m.Name, state)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"./internal/config"
+	"./internal/database"
+	"./internal/logging"
+	"./internal/tenant"
+	"./internal/warehouse"
+)
+
+const warehouseUsage = "usage: salesagency warehouse export [--full]"
+
+// runWarehouse implements the warehouse subcommand and returns the exit
+// code.
+func runWarehouse(args []string) int {
+	if len(args) == 0 || args[0] != "export" || len(args) > 2 || len(args) == 2 && args[1] != "--full" {
+		fmt.Fprintln(os.Stderr, warehouseUsage)
+		return 2
+	}
+	full := len(args) == 2
+
+	logs, dbConfig, whConfig, err := config.LoadWarehouse()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := logging.Setup(logs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if whConfig == nil {
+		fmt.Fprintln(os.Stderr, "WAREHOUSE_S3_BUCKET is required to export to the data warehouse")
+		return 1
+	}
+
+	// Watermarks are read and moved on the primary.
+	dbConfig.ReplicaURL = ""
+	db, err := database.Initialize(dbConfig)
+	if err != nil {
+		slog.Error("Failed to initialize database", "error", err)
+		return 1
+	}
+	defer db.Close()
+
+	exporter, err := warehouse.NewExporter(db, *whConfig)
+	if err != nil {
+		slog.Error("Failed to configure warehouse export", "error", err)
+		return 1
+	}
+
+	results, err := exporter.Run(tenant.WithSystem(context.Background()), full)
+	for _, result := range results {
+		if result.Skipped {
+			fmt.Printf("%-20s skipped, another export is running\n", result.Dataset)
+			continue
+		}
+		fmt.Printf("%-20s %d rows in %d files\n", result.Dataset, result.Rows, result.Files)
+	}
+	if err != nil {
+		slog.Error("Warehouse export failed", "error", err)
+		return 1
+	}
+	return 0
+}